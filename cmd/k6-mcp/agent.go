@@ -0,0 +1,91 @@
+//go:build fts5
+
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/oleiade/k6-mcp/internal/buildinfo"
+	"github.com/oleiade/k6-mcp/internal/config"
+	"github.com/oleiade/k6-mcp/internal/distributed"
+	"github.com/oleiade/k6-mcp/internal/k6bin"
+	"github.com/oleiade/k6-mcp/internal/logging"
+	"github.com/oleiade/k6-mcp/internal/sandbox"
+	"github.com/oleiade/k6-mcp/internal/security"
+)
+
+// agentShutdownTimeout bounds the wait for the run in progress when the agent stops.
+const agentShutdownTimeout = 10 * time.Second
+
+// runAgent runs the agent command: it serves the API through which coordinators dispatch
+// k6 archives to this machine, authenticated with K6_MCP_AGENT_TOKEN.
+func runAgent(args []string) int {
+	flags := flag.NewFlagSet("agent", flag.ContinueOnError)
+	listen := flags.String("listen", ":6566", "address to listen on")
+	certFile := flags.String("tls-cert", "", "PEM certificate to serve HTTPS with")
+	keyFile := flags.String("tls-key", "", "PEM private key of the certificate")
+	if code, ok := parseFlags(flags, args); !ok {
+		return code
+	}
+
+	logger := logging.Default()
+	slog.SetDefault(logger)
+
+	cfg := config.Load()
+	if err := security.SetNetwork(cfg.Network); err != nil {
+		logger.Error("Invalid network configuration", slog.String("error", err.Error()))
+		return 1
+	}
+	if err := sandbox.Configure(cfg.Sandbox, logger); err != nil {
+		logger.Error("Invalid sandbox configuration", slog.String("error", err.Error()))
+		return 1
+	}
+	k6bin.SetManagedDir(cfg.K6Dir)
+
+	handler, err := distributed.NewAgentHandler(cfg.AgentToken, logger)
+	if err != nil {
+		logger.Error("Error creating agent", slog.String("error", err.Error()))
+		return 1
+	}
+
+	server := &http.Server{
+		Addr:              *listen,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), agentShutdownTimeout)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	logger.Info("Starting k6 MCP agent",
+		slog.String("version", buildinfo.Version),
+		slog.String("listen", *listen),
+		slog.Bool("tls", *certFile != ""),
+	)
+
+	if *certFile != "" {
+		err = server.ListenAndServeTLS(*certFile, *keyFile)
+	} else {
+		err = server.ListenAndServe()
+	}
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		logger.Error("Agent error", slog.String("error", err.Error()))
+		return 1
+	}
+
+	return 0
+}
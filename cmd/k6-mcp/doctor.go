@@ -0,0 +1,35 @@
+//go:build fts5
+
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+
+	k6mcp "github.com/oleiade/k6-mcp"
+	"github.com/oleiade/k6-mcp/internal/config"
+	"github.com/oleiade/k6-mcp/internal/doctor"
+)
+
+// runDoctor runs the doctor command: it diagnoses the environment of the server, prints
+// the fixes of the failed checks, and returns the exit code of the command.
+func runDoctor(args []string) int {
+	flags := flag.NewFlagSet("doctor", flag.ContinueOnError)
+	chromaURL := flags.String("chroma-url", "", "base URL of a Chroma server to check, e.g. http://localhost:8000")
+	if code, ok := parseFlags(flags, args); !ok {
+		return code
+	}
+
+	report := doctor.Run(context.Background(), doctor.Options{
+		Config:    config.Load(),
+		Index:     k6mcp.EmbeddedDB,
+		ChromaURL: *chromaURL,
+	})
+	doctor.Print(os.Stdout, report)
+
+	if !report.OK {
+		return 1
+	}
+	return 0
+}
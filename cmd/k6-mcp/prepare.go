@@ -0,0 +1,73 @@
+//go:build fts5
+
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	"github.com/oleiade/k6-mcp/internal/prepare"
+)
+
+// The prepare, index and collect commands write to the dist directory of the working
+// directory, a checkout of the repository: the server embeds the index once rebuilt.
+
+// runPrepare runs the prepare command: it collects the type definitions, then indexes
+// the documentation.
+func runPrepare(args []string) int {
+	flags := flag.NewFlagSet("prepare", flag.ContinueOnError)
+	indexOpts := prepare.Flags(flags)
+
+	return runPreparation(flags, args, func(workDir string) error {
+		opts, err := indexOpts()
+		if err != nil {
+			return err
+		}
+		return prepare.Run(workDir, opts)
+	})
+}
+
+// runIndex runs the index command: it indexes the documentation, from the type
+// definitions collected beforehand.
+func runIndex(args []string) int {
+	flags := flag.NewFlagSet("index", flag.ContinueOnError)
+	indexOpts := prepare.Flags(flags)
+
+	return runPreparation(flags, args, func(workDir string) error {
+		opts, err := indexOpts()
+		if err != nil {
+			return err
+		}
+		return prepare.Index(workDir, opts)
+	})
+}
+
+// runCollect runs the collect command: it collects the type definitions.
+func runCollect(args []string) int {
+	flags := flag.NewFlagSet("collect", flag.ContinueOnError)
+
+	return runPreparation(flags, args, prepare.Collect)
+}
+
+// runPreparation parses the flags of a preparation command, then runs it in the working
+// directory.
+func runPreparation(flags *flag.FlagSet, args []string, run func(workDir string) error) int {
+	if code, ok := parseFlags(flags, args); !ok {
+		return code
+	}
+
+	workDir, err := os.Getwd()
+	if err != nil {
+		log.Printf("Failed to get working directory: %v", err)
+		return 1
+	}
+
+	if err := run(workDir); err != nil {
+		log.Print(err)
+		return 1
+	}
+
+	log.Println("Preparation completed successfully")
+	return 0
+}
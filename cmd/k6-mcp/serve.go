@@ -0,0 +1,54 @@
+//go:build fts5
+
+package main
+
+import (
+	"flag"
+	"log/slog"
+
+	"github.com/oleiade/k6-mcp/internal/buildinfo"
+	"github.com/oleiade/k6-mcp/internal/config"
+	"github.com/oleiade/k6-mcp/internal/logging"
+	"github.com/oleiade/k6-mcp/pkg/k6mcpserver"
+)
+
+// runServe runs the serve command: it serves the MCP server over stdio, or streamable HTTP
+// with -http, configured from the K6_MCP_* environment variables.
+func runServe(args []string) int {
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	httpAddr := flags.String("http", "", "serve over streamable HTTP on this address, e.g. :8080, instead of stdio")
+	noRun := flags.Bool("no-run", false, "disable the tools executing load tests, e.g. on servers shared by a team")
+	if code, ok := parseFlags(flags, args); !ok {
+		return code
+	}
+
+	// Records logged through slog carry the attributes of the request of their context
+	logger := logging.Default()
+	slog.SetDefault(logger)
+
+	logger.Info("Starting k6 MCP server",
+		slog.String("version", buildinfo.Version),
+		slog.String("commit", buildinfo.Commit),
+		slog.String("built_at", buildinfo.Date),
+		slog.Bool("resource_capabilities", true),
+	)
+
+	s, err := k6mcpserver.New(k6mcpserver.WithLogger(logger), k6mcpserver.EnableRun(!*noRun))
+	if err != nil {
+		logger.Error("Error creating server", "error", err)
+		return 1
+	}
+	defer func() { _ = s.Close() }()
+
+	if *httpAddr != "" {
+		err = s.ServeStreamableHTTP(*httpAddr, config.Load().HTTPToken)
+	} else {
+		err = s.ServeStdio()
+	}
+	if err != nil {
+		logger.Error("Server error", slog.String("error", err.Error()))
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,221 @@
+// Package annotations marks load test runs on Grafana dashboards: runs post an annotation
+// to the annotations API of a Grafana instance when they start, which becomes a region
+// spanning the run, with its outcome, when they finish.
+package annotations
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// requestTimeout bounds API requests, so that an unreachable Grafana delays no run
+	// for long.
+	requestTimeout = 10 * time.Second
+	// maxResponseBytes is the maximum size of API responses.
+	maxResponseBytes = 64 * 1024
+)
+
+// Tags of every annotation, and of the outcome of runs.
+const (
+	TagK6     = "k6"
+	TagPassed = "passed"
+	TagFailed = "failed"
+)
+
+// Config holds the settings of the Grafana instance runs are annotated on.
+type Config struct {
+	// URL is the base URL of the Grafana instance, e.g. https://grafana.example.com.
+	URL string
+	// Token is a service account token allowed to write annotations.
+	Token string
+	// DashboardUID is the UID of the dashboard annotations are scoped to. Annotations of
+	// no dashboard show on every dashboard querying them, e.g. by the k6 tag.
+	DashboardUID string
+	// Tags are added to the tags of every annotation.
+	Tags []string
+}
+
+// Run describes an annotated run.
+type Run struct {
+	// Script is the name of the script, if it is named.
+	Script string
+	// Environment is the label of the environment the run targets, if any.
+	Environment string
+	VUs         int
+	Duration    string
+	Iterations  int
+}
+
+// Outcome is the outcome of a finished run.
+type Outcome struct {
+	Success         bool
+	Grade           string
+	TotalRequests   int
+	FailedRequests  int
+	P95ResponseTime float64
+	RequestRate     float64
+	// Error is the error of runs that could not complete.
+	Error string
+}
+
+// annotation is an annotation of the Grafana annotations API.
+type annotation struct {
+	DashboardUID string   `json:"dashboardUID,omitempty"`
+	Time         int64    `json:"time"`
+	TimeEnd      int64    `json:"timeEnd,omitempty"`
+	Tags         []string `json:"tags"`
+	Text         string   `json:"text"`
+}
+
+// Client posts annotations to the Grafana annotations API.
+type Client struct {
+	config Config
+	client *http.Client
+}
+
+// NewClient returns a client of the Grafana instance configured by config. Clients
+// without a URL and token annotate nothing.
+func NewClient(config Config) *Client {
+	config.URL = strings.TrimRight(config.URL, "/")
+	return &Client{config: config, client: &http.Client{Timeout: requestTimeout}}
+}
+
+// Configured reports whether runs are annotated.
+func (c *Client) Configured() bool {
+	return c != nil && c.config.URL != "" && c.config.Token != ""
+}
+
+// RunStarted posts the annotation of a run started at start, and returns its ID.
+func (c *Client) RunStarted(ctx context.Context, start time.Time, run Run) (int64, error) {
+	body, err := c.do(ctx, http.MethodPost, "/api/annotations", annotation{
+		DashboardUID: c.config.DashboardUID,
+		Time:         start.UnixMilli(),
+		Tags:         c.tags(run),
+		Text:         "k6 load test started" + describe(run) + ".",
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	var response struct {
+		ID int64 `json:"id"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil || response.ID == 0 {
+		return 0, fmt.Errorf("unexpected response of the Grafana annotations API: %s", strings.TrimSpace(string(body)))
+	}
+	return response.ID, nil
+}
+
+// RunFinished turns the annotation of the ID into a region spanning the run, from start to
+// end, describing its outcome.
+func (c *Client) RunFinished(ctx context.Context, id int64, start, end time.Time, run Run, outcome Outcome) error {
+	tags := c.tags(run)
+	verdict := "passed"
+	if outcome.Success {
+		tags = append(tags, TagPassed)
+	} else {
+		tags = append(tags, TagFailed)
+		verdict = "failed"
+	}
+
+	text := "k6 load test " + verdict + describe(run) + "."
+	switch {
+	case outcome.Error != "":
+		text += " " + outcome.Error
+	case outcome.TotalRequests > 0:
+		text += fmt.Sprintf(" %d requests, %d failed, p95 %.0f ms, %.1f req/s.",
+			outcome.TotalRequests, outcome.FailedRequests, outcome.P95ResponseTime, outcome.RequestRate)
+	}
+	if outcome.Grade != "" {
+		text += " Grade " + outcome.Grade + "."
+	}
+
+	_, err := c.do(ctx, http.MethodPatch, "/api/annotations/"+strconv.FormatInt(id, 10), annotation{
+		Time:    start.UnixMilli(),
+		TimeEnd: end.UnixMilli(),
+		Tags:    tags,
+		Text:    text,
+	})
+	return err
+}
+
+// tags returns the tags of the annotations of the run.
+func (c *Client) tags(run Run) []string {
+	tags := []string{TagK6}
+	if run.Script != "" {
+		tags = append(tags, "script:"+run.Script)
+	}
+	if run.Environment != "" {
+		tags = append(tags, "env:"+run.Environment)
+	}
+	return append(tags, c.config.Tags...)
+}
+
+// describe returns the description of the script and load of the run, after a colon.
+func describe(run Run) string {
+	var load []string
+	if run.VUs > 0 {
+		load = append(load, fmt.Sprintf("%d VUs", run.VUs))
+	}
+	if run.Duration != "" {
+		load = append(load, run.Duration)
+	}
+	if run.Iterations > 0 {
+		load = append(load, fmt.Sprintf("%d iterations", run.Iterations))
+	}
+
+	var description []string
+	if run.Script != "" {
+		description = append(description, run.Script)
+	}
+	if run.Environment != "" {
+		description = append(description, "on "+run.Environment)
+	}
+	if len(load) > 0 {
+		description = append(description, "("+strings.Join(load, ", ")+")")
+	}
+	if len(description) == 0 {
+		return ""
+	}
+	return ": " + strings.Join(description, " ")
+}
+
+// do calls the API with the annotation, and returns the body of its response.
+func (c *Client) do(ctx context.Context, method, path string, payload annotation) ([]byte, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode annotation: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.config.URL+path, bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.config.Token)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "k6-mcp")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call the Grafana annotations API: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the Grafana annotations API response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("the Grafana annotations API responded with %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	return body, nil
+}
@@ -0,0 +1,203 @@
+package apiref
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const (
+	// DocsBaseURL is the base URL of the published k6 documentation, which documentation
+	// paths are relative to.
+	DocsBaseURL = "https://grafana.com/docs/k6/latest/"
+
+	// maxSummaryLength is the maximum length of the summaries of annotations.
+	maxSummaryLength = 100
+)
+
+// DocURL returns the URL of the published documentation page of the path, such as
+// javascript-api/k6-http/batch.
+func DocURL(path string) string {
+	return DocsBaseURL + strings.Trim(path, "/") + "/"
+}
+
+// Annotation is a comment linking a line of a script to the documentation of a k6 API
+// symbol the line uses.
+type Annotation struct {
+	// Line is the line of the script using the symbol, before annotation.
+	Line int `json:"line"`
+	// Symbol is the symbol used, qualified by its module, such as k6/http.get.
+	Symbol  string `json:"symbol"`
+	Summary string `json:"summary,omitempty"`
+	URL     string `json:"url"`
+}
+
+// ResolveUsage returns the symbol of the usage, with its documentation, or nil when no
+// symbol of its module has its name.
+func (s *Store) ResolveUsage(ctx context.Context, usage Usage) (*Symbol, error) {
+	name := usage.Module + "." + usage.Name
+	if usage.Module == GlobalModule {
+		name = usage.Name
+	}
+
+	symbols, err := s.Lookup(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	for i := range symbols {
+		if symbols[i].Module == usage.Module {
+			return &symbols[i], nil
+		}
+	}
+
+	return nil, nil
+}
+
+// NewAnnotation returns the annotation of the line using the symbol, summarized by the
+// description of its documentation page, or else by the first sentence of its doc comment.
+func NewAnnotation(line int, symbol *Symbol) Annotation {
+	name := symbol.Name
+	if symbol.Module != GlobalModule {
+		name = symbol.Module + "." + symbol.Name
+	}
+
+	summary := symbol.DocDescription
+	if summary == "" {
+		summary = symbol.Doc
+		if end := strings.Index(summary, ". "); end >= 0 {
+			summary = summary[:end+1]
+		}
+	}
+	summary = strings.Join(strings.Fields(summary), " ")
+	if len(summary) > maxSummaryLength {
+		cut := strings.LastIndexByte(summary[:maxSummaryLength], ' ')
+		if cut <= 0 {
+			cut = maxSummaryLength
+		}
+		summary = strings.TrimRightFunc(summary[:cut], unicode.IsPunct) + "…"
+	}
+
+	return Annotation{Line: line, Symbol: name, Summary: summary, URL: DocURL(symbol.DocPath)}
+}
+
+// Annotate returns the script with a comment above the line of each annotation, indented
+// as the line, and the annotations inserted. Lines starting within a template literal or
+// a block comment, where a comment would change the script, are left out, as are the
+// annotations already above their line, so that annotating twice changes nothing. Unless
+// every occurrence is annotated, only the first line of each URL is, in line order.
+func Annotate(script string, annotations []Annotation, everyOccurrence bool) (string, []Annotation) {
+	lines := strings.Split(script, "\n")
+	unsafe := literalLines(script)
+
+	sorted := slices.Clone(annotations)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Line < sorted[j].Line })
+
+	byLine := map[int][]Annotation{}
+	linked := map[string]bool{}
+	for _, annotation := range sorted {
+		if annotation.Line < 1 || annotation.Line > len(lines) || unsafe[annotation.Line] {
+			continue
+		}
+		if linked[annotation.URL] && !everyOccurrence {
+			continue
+		}
+		linked[annotation.URL] = true
+		if annotated(lines, annotation) {
+			continue
+		}
+		byLine[annotation.Line] = append(byLine[annotation.Line], annotation)
+	}
+
+	var b strings.Builder
+	var inserted []Annotation
+	for i, line := range lines {
+		if pending := byLine[i+1]; len(pending) > 0 {
+			sort.SliceStable(pending, func(a, b int) bool { return pending[a].Symbol < pending[b].Symbol })
+			indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+			for _, annotation := range pending {
+				b.WriteString(indent + "// " + annotation.Symbol)
+				if annotation.Summary != "" {
+					b.WriteString(": " + annotation.Summary)
+				}
+				b.WriteString("\n" + indent + "// See " + annotation.URL + "\n")
+				inserted = append(inserted, annotation)
+			}
+		}
+		b.WriteString(line)
+		if i < len(lines)-1 {
+			b.WriteByte('\n')
+		}
+	}
+
+	return b.String(), inserted
+}
+
+// annotated reports whether the comments right above the line of the annotation already
+// link its URL.
+func annotated(lines []string, annotation Annotation) bool {
+	for i := annotation.Line - 2; i >= 0; i-- {
+		comment := strings.TrimSpace(lines[i])
+		if !strings.HasPrefix(comment, "//") {
+			return false
+		}
+		if strings.Contains(comment, annotation.URL) {
+			return true
+		}
+	}
+	return false
+}
+
+// literalLines returns the lines of the script starting within a template literal or a
+// block comment.
+func literalLines(script string) map[int]bool {
+	lines := map[int]bool{}
+	line := 1
+	var within byte // the delimiter of the literal or comment the scan is in, '*' for comments
+	for i := 0; i < len(script); i++ {
+		c := script[i]
+		if c == '\n' {
+			line++
+			switch within {
+			case '`', '*':
+				lines[line] = true
+			case '\'', '"':
+				// Strings end with their line, unless it is continued with a backslash
+				if script[i-1] == '\\' {
+					lines[line] = true
+				} else {
+					within = 0
+				}
+			}
+			continue
+		}
+
+		switch within {
+		case 0:
+			switch {
+			case c == '\'' || c == '"' || c == '`':
+				within = c
+			case c == '/' && i+1 < len(script) && script[i+1] == '/':
+				for i+1 < len(script) && script[i+1] != '\n' {
+					i++
+				}
+			case c == '/' && i+1 < len(script) && script[i+1] == '*':
+				within = '*'
+				i++
+			}
+		case '*':
+			if c == '*' && i+1 < len(script) && script[i+1] == '/' {
+				within = 0
+				i++
+			}
+		default:
+			if c == '\\' && i+1 < len(script) && script[i+1] != '\n' {
+				i++
+			} else if c == within {
+				within = 0
+			}
+		}
+	}
+	return lines
+}
@@ -0,0 +1,292 @@
+// Package apiref indexes the symbols of the k6 JavaScript API, from the k6 type definitions,
+// in the index database, and resolves symbol names, such as k6/http.batch or
+// Options.thresholds, to their signatures and documentation, without fuzzy matching.
+package apiref
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"strings"
+)
+
+const (
+	// maxSymbols bounds the number of symbols a lookup returns, e.g. the exports of a module.
+	maxSymbols = 100
+
+	// apiDocsPrefix is the path prefix of the JavaScript API reference pages.
+	apiDocsPrefix = "javascript-api/"
+)
+
+// ErrUnavailable is returned when the index database has no symbol index.
+var ErrUnavailable = errors.New("the index has no API symbols")
+
+var camelCasePattern = regexp.MustCompile(`([a-z0-9])([A-Z])`)
+
+// Symbol is a symbol of the k6 JavaScript API.
+type Symbol struct {
+	// Module is the module exporting the symbol, such as k6/http, or global for the
+	// symbols available without import.
+	Module string `json:"module"`
+	// Name is the name of the symbol, qualified by its class, interface or namespace, such
+	// as batch, Response.json or Options.thresholds.
+	Name      string `json:"name"`
+	Kind      string `json:"kind"`
+	Signature string `json:"signature"`
+	// Doc is the description of the symbol in its type definitions.
+	Doc string `json:"doc,omitempty"`
+	// File is the type definitions file declaring the symbol, relative to the type
+	// definitions served as types://k6/ resources, and Line the line of its declaration.
+	File string `json:"file"`
+	Line int    `json:"line,omitempty"`
+
+	// DocPath is the path of the documentation page of the symbol, if any, read from the
+	// docs://k6/pages/{path} resource.
+	DocPath string `json:"doc_path,omitempty"`
+	// DocDescription is the description of the documentation page.
+	DocDescription string `json:"doc_description,omitempty"`
+}
+
+// Build replaces the symbol index of the database with the symbols of the type definitions
+// of fsys. It returns the number of indexed symbols.
+func Build(db *sql.DB, fsys fs.FS) (int, error) {
+	declarations, err := ParseDefinitions(fsys)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse type definitions: %w", err)
+	}
+
+	statements := []string{
+		`DROP TABLE IF EXISTS api_symbols;`,
+		`CREATE TABLE api_symbols (
+            module    TEXT NOT NULL,
+            name      TEXT NOT NULL,
+            kind      TEXT NOT NULL,
+            signature TEXT NOT NULL,
+            doc       TEXT NOT NULL DEFAULT '',
+            file      TEXT NOT NULL,
+            line      INTEGER NOT NULL DEFAULT 0
+        );`,
+		`CREATE INDEX api_symbols_name ON api_symbols (name COLLATE NOCASE);`,
+		`CREATE INDEX api_symbols_module ON api_symbols (module);`,
+	}
+	for _, statement := range statements {
+		if _, err := db.Exec(statement); err != nil {
+			return 0, fmt.Errorf("failed to create the symbol index: %w", err)
+		}
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	for _, declaration := range declarations {
+		symbol := declaration.Symbol
+		_, err := tx.Exec(`INSERT INTO api_symbols (module, name, kind, signature, doc, file, line) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			symbol.Module, symbol.Name, symbol.Kind, symbol.Signature, symbol.Doc, symbol.File, symbol.Line)
+		if err != nil {
+			return 0, fmt.Errorf("failed to index symbol %s.%s: %w", symbol.Module, symbol.Name, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return len(declarations), nil
+}
+
+// Store resolves symbols from the symbol index of the index database.
+type Store struct {
+	db *sql.DB
+}
+
+// NewStore returns a Store backed by the given index database.
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+// Lookup resolves the name to the symbols it exactly designates, ignoring case only when
+// no symbol has the exact name:
+//   - a module path, such as k6/http, designates the module's top-level symbols;
+//   - a module path and a name, such as k6/http.batch or k6/metrics.Counter.add, designate
+//     the symbol of the module;
+//   - a name, such as Options.thresholds or batch, designates the symbols of the name in
+//     every module, and http.batch also designates batch in the k6/http module.
+func (s *Store) Lookup(ctx context.Context, name string) ([]Symbol, error) {
+	name = strings.TrimSuffix(strings.TrimSpace(name), "()")
+
+	var symbols []Symbol
+	var err error
+	for _, exact := range []bool{true, false} {
+		symbols, err = s.resolve(ctx, name, exact)
+		if err != nil || len(symbols) > 0 {
+			break
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range symbols {
+		s.attachDocs(ctx, &symbols[i])
+	}
+
+	return symbols, nil
+}
+
+func (s *Store) resolve(ctx context.Context, name string, exact bool) ([]Symbol, error) {
+	if module, symbol, ok := splitModule(name); ok {
+		if symbol == "" {
+			return s.query(ctx, `module = ? AND name NOT LIKE '%.%'`, module)
+		}
+		return s.query(ctx, nameCondition("module = ? AND name", exact), module, symbol)
+	}
+
+	symbols, err := s.query(ctx, nameCondition("name", exact), name)
+	if err != nil || len(symbols) > 0 {
+		return symbols, err
+	}
+
+	// http.batch designates batch in the k6/http module
+	if module, symbol, found := strings.Cut(name, "."); found {
+		return s.query(ctx, nameCondition("module = ? AND name", exact), "k6/"+module, symbol)
+	}
+
+	return nil, nil
+}
+
+// Candidates returns the symbols whose unqualified name is the last segment of name, such
+// as Response.json for json, to suggest when a lookup finds nothing.
+func (s *Store) Candidates(ctx context.Context, name string) ([]Symbol, error) {
+	name = strings.TrimSuffix(strings.TrimSpace(name), "()")
+	if i := strings.LastIndexAny(name, "./"); i >= 0 {
+		name = name[i+1:]
+	}
+	if name == "" {
+		return nil, nil
+	}
+
+	return s.query(ctx, `(name = ? COLLATE NOCASE OR name LIKE ? ESCAPE '\')`, name, "%."+escapeLike(name))
+}
+
+func (s *Store) query(ctx context.Context, condition string, args ...any) ([]Symbol, error) {
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf(`
+        SELECT module, name, kind, signature, doc, file, line
+        FROM api_symbols
+        WHERE %s
+        ORDER BY module, name
+        LIMIT %d`, condition, maxSymbols), args...)
+	if err != nil {
+		if strings.Contains(err.Error(), "no such table") {
+			return nil, ErrUnavailable
+		}
+		return nil, fmt.Errorf("failed to query symbols: %w", err)
+	}
+	defer rows.Close()
+
+	var symbols []Symbol
+	for rows.Next() {
+		var symbol Symbol
+		if err := rows.Scan(&symbol.Module, &symbol.Name, &symbol.Kind, &symbol.Signature, &symbol.Doc, &symbol.File, &symbol.Line); err != nil {
+			return nil, fmt.Errorf("failed to scan symbol: %w", err)
+		}
+		symbols = append(symbols, symbol)
+	}
+
+	return symbols, rows.Err()
+}
+
+// attachDocs links the symbol to its documentation page, when one exists at the path the
+// API reference uses for it, e.g. javascript-api/k6-http/batch for k6/http.batch.
+func (s *Store) attachDocs(ctx context.Context, symbol *Symbol) {
+	for _, path := range docPaths(symbol) {
+		var description string
+		err := s.db.QueryRowContext(ctx, `SELECT description FROM pages WHERE path = ?`, path).Scan(&description)
+		if err == nil {
+			symbol.DocPath = path
+			symbol.DocDescription = description
+			return
+		}
+	}
+}
+
+// docPaths returns the candidate paths of the documentation page of the symbol, most
+// specific first.
+func docPaths(symbol *Symbol) []string {
+	if !strings.HasPrefix(symbol.Module, "k6") {
+		return nil
+	}
+
+	// k6/experimental/websockets is documented under javascript-api/k6-experimental/websockets
+	module, rest, _ := strings.Cut(symbol.Module, "/")
+	base := apiDocsPrefix + module
+	if rest != "" {
+		first, nested, _ := strings.Cut(rest, "/")
+		base += "-" + first
+		if nested != "" {
+			base += "/" + nested
+		}
+	}
+
+	// Response.json is documented at response/response-json
+	segments := strings.Split(symbol.Name, ".")
+	var paths []string
+	for i := len(segments); i > 0; i-- {
+		path := base
+		for j, segment := range segments[:i] {
+			page := kebab(segment)
+			if j > 0 {
+				page = kebab(segments[j-1]) + "-" + page
+			}
+			path += "/" + page
+		}
+		paths = append(paths, path)
+	}
+
+	// Members of the namespace a module exports by default are documented as the module's
+	if len(segments) > 1 {
+		paths = append(paths, base+"/"+kebab(segments[len(segments)-1]))
+	}
+
+	return append(paths, base)
+}
+
+// kebab converts camelCase names to the kebab-case of documentation paths.
+func kebab(name string) string {
+	return strings.ToLower(camelCasePattern.ReplaceAllString(name, "$1-$2"))
+}
+
+// splitModule splits module paths, optionally followed by a symbol name, such as
+// k6/http.batch, into the module and the name.
+func splitModule(name string) (string, string, bool) {
+	if name != "k6" && !strings.HasPrefix(name, "k6/") && !strings.HasPrefix(name, "k6.") {
+		return "", "", false
+	}
+
+	slash := strings.LastIndex(name, "/")
+	dot := strings.Index(name[slash+1:], ".")
+	if dot < 0 {
+		return name, "", true
+	}
+	dot += slash + 1
+
+	return name[:dot], name[dot+1:], true
+}
+
+// nameCondition returns the condition comparing the column to a name, ignoring case
+// unless exact.
+func nameCondition(column string, exact bool) string {
+	if exact {
+		return column + " = ?"
+	}
+	return column + " = ? COLLATE NOCASE"
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
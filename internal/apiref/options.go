@@ -0,0 +1,222 @@
+package apiref
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/oleiade/k6-mcp/internal/fuzzy"
+)
+
+// OptionsReferencePath is the path of the documentation page of the k6 options.
+const OptionsReferencePath = "using-k6/k6-options/reference"
+
+var (
+	optionsDeclarationPattern = regexp.MustCompile(`\boptions\s*=\s*\{`)
+	optionKeyPattern          = regexp.MustCompile(`^\s*(?:['"]([^'"]+)['"]|([A-Za-z_$][\w$]*))\s*:`)
+	headingPattern            = regexp.MustCompile(`(?m)^(#{2,4})\s+(.+?)\s*$`)
+)
+
+// Option describes a k6 option: its default and the section documenting it.
+type Option struct {
+	// Default is the value k6 uses when the option is not set, as a JavaScript literal,
+	// or empty when unset options have no value, such as scenarios.
+	Default string `json:"default,omitempty"`
+	// Heading is the heading of the section of the options reference documenting the option.
+	Heading string `json:"-"`
+	Summary string `json:"summary"`
+	// Caution explains the risk of setting the option to a value other than its default.
+	Caution string `json:"caution,omitempty"`
+}
+
+// KnownOptions are the k6 options scripts set in their options export, keyed by name.
+var KnownOptions = map[string]Option{
+	"batch":                 {Default: "20", Heading: "Batch", Summary: "Maximum number of parallel connections of http.batch() calls."},
+	"batchPerHost":          {Default: "6", Heading: "Batch per host", Summary: "Maximum number of parallel connections per host of http.batch() calls."},
+	"blacklistIPs":          {Heading: "Blacklist IP", Summary: "IP ranges requests are not allowed to reach."},
+	"blockHostnames":        {Heading: "Block hostnames", Summary: "Hostnames requests are not allowed to reach, with * wildcards."},
+	"cloud":                 {Heading: "Cloud", Summary: "Settings of Grafana Cloud k6 runs, such as the project ID and load zones."},
+	"discardResponseBodies": {Default: "false", Heading: "Discard response bodies", Summary: "Drops response bodies unless requests set responseType, saving memory and CPU.", Caution: "Checks and extractions read empty bodies unless their requests set responseType: 'text'."},
+	"dns":                   {Default: "{ ttl: '5m', select: 'random', policy: 'preferIPv4' }", Heading: "DNS", Summary: "DNS resolution: caching TTL, selection of the resolved IPs and IP version policy."},
+	"duration":              {Heading: "Duration", Summary: "Duration of the test, run with the vus option as a constant-vus scenario."},
+	"executionSegment":      {Heading: "Execution segment", Summary: "Share of the test this instance runs, for distributed execution."},
+	"ext":                   {Heading: "Extension options", Summary: "Settings of outputs and extensions, such as ext.loadimpact for the cloud."},
+	"hosts":                 {Heading: "Hosts", Summary: "Overrides of DNS resolution, mapping hostnames to IPs, like /etc/hosts."},
+	"httpDebug":             {Heading: "HTTP debug", Summary: "Logs the requests and responses: 'full' includes their bodies.", Caution: "Logs every request and response, slowing large tests down and possibly leaking secrets."},
+	"insecureSkipTLSVerify": {Default: "false", Heading: "Insecure skip TLS verify", Summary: "Skips the verification of TLS certificates.", Caution: "Disables TLS certificate verification: keep it to test environments with self-signed certificates."},
+	"iterations":            {Default: "1", Heading: "Iterations", Summary: "Total number of iterations, shared by the VUs, when no duration nor stages are set."},
+	"linger":                {Default: "false", Heading: "Linger", Summary: "Keeps k6 running after the test ends."},
+	"localIPs":              {Heading: "Local IPs", Summary: "Local IPs requests are sent from."},
+	"maxRedirects":          {Default: "10", Heading: "Max redirects", Summary: "Maximum number of redirects requests follow."},
+	"minIterationDuration":  {Default: "0", Heading: "Minimum iteration duration", Summary: "Minimum duration of iterations, which sleep until it elapses."},
+	"noConnectionReuse":     {Default: "false", Heading: "No connection reuse", Summary: "Opens a new connection for every request.", Caution: "Every request pays connection and TLS setup, which is rarely how real clients behave."},
+	"noCookiesReset":        {Default: "false", Heading: "No cookies reset", Summary: "Keeps the cookies of VUs across iterations."},
+	"noSetup":               {Default: "false", Heading: "No setup", Summary: "Skips the setup() function."},
+	"noTeardown":            {Default: "false", Heading: "No teardown", Summary: "Skips the teardown() function."},
+	"noUsageReport":         {Default: "false", Heading: "No usage report", Summary: "Disables the anonymous usage report k6 sends."},
+	"noVUConnectionReuse":   {Default: "false", Heading: "No VU connection reuse", Summary: "Closes the connections of VUs between iterations.", Caution: "Every iteration pays connection and TLS setup."},
+	"paused":                {Default: "false", Heading: "Paused", Summary: "Starts the test paused, until resumed through the REST API."},
+	"rps":                   {Default: "0", Heading: "RPS", Summary: "Maximum number of requests per second, across VUs; 0 is unlimited.", Caution: "Caps the load globally and silently: prefer arrival-rate executors to model a request rate."},
+	"scenarios":             {Heading: "Scenarios", Summary: "Workloads of the test, each run by an executor with its own VUs, iterations and timing."},
+	"setupTimeout":          {Default: "'60s'", Heading: "Setup timeout", Summary: "Maximum duration of the setup() function."},
+	"stages":                {Heading: "Stages", Summary: "Ramps of the number of VUs, run as a ramping-vus scenario."},
+	"summaryTimeUnit":       {Heading: "Summary time unit", Summary: "Time unit of the durations of the end-of-test summary."},
+	"summaryTrendStats":     {Default: "['avg', 'min', 'med', 'max', 'p(90)', 'p(95)']", Heading: "Summary trend stats", Summary: "Statistics of the trend metrics of the end-of-test summary."},
+	"systemTags":            {Heading: "System tags", Summary: "The tags k6 adds to metrics, such as status, method and url."},
+	"tags":                  {Heading: "Tags", Summary: "Tags added to every metric of the test."},
+	"teardownTimeout":       {Default: "'60s'", Heading: "Teardown timeout", Summary: "Maximum duration of the teardown() function."},
+	"thresholds":            {Heading: "Thresholds", Summary: "Pass/fail criteria of the test, on its metrics."},
+	"throw":                 {Default: "false", Heading: "Throw", Summary: "Throws exceptions on failed requests instead of only logging them."},
+	"tlsAuth":               {Heading: "TLS authentication", Summary: "Client certificates presented to hosts, for mutual TLS."},
+	"tlsCipherSuites":       {Heading: "TLS cipher suites", Summary: "Cipher suites allowed in TLS handshakes."},
+	"tlsVersion":            {Heading: "TLS version", Summary: "Minimum and maximum TLS versions."},
+	"userAgent":             {Default: "'Grafana k6/<version>'", Heading: "User agent", Summary: "User-Agent header of requests."},
+	"vus":                   {Default: "1", Heading: "VUs", Summary: "Number of virtual users running concurrently."},
+}
+
+// ScriptOption is an option set by the options export of a script.
+type ScriptOption struct {
+	Name string `json:"option"`
+	// Value is the literal value of the option in the script, as written.
+	Value string `json:"value"`
+	Line  int    `json:"line"`
+}
+
+// ScriptOptions returns the options the script sets in its options object, in order.
+// Options are read from the object literal: options computed at runtime are left out.
+// It reports false when the script has no options object.
+func ScriptOptions(script string) ([]ScriptOption, bool) {
+	code := stripComments(script)
+	loc := optionsDeclarationPattern.FindStringIndex(code)
+	if loc == nil {
+		return nil, false
+	}
+	body := code[loc[1]:]
+	end := closingDelimiter(body, '}')
+	if end < 0 {
+		return nil, false
+	}
+	body = body[:end]
+
+	var options []ScriptOption
+	for offset := 0; offset < len(body); {
+		entryEnd := closingDelimiter(body[offset:], ',')
+		if entryEnd < 0 {
+			entryEnd = len(body) - offset
+		}
+		entry := body[offset : offset+entryEnd]
+		line := lineOf(code, loc[1]+offset+len(entry)-len(strings.TrimLeft(entry, " \t\r\n")))
+		offset += entryEnd + 1
+
+		if m := optionKeyPattern.FindStringSubmatch(entry); m != nil {
+			name := m[1] + m[2]
+			value := strings.Join(strings.Fields(entry[len(m[0]):]), " ")
+			options = append(options, ScriptOption{Name: name, Value: value, Line: line})
+		} else if shorthand := strings.TrimSpace(entry); shorthand != "" {
+			// Shorthand properties and spreads, e.g. { vus, ...base }, have no literal value
+			options = append(options, ScriptOption{Name: shorthand, Line: line})
+		}
+	}
+
+	return options, true
+}
+
+// closingDelimiter returns the offset of the first delimiter of s outside of nested
+// brackets, braces, parentheses and strings, or -1.
+func closingDelimiter(s string, delimiter byte) int {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\'' || c == '"' || c == '`':
+			for i++; i < len(s) && s[i] != c; i++ {
+				if s[i] == '\\' {
+					i++
+				}
+			}
+		case c == delimiter && depth == 0:
+			return i
+		case c == '{' || c == '[' || c == '(':
+			depth++
+		case c == '}' || c == ']' || c == ')':
+			depth--
+			if depth < 0 {
+				return -1
+			}
+		}
+	}
+	return -1
+}
+
+// IsDefault reports whether value is the default value of the option, comparing numbers,
+// booleans, durations and strings regardless of their quotes and formatting.
+func (o Option) IsDefault(value string) bool {
+	if o.Default == "" {
+		return false
+	}
+	a, b := normalizeLiteral(value), normalizeLiteral(o.Default)
+	if a == b {
+		return true
+	}
+	if x, err := strconv.ParseFloat(a, 64); err == nil {
+		y, err := strconv.ParseFloat(b, 64)
+		return err == nil && x == y
+	}
+	if x, err := time.ParseDuration(a); err == nil {
+		y, err := time.ParseDuration(b)
+		return err == nil && x == y
+	}
+	return false
+}
+
+// normalizeLiteral strips the quotes and whitespace of a JavaScript literal.
+func normalizeLiteral(value string) string {
+	value = strings.Join(strings.Fields(value), "")
+	return strings.NewReplacer(`'`, ``, `"`, ``, "`", ``).Replace(value)
+}
+
+// OptionDoc returns the section of the options reference documenting the option of the
+// heading, and its anchor, or empty strings when the index has no such section.
+func (s *Store) OptionDoc(ctx context.Context, heading string) (section, anchor string, err error) {
+	var content string
+	err = s.db.QueryRowContext(ctx, `SELECT content FROM pages WHERE path = ?`, OptionsReferencePath).Scan(&content)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", "", nil
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read the options reference: %w", err)
+	}
+
+	headings := headingPattern.FindAllStringSubmatchIndex(content, -1)
+	for i, h := range headings {
+		if !strings.EqualFold(content[h[4]:h[5]], heading) {
+			continue
+		}
+		end := len(content)
+		for _, next := range headings[i+1:] {
+			if next[3]-next[2] <= h[3]-h[2] {
+				end = next[0]
+				break
+			}
+		}
+		return strings.TrimSpace(content[h[1]:end]), strings.ReplaceAll(strings.ToLower(heading), " ", "-"), nil
+	}
+
+	return "", "", nil
+}
+
+// ClosestOption returns the known option whose name is closest to name, ignoring case,
+// such as maxRedirects for maxRedirect, or empty when no option is close.
+func ClosestOption(name string) string {
+	closest, best := "", 3
+	for option := range KnownOptions {
+		if d := fuzzy.EditDistance(strings.ToLower(name), strings.ToLower(option)); d < best || (d == best && option < closest) {
+			closest, best = option, d
+		}
+	}
+	return closest
+}
@@ -0,0 +1,408 @@
+package apiref
+
+import (
+	"io/fs"
+	"path"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+const (
+	// GlobalModule is the module of the symbols available without import, such as open or __ENV.
+	GlobalModule = "global"
+
+	// maxDocExcerpt is the maximum length of the doc excerpts of symbols.
+	maxDocExcerpt = 400
+
+	// maxSnippetBytes is the maximum size of the source snippets of declarations. Classes
+	// and interfaces larger than this are cut, their members being declarations of their own.
+	maxSnippetBytes = 4096
+
+	// dtsSuffix is the file extension of type definitions files.
+	dtsSuffix = ".d.ts"
+)
+
+// Kinds of symbols.
+const (
+	KindFunction    = "function"
+	KindClass       = "class"
+	KindInterface   = "interface"
+	KindNamespace   = "namespace"
+	KindEnum        = "enum"
+	KindType        = "type"
+	KindConstant    = "constant"
+	KindMethod      = "method"
+	KindProperty    = "property"
+	KindConstructor = "constructor"
+)
+
+var (
+	modifierPattern  = regexp.MustCompile(`^(export|declare|default|abstract|static|readonly|public|async)\s+`)
+	headerPattern    = regexp.MustCompile(`^(class|interface|namespace|enum)\s+([A-Za-z_$][\w$]*)`)
+	modulePattern    = regexp.MustCompile(`^module\s+['"]([^'"]+)['"]`)
+	functionPattern  = regexp.MustCompile(`^function\s+([A-Za-z_$][\w$]*)`)
+	variablePattern  = regexp.MustCompile(`^(const|let|var)\s+([A-Za-z_$][\w$]*)`)
+	typePattern      = regexp.MustCompile(`^type\s+([A-Za-z_$][\w$]*)`)
+	methodPattern    = regexp.MustCompile(`^([A-Za-z_$][\w$]*)\??\s*[(<]`)
+	propertyPattern  = regexp.MustCompile(`^(?:get\s+|set\s+)?([A-Za-z_$][\w$]*)\??\s*:`)
+	accessorPattern  = regexp.MustCompile(`^(get|set)\s+([A-Za-z_$][\w$]*)\s*\(`)
+	whitespaceRegexp = regexp.MustCompile(`\s+`)
+)
+
+// container is a declaration holding other declarations: a module, namespace, class,
+// interface or enum.
+type container struct {
+	kind string
+	// name is the qualified name of the container within its module, or the module name
+	// for module containers.
+	name string
+	// declaration is the index of the container's declaration, or -1 for modules.
+	declaration int
+}
+
+// Declaration is a symbol, with the source of its declaration.
+type Declaration struct {
+	Symbol
+
+	// Snippet is the source of the declaration, with its doc comment.
+	Snippet string `json:"snippet"`
+	// Truncated reports whether the snippet was cut to maxSnippetBytes.
+	Truncated bool `json:"truncated,omitempty"`
+
+	// start and end are the offsets of the declaration in the source; end is -1 until the
+	// end of containers is found.
+	start, end int
+}
+
+// parser extracts the declarations of a type definitions file.
+type parser struct {
+	file         string
+	module       string
+	stack        []container
+	declarations []Declaration
+
+	// start is the offset of the declaration being scanned, or -1 between declarations,
+	// and end the offset of the end of the declarations being added.
+	start, end int
+}
+
+// ParseDefinitions extracts the declarations of the .d.ts files of fsys, the k6 type
+// definitions from DefinitelyTyped. The module of the symbols of each file is derived from
+// its path, e.g. k6/http for http/index.d.ts, unless the file declares modules itself.
+func ParseDefinitions(fsys fs.FS) ([]Declaration, error) {
+	var declarations []Declaration
+	err := fs.WalkDir(fsys, ".", func(filePath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(filePath, dtsSuffix) {
+			return nil
+		}
+
+		data, err := fs.ReadFile(fsys, filePath)
+		if err != nil {
+			return err
+		}
+
+		p := &parser{file: filePath, module: moduleOf(filePath), start: -1}
+		p.parse(string(data))
+		declarations = append(declarations, p.declarations...)
+
+		return nil
+	})
+
+	return declarations, err
+}
+
+// moduleOf returns the module of the symbols declared by the type definitions file.
+func moduleOf(filePath string) string {
+	name := strings.TrimSuffix(filePath, dtsSuffix)
+	name = strings.TrimSuffix(path.Clean(name), "/index")
+
+	switch name {
+	case "index", ".":
+		return "k6"
+	case "global", "globals":
+		return GlobalModule
+	default:
+		return "k6/" + name
+	}
+}
+
+// parse scans the source for declarations. Declarations are delimited by semicolons, and by
+// the braces of the bodies of modules, namespaces, classes, interfaces and enums; braces of
+// object types are kept within the declarations they belong to.
+func (p *parser) parse(src string) {
+	var (
+		buf    strings.Builder
+		doc    string
+		parens int
+		types  int
+	)
+
+	defer p.snippets(src)
+
+	for i := 0; i < len(src); i++ {
+		c := src[i]
+		if p.start < 0 && !unicode.IsSpace(rune(c)) && c != '/' && c != '}' && c != ';' && c != ',' {
+			p.start = i
+		}
+
+		switch {
+		case strings.HasPrefix(src[i:], "/**"):
+			end := strings.Index(src[i+3:], "*/")
+			if end < 0 {
+				return
+			}
+			if strings.TrimSpace(buf.String()) == "" {
+				doc = src[i+3 : i+3+end]
+				p.start = i
+			}
+			i += end + 4
+		case strings.HasPrefix(src[i:], "/*"):
+			end := strings.Index(src[i+2:], "*/")
+			if end < 0 {
+				return
+			}
+			i += end + 3
+		case strings.HasPrefix(src[i:], "//"):
+			end := strings.IndexByte(src[i:], '\n')
+			if end < 0 {
+				return
+			}
+			// The line break still separates the surrounding tokens
+			i += end - 1
+		case c == '\'' || c == '"' || c == '`':
+			end := i + 1
+			for end < len(src) && src[end] != c {
+				if src[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(src) {
+				return
+			}
+			buf.WriteString(src[i : end+1])
+			i = end
+		case c == '(' || c == '[':
+			parens++
+			buf.WriteByte(c)
+		case c == ')' || c == ']':
+			parens--
+			buf.WriteByte(c)
+		case c == '{' && parens == 0 && types == 0 && p.header(buf.String(), doc):
+			buf.Reset()
+			doc = ""
+			p.start = -1
+		case c == '{':
+			types++
+			buf.WriteByte(c)
+		case c == '}' && types > 0:
+			types--
+			buf.WriteByte(c)
+		case c == '}':
+			p.end = i
+			p.statement(buf.String(), doc)
+			buf.Reset()
+			doc = ""
+			p.start = -1
+			if len(p.stack) > 0 {
+				if top := p.stack[len(p.stack)-1]; top.declaration >= 0 {
+					p.declarations[top.declaration].end = i + 1
+				}
+				p.stack = p.stack[:len(p.stack)-1]
+			}
+		case c == ';' && parens == 0 && types == 0,
+			c == ',' && parens == 0 && types == 0 && p.in(KindEnum):
+			p.end = i + 1
+			p.statement(buf.String(), doc)
+			buf.Reset()
+			doc = ""
+			p.start = -1
+		default:
+			buf.WriteByte(c)
+		}
+	}
+}
+
+// header handles the text before an opening brace: when it declares a container, it
+// records its symbol and enters it, and returns true.
+func (p *parser) header(text, doc string) bool {
+	text = stripModifiers(normalize(text))
+
+	if text == "global" {
+		p.stack = append(p.stack, container{kind: "module", name: GlobalModule, declaration: -1})
+		return true
+	}
+	if m := modulePattern.FindStringSubmatch(text); m != nil {
+		p.stack = append(p.stack, container{kind: "module", name: m[1], declaration: -1})
+		return true
+	}
+
+	m := headerPattern.FindStringSubmatch(text)
+	if m == nil || p.in(KindClass) || p.in(KindInterface) || p.in(KindEnum) {
+		return false
+	}
+
+	kind, name := m[1], p.qualify(m[2])
+	p.end = -1
+	p.add(kind, name, text, doc)
+	p.stack = append(p.stack, container{kind: kind, name: name, declaration: len(p.declarations) - 1})
+
+	return true
+}
+
+// statement handles a declaration ended by a semicolon or a closing brace.
+func (p *parser) statement(text, doc string) {
+	text = normalize(text)
+	if text == "" || strings.HasPrefix(text, "private ") || strings.HasPrefix(text, "protected ") || strings.HasPrefix(text, "#") {
+		return
+	}
+	text = stripModifiers(text)
+
+	switch {
+	case p.in(KindEnum):
+		return
+	case p.in(KindClass) || p.in(KindInterface):
+		p.member(text, doc)
+	default:
+		if m := functionPattern.FindStringSubmatch(text); m != nil {
+			p.add(KindFunction, p.qualify(m[1]), text, doc)
+		} else if m := variablePattern.FindStringSubmatch(text); m != nil {
+			p.add(KindConstant, p.qualify(m[2]), text, doc)
+		} else if m := typePattern.FindStringSubmatch(text); m != nil {
+			p.add(KindType, p.qualify(m[1]), text, doc)
+		}
+	}
+}
+
+// member handles a member of a class or interface.
+func (p *parser) member(text, doc string) {
+	switch {
+	case strings.HasPrefix(text, "constructor"):
+		p.add(KindConstructor, p.qualify("constructor"), text, doc)
+	case accessorPattern.MatchString(text):
+		p.add(KindProperty, p.qualify(accessorPattern.FindStringSubmatch(text)[2]), text, doc)
+	case methodPattern.MatchString(text):
+		p.add(KindMethod, p.qualify(methodPattern.FindStringSubmatch(text)[1]), text, doc)
+	case propertyPattern.MatchString(text):
+		p.add(KindProperty, p.qualify(propertyPattern.FindStringSubmatch(text)[1]), text, doc)
+	}
+}
+
+// in reports whether the innermost container is of the kind.
+func (p *parser) in(kind string) bool {
+	return len(p.stack) > 0 && p.stack[len(p.stack)-1].kind == kind
+}
+
+// qualify returns the name qualified by the names of the enclosing declarations.
+func (p *parser) qualify(name string) string {
+	for i := len(p.stack) - 1; i >= 0; i-- {
+		if p.stack[i].kind != "module" {
+			return p.stack[i].name + "." + name
+		}
+	}
+	return name
+}
+
+// currentModule returns the module of the declarations being parsed.
+func (p *parser) currentModule() string {
+	for i := len(p.stack) - 1; i >= 0; i-- {
+		if p.stack[i].kind == "module" {
+			return p.stack[i].name
+		}
+	}
+	return p.module
+}
+
+func (p *parser) add(kind, name, signature, doc string) {
+	p.declarations = append(p.declarations, Declaration{
+		Symbol: Symbol{
+			Module:    p.currentModule(),
+			Name:      name,
+			Kind:      kind,
+			Signature: signature,
+			Doc:       docExcerpt(doc),
+			File:      p.file,
+		},
+		start: max(p.start, 0),
+		end:   p.end,
+	})
+}
+
+// snippets sets the lines and source snippets of the declarations.
+func (p *parser) snippets(src string) {
+	for i := range p.declarations {
+		declaration := &p.declarations[i]
+		end := declaration.end
+		if end < 0 || end > len(src) {
+			end = len(src)
+		}
+
+		lineStart := strings.LastIndexByte(src[:declaration.start], '\n') + 1
+		declaration.Line = strings.Count(src[:declaration.start], "\n") + 1
+		declaration.Snippet = dedent(src[lineStart:end])
+		if len(declaration.Snippet) > maxSnippetBytes {
+			cut := strings.LastIndexByte(declaration.Snippet[:maxSnippetBytes], '\n')
+			if cut <= 0 {
+				cut = maxSnippetBytes
+			}
+			declaration.Snippet = declaration.Snippet[:cut] + "\n// …"
+			declaration.Truncated = true
+		}
+	}
+}
+
+// dedent removes the indentation of the first line of the source from all its lines.
+func dedent(source string) string {
+	indent := source[:len(source)-len(strings.TrimLeft(source, " \t"))]
+	lines := strings.Split(source, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimPrefix(line, indent)
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// stripModifiers removes the modifiers declarations start with.
+func stripModifiers(text string) string {
+	for {
+		stripped := modifierPattern.ReplaceAllString(text, "")
+		if stripped == text {
+			return text
+		}
+		text = stripped
+	}
+}
+
+// normalize collapses the whitespace of declarations spanning several lines, and the
+// trailing commas of their parameter lists.
+func normalize(text string) string {
+	text = strings.TrimSpace(whitespaceRegexp.ReplaceAllString(text, " "))
+	return strings.NewReplacer("( ", "(", ", )", ")", " )", ")").Replace(text)
+}
+
+// docExcerpt returns the description of a JSDoc comment, without its tags, cut to
+// maxDocExcerpt characters.
+func docExcerpt(doc string) string {
+	var lines []string
+	for _, line := range strings.Split(doc, "\n") {
+		line = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "*"))
+		if strings.HasPrefix(line, "@") {
+			break
+		}
+		lines = append(lines, line)
+	}
+
+	excerpt := normalize(strings.Join(lines, " "))
+	if len(excerpt) <= maxDocExcerpt {
+		return excerpt
+	}
+
+	cut := strings.LastIndexByte(excerpt[:maxDocExcerpt], ' ')
+	if cut <= 0 {
+		cut = maxDocExcerpt
+	}
+	return excerpt[:cut] + "…"
+}
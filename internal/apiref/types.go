@@ -0,0 +1,140 @@
+package apiref
+
+import (
+	"io/fs"
+	"sort"
+	"strings"
+)
+
+const (
+	// DefaultTypeResults and MaxTypeResults bound the number of declarations a type search returns.
+	DefaultTypeResults = 10
+	MaxTypeResults     = 50
+)
+
+// Scores of the matches of a query term, by where it matches.
+const (
+	scoreNameExact    = 10
+	scoreNameSegment  = 6
+	scoreNameContains = 4
+	scoreSignature    = 2
+	scoreDoc          = 1
+)
+
+// TypeQuery filters and limits a type search.
+type TypeQuery struct {
+	// Text holds the terms the declarations must all match, in their name, signature or
+	// doc comment.
+	Text string
+	// Module restricts the search to a module, such as k6/http.
+	Module string
+	// Kind restricts the search to a kind of declarations, such as interface.
+	Kind  string
+	Limit int
+}
+
+// TypeMatch is a declaration matching a type search.
+type TypeMatch struct {
+	Declaration
+	Score int `json:"score"`
+}
+
+// TypeIndex searches the declarations of type definitions, in memory.
+type TypeIndex struct {
+	declarations []Declaration
+}
+
+// NewTypeIndex parses the type definitions of fsys into a TypeIndex.
+func NewTypeIndex(fsys fs.FS) (*TypeIndex, error) {
+	declarations, err := ParseDefinitions(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &TypeIndex{declarations: declarations}, nil
+}
+
+// Len returns the number of indexed declarations.
+func (t *TypeIndex) Len() int {
+	return len(t.declarations)
+}
+
+// Search returns the declarations matching all the terms of the query, best matches first,
+// and the number of matching declarations.
+func (t *TypeIndex) Search(query TypeQuery) ([]TypeMatch, int) {
+	if query.Limit <= 0 {
+		query.Limit = DefaultTypeResults
+	}
+	terms := strings.FieldsFunc(strings.ToLower(query.Text), func(r rune) bool {
+		return r == ' ' || r == '.' || r == '/' || r == '(' || r == ')'
+	})
+
+	matches := []TypeMatch{}
+	for _, declaration := range t.declarations {
+		if query.Module != "" && declaration.Module != query.Module {
+			continue
+		}
+		if query.Kind != "" && declaration.Kind != query.Kind {
+			continue
+		}
+
+		if score := scoreDeclaration(declaration, terms); score > 0 {
+			matches = append(matches, TypeMatch{Declaration: declaration, Score: score})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return len(matches[i].Name) < len(matches[j].Name)
+	})
+
+	total := len(matches)
+	if len(matches) > query.Limit {
+		matches = matches[:query.Limit]
+	}
+
+	return matches, total
+}
+
+// scoreDeclaration scores how well the declaration matches the terms, or returns 0 when
+// a term matches nowhere. Declarations without terms score 1, to list them by filters.
+func scoreDeclaration(declaration Declaration, terms []string) int {
+	if len(terms) == 0 {
+		return 1
+	}
+
+	name := strings.ToLower(declaration.Name)
+	segments := strings.Split(name, ".")
+	signature := strings.ToLower(declaration.Signature)
+	doc := strings.ToLower(declaration.Doc)
+
+	score := 0
+	for _, term := range terms {
+		switch {
+		case name == term:
+			score += scoreNameExact
+		case contains(segments, term):
+			score += scoreNameSegment
+		case strings.Contains(name, term):
+			score += scoreNameContains
+		case strings.Contains(signature, term):
+			score += scoreSignature
+		case strings.Contains(doc, term):
+			score += scoreDoc
+		default:
+			return 0
+		}
+	}
+
+	return score
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,254 @@
+package apiref
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// maxUsageLines bounds the number of lines reported per API usage.
+const maxUsageLines = 10
+
+// Kinds of imports.
+const (
+	ImportK6        = "k6"
+	ImportExtension = "extension"
+	ImportRemote    = "remote"
+	ImportLocal     = "local"
+)
+
+var (
+	importPattern   = regexp.MustCompile(`(?s)\bimport\s+([^'";]+?)\s+from\s*['"]([^'"]+)['"]`)
+	bareImport      = regexp.MustCompile(`\bimport\s*['"]([^'"]+)['"]`)
+	requirePattern  = regexp.MustCompile(`\b(?:const|let|var)\s+([A-Za-z_$][\w$]*|\{[^}]*\})\s*=\s*require\(\s*['"]([^'"]+)['"]\s*\)`)
+	globalsPattern  = regexp.MustCompile(`\b(open|__ENV|__VU|__ITER)\b`)
+	identifierRegex = regexp.MustCompile(`^[A-Za-z_$][\w$]*$`)
+)
+
+// Import is an import of a script.
+type Import struct {
+	Module string `json:"module"`
+	// Kind is k6 for the k6 modules, extension for the k6/x/ extension modules, remote for
+	// URLs, such as jslib.k6.io libraries, and local for the script's own modules.
+	Kind string `json:"kind"`
+	Line int    `json:"line"`
+	// Names are the local names the import binds.
+	Names []string `json:"names,omitempty"`
+}
+
+// Usage is a use of a symbol of the k6 API by a script.
+type Usage struct {
+	Module string `json:"module"`
+	// Name is the name of the symbol within its module, such as batch or Counter.
+	Name string `json:"name"`
+	// Occurrences counts the uses of the symbol, of which Lines lists the first lines.
+	Occurrences int   `json:"occurrences"`
+	Lines       []int `json:"lines"`
+}
+
+// binding maps a local name of a script to what it imports: a whole module, when symbol
+// is empty, or a symbol of the module.
+type binding struct {
+	module string
+	symbol string
+}
+
+// ScriptUsage returns the imports of the script, and its uses of the k6 API: the members
+// of the k6 modules it accesses, such as http.get, the functions and classes it imports by
+// name and uses, such as check or Counter, and the globals it uses, such as open or __ENV.
+// Comments are ignored; uses through other variables, such as the methods of responses,
+// are not tracked.
+func ScriptUsage(script string) ([]Import, []Usage) {
+	code := stripComments(script)
+
+	var imports []Import
+	var statements [][]int
+	bindings := map[string]binding{}
+	for _, m := range importPattern.FindAllStringSubmatchIndex(code, -1) {
+		clause, module := code[m[2]:m[3]], code[m[4]:m[5]]
+		names := bindClause(bindings, clause, module)
+		imports = append(imports, Import{Module: module, Kind: importKind(module), Line: lineOf(code, m[0]), Names: names})
+		statements = append(statements, m[:2])
+	}
+	for _, m := range bareImport.FindAllStringSubmatchIndex(code, -1) {
+		module := code[m[2]:m[3]]
+		imports = append(imports, Import{Module: module, Kind: importKind(module), Line: lineOf(code, m[0])})
+	}
+	for _, m := range requirePattern.FindAllStringSubmatchIndex(code, -1) {
+		clause, module := code[m[2]:m[3]], code[m[4]:m[5]]
+		if strings.HasPrefix(clause, "{") {
+			clause = strings.ReplaceAll(clause, ":", " as ")
+		} else {
+			clause = "* as " + clause
+		}
+		names := bindClause(bindings, clause, module)
+		imports = append(imports, Import{Module: module, Kind: importKind(module), Line: lineOf(code, m[0]), Names: names})
+		statements = append(statements, m[:2])
+	}
+	sort.SliceStable(imports, func(i, j int) bool { return imports[i].Line < imports[j].Line })
+
+	usages := map[binding]*Usage{}
+	record := func(b binding, offset int) {
+		usage, ok := usages[b]
+		if !ok {
+			usage = &Usage{Module: b.module, Name: b.symbol}
+			usages[b] = usage
+		}
+		usage.Occurrences++
+		if line := lineOf(code, offset); len(usage.Lines) < maxUsageLines && (len(usage.Lines) == 0 || usage.Lines[len(usage.Lines)-1] != line) {
+			usage.Lines = append(usage.Lines, line)
+		}
+	}
+
+	for local, b := range bindings {
+		if importKind(b.module) != ImportK6 && importKind(b.module) != ImportExtension {
+			continue
+		}
+
+		if b.symbol == "" {
+			// Members of imported modules, such as http.get
+			pattern := regexp.MustCompile(`(?:^|[^\w$.])` + regexp.QuoteMeta(local) + `\s*\.\s*([A-Za-z_$][\w$]*)`)
+			for _, m := range pattern.FindAllStringSubmatchIndex(code, -1) {
+				record(binding{module: b.module, symbol: code[m[2]:m[3]]}, m[2])
+			}
+			continue
+		}
+
+		// Symbols imported by name, used past their import
+		pattern := regexp.MustCompile(`(?:^|[^\w$.])` + regexp.QuoteMeta(local) + `\b`)
+		for _, m := range pattern.FindAllStringIndex(code, -1) {
+			if within(statements, m[0]) {
+				continue
+			}
+			record(b, m[1]-len(local))
+		}
+	}
+
+	for _, m := range globalsPattern.FindAllStringSubmatchIndex(code, -1) {
+		name := code[m[2]:m[3]]
+		if _, shadowed := bindings[name]; shadowed {
+			continue
+		}
+		if before := strings.TrimRight(code[:m[2]], " \t\n"); strings.HasSuffix(before, ".") {
+			continue
+		}
+		if name == "open" && !strings.HasPrefix(strings.TrimSpace(code[m[3]:]), "(") {
+			continue
+		}
+		record(binding{module: GlobalModule, symbol: name}, m[2])
+	}
+
+	result := make([]Usage, 0, len(usages))
+	for _, usage := range usages {
+		result = append(result, *usage)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Lines[0] != result[j].Lines[0] {
+			return result[i].Lines[0] < result[j].Lines[0]
+		}
+		return result[i].Module+"."+result[i].Name < result[j].Module+"."+result[j].Name
+	})
+
+	return imports, result
+}
+
+// bindClause binds the local names of an import clause, such as `http`, `* as http`,
+// `{ check, sleep as pause }` or `http, { get }`, and returns them.
+func bindClause(bindings map[string]binding, clause, module string) []string {
+	var names []string
+	named := ""
+	if open := strings.IndexByte(clause, '{'); open >= 0 {
+		end := strings.IndexByte(clause, '}')
+		if end > open {
+			named = clause[open+1 : end]
+			clause = clause[:open] + clause[end+1:]
+		}
+	}
+
+	for _, part := range strings.Split(clause, ",") {
+		part = strings.TrimSpace(part)
+		part = strings.TrimSpace(strings.TrimPrefix(part, "* as "))
+		if identifierRegex.MatchString(part) {
+			bindings[part] = binding{module: module}
+			names = append(names, part)
+		}
+	}
+
+	for _, part := range strings.Split(named, ",") {
+		imported, local, found := strings.Cut(strings.TrimSpace(part), " as ")
+		imported = strings.TrimSpace(imported)
+		if !found {
+			local = imported
+		}
+		local = strings.TrimSpace(local)
+		if !identifierRegex.MatchString(local) {
+			continue
+		}
+		if imported == "default" {
+			bindings[local] = binding{module: module}
+		} else {
+			bindings[local] = binding{module: module, symbol: imported}
+		}
+		names = append(names, local)
+	}
+
+	return names
+}
+
+func importKind(module string) string {
+	switch {
+	case strings.HasPrefix(module, "k6/x/"):
+		return ImportExtension
+	case module == "k6" || strings.HasPrefix(module, "k6/"):
+		return ImportK6
+	case strings.HasPrefix(module, "https://") || strings.HasPrefix(module, "http://"):
+		return ImportRemote
+	default:
+		return ImportLocal
+	}
+}
+
+// within reports whether the offset is within one of the statements.
+func within(statements [][]int, offset int) bool {
+	for _, statement := range statements {
+		if offset >= statement[0] && offset < statement[1] {
+			return true
+		}
+	}
+	return false
+}
+
+func lineOf(code string, offset int) int {
+	return strings.Count(code[:offset], "\n") + 1
+}
+
+// stripComments blanks the comments of the script, keeping its line breaks, so that
+// offsets keep their lines.
+func stripComments(script string) string {
+	out := []byte(script)
+	for i := 0; i < len(out); i++ {
+		switch c := out[i]; {
+		case c == '\'' || c == '"' || c == '`':
+			for i++; i < len(out) && out[i] != c; i++ {
+				if out[i] == '\\' {
+					i++
+				}
+			}
+		case c == '/' && i+1 < len(out) && out[i+1] == '/':
+			for ; i < len(out) && out[i] != '\n'; i++ {
+				out[i] = ' '
+			}
+		case c == '/' && i+1 < len(out) && out[i+1] == '*':
+			for ; i < len(out) && !(out[i] == '*' && i+1 < len(out) && out[i+1] == '/'); i++ {
+				if out[i] != '\n' {
+					out[i] = ' '
+				}
+			}
+			if i+1 < len(out) {
+				out[i], out[i+1] = ' ', ' '
+				i++
+			}
+		}
+	}
+	return string(out)
+}
@@ -0,0 +1,279 @@
+// Package archive packages k6 scripts, their local modules and data files into k6 archives.
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/oleiade/k6-mcp/internal/execx"
+	"github.com/oleiade/k6-mcp/internal/k6bin"
+	"github.com/oleiade/k6-mcp/internal/logging"
+	"github.com/oleiade/k6-mcp/internal/sandbox"
+	"github.com/oleiade/k6-mcp/internal/security"
+	"github.com/oleiade/k6-mcp/internal/workspace"
+)
+
+const (
+	// DefaultTimeout is the default timeout for creating an archive.
+	DefaultTimeout = 60 * time.Second
+	// archiveName is the name of the archive k6 writes in the working directory.
+	archiveName = "archive.tar"
+)
+
+// Result contains the outcome of a k6 archive creation.
+type Result struct {
+	Success   bool     `json:"success"`
+	SizeBytes int      `json:"size_bytes,omitempty"`
+	SHA256    string   `json:"sha256,omitempty"`
+	Entries   []string `json:"entries,omitempty"`
+	Stderr    string   `json:"stderr,omitempty"`
+	Error     string   `json:"error,omitempty"`
+	Duration  string   `json:"duration"`
+
+	// Archive holds the content of the tarball produced by k6.
+	Archive []byte `json:"-"`
+}
+
+// Error represents errors that occur while creating a k6 archive.
+type Error struct {
+	Type    string
+	Message string
+	Cause   error
+}
+
+func (e *Error) Error() string {
+	if e.Cause != nil {
+		return fmt.Sprintf("%s: %s (caused by: %v)", e.Type, e.Message, e.Cause)
+	}
+	return fmt.Sprintf("%s: %s", e.Type, e.Message)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Cause
+}
+
+// CreateArchive runs `k6 archive` on the script, with the given files laid out next to it,
+// and returns the resulting tarball.
+//
+// Files are keyed by their path relative to the script, e.g. "lib/auth.js" for a module
+// imported as './lib/auth.js', or "data/users.csv" for a file opened with open().
+func CreateArchive(ctx context.Context, script string, files map[string]string) (*Result, error) {
+	startTime := time.Now()
+	logger := logging.WithComponent("archive")
+
+	logger.DebugContext(ctx, "Starting k6 archive creation",
+		slog.Int("script_size", len(script)),
+		slog.Int("file_count", len(files)),
+	)
+
+	if err := validateInput(script, files); err != nil {
+		logger.WarnContext(ctx, "Archive input validation failed",
+			slog.String("error", err.Error()),
+		)
+		return &Result{
+			Success:  false,
+			Error:    err.Error(),
+			Duration: time.Since(startTime).String(),
+		}, err
+	}
+
+	ws, err := workspace.Create("k6-archive-", script, files)
+	if err != nil {
+		logging.FileOperation(ctx, "archive", "create_workspace", "", err)
+		return &Result{
+			Success:  false,
+			Error:    fmt.Sprintf("failed to prepare archive files: %v", err),
+			Duration: time.Since(startTime).String(),
+		}, &Error{
+			Type:    "FILE_CREATION",
+			Message: "failed to prepare archive files",
+			Cause:   err,
+		}
+	}
+	defer ws.Cleanup()
+
+	result, err := executeK6Archive(ctx, ws.Dir)
+	result.Duration = time.Since(startTime).String()
+
+	logger.InfoContext(ctx, "k6 archive creation completed",
+		slog.Bool("success", result.Success),
+		slog.Int("size_bytes", result.SizeBytes),
+		slog.Int("entry_count", len(result.Entries)),
+		slog.Duration("duration", time.Since(startTime)),
+	)
+
+	return result, err
+}
+
+// validateInput checks the script and the additional files before they are written to disk.
+func validateInput(script string, files map[string]string) error {
+	if err := security.ValidateScriptContent(script); err != nil {
+		return &Error{
+			Type:    "SECURITY_VALIDATION",
+			Message: "script failed security validation",
+			Cause:   err,
+		}
+	}
+
+	if err := workspace.ValidateFiles(files); err != nil {
+		return &Error{
+			Type:    "PARAMETER_VALIDATION",
+			Message: "invalid files",
+			Cause:   err,
+		}
+	}
+
+	return nil
+}
+
+// executeK6Archive runs `k6 archive` in workDir, and reads back the produced tarball.
+func executeK6Archive(ctx context.Context, workDir string) (*Result, error) {
+	logger := logging.WithComponent("archive")
+	startTime := time.Now()
+
+	k6Path, err := k6bin.Find()
+	if err != nil {
+		logger.ErrorContext(ctx, "k6 executable not found",
+			slog.String("error", err.Error()),
+		)
+		return &Result{
+			Success: false,
+			Error:   k6bin.NotFoundMessage,
+		}, &Error{
+			Type:    "K6_NOT_FOUND",
+			Message: k6bin.NotFoundMessage,
+			Cause:   err,
+		}
+	}
+
+	execution, err := sandbox.Default().Run(ctx, sandbox.Command{
+		Path: k6Path,
+		Args: []string{"archive", "--quiet", "--archive-out", archiveName, workspace.ScriptName},
+		Env:  security.SecureEnvironment(),
+		Dir:  workDir,
+	}, execx.WithTimeout(DefaultTimeout))
+	exitCode := execution.ExitCode
+	logging.ExecutionEvent(ctx, "archive", "k6 archive", time.Since(startTime), exitCode, err)
+
+	result := &Result{Stderr: security.SanitizeOutput(execution.Stderr)}
+
+	if err != nil {
+		if errors.Is(err, execx.ErrTimeout) {
+			result.Error = fmt.Sprintf("k6 archive timed out after %v", DefaultTimeout)
+			return result, &Error{Type: "TIMEOUT", Message: result.Error, Cause: err}
+		}
+		if errors.Is(err, execx.ErrCanceled) {
+			result.Error = "k6 archive was cancelled"
+			return result, &Error{Type: "CANCELED", Message: result.Error, Cause: err}
+		}
+		var exitError *exec.ExitError
+		if errors.As(err, &exitError) {
+			// k6 ran but could not archive the script, e.g. because of a missing import;
+			// the details are reported in stderr.
+			result.Error = fmt.Sprintf("k6 archive failed with exit code %d", exitCode)
+			return result, nil
+		}
+		result.Error = fmt.Sprintf("failed to execute k6: %v", err)
+		return result, &Error{Type: "EXECUTION_ERROR", Message: "failed to execute k6 archive command", Cause: err}
+	}
+
+	archive, err := os.ReadFile(filepath.Join(workDir, archiveName))
+	if err != nil {
+		result.Error = "failed to read the archive produced by k6"
+		return result, &Error{Type: "FILE_READ", Message: result.Error, Cause: err}
+	}
+
+	entries, err := listEntries(archive)
+	if err != nil {
+		result.Error = "k6 produced an invalid archive"
+		return result, &Error{Type: "INVALID_ARCHIVE", Message: result.Error, Cause: err}
+	}
+
+	sum := sha256.Sum256(archive)
+	result.Success = true
+	result.Archive = archive
+	result.SizeBytes = len(archive)
+	result.SHA256 = hex.EncodeToString(sum[:])
+	result.Entries = entries
+
+	return result, nil
+}
+
+// listEntries returns the sorted names of the regular files in a tarball.
+func listEntries(archive []byte) ([]string, error) {
+	var entries []string
+
+	reader := tar.NewReader(bytes.NewReader(archive))
+	for {
+		header, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if header.Typeflag == tar.TypeReg {
+			entries = append(entries, header.Name)
+		}
+	}
+	sort.Strings(entries)
+
+	return entries, nil
+}
+
+// WriteToWorkspace writes the content, such as an archive, to the relative path name
+// within the workspace directory dir, and returns the absolute path it was written to. An
+// existing file is only overwritten when replace is set.
+func WriteToWorkspace(dir, name string, content []byte, replace bool) (string, error) {
+	cleaned, err := workspace.CleanRelativePath(name)
+	if err != nil {
+		return "", &Error{
+			Type:    "PARAMETER_VALIDATION",
+			Message: fmt.Sprintf("invalid output path %q", name),
+			Cause:   err,
+		}
+	}
+
+	target, err := filepath.Abs(filepath.Join(dir, filepath.FromSlash(cleaned)))
+	if err != nil {
+		return "", &Error{Type: "FILE_WRITE", Message: "failed to resolve output path", Cause: err}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), security.SecureDirMode); err != nil {
+		return "", &Error{Type: "FILE_CREATION", Message: "failed to create output directory", Cause: err}
+	}
+
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if !replace {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	}
+	f, err := os.OpenFile(target, flags, security.SecureFileMode)
+	if errors.Is(err, fs.ErrExist) {
+		return "", &Error{Type: "FILE_EXISTS", Message: fmt.Sprintf("%s already exists", cleaned), Cause: err}
+	}
+	if err != nil {
+		return "", &Error{Type: "FILE_WRITE", Message: "failed to write file", Cause: err}
+	}
+	_, err = f.Write(content)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", &Error{Type: "FILE_WRITE", Message: "failed to write file", Cause: err}
+	}
+
+	return target, nil
+}
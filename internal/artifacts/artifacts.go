@@ -0,0 +1,337 @@
+// Package artifacts stores the files runs and tools produce, such as k6 JSON outputs,
+// summary exports and reports, as named artifacts retrieved in chunks, so that large
+// results are not forced inline into tool responses. The artifacts share a size quota:
+// the oldest ones are evicted to make room for new ones.
+package artifacts
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/oleiade/k6-mcp/internal/jsonfile"
+	"github.com/oleiade/k6-mcp/internal/security"
+)
+
+const (
+	// DefaultQuotaBytes is the default total size of the stored artifacts.
+	DefaultQuotaBytes = 1024 * 1024 * 1024
+	// DefaultListLimit and MaxListLimit bound the number of artifacts listed.
+	DefaultListLimit = 50
+	MaxListLimit     = 500
+
+	// artifactsDirName is the name of the directory, within the data directory, holding
+	// the artifacts and their index.
+	artifactsDirName = "artifacts"
+	indexFileName    = "index.json"
+)
+
+// Kinds of artifacts.
+const (
+	KindOutput        = "k6-output"
+	KindSummaryExport = "summary-export"
+	KindReport        = "report"
+	// KindToolResponse is the complete response of a tool, stored when it was truncated to
+	// fit the response budget.
+	KindToolResponse = "tool-response"
+)
+
+// ErrNotFound is returned when no artifact has the requested ID.
+var ErrNotFound = errors.New("artifact not found")
+
+var (
+	idPattern   = regexp.MustCompile(`^[0-9a-f]{16}$`)
+	namePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]{0,127}$`)
+)
+
+// Artifact describes a stored artifact.
+type Artifact struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Kind        string    `json:"kind"`
+	ContentType string    `json:"content_type"`
+	Bytes       int64     `json:"bytes"`
+	SHA256      string    `json:"sha256"`
+	CreatedAt   time.Time `json:"created_at"`
+	// RunID is the ID of the run in the run history the artifact was produced by, if any.
+	RunID int `json:"run_id,omitempty"`
+	// Source is the tool the artifact was produced by.
+	Source string `json:"source,omitempty"`
+}
+
+// Query filters artifacts.
+type Query struct {
+	RunID int
+	Kind  string
+	Limit int
+}
+
+// Store persists artifacts in a directory, with their index in a JSON file.
+type Store struct {
+	dir   string
+	quota int64
+	mu    sync.Mutex
+}
+
+// NewStore creates a Store keeping its artifacts in the artifacts directory of dir, whose
+// total size is kept within quota bytes.
+func NewStore(dir string, quota int64) *Store {
+	if quota <= 0 {
+		quota = DefaultQuotaBytes
+	}
+	return &Store{dir: filepath.Join(dir, artifactsDirName), quota: quota}
+}
+
+// Quota returns the total size the artifacts are kept within.
+func (s *Store) Quota() int64 {
+	return s.quota
+}
+
+// Save stores the content of r as an artifact described by meta, whose ID, size, digest
+// and creation time are set. The oldest artifacts are evicted to keep the total size
+// within the quota; artifacts larger than the quota are rejected.
+func (s *Store) Save(meta Artifact, r io.Reader) (*Artifact, error) {
+	if !namePattern.MatchString(meta.Name) {
+		return nil, fmt.Errorf("invalid artifact name %q: use up to 128 letters, digits and '_.-'", meta.Name)
+	}
+
+	id, err := newID()
+	if err != nil {
+		return nil, err
+	}
+	meta.ID = id
+	meta.CreatedAt = time.Now().UTC()
+
+	if err := os.MkdirAll(s.dir, security.SecureDirMode); err != nil {
+		return nil, fmt.Errorf("failed to create artifacts directory: %w", err)
+	}
+	path := s.path(id)
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, security.SecureFileMode)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create artifact: %w", err)
+	}
+
+	hash := sha256.New()
+	written, copyErr := io.Copy(io.MultiWriter(file, hash), io.LimitReader(r, s.quota+1))
+	closeErr := file.Close()
+	switch {
+	case copyErr != nil:
+		_ = os.Remove(path)
+		return nil, fmt.Errorf("failed to write artifact: %w", copyErr)
+	case closeErr != nil:
+		_ = os.Remove(path)
+		return nil, fmt.Errorf("failed to write artifact: %w", closeErr)
+	case written > s.quota:
+		_ = os.Remove(path)
+		return nil, fmt.Errorf("artifact %s exceeds the artifacts quota of %d bytes", meta.Name, s.quota)
+	}
+	meta.Bytes = written
+	meta.SHA256 = hex.EncodeToString(hash.Sum(nil))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	index, err := s.load()
+	if err != nil {
+		_ = os.Remove(path)
+		return nil, err
+	}
+	index = append(index, meta)
+	index = s.evict(index)
+	if err := s.save(index); err != nil {
+		_ = os.Remove(path)
+		return nil, err
+	}
+
+	return &meta, nil
+}
+
+// SaveFile stores the file at path as an artifact, like Save, and removes the file.
+func (s *Store) SaveFile(meta Artifact, path string) (*Artifact, error) {
+	// #nosec G304 - the path is a file produced by the server
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", filepath.Base(path), err)
+	}
+	defer func() { _ = file.Close() }()
+
+	artifact, err := s.Save(meta, file)
+	if err != nil {
+		return nil, err
+	}
+	_ = file.Close()
+	_ = os.Remove(path)
+
+	return artifact, nil
+}
+
+// evict drops the oldest artifacts of the index, and their files, until the total size is
+// within the quota.
+func (s *Store) evict(index []Artifact) []Artifact {
+	var total int64
+	for _, artifact := range index {
+		total += artifact.Bytes
+	}
+
+	for len(index) > 1 && total > s.quota {
+		_ = os.Remove(s.path(index[0].ID))
+		total -= index[0].Bytes
+		index = index[1:]
+	}
+
+	return index
+}
+
+// List returns the artifacts matching the query, most recent first, the number of matching
+// artifacts, and the total size of the stored artifacts.
+func (s *Store) List(query Query) ([]Artifact, int, int64, error) {
+	if query.Limit <= 0 {
+		query.Limit = DefaultListLimit
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	index, err := s.load()
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	matching := []Artifact{}
+	var used int64
+	for i := len(index) - 1; i >= 0; i-- {
+		artifact := index[i]
+		used += artifact.Bytes
+		if (query.RunID != 0 && artifact.RunID != query.RunID) || (query.Kind != "" && artifact.Kind != query.Kind) {
+			continue
+		}
+		matching = append(matching, artifact)
+	}
+	total := len(matching)
+	if len(matching) > query.Limit {
+		matching = matching[:query.Limit]
+	}
+
+	return matching, total, used, nil
+}
+
+// Read returns up to limit bytes of the content of the artifact, from offset.
+func (s *Store) Read(id string, offset, limit int64) (*Artifact, []byte, error) {
+	artifact, err := s.Get(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if offset < 0 || offset > artifact.Bytes {
+		return nil, nil, fmt.Errorf("offset %d is out of the %d bytes of the artifact", offset, artifact.Bytes)
+	}
+
+	file, err := os.Open(s.path(id))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open artifact: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	data := make([]byte, min(limit, artifact.Bytes-offset))
+	if _, err := file.ReadAt(data, offset); err != nil && !errors.Is(err, io.EOF) {
+		return nil, nil, fmt.Errorf("failed to read artifact: %w", err)
+	}
+
+	return artifact, data, nil
+}
+
+// Get returns the artifact of the ID.
+func (s *Store) Get(id string) (*Artifact, error) {
+	if !idPattern.MatchString(id) {
+		return nil, ErrNotFound
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	index, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	for _, artifact := range index {
+		if artifact.ID == id {
+			return &artifact, nil
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
+// Path returns the path of the file of the artifact.
+func (s *Store) Path(id string) string {
+	return s.path(id)
+}
+
+func (s *Store) path(id string) string {
+	return filepath.Join(s.dir, id)
+}
+
+func (s *Store) load() ([]Artifact, error) {
+	var index []Artifact
+	if err := jsonfile.Load(filepath.Join(s.dir, indexFileName), &index); err != nil {
+		return nil, fmt.Errorf("failed to read artifacts index: %w", err)
+	}
+	sort.SliceStable(index, func(i, j int) bool { return index[i].CreatedAt.Before(index[j].CreatedAt) })
+
+	return index, nil
+}
+
+// save atomically replaces the index file.
+func (s *Store) save(index []Artifact) error {
+	if err := jsonfile.SaveAtomic(filepath.Join(s.dir, indexFileName), index); err != nil {
+		return fmt.Errorf("failed to write artifacts index: %w", err)
+	}
+
+	return nil
+}
+
+// newID returns a random artifact ID.
+func newID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate artifact ID: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Prune removes the artifacts created before the cutoff, and their files, and returns
+// their number and total size. With dryRun, nothing is removed.
+func (s *Store) Prune(before time.Time, dryRun bool) (int, int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	index, err := s.load()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	// The index is ordered by creation time, so the pruned artifacts are the first ones
+	var removed int
+	var freed int64
+	for removed < len(index) && index[removed].CreatedAt.Before(before) {
+		freed += index[removed].Bytes
+		removed++
+	}
+
+	if removed == 0 || dryRun {
+		return removed, freed, nil
+	}
+	for _, artifact := range index[:removed] {
+		_ = os.Remove(s.path(artifact.ID))
+	}
+
+	return removed, freed, s.save(index[removed:])
+}
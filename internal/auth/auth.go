@@ -0,0 +1,394 @@
+// Package auth acquires OAuth2 access tokens for tests, server-side: scripts receive
+// short-lived tokens through their environment, and the client secrets and passwords of
+// the token requests stay in the server configuration, out of the conversation.
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/oleiade/k6-mcp/internal/logging"
+)
+
+const (
+	// GrantClientCredentials and GrantPassword are the supported OAuth2 grant types.
+	GrantClientCredentials = "client_credentials"
+	GrantPassword          = "password"
+
+	// DefaultEnvVar is the environment variable tokens are exposed to scripts in, unless
+	// profiles name another one.
+	DefaultEnvVar = "ACCESS_TOKEN"
+
+	// requestTimeout bounds token requests.
+	requestTimeout = 15 * time.Second
+	// maxResponseBytes is the maximum size of token responses.
+	maxResponseBytes = 1024 * 1024
+	// expiryMargin is how long before their expiry cached tokens are renewed.
+	expiryMargin = 60 * time.Second
+)
+
+var (
+	profileNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]{0,63}$`)
+	envVarPattern      = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+// Error represents errors that occur while loading profiles or acquiring tokens.
+type Error struct {
+	Type    string
+	Message string
+	Cause   error
+}
+
+func (e *Error) Error() string {
+	if e.Cause != nil {
+		return fmt.Sprintf("%s: %s (caused by: %v)", e.Type, e.Message, e.Cause)
+	}
+	return fmt.Sprintf("%s: %s", e.Type, e.Message)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Cause
+}
+
+// Profile describes how to acquire tokens from an OAuth2 or OIDC token endpoint. Secrets
+// are either set in the profile, or read from the server environment variables named by
+// the *_env fields, so that the profiles file can be shared without them.
+type Profile struct {
+	TokenURL  string `json:"token_url"`
+	GrantType string `json:"grant_type"`
+
+	ClientID        string `json:"client_id"`
+	ClientSecret    string `json:"client_secret,omitempty"`
+	ClientSecretEnv string `json:"client_secret_env,omitempty"`
+
+	// Username and Password are the resource owner credentials of the password grant.
+	Username    string `json:"username,omitempty"`
+	Password    string `json:"password,omitempty"`
+	PasswordEnv string `json:"password_env,omitempty"`
+
+	Scope    string `json:"scope,omitempty"`
+	Audience string `json:"audience,omitempty"`
+
+	// ClientAuth is "basic" (default) to send the client credentials in an Authorization
+	// header, or "post" to send them in the request body.
+	ClientAuth string `json:"client_auth,omitempty"`
+
+	// EnvVar is the environment variable the token is exposed to scripts in.
+	EnvVar string `json:"env_var,omitempty"`
+}
+
+// ProfileInfo describes a profile without its credentials.
+type ProfileInfo struct {
+	Name      string `json:"name"`
+	GrantType string `json:"grant_type"`
+	TokenHost string `json:"token_host"`
+	Scope     string `json:"scope,omitempty"`
+	Audience  string `json:"audience,omitempty"`
+	EnvVar    string `json:"env_var"`
+}
+
+// Token is an access token acquired for a profile.
+type Token struct {
+	AccessToken string
+	TokenType   string
+	// ExpiresAt is when the token expires, or the zero time when the endpoint didn't tell.
+	ExpiresAt time.Time
+	// EnvVar is the environment variable the token is exposed to scripts in.
+	EnvVar string
+}
+
+// Provider acquires and caches the tokens of profiles.
+type Provider struct {
+	profiles map[string]Profile
+	client   *http.Client
+
+	mu     sync.Mutex
+	tokens map[string]*Token
+}
+
+// profilesFile is the format of profiles files.
+type profilesFile struct {
+	Profiles map[string]Profile `json:"profiles"`
+}
+
+// Load reads the profiles file at path, e.g.
+//
+//	{"profiles": {"staging": {"token_url": "https://auth.example.com/oauth/token",
+//	  "grant_type": "client_credentials", "client_id": "k6", "client_secret_env": "STAGING_SECRET"}}}
+func Load(path string) (*Provider, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, &Error{Type: "CONFIG_ERROR", Message: "failed to read auth profiles", Cause: err}
+	}
+
+	var file profilesFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, &Error{Type: "CONFIG_ERROR", Message: "invalid auth profiles file", Cause: err}
+	}
+
+	for name, profile := range file.Profiles {
+		if err := validateProfile(name, &profile); err != nil {
+			return nil, err
+		}
+		file.Profiles[name] = profile
+	}
+
+	return NewProvider(file.Profiles), nil
+}
+
+// NewProvider creates a provider of the tokens of the given, valid, profiles.
+func NewProvider(profiles map[string]Profile) *Provider {
+	return &Provider{
+		profiles: profiles,
+		client:   &http.Client{Timeout: requestTimeout},
+		tokens:   make(map[string]*Token),
+	}
+}
+
+// validateProfile validates a profile, completing its defaults.
+func validateProfile(name string, profile *Profile) error {
+	invalid := func(format string, args ...interface{}) error {
+		return &Error{Type: "CONFIG_ERROR", Message: fmt.Sprintf("auth profile %q: ", name) + fmt.Sprintf(format, args...)}
+	}
+
+	if !profileNamePattern.MatchString(name) {
+		return invalid("profile names use up to 64 letters, digits and '_.-'")
+	}
+
+	u, err := url.Parse(profile.TokenURL)
+	if err != nil || u.Hostname() == "" {
+		return invalid("token_url must be an absolute URL")
+	}
+	// Credentials only travel in plaintext to local endpoints, such as test identity providers
+	if u.Scheme != "https" && !(u.Scheme == "http" && isLoopback(u.Hostname())) {
+		return invalid("token_url must be an https URL, or an http URL of the local host")
+	}
+
+	switch profile.GrantType {
+	case GrantClientCredentials:
+	case GrantPassword:
+		if profile.Username == "" || (profile.Password == "" && profile.PasswordEnv == "") {
+			return invalid("the password grant requires a username, and a password or password_env")
+		}
+	default:
+		return invalid("grant_type must be %q or %q", GrantClientCredentials, GrantPassword)
+	}
+
+	if profile.ClientID == "" {
+		return invalid("client_id is required")
+	}
+	switch profile.ClientAuth {
+	case "":
+		profile.ClientAuth = "basic"
+	case "basic", "post":
+	default:
+		return invalid("client_auth must be \"basic\" or \"post\"")
+	}
+
+	if profile.EnvVar == "" {
+		profile.EnvVar = DefaultEnvVar
+	}
+	if !envVarPattern.MatchString(profile.EnvVar) {
+		return invalid("env_var must be an environment variable name")
+	}
+
+	return nil
+}
+
+// Profiles describes the profiles, sorted by name. It is safe to call on a nil provider.
+func (p *Provider) Profiles() []ProfileInfo {
+	infos := []ProfileInfo{}
+	if p == nil {
+		return infos
+	}
+
+	for name, profile := range p.profiles {
+		u, _ := url.Parse(profile.TokenURL)
+		infos = append(infos, ProfileInfo{
+			Name:      name,
+			GrantType: profile.GrantType,
+			TokenHost: u.Host,
+			Scope:     profile.Scope,
+			Audience:  profile.Audience,
+			EnvVar:    profile.EnvVar,
+		})
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+
+	return infos
+}
+
+// EnvVar returns the environment variable the tokens of the profile are exposed in. It is
+// safe to call on a nil provider.
+func (p *Provider) EnvVar(name string) (string, error) {
+	profile, err := p.profile(name)
+	if err != nil {
+		return "", err
+	}
+	return profile.EnvVar, nil
+}
+
+// Token returns a token of the profile, reusing the cached one until shortly before its
+// expiry. It is safe to call on a nil provider.
+func (p *Provider) Token(ctx context.Context, name string) (*Token, error) {
+	profile, err := p.profile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if token, ok := p.tokens[name]; ok && !token.ExpiresAt.IsZero() && time.Until(token.ExpiresAt) > expiryMargin {
+		return token, nil
+	}
+
+	token, err := p.requestToken(ctx, profile)
+	if err != nil {
+		logging.WithComponent("auth").WarnContext(ctx, "Failed to acquire token",
+			slog.String("profile", name),
+			slog.String("error", err.Error()),
+		)
+		return nil, err
+	}
+	p.tokens[name] = token
+
+	return token, nil
+}
+
+func (p *Provider) profile(name string) (Profile, error) {
+	if p == nil || len(p.profiles) == 0 {
+		return Profile{}, &Error{Type: "NO_PROFILES", Message: "no auth profile is configured; set K6_MCP_AUTH_PROFILES to the path of an auth profiles file"}
+	}
+	profile, ok := p.profiles[name]
+	if !ok {
+		names := make([]string, 0, len(p.profiles))
+		for name := range p.profiles {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return Profile{}, &Error{Type: "UNKNOWN_PROFILE", Message: fmt.Sprintf("unknown auth profile %q; available profiles: %s", name, strings.Join(names, ", "))}
+	}
+	return profile, nil
+}
+
+// tokenResponse is the response of token endpoints, successful or not.
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	TokenType        string `json:"token_type"`
+	ExpiresIn        int64  `json:"expires_in"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// requestToken requests a token from the token endpoint of the profile.
+func (p *Provider) requestToken(ctx context.Context, profile Profile) (*Token, error) {
+	secret, err := secretValue(profile.ClientSecret, profile.ClientSecretEnv)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{"grant_type": {profile.GrantType}}
+	if profile.GrantType == GrantPassword {
+		password, err := secretValue(profile.Password, profile.PasswordEnv)
+		if err != nil {
+			return nil, err
+		}
+		form.Set("username", profile.Username)
+		form.Set("password", password)
+	}
+	if profile.Scope != "" {
+		form.Set("scope", profile.Scope)
+	}
+	if profile.Audience != "" {
+		form.Set("audience", profile.Audience)
+	}
+	if profile.ClientAuth == "post" {
+		form.Set("client_id", profile.ClientID)
+		if secret != "" {
+			form.Set("client_secret", secret)
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, profile.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, &Error{Type: "TOKEN_ERROR", Message: "failed to build token request", Cause: err}
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if profile.ClientAuth == "basic" {
+		req.SetBasicAuth(url.QueryEscape(profile.ClientID), url.QueryEscape(secret))
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, &Error{Type: "TOKEN_ERROR", Message: "token request failed", Cause: err}
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return nil, &Error{Type: "TOKEN_ERROR", Message: "failed to read token response", Cause: err}
+	}
+
+	var response tokenResponse
+	decodeErr := json.Unmarshal(body, &response)
+	if resp.StatusCode != http.StatusOK || response.Error != "" {
+		message := fmt.Sprintf("token endpoint responded with %s", resp.Status)
+		if response.Error != "" {
+			message += ": " + response.Error
+			if response.ErrorDescription != "" {
+				message += " (" + response.ErrorDescription + ")"
+			}
+		}
+		return nil, &Error{Type: "TOKEN_ERROR", Message: message}
+	}
+	if decodeErr != nil || response.AccessToken == "" {
+		return nil, &Error{Type: "TOKEN_ERROR", Message: "token response holds no access_token", Cause: decodeErr}
+	}
+
+	token := &Token{
+		AccessToken: response.AccessToken,
+		TokenType:   response.TokenType,
+		EnvVar:      profile.EnvVar,
+	}
+	if response.ExpiresIn > 0 {
+		token.ExpiresAt = time.Now().Add(time.Duration(response.ExpiresIn) * time.Second).UTC()
+	}
+
+	return token, nil
+}
+
+// secretValue returns the value of a secret, set in the profile or read from the server
+// environment variable env.
+func secretValue(value, env string) (string, error) {
+	if env == "" {
+		return value, nil
+	}
+	value, ok := os.LookupEnv(env)
+	if !ok || value == "" {
+		return "", &Error{Type: "CONFIG_ERROR", Message: fmt.Sprintf("the server environment variable %s holding the secret is not set", env)}
+	}
+	return value, nil
+}
+
+// isLoopback reports whether the host is the local host.
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
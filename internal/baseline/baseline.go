@@ -0,0 +1,277 @@
+// Package baseline stores performance baselines for named tests, and compares new runs
+// against them to gate deployments on performance regressions.
+package baseline
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/oleiade/k6-mcp/internal/jsonfile"
+	"github.com/oleiade/k6-mcp/internal/runner"
+)
+
+const (
+	// DefaultTolerancePercent is the default relative degradation allowed for latency and throughput.
+	DefaultTolerancePercent = 10.0
+	// DefaultMaxErrorRateIncrease is the default error rate increase allowed, in percentage points.
+	DefaultMaxErrorRateIncrease = 1.0
+	// storeFileName is the name of the file baselines are stored in.
+	storeFileName = "baselines.json"
+)
+
+// ErrBaselineNotFound is returned when no baseline exists for a test.
+var ErrBaselineNotFound = errors.New("baseline not found")
+
+// testNamePattern matches valid test names.
+var testNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9 _.:/-]{0,127}$`)
+
+// Baseline is the reference performance of a named test.
+type Baseline struct {
+	TestName  string             `json:"test_name"`
+	Summary   runner.TestSummary `json:"summary"`
+	Notes     string             `json:"notes,omitempty"`
+	CreatedAt time.Time          `json:"created_at"`
+}
+
+// Store persists baselines in a JSON file.
+type Store struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewStore creates a Store keeping its baselines in dir.
+func NewStore(dir string) *Store {
+	return &Store{path: filepath.Join(dir, storeFileName)}
+}
+
+// Set records summary as the baseline of the named test, replacing any previous one.
+func (s *Store) Set(testName string, summary runner.TestSummary, notes string) (*Baseline, error) {
+	if !testNamePattern.MatchString(testName) {
+		return nil, fmt.Errorf("invalid test name %q: use up to 128 letters, digits, spaces and '_.:/-'", testName)
+	}
+	if summary.TotalRequests <= 0 {
+		return nil, fmt.Errorf("the summary has no requests; a baseline needs a run that made HTTP requests")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	baselines, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+
+	b := &Baseline{
+		TestName:  testName,
+		Summary:   summary,
+		Notes:     notes,
+		CreatedAt: time.Now().UTC(),
+	}
+	baselines[testName] = b
+
+	if err := s.save(baselines); err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// Get returns the baseline of the named test, or ErrBaselineNotFound.
+func (s *Store) Get(testName string) (*Baseline, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	baselines, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+
+	b, ok := baselines[testName]
+	if !ok {
+		return nil, fmt.Errorf("%w for test %q", ErrBaselineNotFound, testName)
+	}
+
+	return b, nil
+}
+
+// Import records a baseline read from another data directory, unless the test already
+// has a baseline and replace is not set. It reports whether the baseline was imported.
+func (s *Store) Import(b *Baseline, replace bool) (bool, error) {
+	if !testNamePattern.MatchString(b.TestName) {
+		return false, fmt.Errorf("invalid test name %q: use up to 128 letters, digits, spaces and '_.:/-'", b.TestName)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	baselines, err := s.load()
+	if err != nil {
+		return false, err
+	}
+	if _, exists := baselines[b.TestName]; exists && !replace {
+		return false, nil
+	}
+
+	baselines[b.TestName] = b
+	if err := s.save(baselines); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// List returns the names of the tests having a baseline, sorted.
+func (s *Store) List() ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	baselines, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(baselines))
+	for name := range baselines {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names, nil
+}
+
+func (s *Store) load() (map[string]*Baseline, error) {
+	baselines := make(map[string]*Baseline)
+	if err := jsonfile.Load(s.path, &baselines); err != nil {
+		return nil, fmt.Errorf("failed to read baselines: %w", err)
+	}
+
+	return baselines, nil
+}
+
+// save atomically replaces the baselines file.
+func (s *Store) save(baselines map[string]*Baseline) error {
+	if err := jsonfile.SaveAtomic(s.path, baselines); err != nil {
+		return fmt.Errorf("failed to write baselines: %w", err)
+	}
+
+	return nil
+}
+
+// Tolerance defines how much a run may degrade compared to its baseline.
+type Tolerance struct {
+	// Percent is the relative degradation allowed for response times (increase) and
+	// request rate (decrease).
+	Percent float64 `json:"percent"`
+
+	// MaxErrorRateIncrease is the error rate increase allowed, in percentage points.
+	MaxErrorRateIncrease float64 `json:"max_error_rate_increase"`
+}
+
+// DefaultTolerance returns the default tolerance.
+func DefaultTolerance() Tolerance {
+	return Tolerance{
+		Percent:              DefaultTolerancePercent,
+		MaxErrorRateIncrease: DefaultMaxErrorRateIncrease,
+	}
+}
+
+// MetricComparison compares a metric of a run to its baseline value.
+type MetricComparison struct {
+	Metric        string  `json:"metric"`
+	Baseline      float64 `json:"baseline"`
+	Current       float64 `json:"current"`
+	ChangePercent float64 `json:"change_percent"`
+	Limit         float64 `json:"limit"`
+	Passed        bool    `json:"passed"`
+}
+
+// Comparison is the outcome of comparing a run to its baseline.
+type Comparison struct {
+	TestName  string             `json:"test_name"`
+	Passed    bool               `json:"passed"`
+	Gate      string             `json:"gate"` // "pass" or "fail"
+	Tolerance Tolerance          `json:"tolerance"`
+	Metrics   []MetricComparison `json:"metrics"`
+	Failures  []string           `json:"failures,omitempty"`
+	Baseline  *Baseline          `json:"baseline"`
+}
+
+// Compare compares the current run summary to the baseline.
+func Compare(b *Baseline, current runner.TestSummary, tolerance Tolerance) *Comparison {
+	factor := tolerance.Percent / 100
+
+	metrics := []MetricComparison{
+		upperBound("avg_response_time_ms", b.Summary.AvgResponseTime, current.AvgResponseTime, b.Summary.AvgResponseTime*(1+factor)),
+		upperBound("p95_response_time_ms", b.Summary.P95ResponseTime, current.P95ResponseTime, b.Summary.P95ResponseTime*(1+factor)),
+		lowerBound("request_rate_per_second", b.Summary.RequestRate, current.RequestRate, b.Summary.RequestRate*(1-factor)),
+		upperBound("error_rate_percent", errorRate(b.Summary), errorRate(current), errorRate(b.Summary)+tolerance.MaxErrorRateIncrease),
+	}
+
+	comparison := &Comparison{
+		TestName:  b.TestName,
+		Passed:    true,
+		Gate:      "pass",
+		Tolerance: tolerance,
+		Metrics:   metrics,
+		Baseline:  b,
+	}
+
+	for _, m := range metrics {
+		if !m.Passed {
+			comparison.Passed = false
+			comparison.Gate = "fail"
+			comparison.Failures = append(comparison.Failures,
+				fmt.Sprintf("%s regressed: %.2f vs baseline %.2f (limit %.2f)", m.Metric, m.Current, m.Baseline, m.Limit))
+		}
+	}
+
+	return comparison
+}
+
+// upperBound compares a metric that must not exceed limit.
+func upperBound(metric string, baseline, current, limit float64) MetricComparison {
+	return MetricComparison{
+		Metric:        metric,
+		Baseline:      baseline,
+		Current:       current,
+		ChangePercent: changePercent(baseline, current),
+		Limit:         limit,
+		Passed:        current <= limit,
+	}
+}
+
+// lowerBound compares a metric that must not fall below limit.
+func lowerBound(metric string, baseline, current, limit float64) MetricComparison {
+	return MetricComparison{
+		Metric:        metric,
+		Baseline:      baseline,
+		Current:       current,
+		ChangePercent: changePercent(baseline, current),
+		Limit:         limit,
+		Passed:        current >= limit,
+	}
+}
+
+// changePercent returns the relative change from baseline to current, rounded to two decimals.
+func changePercent(baseline, current float64) float64 {
+	if baseline == 0 {
+		return 0
+	}
+
+	return math.Round((current-baseline)/baseline*10000) / 100
+}
+
+// errorRate returns the percentage of failed requests of a run.
+func errorRate(summary runner.TestSummary) float64 {
+	if summary.TotalRequests == 0 {
+		return 0
+	}
+
+	return float64(summary.FailedRequests) / float64(summary.TotalRequests) * 100
+}
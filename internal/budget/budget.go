@@ -0,0 +1,312 @@
+// Package budget bounds the size of tool responses, so that clients with small context
+// windows are not flooded by large results: responses over the budget have their low-value
+// fields, such as the raw output of k6 and lists of guidance, shortened, and are stored in
+// full as artifacts.
+package budget
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/oleiade/k6-mcp/internal/artifacts"
+)
+
+// BytesPerToken is the number of bytes of a response estimated to make a token.
+const BytesPerToken = 4
+
+// TruncationField is the field of the responses noting their truncation.
+const TruncationField = "truncation"
+
+// lowValueFields are the fields shortened, wherever they appear in a response, when it
+// exceeds the budget: the raw output of processes and lists of guidance, which the rest of
+// the response summarizes.
+var lowValueFields = map[string]bool{
+	"stdout":          true,
+	"stderr":          true,
+	"recommendations": true,
+	"next_steps":      true,
+	"notes":           true,
+}
+
+// levels are the successive limits applied to the low-value fields until a response fits:
+// text fields keep their last keepBytes bytes, where processes write their summary, and
+// lists their first keepItems items.
+var levels = []struct {
+	keepBytes int
+	keepItems int
+}{
+	{keepBytes: 2048, keepItems: 3},
+	{keepBytes: 0, keepItems: 0},
+}
+
+// Budget bounds the size of tool responses.
+type Budget struct {
+	// MaxTokens is the estimated number of tokens of responses, beyond which they are
+	// truncated; 0 disables truncation.
+	MaxTokens int
+	// Artifacts stores the complete responses that were truncated, if set.
+	Artifacts *artifacts.Store
+}
+
+// MaxBytes returns the size of responses matching MaxTokens.
+func (b Budget) MaxBytes() int {
+	return b.MaxTokens * BytesPerToken
+}
+
+var (
+	mu      sync.RWMutex
+	current Budget
+)
+
+// Set sets the budget of the tool responses.
+func Set(budget Budget) {
+	mu.Lock()
+	defer mu.Unlock()
+	current = budget
+}
+
+// Current returns the budget of the tool responses; by default, responses are not truncated.
+func Current() Budget {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current
+}
+
+// EstimateTokens returns the estimated number of tokens of a response of size bytes.
+func EstimateTokens(size int) int {
+	return (size + BytesPerToken - 1) / BytesPerToken
+}
+
+// Truncation notes the truncation of a response. Bytes and EstimatedTokens are the size of
+// the shortened response, without the note.
+type Truncation struct {
+	OriginalBytes   int `json:"original_bytes"`
+	Bytes           int `json:"bytes"`
+	EstimatedTokens int `json:"estimated_tokens"`
+	BudgetTokens    int `json:"budget_tokens"`
+	// Fields are the paths of the fields shortened, such as "stdout" or "stages[1].notes".
+	Fields []string `json:"fields"`
+	// ArtifactID is the ID of the artifact holding the complete response.
+	ArtifactID string `json:"artifact_id,omitempty"`
+	Note       string `json:"note"`
+}
+
+// Fit returns the JSON response of the tool shortened to fit the budget, and the note of its
+// truncation added to it, or the response as is with a nil Truncation when it fits, or when
+// it has no low-value fields to shorten. Responses that still exceed the budget once their
+// low-value fields are emptied are returned so, rather than losing results.
+func (b Budget) Fit(tool, response string) (string, *Truncation) {
+	maxBytes := b.MaxBytes()
+	if maxBytes <= 0 || len(response) <= maxBytes {
+		return response, nil
+	}
+	if _, ok := decodeObject([]byte(response)); !ok {
+		return response, nil
+	}
+
+	var shortened bytes.Buffer
+	var fields []string
+	for _, level := range levels {
+		t := &trimmer{keepBytes: level.keepBytes, keepItems: level.keepItems}
+		trimmed := t.walk(json.RawMessage(response), "")
+		shortened.Reset()
+		if err := json.Indent(&shortened, trimmed, "", "  "); err != nil {
+			return response, nil
+		}
+		fields = t.fields
+		if shortened.Len() <= maxBytes {
+			break
+		}
+	}
+	if len(fields) == 0 {
+		return response, nil
+	}
+
+	truncation := &Truncation{
+		OriginalBytes:   len(response),
+		Bytes:           shortened.Len(),
+		EstimatedTokens: EstimateTokens(shortened.Len()),
+		BudgetTokens:    b.MaxTokens,
+		Fields:          fields,
+		Note:            fmt.Sprintf("The response exceeded the budget of %d tokens, so the fields listed were shortened.", b.MaxTokens),
+	}
+	if b.Artifacts != nil {
+		artifact, err := b.Artifacts.Save(artifacts.Artifact{
+			Name:        tool + "-response.json",
+			Kind:        artifacts.KindToolResponse,
+			ContentType: "application/json",
+			RunID:       runID(response),
+			Source:      tool,
+		}, strings.NewReader(response))
+		if err == nil {
+			truncation.ArtifactID = artifact.ID
+			truncation.Note += fmt.Sprintf(" The complete response is the artifact %s: read it in chunks with get_artifact.", artifact.ID)
+		}
+	}
+
+	members, ok := decodeObject(shortened.Bytes())
+	if !ok {
+		return response, nil
+	}
+	note, err := json.Marshal(truncation)
+	if err != nil {
+		return response, nil
+	}
+	members = append(members, member{key: TruncationField, value: note})
+
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, encodeObject(members), "", "  "); err != nil {
+		return response, nil
+	}
+
+	return indented.String(), truncation
+}
+
+// runID returns the run_id of the response, if any, to attach its artifact to the run.
+func runID(response string) int {
+	var fields struct {
+		RunID int `json:"run_id"`
+	}
+	_ = json.Unmarshal([]byte(response), &fields)
+	return fields.RunID
+}
+
+// trimmer shortens the low-value fields of a response to a level.
+type trimmer struct {
+	keepBytes int
+	keepItems int
+	// fields are the paths of the fields shortened.
+	fields []string
+}
+
+// walk returns the JSON value with its low-value fields shortened, recording their paths.
+func (t *trimmer) walk(raw json.RawMessage, path string) json.RawMessage {
+	value := bytes.TrimSpace(raw)
+	if len(value) == 0 {
+		return raw
+	}
+
+	switch value[0] {
+	case '{':
+		members, ok := decodeObject(value)
+		if !ok {
+			return raw
+		}
+		for i, m := range members {
+			memberPath := m.key
+			if path != "" {
+				memberPath = path + "." + m.key
+			}
+			if lowValueFields[m.key] {
+				if shortened, ok := t.shorten(m.value); ok {
+					members[i].value = shortened
+					t.fields = append(t.fields, memberPath)
+					continue
+				}
+			}
+			members[i].value = t.walk(m.value, memberPath)
+		}
+		return encodeObject(members)
+	case '[':
+		var items []json.RawMessage
+		if err := json.Unmarshal(value, &items); err != nil {
+			return raw
+		}
+		for i, item := range items {
+			items[i] = t.walk(item, path+"["+strconv.Itoa(i)+"]")
+		}
+		encoded, err := json.Marshal(items)
+		if err != nil {
+			return raw
+		}
+		return encoded
+	}
+
+	return raw
+}
+
+// shorten returns the value of a low-value field shortened to the level, and whether it was.
+func (t *trimmer) shorten(raw json.RawMessage) (json.RawMessage, bool) {
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		if len(text) <= t.keepBytes {
+			return raw, false
+		}
+		start := len(text) - t.keepBytes
+		for start < len(text) && !utf8.RuneStart(text[start]) {
+			start++
+		}
+		shortened := fmt.Sprintf("[%d bytes truncated]", start)
+		if start < len(text) {
+			shortened += "\n" + text[start:]
+		}
+		encoded, err := json.Marshal(shortened)
+		return encoded, err == nil
+	}
+
+	var items []json.RawMessage
+	if err := json.Unmarshal(raw, &items); err == nil {
+		if len(items) <= t.keepItems {
+			return raw, false
+		}
+		encoded, err := json.Marshal(items[:t.keepItems])
+		return encoded, err == nil
+	}
+
+	return raw, false
+}
+
+// member is a member of a JSON object, whose order is kept.
+type member struct {
+	key   string
+	value json.RawMessage
+}
+
+// decodeObject decodes the members of a JSON object, in order.
+func decodeObject(raw []byte) ([]member, bool) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, false
+	}
+
+	members := []member{}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, false
+		}
+		key, ok := tok.(string)
+		if !ok {
+			return nil, false
+		}
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, false
+		}
+		members = append(members, member{key: key, value: value})
+	}
+
+	return members, true
+}
+
+// encodeObject encodes the members as a JSON object.
+func encodeObject(members []member) json.RawMessage {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, m := range members {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(m.key)
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(m.value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes()
+}
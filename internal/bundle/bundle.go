@@ -0,0 +1,493 @@
+// Package bundle packs the test assets the server keeps for a project, namely the
+// revisions of its scripts, its run defaults, baselines and run history, together with
+// fixture files, into a portable tarball, and unpacks such tarballs into the stores of
+// another data directory, to move them between machines or commit them to a repository.
+package bundle
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/oleiade/k6-mcp/internal/archive"
+	"github.com/oleiade/k6-mcp/internal/baseline"
+	"github.com/oleiade/k6-mcp/internal/buildinfo"
+	"github.com/oleiade/k6-mcp/internal/defaults"
+	"github.com/oleiade/k6-mcp/internal/history"
+	"github.com/oleiade/k6-mcp/internal/security"
+	"github.com/oleiade/k6-mcp/internal/workspace"
+)
+
+const (
+	// FormatVersion is the version of the layout of bundles. Bundles of later versions are
+	// rejected.
+	FormatVersion = 1
+	// MaxBundleBytes is the maximum size of bundles, compressed, and of their content,
+	// uncompressed.
+	MaxBundleBytes = 50 << 20
+
+	manifestName  = "manifest.json"
+	defaultsName  = "defaults.json"
+	baselinesName = "baselines.json"
+	runsName      = "runs.json"
+	scriptsDir    = "scripts/"
+	fixturesDir   = "fixtures/"
+)
+
+// Manifest describes the content of a bundle.
+type Manifest struct {
+	FormatVersion int       `json:"format_version"`
+	Project       string    `json:"project,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+	ServerVersion string    `json:"server_version"`
+	// Projects are the projects whose defaults the bundle holds, Scripts the scripts whose
+	// revisions it holds, and Baselines the tests whose baselines it holds.
+	Projects  []string `json:"projects,omitempty"`
+	Scripts   []string `json:"scripts,omitempty"`
+	Baselines []string `json:"baselines,omitempty"`
+	Runs      int      `json:"runs"`
+	Fixtures  []string `json:"fixtures,omitempty"`
+}
+
+// Stores are the stores assets are exported from and imported into.
+type Stores struct {
+	Scripts   *history.Store
+	Baselines *baseline.Store
+	Defaults  *defaults.Store
+}
+
+// Selection selects the assets of an export.
+type Selection struct {
+	// Project selects the defaults of the project, and, unless Scripts is set, the scripts
+	// and tests named after it: the project name itself, or names starting with the project
+	// name and a slash, e.g. checkout/smoke for the checkout project. Without a project
+	// and scripts, every asset is selected.
+	Project string
+	// Scripts selects the scripts and tests of these names.
+	Scripts []string
+	// Fixtures are the files to bundle, such as data files and local modules, keyed by
+	// their path relative to the scripts.
+	Fixtures map[string]string
+}
+
+// includes reports whether the selection includes the script or test of the name.
+func (s Selection) includes(name string) bool {
+	switch {
+	case len(s.Scripts) > 0:
+		for _, script := range s.Scripts {
+			if script == name {
+				return true
+			}
+		}
+		return false
+	case s.Project != "":
+		return name == s.Project || strings.HasPrefix(name, s.Project+"/")
+	default:
+		return true
+	}
+}
+
+// ImportResult is the outcome of an import: the assets imported and the assets skipped,
+// which already existed.
+type ImportResult struct {
+	Manifest         *Manifest         `json:"manifest"`
+	Projects         []string          `json:"projects,omitempty"`
+	SkippedProjects  []string          `json:"skipped_projects,omitempty"`
+	Scripts          []string          `json:"scripts,omitempty"`
+	SkippedScripts   []string          `json:"skipped_scripts,omitempty"`
+	Baselines        []string          `json:"baselines,omitempty"`
+	SkippedBaselines []string          `json:"skipped_baselines,omitempty"`
+	Runs             int               `json:"runs"`
+	SkippedRuns      int               `json:"skipped_runs"`
+	Fixtures         map[string]string `json:"-"`
+}
+
+// Export packs the selected assets into a gzipped tarball, and returns it with its manifest.
+func Export(stores Stores, selection Selection) ([]byte, *Manifest, error) {
+	if err := workspace.ValidateFiles(selection.Fixtures); err != nil {
+		return nil, nil, err
+	}
+
+	manifest := &Manifest{
+		FormatVersion: FormatVersion,
+		Project:       selection.Project,
+		CreatedAt:     time.Now().UTC(),
+		ServerVersion: buildinfo.Version,
+	}
+	entries := make(map[string][]byte)
+
+	projects, err := exportDefaults(stores.Defaults, selection)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(projects) > 0 {
+		for name := range projects {
+			manifest.Projects = append(manifest.Projects, name)
+		}
+		if entries[defaultsName], err = json.MarshalIndent(projects, "", "  "); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	names, err := stores.Scripts.List()
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, name := range names {
+		if !selection.includes(name) {
+			continue
+		}
+		script, err := stores.Scripts.Export(name)
+		if err != nil {
+			return nil, nil, err
+		}
+		manifest.Scripts = append(manifest.Scripts, name)
+		if entries[fmt.Sprintf("%s%03d.json", scriptsDir, len(manifest.Scripts))], err = json.MarshalIndent(script, "", "  "); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	tests, err := stores.Baselines.List()
+	if err != nil {
+		return nil, nil, err
+	}
+	baselines := make(map[string]*baseline.Baseline)
+	for _, name := range tests {
+		if !selection.includes(name) {
+			continue
+		}
+		if baselines[name], err = stores.Baselines.Get(name); err != nil {
+			return nil, nil, err
+		}
+		manifest.Baselines = append(manifest.Baselines, name)
+	}
+	if len(baselines) > 0 {
+		if entries[baselinesName], err = json.MarshalIndent(baselines, "", "  "); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// Runs of unnamed scripts only go with whole exports
+	runs, err := stores.Scripts.ExportRuns(func(script string) bool {
+		return (script != "" || (selection.Project == "" && len(selection.Scripts) == 0)) && selection.includes(script)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if manifest.Runs = len(runs); manifest.Runs > 0 {
+		if entries[runsName], err = json.MarshalIndent(runs, "", "  "); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	for name, content := range selection.Fixtures {
+		cleaned, _ := workspace.CleanRelativePath(name)
+		manifest.Fixtures = append(manifest.Fixtures, cleaned)
+		entries[fixturesDir+cleaned] = []byte(content)
+	}
+
+	if len(entries) == 0 {
+		return nil, nil, errors.New("nothing to export: no defaults, scripts, baselines, runs or fixtures are selected")
+	}
+	sort.Strings(manifest.Projects)
+	sort.Strings(manifest.Fixtures)
+
+	if entries[manifestName], err = json.MarshalIndent(manifest, "", "  "); err != nil {
+		return nil, nil, err
+	}
+
+	data, err := pack(entries, manifest.CreatedAt)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(data) > MaxBundleBytes {
+		return nil, nil, fmt.Errorf("the bundle exceeds %d bytes: export fewer scripts or fixtures", MaxBundleBytes)
+	}
+
+	return data, manifest, nil
+}
+
+// exportDefaults returns the defaults of the selected project, or of every project when no
+// project nor script is selected.
+func exportDefaults(store *defaults.Store, selection Selection) (map[string]*defaults.Defaults, error) {
+	var names []string
+	switch {
+	case selection.Project != "":
+		names = []string{selection.Project}
+	case len(selection.Scripts) == 0:
+		var err error
+		if names, err = store.Projects(); err != nil {
+			return nil, err
+		}
+	}
+
+	projects := make(map[string]*defaults.Defaults)
+	for _, name := range names {
+		d, err := store.Project(name)
+		if err != nil {
+			return nil, err
+		}
+		if d != nil {
+			projects[name] = d
+		}
+	}
+
+	return projects, nil
+}
+
+// Import unpacks a bundle into the stores. Assets that already exist are skipped, unless
+// replace is set. Fixtures are returned, for the caller to write them.
+func Import(stores Stores, data []byte, replace bool) (*ImportResult, error) {
+	if len(data) > MaxBundleBytes {
+		return nil, fmt.Errorf("the bundle exceeds %d bytes", MaxBundleBytes)
+	}
+
+	entries, err := unpack(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest Manifest
+	if err := decodeEntry(entries, manifestName, &manifest); err != nil {
+		return nil, err
+	}
+	if manifest.FormatVersion < 1 || manifest.FormatVersion > FormatVersion {
+		return nil, fmt.Errorf("unsupported bundle format version %d: this server reads versions up to %d", manifest.FormatVersion, FormatVersion)
+	}
+
+	result := &ImportResult{Manifest: &manifest, Fixtures: make(map[string]string)}
+
+	// Decode every asset before importing any, so that malformed bundles change nothing
+	projects := make(map[string]*defaults.Defaults)
+	if _, ok := entries[defaultsName]; ok {
+		if err := decodeEntry(entries, defaultsName, &projects); err != nil {
+			return nil, err
+		}
+		for name, d := range projects {
+			if err := d.Validate(); err != nil {
+				return nil, fmt.Errorf("invalid defaults of project %q: %w", name, err)
+			}
+		}
+	}
+	baselines := make(map[string]*baseline.Baseline)
+	if _, ok := entries[baselinesName]; ok {
+		if err := decodeEntry(entries, baselinesName, &baselines); err != nil {
+			return nil, err
+		}
+	}
+	var runs []history.RunRecord
+	if _, ok := entries[runsName]; ok {
+		if err := decodeEntry(entries, runsName, &runs); err != nil {
+			return nil, err
+		}
+	}
+	var scripts []*history.Script
+	for name := range entries {
+		if !strings.HasPrefix(name, scriptsDir) {
+			continue
+		}
+		var script history.Script
+		if err := decodeEntry(entries, name, &script); err != nil {
+			return nil, err
+		}
+		scripts = append(scripts, &script)
+	}
+	sort.Slice(scripts, func(i, j int) bool { return scripts[i].Name < scripts[j].Name })
+	for name, content := range entries {
+		if fixture, ok := strings.CutPrefix(name, fixturesDir); ok {
+			result.Fixtures[fixture] = string(content)
+		}
+	}
+	if err := workspace.ValidateFiles(result.Fixtures); err != nil {
+		return nil, fmt.Errorf("invalid fixtures: %w", err)
+	}
+
+	for _, name := range sortedKeys(projects) {
+		if current, err := stores.Defaults.Project(name); err != nil {
+			return nil, err
+		} else if current != nil && !replace {
+			result.SkippedProjects = append(result.SkippedProjects, name)
+			continue
+		}
+		if _, err := stores.Defaults.SetProject(name, projects[name], true); err != nil {
+			return nil, fmt.Errorf("failed to import the defaults of project %q: %w", name, err)
+		}
+		result.Projects = append(result.Projects, name)
+	}
+
+	for _, script := range scripts {
+		imported, err := stores.Scripts.Import(script, replace)
+		if err != nil {
+			return nil, fmt.Errorf("failed to import script %q: %w", script.Name, err)
+		}
+		if imported {
+			result.Scripts = append(result.Scripts, script.Name)
+		} else {
+			result.SkippedScripts = append(result.SkippedScripts, script.Name)
+		}
+	}
+
+	for _, name := range sortedKeys(baselines) {
+		b := baselines[name]
+		b.TestName = name
+		imported, err := stores.Baselines.Import(b, replace)
+		if err != nil {
+			return nil, fmt.Errorf("failed to import the baseline of test %q: %w", name, err)
+		}
+		if imported {
+			result.Baselines = append(result.Baselines, name)
+		} else {
+			result.SkippedBaselines = append(result.SkippedBaselines, name)
+		}
+	}
+
+	if result.Runs, err = stores.Scripts.ImportRuns(runs); err != nil {
+		return nil, fmt.Errorf("failed to import runs: %w", err)
+	}
+	result.SkippedRuns = len(runs) - result.Runs
+
+	return result, nil
+}
+
+// WriteFixtures writes the fixtures of an import under the dir directory, relative to
+// root, and returns the paths written. Existing files are only overwritten when replace is
+// set: otherwise, no fixture is written when any of them exists.
+func WriteFixtures(root, dir string, fixtures map[string]string, replace bool) ([]string, error) {
+	base, err := workspace.CleanRelativePath(dir)
+	if err != nil {
+		return nil, fmt.Errorf("invalid fixtures directory %q: %w", dir, err)
+	}
+
+	names := make([]string, 0, len(fixtures))
+	var existing []string
+	for _, name := range sortedKeys(fixtures) {
+		cleaned, err := workspace.CleanRelativePath(name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid fixture path %q: %w", name, err)
+		}
+		target := path.Join(base, cleaned)
+		if _, err := os.Stat(filepath.Join(root, filepath.FromSlash(target))); err == nil {
+			existing = append(existing, target)
+		}
+		names = append(names, target)
+	}
+	if len(existing) > 0 && !replace {
+		return nil, fmt.Errorf("fixtures already exist, set replace to overwrite them: %s", strings.Join(existing, ", "))
+	}
+
+	var written []string
+	for i, name := range sortedKeys(fixtures) {
+		target, err := archive.WriteToWorkspace(root, names[i], []byte(fixtures[name]), replace)
+		if err != nil {
+			return written, fmt.Errorf("failed to write fixture %s: %w", names[i], err)
+		}
+		written = append(written, target)
+	}
+
+	return written, nil
+}
+
+// pack writes the entries into a gzipped tarball, sorted by name.
+func pack(entries map[string][]byte, modTime time.Time) ([]byte, error) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+
+	for _, name := range sortedKeys(entries) {
+		content := entries[name]
+		header := &tar.Header{
+			Name:    name,
+			Mode:    security.SecureFileMode,
+			Size:    int64(len(content)),
+			ModTime: modTime,
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", name, err)
+		}
+		if _, err := tw.Write(content); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", name, err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// unpack reads the regular files of a gzipped tarball, up to MaxBundleBytes in total.
+func unpack(data []byte) (map[string][]byte, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("the bundle is not a gzipped tarball: %w", err)
+	}
+	defer gz.Close()
+
+	entries := make(map[string][]byte)
+	tr := tar.NewReader(gz)
+	var total int64
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the bundle: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		name := path.Clean(header.Name)
+		if _, err := workspace.CleanRelativePath(name); err != nil {
+			return nil, fmt.Errorf("invalid bundle entry %q: %w", header.Name, err)
+		}
+
+		total += header.Size
+		if total > MaxBundleBytes {
+			return nil, fmt.Errorf("the content of the bundle exceeds %d bytes", MaxBundleBytes)
+		}
+		content, err := io.ReadAll(io.LimitReader(tr, header.Size))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		entries[name] = content
+	}
+
+	return entries, nil
+}
+
+// decodeEntry decodes the JSON entry of the bundle into v.
+func decodeEntry(entries map[string][]byte, name string, v any) error {
+	content, ok := entries[name]
+	if !ok {
+		return fmt.Errorf("the bundle has no %s: it was not exported by export_workspace", name)
+	}
+	if err := json.Unmarshal(content, v); err != nil {
+		return fmt.Errorf("invalid %s: %w", name, err)
+	}
+	return nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,94 @@
+package bundle
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/oleiade/k6-mcp/internal/security"
+)
+
+func TestWriteFixtures(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	fixtures := map[string]string{
+		"data/users.csv":  "username\nalice\n",
+		"data/items.json": "[]",
+	}
+
+	written, err := WriteFixtures(root, "fixtures", fixtures, false)
+	if err != nil {
+		t.Fatalf("WriteFixtures: %v", err)
+	}
+	if len(written) != 2 {
+		t.Fatalf("WriteFixtures() wrote %v, want 2 files", written)
+	}
+
+	target := filepath.Join(root, "fixtures", "data", "users.csv")
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(target)
+		if err != nil {
+			t.Fatalf("Stat: %v", err)
+		}
+		if mode := info.Mode().Perm(); mode != security.SecureFileMode {
+			t.Errorf("file mode = %o, want %o", mode, security.SecureFileMode)
+		}
+		info, err = os.Stat(filepath.Dir(target))
+		if err != nil {
+			t.Fatalf("Stat: %v", err)
+		}
+		if mode := info.Mode().Perm(); mode != security.SecureDirMode {
+			t.Errorf("directory mode = %o, want %o", mode, security.SecureDirMode)
+		}
+	}
+
+	// Existing files are kept unless replace is set, and nothing is written
+	if err := os.WriteFile(target, []byte("edited"), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if err := os.Remove(filepath.Join(root, "fixtures", "data", "items.json")); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	if _, err := WriteFixtures(root, "fixtures", fixtures, false); err == nil {
+		t.Fatal("WriteFixtures() over an existing file returned no error")
+	}
+	if got, _ := os.ReadFile(target); string(got) != "edited" {
+		t.Errorf("existing fixture was overwritten: %q", got)
+	}
+	if _, err := os.Stat(filepath.Join(root, "fixtures", "data", "items.json")); !os.IsNotExist(err) {
+		t.Errorf("a fixture was written although another one exists: %v", err)
+	}
+
+	if _, err := WriteFixtures(root, "fixtures", fixtures, true); err != nil {
+		t.Fatalf("WriteFixtures with replace: %v", err)
+	}
+	if got, _ := os.ReadFile(target); string(got) != fixtures["data/users.csv"] {
+		t.Errorf("fixture was not replaced: %q", got)
+	}
+}
+
+func TestWriteFixturesRejectsEscapingPaths(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		dir  string
+		file string
+	}{
+		{name: "directory", dir: "../outside", file: "users.csv"},
+		{name: "fixture", dir: "fixtures", file: "../../users.csv"},
+		{name: "absolute", dir: "fixtures", file: "/etc/passwd"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if _, err := WriteFixtures(t.TempDir(), tt.dir, map[string]string{tt.file: "x"}, true); err == nil {
+				t.Error("WriteFixtures() returned no error")
+			}
+		})
+	}
+}
@@ -0,0 +1,271 @@
+// Package catalog registers named tests: a durable identity, with an owner, a description
+// and tags, linked to the named script the test runs. Runs and baselines are keyed by test
+// name, so that they stay attached to the test as its script is edited, or replaced.
+package catalog
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/oleiade/k6-mcp/internal/jsonfile"
+)
+
+const (
+	// MaxTags is the number of tags a test may have.
+	MaxTags = 20
+	// maxDescriptionLength is the maximum length of the descriptions of tests.
+	maxDescriptionLength = 2000
+	// storeFileName is the name of the file, within the data directory, tests are stored in.
+	storeFileName = "tests.json"
+)
+
+// ErrTestNotFound is returned when no test is registered under a name.
+var ErrTestNotFound = errors.New("test not found")
+
+// ErrTestExists is returned when registering a test under a name already taken.
+var ErrTestExists = errors.New("test already registered")
+
+var (
+	// namePattern matches valid test and script names, as the history and baselines do.
+	namePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9 _.:/-]{0,127}$`)
+	// tagPattern matches valid tags.
+	tagPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_.:/-]{0,63}$`)
+)
+
+// Test is a named test.
+type Test struct {
+	Name        string   `json:"name"`
+	Owner       string   `json:"owner,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	// Script is the name of the script the test runs, whose revisions the history keeps.
+	Script    string    `json:"script_name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Changes are the changes of an update of a test. Nil fields are left unchanged.
+type Changes struct {
+	Owner       *string
+	Description *string
+	Tags        *[]string
+	Script      *string
+}
+
+// Filter filters tests. Zero fields match every test.
+type Filter struct {
+	Owner string
+	// Tag matches the tests having the tag.
+	Tag string
+	// Script matches the tests linked to the named script.
+	Script string
+}
+
+// matches reports whether the test matches the filter.
+func (f *Filter) matches(test *Test) bool {
+	switch {
+	case f.Owner != "" && !strings.EqualFold(test.Owner, f.Owner):
+		return false
+	case f.Tag != "" && !slices.Contains(test.Tags, strings.ToLower(f.Tag)):
+		return false
+	case f.Script != "" && test.Script != f.Script:
+		return false
+	}
+	return true
+}
+
+// Store persists tests in a JSON file.
+type Store struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewStore creates a Store keeping its tests in dir.
+func NewStore(dir string) *Store {
+	return &Store{path: filepath.Join(dir, storeFileName)}
+}
+
+// Register registers a new test, or returns ErrTestExists when its name is taken.
+func (s *Store) Register(test Test) (*Test, error) {
+	if err := validate(&test); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tests, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	if _, exists := tests[test.Name]; exists {
+		return nil, fmt.Errorf("%w: %q", ErrTestExists, test.Name)
+	}
+
+	now := time.Now().UTC()
+	test.CreatedAt = now
+	test.UpdatedAt = now
+	tests[test.Name] = &test
+
+	if err := s.save(tests); err != nil {
+		return nil, err
+	}
+
+	return &test, nil
+}
+
+// Update applies the changes to the named test, and returns it.
+func (s *Store) Update(name string, changes Changes) (*Test, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tests, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	current, ok := tests[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrTestNotFound, name)
+	}
+
+	test := *current
+	if changes.Owner != nil {
+		test.Owner = *changes.Owner
+	}
+	if changes.Description != nil {
+		test.Description = *changes.Description
+	}
+	if changes.Tags != nil {
+		test.Tags = *changes.Tags
+	}
+	if changes.Script != nil {
+		test.Script = *changes.Script
+	}
+	if err := validate(&test); err != nil {
+		return nil, err
+	}
+
+	test.UpdatedAt = time.Now().UTC()
+	tests[name] = &test
+
+	if err := s.save(tests); err != nil {
+		return nil, err
+	}
+
+	return &test, nil
+}
+
+// Get returns the named test, or ErrTestNotFound.
+func (s *Store) Get(name string) (*Test, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tests, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+
+	test, ok := tests[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrTestNotFound, name)
+	}
+
+	return test, nil
+}
+
+// List returns the tests matching the filter, sorted by name.
+func (s *Store) List(filter Filter) ([]Test, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tests, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+
+	matching := []Test{}
+	for _, test := range tests {
+		if filter.matches(test) {
+			matching = append(matching, *test)
+		}
+	}
+	sort.Slice(matching, func(i, j int) bool { return matching[i].Name < matching[j].Name })
+
+	return matching, nil
+}
+
+// Delete unregisters the named test. Its runs and baseline are kept.
+func (s *Store) Delete(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tests, err := s.load()
+	if err != nil {
+		return err
+	}
+	if _, ok := tests[name]; !ok {
+		return fmt.Errorf("%w: %q", ErrTestNotFound, name)
+	}
+
+	delete(tests, name)
+	return s.save(tests)
+}
+
+// validate checks the fields of a test, normalizing its tags: they are lowercased, sorted
+// and deduplicated.
+func validate(test *Test) error {
+	if !namePattern.MatchString(test.Name) {
+		return fmt.Errorf("invalid test name %q: use up to 128 letters, digits, spaces and '_.:/-'", test.Name)
+	}
+	if !namePattern.MatchString(test.Script) {
+		return fmt.Errorf("invalid script name %q: use up to 128 letters, digits, spaces and '_.:/-'", test.Script)
+	}
+	if len(test.Description) > maxDescriptionLength {
+		return fmt.Errorf("the description is longer than %d characters", maxDescriptionLength)
+	}
+
+	tags := make([]string, 0, len(test.Tags))
+	for _, tag := range test.Tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if !tagPattern.MatchString(tag) {
+			return fmt.Errorf("invalid tag %q: use up to 64 lowercase letters, digits and '_.:/-'", tag)
+		}
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	tags = slices.Compact(tags)
+	if len(tags) > MaxTags {
+		return fmt.Errorf("a test has at most %d tags", MaxTags)
+	}
+	test.Tags = nil
+	if len(tags) > 0 {
+		test.Tags = tags
+	}
+
+	return nil
+}
+
+func (s *Store) load() (map[string]*Test, error) {
+	tests := make(map[string]*Test)
+	if err := jsonfile.Load(s.path, &tests); err != nil {
+		return nil, fmt.Errorf("failed to read tests: %w", err)
+	}
+
+	return tests, nil
+}
+
+// save atomically replaces the tests file.
+func (s *Store) save(tests map[string]*Test) error {
+	if err := jsonfile.SaveAtomic(s.path, tests); err != nil {
+		return fmt.Errorf("failed to write tests: %w", err)
+	}
+
+	return nil
+}
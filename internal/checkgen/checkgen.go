@@ -0,0 +1,264 @@
+// Package checkgen infers the shape of HTTP responses, from a probe request or a sample
+// response, and generates the k6 checks asserting their status, content type and key JSON
+// fields, so that hardening scripts doesn't start from boilerplate.
+package checkgen
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/oleiade/k6-mcp/internal/codegen"
+)
+
+const (
+	// DefaultMaxFields is the default number of JSON fields checks are generated for, and
+	// MaxFields its maximum.
+	DefaultMaxFields = 10
+	MaxFields        = 50
+
+	// MaxBodyBytes is the maximum size of the bodies inferred from.
+	MaxBodyBytes = 1024 * 1024 // 1MB
+
+	// maxDepth is the depth of the deepest fields checked: data.items.0.id is at depth 3.
+	maxDepth = 3
+	// maxTitleLength is the maximum length of the HTML titles checked.
+	maxTitleLength = 80
+)
+
+// Types of JSON values.
+const (
+	TypeString  = "string"
+	TypeNumber  = "number"
+	TypeBoolean = "boolean"
+	TypeObject  = "object"
+	TypeArray   = "array"
+	TypeNull    = "null"
+)
+
+var (
+	// keyPattern matches the keys that can be written in the paths of res.json() without
+	// escaping, excluding the characters of its query syntax.
+	keyPattern = regexp.MustCompile(`^[A-Za-z0-9_$-]+$`)
+	// titlePattern matches the title of HTML documents.
+	titlePattern = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+)
+
+// Response is an HTTP response checks are inferred from.
+type Response struct {
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    string            `json:"body,omitempty"`
+	// Truncated reports that the body was cut at MaxBodyBytes.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// header returns the value of the header, whatever the case of its name.
+func (r Response) header(name string) string {
+	for key, value := range r.Headers {
+		if strings.EqualFold(key, name) {
+			return value
+		}
+	}
+	return ""
+}
+
+// Field is a field of a JSON response, at its path in the syntax of res.json().
+type Field struct {
+	Path string `json:"path"`
+	Type string `json:"type"`
+}
+
+// Inference is the shape inferred from a response, and its checks.
+type Inference struct {
+	Status      int    `json:"status"`
+	ContentType string `json:"content_type,omitempty"`
+	// JSON reports whether the body is JSON, and Fields are its checked fields, the
+	// shallowest first.
+	JSON   bool    `json:"json"`
+	Fields []Field `json:"fields,omitempty"`
+	// Checks are the checks of the response, in order: status, content type, then body.
+	Checks []codegen.Check `json:"-"`
+	Notes  []string        `json:"notes,omitempty"`
+}
+
+// Infer infers the shape of the response, and the checks asserting it, checking up to
+// maxFields JSON fields.
+func Infer(response Response, maxFields int) (*Inference, error) {
+	if response.Status < 100 || response.Status > 599 {
+		return nil, fmt.Errorf("invalid status %d: expected an HTTP status between 100 and 599", response.Status)
+	}
+	if maxFields <= 0 {
+		maxFields = DefaultMaxFields
+	}
+
+	inference := &Inference{Status: response.Status}
+	inference.Checks = append(inference.Checks, codegen.StatusCheck(response.Status))
+
+	if contentType := response.header("Content-Type"); contentType != "" {
+		mediaType, _, err := mime.ParseMediaType(contentType)
+		if err != nil {
+			mediaType = strings.TrimSpace(strings.Split(contentType, ";")[0])
+		}
+		inference.ContentType = strings.ToLower(mediaType)
+		inference.Checks = append(inference.Checks, codegen.ContentTypeCheck(inference.ContentType))
+	}
+
+	body := strings.TrimSpace(response.Body)
+	switch {
+	case body == "":
+		inference.Notes = append(inference.Notes, "The response has no body: only its status and headers are checked.")
+	case isJSONType(inference.ContentType) || (inference.ContentType == "" && json.Valid([]byte(body))):
+		inferJSON(inference, body, maxFields, response.Truncated)
+	case strings.Contains(inference.ContentType, "html"):
+		if match := titlePattern.FindStringSubmatch(body); match != nil {
+			title := strings.Join(strings.Fields(match[1]), " ")
+			if title != "" && len(title) <= maxTitleLength {
+				inference.Checks = append(inference.Checks, codegen.BodyContainsCheck(title))
+			}
+		}
+	default:
+		inference.Notes = append(inference.Notes, "The body is neither JSON nor HTML: add a check of a text it must contain, e.g. r.body.includes('...').")
+	}
+
+	return inference, nil
+}
+
+// inferJSON adds the checks of the fields of the JSON body.
+func inferJSON(inference *Inference, body string, maxFields int, truncated bool) {
+	decoder := json.NewDecoder(bytes.NewReader([]byte(body)))
+	decoder.UseNumber()
+	var root any
+	if err := decoder.Decode(&root); err != nil {
+		note := "The body is not valid JSON, so no field is checked: " + err.Error() + "."
+		if truncated {
+			note = fmt.Sprintf("The body exceeds %d bytes and was truncated, so no field is checked.", MaxBodyBytes)
+		}
+		inference.Notes = append(inference.Notes, note)
+		return
+	}
+	inference.JSON = true
+
+	fields, skipped, more := jsonFields(root, maxFields)
+	inference.Fields = fields
+
+	// The type of the root is only checked when no field implies it
+	rootType := jsonType(root)
+	if rootType == TypeArray || len(fields) == 0 {
+		inference.Checks = append(inference.Checks, codegen.JSONTypeCheck("", rootType))
+	}
+	nullable := false
+	for _, field := range fields {
+		// Fields null in the sample are likely nullable: only their presence is checked
+		if field.Type == TypeNull {
+			nullable = true
+			inference.Checks = append(inference.Checks, codegen.JSONPresenceCheck(field.Path))
+			continue
+		}
+		inference.Checks = append(inference.Checks, codegen.JSONTypeCheck(field.Path, field.Type))
+	}
+
+	if more {
+		inference.Notes = append(inference.Notes, fmt.Sprintf("Only the first %d fields are checked, the shallowest first: raise max_fields, or remove the checks of fields the script doesn't rely on.", maxFields))
+	}
+	if skipped > 0 {
+		inference.Notes = append(inference.Notes, fmt.Sprintf("%d fields with keys res.json() paths can't express without escaping were left out.", skipped))
+	}
+	if nullable {
+		inference.Notes = append(inference.Notes, "Fields null in the response are only checked to be present, as their type is unknown.")
+	}
+	if rootType == TypeArray {
+		inference.Notes = append(inference.Notes, "Fields of arrays are checked on their first element, e.g. items.0.id: empty arrays fail these checks.")
+	}
+	inference.Notes = append(inference.Notes, "r.json() throws on bodies that are not JSON, which fails the iteration: where error responses are not JSON, run the JSON checks only once the status check passed.")
+}
+
+// jsonFields returns up to maxFields fields of the value, breadth first, with keys sorted,
+// along with the number of fields skipped for their keys, and whether fields were left out.
+func jsonFields(root any, maxFields int) ([]Field, int, bool) {
+	type node struct {
+		path  string
+		value any
+		depth int
+	}
+
+	var fields []Field
+	skipped := 0
+	queue := []node{{value: root}}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		if current.depth >= maxDepth {
+			continue
+		}
+
+		var children []node
+		switch value := current.value.(type) {
+		case map[string]any:
+			keys := make([]string, 0, len(value))
+			for key := range value {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				if !keyPattern.MatchString(key) {
+					skipped++
+					continue
+				}
+				children = append(children, node{path: joinPath(current.path, key), value: value[key], depth: current.depth + 1})
+			}
+		case []any:
+			// Arrays are descended into through their first element, unchecked itself, at
+			// the depth of the array
+			if len(value) > 0 {
+				queue = append(queue, node{path: joinPath(current.path, "0"), value: value[0], depth: current.depth})
+			}
+		}
+
+		for _, child := range children {
+			if len(fields) == maxFields {
+				return fields, skipped, true
+			}
+			fields = append(fields, Field{Path: child.path, Type: jsonType(child.value)})
+			queue = append(queue, child)
+		}
+	}
+
+	return fields, skipped, false
+}
+
+// joinPath returns the path of the key under the path, in the syntax of res.json().
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// jsonType returns the JSON type of a decoded value.
+func jsonType(value any) string {
+	switch value.(type) {
+	case map[string]any:
+		return TypeObject
+	case []any:
+		return TypeArray
+	case string:
+		return TypeString
+	case json.Number, float64:
+		return TypeNumber
+	case bool:
+		return TypeBoolean
+	default:
+		return TypeNull
+	}
+}
+
+// isJSONType reports whether the media type is JSON, such as application/json or
+// application/problem+json.
+func isJSONType(mediaType string) bool {
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
@@ -0,0 +1,89 @@
+package checkgen
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/oleiade/k6-mcp/internal/buildinfo"
+)
+
+// probeTimeout bounds probe requests.
+const probeTimeout = 15 * time.Second
+
+// ProbeMethods are the methods of probe requests: those reading resources, and POST, which
+// many APIs read through, such as search or GraphQL endpoints.
+var ProbeMethods = []string{http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPost}
+
+// ProbeRequest is the single request sent to infer the shape of the responses of a URL.
+type ProbeRequest struct {
+	Method  string
+	URL     string
+	Headers map[string]string
+	Body    string
+}
+
+// Validate checks the method and URL of the request.
+func (r ProbeRequest) Validate() error {
+	if !slices.Contains(ProbeMethods, strings.ToUpper(r.Method)) {
+		return fmt.Errorf("unsupported method %q: probes only send %s requests", r.Method, strings.Join(ProbeMethods, ", "))
+	}
+	u, err := url.Parse(r.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid URL %q: expected an http or https URL, e.g. https://api.example.com/users/1", r.URL)
+	}
+	return nil
+}
+
+// Probe sends the request and returns its response, with the body cut at MaxBodyBytes,
+// and the time it took. Redirects are followed, as k6 does.
+func Probe(ctx context.Context, request ProbeRequest) (Response, time.Duration, error) {
+	if err := request.Validate(); err != nil {
+		return Response{}, 0, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+
+	var body io.Reader
+	if request.Body != "" {
+		body = strings.NewReader(request.Body)
+	}
+	req, err := http.NewRequestWithContext(ctx, strings.ToUpper(request.Method), request.URL, body)
+	if err != nil {
+		return Response{}, 0, fmt.Errorf("failed to build the probe request: %w", err)
+	}
+	req.Header.Set("User-Agent", "k6-mcp/"+buildinfo.Version)
+	for name, value := range request.Headers {
+		req.Header.Set(name, value)
+	}
+
+	start := time.Now()
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return Response{}, 0, fmt.Errorf("probe request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, MaxBodyBytes+1))
+	elapsed := time.Since(start)
+	if err != nil {
+		return Response{}, elapsed, fmt.Errorf("failed to read the probe response: %w", err)
+	}
+
+	response := Response{Status: resp.StatusCode, Headers: make(map[string]string, len(resp.Header))}
+	for name, values := range resp.Header {
+		response.Headers[name] = strings.Join(values, ", ")
+	}
+	if len(data) > MaxBodyBytes {
+		data, response.Truncated = data[:MaxBodyBytes], true
+	}
+	response.Body = string(data)
+
+	return response, elapsed, nil
+}
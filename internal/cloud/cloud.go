@@ -0,0 +1,203 @@
+// Package cloud reads and updates the load tests of Grafana Cloud k6 through its REST API,
+// so that tests managed in the cloud can be validated, run and modified locally.
+package cloud
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/oleiade/k6-mcp/internal/security"
+)
+
+const (
+	// DefaultAPIURL is the base URL of the Grafana Cloud k6 API.
+	DefaultAPIURL = "https://api.k6.io"
+
+	// Scheme prefixes the references of cloud tests in script_url, e.g. k6cloud://1234.
+	Scheme = "k6cloud://"
+
+	// requestTimeout bounds API requests.
+	requestTimeout = 30 * time.Second
+	// maxResponseBytes is the maximum size of API responses other than scripts.
+	maxResponseBytes = 1024 * 1024
+)
+
+// ErrNotConfigured is returned when no API token is configured.
+var ErrNotConfigured = errors.New("the Grafana Cloud k6 API is not configured: set K6_MCP_CLOUD_TOKEN and K6_MCP_CLOUD_STACK_ID")
+
+// Config holds the settings of the Grafana Cloud k6 API.
+type Config struct {
+	// APIURL is the base URL of the API.
+	APIURL string
+	// Token is the API token, a personal or stack token of Grafana Cloud k6.
+	Token string
+	// StackID is the ID of the Grafana Cloud stack the tests belong to.
+	StackID string
+}
+
+// Error represents errors that occur while calling the API.
+type Error struct {
+	Type    string
+	Message string
+	Cause   error
+}
+
+func (e *Error) Error() string {
+	if e.Cause != nil {
+		return fmt.Sprintf("%s: %s (caused by: %v)", e.Type, e.Message, e.Cause)
+	}
+	return fmt.Sprintf("%s: %s", e.Type, e.Message)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Cause
+}
+
+// Test is a load test of Grafana Cloud k6.
+type Test struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	ProjectID int64     `json:"project_id"`
+	Created   time.Time `json:"created"`
+	Updated   time.Time `json:"updated"`
+}
+
+// Client calls the Grafana Cloud k6 API.
+type Client struct {
+	config Config
+	client *http.Client
+}
+
+// NewClient returns a client of the API configured by config. Clients without a token
+// fail every call with ErrNotConfigured.
+func NewClient(config Config) *Client {
+	if config.APIURL == "" {
+		config.APIURL = DefaultAPIURL
+	}
+	config.APIURL = strings.TrimRight(config.APIURL, "/")
+
+	return &Client{config: config, client: &http.Client{Timeout: requestTimeout}}
+}
+
+// Configured reports whether the client has the token and stack ID calls require.
+func (c *Client) Configured() bool {
+	return c != nil && c.config.Token != "" && c.config.StackID != ""
+}
+
+// ParseReference returns the test ID of a k6cloud://<test id> reference.
+func ParseReference(ref string) (int64, error) {
+	id, err := strconv.ParseInt(strings.TrimPrefix(ref, Scheme), 10, 64)
+	if err != nil || id <= 0 {
+		return 0, &Error{Type: "INVALID_REFERENCE", Message: fmt.Sprintf("invalid cloud test reference %q: expected %s<test id>, e.g. %s1234", ref, Scheme, Scheme)}
+	}
+	return id, nil
+}
+
+// Test returns the load test of the ID.
+func (c *Client) Test(ctx context.Context, id int64) (*Test, error) {
+	body, err := c.do(ctx, http.MethodGet, c.testPath(id), nil, maxResponseBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	var test Test
+	if err := json.Unmarshal(body, &test); err != nil {
+		return nil, &Error{Type: "API_ERROR", Message: "failed to decode load test", Cause: err}
+	}
+	return &test, nil
+}
+
+// Script returns the script of the load test of the ID.
+func (c *Client) Script(ctx context.Context, id int64) (string, error) {
+	body, err := c.do(ctx, http.MethodGet, c.testPath(id)+"/script", nil, security.MaxScriptSizeBytes)
+	if err != nil {
+		return "", err
+	}
+	return string(body), nil
+}
+
+// UpdateScript replaces the script of the load test of the ID.
+func (c *Client) UpdateScript(ctx context.Context, id int64, script string) error {
+	_, err := c.do(ctx, http.MethodPut, c.testPath(id)+"/script", []byte(script), maxResponseBytes)
+	return err
+}
+
+// testPath returns the API path of the load test of the ID.
+func (c *Client) testPath(id int64) string {
+	return "/cloud/v6/load_tests/" + strconv.FormatInt(id, 10)
+}
+
+// do calls the API, and returns the body of its response, of at most maxBytes.
+func (c *Client) do(ctx context.Context, method, path string, body []byte, maxBytes int64) ([]byte, error) {
+	if !c.Configured() {
+		return nil, ErrNotConfigured
+	}
+
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.config.APIURL+path, reader)
+	if err != nil {
+		return nil, &Error{Type: "API_ERROR", Message: "failed to build request", Cause: err}
+	}
+	req.Header.Set("Authorization", "Bearer "+c.config.Token)
+	req.Header.Set("X-Stack-Id", c.config.StackID)
+	req.Header.Set("User-Agent", "k6-mcp")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/octet-stream")
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, &Error{Type: "API_ERROR", Message: "failed to call the Grafana Cloud k6 API", Cause: err}
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxBytes+1))
+	if err != nil {
+		return nil, &Error{Type: "API_ERROR", Message: "failed to read the API response", Cause: err}
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, &Error{Type: "NOT_FOUND", Message: "the load test does not exist, or is not in the configured stack"}
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return nil, &Error{Type: "UNAUTHORIZED", Message: fmt.Sprintf("the API token was rejected (%s)", resp.Status)}
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		return nil, &Error{Type: "API_ERROR", Message: fmt.Sprintf("the API responded with %s%s", resp.Status, apiMessage(data))}
+	}
+	if int64(len(data)) > maxBytes {
+		return nil, &Error{Type: "TOO_LARGE", Message: fmt.Sprintf("the API response exceeds %d bytes", maxBytes)}
+	}
+
+	return data, nil
+}
+
+// apiMessage returns ": " and the message of an API error response, or "".
+func apiMessage(body []byte) string {
+	var response struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return ""
+	}
+	if response.Error.Message != "" {
+		return ": " + response.Error.Message
+	}
+	if response.Message != "" {
+		return ": " + response.Message
+	}
+	return ""
+}
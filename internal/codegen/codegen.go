@@ -0,0 +1,313 @@
+// Package codegen builds k6 scripts from a structured model of their imports, options,
+// scenarios, requests and checks. Converters and generators fill the model, and Render
+// writes it in the configured code style, so that every generated script is consistent.
+package codegen
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Executors of scenarios.
+const (
+	ExecutorSharedIterations    = "shared-iterations"
+	ExecutorPerVUIterations     = "per-vu-iterations"
+	ExecutorConstantVUs         = "constant-vus"
+	ExecutorRampingVUs          = "ramping-vus"
+	ExecutorConstantArrivalRate = "constant-arrival-rate"
+	ExecutorRampingArrivalRate  = "ramping-arrival-rate"
+)
+
+// Script is the model of a k6 script.
+type Script struct {
+	// Comment is the header comment of the script, such as the source it was converted from.
+	Comment string
+
+	// BaseURL is the URL requests starting with it are written relative to, so that the
+	// target is set with the BASE_URL environment variable: `${BASE_URL}/path`.
+	BaseURL string
+
+	// Imports are the imports the requests, checks and groups don't imply, such as the
+	// modules of Raw expressions. The k6 and k6/http imports are added as needed.
+	Imports []Import
+
+	Options Options
+
+	// Flow is the flow of the default function.
+	Flow Flow
+
+	// Scenarios are the named scenarios of the options, each running its own flow.
+	Scenarios []Scenario
+}
+
+// Import is an import statement.
+type Import struct {
+	Module string
+	// Default is the name the default export is bound to, and Names the named exports
+	// imported.
+	Default string
+	Names   []string
+}
+
+// Options is the options of scripts. Zero fields are left out.
+type Options struct {
+	VUs        int
+	Duration   string
+	Iterations int
+	Stages     []Stage
+	// Thresholds are the threshold expressions of metrics, e.g. http_req_duration: p(95)<500.
+	Thresholds map[string][]string
+	Tags       map[string]string
+}
+
+// Stage is a stage of the ramping of VUs or of arrival rates.
+type Stage struct {
+	Duration string
+	Target   int
+}
+
+// Scenario is a named scenario, running its flow with its executor.
+type Scenario struct {
+	// Name is the name of the scenario, and of the exported function running its flow.
+	Name     string
+	Executor string
+
+	VUs             int
+	Iterations      int
+	Duration        string
+	Rate            int
+	TimeUnit        string
+	PreAllocatedVUs int
+	MaxVUs          int
+	StartVUs        int
+	StartRate       int
+	Stages          []Stage
+	StartTime       string
+	Tags            map[string]string
+
+	Flow Flow
+}
+
+// Flow is a sequence of requests, in groups.
+type Flow struct {
+	Groups []Group
+}
+
+// Group is a group of requests. Requests of unnamed groups are written outside groups.
+type Group struct {
+	Name     string
+	Requests []Request
+}
+
+// Request is an HTTP request, with the checks of its response.
+type Request struct {
+	// Comment is written above the request.
+	Comment string
+	Method  string
+	URL     string
+	Headers map[string]string
+	// Body is the request body, sent as is. JSONBody, when set instead, is sent serialized
+	// with JSON.stringify, with a JSON content type.
+	Body     string
+	JSONBody Value
+	Tags     map[string]string
+	Checks   []Check
+	// Extractions assign values of the response to variables, for later requests to send.
+	Extractions []Extraction
+	// Substitutions replace values of the URL, headers and body with expressions, such as
+	// the variables of earlier extractions.
+	Substitutions []Substitution
+	// ThinkTime is the pause after the request, in seconds.
+	ThinkTime float64
+}
+
+// Extraction assigns a value of the response res to a variable. The variables of a flow
+// are declared at the beginning of its function, so that every group can read them.
+type Extraction struct {
+	Variable string
+	// Expression is the JavaScript expression of the value, e.g. res.json('token').
+	Expression Value
+}
+
+// Substitution replaces the occurrences of a value with a JavaScript expression, written
+// in template literals: `/users/${userId}`.
+type Substitution struct {
+	Value      string
+	Expression string
+}
+
+// Check is a check of responses, named following the style.
+type Check struct {
+	// Name describes the check, e.g. "status is 200".
+	Name string
+	// Condition is the JavaScript expression of the response r the check asserts.
+	Condition Value
+}
+
+// StatusCheck returns the check of the status of responses.
+func StatusCheck(status int) Check {
+	return Check{Name: "status is " + strconv.Itoa(status), Condition: Raw("r.status === " + strconv.Itoa(status))}
+}
+
+// BodyContainsCheck returns the check of responses holding the text.
+func BodyContainsCheck(text string) Check {
+	return Check{Name: "body contains " + text, Condition: Concat{Raw("r.body.includes("), String(text), Raw(")")}}
+}
+
+// ContentTypeCheck returns the check of responses of the media type, e.g. application/json.
+func ContentTypeCheck(mediaType string) Check {
+	return Check{
+		Name:      "content type is " + mediaType,
+		Condition: Concat{Raw("(r.headers["), String("Content-Type"), Raw("] || "), String(""), Raw(").includes("), String(mediaType), Raw(")")},
+	}
+}
+
+// JSONTypeCheck returns the check of the type of the value at the path of JSON responses,
+// in the syntax of res.json(), or of the whole body with an empty path. The types are those
+// of JSON: string, number, boolean, object, array and null.
+func JSONTypeCheck(path, jsonType string) Check {
+	subject, value := "body", Value(Raw("r.json()"))
+	if path != "" {
+		subject, value = path, Concat{Raw("r.json("), String(path), Raw(")")}
+	}
+
+	var condition Value
+	switch jsonType {
+	case "array":
+		condition = Concat{Raw("Array.isArray("), value, Raw(")")}
+	case "object":
+		condition = Concat{Raw("typeof "), value, Raw(" === "), String("object"), Raw(" && !Array.isArray("), value, Raw(") && "), value, Raw(" !== null")}
+	case "null":
+		condition = Concat{value, Raw(" === null")}
+	default:
+		condition = Concat{Raw("typeof "), value, Raw(" === "), String(jsonType)}
+	}
+
+	article := "a "
+	if jsonType == "array" || jsonType == "object" {
+		article = "an "
+	}
+	if jsonType == "null" {
+		article = ""
+	}
+	return Check{Name: subject + " is " + article + jsonType, Condition: condition}
+}
+
+// JSONPresenceCheck returns the check of JSON responses holding a value, of any type, at
+// the path, in the syntax of res.json().
+func JSONPresenceCheck(path string) Check {
+	return Check{Name: "has " + path, Condition: Concat{Raw("r.json("), String(path), Raw(") !== undefined")}}
+}
+
+// JSONPathExtraction returns the extraction of the value at the path of JSON responses,
+// in the syntax of res.json(): data.items.0.id.
+func JSONPathExtraction(variable, path string) Extraction {
+	return Extraction{Variable: variable, Expression: Concat{Raw("res.json("), String(path), Raw(")")}}
+}
+
+// SelectorExtraction returns the extraction of an attribute of the first element of HTML
+// responses matching the CSS selector, such as the value of a hidden input.
+func SelectorExtraction(variable, selector, attribute string) Extraction {
+	return Extraction{Variable: variable, Expression: Concat{
+		Raw("res.html().find("), String(selector), Raw(").first().attr("), String(attribute), Raw(")"),
+	}}
+}
+
+// HeaderExtraction returns the extraction of a response header.
+func HeaderExtraction(variable, header string) Extraction {
+	return Extraction{Variable: variable, Expression: Concat{Raw("res.headers["), String(header), Raw("]")}}
+}
+
+// RegexpExtraction returns the extraction of the first group of the regular expression in
+// the response body. The expression must be valid in both Go and JavaScript.
+func RegexpExtraction(variable, pattern string) Extraction {
+	literal := "/" + strings.ReplaceAll(pattern, "/", `\/`) + "/"
+	return Extraction{Variable: variable, Expression: Raw("(res.body.match(" + literal + ") || [])[1]")}
+}
+
+// Validate checks that the script can be rendered.
+func (s *Script) Validate() error {
+	if len(s.Flow.Groups) == 0 && len(s.Scenarios) == 0 {
+		return fmt.Errorf("the script has neither a flow nor scenarios")
+	}
+
+	if err := s.Flow.validate("the default function"); err != nil {
+		return err
+	}
+
+	return s.validateScenarios(true)
+}
+
+// validateScenarios checks the names and executors of the scenarios, and their flows
+// when they must have one.
+func (s *Script) validateScenarios(flows bool) error {
+	seen := make(map[string]bool)
+	for _, scenario := range s.Scenarios {
+		if !identifierPattern.MatchString(scenario.Name) || scenario.Name == "default" {
+			return fmt.Errorf("scenario name %q must be a JavaScript identifier", scenario.Name)
+		}
+		if seen[scenario.Name] {
+			return fmt.Errorf("duplicate scenario %q", scenario.Name)
+		}
+		seen[scenario.Name] = true
+
+		if scenario.Executor == "" {
+			return fmt.Errorf("scenario %q has no executor", scenario.Name)
+		}
+		if !flows {
+			continue
+		}
+		if len(scenario.Flow.Groups) == 0 {
+			return fmt.Errorf("scenario %q has no flow", scenario.Name)
+		}
+		if err := scenario.Flow.validate("scenario " + strconv.Quote(scenario.Name)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validate checks the requests of the flow.
+func (f Flow) validate(what string) error {
+	for _, group := range f.Groups {
+		for _, request := range group.Requests {
+			if request.URL == "" {
+				return fmt.Errorf("a request of %s has no URL", what)
+			}
+			if request.Body != "" && request.JSONBody != nil {
+				return fmt.Errorf("request %s %s of %s has both a body and a JSON body", request.Method, request.URL, what)
+			}
+			if request.ThinkTime < 0 {
+				return fmt.Errorf("request %s %s of %s has a negative think time", request.Method, request.URL, what)
+			}
+			for _, check := range request.Checks {
+				if strings.TrimSpace(check.Name) == "" || check.Condition == nil {
+					return fmt.Errorf("a check of request %s %s of %s has no name or condition", request.Method, request.URL, what)
+				}
+			}
+			for _, extraction := range request.Extractions {
+				if !identifierPattern.MatchString(extraction.Variable) || extraction.Expression == nil {
+					return fmt.Errorf("an extraction of request %s %s of %s has no expression, or a variable that is not a JavaScript identifier", request.Method, request.URL, what)
+				}
+			}
+			for _, substitution := range request.Substitutions {
+				if substitution.Value == "" || substitution.Expression == "" {
+					return fmt.Errorf("a substitution of request %s %s of %s has no value or expression", request.Method, request.URL, what)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// method returns the upper-case method of the request, GET by default.
+func (r Request) method() string {
+	if r.Method == "" {
+		return http.MethodGet
+	}
+	return strings.ToUpper(r.Method)
+}
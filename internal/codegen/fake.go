@@ -0,0 +1,204 @@
+package codegen
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// UtilsModule is the jslib module of the helpers fake values are generated with.
+const UtilsModule = "https://jslib.k6.io/k6-utils/1.4.0/index.js"
+
+// Kinds of fake values.
+const (
+	FakeUUID      = "uuid"
+	FakeEmail     = "email"
+	FakeUsername  = "username"
+	FakeName      = "name"
+	FakePhone     = "phone"
+	FakeString    = "string"
+	FakeInt       = "int"
+	FakeTimestamp = "timestamp"
+)
+
+// FakeKinds are the kinds of fake values, as accepted by ParseFake.
+var FakeKinds = []string{FakeUUID, FakeEmail, FakeUsername, FakeName, FakePhone, FakeString, FakeInt, FakeTimestamp}
+
+// fakeNames are the first names fake names are picked from.
+var fakeNames = []string{"Alice", "Bob", "Carol", "Dave", "Erin", "Frank", "Grace", "Heidi", "Ivan", "Judy"}
+
+// Fake is a value generated on each iteration, such as a unique email or a random UUID, so
+// that payloads don't collide with the server-side uniqueness checks and caches a replayed
+// example value runs into.
+type Fake struct {
+	Kind string
+	// Min and Max bound the values of int fakes, and Max the length of string fakes.
+	Min, Max int
+}
+
+// ParseFake parses a schema hint: a kind, with the bounds of ints or the length of strings
+// after a colon, such as "email", "int:18-99" or "string:12".
+func ParseFake(hint string) (Fake, error) {
+	kind, bounds, _ := strings.Cut(strings.ToLower(strings.TrimSpace(hint)), ":")
+
+	fake := Fake{Kind: kind}
+	switch kind {
+	case FakeInt:
+		fake.Min, fake.Max = 1, 1000
+		if bounds != "" {
+			low, high, ok := strings.Cut(bounds, "-")
+			lowest, errLow := strconv.Atoi(low)
+			highest, errHigh := strconv.Atoi(high)
+			if !ok || errLow != nil || errHigh != nil || lowest > highest {
+				return Fake{}, fmt.Errorf("invalid int bounds %q: expected min-max, e.g. int:18-99", bounds)
+			}
+			fake.Min, fake.Max = lowest, highest
+		}
+	case FakeString:
+		fake.Max = 10
+		if bounds != "" {
+			length, err := strconv.Atoi(bounds)
+			if err != nil || length < 1 || length > 1024 {
+				return Fake{}, fmt.Errorf("invalid string length %q: expected 1 to 1024, e.g. string:12", bounds)
+			}
+			fake.Max = length
+		}
+	case FakeUUID, FakeEmail, FakeUsername, FakeName, FakePhone, FakeTimestamp:
+		if bounds != "" {
+			return Fake{}, fmt.Errorf("%s values take no parameter", kind)
+		}
+	default:
+		return Fake{}, fmt.Errorf("unknown kind %q: expected one of %s", kind, strings.Join(FakeKinds, ", "))
+	}
+
+	return fake, nil
+}
+
+func (f Fake) write(w *writer) {
+	switch f.Kind {
+	case FakeUUID:
+		w.write("uuidv4()")
+	case FakeEmail:
+		w.write("`user_${randomString(10)}@example.com`")
+	case FakeUsername:
+		w.write("`user_${randomString(8)}`")
+	case FakeName:
+		names := make([]string, len(fakeNames))
+		for i, name := range fakeNames {
+			names[i] = w.style.Quote(name)
+		}
+		w.write("randomItem([" + strings.Join(names, ", ") + "])")
+	case FakePhone:
+		w.write("`+1555${randomIntBetween(1000000, 9999999)}`")
+	case FakeString:
+		w.write("randomString(" + strconv.Itoa(max(f.Max, 1)) + ")")
+	case FakeInt:
+		w.write("randomIntBetween(" + strconv.Itoa(f.Min) + ", " + strconv.Itoa(f.Max) + ")")
+	default:
+		w.write("new Date().toISOString()")
+	}
+}
+
+// helpers returns the helpers of UtilsModule the fake is generated with.
+func (f Fake) helpers() []string {
+	switch f.Kind {
+	case FakeUUID:
+		return []string{"uuidv4"}
+	case FakeEmail, FakeUsername, FakeString:
+		return []string{"randomString"}
+	case FakeName:
+		return []string{"randomItem"}
+	case FakePhone, FakeInt:
+		return []string{"randomIntBetween"}
+	default:
+		return nil
+	}
+}
+
+// inferredFakes are the kinds of fakes of the fields, by lower-case name, Parameterize
+// infers.
+var inferredFakes = map[string]string{
+	"email": FakeEmail, "email_address": FakeEmail, "emailaddress": FakeEmail, "mail": FakeEmail,
+	"uuid": FakeUUID, "guid": FakeUUID,
+	"username": FakeUsername, "user_name": FakeUsername,
+	"first_name": FakeName, "firstname": FakeName, "last_name": FakeName, "lastname": FakeName, "full_name": FakeName, "fullname": FakeName,
+	"phone": FakePhone, "phone_number": FakePhone, "phonenumber": FakePhone, "mobile": FakePhone,
+}
+
+// Parameterize returns the value with the fields of its objects replaced by fakes: those
+// the hints name, by key or by dotted path such as user.email, and, when infer is set, the
+// string fields named as emails, UUIDs, usernames, names or phone numbers.
+func Parameterize(value Value, hints map[string]Fake, infer bool) Value {
+	return parameterize(value, "", hints, infer)
+}
+
+func parameterize(value Value, path string, hints map[string]Fake, infer bool) Value {
+	switch v := value.(type) {
+	case Object:
+		object := make(Object, len(v))
+		for i, field := range v {
+			fieldPath := field.Key
+			if path != "" {
+				fieldPath = path + "." + field.Key
+			}
+
+			if fake, ok := hints[fieldPath]; ok {
+				object[i] = Field{Key: field.Key, Value: fake}
+				continue
+			}
+			if fake, ok := hints[field.Key]; ok {
+				object[i] = Field{Key: field.Key, Value: fake}
+				continue
+			}
+			if _, isString := field.Value.(String); infer && isString {
+				if kind, ok := inferredFakes[strings.ToLower(field.Key)]; ok {
+					object[i] = Field{Key: field.Key, Value: Fake{Kind: kind}}
+					continue
+				}
+			}
+			object[i] = Field{Key: field.Key, Value: parameterize(field.Value, fieldPath, hints, infer)}
+		}
+		return object
+	case Array:
+		array := make(Array, len(v))
+		for i, element := range v {
+			array[i] = parameterize(element, path, hints, infer)
+		}
+		return array
+	default:
+		return value
+	}
+}
+
+// fakeHelpers adds the helpers of UtilsModule the fakes of the value are generated with.
+func fakeHelpers(value Value, helpers map[string]bool) {
+	switch v := value.(type) {
+	case Fake:
+		for _, helper := range v.helpers() {
+			helpers[helper] = true
+		}
+	case Object:
+		for _, field := range v {
+			fakeHelpers(field.Value, helpers)
+		}
+	case Array:
+		for _, element := range v {
+			fakeHelpers(element, helpers)
+		}
+	case Concat:
+		for _, part := range v {
+			fakeHelpers(part, helpers)
+		}
+	}
+}
+
+// sortedHelpers returns the helpers, sorted.
+func sortedHelpers(helpers map[string]bool) []string {
+	names := make([]string, 0, len(helpers))
+	for name := range helpers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
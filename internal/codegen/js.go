@@ -0,0 +1,197 @@
+package codegen
+
+import (
+	"encoding/json"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/oleiade/k6-mcp/internal/style"
+)
+
+// identifierPattern matches the JavaScript identifiers object keys are written bare as.
+var identifierPattern = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+// Value is a JavaScript value of the generated code: String, Number, Bool, Raw, Concat,
+// Array, Object or Fake.
+type Value interface {
+	write(w *writer)
+}
+
+// String is a string literal, quoted in the quotes of the style.
+type String string
+
+// Number is a number literal.
+type Number float64
+
+// Bool is a boolean literal.
+type Bool bool
+
+// Raw is an expression written as is, such as __ENV.BASE_URL or (r) => r.status === 200.
+type Raw string
+
+// Concat is an expression made of values written one after the other, such as a call
+// with a string argument: Concat{Raw("r.body.includes("), String("ok"), Raw(")")}.
+type Concat []Value
+
+// Array is an array literal.
+type Array []Value
+
+// Field is a property of an object literal.
+type Field struct {
+	Key   string
+	Value Value
+}
+
+// Object is an object literal, whose properties are written in order.
+type Object []Field
+
+// StringMap returns the object literal of a map of strings, sorted by key.
+func StringMap(m map[string]string) Object {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	object := make(Object, 0, len(keys))
+	for _, key := range keys {
+		object = append(object, Field{Key: key, Value: String(m[key])})
+	}
+	return object
+}
+
+// JSON returns the literal of a value decoded from JSON, such as a recorded request body.
+// Object keys are sorted, and json.Number values are written as is.
+func JSON(v any) Value {
+	switch v := v.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		object := make(Object, 0, len(keys))
+		for _, key := range keys {
+			object = append(object, Field{Key: key, Value: JSON(v[key])})
+		}
+		return object
+	case []any:
+		array := make(Array, len(v))
+		for i, element := range v {
+			array[i] = JSON(element)
+		}
+		return array
+	case string:
+		return String(v)
+	case json.Number:
+		return Raw(v.String())
+	case float64:
+		return Number(v)
+	case bool:
+		return Bool(v)
+	default:
+		return Raw("null")
+	}
+}
+
+func (s String) write(w *writer) {
+	w.write(w.style.Quote(string(s)))
+}
+
+func (n Number) write(w *writer) {
+	w.write(strconv.FormatFloat(float64(n), 'f', -1, 64))
+}
+
+func (b Bool) write(w *writer) {
+	w.write(strconv.FormatBool(bool(b)))
+}
+
+func (r Raw) write(w *writer) {
+	w.write(string(r))
+}
+
+func (c Concat) write(w *writer) {
+	for _, value := range c {
+		value.write(w)
+	}
+}
+
+func (a Array) write(w *writer) {
+	if len(a) == 0 {
+		w.write("[]")
+		return
+	}
+
+	w.write("[\n")
+	w.indent++
+	for _, value := range a {
+		w.startLine()
+		value.write(w)
+		w.write(",\n")
+	}
+	w.indent--
+	w.startLine()
+	w.write("]")
+}
+
+func (o Object) write(w *writer) {
+	if len(o) == 0 {
+		w.write("{}")
+		return
+	}
+
+	w.write("{\n")
+	w.indent++
+	for _, field := range o {
+		w.startLine()
+		if identifierPattern.MatchString(field.Key) {
+			w.write(field.Key)
+		} else {
+			w.write(w.style.Quote(field.Key))
+		}
+		w.write(": ")
+		field.Value.write(w)
+		w.write(",\n")
+	}
+	w.indent--
+	w.startLine()
+	w.write("}")
+}
+
+// writer writes the generated code, indented with two spaces.
+type writer struct {
+	style  style.Style
+	b      strings.Builder
+	indent int
+}
+
+// write writes text as is.
+func (w *writer) write(text string) {
+	w.b.WriteString(text)
+}
+
+// startLine writes the indentation of a new line.
+func (w *writer) startLine() {
+	w.b.WriteString(strings.Repeat("  ", w.indent))
+}
+
+// line writes an indented line.
+func (w *writer) line(text string) {
+	if text == "" {
+		w.b.WriteString("\n")
+		return
+	}
+	w.startLine()
+	w.b.WriteString(text + "\n")
+}
+
+// value writes a value, as the end of the line started with prefix, followed by suffix.
+func (w *writer) value(prefix string, value Value, suffix string) {
+	w.startLine()
+	w.write(prefix)
+	value.write(w)
+	w.write(suffix + "\n")
+}
@@ -0,0 +1,536 @@
+package codegen
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/oleiade/k6-mcp/internal/style"
+)
+
+// Modules of the imports the requests, checks and groups imply.
+const (
+	moduleK6     = "k6"
+	moduleHTTP   = "k6/http"
+	moduleOption = "k6/options"
+)
+
+// Render writes the script in the style, after validating it.
+func Render(script *Script, st style.Style) (string, error) {
+	if err := script.Validate(); err != nil {
+		return "", err
+	}
+
+	w := &writer{style: st}
+
+	if script.Comment != "" {
+		for _, line := range strings.Split(strings.TrimSpace(script.Comment), "\n") {
+			w.line(strings.TrimSpace("// " + line))
+		}
+		w.line("")
+	}
+
+	typed := st.Language == style.LanguageTypeScript && st.Modules == style.ModulesESM
+	for _, imp := range script.imports(typed) {
+		w.line(st.Import(imp.Module, imp.Default, imp.Names...))
+	}
+	w.line("")
+
+	if script.BaseURL != "" {
+		w.line("const BASE_URL = __ENV.BASE_URL || " + st.Quote(script.BaseURL) + ";")
+		w.line("")
+	}
+
+	script.writeOptions(w, typed)
+
+	if len(script.Flow.Groups) > 0 {
+		w.line("")
+		script.writeFunction(w, "default", script.Flow)
+	}
+	for _, scenario := range script.Scenarios {
+		w.line("")
+		script.writeFunction(w, scenario.Name, scenario.Flow)
+	}
+
+	return w.b.String(), nil
+}
+
+// imports returns the imports of the script: k6/http for requests, k6 for checks, groups
+// and think times, the k6-utils helpers of fake values, the Options type of typed scripts,
+// then the script's own imports.
+func (s *Script) imports(typed bool) []Import {
+	var requests, checks, groups, sleeps bool
+	helpers := make(map[string]bool)
+	for _, flow := range s.flows() {
+		for _, group := range flow.Groups {
+			groups = groups || group.Name != ""
+			for _, request := range group.Requests {
+				requests = true
+				checks = checks || len(request.Checks) > 0
+				sleeps = sleeps || request.ThinkTime > 0
+				if request.JSONBody != nil {
+					fakeHelpers(request.JSONBody, helpers)
+				}
+			}
+		}
+	}
+
+	var imports []Import
+	if requests {
+		imports = append(imports, Import{Module: moduleHTTP, Default: "http"})
+	}
+
+	k6 := Import{Module: moduleK6}
+	for _, name := range []struct {
+		name string
+		used bool
+	}{{"check", checks}, {"group", groups}, {"sleep", sleeps}} {
+		if name.used {
+			k6.Names = append(k6.Names, name.name)
+		}
+	}
+	if len(k6.Names) > 0 {
+		imports = append(imports, k6)
+	}
+
+	if len(helpers) > 0 {
+		imports = append(imports, Import{Module: UtilsModule, Names: sortedHelpers(helpers)})
+	}
+
+	if typed {
+		imports = append(imports, Import{Module: moduleOption, Names: []string{"Options"}})
+	}
+
+	return append(imports, s.Imports...)
+}
+
+// RenderOptions writes the options statement of the script alone, for scripts whose
+// functions are written by hand, such as the exported functions of weighted scenarios.
+// The scenarios of the script need no flow.
+func RenderOptions(script *Script, st style.Style) (string, error) {
+	if err := script.validateScenarios(false); err != nil {
+		return "", err
+	}
+
+	w := &writer{style: st}
+	script.writeOptions(w, st.Language == style.LanguageTypeScript && st.Modules == style.ModulesESM)
+	return w.b.String(), nil
+}
+
+// writeOptions writes the exported options statement, typed with the Options type of
+// typed scripts.
+func (s *Script) writeOptions(w *writer, typed bool) {
+	optionsName := "options"
+	if typed {
+		optionsName = "options: Options"
+	}
+	w.value(strings.Replace(w.style.Export("options"), "options", optionsName, 1), s.options(), ";")
+}
+
+// flows returns the flows of the default function and of the scenarios.
+func (s *Script) flows() []Flow {
+	flows := []Flow{s.Flow}
+	for _, scenario := range s.Scenarios {
+		flows = append(flows, scenario.Flow)
+	}
+	return flows
+}
+
+// options returns the options object of the script.
+func (s *Script) options() Object {
+	options := Object{}
+	if s.Options.VUs > 0 {
+		options = append(options, Field{"vus", Number(s.Options.VUs)})
+	}
+	if s.Options.Duration != "" {
+		options = append(options, Field{"duration", String(s.Options.Duration)})
+	}
+	if s.Options.Iterations > 0 {
+		options = append(options, Field{"iterations", Number(s.Options.Iterations)})
+	}
+	if len(s.Options.Stages) > 0 {
+		options = append(options, Field{"stages", stagesArray(s.Options.Stages)})
+	}
+
+	if len(s.Scenarios) > 0 {
+		scenarios := Object{}
+		for _, scenario := range s.Scenarios {
+			scenarios = append(scenarios, Field{scenario.Name, scenario.object()})
+		}
+		options = append(options, Field{"scenarios", scenarios})
+	}
+
+	if len(s.Options.Thresholds) > 0 {
+		thresholds := Object{}
+		for _, metric := range sortedKeys(s.Options.Thresholds) {
+			expressions := Array{}
+			for _, expression := range s.Options.Thresholds[metric] {
+				expressions = append(expressions, String(expression))
+			}
+			thresholds = append(thresholds, Field{metric, expressions})
+		}
+		options = append(options, Field{"thresholds", thresholds})
+	}
+
+	if len(s.Options.Tags) > 0 {
+		options = append(options, Field{"tags", StringMap(s.Options.Tags)})
+	}
+
+	return options
+}
+
+// object returns the options object of the scenario, running its exported function.
+func (sc Scenario) object() Object {
+	object := Object{
+		{"executor", String(sc.Executor)},
+		{"exec", String(sc.Name)},
+	}
+
+	for _, field := range []struct {
+		key   string
+		value int
+	}{
+		{"vus", sc.VUs},
+		{"iterations", sc.Iterations},
+		{"startVUs", sc.StartVUs},
+		{"rate", sc.Rate},
+		{"startRate", sc.StartRate},
+		{"preAllocatedVUs", sc.PreAllocatedVUs},
+		{"maxVUs", sc.MaxVUs},
+	} {
+		if field.value > 0 {
+			object = append(object, Field{field.key, Number(field.value)})
+		}
+	}
+
+	for _, field := range []struct{ key, value string }{
+		{"duration", sc.Duration},
+		{"timeUnit", sc.TimeUnit},
+		{"startTime", sc.StartTime},
+	} {
+		if field.value != "" {
+			object = append(object, Field{field.key, String(field.value)})
+		}
+	}
+
+	if len(sc.Stages) > 0 {
+		object = append(object, Field{"stages", stagesArray(sc.Stages)})
+	}
+	if len(sc.Tags) > 0 {
+		object = append(object, Field{"tags", StringMap(sc.Tags)})
+	}
+
+	return object
+}
+
+// stagesArray returns the array of stages.
+func stagesArray(stages []Stage) Array {
+	array := make(Array, len(stages))
+	for i, stage := range stages {
+		array[i] = Object{{"duration", String(stage.Duration)}, {"target", Number(stage.Target)}}
+	}
+	return array
+}
+
+// writeFunction writes the exported function running the flow.
+func (s *Script) writeFunction(w *writer, name string, flow Flow) {
+	switch {
+	case w.style.Modules == style.ModulesCommonJS:
+		w.line("module.exports." + name + " = function () {")
+	case name == "default":
+		w.line("export default function () {")
+	default:
+		w.line("export function " + name + "() {")
+	}
+
+	w.indent++
+	if variables := flow.variables(); len(variables) > 0 {
+		w.line("let " + strings.Join(variables, ", ") + ";")
+		w.line("")
+	}
+
+	declared := false
+	for i, group := range flow.Groups {
+		if i > 0 {
+			w.line("")
+		}
+		if group.Name == "" {
+			s.writeRequests(w, group.Requests, &declared)
+			continue
+		}
+
+		w.line("group(" + w.style.Quote(group.Name) + ", function () {")
+		w.indent++
+		groupDeclared := false
+		s.writeRequests(w, group.Requests, &groupDeclared)
+		w.indent--
+		w.line("});")
+	}
+	w.indent--
+
+	if w.style.Modules == style.ModulesCommonJS {
+		w.line("};")
+	} else {
+		w.line("}")
+	}
+}
+
+// writeRequests writes the requests, with their checks, extractions and think times,
+// declaring the response variable unless it is already declared in the scope.
+func (s *Script) writeRequests(w *writer, requests []Request, declared *bool) {
+	for i, request := range requests {
+		if i > 0 {
+			w.line("")
+		}
+		if request.Comment != "" {
+			for _, line := range strings.Split(strings.TrimSpace(request.Comment), "\n") {
+				w.line(strings.TrimSpace("// " + line))
+			}
+		}
+
+		assignment := "res = "
+		if !*declared {
+			assignment = "let res = "
+			*declared = true
+		}
+
+		callee, args := s.call(request)
+		w.startLine()
+		w.write(assignment + callee + "(")
+		for j, arg := range args {
+			if j > 0 {
+				w.write(", ")
+			}
+			arg.write(w)
+		}
+		w.write(");\n")
+
+		writeChecks(w, request.Checks)
+
+		for _, extraction := range request.Extractions {
+			w.value(extraction.Variable+" = ", extraction.Expression, ";")
+		}
+
+		if request.ThinkTime > 0 {
+			w.value("sleep(", Number(request.ThinkTime), ");")
+		}
+	}
+}
+
+// RenderChecks writes the check() call of the checks of the response res alone, for
+// scripts whose requests are written by hand.
+func RenderChecks(checks []Check, st style.Style) string {
+	w := &writer{style: st}
+	writeChecks(w, checks)
+	return w.b.String()
+}
+
+// writeChecks writes the check() call of the checks of the response res, if any.
+func writeChecks(w *writer, checks []Check) {
+	if len(checks) == 0 {
+		return
+	}
+
+	w.line("check(res, {")
+	w.indent++
+	for _, check := range checks {
+		w.startLine()
+		w.write(w.style.Quote(w.style.CheckName(check.Name)) + ": (r) => ")
+		check.Condition.write(w)
+		w.write(",\n")
+	}
+	w.indent--
+	w.line("});")
+}
+
+// call returns the function of the k6/http module sending the request, and its arguments.
+func (s *Script) call(request Request) (string, []Value) {
+	url := s.url(request.URL, request.Substitutions)
+
+	headers := request.Headers
+	if request.JSONBody != nil && !hasHeader(headers, "Content-Type") {
+		headers = make(map[string]string, len(request.Headers)+1)
+		for name, value := range request.Headers {
+			headers[name] = value
+		}
+		headers["Content-Type"] = "application/json"
+	}
+
+	params := Object{}
+	if len(headers) > 0 {
+		params = append(params, Field{"headers", substituteValue(StringMap(headers), request.Substitutions)})
+	}
+	if len(request.Tags) > 0 {
+		params = append(params, Field{"tags", StringMap(request.Tags)})
+	}
+
+	var body Value = Raw("null")
+	switch {
+	case request.JSONBody != nil:
+		body = Concat{Raw("JSON.stringify("), substituteValue(request.JSONBody, request.Substitutions), Raw(")")}
+	case request.Body != "":
+		body = substitute(request.Body, request.Substitutions)
+	}
+	hasBody := request.JSONBody != nil || request.Body != ""
+
+	withParams := func(args ...Value) []Value {
+		if len(params) > 0 {
+			return append(args, params)
+		}
+		return args
+	}
+
+	switch method := request.method(); method {
+	case http.MethodGet, http.MethodHead:
+		if !hasBody {
+			return "http." + strings.ToLower(method), withParams(url)
+		}
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodOptions:
+		return "http." + strings.ToLower(method), withParams(url, body)
+	case http.MethodDelete:
+		return "http.del", withParams(url, body)
+	}
+
+	return "http.request", withParams(String(request.method()), url, body)
+}
+
+// url returns the URL expression, relative to BASE_URL when it starts with the base URL.
+func (s *Script) url(url string, substitutions []Substitution) Value {
+	base := strings.TrimSuffix(s.BaseURL, "/")
+	if base == "" || (url != base && !strings.HasPrefix(url, base+"/") && !strings.HasPrefix(url, base+"?")) {
+		return substitute(url, substitutions)
+	}
+
+	path, _ := templateText(strings.TrimPrefix(url, base), substitutions)
+	return Raw("`${BASE_URL}" + path + "`")
+}
+
+// variables returns the variables the extractions of the flow assign, in order.
+func (f Flow) variables() []string {
+	var variables []string
+	seen := make(map[string]bool)
+	for _, group := range f.Groups {
+		for _, request := range group.Requests {
+			for _, extraction := range request.Extractions {
+				if !seen[extraction.Variable] {
+					seen[extraction.Variable] = true
+					variables = append(variables, extraction.Variable)
+				}
+			}
+		}
+	}
+	return variables
+}
+
+// substitute returns the text as a template literal with the substitutions applied, as
+// the expression of the substitution of the whole text, or as a string literal when none
+// applies.
+func substitute(text string, substitutions []Substitution) Value {
+	for _, substitution := range substitutions {
+		if text == substitution.Value {
+			return Raw(substitution.Expression)
+		}
+	}
+	if body, substituted := templateText(text, substitutions); substituted {
+		return Raw("`" + body + "`")
+	}
+	return String(text)
+}
+
+// substituteValue returns the value with the substitutions applied to its strings, and to
+// its raw literals, such as numbers decoded from JSON, matching a value entirely.
+func substituteValue(value Value, substitutions []Substitution) Value {
+	if len(substitutions) == 0 {
+		return value
+	}
+
+	switch v := value.(type) {
+	case String:
+		return substitute(string(v), substitutions)
+	case Raw:
+		for _, substitution := range substitutions {
+			if string(v) == substitution.Value {
+				return Raw(substitution.Expression)
+			}
+		}
+		return v
+	case Object:
+		object := make(Object, len(v))
+		for i, field := range v {
+			object[i] = Field{Key: field.Key, Value: substituteValue(field.Value, substitutions)}
+		}
+		return object
+	case Array:
+		array := make(Array, len(v))
+		for i, element := range v {
+			array[i] = substituteValue(element, substitutions)
+		}
+		return array
+	case Concat:
+		concat := make(Concat, len(v))
+		for i, part := range v {
+			concat[i] = substituteValue(part, substitutions)
+		}
+		return concat
+	default:
+		return value
+	}
+}
+
+// templateText returns the text escaped for template literals, with the occurrences of
+// the values of the substitutions replaced with their expressions, the longest values
+// first, and whether any was replaced.
+func templateText(text string, substitutions []Substitution) (string, bool) {
+	var b strings.Builder
+	substituted := false
+	for i := 0; i < len(text); {
+		match := -1
+		for j, substitution := range substitutions {
+			if strings.HasPrefix(text[i:], substitution.Value) && (match < 0 || len(substitution.Value) > len(substitutions[match].Value)) {
+				match = j
+			}
+		}
+		if match >= 0 {
+			b.WriteString("${" + substitutions[match].Expression + "}")
+			i += len(substitutions[match].Value)
+			substituted = true
+			continue
+		}
+
+		switch c := text[i]; {
+		case c == '\\' || c == '`':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c == '$' && strings.HasPrefix(text[i:], "${"):
+			b.WriteString("\\$")
+		case c == '\n':
+			b.WriteString(`\n`)
+		case c == '\r':
+			b.WriteString(`\r`)
+		default:
+			b.WriteByte(c)
+		}
+		i++
+	}
+	return b.String(), substituted
+}
+
+// hasHeader reports whether the headers hold the header, whatever its case.
+func hasHeader(headers map[string]string, name string) bool {
+	for header := range headers {
+		if strings.EqualFold(header, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// sortedKeys returns the keys of the map, sorted.
+func sortedKeys(m map[string][]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,272 @@
+package codegen
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/oleiade/k6-mcp/internal/style"
+)
+
+// update rewrites the golden files with the rendered scripts: go test ./internal/codegen -update
+var update = flag.Bool("update", false, "update the golden files")
+
+// checkGolden compares the rendered output to the golden file testdata/<name>.golden.
+func checkGolden(t *testing.T, name, got string) {
+	t.Helper()
+
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatalf("writing %s: %v", path, err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading %s: %v (run with -update to create it)", path, err)
+	}
+	if got != string(want) {
+		t.Errorf("rendered output differs from %s:\n--- got\n%s\n--- want\n%s", path, got, want)
+	}
+}
+
+// checkoutFlow is a flow of grouped requests with checks, think times, JSON bodies and a
+// value extracted from a response for the next request.
+func checkoutFlow() Flow {
+	return Flow{Groups: []Group{
+		{
+			Name: "login",
+			Requests: []Request{{
+				Comment: "Authenticate",
+				Method:  "post",
+				URL:     "https://shop.example.com/api/login",
+				JSONBody: Object{
+					{Key: "username", Value: String("alice")},
+					{Key: "password", Value: Raw("__ENV.PASSWORD")},
+				},
+				Checks:      []Check{StatusCheck(200), JSONPresenceCheck("token")},
+				Extractions: []Extraction{JSONPathExtraction("token", "token")},
+				ThinkTime:   1,
+			}},
+		},
+		{
+			Name: "cart",
+			Requests: []Request{
+				{
+					URL:           "https://shop.example.com/api/cart?session=abc123",
+					Headers:       map[string]string{"Authorization": "Bearer abc123"},
+					Substitutions: []Substitution{{Value: "abc123", Expression: "token"}},
+					Tags:          map[string]string{"name": "cart"},
+					Checks:        []Check{StatusCheck(200), ContentTypeCheck("application/json"), JSONTypeCheck("items", "array")},
+				},
+				{
+					Method:  "PUT",
+					URL:     "https://cdn.example.com/upload",
+					Headers: map[string]string{"Content-Type": "text/plain"},
+					Body:    "it's \"quoted\"",
+					Checks:  []Check{BodyContainsCheck("ok")},
+				},
+			},
+		},
+	}}
+}
+
+func TestRender(t *testing.T) {
+	t.Parallel()
+
+	typeScript := style.Style{
+		Modules:     style.ModulesESM,
+		Language:    style.LanguageTypeScript,
+		Quotes:      style.QuotesDouble,
+		CheckNaming: style.CheckNamingSnakeCase,
+	}
+	commonJS := style.Style{
+		Modules:     style.ModulesCommonJS,
+		Language:    style.LanguageJavaScript,
+		Quotes:      style.QuotesSingle,
+		CheckNaming: style.CheckNamingKebabCase,
+	}
+
+	tests := []struct {
+		name   string
+		style  style.Style
+		script *Script
+	}{
+		{
+			name:  "requests",
+			style: style.Default(),
+			script: &Script{
+				Comment: "Converted from checkout.har",
+				BaseURL: "https://shop.example.com",
+				Options: Options{VUs: 1, Iterations: 1},
+				Flow:    checkoutFlow(),
+			},
+		},
+		{
+			name:  "options",
+			style: style.Default(),
+			script: &Script{
+				Imports: []Import{{Module: "k6/crypto", Default: "crypto"}},
+				Options: Options{
+					Stages:     []Stage{{Duration: "30s", Target: 10}, {Duration: "1m", Target: 10}, {Duration: "30s", Target: 0}},
+					Thresholds: map[string][]string{"http_req_failed": {"rate<0.01"}, "http_req_duration": {"p(95)<500", "p(99)<1000"}},
+					Tags:       map[string]string{"team": "checkout"},
+				},
+				Flow: Flow{Groups: []Group{{Requests: []Request{{
+					URL:    "https://shop.example.com/api/items",
+					Checks: []Check{{Name: "digest is set", Condition: Raw("crypto.sha256(r.body, 'hex') !== ''")}},
+				}}}}},
+			},
+		},
+		{
+			name:  "scenarios",
+			style: style.Default(),
+			script: &Script{
+				Options: Options{Thresholds: map[string][]string{"checks": {"rate>0.99"}}},
+				Scenarios: []Scenario{
+					{
+						Name:     "browse",
+						Executor: ExecutorConstantVUs,
+						VUs:      5,
+						Duration: "1m",
+						Tags:     map[string]string{"flow": "browse"},
+						Flow: Flow{Groups: []Group{{Requests: []Request{{
+							URL:       "https://shop.example.com/",
+							Checks:    []Check{StatusCheck(200)},
+							ThinkTime: 2.5,
+						}}}}},
+					},
+					{
+						Name:            "checkout",
+						Executor:        ExecutorRampingArrivalRate,
+						StartRate:       1,
+						TimeUnit:        "1s",
+						PreAllocatedVUs: 10,
+						MaxVUs:          20,
+						Stages:          []Stage{{Duration: "1m", Target: 5}},
+						StartTime:       "10s",
+						Flow:            checkoutFlow(),
+					},
+				},
+			},
+		},
+		{
+			name:  "typescript",
+			style: typeScript,
+			script: &Script{
+				BaseURL: "https://shop.example.com",
+				Options: Options{VUs: 2, Duration: "30s"},
+				Flow:    checkoutFlow(),
+			},
+		},
+		{
+			name:  "commonjs",
+			style: commonJS,
+			script: &Script{
+				Options: Options{VUs: 1, Duration: "10s"},
+				Flow: Flow{Groups: []Group{{Name: "home", Requests: []Request{{
+					URL:       "https://shop.example.com/",
+					Checks:    []Check{StatusCheck(200), BodyContainsCheck("Welcome")},
+					ThinkTime: 1,
+				}}}}},
+			},
+		},
+		{
+			name:  "fakes",
+			style: style.Default(),
+			script: &Script{
+				Flow: Flow{Groups: []Group{{Requests: []Request{{
+					Method: "POST",
+					URL:    "https://shop.example.com/api/users",
+					JSONBody: Object{
+						{Key: "id", Value: Fake{Kind: FakeUUID}},
+						{Key: "email", Value: Fake{Kind: FakeEmail}},
+						{Key: "age", Value: Fake{Kind: FakeInt, Min: 18, Max: 99}},
+					},
+					Checks: []Check{StatusCheck(201)},
+				}}}}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := Render(tt.script, tt.style)
+			if err != nil {
+				t.Fatalf("Render: %v", err)
+			}
+			checkGolden(t, "render_"+tt.name, got)
+		})
+	}
+}
+
+func TestRenderOptions(t *testing.T) {
+	t.Parallel()
+
+	script := &Script{
+		Options: Options{Thresholds: map[string][]string{"http_req_duration": {"p(95)<500"}}},
+		Scenarios: []Scenario{
+			{Name: "browse", Executor: ExecutorConstantArrivalRate, Rate: 30, TimeUnit: "1m", Duration: "5m", PreAllocatedVUs: 5},
+			{Name: "search", Executor: ExecutorPerVUIterations, VUs: 2, Iterations: 10},
+		},
+	}
+
+	got, err := RenderOptions(script, style.Default())
+	if err != nil {
+		t.Fatalf("RenderOptions: %v", err)
+	}
+	checkGolden(t, "render_options_only", got)
+}
+
+func TestRenderChecks(t *testing.T) {
+	t.Parallel()
+
+	checks := []Check{StatusCheck(200), JSONTypeCheck("", "object"), JSONTypeCheck("deleted_at", "null")}
+	for _, tt := range []struct {
+		name  string
+		style style.Style
+	}{
+		{name: "sentence", style: style.Default()},
+		{name: "snake_case", style: style.Style{Modules: style.ModulesESM, Language: style.LanguageJavaScript, Quotes: style.QuotesDouble, CheckNaming: style.CheckNamingSnakeCase}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			checkGolden(t, "render_checks_"+tt.name, RenderChecks(checks, tt.style))
+		})
+	}
+}
+
+func TestRenderInvalidScripts(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		script *Script
+	}{
+		{name: "empty", script: &Script{}},
+		{name: "request without URL", script: &Script{Flow: Flow{Groups: []Group{{Requests: []Request{{Method: "GET"}}}}}}},
+		{name: "body and JSON body", script: &Script{Flow: Flow{Groups: []Group{{Requests: []Request{{URL: "https://example.com", Body: "x", JSONBody: String("y")}}}}}}},
+		{name: "unnamed check", script: &Script{Flow: Flow{Groups: []Group{{Requests: []Request{{URL: "https://example.com", Checks: []Check{{Condition: Raw("true")}}}}}}}}},
+		{name: "duplicate scenario", script: &Script{Scenarios: []Scenario{
+			{Name: "a", Executor: ExecutorConstantVUs, Flow: checkoutFlow()},
+			{Name: "a", Executor: ExecutorConstantVUs, Flow: checkoutFlow()},
+		}}},
+		{name: "scenario without executor", script: &Script{Scenarios: []Scenario{{Name: "a", Flow: checkoutFlow()}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if _, err := Render(tt.script, style.Default()); err == nil {
+				t.Error("Render() returned no error")
+			}
+		})
+	}
+}
@@ -0,0 +1,439 @@
+// Package config provides the k6 MCP server configuration, read from K6_MCP_* environment variables.
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/oleiade/k6-mcp/internal/annotations"
+	"github.com/oleiade/k6-mcp/internal/artifacts"
+	"github.com/oleiade/k6-mcp/internal/cloud"
+	"github.com/oleiade/k6-mcp/internal/continuation"
+	"github.com/oleiade/k6-mcp/internal/federation"
+	"github.com/oleiade/k6-mcp/internal/sandbox"
+	"github.com/oleiade/k6-mcp/internal/security"
+)
+
+// appName names the server's directories.
+const appName = "k6-mcp"
+
+// DefaultScriptURLAllowedHosts are the hosts scripts can be fetched from when
+// K6_MCP_SCRIPT_URL_ALLOWED_HOSTS is not set.
+var DefaultScriptURLAllowedHosts = []string{
+	"github.com",
+	"raw.githubusercontent.com",
+	"gitlab.com",
+	"bitbucket.org",
+}
+
+// Config holds the server configuration.
+type Config struct {
+	// ScriptURLAllowedHosts are the hosts scripts can be fetched from through script_url.
+	// Entries starting with "*." also match any subdomain. An empty list disables script_url.
+	ScriptURLAllowedHosts []string
+
+	// ScriptURLMaxBytes is the maximum size of a script fetched through script_url.
+	ScriptURLMaxBytes int64
+
+	// DataDir is the directory persistent server data, such as baselines, is stored in.
+	DataDir string
+
+	// CacheDir is the directory disposable server data, such as the extracted search
+	// index, is stored in.
+	CacheDir string
+
+	// K6Download enables the setup_k6 tool downloading official k6 releases, when k6 is
+	// not installed.
+	K6Download bool
+
+	// K6Dir is the directory k6 is downloaded into by the setup_k6 tool.
+	K6Dir string
+
+	// Network holds the proxy and CA bundle settings passed on to k6 and the other
+	// processes the server spawns.
+	Network security.Network
+
+	// AuthProfiles is the path of the file of the OAuth2 profiles runs acquire tokens with.
+	AuthProfiles string
+
+	// ArtifactsMaxBytes is the total size of the stored artifacts, beyond which the oldest
+	// ones are evicted.
+	ArtifactsMaxBytes int64
+
+	// InlineOutputBytes is the size of run outputs and search results inlined in tool
+	// responses, beyond which the remainder is retrieved with get_more_output.
+	InlineOutputBytes int
+
+	// TemplatesDir is the directory whose files override the embedded prompt, resource and
+	// code generation templates, laid out like the resources directory.
+	TemplatesDir string
+
+	// ScriptStyle is the path of the JSON file of the code style generators apply and
+	// scripts are linted against (see style.Style).
+	ScriptStyle string
+
+	// WarningThresholds lists the limits applied to the summary of every run, such as
+	// "error_rate=1%,p95=1s" (see runner.ParseWarningThresholds).
+	WarningThresholds string
+
+	// Cloud holds the settings of the Grafana Cloud k6 API, through which cloud tests are
+	// fetched and updated.
+	Cloud cloud.Config
+
+	// RetentionMaxAge is the age beyond which run records, suite runs, old script
+	// revisions and artifacts are removed, such as "30d" (see retention.ParseAge).
+	RetentionMaxAge string
+
+	// HistoryMaxBytes is the size of the run history beyond which the oldest runs are
+	// removed.
+	HistoryMaxBytes int64
+
+	// WorkspaceMaxAge is the age beyond which the workspaces left behind in the temporary
+	// directory are removed (see retention.ParseAge).
+	WorkspaceMaxAge string
+
+	// RetentionInterval is the interval between the sweeps removing the data beyond the
+	// retention settings (see retention.ParseAge).
+	RetentionInterval string
+
+	// Grafana holds the settings of the Grafana instance runs are annotated on.
+	Grafana annotations.Config
+
+	// Agents are the k6-mcp agents run_distributed dispatches runs to, as name=url pairs
+	// (see distributed.ParseAgents), and AgentToken the token agents require.
+	Agents     string
+	AgentToken string
+
+	// Upstream holds the settings of the k6-mcp server documentation tools are proxied to
+	// while the local index is unavailable, or older than UpstreamMaxIndexAge (see
+	// retention.ParseAge).
+	Upstream            federation.Config
+	UpstreamMaxIndexAge string
+
+	// HTTPToken is the bearer token clients of the streamable HTTP transport must present.
+	HTTPToken string
+
+	// Locale is the locale of the recommendations, next steps and error hints of the
+	// tools, such as "fr" or "es" (see locale.Parse).
+	Locale string
+
+	// Sandbox selects the isolation level k6 runs at, and bounds its resources.
+	Sandbox sandbox.Config
+
+	// ResultHooks are the names of the hooks post-processing the results of runs, in order,
+	// and ResultHookSettings their settings, keyed by hook name (see runner.NewResultHook).
+	ResultHooks        []string
+	ResultHookSettings map[string]string
+
+	// ScrubRules is the path of the JSON file of the rules detecting personal data (see
+	// privacy.Config), and ScrubTargets the data scrubbed automatically, such as
+	// "files,recordings" (see privacy.ParseTargets).
+	ScrubRules   string
+	ScrubTargets string
+
+	// ResponseMaxTokens is the estimated number of tokens of tool responses beyond which
+	// their low-value fields are shortened; 0 disables the budget.
+	ResponseMaxTokens int
+}
+
+// Load reads the configuration from the environment:
+//
+//   - K6_MCP_SCRIPT_URL_ALLOWED_HOSTS: comma-separated list of allowed hosts, or "none"
+//     to disable fetching scripts from URLs.
+//   - K6_MCP_SCRIPT_URL_MAX_BYTES: maximum size of fetched scripts, in bytes.
+//   - K6_MCP_DATA_DIR: directory persistent data is stored in. Defaults to
+//     $XDG_DATA_HOME/k6-mcp, or ~/.local/share/k6-mcp.
+//   - K6_MCP_CACHE_DIR: directory cached data is stored in. Defaults to the user cache
+//     directory ($XDG_CACHE_HOME/k6-mcp, or ~/.cache/k6-mcp on Linux).
+//   - K6_MCP_K6_DOWNLOAD: set to true to allow downloading k6 when it is not installed.
+//     k6 is then installed into the bin directory of the data directory.
+//   - K6_MCP_HTTP_PROXY, K6_MCP_HTTPS_PROXY, K6_MCP_NO_PROXY: proxy settings of spawned
+//     processes.
+//   - K6_MCP_INHERIT_PROXY: set to true to pass the server's own HTTP_PROXY, HTTPS_PROXY
+//     and NO_PROXY on to spawned processes, unless the K6_MCP_* variables override them.
+//   - K6_MCP_CA_BUNDLE: path of a PEM bundle of the certificate authorities spawned
+//     processes trust.
+//   - K6_MCP_AUTH_PROFILES: path of the file of the OAuth2 profiles runs acquire access
+//     tokens with.
+//   - K6_MCP_ARTIFACTS_MAX_BYTES: total size of the stored artifacts, in bytes.
+//   - K6_MCP_INLINE_OUTPUT_BYTES: size of the run outputs and search results inlined in
+//     tool responses, in bytes.
+//   - K6_MCP_CLOUD_TOKEN, K6_MCP_CLOUD_STACK_ID: API token and stack ID of Grafana Cloud
+//     k6, to fetch and update cloud tests.
+//   - K6_MCP_CLOUD_API_URL: base URL of the Grafana Cloud k6 API.
+//   - K6_MCP_TEMPLATES_DIR: directory of files overriding the embedded templates, such as
+//     prompts/generate_script.md or templates/terraform_load_test.tf.tmpl.
+//   - K6_MCP_SCRIPT_STYLE: path of the JSON file of the code style of generated scripts,
+//     also checked by validation.
+//   - K6_MCP_WARNING_THRESHOLDS: comma-separated limits applied to the summary of every
+//     run, e.g. "error_rate=1%,p95=1s,p99=2s", or "none".
+//   - K6_MCP_RETENTION_MAX_AGE: age beyond which run records, suite runs, old script
+//     revisions and artifacts are removed, such as "30d" or "720h". Unset keeps them.
+//   - K6_MCP_HISTORY_MAX_BYTES: size of the run history beyond which the oldest runs are
+//     removed, in bytes.
+//   - K6_MCP_WORKSPACE_MAX_AGE: age beyond which workspaces left behind in the temporary
+//     directory are removed. Defaults to 24h.
+//   - K6_MCP_RETENTION_INTERVAL: interval between retention sweeps. Defaults to 1h.
+//   - K6_MCP_GRAFANA_URL, K6_MCP_GRAFANA_TOKEN: base URL of a Grafana instance, and a
+//     service account token allowed to write annotations, to annotate runs on dashboards.
+//   - K6_MCP_GRAFANA_DASHBOARD_UID: UID of the dashboard run annotations are scoped to.
+//   - K6_MCP_GRAFANA_ANNOTATION_TAGS: comma-separated tags added to run annotations.
+//   - K6_MCP_AGENTS: comma-separated k6-mcp agents to distribute runs across, as name=url
+//     pairs, e.g. "eu-west=https://eu.example.com:6566".
+//   - K6_MCP_AGENT_TOKEN: token agents require from coordinators, and coordinators present.
+//   - K6_MCP_UPSTREAM_URL, K6_MCP_UPSTREAM_TOKEN: streamable HTTP endpoint of an upstream
+//     k6-mcp server, and its token, answering the documentation tools while the local
+//     index is unavailable.
+//   - K6_MCP_UPSTREAM_MAX_INDEX_AGE: age of the local documentation, such as "90d", beyond
+//     which the upstream server answers the documentation tools too. Disabled by default.
+//   - K6_MCP_HTTP_TOKEN: bearer token required from the clients of `serve -http`.
+//   - K6_MCP_LOCALE: locale of the guidance of the tools, such as "fr" or "es_ES.UTF-8".
+//     Defaults to English.
+//   - K6_MCP_SANDBOX: sandbox backend k6 runs in: exec (default), rlimit, docker or gvisor.
+//   - K6_MCP_SANDBOX_MEMORY_BYTES, K6_MCP_SANDBOX_CPU_SECONDS, K6_MCP_SANDBOX_CPUS,
+//     K6_MCP_SANDBOX_OPEN_FILES, K6_MCP_SANDBOX_PROCESSES: resource limits of sandboxed
+//     k6 processes, applied by the backends supporting them.
+//   - K6_MCP_SANDBOX_IMAGE, K6_MCP_SANDBOX_NETWORK: k6 image and Docker network of the
+//     container backends. Default to grafana/k6:latest and the host network.
+//   - K6_MCP_RESULT_HOOKS: comma-separated hooks post-processing the results of runs, in
+//     order, such as "slo,transfer_cost".
+//   - K6_MCP_HOOK_<NAME>: settings of the hook of the name, e.g. K6_MCP_HOOK_SLO for the
+//     objectives of the slo hook.
+//   - K6_MCP_SCRUB_RULES: path of the JSON file disabling built-in rules detecting personal
+//     data, and adding custom ones.
+//   - K6_MCP_SCRUB: comma-separated data whose personal data is masked automatically:
+//     files (companion files, before they are written to workspaces) and recordings.
+//   - K6_MCP_RESPONSE_MAX_TOKENS: estimated number of tokens of tool responses beyond which
+//     their raw output and guidance lists are shortened. Unset disables the budget.
+func Load() Config {
+	config := Config{
+		ScriptURLAllowedHosts: DefaultScriptURLAllowedHosts,
+		ScriptURLMaxBytes:     security.MaxScriptSizeBytes,
+		DataDir:               defaultDataDir(),
+		CacheDir:              defaultCacheDir(),
+		ArtifactsMaxBytes:     artifacts.DefaultQuotaBytes,
+		InlineOutputBytes:     continuation.DefaultInlineBytes,
+	}
+
+	if dataDir := os.Getenv("K6_MCP_DATA_DIR"); dataDir != "" {
+		config.DataDir = dataDir
+	}
+
+	if cacheDir := os.Getenv("K6_MCP_CACHE_DIR"); cacheDir != "" {
+		config.CacheDir = cacheDir
+	}
+
+	config.K6Dir = filepath.Join(config.DataDir, "bin")
+	if download, err := strconv.ParseBool(os.Getenv("K6_MCP_K6_DOWNLOAD")); err == nil {
+		config.K6Download = download
+	}
+
+	if hosts, ok := os.LookupEnv("K6_MCP_SCRIPT_URL_ALLOWED_HOSTS"); ok {
+		config.ScriptURLAllowedHosts = parseList(hosts)
+		if len(config.ScriptURLAllowedHosts) == 1 && strings.EqualFold(config.ScriptURLAllowedHosts[0], "none") {
+			config.ScriptURLAllowedHosts = nil
+		}
+	}
+
+	config.Network = loadNetwork()
+	config.Sandbox = loadSandbox()
+	config.ResultHooks, config.ResultHookSettings = loadResultHooks()
+	config.AuthProfiles = os.Getenv("K6_MCP_AUTH_PROFILES")
+	config.TemplatesDir = os.Getenv("K6_MCP_TEMPLATES_DIR")
+	config.ScriptStyle = os.Getenv("K6_MCP_SCRIPT_STYLE")
+	config.WarningThresholds = os.Getenv("K6_MCP_WARNING_THRESHOLDS")
+	config.Locale = os.Getenv("K6_MCP_LOCALE")
+	config.ScrubRules = os.Getenv("K6_MCP_SCRUB_RULES")
+	config.ScrubTargets = os.Getenv("K6_MCP_SCRUB")
+	config.Agents = os.Getenv("K6_MCP_AGENTS")
+	config.AgentToken = os.Getenv("K6_MCP_AGENT_TOKEN")
+	config.Upstream = federation.Config{
+		URL:   os.Getenv("K6_MCP_UPSTREAM_URL"),
+		Token: os.Getenv("K6_MCP_UPSTREAM_TOKEN"),
+	}
+	config.UpstreamMaxIndexAge = os.Getenv("K6_MCP_UPSTREAM_MAX_INDEX_AGE")
+	config.HTTPToken = os.Getenv("K6_MCP_HTTP_TOKEN")
+	config.RetentionMaxAge = os.Getenv("K6_MCP_RETENTION_MAX_AGE")
+	config.WorkspaceMaxAge = os.Getenv("K6_MCP_WORKSPACE_MAX_AGE")
+	config.RetentionInterval = os.Getenv("K6_MCP_RETENTION_INTERVAL")
+	config.Cloud = cloud.Config{
+		APIURL:  os.Getenv("K6_MCP_CLOUD_API_URL"),
+		Token:   os.Getenv("K6_MCP_CLOUD_TOKEN"),
+		StackID: os.Getenv("K6_MCP_CLOUD_STACK_ID"),
+	}
+
+	config.Grafana = annotations.Config{
+		URL:          os.Getenv("K6_MCP_GRAFANA_URL"),
+		Token:        os.Getenv("K6_MCP_GRAFANA_TOKEN"),
+		DashboardUID: os.Getenv("K6_MCP_GRAFANA_DASHBOARD_UID"),
+		Tags:         parseList(os.Getenv("K6_MCP_GRAFANA_ANNOTATION_TAGS")),
+	}
+
+	if maxBytes := os.Getenv("K6_MCP_ARTIFACTS_MAX_BYTES"); maxBytes != "" {
+		if n, err := strconv.ParseInt(maxBytes, 10, 64); err == nil && n > 0 {
+			config.ArtifactsMaxBytes = n
+		}
+	}
+
+	if maxBytes := os.Getenv("K6_MCP_HISTORY_MAX_BYTES"); maxBytes != "" {
+		if n, err := strconv.ParseInt(maxBytes, 10, 64); err == nil && n > 0 {
+			config.HistoryMaxBytes = n
+		}
+	}
+
+	if inlineBytes := os.Getenv("K6_MCP_INLINE_OUTPUT_BYTES"); inlineBytes != "" {
+		if n, err := strconv.Atoi(inlineBytes); err == nil && n > 0 {
+			config.InlineOutputBytes = n
+		}
+	}
+
+	if maxTokens := os.Getenv("K6_MCP_RESPONSE_MAX_TOKENS"); maxTokens != "" {
+		if n, err := strconv.Atoi(maxTokens); err == nil && n > 0 {
+			config.ResponseMaxTokens = n
+		}
+	}
+
+	if maxBytes := os.Getenv("K6_MCP_SCRIPT_URL_MAX_BYTES"); maxBytes != "" {
+		// The script size limit enforced by the security package still applies, so larger
+		// values are capped to it.
+		if n, err := strconv.ParseInt(maxBytes, 10, 64); err == nil && n > 0 && n <= security.MaxScriptSizeBytes {
+			config.ScriptURLMaxBytes = n
+		}
+	}
+
+	return config
+}
+
+// loadNetwork reads the network settings of spawned processes from the environment.
+func loadNetwork() security.Network {
+	var network security.Network
+	if inherit, err := strconv.ParseBool(os.Getenv("K6_MCP_INHERIT_PROXY")); err == nil && inherit {
+		network = security.Network{
+			HTTPProxy:  getenvAny("HTTP_PROXY", "http_proxy"),
+			HTTPSProxy: getenvAny("HTTPS_PROXY", "https_proxy"),
+			NoProxy:    getenvAny("NO_PROXY", "no_proxy"),
+		}
+	}
+
+	if proxy := os.Getenv("K6_MCP_HTTP_PROXY"); proxy != "" {
+		network.HTTPProxy = proxy
+	}
+	if proxy := os.Getenv("K6_MCP_HTTPS_PROXY"); proxy != "" {
+		network.HTTPSProxy = proxy
+	}
+	if noProxy := os.Getenv("K6_MCP_NO_PROXY"); noProxy != "" {
+		network.NoProxy = noProxy
+	}
+	network.CABundle = os.Getenv("K6_MCP_CA_BUNDLE")
+
+	return network
+}
+
+// loadSandbox reads the sandbox settings from the environment. Invalid limits are kept
+// negative, so that creating the sandbox reports them rather than ignoring them.
+func loadSandbox() sandbox.Config {
+	cfg := sandbox.Config{
+		Backend: os.Getenv("K6_MCP_SANDBOX"),
+		Image:   os.Getenv("K6_MCP_SANDBOX_IMAGE"),
+		Network: os.Getenv("K6_MCP_SANDBOX_NETWORK"),
+	}
+
+	for name, limit := range map[string]*int{
+		"K6_MCP_SANDBOX_CPU_SECONDS": &cfg.Limits.CPUSeconds,
+		"K6_MCP_SANDBOX_OPEN_FILES":  &cfg.Limits.OpenFiles,
+		"K6_MCP_SANDBOX_PROCESSES":   &cfg.Limits.Processes,
+	} {
+		if value := os.Getenv(name); value != "" {
+			if n, err := strconv.Atoi(value); err == nil {
+				*limit = n
+			} else {
+				*limit = -1
+			}
+		}
+	}
+	if value := os.Getenv("K6_MCP_SANDBOX_MEMORY_BYTES"); value != "" {
+		if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+			cfg.Limits.MemoryBytes = n
+		} else {
+			cfg.Limits.MemoryBytes = -1
+		}
+	}
+	if value := os.Getenv("K6_MCP_SANDBOX_CPUS"); value != "" {
+		if n, err := strconv.ParseFloat(value, 64); err == nil {
+			cfg.Limits.CPUs = n
+		} else {
+			cfg.Limits.CPUs = -1
+		}
+	}
+
+	return cfg
+}
+
+// getenvAny returns the value of the first of the variables that is set and not empty.
+func getenvAny(names ...string) string {
+	for _, name := range names {
+		if value := os.Getenv(name); value != "" {
+			return value
+		}
+	}
+
+	return ""
+}
+
+// defaultDataDir returns the XDG data directory of the server, falling back to a
+// directory in the temporary directory when the home directory can't be determined.
+func defaultDataDir() string {
+	if dataHome := os.Getenv("XDG_DATA_HOME"); dataHome != "" {
+		return filepath.Join(dataHome, appName)
+	}
+
+	if home, err := os.UserHomeDir(); err == nil {
+		return filepath.Join(home, ".local", "share", appName)
+	}
+
+	return filepath.Join(os.TempDir(), appName)
+}
+
+// defaultCacheDir returns the user cache directory of the server, falling back to a
+// directory in the temporary directory when it can't be determined.
+func defaultCacheDir() string {
+	if cacheHome, err := os.UserCacheDir(); err == nil {
+		return filepath.Join(cacheHome, appName)
+	}
+
+	return filepath.Join(os.TempDir(), appName+"-cache")
+}
+
+// parseList splits a comma-separated list, trimming and dropping empty entries.
+// loadResultHooks reads the names of the result hooks, and the settings of each of them
+// from the variable of its name, such as K6_MCP_HOOK_TRANSFER_COST for transfer_cost.
+func loadResultHooks() ([]string, map[string]string) {
+	names := parseList(os.Getenv("K6_MCP_RESULT_HOOKS"))
+	settings := make(map[string]string, len(names))
+	for _, name := range names {
+		variable := "K6_MCP_HOOK_" + strings.ToUpper(strings.Map(func(r rune) rune {
+			if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+				return r
+			}
+			return '_'
+		}, name))
+		if value := os.Getenv(variable); value != "" {
+			settings[name] = value
+		}
+	}
+
+	return names, settings
+}
+
+func parseList(s string) []string {
+	var values []string
+	for _, value := range strings.Split(s, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, strings.ToLower(value))
+		}
+	}
+
+	return values
+}
@@ -0,0 +1,194 @@
+// Package continuation keeps the remainder of large tool response fields, such as the
+// output of runs and the content of documentation pages, in memory, so that responses stay
+// within the context limits of clients while the full content remains retrievable, in
+// chunks, with continuation tokens.
+package continuation
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// DefaultInlineBytes is the default size of the content inlined in tool responses.
+	DefaultInlineBytes = 16 * 1024
+	// MaxChunkBytes bounds the size of the chunks returned for continuation tokens.
+	MaxChunkBytes = 256 * 1024
+	// DefaultTTL is how long the remainder of content is kept after it was last read.
+	DefaultTTL = 30 * time.Minute
+	// maxEntries bounds the number of contents kept; the least recently used are dropped.
+	maxEntries = 128
+)
+
+// ErrExpired is returned for tokens whose content was dropped, or never existed.
+var ErrExpired = errors.New("continuation token expired or unknown")
+
+// Ref describes the remainder of a truncated content.
+type Ref struct {
+	// Token retrieves the next chunk of the content with get_more_output.
+	Token string `json:"token"`
+	// TotalBytes is the size of the whole content.
+	TotalBytes int `json:"total_bytes"`
+	// RemainingBytes is the size of the content after the returned chunk.
+	RemainingBytes int `json:"remaining_bytes"`
+}
+
+// Chunk is a chunk of a content retrieved with a continuation token.
+type Chunk struct {
+	// Label names the content, such as "stdout".
+	Label   string `json:"label"`
+	Offset  int    `json:"offset"`
+	Content string `json:"content"`
+	// Next retrieves the following chunk, or is nil when the chunk ends the content.
+	Next *Ref `json:"next,omitempty"`
+}
+
+type entry struct {
+	label    string
+	content  string
+	lastUsed time.Time
+}
+
+// Store keeps truncated contents in memory.
+type Store struct {
+	inline  int
+	ttl     time.Duration
+	mu      sync.Mutex
+	entries map[string]*entry
+}
+
+// NewStore creates a Store inlining up to inline bytes of contents, and keeping their
+// remainder for ttl after it was last read.
+func NewStore(inline int, ttl time.Duration) *Store {
+	if inline <= 0 {
+		inline = DefaultInlineBytes
+	}
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	return &Store{inline: inline, ttl: ttl, entries: make(map[string]*entry)}
+}
+
+// InlineBytes returns the size of the content inlined in tool responses.
+func (s *Store) InlineBytes() int {
+	return s.inline
+}
+
+// Truncate returns content as is when it fits in the inline size. Otherwise, it keeps the
+// content, labeled label, and returns its first chunk and the reference to the remainder.
+// A nil Store never truncates.
+func (s *Store) Truncate(label, content string) (string, *Ref) {
+	if s == nil || len(content) <= s.inline {
+		return content, nil
+	}
+
+	id, err := newID()
+	if err != nil {
+		// Responses are better too large than missing output
+		return content, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.expire()
+	s.entries[id] = &entry{label: label, content: content, lastUsed: time.Now()}
+
+	head := content[:cut(content, 0, s.inline)]
+	return head, ref(id, content, len(head))
+}
+
+// Next returns the chunk of up to limit bytes of the content the token refers to.
+func (s *Store) Next(token string, limit int) (*Chunk, error) {
+	if limit <= 0 || limit > MaxChunkBytes {
+		limit = s.inline
+	}
+
+	id, offsetText, found := strings.Cut(token, ".")
+	offset, err := strconv.Atoi(offsetText)
+	if !found || err != nil || offset < 0 {
+		return nil, ErrExpired
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.expire()
+	e, ok := s.entries[id]
+	if !ok {
+		return nil, ErrExpired
+	}
+	if offset > len(e.content) {
+		return nil, fmt.Errorf("offset %d is out of the %d bytes of the content", offset, len(e.content))
+	}
+	e.lastUsed = time.Now()
+
+	end := cut(e.content, offset, limit)
+	chunk := &Chunk{Label: e.label, Offset: offset, Content: e.content[offset:end]}
+	if end < len(e.content) {
+		chunk.Next = ref(id, e.content, end)
+	}
+
+	return chunk, nil
+}
+
+// expire drops the expired contents, and the least recently used ones beyond maxEntries.
+// The caller holds the lock.
+func (s *Store) expire() {
+	now := time.Now()
+	for id, e := range s.entries {
+		if now.Sub(e.lastUsed) > s.ttl {
+			delete(s.entries, id)
+		}
+	}
+
+	for len(s.entries) >= maxEntries {
+		var oldest string
+		for id, e := range s.entries {
+			if oldest == "" || e.lastUsed.Before(s.entries[oldest].lastUsed) {
+				oldest = id
+			}
+		}
+		delete(s.entries, oldest)
+	}
+}
+
+// ref returns the reference to the content of the entry from offset.
+func ref(id, content string, offset int) *Ref {
+	return &Ref{
+		Token:          id + "." + strconv.Itoa(offset),
+		TotalBytes:     len(content),
+		RemainingBytes: len(content) - offset,
+	}
+}
+
+// cut returns the end of the chunk of up to limit bytes of content from offset, moved back
+// to a character boundary.
+func cut(content string, offset, limit int) int {
+	end := offset + limit
+	if end >= len(content) {
+		return len(content)
+	}
+	for i := end; i > offset && end-i < utf8.UTFMax; i-- {
+		if utf8.RuneStart(content[i]) {
+			return i
+		}
+	}
+	return end
+}
+
+// newID returns a random content ID.
+func newID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate continuation token: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
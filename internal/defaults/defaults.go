@@ -0,0 +1,345 @@
+// Package defaults stores default run options, for the client session or for named
+// projects, and merges them into the arguments of runs, so that agents don't have to
+// repeat the same parameters on every run.
+package defaults
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/oleiade/k6-mcp/internal/jsonfile"
+	"github.com/oleiade/k6-mcp/internal/runner"
+)
+
+const (
+	// TargetHostEnvVar is the environment variable the target host is exposed to scripts as.
+	TargetHostEnvVar = "BASE_URL"
+	// MaxRateLimit is the maximum rate limit of defaults, in requests per second.
+	MaxRateLimit = 1000000
+	// storeFileName is the name of the file project defaults are stored in.
+	storeFileName = "defaults.json"
+)
+
+// projectNamePattern matches valid project names.
+var projectNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9 _.:/-]{0,127}$`)
+
+// Defaults are default run options.
+type Defaults struct {
+	VUs        int                 `json:"vus,omitempty"`
+	Duration   string              `json:"duration,omitempty"`
+	Thresholds map[string][]string `json:"thresholds,omitempty"`
+	Env        map[string]string   `json:"env,omitempty"`
+	// TargetHost is the base URL of the system under test, exposed to scripts as the
+	// TargetHostEnvVar environment variable.
+	TargetHost string `json:"target_host,omitempty"`
+	// RateLimit is the request rate, per second, the target tolerates from the load
+	// generator before its WAF or rate limiting blocks it. Runs planned to exceed it are
+	// blocked.
+	RateLimit float64   `json:"rate_limit,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// IsZero reports whether the defaults set no option.
+func (d *Defaults) IsZero() bool {
+	return d == nil || (d.VUs == 0 && d.Duration == "" && len(d.Thresholds) == 0 && len(d.Env) == 0 && d.TargetHost == "" && d.RateLimit == 0)
+}
+
+// Validate checks the options of the defaults.
+func (d *Defaults) Validate() error {
+	if d.VUs < 0 || d.VUs > runner.MaxVUs {
+		return fmt.Errorf("vus must be between 1 and %d", runner.MaxVUs)
+	}
+	if d.Duration != "" {
+		duration, err := time.ParseDuration(d.Duration)
+		if err != nil || duration <= 0 || duration > runner.MaxDuration {
+			return fmt.Errorf("duration must be a valid duration of at most %v, e.g. '30s'", runner.MaxDuration)
+		}
+	}
+	if d.TargetHost != "" {
+		u, err := url.Parse(d.TargetHost)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("target_host must be an http or https URL, e.g. 'https://staging.example.com'")
+		}
+	}
+	if d.RateLimit < 0 || d.RateLimit > MaxRateLimit {
+		return fmt.Errorf("rate_limit must be a request rate per second between 0 and %d", MaxRateLimit)
+	}
+	if err := runner.ValidateThresholds(d.Thresholds); err != nil {
+		return err
+	}
+
+	return runner.ValidateEnv(d.Env)
+}
+
+// merge overlays the options set by update on the defaults: environment variables and
+// thresholds are merged by name and metric.
+func (d *Defaults) merge(update *Defaults) {
+	if update == nil {
+		return
+	}
+	if update.VUs > 0 {
+		d.VUs = update.VUs
+	}
+	if update.Duration != "" {
+		d.Duration = update.Duration
+	}
+	if update.TargetHost != "" {
+		d.TargetHost = update.TargetHost
+	}
+	if update.RateLimit > 0 {
+		d.RateLimit = update.RateLimit
+	}
+	for name, value := range update.Env {
+		if d.Env == nil {
+			d.Env = make(map[string]string)
+		}
+		d.Env[name] = value
+	}
+	for metric, thresholds := range update.Thresholds {
+		if d.Thresholds == nil {
+			d.Thresholds = make(map[string][]string)
+		}
+		d.Thresholds[metric] = thresholds
+	}
+}
+
+// Store keeps the defaults of client sessions in memory, and persists the defaults of
+// projects in a JSON file.
+type Store struct {
+	path string
+
+	mu       sync.Mutex
+	sessions map[string]*Defaults
+}
+
+// NewStore creates a Store keeping its project defaults in dir.
+func NewStore(dir string) *Store {
+	return &Store{
+		path:     filepath.Join(dir, storeFileName),
+		sessions: make(map[string]*Defaults),
+	}
+}
+
+// RegisterHooks registers the server hooks forgetting the defaults of closed sessions.
+func (s *Store) RegisterHooks(hooks *server.Hooks) {
+	hooks.AddOnUnregisterSession(func(_ context.Context, session server.ClientSession) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		delete(s.sessions, session.SessionID())
+	})
+}
+
+// Session returns the defaults of the session, or nil.
+func (s *Store) Session(sessionID string) *Defaults {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.sessions[sessionID]
+}
+
+// SetSession merges update into the defaults of the session, or replaces them with it
+// when replace is set, and returns the resulting defaults. Empty defaults are removed.
+func (s *Store) SetSession(sessionID string, update *Defaults, replace bool) (*Defaults, error) {
+	if err := update.Validate(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	d := apply(s.sessions[sessionID], update, replace)
+	if d.IsZero() {
+		delete(s.sessions, sessionID)
+	} else {
+		s.sessions[sessionID] = d
+	}
+
+	return d, nil
+}
+
+// Project returns the defaults of the named project, or nil.
+func (s *Store) Project(name string) (*Defaults, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	projects, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+
+	return projects[name], nil
+}
+
+// SetProject merges update into the defaults of the named project, or replaces them with
+// it when replace is set, and returns the resulting defaults. Empty defaults are removed.
+func (s *Store) SetProject(name string, update *Defaults, replace bool) (*Defaults, error) {
+	if !projectNamePattern.MatchString(name) {
+		return nil, fmt.Errorf("invalid project name %q: use up to 128 letters, digits, spaces and '_.:/-'", name)
+	}
+	if err := update.Validate(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	projects, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+
+	d := apply(projects[name], update, replace)
+	if d.IsZero() {
+		delete(projects, name)
+	} else {
+		projects[name] = d
+	}
+
+	if err := s.save(projects); err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// Projects returns the names of the projects having defaults, sorted.
+func (s *Store) Projects() ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	projects, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(projects))
+	for name := range projects {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names, nil
+}
+
+// apply returns the defaults resulting from setting update over current.
+func apply(current, update *Defaults, replace bool) *Defaults {
+	d := &Defaults{}
+	if current != nil && !replace {
+		d.merge(current)
+	}
+	d.merge(update)
+	d.UpdatedAt = time.Now().UTC()
+
+	return d
+}
+
+func (s *Store) load() (map[string]*Defaults, error) {
+	projects := make(map[string]*Defaults)
+	if err := jsonfile.Load(s.path, &projects); err != nil {
+		return nil, fmt.Errorf("failed to read defaults: %w", err)
+	}
+
+	return projects, nil
+}
+
+// save atomically replaces the defaults file.
+func (s *Store) save(projects map[string]*Defaults) error {
+	if err := jsonfile.SaveAtomic(s.path, projects); err != nil {
+		return fmt.Errorf("failed to write defaults: %w", err)
+	}
+
+	return nil
+}
+
+// Effective returns the defaults in effect given the defaults of a project and of the
+// session, which take precedence. It returns nil when neither sets any option.
+func Effective(project, session *Defaults) *Defaults {
+	if project.IsZero() && session.IsZero() {
+		return nil
+	}
+
+	d := &Defaults{}
+	d.merge(project)
+	d.merge(session)
+	return d
+}
+
+// Apply returns a copy of the run arguments args completed with the defaults, and the
+// names of the arguments the defaults were applied to. Arguments given explicitly take
+// precedence: environment variables and thresholds are merged by name and metric, and the
+// default VUs and duration are left out of runs using stages, and the default duration out
+// of runs using iterations, which set their own.
+func (d *Defaults) Apply(args map[string]interface{}) (map[string]interface{}, []string) {
+	merged := make(map[string]interface{}, len(args)+4)
+	for key, value := range args {
+		merged[key] = value
+	}
+	if d.IsZero() {
+		return merged, nil
+	}
+
+	var applied []string
+	_, hasStages := args["stages"]
+	_, hasIterations := args["iterations"]
+
+	if _, exists := args["vus"]; !exists && !hasStages && d.VUs > 0 {
+		merged["vus"] = float64(d.VUs)
+		applied = append(applied, "vus")
+	}
+	if _, exists := args["duration"]; !exists && !hasStages && !hasIterations && d.Duration != "" {
+		merged["duration"] = d.Duration
+		applied = append(applied, "duration")
+	}
+
+	env := make(map[string]interface{})
+	for name, value := range d.Env {
+		env[name] = value
+	}
+	if d.TargetHost != "" {
+		if _, exists := env[TargetHostEnvVar]; !exists {
+			env[TargetHostEnvVar] = d.TargetHost
+		}
+	}
+	if len(env) > 0 {
+		if explicit, ok := args["env"].(map[string]interface{}); ok {
+			for name, value := range explicit {
+				env[name] = value
+			}
+		}
+		if _, exists := args["env"]; !exists || isObject(args["env"]) {
+			merged["env"] = env
+			applied = append(applied, "env")
+		}
+	}
+
+	if len(d.Thresholds) > 0 {
+		thresholds := make(map[string]interface{})
+		for metric, expressions := range d.Thresholds {
+			thresholds[metric] = expressions
+		}
+		if explicit, ok := args["thresholds"].(map[string]interface{}); ok {
+			for metric, expressions := range explicit {
+				thresholds[metric] = expressions
+			}
+		}
+		if _, exists := args["thresholds"]; !exists || isObject(args["thresholds"]) {
+			merged["thresholds"] = thresholds
+			applied = append(applied, "thresholds")
+		}
+	}
+
+	return merged, applied
+}
+
+// isObject reports whether a tool argument is a JSON object.
+func isObject(value interface{}) bool {
+	_, ok := value.(map[string]interface{})
+	return ok
+}
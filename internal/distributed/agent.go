@@ -0,0 +1,131 @@
+package distributed
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sync"
+
+	"github.com/oleiade/k6-mcp/internal/buildinfo"
+	"github.com/oleiade/k6-mcp/internal/k6bin"
+	"github.com/oleiade/k6-mcp/internal/runner"
+)
+
+// Health is the readiness of an agent.
+type Health struct {
+	Version string `json:"version"`
+	// K6 reports whether the agent found a k6 executable, and Busy whether it is running.
+	K6   bool `json:"k6"`
+	Busy bool `json:"busy"`
+}
+
+// AgentHandler serves the API of an agent: it runs the archives it receives, one at a time,
+// for coordinators presenting its token.
+type AgentHandler struct {
+	token  string
+	logger *slog.Logger
+
+	// running serializes runs, so that concurrent ones don't share the load generator
+	running sync.Mutex
+}
+
+// NewAgentHandler returns the handler of the API of an agent accepting the token.
+func NewAgentHandler(token string, logger *slog.Logger) (*AgentHandler, error) {
+	if token == "" {
+		return nil, ErrNoToken
+	}
+	return &AgentHandler{token: token, logger: logger}, nil
+}
+
+func (h *AgentHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !h.authorized(r) {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "missing or invalid token"})
+		return
+	}
+
+	switch r.URL.Path {
+	case HealthPath:
+		h.health(w, r)
+	case RunsPath:
+		h.run(w, r)
+	default:
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
+	}
+}
+
+func (h *AgentHandler) authorized(r *http.Request) bool {
+	const prefix = "Bearer "
+	header := r.Header.Get("Authorization")
+	if len(header) <= len(prefix) || header[:len(prefix)] != prefix {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(header[len(prefix):]), []byte(h.token)) == 1
+}
+
+func (h *AgentHandler) health(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+
+	_, err := k6bin.Find()
+	busy := !h.running.TryLock()
+	if !busy {
+		h.running.Unlock()
+	}
+	writeJSON(w, http.StatusOK, Health{Version: buildinfo.Version, K6: err == nil, Busy: busy})
+}
+
+func (h *AgentHandler) run(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+
+	var request RunRequest
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBytes))
+	if err := decoder.Decode(&request); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid run request: " + err.Error()})
+		return
+	}
+
+	if !h.running.TryLock() {
+		writeJSON(w, http.StatusConflict, map[string]string{"error": "the agent is already running a test"})
+		return
+	}
+	defer h.running.Unlock()
+
+	h.logger.InfoContext(r.Context(), "Running dispatched archive",
+		slog.Int("archive_size", len(request.Archive)),
+		slog.String("remote", r.RemoteAddr),
+	)
+
+	result, err := runner.RunK6Archive(r.Context(), request.Archive, request.Options())
+	var runErr *runner.RunError
+	if errors.As(err, &runErr) && (runErr.Type == "INPUT_VALIDATION" || runErr.Type == "PARAMETER_VALIDATION") {
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]string{"error": err.Error()})
+		return
+	}
+
+	// Coordinators merge the summaries: the raw metrics and most of the output stay here
+	result.Metrics = nil
+	result.Stdout = tail(result.Stdout, maxOutputBytes)
+	result.Stderr = tail(result.Stderr, maxOutputBytes)
+	writeJSON(w, http.StatusOK, result)
+}
+
+// tail returns the last n bytes of s.
+func tail(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[len(s)-n:]
+}
+
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(value)
+}
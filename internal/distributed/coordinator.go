@@ -0,0 +1,270 @@
+package distributed
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/oleiade/k6-mcp/internal/runner"
+)
+
+// TagAgent is the tag of the metrics of the runs of an agent, naming the agent.
+const TagAgent = "agent"
+
+// AgentRun is the outcome of the run of an agent.
+type AgentRun struct {
+	Agent    string            `json:"agent"`
+	URL      string            `json:"url"`
+	Success  bool              `json:"success"`
+	Error    string            `json:"error,omitempty"`
+	Duration string            `json:"duration"`
+	Result   *runner.RunResult `json:"result,omitempty"`
+}
+
+// Coordinator dispatches runs to agents.
+type Coordinator struct {
+	agents []Agent
+	token  string
+	client *http.Client
+}
+
+// NewCoordinator returns a coordinator of the agents, presenting them the token.
+func NewCoordinator(agents []Agent, token string) *Coordinator {
+	return &Coordinator{agents: agents, token: token, client: &http.Client{Timeout: requestTimeout}}
+}
+
+// Configured reports whether runs can be dispatched: agents and a token are configured.
+func (c *Coordinator) Configured() bool {
+	return c != nil && len(c.agents) > 0 && c.token != ""
+}
+
+// Agents returns the agents of the coordinator.
+func (c *Coordinator) Agents() []Agent {
+	return c.agents
+}
+
+// Select returns the named agents, or all of them when no name is given.
+func (c *Coordinator) Select(names []string) ([]Agent, error) {
+	if len(names) == 0 {
+		return c.agents, nil
+	}
+
+	byName := make(map[string]Agent, len(c.agents))
+	known := make([]string, len(c.agents))
+	for i, agent := range c.agents {
+		byName[agent.Name] = agent
+		known[i] = agent.Name
+	}
+
+	var selected []Agent
+	seen := make(map[string]bool)
+	for _, name := range names {
+		agent, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown agent %q; configured agents are %s", name, strings.Join(known, ", "))
+		}
+		if !seen[name] {
+			seen[name] = true
+			selected = append(selected, agent)
+		}
+	}
+	return selected, nil
+}
+
+// Dispatch sends the run to the agents at once, each tagging its metrics with its name,
+// and returns their outcomes, in the order of the agents.
+func (c *Coordinator) Dispatch(ctx context.Context, agents []Agent, request RunRequest) []AgentRun {
+	runs := make([]AgentRun, len(agents))
+
+	var wg sync.WaitGroup
+	for i, agent := range agents {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			runs[i] = c.dispatch(ctx, agent, request)
+		}()
+	}
+	wg.Wait()
+
+	return runs
+}
+
+// dispatch sends the run to the agent.
+func (c *Coordinator) dispatch(ctx context.Context, agent Agent, request RunRequest) AgentRun {
+	start := time.Now()
+	run := AgentRun{Agent: agent.Name, URL: agent.URL}
+
+	tags := make(map[string]string, len(request.Tags)+1)
+	for name, value := range request.Tags {
+		tags[name] = value
+	}
+	tags[TagAgent] = agent.Name
+	request.Tags = tags
+
+	result, err := c.post(ctx, agent, request)
+	run.Duration = time.Since(start).Round(time.Millisecond).String()
+	if err != nil {
+		run.Error = err.Error()
+		return run
+	}
+
+	run.Result = result
+	run.Success = result.Success
+	if !result.Success {
+		run.Error = result.Error
+	}
+	return run
+}
+
+func (c *Coordinator) post(ctx context.Context, agent Agent, request RunRequest) (*runner.RunResult, error) {
+	data, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode run request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, agent.URL+RunsPath, bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "k6-mcp")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach the agent: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the agent response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		var failure struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(body, &failure) == nil && failure.Error != "" {
+			return nil, fmt.Errorf("the agent responded with %s: %s", resp.Status, failure.Error)
+		}
+		return nil, fmt.Errorf("the agent responded with %s", resp.Status)
+	}
+
+	var result runner.RunResult
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode the agent result: %w", err)
+	}
+	return &result, nil
+}
+
+// Merged is the summary of the runs of several agents, merged.
+type Merged struct {
+	// Success is set when every agent ran successfully.
+	Success bool `json:"success"`
+	Agents  int  `json:"agents"`
+	Failed  int  `json:"failed_agents"`
+
+	TotalRequests     int     `json:"total_requests"`
+	FailedRequests    int     `json:"failed_requests"`
+	ErrorRate         float64 `json:"error_rate"`
+	RequestRate       float64 `json:"request_rate_per_second"`
+	AvgResponseTime   float64 `json:"avg_response_time_ms"`
+	P95ResponseTime   float64 `json:"p95_response_time_ms"`
+	MaxResponseTime   float64 `json:"max_response_time_ms,omitempty"`
+	Iterations        int     `json:"iterations,omitempty"`
+	DataReceivedBytes int64   `json:"data_received_bytes,omitempty"`
+	DataSentBytes     int64   `json:"data_sent_bytes,omitempty"`
+
+	// Thresholds pass when they passed on every agent that evaluated them, and Checks sum
+	// the passes and fails of all the agents.
+	Thresholds []runner.ThresholdOutcome `json:"thresholds,omitempty"`
+	Checks     []runner.CheckOutcome     `json:"checks,omitempty"`
+
+	// Notes explain the approximations of the merge.
+	Notes []string `json:"notes"`
+}
+
+// Merge merges the summaries of the runs of the agents: counts and rates are summed,
+// average response times weighted by requests, and percentiles bounded by the highest
+// agent percentile, as the agents report no histograms.
+func Merge(runs []AgentRun) Merged {
+	merged := Merged{Success: len(runs) > 0, Agents: len(runs)}
+
+	thresholds := make(map[string]*runner.ThresholdOutcome)
+	var thresholdOrder []string
+	checks := make(map[string]*runner.CheckOutcome)
+	var checkOrder []string
+	var weightedAvg float64
+
+	for _, run := range runs {
+		if !run.Success {
+			merged.Success = false
+			merged.Failed++
+		}
+		if run.Result == nil {
+			continue
+		}
+
+		summary := run.Result.Summary
+		merged.TotalRequests += summary.TotalRequests
+		merged.FailedRequests += summary.FailedRequests
+		merged.RequestRate += summary.RequestRate
+		merged.Iterations += summary.Iterations
+		merged.DataReceivedBytes += summary.DataReceivedBytes
+		merged.DataSentBytes += summary.DataSentBytes
+		weightedAvg += summary.AvgResponseTime * float64(summary.TotalRequests)
+		merged.P95ResponseTime = max(merged.P95ResponseTime, summary.P95ResponseTime)
+		merged.MaxResponseTime = max(merged.MaxResponseTime, summary.MaxResponseTime)
+
+		for _, threshold := range run.Result.Thresholds {
+			key := threshold.Metric + "\x00" + threshold.Threshold
+			if existing, ok := thresholds[key]; ok {
+				existing.Passed = existing.Passed && threshold.Passed
+				continue
+			}
+			outcome := threshold
+			thresholds[key] = &outcome
+			thresholdOrder = append(thresholdOrder, key)
+		}
+		for _, check := range run.Result.Checks {
+			key := check.Group + "\x00" + check.Name
+			if existing, ok := checks[key]; ok {
+				existing.Passes += check.Passes
+				existing.Fails += check.Fails
+				continue
+			}
+			outcome := check
+			checks[key] = &outcome
+			checkOrder = append(checkOrder, key)
+		}
+	}
+
+	if merged.TotalRequests > 0 {
+		merged.ErrorRate = float64(merged.FailedRequests) / float64(merged.TotalRequests)
+		merged.AvgResponseTime = weightedAvg / float64(merged.TotalRequests)
+	}
+	for _, key := range thresholdOrder {
+		merged.Thresholds = append(merged.Thresholds, *thresholds[key])
+	}
+	for _, key := range checkOrder {
+		merged.Checks = append(merged.Checks, *checks[key])
+	}
+	sort.SliceStable(merged.Thresholds, func(i, j int) bool {
+		return !merged.Thresholds[i].Passed && merged.Thresholds[j].Passed
+	})
+
+	merged.Notes = []string{
+		"Requests, iterations, data and request rates are summed across agents; the average response time is weighted by the requests of each agent.",
+		"The p95 response time is the highest p95 of the agents, an upper bound of the p95 of all the requests, as agents report no histograms.",
+		"Each agent evaluates thresholds on its own metrics: a threshold passes when it passed on every agent.",
+	}
+
+	return merged
+}
@@ -0,0 +1,116 @@
+// Package distributed spreads runs across machines: k6-mcp agents, started with the agent
+// command on each load generator, run the k6 archives they receive over HTTP, and a
+// coordinator dispatches the same archive to all of them at once and merges the summaries
+// of their results. It is a primitive distributed mode for setups without Grafana Cloud.
+package distributed
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/oleiade/k6-mcp/internal/runner"
+)
+
+const (
+	// RunsPath is the path of the API agents receive runs on, and HealthPath the one
+	// reporting their readiness.
+	RunsPath   = "/v1/runs"
+	HealthPath = "/v1/health"
+
+	// MaxAgents is the maximum number of agents of a coordinator.
+	MaxAgents = 20
+
+	// requestTimeout bounds the dispatch of runs: the longest run, and a margin for the
+	// transfer of the archive and the result.
+	requestTimeout = runner.DefaultTimeout + time.Minute
+	// maxRequestBytes bounds the requests agents accept: an archive, base64-encoded, and
+	// the options of its run.
+	maxRequestBytes = runner.MaxArchiveBytes*4/3 + 1024*1024
+	// maxResponseBytes bounds the results coordinators read.
+	maxResponseBytes = 16 * 1024 * 1024
+	// maxOutputBytes is the size of the output of k6 agents return, from its end.
+	maxOutputBytes = 4 * 1024
+)
+
+// agentNamePattern matches the names of agents.
+var agentNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]{0,62}$`)
+
+// ErrNoToken is returned by agents started without a token.
+var ErrNoToken = errors.New("the agent requires a token: set K6_MCP_AGENT_TOKEN")
+
+// Agent is a remote k6-mcp agent runs are dispatched to.
+type Agent struct {
+	// Name identifies the agent, e.g. its region, and tags the metrics of its runs.
+	Name string `json:"name"`
+	// URL is the base URL of the API of the agent, e.g. https://eu-west.example.com:6566.
+	URL string `json:"url"`
+}
+
+// RunRequest is a run dispatched to an agent.
+type RunRequest struct {
+	// Archive is the k6 archive to run, as produced by k6 archive.
+	Archive []byte `json:"archive"`
+
+	VUs         int                 `json:"vus,omitempty"`
+	Duration    string              `json:"duration,omitempty"`
+	Iterations  int                 `json:"iterations,omitempty"`
+	Stages      []runner.Stage      `json:"stages,omitempty"`
+	Env         map[string]string   `json:"env,omitempty"`
+	Thresholds  map[string][]string `json:"thresholds,omitempty"`
+	Tags        map[string]string   `json:"tags,omitempty"`
+	TestName    string              `json:"test_name,omitempty"`
+	Environment string              `json:"environment,omitempty"`
+}
+
+// Options returns the run options of the request.
+func (r *RunRequest) Options() *runner.RunOptions {
+	return &runner.RunOptions{
+		VUs:         r.VUs,
+		Duration:    r.Duration,
+		Iterations:  r.Iterations,
+		Stages:      r.Stages,
+		Env:         r.Env,
+		Thresholds:  r.Thresholds,
+		Tags:        r.Tags,
+		TestName:    r.TestName,
+		Environment: r.Environment,
+	}
+}
+
+// ParseAgents parses a comma-separated list of agents, each as name=url, such as
+// "eu-west=https://eu.example.com:6566,us-east=https://us.example.com:6566".
+func ParseAgents(value string) ([]Agent, error) {
+	var agents []Agent
+	seen := make(map[string]bool)
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		name, rawURL, ok := strings.Cut(entry, "=")
+		name, rawURL = strings.TrimSpace(name), strings.TrimSpace(rawURL)
+		if !ok || !agentNamePattern.MatchString(name) {
+			return nil, fmt.Errorf("invalid agent %q: expected name=url, with a name of letters, digits and '_.-'", entry)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("agent %q is listed more than once", name)
+		}
+		seen[name] = true
+
+		u, err := url.Parse(rawURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("invalid URL of agent %q: expected an http or https URL, e.g. https://eu.example.com:6566", name)
+		}
+		agents = append(agents, Agent{Name: name, URL: strings.TrimRight(rawURL, "/")})
+	}
+
+	if len(agents) > MaxAgents {
+		return nil, fmt.Errorf("at most %d agents can be configured", MaxAgents)
+	}
+	return agents, nil
+}
@@ -0,0 +1,222 @@
+// Package docs provides access to the k6 documentation pages stored in the embedded index database.
+package docs
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrPageNotFound is returned when no documentation page exists at the requested path.
+var ErrPageNotFound = errors.New("documentation page not found")
+
+// Page is a full k6 documentation page.
+type Page struct {
+	// Path is the slash-separated path of the page relative to the documentation root,
+	// e.g. "javascript-api/k6-http/batch".
+	Path string `json:"path"`
+
+	// Title is the title of the page.
+	Title string `json:"title"`
+
+	// Description is a short summary of the page, if any.
+	Description string `json:"description,omitempty"`
+
+	// Content is the markdown content of the page.
+	Content string `json:"content,omitempty"`
+
+	// Updated is the time the page was last changed, zero when unknown.
+	Updated time.Time `json:"updated,omitzero"`
+}
+
+// Store reads documentation pages from the index database's pages table.
+type Store struct {
+	db *sql.DB
+}
+
+// NewStore returns a Store backed by the given index database.
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+// Get returns the page stored at path.
+//
+// Leading and trailing slashes as well as a ".md" suffix are ignored, so that
+// "/javascript-api/k6-http/batch.md" and "javascript-api/k6-http/batch" resolve to the same page.
+func (s *Store) Get(ctx context.Context, path string) (*Page, error) {
+	path = NormalizePath(path)
+
+	var page Page
+	var updated int64
+	err := s.db.QueryRowContext(ctx, `
+        SELECT path, title, description, content, updated
+        FROM pages
+        WHERE path = ?`, path).Scan(&page.Path, &page.Title, &page.Description, &page.Content, &updated)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %s", ErrPageNotFound, path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query page %q: %w", path, err)
+	}
+	if updated > 0 {
+		page.Updated = time.Unix(updated, 0).UTC()
+	}
+
+	return &page, nil
+}
+
+// LatestUpdate returns the time of the last change of the documentation, zero when the
+// index has no modification times.
+func (s *Store) LatestUpdate(ctx context.Context) (time.Time, error) {
+	var updated int64
+	if err := s.db.QueryRowContext(ctx, `SELECT COALESCE(MAX(updated), 0) FROM pages`).Scan(&updated); err != nil {
+		return time.Time{}, fmt.Errorf("failed to query the last documentation update: %w", err)
+	}
+	if updated == 0 {
+		return time.Time{}, nil
+	}
+
+	return time.Unix(updated, 0).UTC(), nil
+}
+
+// Recent lists up to limit pages under prefix updated since the given time, without their
+// content, the most recently updated first. An empty prefix lists pages of any section, and
+// a zero time pages of any age, including those of unknown age.
+func (s *Store) Recent(ctx context.Context, prefix string, since time.Time, limit int) ([]Page, error) {
+	prefix = NormalizePath(prefix)
+
+	pattern := "%"
+	if prefix != "" {
+		pattern = escapeLike(prefix) + "/%"
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+        SELECT path, title, description, updated
+        FROM pages
+        WHERE path LIKE ? ESCAPE '\' AND updated >= ?
+        ORDER BY updated DESC, path DESC
+        LIMIT ?`, pattern, since.Unix(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the pages updated under %q: %w", prefix, err)
+	}
+	defer rows.Close()
+
+	var pages []Page
+	for rows.Next() {
+		var page Page
+		var updated int64
+		if err := rows.Scan(&page.Path, &page.Title, &page.Description, &updated); err != nil {
+			return nil, fmt.Errorf("failed to scan page: %w", err)
+		}
+		if updated > 0 {
+			page.Updated = time.Unix(updated, 0).UTC()
+		}
+		pages = append(pages, page)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list the pages updated under %q: %w", prefix, err)
+	}
+
+	return pages, nil
+}
+
+// NormalizePath converts a user-provided documentation path into the form pages are stored under.
+func NormalizePath(path string) string {
+	path = strings.TrimSpace(path)
+	path = strings.Trim(path, "/")
+	return strings.TrimSuffix(path, ".md")
+}
+
+// Entry is a node of the documentation tree, as returned by Browse.
+type Entry struct {
+	// Path is the path of the entry relative to the documentation root.
+	Path string `json:"path"`
+
+	// Title is the title of the entry's page, or its last path segment if the
+	// entry is a section without a page of its own.
+	Title string `json:"title"`
+
+	// Description is a short summary of the entry's page, if any.
+	Description string `json:"description,omitempty"`
+
+	// Pages is the number of pages found under the entry, excluding the entry itself.
+	Pages int `json:"pages"`
+}
+
+// Browse lists the direct children of prefix in the documentation tree.
+//
+// An empty prefix lists the top-level categories (e.g. "javascript-api", "using-k6").
+// Entries are sorted by path.
+func (s *Store) Browse(ctx context.Context, prefix string) ([]Entry, error) {
+	prefix = NormalizePath(prefix)
+
+	pattern := "%"
+	if prefix != "" {
+		pattern = escapeLike(prefix) + "/%"
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+        SELECT path, title, description
+        FROM pages
+        WHERE path LIKE ? ESCAPE '\'
+        ORDER BY path`, pattern)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pages under %q: %w", prefix, err)
+	}
+	defer rows.Close()
+
+	entries := map[string]*Entry{}
+	for rows.Next() {
+		var page Page
+		if err := rows.Scan(&page.Path, &page.Title, &page.Description); err != nil {
+			return nil, fmt.Errorf("failed to scan page: %w", err)
+		}
+
+		rel := page.Path
+		if prefix != "" {
+			rel = strings.TrimPrefix(page.Path, prefix+"/")
+		}
+		if rel == "" {
+			continue
+		}
+
+		segment, _, nested := strings.Cut(rel, "/")
+		childPath := segment
+		if prefix != "" {
+			childPath = prefix + "/" + segment
+		}
+
+		entry, ok := entries[childPath]
+		if !ok {
+			entry = &Entry{Path: childPath, Title: segment}
+			entries[childPath] = entry
+		}
+
+		if nested {
+			entry.Pages++
+			continue
+		}
+		entry.Title = page.Title
+		entry.Description = page.Description
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list pages under %q: %w", prefix, err)
+	}
+
+	result := make([]Entry, 0, len(entries))
+	for _, entry := range entries {
+		result = append(result, *entry)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Path < result[j].Path })
+
+	return result, nil
+}
+
+// escapeLike escapes the LIKE wildcards in s, using backslash as the escape character.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
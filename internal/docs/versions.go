@@ -0,0 +1,321 @@
+package docs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MaxDiffPages is the maximum number of pages of each kind of change a diff reports.
+const MaxDiffPages = 100
+
+// ErrVersionNotFound is returned when the index holds no documentation of a k6 version.
+var ErrVersionNotFound = errors.New("documentation version not found")
+
+// versionPattern matches k6 versions, such as v1.2.x, 1.2 or v0.52.1.
+var versionPattern = regexp.MustCompile(`^v?(\d+)\.(\d+)(?:\.(?:\d+|x))?$`)
+
+// Version is a k6 version of the documentation held by the index, such as v1.2.x.
+type Version struct {
+	Name string `json:"version"`
+	// Latest reports whether the version is the latest one, which the other tools serve.
+	Latest bool `json:"latest,omitempty"`
+}
+
+// Versions lists the k6 versions of the documentation the index holds, the latest first.
+// Indexes built without versions list none.
+func (s *Store) Versions(ctx context.Context) ([]Version, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT version, latest FROM doc_versions`)
+	if err != nil {
+		if strings.Contains(err.Error(), "no such table") {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to list the documentation versions: %w", err)
+	}
+	defer rows.Close()
+
+	var versions []Version
+	for rows.Next() {
+		var version Version
+		if err := rows.Scan(&version.Name, &version.Latest); err != nil {
+			return nil, fmt.Errorf("failed to scan version: %w", err)
+		}
+		versions = append(versions, version)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list the documentation versions: %w", err)
+	}
+
+	sort.Slice(versions, func(i, j int) bool {
+		mi, ni := parseVersion(versions[i].Name)
+		mj, nj := parseVersion(versions[j].Name)
+		if mi != mj {
+			return mi > mj
+		}
+		return ni > nj
+	})
+
+	return versions, nil
+}
+
+// ResolveVersion returns the indexed version of a user-provided version, such as "1.2",
+// "v1.2.3", "v1.2.x" or "latest".
+func (s *Store) ResolveVersion(ctx context.Context, name string) (Version, error) {
+	versions, err := s.Versions(ctx)
+	if err != nil {
+		return Version{}, err
+	}
+
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "latest" {
+		for _, version := range versions {
+			if version.Latest {
+				return version, nil
+			}
+		}
+		return Version{}, fmt.Errorf("%w: latest", ErrVersionNotFound)
+	}
+
+	major, minor := parseVersion(name)
+	if major < 0 {
+		return Version{}, fmt.Errorf("invalid version %q: expected a k6 version such as 1.2, v1.2.x or v0.52.0", name)
+	}
+	for _, version := range versions {
+		if m, n := parseVersion(version.Name); m == major && n == minor {
+			return version, nil
+		}
+	}
+
+	return Version{}, fmt.Errorf("%w: v%d.%d.x", ErrVersionNotFound, major, minor)
+}
+
+// parseVersion returns the major and minor numbers of a version, or -1 when it is not one.
+func parseVersion(name string) (int, int) {
+	matches := versionPattern.FindStringSubmatch(name)
+	if matches == nil {
+		return -1, -1
+	}
+	major, _ := strconv.Atoi(matches[1])
+	minor, _ := strconv.Atoi(matches[2])
+	return major, minor
+}
+
+// VersionPages returns the pages of the version at path and nested under it, keyed by
+// path. An empty path returns every page of the version.
+func (s *Store) VersionPages(ctx context.Context, version Version, path string) (map[string]Page, error) {
+	path = NormalizePath(path)
+	pattern := "%"
+	if path != "" {
+		pattern = escapeLike(path) + "/%"
+	}
+
+	pages := map[string]Page{}
+	rows, err := s.db.QueryContext(ctx, `
+        SELECT path, title, description, content
+        FROM pages
+        WHERE path = ? OR path LIKE ? ESCAPE '\'`, path, pattern)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the pages under %q: %w", path, err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var page Page
+		if err := rows.Scan(&page.Path, &page.Title, &page.Description, &page.Content); err != nil {
+			return nil, fmt.Errorf("failed to scan page: %w", err)
+		}
+		pages[page.Path] = page
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list the pages under %q: %w", path, err)
+	}
+	if version.Latest {
+		return pages, nil
+	}
+
+	// Earlier versions only store the pages differing from the latest ones
+	versionRows, err := s.db.QueryContext(ctx, `
+        SELECT path, title, description, content
+        FROM page_versions
+        WHERE version = ? AND (path = ? OR path LIKE ? ESCAPE '\')`, version.Name, path, pattern)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the %s pages under %q: %w", version.Name, path, err)
+	}
+	defer versionRows.Close()
+	for versionRows.Next() {
+		var page Page
+		var content *string
+		if err := versionRows.Scan(&page.Path, &page.Title, &page.Description, &content); err != nil {
+			return nil, fmt.Errorf("failed to scan page: %w", err)
+		}
+		if content == nil {
+			delete(pages, page.Path)
+			continue
+		}
+		page.Content = *content
+		pages[page.Path] = page
+	}
+	if err := versionRows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list the %s pages under %q: %w", version.Name, path, err)
+	}
+
+	return pages, nil
+}
+
+// VersionDiff is the comparison of the pages of two versions of the documentation.
+type VersionDiff struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Path string `json:"path"`
+	// Added, Removed and Changed are the pages added, removed and changed from the From
+	// version to the To version, sorted by path, up to MaxDiffPages of each.
+	Added     []PageChange `json:"added"`
+	Removed   []PageChange `json:"removed"`
+	Changed   []PageChange `json:"changed"`
+	Unchanged int          `json:"unchanged"`
+	// Truncated reports whether pages beyond MaxDiffPages were left out.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// PageChange is a page added, removed or changed between two versions. Items are the
+// headings and table entries of pages, such as the functions and options they document.
+type PageChange struct {
+	Path         string   `json:"path"`
+	Title        string   `json:"title"`
+	AddedItems   []string `json:"added_items,omitempty"`
+	RemovedItems []string `json:"removed_items,omitempty"`
+	// AddedLines and RemovedLines count the non-blank lines of changed pages only found in
+	// the To and From versions, respectively, regardless of their order.
+	AddedLines   int `json:"added_lines,omitempty"`
+	RemovedLines int `json:"removed_lines,omitempty"`
+}
+
+// Diff compares the pages at path and nested under it between two versions.
+func (s *Store) Diff(ctx context.Context, from, to Version, path string) (*VersionDiff, error) {
+	fromPages, err := s.VersionPages(ctx, from, path)
+	if err != nil {
+		return nil, err
+	}
+	toPages, err := s.VersionPages(ctx, to, path)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := &VersionDiff{
+		From:    from.Name,
+		To:      to.Name,
+		Path:    NormalizePath(path),
+		Added:   []PageChange{},
+		Removed: []PageChange{},
+		Changed: []PageChange{},
+	}
+	for docPath, page := range toPages {
+		previous, ok := fromPages[docPath]
+		switch {
+		case !ok:
+			diff.Added = append(diff.Added, PageChange{Path: docPath, Title: page.Title, AddedItems: pageItems(page.Content)})
+		case previous.Content == page.Content && previous.Title == page.Title:
+			diff.Unchanged++
+		default:
+			change := PageChange{Path: docPath, Title: page.Title}
+			change.AddedItems, change.RemovedItems = diffStrings(pageItems(previous.Content), pageItems(page.Content))
+			added, removed := diffStrings(contentLines(previous.Content), contentLines(page.Content))
+			change.AddedLines, change.RemovedLines = len(added), len(removed)
+			diff.Changed = append(diff.Changed, change)
+		}
+	}
+	for docPath, page := range fromPages {
+		if _, ok := toPages[docPath]; !ok {
+			diff.Removed = append(diff.Removed, PageChange{Path: docPath, Title: page.Title, RemovedItems: pageItems(page.Content)})
+		}
+	}
+
+	for _, changes := range []*[]PageChange{&diff.Added, &diff.Removed, &diff.Changed} {
+		sort.Slice(*changes, func(i, j int) bool { return (*changes)[i].Path < (*changes)[j].Path })
+		if len(*changes) > MaxDiffPages {
+			*changes = (*changes)[:MaxDiffPages]
+			diff.Truncated = true
+		}
+	}
+
+	return diff, nil
+}
+
+// tableEntryPattern matches the first cell of table rows naming code, such as the options
+// of option references: | `discardResponseBodies` | ... |
+var tableEntryPattern = regexp.MustCompile("^\\|\\s*`([^`]+)`\\s*\\|")
+
+// pageItems returns the headings and code table entries of the markdown content, outside
+// of code blocks, in order and without duplicates.
+func pageItems(content string) []string {
+	var items []string
+	seen := map[string]bool{}
+	inCode := false
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "```") {
+			inCode = !inCode
+			continue
+		}
+		if inCode {
+			continue
+		}
+
+		item := ""
+		if strings.HasPrefix(line, "#") {
+			item = strings.TrimSpace(strings.TrimLeft(line, "#"))
+		} else if matches := tableEntryPattern.FindStringSubmatch(line); matches != nil {
+			item = matches[1]
+		}
+		if item != "" && !seen[item] {
+			seen[item] = true
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
+
+// contentLines returns the non-blank lines of the content, trimmed.
+func contentLines(content string) []string {
+	var lines []string
+	for _, line := range strings.Split(content, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// diffStrings returns the strings of to missing from from, and those of from missing from
+// to, counting repeated strings.
+func diffStrings(from, to []string) (added, removed []string) {
+	counts := make(map[string]int, len(from))
+	for _, s := range from {
+		counts[s]++
+	}
+	for _, s := range to {
+		if counts[s] > 0 {
+			counts[s]--
+			continue
+		}
+		added = append(added, s)
+	}
+
+	remaining := make(map[string]int, len(to))
+	for _, s := range to {
+		remaining[s]++
+	}
+	for _, s := range from {
+		if remaining[s] > 0 {
+			remaining[s]--
+			continue
+		}
+		removed = append(removed, s)
+	}
+
+	return added, removed
+}
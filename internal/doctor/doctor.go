@@ -0,0 +1,317 @@
+// Package doctor diagnoses the environment the server runs in: the k6 executable, the
+// SQLite FTS5 support the search tools need, the embedded search index, the directories
+// the server writes to and, optionally, a Chroma server.
+package doctor
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/oleiade/k6-mcp/internal/config"
+	"github.com/oleiade/k6-mcp/internal/k6bin"
+	"github.com/oleiade/k6-mcp/internal/search"
+)
+
+// Statuses of checks.
+const (
+	StatusOK   = "ok"
+	StatusWarn = "warn"
+	StatusFail = "fail"
+	// StatusSkipped is the status of optional checks that were not requested.
+	StatusSkipped = "skipped"
+)
+
+const (
+	// versionTimeout bounds the run of k6 version.
+	versionTimeout = 10 * time.Second
+
+	// chromaTimeout bounds the requests to the Chroma server.
+	chromaTimeout = 5 * time.Second
+)
+
+// chromaHeartbeatPaths are the heartbeat endpoints of the Chroma API, newest first.
+var chromaHeartbeatPaths = []string{"/api/v2/heartbeat", "/api/v1/heartbeat"}
+
+// Check is the outcome of a diagnostic.
+type Check struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Detail string `json:"detail"`
+	// Fix is the action resolving a failure or warning.
+	Fix string `json:"fix,omitempty"`
+}
+
+// Report is the outcome of all the diagnostics.
+type Report struct {
+	// OK is false when a check failed; warnings don't fail the report.
+	OK     bool    `json:"ok"`
+	Checks []Check `json:"checks"`
+}
+
+// Options configures the diagnostics.
+type Options struct {
+	Config config.Config
+
+	// Index is the embedded search index database.
+	Index []byte
+
+	// ChromaURL is the base URL of the Chroma server to check, which is skipped when empty.
+	ChromaURL string
+}
+
+// Run runs the diagnostics.
+func Run(ctx context.Context, opts Options) Report {
+	checks := []Check{
+		CheckK6(ctx, opts.Config.K6Dir),
+		CheckFTS5(ctx),
+		CheckIndex(ctx, opts.Index),
+		CheckWritable("temp directory", os.TempDir(), ""),
+		CheckWritable("cache directory", opts.Config.CacheDir, "K6_MCP_CACHE_DIR"),
+		CheckWritable("data directory", opts.Config.DataDir, "K6_MCP_DATA_DIR"),
+		CheckChroma(ctx, opts.ChromaURL),
+	}
+
+	report := Report{OK: true, Checks: checks}
+	for _, check := range checks {
+		if check.Status == StatusFail {
+			report.OK = false
+		}
+	}
+
+	return report
+}
+
+// CheckK6 checks that k6 is installed, on the PATH or in the managed directory, and runs.
+func CheckK6(ctx context.Context, managedDir string) Check {
+	check := Check{Name: "k6"}
+
+	k6bin.SetManagedDir(managedDir)
+	path, err := k6bin.Find()
+	if err != nil {
+		check.Status = StatusFail
+		check.Detail = "k6 is neither on the PATH nor in " + managedDir + "."
+		check.Fix = "Install k6 (https://grafana.com/docs/k6/latest/set-up/install-k6/), or set K6_MCP_K6_DOWNLOAD=true and install it with the setup_k6 tool."
+		return check
+	}
+
+	versionCtx, cancel := context.WithTimeout(ctx, versionTimeout)
+	defer cancel()
+	// #nosec G204 - path is the k6 executable found on the PATH or installed by setup_k6
+	output, err := exec.CommandContext(versionCtx, path, "version").CombinedOutput()
+	if err != nil {
+		check.Status = StatusFail
+		check.Detail = fmt.Sprintf("%s fails to run: %v.", path, err)
+		check.Fix = "Reinstall k6 for this platform, or remove the broken executable so that setup_k6 can install one."
+		return check
+	}
+
+	check.Status = StatusOK
+	check.Detail = fmt.Sprintf("%s (%s)", firstLine(string(output)), path)
+	return check
+}
+
+// CheckFTS5 checks that SQLite was compiled with the FTS5 extension.
+func CheckFTS5(ctx context.Context) Check {
+	check := Check{Name: "fts5"}
+
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err == nil {
+		defer func() { _ = db.Close() }()
+		_, err = db.ExecContext(ctx, `CREATE VIRTUAL TABLE doctor USING fts5(content);`)
+	}
+	if err != nil {
+		check.Status = StatusFail
+		check.Detail = "SQLite lacks the FTS5 extension: " + err.Error() + "."
+		check.Fix = "Rebuild the server with the fts5 build tag: go build -tags fts5 ./cmd/k6-mcp."
+		return check
+	}
+
+	check.Status = StatusOK
+	check.Detail = "SQLite supports FTS5 full-text search."
+	return check
+}
+
+// CheckIndex checks the integrity of the search index database: of its SQLite pages, of
+// its full-text index, and that it holds documentation.
+func CheckIndex(ctx context.Context, index []byte) Check {
+	check := Check{Name: "index", Status: StatusFail, Fix: "Rebuild the index with just index, then rebuild the server."}
+	if len(index) == 0 {
+		check.Detail = "The server embeds no search index."
+		return check
+	}
+
+	// The full-text integrity check writes, so it runs against a copy of the index
+	file, err := os.CreateTemp("", "k6-mcp-doctor-*.db")
+	if err != nil {
+		check.Detail = "The index could not be copied to the temp directory: " + err.Error() + "."
+		check.Fix = "Make the temp directory writable, or point TMPDIR at a writable directory."
+		return check
+	}
+	defer func() { _ = os.Remove(file.Name()) }()
+	_, err = file.Write(index)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		check.Detail = "The index could not be copied to the temp directory: " + err.Error() + "."
+		check.Fix = "Free up space in the temp directory, or point TMPDIR at another directory."
+		return check
+	}
+
+	db, err := sql.Open("sqlite3", file.Name())
+	if err != nil {
+		check.Detail = "The index could not be opened: " + err.Error() + "."
+		return check
+	}
+	defer func() { _ = db.Close() }()
+
+	var result string
+	if err := db.QueryRowContext(ctx, `PRAGMA quick_check;`).Scan(&result); err != nil || result != "ok" {
+		check.Detail = "The index database is corrupt: " + errorOr(err, result) + "."
+		return check
+	}
+
+	language, err := search.LookupLanguage(search.DefaultLanguage)
+	if err != nil {
+		check.Detail = err.Error()
+		return check
+	}
+	table := language.Table()
+	// #nosec G201 - the table is the name of a documentation table, not user input
+	if _, err := db.ExecContext(ctx, fmt.Sprintf(`INSERT INTO %s(%s) VALUES('integrity-check');`, table, table)); err != nil {
+		check.Detail = "The full-text index is inconsistent with the documentation: " + err.Error() + "."
+		return check
+	}
+
+	var chunks, pages int
+	// #nosec G201 - the table is the name of a documentation table, not user input
+	if err := db.QueryRowContext(ctx, fmt.Sprintf(`SELECT count(*) FROM %s;`, table)).Scan(&chunks); err != nil {
+		check.Detail = "The documentation table could not be read: " + err.Error() + "."
+		return check
+	}
+	if err := db.QueryRowContext(ctx, `SELECT count(*) FROM pages;`).Scan(&pages); err != nil {
+		check.Detail = "The pages table could not be read: " + err.Error() + "."
+		return check
+	}
+	if chunks == 0 || pages == 0 {
+		check.Detail = fmt.Sprintf("The index is empty: %d documentation chunks and %d pages.", chunks, pages)
+		return check
+	}
+
+	check.Status = StatusOK
+	check.Detail = fmt.Sprintf("%d documentation chunks and %d pages, %.1f MB.", chunks, pages, float64(len(index))/(1024*1024))
+	check.Fix = ""
+	return check
+}
+
+// CheckWritable checks that the server can create files in dir, creating it if missing.
+// variable is the environment variable setting the directory, if any.
+func CheckWritable(name, dir, variable string) Check {
+	check := Check{Name: name}
+
+	err := os.MkdirAll(dir, 0o750)
+	if err == nil {
+		var file *os.File
+		if file, err = os.CreateTemp(dir, ".doctor-*"); err == nil {
+			_ = file.Close()
+			_ = os.Remove(file.Name())
+		}
+	}
+	if err != nil {
+		check.Status = StatusFail
+		check.Detail = fmt.Sprintf("%s is not writable: %v.", dir, err)
+		if variable != "" {
+			check.Fix = fmt.Sprintf("Grant write access to %s, or set %s to a writable directory.", dir, variable)
+		} else {
+			check.Fix = fmt.Sprintf("Grant write access to %s, or set TMPDIR to a writable directory.", dir)
+		}
+		return check
+	}
+
+	check.Status = StatusOK
+	check.Detail = filepath.Clean(dir) + " is writable."
+	return check
+}
+
+// CheckChroma checks that the Chroma server at baseURL answers its heartbeat endpoint. It
+// is skipped when baseURL is empty.
+func CheckChroma(ctx context.Context, baseURL string) Check {
+	check := Check{Name: "chroma"}
+	if baseURL == "" {
+		check.Status = StatusSkipped
+		check.Detail = "No Chroma URL was given."
+		return check
+	}
+
+	client := &http.Client{Timeout: chromaTimeout}
+	var err error
+	for _, path := range chromaHeartbeatPaths {
+		if err = heartbeat(ctx, client, strings.TrimSuffix(baseURL, "/")+path); err == nil {
+			check.Status = StatusOK
+			check.Detail = "Chroma answers at " + baseURL + "."
+			return check
+		}
+	}
+
+	// Chroma is an optional companion of the server: it being down fails no tool
+	check.Status = StatusWarn
+	check.Detail = fmt.Sprintf("Chroma is unreachable at %s: %v.", baseURL, err)
+	check.Fix = "Start Chroma, e.g. with docker run -p 8000:8000 chromadb/chroma, or check the URL."
+	return check
+}
+
+// heartbeat requests a Chroma heartbeat endpoint.
+func heartbeat(ctx context.Context, client *http.Client, url string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.New("unexpected status " + resp.Status)
+	}
+	return nil
+}
+
+// Print writes the report to w, one line per check followed by its fix.
+func Print(w io.Writer, report Report) {
+	for _, check := range report.Checks {
+		_, _ = fmt.Fprintf(w, "[%-7s] %-16s %s\n", check.Status, check.Name, check.Detail)
+		if check.Fix != "" {
+			_, _ = fmt.Fprintf(w, "%26s %s\n", "fix:", check.Fix)
+		}
+	}
+
+	if report.OK {
+		_, _ = fmt.Fprintln(w, "\nAll checks passed.")
+	} else {
+		_, _ = fmt.Fprintln(w, "\nSome checks failed: apply the fixes above, then run k6-mcp doctor again.")
+	}
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(s), "\n")
+	return line
+}
+
+func errorOr(err error, result string) string {
+	if err != nil {
+		return err.Error()
+	}
+	return result
+}
@@ -0,0 +1,165 @@
+// Package execx runs the commands the server spawns, such as k6: with a timeout, in a
+// process group killed when their context is cancelled, with bounded output capture or
+// streaming, and with their errors classified.
+package execx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"time"
+)
+
+const (
+	// DefaultOutputLimit is the amount of captured output kept per stream. Earlier output
+	// is dropped.
+	DefaultOutputLimit = 64 * 1024
+
+	// waitDelay bounds the time waiting for the output of cancelled commands.
+	waitDelay = 5 * time.Second
+)
+
+var (
+	// ErrTimeout is wrapped by the errors of commands killed by their timeout.
+	ErrTimeout = errors.New("command timed out")
+	// ErrCanceled is wrapped by the errors of commands killed because their context was
+	// cancelled, e.g. because the client cancelled the request or disconnected.
+	ErrCanceled = errors.New("command was cancelled")
+)
+
+// Result is the outcome of a command.
+type Result struct {
+	// Stdout and Stderr hold the tail of the output of the streams not redirected with
+	// WithStdout or WithStderr.
+	Stdout string
+	Stderr string
+	// ExitCode is the exit code of the command, or -1 when it didn't exit normally.
+	ExitCode int
+	Duration time.Duration
+}
+
+// Option configures a command run by Run.
+type Option func(*command)
+
+type command struct {
+	timeout     time.Duration
+	env         []string
+	dir         string
+	stdout      io.Writer
+	stderr      io.Writer
+	outputLimit int
+}
+
+// WithTimeout kills the command once the timeout elapses.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *command) {
+		c.timeout = timeout
+	}
+}
+
+// WithEnv sets the environment of the command. The command inherits no environment
+// otherwise: the server's may hold secrets.
+func WithEnv(env []string) Option {
+	return func(c *command) {
+		c.env = env
+	}
+}
+
+// WithDir sets the working directory of the command.
+func WithDir(dir string) Option {
+	return func(c *command) {
+		c.dir = dir
+	}
+}
+
+// WithStdout streams the standard output of the command to w, as it is produced, rather
+// than capturing it.
+func WithStdout(w io.Writer) Option {
+	return func(c *command) {
+		c.stdout = w
+	}
+}
+
+// WithStderr streams the standard error of the command to w, as it is produced, rather
+// than capturing it.
+func WithStderr(w io.Writer) Option {
+	return func(c *command) {
+		c.stderr = w
+	}
+}
+
+// WithOutputLimit sets the amount of captured output kept per stream, instead of
+// DefaultOutputLimit.
+func WithOutputLimit(limit int) Option {
+	return func(c *command) {
+		c.outputLimit = limit
+	}
+}
+
+// Run runs the named command until it exits, its timeout elapses or ctx is cancelled.
+// The returned error wraps ErrTimeout or ErrCanceled when the command was killed, and
+// an *exec.ExitError when it exited with a non-zero code; the result is returned in
+// every case, with the output produced until then.
+func Run(ctx context.Context, name string, args []string, opts ...Option) (*Result, error) {
+	c := command{outputLimit: DefaultOutputLimit, env: []string{}}
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	cmdCtx := ctx
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		cmdCtx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	// #nosec G204 - callers validate the executable and sanitize the arguments
+	cmd := exec.CommandContext(cmdCtx, name, args...)
+	cmd.Env = c.env
+	cmd.Dir = c.dir
+	setProcessGroup(cmd)
+
+	var stdout, stderr *TailBuffer
+	cmd.Stdout, cmd.Stderr = c.stdout, c.stderr
+	if cmd.Stdout == nil {
+		stdout = NewTailBuffer(c.outputLimit)
+		cmd.Stdout = stdout
+	}
+	if cmd.Stderr == nil {
+		stderr = NewTailBuffer(c.outputLimit)
+		cmd.Stderr = stderr
+	}
+
+	startTime := time.Now()
+	err := cmd.Run()
+
+	result := &Result{Duration: time.Since(startTime)}
+	if stdout != nil {
+		result.Stdout = stdout.String()
+	}
+	if stderr != nil {
+		result.Stderr = stderr.String()
+	}
+
+	if err == nil {
+		return result, nil
+	}
+
+	result.ExitCode = -1
+	var exitError *exec.ExitError
+	if errors.As(err, &exitError) {
+		result.ExitCode = exitError.ExitCode()
+	}
+
+	// Killed processes exit with an *exec.ExitError, not the error of their context
+	switch ctxErr := cmdCtx.Err(); {
+	case errors.Is(ctxErr, context.DeadlineExceeded):
+		return result, fmt.Errorf("%w after %v: %w", ErrTimeout, c.timeout, err)
+	case errors.Is(ctxErr, context.Canceled):
+		return result, fmt.Errorf("%w: %w", ErrCanceled, err)
+	default:
+		return result, fmt.Errorf("command execution failed: %w", err)
+	}
+}
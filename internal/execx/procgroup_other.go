@@ -0,0 +1,11 @@
+//go:build !unix
+
+package execx
+
+import "os/exec"
+
+// setProcessGroup only bounds the time waiting for the output of cancelled commands on
+// platforms without process groups: the cancellation of the context kills the command alone.
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.WaitDelay = waitDelay
+}
@@ -0,0 +1,44 @@
+package execx
+
+import "fmt"
+
+// TailBuffer is an io.Writer keeping only the last limit bytes written to it.
+type TailBuffer struct {
+	buf     []byte
+	limit   int
+	dropped int64
+}
+
+// NewTailBuffer returns a tail buffer keeping the last limit bytes written to it.
+func NewTailBuffer(limit int) *TailBuffer {
+	return &TailBuffer{limit: limit}
+}
+
+// Write implements io.Writer. It never fails.
+func (t *TailBuffer) Write(p []byte) (int, error) {
+	t.buf = append(t.buf, p...)
+
+	// Compact only once the buffer holds twice the limit, so that writes stay amortized O(1)
+	if len(t.buf) > 2*t.limit {
+		excess := len(t.buf) - t.limit
+		t.dropped += int64(excess)
+		t.buf = append(t.buf[:0], t.buf[excess:]...)
+	}
+
+	return len(p), nil
+}
+
+// String returns the kept output, prefixed with a marker when earlier output was dropped.
+func (t *TailBuffer) String() string {
+	kept := t.buf
+	dropped := t.dropped
+	if excess := len(kept) - t.limit; excess > 0 {
+		kept = kept[excess:]
+		dropped += int64(excess)
+	}
+
+	if dropped == 0 {
+		return string(kept)
+	}
+	return fmt.Sprintf("[... %d bytes of earlier output truncated ...]\n%s", dropped, kept)
+}
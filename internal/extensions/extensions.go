@@ -0,0 +1,371 @@
+// Package extensions indexes the metadata of the k6 extension registry in the index
+// database, and finds the extensions adding the protocols and features k6 lacks, such as
+// Kafka, MQTT or SQL, with the instructions to build k6 with them.
+package extensions
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	// DefaultRegistryURL is the URL of the k6 extension registry.
+	DefaultRegistryURL = "https://registry.k6.io/registry.json"
+
+	// maxRegistryBytes bounds the size of the registry downloaded at indexing.
+	maxRegistryBytes = 16 << 20
+
+	// StaleAfter is the time without changes to its repository after which an extension
+	// is considered unmaintained.
+	StaleAfter = 365 * 24 * time.Hour
+)
+
+// Tiers of extensions, from the most supported.
+const (
+	TierOfficial  = "official"
+	TierPartner   = "partner"
+	TierCommunity = "community"
+)
+
+// Maintenance statuses of extensions.
+const (
+	StatusMaintained = "maintained"
+	StatusStale      = "stale"
+	StatusArchived   = "archived"
+)
+
+// ErrUnavailable is returned when the index database has no extension index.
+var ErrUnavailable = errors.New("the index has no extensions")
+
+// tierBoosts multiply the BM25 scores of the extensions of each tier, so that supported
+// extensions rank first among similar matches.
+var tierBoosts = map[string]float64{TierOfficial: 2, TierPartner: 1.5, TierCommunity: 1}
+
+// stopWords are the words of questions left out of extension searches, such as "can k6
+// test Kafka?", which every extension would match.
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "can": true, "do": true, "does": true, "for": true,
+	"how": true, "i": true, "in": true, "is": true, "it": true, "k6": true, "of": true,
+	"on": true, "or": true, "the": true, "to": true, "use": true, "using": true, "with": true,
+	"test": true, "testing": true, "load": true, "support": true, "supports": true,
+	"extension": true, "extensions": true, "xk6": true, "x": true, "what": true, "which": true,
+}
+
+// Extension is an extension of the registry.
+type Extension struct {
+	// Module is the Go module path of the extension, which k6 is built with.
+	Module      string `json:"module"`
+	Description string `json:"description"`
+	// Imports are the JavaScript modules the extension adds, such as k6/x/kafka, and
+	// Outputs the outputs, used with k6 run --out.
+	Imports    []string `json:"imports,omitempty"`
+	Outputs    []string `json:"outputs,omitempty"`
+	Tier       string   `json:"tier"`
+	Categories []string `json:"categories,omitempty"`
+	Versions   []string `json:"versions,omitempty"`
+	// Cgo reports whether building the extension requires cgo.
+	Cgo  bool        `json:"cgo,omitempty"`
+	Repo *Repository `json:"repo,omitempty"`
+}
+
+// Repository is the source repository of an extension.
+type Repository struct {
+	URL      string   `json:"url"`
+	Stars    int      `json:"stars"`
+	License  string   `json:"license,omitempty"`
+	Archived bool     `json:"archived,omitempty"`
+	Topics   []string `json:"topics,omitempty"`
+	// Timestamp is the Unix time of the last change to the repository.
+	Timestamp float64 `json:"timestamp,omitempty"`
+}
+
+// Match is an extension matching a search, with its maintenance status and the
+// instructions to use it.
+type Match struct {
+	Module      string   `json:"module"`
+	Description string   `json:"description"`
+	Imports     []string `json:"imports,omitempty"`
+	Outputs     []string `json:"outputs,omitempty"`
+	Tier        string   `json:"tier"`
+	Categories  []string `json:"categories,omitempty"`
+	// LatestVersion is the latest version of the extension, if any is released.
+	LatestVersion string `json:"latest_version,omitempty"`
+	Status        string `json:"status"`
+	Repository    string `json:"repository,omitempty"`
+	Stars         int    `json:"stars"`
+	License       string `json:"license,omitempty"`
+	// Updated is the date of the last change to the repository, if known.
+	Updated string   `json:"updated,omitempty"`
+	Cgo     bool     `json:"cgo,omitempty"`
+	Build   []string `json:"build"`
+}
+
+// Options are the options of extension searches.
+type Options struct {
+	// Tier restricts the search to the extensions of a tier, when set.
+	Tier string
+	// IncludeArchived also returns the extensions whose repository is archived.
+	IncludeArchived bool
+	MaxResults      int
+}
+
+// Fetch downloads the extensions of the registry at url.
+func Fetch(ctx context.Context, client *http.Client, url string) ([]Extension, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid registry URL: %w", err)
+	}
+	request.Header.Set("User-Agent", "k6-mcp")
+
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download the registry: %w", err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download the registry: HTTP %d", response.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(response.Body, maxRegistryBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to download the registry: %w", err)
+	}
+	if len(body) > maxRegistryBytes {
+		return nil, fmt.Errorf("the registry exceeds %d bytes", maxRegistryBytes)
+	}
+
+	var extensions []Extension
+	if err := json.Unmarshal(body, &extensions); err != nil {
+		return nil, fmt.Errorf("failed to read the registry: %w", err)
+	}
+
+	return extensions, nil
+}
+
+// Build replaces the extension index of the database with the extensions. k6 itself,
+// listed in the registry as the provider of its built-in modules, is left out. It returns
+// the number of indexed extensions.
+func Build(db *sql.DB, extensions []Extension) (int, error) {
+	statements := []string{
+		`DROP TABLE IF EXISTS extensions;`,
+		`DROP TABLE IF EXISTS extensions_fts;`,
+		`CREATE TABLE extensions (
+            module   TEXT PRIMARY KEY,
+            metadata TEXT NOT NULL,
+            tier     TEXT NOT NULL,
+            archived INTEGER NOT NULL DEFAULT 0
+        );`,
+		`CREATE VIRTUAL TABLE extensions_fts USING fts5(
+            module,
+            description,
+            keywords,
+            tokenize = 'porter unicode61'
+        );`,
+	}
+	for _, statement := range statements {
+		if _, err := db.Exec(statement); err != nil {
+			return 0, fmt.Errorf("failed to create the extension index: %w", err)
+		}
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	count := 0
+	for _, extension := range extensions {
+		if extension.Module == "" || extension.Module == "go.k6.io/k6" {
+			continue
+		}
+
+		metadata, err := json.Marshal(extension)
+		if err != nil {
+			return 0, err
+		}
+		archived := extension.Repo != nil && extension.Repo.Archived
+
+		result, err := tx.Exec(`INSERT INTO extensions (module, metadata, tier, archived) VALUES (?, ?, ?, ?)`,
+			extension.Module, string(metadata), extension.Tier, archived)
+		if err != nil {
+			return 0, fmt.Errorf("failed to index extension %s: %w", extension.Module, err)
+		}
+		rowID, err := result.LastInsertId()
+		if err != nil {
+			return 0, err
+		}
+
+		_, err = tx.Exec(`INSERT INTO extensions_fts (rowid, module, description, keywords) VALUES (?, ?, ?, ?)`,
+			rowID, extension.Module, extension.Description, strings.Join(extension.keywords(), " "))
+		if err != nil {
+			return 0, fmt.Errorf("failed to index extension %s: %w", extension.Module, err)
+		}
+		count++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// keywords returns the words extensions are also found by: their imports, outputs,
+// categories and repository topics.
+func (e Extension) keywords() []string {
+	keywords := append(append(append([]string{}, e.Imports...), e.Outputs...), e.Categories...)
+	if e.Repo != nil {
+		keywords = append(keywords, e.Repo.Topics...)
+	}
+	return keywords
+}
+
+// Store finds extensions in the extension index of the index database.
+type Store struct {
+	db *sql.DB
+}
+
+// NewStore returns a Store backed by the given index database.
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+// Find returns the extensions matching any significant word of the query, such as a
+// protocol or a technology, ranked by relevance and tier.
+func (s *Store) Find(ctx context.Context, query string, opts Options) ([]Match, error) {
+	match := matchQuery(query)
+	if match == "" {
+		return nil, nil
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+        SELECT e.metadata
+        FROM extensions_fts f
+        JOIN extensions e ON e.rowid = f.rowid
+        WHERE extensions_fts MATCH ?
+          AND (? = '' OR e.tier = ?)
+          AND (? OR e.archived = 0)
+        ORDER BY bm25(extensions_fts, 2.0, 1.0, 3.0)
+            * CASE e.tier WHEN ? THEN ? WHEN ? THEN ? ELSE ? END
+        LIMIT ?`,
+		match, opts.Tier, opts.Tier, opts.IncludeArchived,
+		TierOfficial, tierBoosts[TierOfficial], TierPartner, tierBoosts[TierPartner], tierBoosts[TierCommunity],
+		opts.MaxResults)
+	if err != nil {
+		if strings.Contains(err.Error(), "no such table") {
+			return nil, ErrUnavailable
+		}
+		return nil, fmt.Errorf("failed to query extensions: %w", err)
+	}
+	defer rows.Close()
+
+	var matches []Match
+	for rows.Next() {
+		var metadata string
+		if err := rows.Scan(&metadata); err != nil {
+			return nil, fmt.Errorf("failed to scan extension: %w", err)
+		}
+		var extension Extension
+		if err := json.Unmarshal([]byte(metadata), &extension); err != nil {
+			return nil, fmt.Errorf("failed to read extension: %w", err)
+		}
+		matches = append(matches, extension.match(time.Now()))
+	}
+
+	return matches, rows.Err()
+}
+
+// matchQuery returns the FTS5 query matching any significant word of the query, as a
+// prefix, or "" when it has none.
+func matchQuery(query string) string {
+	words := strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	var terms []string
+	seen := make(map[string]bool)
+	for _, word := range words {
+		if stopWords[word] || seen[word] || len(word) < 2 {
+			continue
+		}
+		seen[word] = true
+		terms = append(terms, `"`+word+`"*`)
+	}
+
+	return strings.Join(terms, " OR ")
+}
+
+// match returns the match of the extension, with its status at the given time.
+func (e Extension) match(now time.Time) Match {
+	match := Match{
+		Module:      e.Module,
+		Description: e.Description,
+		Imports:     e.Imports,
+		Outputs:     e.Outputs,
+		Tier:        e.Tier,
+		Categories:  e.Categories,
+		Cgo:         e.Cgo,
+		Status:      StatusMaintained,
+	}
+	if len(e.Versions) > 0 {
+		match.LatestVersion = e.Versions[0]
+	}
+
+	if e.Repo != nil {
+		match.Repository = e.Repo.URL
+		match.Stars = e.Repo.Stars
+		match.License = e.Repo.License
+		if e.Repo.Timestamp > 0 {
+			updated := time.Unix(int64(e.Repo.Timestamp), 0).UTC()
+			match.Updated = updated.Format(time.DateOnly)
+			if now.Sub(updated) > StaleAfter {
+				match.Status = StatusStale
+			}
+		}
+		if e.Repo.Archived {
+			match.Status = StatusArchived
+		}
+	}
+
+	match.Build = e.instructions(match.LatestVersion)
+
+	return match
+}
+
+// instructions returns the instructions to use the extension: building k6 with it with
+// xk6, natively or with Docker, and how scripts use it.
+func (e Extension) instructions(version string) []string {
+	with := e.Module
+	if version != "" {
+		with += "@" + version
+	}
+
+	instructions := []string{
+		"Build a k6 binary with the extension with xk6 (go install go.k6.io/xk6/cmd/xk6@latest): xk6 build --with " + with,
+		`Or build it with Docker, without a Go toolchain: docker run --rm -u "$(id -u):$(id -g)" -v "${PWD}:/xk6" grafana/xk6 build --with ` + with,
+	}
+	if e.Cgo {
+		instructions = append(instructions, "The extension requires cgo: build it with CGO_ENABLED=1 and a C toolchain")
+	}
+	for _, module := range e.Imports {
+		instructions = append(instructions, fmt.Sprintf("Import it in scripts from %q, and run them with the built binary: ./k6 run script.js", module))
+	}
+	for _, output := range e.Outputs {
+		instructions = append(instructions, fmt.Sprintf("Send the metrics of runs to it with the built binary: ./k6 run --out %s script.js", output))
+	}
+	if e.Tier == TierOfficial {
+		instructions = append(instructions, "Official extensions are also provisioned automatically by recent k6 versions and Grafana Cloud k6 when scripts import them, without a custom build")
+	}
+
+	return instructions
+}
@@ -0,0 +1,146 @@
+// Package federation proxies documentation tools to an upstream k6-mcp server, served over
+// streamable HTTP, so that lightweight local installs can defer the documentation index to
+// a server shared by a team when their own index is unavailable or stale.
+package federation
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/client/transport"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/oleiade/k6-mcp/internal/buildinfo"
+)
+
+// requestTimeout bounds the calls to the upstream server.
+const requestTimeout = 30 * time.Second
+
+// Config holds the settings of the upstream server.
+type Config struct {
+	// URL is the streamable HTTP endpoint of the upstream server, e.g.
+	// https://k6-mcp.example.com/mcp.
+	URL string
+	// Token is the bearer token the upstream server requires, if any.
+	Token string
+}
+
+// Validate checks the URL of the upstream server, if any.
+func (c Config) Validate() error {
+	if c.URL == "" {
+		return nil
+	}
+	u, err := url.Parse(c.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid upstream URL %q: expected an http or https URL, e.g. https://k6-mcp.example.com/mcp", c.URL)
+	}
+	return nil
+}
+
+// Client calls the tools of the upstream server. It connects on its first call, and
+// reconnects on the call following a failure.
+type Client struct {
+	config Config
+
+	mu      sync.Mutex
+	session *client.Client
+}
+
+// NewClient returns a client of the upstream server configured by config. Clients without
+// a URL proxy nothing.
+func NewClient(config Config) *Client {
+	config.URL = strings.TrimRight(config.URL, "/")
+	return &Client{config: config}
+}
+
+// Configured reports whether an upstream server is configured.
+func (c *Client) Configured() bool {
+	return c != nil && c.config.URL != ""
+}
+
+// URL returns the endpoint of the upstream server.
+func (c *Client) URL() string {
+	return c.config.URL
+}
+
+// CallTool calls the named tool of the upstream server with the arguments.
+func (c *Client) CallTool(ctx context.Context, name string, args map[string]any) (*mcp.CallToolResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+
+	session, err := c.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = name
+	request.Params.Arguments = args
+	result, err := session.CallTool(ctx, request)
+	if err != nil {
+		c.reset(session)
+		return nil, fmt.Errorf("upstream call of %s failed: %w", name, err)
+	}
+	return result, nil
+}
+
+// Close closes the connection to the upstream server, if any.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.session == nil {
+		return nil
+	}
+	err := c.session.Close()
+	c.session = nil
+	return err
+}
+
+// connect returns the session with the upstream server, initializing it first if needed.
+func (c *Client) connect(ctx context.Context) (*client.Client, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.session != nil {
+		return c.session, nil
+	}
+
+	var options []transport.StreamableHTTPCOption
+	if c.config.Token != "" {
+		options = append(options, transport.WithHTTPHeaders(map[string]string{"Authorization": "Bearer " + c.config.Token}))
+	}
+	session, err := client.NewStreamableHttpClient(c.config.URL, options...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create upstream client: %w", err)
+	}
+	if err := session.Start(ctx); err != nil {
+		return nil, fmt.Errorf("failed to connect to upstream server: %w", err)
+	}
+
+	initialize := mcp.InitializeRequest{}
+	initialize.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+	initialize.Params.ClientInfo = mcp.Implementation{Name: "k6-mcp", Version: buildinfo.Version}
+	if _, err := session.Initialize(ctx, initialize); err != nil {
+		_ = session.Close()
+		return nil, fmt.Errorf("failed to initialize upstream session: %w", err)
+	}
+
+	c.session = session
+	return session, nil
+}
+
+// reset drops the session after a failure, unless another call replaced it already.
+func (c *Client) reset(session *client.Client) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.session == session {
+		_ = c.session.Close()
+		c.session = nil
+	}
+}
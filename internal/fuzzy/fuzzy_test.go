@@ -0,0 +1,32 @@
+package fuzzy
+
+import "testing"
+
+func TestEditDistance(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{a: "", b: "", want: 0},
+		{a: "", b: "vus", want: 3},
+		{a: "vus", b: "", want: 3},
+		{a: "duration", b: "duration", want: 0},
+		{a: "maxRedirect", b: "maxRedirects", want: 1},
+		{a: "http_req_durations", b: "http_req_duration", want: 1},
+		{a: "http_req_duraton", b: "http_req_duration", want: 1},
+		{a: "iteratoins", b: "iterations", want: 2},
+		{a: "kitten", b: "sitting", want: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+"/"+tt.b, func(t *testing.T) {
+			t.Parallel()
+
+			if got := EditDistance(tt.a, tt.b); got != tt.want {
+				t.Errorf("EditDistance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
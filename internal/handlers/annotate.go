@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log/slog"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/oleiade/k6-mcp/internal/apiref"
+	"github.com/oleiade/k6-mcp/internal/scriptsource"
+)
+
+// AnnotateScriptResult is the result of the annotate_script tool.
+type AnnotateScriptResult struct {
+	// Script is the script with the comments of the annotations inserted.
+	Script      string              `json:"script"`
+	Annotations []apiref.Annotation `json:"annotations"`
+	// Undocumented lists the usages of the k6 API no documentation page documents, as
+	// module.name.
+	Undocumented []string `json:"undocumented,omitempty"`
+}
+
+// AnnotateScriptHandler inserts comments linking the k6 API calls of scripts to their
+// documentation.
+type AnnotateScriptHandler struct {
+	fetcher *scriptsource.Fetcher
+	symbols *apiref.Store
+}
+
+var _ ToolHandler = &AnnotateScriptHandler{}
+
+func NewAnnotateScriptHandler(fetcher *scriptsource.Fetcher, db *sql.DB) *AnnotateScriptHandler {
+	return &AnnotateScriptHandler{fetcher: fetcher, symbols: apiref.NewStore(db)}
+}
+
+func (h *AnnotateScriptHandler) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	script, errMsg := resolveScript(ctx, request.GetArguments(), h.fetcher)
+	if errMsg != "" {
+		return mcp.NewToolResultError(errMsg), nil
+	}
+	allOccurrences := request.GetBool("all_occurrences", false)
+
+	_, usages := apiref.ScriptUsage(script)
+	var annotations []apiref.Annotation
+	var undocumented []string
+	for _, usage := range usages {
+		symbol, err := h.symbols.ResolveUsage(ctx, usage)
+		if errors.Is(err, apiref.ErrUnavailable) {
+			return mcp.NewToolResultError("The API symbol index is not available in this build. Use the search_k6_documentation tool instead."), nil
+		}
+		if err != nil {
+			return mcp.NewToolResultError("Failed to look up the script's API usages; reason: " + err.Error()), nil
+		}
+		if symbol == nil || symbol.DocPath == "" {
+			undocumented = append(undocumented, usage.Module+"."+usage.Name)
+			continue
+		}
+
+		for _, line := range usage.Lines {
+			annotations = append(annotations, apiref.NewAnnotation(line, symbol))
+		}
+	}
+
+	annotated, inserted := apiref.Annotate(script, annotations, allOccurrences)
+	result := AnnotateScriptResult{Script: annotated, Annotations: inserted, Undocumented: undocumented}
+	if result.Annotations == nil {
+		result.Annotations = []apiref.Annotation{}
+	}
+
+	slog.InfoContext(ctx, "script annotated",
+		slog.Int("usages", len(usages)),
+		slog.Int("annotations", len(result.Annotations)),
+		slog.Int("undocumented", len(result.Undocumented)),
+	)
+
+	resultJSON, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("failed to serialize annotated script"), err
+	}
+
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
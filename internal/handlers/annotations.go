@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/oleiade/k6-mcp/internal/annotations"
+	"github.com/oleiade/k6-mcp/internal/runner"
+)
+
+// annotatedRun describes a run of the script with the options, for its Grafana annotations.
+func annotatedRun(revision *ScriptRevisionRef, options *runner.RunOptions) annotations.Run {
+	run := annotations.Run{
+		Environment: options.Environment,
+		VUs:         options.VUs,
+		Duration:    options.Duration,
+		Iterations:  options.Iterations,
+	}
+	if revision != nil {
+		run.Script = revision.Name
+	}
+	return run
+}
+
+// annotateRunStarted posts the Grafana annotation of a run started at start, and returns
+// its ID, or 0 when runs are not annotated. Annotation failures don't fail runs.
+func annotateRunStarted(ctx context.Context, client *annotations.Client, start time.Time, run annotations.Run) int64 {
+	if !client.Configured() {
+		return 0
+	}
+
+	id, err := client.RunStarted(ctx, start, run)
+	if err != nil {
+		slog.WarnContext(ctx, "failed to annotate run start in Grafana", slog.String("error", err.Error()))
+		return 0
+	}
+	return id
+}
+
+// annotateRunFinished completes the Grafana annotation of the ID with the outcome of the
+// run, even when the request was canceled.
+func annotateRunFinished(ctx context.Context, client *annotations.Client, id int64, start time.Time, run annotations.Run, result *runner.RunResult, runErr error) {
+	if id == 0 {
+		return
+	}
+
+	var outcome annotations.Outcome
+	if result != nil {
+		outcome = annotations.Outcome{
+			Success:         result.Success,
+			Grade:           result.Analysis.Grade,
+			TotalRequests:   result.Summary.TotalRequests,
+			FailedRequests:  result.Summary.FailedRequests,
+			P95ResponseTime: result.Summary.P95ResponseTime,
+			RequestRate:     result.Summary.RequestRate,
+			Error:           result.Error,
+		}
+	} else if runErr != nil {
+		outcome.Error = runErr.Error()
+	}
+
+	if err := client.RunFinished(context.WithoutCancel(ctx), id, start, time.Now(), run, outcome); err != nil {
+		slog.WarnContext(ctx, "failed to annotate run end in Grafana", slog.String("error", err.Error()))
+	}
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/oleiade/k6-mcp/internal/archive"
+	"github.com/oleiade/k6-mcp/internal/logging"
+)
+
+const (
+	// archiveOutputBase64 returns the archive inline, base64 encoded.
+	archiveOutputBase64 = "base64"
+	// archiveOutputFile writes the archive to the workspace.
+	archiveOutputFile = "file"
+	// defaultArchivePath is the workspace path archives are written to by default.
+	defaultArchivePath = "archive.tar"
+)
+
+// ExportArchiveHandler packages a script and its dependencies into a k6 archive.
+type ExportArchiveHandler struct{}
+
+var _ ToolHandler = &ExportArchiveHandler{}
+
+func NewExportArchiveHandler() *ExportArchiveHandler {
+	return &ExportArchiveHandler{}
+}
+
+// ExportArchiveResult is the result of the export_archive tool.
+type ExportArchiveResult struct {
+	*archive.Result
+
+	// ArchiveBase64 holds the archive when the output is base64.
+	ArchiveBase64 string `json:"archive_base64,omitempty"`
+
+	// Path is the absolute path the archive was written to when the output is file.
+	Path string `json:"path,omitempty"`
+}
+
+func (h *ExportArchiveHandler) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	script, err := request.RequireString("script")
+	if err != nil {
+		return mcp.NewToolResultError("Missing required parameter 'script'. Please provide your k6 script content as a string."), nil
+	}
+
+	var files map[string]string
+	if filesValue, exists := request.GetArguments()["files"]; exists {
+		if err := decodeArg(filesValue, &files); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Invalid files format: %s. Example: {\"lib/auth.js\": \"export function login() {}\", \"data/users.csv\": \"username,password\\nalice,secret\"}", err.Error())), nil
+		}
+	}
+
+	output := request.GetString("output", archiveOutputBase64)
+	if output != archiveOutputBase64 && output != archiveOutputFile {
+		return mcp.NewToolResultError(fmt.Sprintf("Parameter 'output' must be either %q or %q", archiveOutputBase64, archiveOutputFile)), nil
+	}
+
+	result, err := archive.CreateArchive(ctx, script, files)
+	if err != nil {
+		logging.Default().ErrorContext(ctx, "Archive creation error",
+			slog.String("error", err.Error()),
+		)
+	}
+
+	response := ExportArchiveResult{Result: result}
+	if result.Success {
+		switch output {
+		case archiveOutputFile:
+			workspace, wdErr := os.Getwd()
+			if wdErr != nil {
+				return mcp.NewToolResultError("Failed to resolve the workspace directory; reason: " + wdErr.Error()), nil
+			}
+			path, writeErr := archive.WriteToWorkspace(workspace, request.GetString("path", defaultArchivePath), result.Archive, true)
+			if writeErr != nil {
+				return mcp.NewToolResultError("Failed to write archive; reason: " + writeErr.Error()), nil
+			}
+			response.Path = path
+		default:
+			response.ArchiveBase64 = base64.StdEncoding.EncodeToString(result.Archive)
+		}
+	}
+
+	resultJSON, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("failed to serialize archive result"), err
+	}
+
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
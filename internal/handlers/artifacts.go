@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"unicode/utf8"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/oleiade/k6-mcp/internal/artifacts"
+	"github.com/oleiade/k6-mcp/internal/runner"
+)
+
+const (
+	// defaultArtifactChunkBytes and maxArtifactChunkBytes bound the content get_artifact returns.
+	defaultArtifactChunkBytes = 64 * 1024
+	maxArtifactChunkBytes     = 1024 * 1024
+)
+
+// storeRunArtifacts stores the summary export and saved output of a run as artifacts, and
+// points the result's output file to the stored output. Storage failures don't fail runs.
+func storeRunArtifacts(ctx context.Context, store *artifacts.Store, runID int, result *runner.RunResult) []artifacts.Artifact {
+	if store == nil || result == nil {
+		return nil
+	}
+
+	var stored []artifacts.Artifact
+	if len(result.SummaryExport) > 0 {
+		artifact, err := store.Save(artifacts.Artifact{
+			Name:        "summary.json",
+			Kind:        artifacts.KindSummaryExport,
+			ContentType: "application/json",
+			RunID:       runID,
+			Source:      "run_test",
+		}, bytes.NewReader(result.SummaryExport))
+		if err != nil {
+			slog.WarnContext(ctx, "failed to store summary export artifact", slog.String("error", err.Error()))
+		} else {
+			stored = append(stored, *artifact)
+		}
+	}
+
+	if result.OutputFile != "" {
+		artifact, err := store.SaveFile(artifacts.Artifact{
+			Name:        "output.jsonl",
+			Kind:        artifacts.KindOutput,
+			ContentType: "application/x-ndjson",
+			RunID:       runID,
+			Source:      "run_test",
+		}, result.OutputFile)
+		if err != nil {
+			slog.WarnContext(ctx, "failed to store output artifact", slog.String("error", err.Error()))
+		} else {
+			result.OutputFile = store.Path(artifact.ID)
+			stored = append(stored, *artifact)
+		}
+	}
+
+	return stored
+}
+
+// ListArtifactsResult is the result of the list_artifacts tool.
+type ListArtifactsResult struct {
+	Artifacts []artifacts.Artifact `json:"artifacts"`
+	// Total counts the matching artifacts, of which Artifacts holds the most recent ones.
+	Total      int   `json:"total"`
+	UsedBytes  int64 `json:"used_bytes"`
+	QuotaBytes int64 `json:"quota_bytes"`
+}
+
+// ListArtifactsHandler lists the stored artifacts.
+type ListArtifactsHandler struct {
+	store *artifacts.Store
+}
+
+var _ ToolHandler = &ListArtifactsHandler{}
+
+func NewListArtifactsHandler(store *artifacts.Store) *ListArtifactsHandler {
+	return &ListArtifactsHandler{store: store}
+}
+
+func (h *ListArtifactsHandler) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	query := artifacts.Query{
+		RunID: request.GetInt("run_id", 0),
+		Kind:  request.GetString("kind", ""),
+		Limit: request.GetInt("limit", 0),
+	}
+	if query.Limit < 0 || query.Limit > artifacts.MaxListLimit {
+		return mcp.NewToolResultError(fmt.Sprintf("Parameter 'limit' must be between 1 and %d", artifacts.MaxListLimit)), nil
+	}
+
+	list, total, used, err := h.store.List(query)
+	if err != nil {
+		return mcp.NewToolResultError("Failed to list artifacts; reason: " + err.Error()), nil
+	}
+
+	resultJSON, err := json.MarshalIndent(ListArtifactsResult{Artifacts: list, Total: total, UsedBytes: used, QuotaBytes: h.store.Quota()}, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("failed to serialize artifacts"), err
+	}
+
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// GetArtifactResult is the result of the get_artifact tool: a chunk of the content of an
+// artifact.
+type GetArtifactResult struct {
+	Artifact artifacts.Artifact `json:"artifact"`
+	Offset   int64              `json:"offset"`
+	Bytes    int                `json:"bytes"`
+	// NextOffset is the offset of the next chunk, or 0 when the chunk ends the artifact.
+	NextOffset int64 `json:"next_offset,omitempty"`
+	// Encoding is "text" for UTF-8 content, or "base64" for binary content.
+	Encoding string `json:"encoding"`
+	Content  string `json:"content"`
+}
+
+// GetArtifactHandler returns the content of stored artifacts, in chunks.
+type GetArtifactHandler struct {
+	store *artifacts.Store
+}
+
+var _ ToolHandler = &GetArtifactHandler{}
+
+func NewGetArtifactHandler(store *artifacts.Store) *GetArtifactHandler {
+	return &GetArtifactHandler{store: store}
+}
+
+func (h *GetArtifactHandler) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	id, err := request.RequireString("id")
+	if err != nil {
+		return mcp.NewToolResultError("Missing required parameter 'id'. Use list_artifacts to find the ID of an artifact."), nil
+	}
+
+	offset := int64(request.GetInt("offset", 0))
+	limit := request.GetInt("max_bytes", defaultArtifactChunkBytes)
+	if limit <= 0 || limit > maxArtifactChunkBytes {
+		return mcp.NewToolResultError(fmt.Sprintf("Parameter 'max_bytes' must be between 1 and %d", maxArtifactChunkBytes)), nil
+	}
+
+	artifact, data, err := h.store.Read(id, offset, int64(limit))
+	if errors.Is(err, artifacts.ErrNotFound) {
+		return mcp.NewToolResultError(fmt.Sprintf("No artifact has the ID %q. Use list_artifacts to find the ID of an artifact; the oldest artifacts are evicted when the quota is reached.", id)), nil
+	}
+	if err != nil {
+		return mcp.NewToolResultError("Failed to read artifact; reason: " + err.Error()), nil
+	}
+
+	result := GetArtifactResult{Artifact: *artifact, Offset: offset, Encoding: "text"}
+	end := offset + int64(len(data))
+	if end < artifact.Bytes {
+		// Text chunks end on a character boundary, so that the next chunk starts on one
+		if cut := utf8Boundary(data); cut > 0 && utf8.Valid(data[:cut]) {
+			data = data[:cut]
+			end = offset + int64(cut)
+		}
+	}
+	if utf8.Valid(data) {
+		result.Content = string(data)
+	} else {
+		result.Encoding = "base64"
+		result.Content = base64.StdEncoding.EncodeToString(data)
+	}
+	result.Bytes = len(data)
+	if end < artifact.Bytes {
+		result.NextOffset = end
+	}
+
+	slog.InfoContext(ctx, "artifact read",
+		slog.String("id", artifact.ID),
+		slog.Int64("offset", offset),
+		slog.Int("bytes", result.Bytes),
+	)
+
+	resultJSON, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("failed to serialize artifact"), err
+	}
+
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// utf8Boundary returns the length of data without the incomplete character it may end with.
+func utf8Boundary(data []byte) int {
+	for i := len(data) - 1; i >= 0 && i >= len(data)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(data[i]) {
+			if utf8.FullRune(data[i:]) {
+				return len(data)
+			}
+			return i
+		}
+	}
+	return len(data)
+}
@@ -0,0 +1,240 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/oleiade/k6-mcp/internal/search"
+)
+
+const (
+	// DefaultCitations and MaxCitations bound the number of quotes answers are composed of.
+	DefaultCitations = 5
+	MaxCitations     = 10
+
+	// askChunks is the number of documentation chunks passages are quoted from.
+	askChunks = 10
+	// maxQuestionTerms bounds the number of question terms chunks are retrieved with.
+	maxQuestionTerms = 8
+	// maxQuoteBytes bounds the size of quotes.
+	maxQuoteBytes = 800
+)
+
+// questionStopwords are the words of questions that don't help find documentation.
+var questionStopwords = map[string]bool{
+	"about": true, "and": true, "are": true, "can": true, "does": true, "for": true,
+	"from": true, "have": true, "how": true, "into": true, "its": true, "not": true,
+	"should": true, "that": true, "the": true, "their": true, "them": true, "then": true,
+	"there": true, "these": true, "this": true, "use": true, "using": true, "what": true,
+	"when": true, "where": true, "which": true, "while": true, "who": true, "why": true,
+	"will": true, "with": true, "would": true, "you": true, "your": true, "k6": true,
+}
+
+// Citation is a passage of the documentation an answer quotes.
+type Citation struct {
+	// Index is the number answers refer to the citation with, as [1], [2], ...
+	Index int    `json:"index"`
+	Title string `json:"title"`
+	Path  string `json:"path"`
+	// URI is the docs://k6/pages/{path} resource of the full page.
+	URI   string `json:"uri"`
+	Quote string `json:"quote"`
+}
+
+// AskDocumentationResult is the result of the ask_documentation tool.
+type AskDocumentationResult struct {
+	Question string `json:"question"`
+	// Answer is composed of the quotes of the citations, referring to them by index.
+	Answer    string     `json:"answer"`
+	Citations []Citation `json:"citations"`
+}
+
+// AskDocumentationHandler answers questions with cited passages of the documentation,
+// for clients without the context to read search results in full.
+type AskDocumentationHandler struct {
+	searcher search.Search
+}
+
+var _ ToolHandler = &AskDocumentationHandler{}
+
+func NewAskDocumentationHandler(db *sql.DB) *AskDocumentationHandler {
+	return &AskDocumentationHandler{searcher: search.NewFullTextSearcher(db)}
+}
+
+func (h *AskDocumentationHandler) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	question, err := request.RequireString("question")
+	if err != nil || strings.TrimSpace(question) == "" {
+		return mcp.NewToolResultError("Missing required parameter 'question'. Example: 'How do I fail a test when the 95th percentile of the request duration exceeds 500ms?'"), nil
+	}
+
+	citations := request.GetInt("max_citations", DefaultCitations)
+	if citations <= 0 || citations > MaxCitations {
+		return mcp.NewToolResultError(fmt.Sprintf("Parameter 'max_citations' must be between 1 and %d.", MaxCitations)), nil
+	}
+
+	terms := questionTerms(question)
+	if len(terms) == 0 {
+		return mcp.NewToolResultError("The question has no terms to search the documentation with. Ask about k6 features, APIs or options, e.g. 'How do thresholds abort a test?'"), nil
+	}
+
+	options := search.DefaultOptions()
+	options.MaxResults = askChunks
+	chunks, err := h.searcher.Search(ctx, termsQuery(terms), options)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("search failed: %v", err)), nil
+	}
+
+	result := AskDocumentationResult{Question: question, Citations: quotePassages(chunks, terms, citations)}
+	result.Answer = composeAnswer(result.Citations)
+
+	slog.InfoContext(ctx, "documentation question answered",
+		slog.Int("terms", len(terms)),
+		slog.Int("chunks", len(chunks)),
+		slog.Int("citations", len(result.Citations)),
+	)
+
+	resultJSON, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("failed to serialize answer"), err
+	}
+
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// questionTerms returns the distinct, lowercased terms of the question worth searching,
+// keeping identifiers such as http.batch whole.
+func questionTerms(question string) []string {
+	fields := strings.FieldsFunc(question, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '.' && r != '/' && r != '_' && r != '-'
+	})
+
+	seen := make(map[string]bool)
+	var terms []string
+	for _, field := range fields {
+		term := strings.ToLower(strings.Trim(field, ".-/_"))
+		if len(term) < 3 || questionStopwords[term] || seen[term] {
+			continue
+		}
+		seen[term] = true
+		terms = append(terms, term)
+		if len(terms) == maxQuestionTerms {
+			break
+		}
+	}
+
+	return terms
+}
+
+// termsQuery returns the FTS5 query of the chunks matching any of the terms, as phrases,
+// so that BM25 ranks the chunks matching the most terms first.
+func termsQuery(terms []string) string {
+	phrases := make([]string, len(terms))
+	for i, term := range terms {
+		phrases[i] = `"` + term + `"`
+	}
+	return strings.Join(phrases, " OR ")
+}
+
+// quotePassages returns the citations of the paragraphs of the chunks matching the most
+// distinct terms, at most one per chunk, in the order of their chunks' relevance.
+func quotePassages(chunks []search.Result, terms []string, limit int) []Citation {
+	type passage struct {
+		chunk   int
+		matches int
+		text    string
+	}
+
+	var passages []passage
+	for i, chunk := range chunks {
+		best := passage{chunk: i}
+		for _, paragraph := range strings.Split(chunk.Content, "\n\n") {
+			paragraph = strings.TrimSpace(paragraph)
+			if matches := countTerms(paragraph, terms); matches > best.matches {
+				best.matches, best.text = matches, paragraph
+			}
+		}
+		if best.matches > 0 {
+			passages = append(passages, best)
+		}
+	}
+
+	// Prefer the passages matching the most terms, then the most relevant chunks
+	sort.SliceStable(passages, func(i, j int) bool {
+		return passages[i].matches > passages[j].matches
+	})
+	if len(passages) > limit {
+		passages = passages[:limit]
+	}
+	sort.SliceStable(passages, func(i, j int) bool {
+		return passages[i].chunk < passages[j].chunk
+	})
+
+	citations := make([]Citation, len(passages))
+	for i, p := range passages {
+		chunk := chunks[p.chunk]
+		citations[i] = Citation{
+			Index: i + 1,
+			Title: chunk.Title,
+			Path:  chunk.Path,
+			URI:   DocumentationURIPrefix + chunk.Path,
+			Quote: clipQuote(p.text),
+		}
+	}
+
+	return citations
+}
+
+// countTerms returns the number of distinct terms the text holds.
+func countTerms(text string, terms []string) int {
+	text = strings.ToLower(text)
+	count := 0
+	for _, term := range terms {
+		if strings.Contains(text, term) {
+			count++
+		}
+	}
+	return count
+}
+
+// clipQuote truncates quotes to maxQuoteBytes, on a rune boundary.
+func clipQuote(quote string) string {
+	if len(quote) <= maxQuoteBytes {
+		return quote
+	}
+	cut := maxQuoteBytes
+	for cut > 0 && quote[cut]&0xC0 == 0x80 {
+		cut--
+	}
+	return quote[:cut] + "…"
+}
+
+// composeAnswer returns the answer quoting the citations, each followed by its reference.
+func composeAnswer(citations []Citation) string {
+	if len(citations) == 0 {
+		return "The documentation has no passage answering the question. Rephrase it with the names of k6 features, APIs or options, or use search_k6_documentation."
+	}
+
+	var b strings.Builder
+	b.WriteString("According to the k6 documentation:\n")
+	for _, citation := range citations {
+		fmt.Fprintf(&b, "\n%s [%d]\n", quoteBlock(citation.Quote), citation.Index)
+	}
+	b.WriteString("\nSources:\n")
+	for _, citation := range citations {
+		fmt.Fprintf(&b, "[%d] %s (%s)\n", citation.Index, citation.Title, citation.URI)
+	}
+
+	return b.String()
+}
+
+// quoteBlock returns the text as a markdown block quote.
+func quoteBlock(text string) string {
+	return "> " + strings.ReplaceAll(text, "\n", "\n> ")
+}
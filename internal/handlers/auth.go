@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/oleiade/k6-mcp/internal/auth"
+	"github.com/oleiade/k6-mcp/internal/runner"
+)
+
+// AuthTokenRef describes the token a run acquired with an auth profile, without the token.
+type AuthTokenRef struct {
+	Profile string `json:"profile"`
+	// EnvVar is the environment variable the script reads the token from, with __ENV.
+	EnvVar    string     `json:"env_var"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// applyAuthProfile acquires a token with the auth profile, and exposes it to the script
+// through the secret environment of the run options. Previews only check the profile, and
+// expose a redacted placeholder. It returns a user-facing error message on failure.
+func applyAuthProfile(ctx context.Context, provider *auth.Provider, profile string, options *runner.RunOptions, preview bool) (*AuthTokenRef, string) {
+	envVar, err := provider.EnvVar(profile)
+	if err != nil {
+		return nil, "Invalid 'auth_profile': " + err.Error()
+	}
+	if _, set := options.Env[envVar]; set {
+		return nil, fmt.Sprintf("Parameter 'env' sets %s, which auth profile %q exposes the token in", envVar, profile)
+	}
+
+	ref := &AuthTokenRef{Profile: profile, EnvVar: envVar}
+	value := "[REDACTED]"
+	if !preview {
+		token, err := provider.Token(ctx, profile)
+		if err != nil {
+			return nil, fmt.Sprintf("Failed to acquire a token with auth profile %q; reason: %v", profile, err)
+		}
+		value = token.AccessToken
+		if !token.ExpiresAt.IsZero() {
+			ref.ExpiresAt = &token.ExpiresAt
+		}
+	}
+
+	if options.SecretEnv == nil {
+		options.SecretEnv = make(map[string]string)
+	}
+	options.SecretEnv[envVar] = value
+
+	slog.InfoContext(ctx, "auth profile applied to run",
+		slog.String("profile", profile),
+		slog.String("env_var", envVar),
+		slog.Bool("preview", preview),
+	)
+
+	return ref, ""
+}
+
+// ListAuthProfilesResult is the result of the list_auth_profiles tool.
+type ListAuthProfilesResult struct {
+	Profiles []auth.ProfileInfo `json:"profiles"`
+}
+
+// ListAuthProfilesHandler lists the auth profiles runs can acquire tokens with.
+type ListAuthProfilesHandler struct {
+	provider *auth.Provider
+}
+
+var _ ToolHandler = &ListAuthProfilesHandler{}
+
+func NewListAuthProfilesHandler(provider *auth.Provider) *ListAuthProfilesHandler {
+	return &ListAuthProfilesHandler{provider: provider}
+}
+
+func (h *ListAuthProfilesHandler) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	profiles := h.provider.Profiles()
+	if len(profiles) == 0 {
+		return mcp.NewToolResultError("No auth profile is configured. The server administrator can define OAuth2 token profiles in a file whose path is set in K6_MCP_AUTH_PROFILES."), nil
+	}
+
+	resultJSON, err := json.MarshalIndent(ListAuthProfilesResult{Profiles: profiles}, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("failed to serialize auth profiles"), err
+	}
+
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
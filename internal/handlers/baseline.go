@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/oleiade/k6-mcp/internal/baseline"
+	"github.com/oleiade/k6-mcp/internal/runner"
+)
+
+// summaryExample illustrates the summary argument expected by the baseline tools.
+const summaryExample = `{"total_requests": 1200, "failed_requests": 3, "avg_response_time_ms": 120.5, "p95_response_time_ms": 310.2, "request_rate_per_second": 40}`
+
+// SetBaselineHandler records a run as the performance baseline of a named test.
+type SetBaselineHandler struct {
+	store *baseline.Store
+}
+
+var _ ToolHandler = &SetBaselineHandler{}
+
+func NewSetBaselineHandler(store *baseline.Store) *SetBaselineHandler {
+	return &SetBaselineHandler{store: store}
+}
+
+func (h *SetBaselineHandler) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	testName, err := request.RequireString("test_name")
+	if err != nil {
+		return mcp.NewToolResultError("Missing required parameter 'test_name'. Example: 'checkout-flow'"), nil
+	}
+
+	summary, errMsg := parseSummaryArg(request.GetArguments())
+	if errMsg != "" {
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	b, err := h.store.Set(testName, summary, request.GetString("notes", ""))
+	if err != nil {
+		return mcp.NewToolResultError("Failed to set baseline; reason: " + err.Error()), nil
+	}
+
+	resultJSON, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("failed to serialize baseline"), err
+	}
+
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// CheckAgainstBaselineHandler compares a run to the baseline of a named test, and
+// returns a pass/fail gate.
+type CheckAgainstBaselineHandler struct {
+	store *baseline.Store
+}
+
+var _ ToolHandler = &CheckAgainstBaselineHandler{}
+
+func NewCheckAgainstBaselineHandler(store *baseline.Store) *CheckAgainstBaselineHandler {
+	return &CheckAgainstBaselineHandler{store: store}
+}
+
+func (h *CheckAgainstBaselineHandler) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	testName, err := request.RequireString("test_name")
+	if err != nil {
+		return mcp.NewToolResultError("Missing required parameter 'test_name'. Example: 'checkout-flow'"), nil
+	}
+
+	summary, errMsg := parseSummaryArg(request.GetArguments())
+	if errMsg != "" {
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	tolerance := baseline.DefaultTolerance()
+	tolerance.Percent = request.GetFloat("tolerance_percent", tolerance.Percent)
+	tolerance.MaxErrorRateIncrease = request.GetFloat("max_error_rate_increase", tolerance.MaxErrorRateIncrease)
+	if tolerance.Percent < 0 || tolerance.MaxErrorRateIncrease < 0 {
+		return mcp.NewToolResultError("Parameters 'tolerance_percent' and 'max_error_rate_increase' must not be negative"), nil
+	}
+
+	b, err := h.store.Get(testName)
+	if errors.Is(err, baseline.ErrBaselineNotFound) {
+		return mcp.NewToolResultError(fmt.Sprintf("No baseline found for test %q. Use the set_baseline tool to record one first.", testName)), nil
+	}
+	if err != nil {
+		return mcp.NewToolResultError("Failed to read baseline; reason: " + err.Error()), nil
+	}
+
+	comparison := baseline.Compare(b, summary, tolerance)
+
+	resultJSON, err := json.MarshalIndent(comparison, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("failed to serialize baseline comparison"), err
+	}
+
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// parseSummaryArg decodes the 'summary' argument, as returned by the run_k6_script tool.
+func parseSummaryArg(args map[string]interface{}) (runner.TestSummary, string) {
+	var summary runner.TestSummary
+
+	summaryValue, exists := args["summary"]
+	if !exists {
+		return summary, "Missing required parameter 'summary'. Pass the 'summary' object returned by run_k6_script. Example: " + summaryExample
+	}
+
+	if err := decodeArg(summaryValue, &summary); err != nil {
+		return summary, fmt.Sprintf("Invalid summary format: %s. Example: %s", err.Error(), summaryExample)
+	}
+
+	return summary, ""
+}
@@ -0,0 +1,299 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/oleiade/k6-mcp/internal/history"
+	"github.com/oleiade/k6-mcp/internal/notify"
+	"github.com/oleiade/k6-mcp/internal/runner"
+	"github.com/oleiade/k6-mcp/internal/scriptsource"
+)
+
+const (
+	// MaxBreakingPointRuns is the maximum number of runs a breaking point search can make.
+	MaxBreakingPointRuns = 12
+	// DefaultBreakingPointRuns is the default number of runs of a breaking point search.
+	DefaultBreakingPointRuns = 8
+	// DefaultProbeDuration is the default duration of each run of a breaking point search.
+	DefaultProbeDuration = "20s"
+	// MaxProbeDuration is the maximum duration of each run of a breaking point search.
+	MaxProbeDuration = time.Minute
+	// DefaultMaxErrorRatePercent is the default error rate, in percent, above which a load
+	// is considered past the breaking point.
+	DefaultMaxErrorRatePercent = 1.0
+
+	// k6ThresholdsExitCode is the exit code of k6 runs whose thresholds were crossed.
+	k6ThresholdsExitCode = 99
+)
+
+// BreakingPointProbe is one run of a breaking point search.
+type BreakingPointProbe struct {
+	VUs             int     `json:"vus"`
+	Phase           string  `json:"phase"` // "ramp" or "bisect"
+	Passed          bool    `json:"passed"`
+	Breach          string  `json:"breach,omitempty"`
+	ExitCode        int     `json:"exit_code"`
+	TotalRequests   int     `json:"total_requests"`
+	ErrorRate       float64 `json:"error_rate_percent"`
+	P95ResponseTime float64 `json:"p95_response_time_ms"`
+	RequestRate     float64 `json:"request_rate_per_second"`
+	Duration        string  `json:"duration,omitempty"`
+}
+
+// FindBreakingPointResult is the result of the find_breaking_point tool.
+type FindBreakingPointResult struct {
+	// Found reports whether a load past the breaking point was reached.
+	Found bool `json:"breaking_point_found"`
+	// CapacityVUs is the highest load that stayed within the limits.
+	CapacityVUs int `json:"capacity_vus"`
+	// CapacityRequestRate is the request rate sustained at CapacityVUs.
+	CapacityRequestRate float64 `json:"capacity_request_rate_per_second"`
+	// BreakingVUs is the lowest load that exceeded the limits.
+	BreakingVUs int    `json:"breaking_vus,omitempty"`
+	Reason      string `json:"reason,omitempty"`
+	Conclusion  string `json:"conclusion"`
+
+	MaxErrorRate float64 `json:"max_error_rate_percent"`
+	MaxP95       float64 `json:"max_p95_response_time_ms,omitempty"`
+
+	Probes   []BreakingPointProbe `json:"runs"`
+	Duration string               `json:"duration"`
+	Script   *ScriptRevisionRef   `json:"script,omitempty"`
+}
+
+// breakingPointSearch holds the parameters of a breaking point search.
+type breakingPointSearch struct {
+	startVUs     int
+	maxVUs       int
+	step         int
+	maxRuns      int
+	maxErrorRate float64
+	maxP95       float64
+}
+
+// FindBreakingPointHandler searches the load at which a system starts failing.
+type FindBreakingPointHandler struct {
+	fetcher *scriptsource.Fetcher
+	scripts *history.Store
+}
+
+var _ ToolHandler = &FindBreakingPointHandler{}
+
+func NewFindBreakingPointHandler(fetcher *scriptsource.Fetcher, scripts *history.Store) *FindBreakingPointHandler {
+	return &FindBreakingPointHandler{fetcher: fetcher, scripts: scripts}
+}
+
+func (h *FindBreakingPointHandler) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+
+	script, errMsg := resolveScript(ctx, args, h.fetcher)
+	if errMsg != "" {
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	search := breakingPointSearch{
+		startVUs:     request.GetInt("start_vus", 1),
+		maxVUs:       request.GetInt("max_vus", runner.MaxVUs),
+		step:         request.GetInt("step_vus", 0),
+		maxRuns:      request.GetInt("max_runs", DefaultBreakingPointRuns),
+		maxErrorRate: request.GetFloat("max_error_rate_percent", DefaultMaxErrorRatePercent),
+		maxP95:       request.GetFloat("max_p95_ms", 0),
+	}
+	if errMsg := search.validate(); errMsg != "" {
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	// Each probe runs for a short, fixed duration
+	baseArgs := map[string]interface{}{"duration": DefaultProbeDuration}
+	for _, key := range []string{"duration", "files", "env"} {
+		if value, exists := args[key]; exists {
+			baseArgs[key] = value
+		}
+	}
+	baseOptions, err := parseRunOptions(baseArgs)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid parameters: %v.", err)), nil
+	}
+	if duration, err := time.ParseDuration(baseOptions.Duration); err != nil || duration > MaxProbeDuration {
+		return mcp.NewToolResultError(fmt.Sprintf("duration must be a valid duration of at most %v, e.g. '20s': the search makes several short runs.", MaxProbeDuration)), nil
+	}
+
+	revision, errMsg := recordScript(args, h.scripts, script, "find_breaking_point")
+	if errMsg != "" {
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	startTime := time.Now()
+	result := FindBreakingPointResult{
+		MaxErrorRate: search.maxErrorRate,
+		MaxP95:       search.maxP95,
+		Script:       revision,
+	}
+
+	probe := func(vus int, phase string) (*BreakingPointProbe, string) {
+		options := *baseOptions
+		options.VUs = vus
+
+		notify.Send(ctx, mcp.LoggingLevelInfo, notify.EventRunStarted, "Breaking point probe started", map[string]any{
+			"vus":   vus,
+			"phase": phase,
+			"run":   len(result.Probes) + 1,
+		})
+
+		runResult, _ := runner.RunK6Test(ctx, script, &options)
+		notifyRunFinished(ctx, runResult)
+
+		p, errMsg := search.evaluate(vus, phase, runResult)
+		if p != nil {
+			result.Probes = append(result.Probes, *p)
+		}
+		return p, errMsg
+	}
+
+	// Ramp up the load until it exceeds the limits, then bisect between the last load within
+	// the limits and the first one past them.
+	good, bad := 0, 0
+	var goodProbe *BreakingPointProbe
+	for vus := search.startVUs; len(result.Probes) < search.maxRuns && ctx.Err() == nil; vus = search.next(vus) {
+		p, errMsg := probe(vus, "ramp")
+		if errMsg != "" {
+			return mcp.NewToolResultError(errMsg), nil
+		}
+		if !p.Passed {
+			bad = vus
+			result.Reason = p.Breach
+			break
+		}
+		good, goodProbe = vus, p
+		if vus >= search.maxVUs {
+			break
+		}
+	}
+
+	for bad > 0 && bad-good > 1 && len(result.Probes) < search.maxRuns && ctx.Err() == nil {
+		vus := good + (bad-good)/2
+		p, errMsg := probe(vus, "bisect")
+		if errMsg != "" {
+			return mcp.NewToolResultError(errMsg), nil
+		}
+		if p.Passed {
+			good, goodProbe = vus, p
+		} else {
+			bad = vus
+			result.Reason = p.Breach
+		}
+	}
+
+	result.Found = bad > 0
+	result.BreakingVUs = bad
+	result.CapacityVUs = good
+	if goodProbe != nil {
+		result.CapacityRequestRate = goodProbe.RequestRate
+	}
+	result.Conclusion = search.conclusion(good, bad, goodProbe)
+	result.Duration = time.Since(startTime).String()
+
+	resultJSON, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("failed to serialize breaking point result"), err
+	}
+
+	slog.InfoContext(ctx, "breaking point search completed",
+		slog.Bool("found", result.Found),
+		slog.Int("capacity_vus", result.CapacityVUs),
+		slog.Int("breaking_vus", result.BreakingVUs),
+		slog.Int("runs", len(result.Probes)),
+	)
+
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// validate checks the search parameters, and returns a user-facing error message when
+// they are invalid.
+func (s *breakingPointSearch) validate() string {
+	switch {
+	case s.startVUs < 1 || s.startVUs > runner.MaxVUs:
+		return fmt.Sprintf("start_vus must be between 1 and %d.", runner.MaxVUs)
+	case s.maxVUs < s.startVUs || s.maxVUs > runner.MaxVUs:
+		return fmt.Sprintf("max_vus must be between start_vus and %d.", runner.MaxVUs)
+	case s.step < 0:
+		return "step_vus cannot be negative; omit it to double the VUs between runs."
+	case s.maxRuns < 2 || s.maxRuns > MaxBreakingPointRuns:
+		return fmt.Sprintf("max_runs must be between 2 and %d.", MaxBreakingPointRuns)
+	case s.maxErrorRate < 0 || s.maxErrorRate > 100:
+		return "max_error_rate_percent must be between 0 and 100."
+	case s.maxP95 < 0:
+		return "max_p95_ms cannot be negative."
+	}
+
+	return ""
+}
+
+// next returns the load of the ramp run following one of the given VUs: the VUs are
+// increased by the step, or doubled without one, up to the maximum.
+func (s *breakingPointSearch) next(vus int) int {
+	next := vus * 2
+	if s.step > 0 {
+		next = vus + s.step
+	}
+
+	return min(next, s.maxVUs)
+}
+
+// evaluate checks a probe run against the limits. It returns a user-facing error message
+// when the run failed for other reasons than the load, e.g. a broken script.
+func (s *breakingPointSearch) evaluate(vus int, phase string, result *runner.RunResult) (*BreakingPointProbe, string) {
+	if result == nil {
+		return nil, fmt.Sprintf("The run at %d VUs produced no result.", vus)
+	}
+
+	p := &BreakingPointProbe{
+		VUs:             vus,
+		Phase:           phase,
+		ExitCode:        result.ExitCode,
+		TotalRequests:   result.Summary.TotalRequests,
+		P95ResponseTime: result.Summary.P95ResponseTime,
+		RequestRate:     result.Summary.RequestRate,
+		Duration:        result.Duration,
+	}
+	if p.TotalRequests > 0 {
+		p.ErrorRate = float64(result.Summary.FailedRequests) / float64(p.TotalRequests) * 100
+	}
+
+	switch {
+	case result.ExitCode == k6ThresholdsExitCode:
+		p.Breach = "the script's thresholds were crossed"
+	case !result.Success:
+		return nil, fmt.Sprintf("The run at %d VUs failed: %s. Validate the script first; the breaking point search only tolerates failures caused by the load. stderr: %s",
+			vus, result.Error, result.Stderr)
+	case p.TotalRequests == 0:
+		return nil, fmt.Sprintf("The run at %d VUs made no HTTP requests, so there is no error rate or latency to measure.", vus)
+	case p.ErrorRate > s.maxErrorRate:
+		p.Breach = fmt.Sprintf("error rate %.2f%% exceeds %.2f%%", p.ErrorRate, s.maxErrorRate)
+	case s.maxP95 > 0 && p.P95ResponseTime > s.maxP95:
+		p.Breach = fmt.Sprintf("p95 response time %.1fms exceeds %.1fms", p.P95ResponseTime, s.maxP95)
+	}
+	p.Passed = p.Breach == ""
+
+	return p, ""
+}
+
+// conclusion summarizes the outcome of the search.
+func (s *breakingPointSearch) conclusion(good, bad int, goodProbe *BreakingPointProbe) string {
+	switch {
+	case bad == 0:
+		return fmt.Sprintf("No breaking point found up to %d VUs: the system stayed within the limits at every load tested. Raise max_vus or max_runs, or lower the limits, to search further.", good)
+	case good == 0:
+		return fmt.Sprintf("The system already exceeds the limits at %d VUs, the lowest load tested. Lower start_vus, or investigate the failures at low load first.", bad)
+	case bad-good > 1:
+		return fmt.Sprintf("Estimated capacity: between %d and %d VUs (about %.1f req/s at %d VUs). The search ran out of runs before narrowing it further; raise max_runs for more precision.",
+			good, bad, goodProbe.RequestRate, good)
+	default:
+		return fmt.Sprintf("Estimated capacity: %d VUs (about %.1f req/s); the limits are exceeded at %d VUs.", good, goodProbe.RequestRate, bad)
+	}
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/oleiade/k6-mcp/internal/docs"
+)
+
+// BrowseDocumentationHandler lists the k6 documentation tree, one level at a time.
+type BrowseDocumentationHandler struct {
+	store *docs.Store
+}
+
+var _ ToolHandler = &BrowseDocumentationHandler{}
+
+// NewBrowseDocumentationHandler returns a BrowseDocumentationHandler reading pages from db.
+func NewBrowseDocumentationHandler(db *sql.DB) *BrowseDocumentationHandler {
+	return &BrowseDocumentationHandler{store: docs.NewStore(db)}
+}
+
+// BrowseResult is the structured response of the browse_documentation tool.
+type BrowseResult struct {
+	Prefix  string       `json:"prefix"`
+	Entries []docs.Entry `json:"entries"`
+}
+
+// Handle lists the categories and pages found directly under the requested prefix.
+func (h *BrowseDocumentationHandler) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+
+	prefix := ""
+	if prefixValue, exists := args["prefix"]; exists {
+		p, ok := prefixValue.(string)
+		if !ok {
+			return mcp.NewToolResultError("Parameter 'prefix' must be a string path such as 'javascript-api' or 'javascript-api/k6-http'. Received: " + fmt.Sprintf("%T", prefixValue)), nil
+		}
+		prefix = docs.NormalizePath(p)
+	}
+
+	entries, err := h.store.Browse(ctx, prefix)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("browse failed: %v", err)), nil
+	}
+
+	if len(entries) == 0 && prefix != "" {
+		return mcp.NewToolResultError(fmt.Sprintf("No documentation pages found under '%s'. Call the tool without a prefix to list the top-level categories.", prefix)), nil
+	}
+
+	resultJSON, err := json.MarshalIndent(BrowseResult{Prefix: prefix, Entries: entries}, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("failed to serialize browse results"), err
+	}
+
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
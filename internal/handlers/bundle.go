@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/oleiade/k6-mcp/internal/archive"
+	"github.com/oleiade/k6-mcp/internal/bundle"
+	"github.com/oleiade/k6-mcp/internal/workspace"
+)
+
+const (
+	// bundleOutputBase64 returns the bundle inline, base64 encoded.
+	bundleOutputBase64 = "base64"
+	// bundleOutputFile writes the bundle to the workspace.
+	bundleOutputFile = "file"
+	// defaultBundlePath is the workspace path bundles are written to by default.
+	defaultBundlePath = "k6-workspace.tar.gz"
+)
+
+// ExportWorkspaceResult is the result of the export_workspace tool.
+type ExportWorkspaceResult struct {
+	*bundle.Manifest
+
+	SizeBytes int `json:"size_bytes"`
+	// BundleBase64 holds the bundle when the output is base64.
+	BundleBase64 string `json:"bundle_base64,omitempty"`
+	// Path is the absolute path the bundle was written to when the output is file.
+	Path string `json:"path,omitempty"`
+}
+
+// ImportWorkspaceResult is the result of the import_workspace tool.
+type ImportWorkspaceResult struct {
+	*bundle.ImportResult
+
+	// Fixtures holds the fixtures of the bundle when no fixtures_dir is given, and
+	// FixturePaths the paths they were written to otherwise.
+	Fixtures     map[string]string `json:"fixtures,omitempty"`
+	FixturePaths []string          `json:"fixture_paths,omitempty"`
+}
+
+// ExportWorkspaceHandler packs the scripts, defaults, baselines and run history of a
+// project into a portable bundle.
+type ExportWorkspaceHandler struct {
+	stores bundle.Stores
+}
+
+var _ ToolHandler = &ExportWorkspaceHandler{}
+
+func NewExportWorkspaceHandler(stores bundle.Stores) *ExportWorkspaceHandler {
+	return &ExportWorkspaceHandler{stores: stores}
+}
+
+func (h *ExportWorkspaceHandler) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+
+	selection := bundle.Selection{Project: request.GetString("project", "")}
+	if scriptsValue, exists := args["scripts"]; exists {
+		if err := decodeArg(scriptsValue, &selection.Scripts); err != nil {
+			return mcp.NewToolResultError("Invalid scripts format: expected an array of script names. Example: [\"checkout/smoke\", \"checkout/load\"]"), nil
+		}
+	}
+	if filesValue, exists := args["files"]; exists {
+		if err := decodeArg(filesValue, &selection.Fixtures); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Invalid files format: %s. Example: {\"data/users.csv\": \"username,password\\nalice,secret\"}", err.Error())), nil
+		}
+	}
+
+	output := request.GetString("output", bundleOutputBase64)
+	if output != bundleOutputBase64 && output != bundleOutputFile {
+		return mcp.NewToolResultError(fmt.Sprintf("Parameter 'output' must be either %q or %q", bundleOutputBase64, bundleOutputFile)), nil
+	}
+
+	data, manifest, err := bundle.Export(h.stores, selection)
+	if err != nil {
+		return mcp.NewToolResultError("Failed to export the workspace; reason: " + err.Error()), nil
+	}
+
+	slog.InfoContext(ctx, "workspace exported",
+		slog.String("project", selection.Project),
+		slog.Int("scripts", len(manifest.Scripts)),
+		slog.Int("runs", manifest.Runs),
+		slog.Int("size_bytes", len(data)),
+	)
+
+	result := ExportWorkspaceResult{Manifest: manifest, SizeBytes: len(data)}
+	switch output {
+	case bundleOutputFile:
+		dir, err := os.Getwd()
+		if err != nil {
+			return mcp.NewToolResultError("Failed to resolve the workspace directory; reason: " + err.Error()), nil
+		}
+		path, err := archive.WriteToWorkspace(dir, request.GetString("path", defaultBundlePath), data, true)
+		if err != nil {
+			return mcp.NewToolResultError("Failed to write the bundle; reason: " + err.Error()), nil
+		}
+		result.Path = path
+	default:
+		result.BundleBase64 = base64.StdEncoding.EncodeToString(data)
+	}
+
+	resultJSON, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("failed to serialize export result"), err
+	}
+
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// ImportWorkspaceHandler unpacks a bundle made by export_workspace into the stores of
+// the server.
+type ImportWorkspaceHandler struct {
+	stores bundle.Stores
+}
+
+var _ ToolHandler = &ImportWorkspaceHandler{}
+
+func NewImportWorkspaceHandler(stores bundle.Stores) *ImportWorkspaceHandler {
+	return &ImportWorkspaceHandler{stores: stores}
+}
+
+func (h *ImportWorkspaceHandler) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	encoded := request.GetString("bundle_base64", "")
+	path := request.GetString("path", "")
+	if (encoded == "") == (path == "") {
+		return mcp.NewToolResultError("Provide either 'bundle_base64', the bundle returned by export_workspace, or 'path', the workspace path of a bundle file."), nil
+	}
+
+	dir, err := os.Getwd()
+	if err != nil {
+		return mcp.NewToolResultError("Failed to resolve the workspace directory; reason: " + err.Error()), nil
+	}
+
+	var data []byte
+	if encoded != "" {
+		if data, err = base64.StdEncoding.DecodeString(encoded); err != nil {
+			return mcp.NewToolResultError("Parameter 'bundle_base64' is not valid base64: " + err.Error()), nil
+		}
+	} else {
+		cleaned, err := workspace.CleanRelativePath(path)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Invalid path %q: %s", path, err.Error())), nil
+		}
+		if data, err = os.ReadFile(filepath.Join(dir, filepath.FromSlash(cleaned))); err != nil {
+			return mcp.NewToolResultError("Failed to read the bundle; reason: " + err.Error()), nil
+		}
+	}
+
+	replace := request.GetBool("replace", false)
+	imported, err := bundle.Import(h.stores, data, replace)
+	if err != nil {
+		return mcp.NewToolResultError("Failed to import the workspace; reason: " + err.Error()), nil
+	}
+
+	slog.InfoContext(ctx, "workspace imported",
+		slog.String("project", imported.Manifest.Project),
+		slog.Int("scripts", len(imported.Scripts)),
+		slog.Int("runs", imported.Runs),
+	)
+
+	result := ImportWorkspaceResult{ImportResult: imported}
+	if fixturesDir := request.GetString("fixtures_dir", ""); fixturesDir != "" {
+		if result.FixturePaths, err = bundle.WriteFixtures(dir, fixturesDir, imported.Fixtures, replace); err != nil {
+			return mcp.NewToolResultError("The workspace was imported, but writing its fixtures failed; reason: " + err.Error()), nil
+		}
+	} else if len(imported.Fixtures) > 0 {
+		result.Fixtures = imported.Fixtures
+	}
+
+	resultJSON, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("failed to serialize import result"), err
+	}
+
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
@@ -0,0 +1,389 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/oleiade/k6-mcp/internal/baseline"
+	"github.com/oleiade/k6-mcp/internal/catalog"
+	"github.com/oleiade/k6-mcp/internal/history"
+	"github.com/oleiade/k6-mcp/internal/runner"
+	"github.com/oleiade/k6-mcp/internal/scriptsource"
+)
+
+// testStatsPoints is the number of recent runs get_test returns the trend of.
+const testStatsPoints = 10
+
+// resolveTest resolves the registered test named by the 'test_name' argument, if any. It
+// returns the arguments completed with the script of the test: its name, under which the
+// run records a revision, and, unless the script is given, the content of its latest
+// revision. It returns a user-facing error message when the test or its script is unknown.
+func resolveTest(args map[string]interface{}, tests *catalog.Store, scripts *history.Store) (*catalog.Test, map[string]interface{}, string) {
+	nameValue, exists := args["test_name"]
+	if !exists {
+		return nil, args, ""
+	}
+
+	name, ok := nameValue.(string)
+	if !ok || name == "" {
+		return nil, nil, "Parameter 'test_name' must be a non-empty string. Example: 'checkout-flow'"
+	}
+
+	test, err := tests.Get(name)
+	if errors.Is(err, catalog.ErrTestNotFound) {
+		return nil, nil, fmt.Sprintf("No test is registered as %q. Register it with register_test, or call list_tests to find its name.", name)
+	}
+	if err != nil {
+		return nil, nil, "Failed to read the test; reason: " + err.Error()
+	}
+	if scriptName, ok := args["script_name"].(string); ok && scriptName != test.Script {
+		return nil, nil, fmt.Sprintf("Test %q runs the script %q, not %q: omit 'script_name', or link the test to another script with update_test.", name, test.Script, scriptName)
+	}
+
+	completed := maps.Clone(args)
+	completed["script_name"] = test.Script
+	if args["script"] == nil && args["script_url"] == nil {
+		rev, err := scripts.Get(test.Script, 0)
+		if errors.Is(err, history.ErrScriptNotFound) {
+			return nil, nil, fmt.Sprintf("The script %q of test %q has no recorded revision: pass its content through 'script'.", test.Script, name)
+		}
+		if err != nil {
+			return nil, nil, "Failed to read the script of the test; reason: " + err.Error()
+		}
+		completed["script"] = rev.Content
+	}
+
+	return test, completed, ""
+}
+
+// compareToBaseline compares the result of a run of the test to its baseline, if it has
+// one. Runs without requests, and baselines that cannot be read, are not compared.
+func compareToBaseline(ctx context.Context, store *baseline.Store, test *catalog.Test, result *runner.RunResult) *baseline.Comparison {
+	if test == nil || result == nil || result.Summary.TotalRequests == 0 {
+		return nil
+	}
+
+	b, err := store.Get(test.Name)
+	if err != nil {
+		if !errors.Is(err, baseline.ErrBaselineNotFound) {
+			slog.WarnContext(ctx, "failed to read the baseline of the test", slog.String("test", test.Name), slog.String("error", err.Error()))
+		}
+		return nil
+	}
+
+	return baseline.Compare(b, result.Summary, baseline.DefaultTolerance())
+}
+
+// TestResult is the result of the register_test and update_test tools.
+type TestResult struct {
+	Test *catalog.Test `json:"test"`
+	// Script identifies the revision recorded from the script given, if any.
+	Script *ScriptRevisionRef `json:"script,omitempty"`
+}
+
+// RegisterTestHandler registers named tests.
+type RegisterTestHandler struct {
+	tests   *catalog.Store
+	scripts *history.Store
+	fetcher *scriptsource.Fetcher
+}
+
+var _ ToolHandler = &RegisterTestHandler{}
+
+func NewRegisterTestHandler(tests *catalog.Store, scripts *history.Store, fetcher *scriptsource.Fetcher) *RegisterTestHandler {
+	return &RegisterTestHandler{tests: tests, scripts: scripts, fetcher: fetcher}
+}
+
+func (h *RegisterTestHandler) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+
+	name, err := request.RequireString("test_name")
+	if err != nil {
+		return mcp.NewToolResultError("Missing required parameter 'test_name'. Example: 'checkout-flow'"), nil
+	}
+	scriptName, err := request.RequireString("script_name")
+	if err != nil {
+		return mcp.NewToolResultError("Missing required parameter 'script_name': the name of the script the test runs. Example: 'checkout-flow'"), nil
+	}
+
+	test := catalog.Test{
+		Name:        name,
+		Owner:       request.GetString("owner", ""),
+		Description: request.GetString("description", ""),
+		Script:      scriptName,
+	}
+	if tagsValue, exists := args["tags"]; exists {
+		if err := decodeArg(tagsValue, &test.Tags); err != nil {
+			return mcp.NewToolResultError("Parameter 'tags' must be an array of strings. Example: [\"checkout\", \"smoke\"]"), nil
+		}
+	}
+
+	if _, err := h.tests.Get(name); err == nil {
+		return mcp.NewToolResultError(fmt.Sprintf("A test is already registered as %q. Change it with update_test.", name)), nil
+	}
+
+	revision, errMsg := h.recordTestScript(ctx, args, scriptName, "register_test")
+	if errMsg != "" {
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	registered, err := h.tests.Register(test)
+	if errors.Is(err, catalog.ErrTestExists) {
+		return mcp.NewToolResultError(fmt.Sprintf("A test is already registered as %q. Change it with update_test.", name)), nil
+	}
+	if err != nil {
+		return mcp.NewToolResultError("Failed to register the test; reason: " + err.Error()), nil
+	}
+
+	slog.InfoContext(ctx, "test registered", slog.String("test", name), slog.String("script", scriptName))
+
+	return testResult(registered, revision)
+}
+
+// recordTestScript records the script given, if any, as a revision of the named script.
+// Otherwise, the script must have a recorded revision already. It returns a user-facing
+// error message when it has none, or the script could not be recorded.
+func (h *RegisterTestHandler) recordTestScript(ctx context.Context, args map[string]interface{}, scriptName, source string) (*ScriptRevisionRef, string) {
+	if args["script"] == nil && args["script_url"] == nil {
+		if _, err := h.scripts.History(scriptName); err != nil {
+			if errors.Is(err, history.ErrScriptNotFound) {
+				return nil, fmt.Sprintf("The script %q has no recorded revision: pass its content through 'script' or 'script_url'.", scriptName)
+			}
+			return nil, "Failed to read the script history; reason: " + err.Error()
+		}
+		return nil, ""
+	}
+
+	script, errMsg := resolveScript(ctx, args, h.fetcher)
+	if errMsg != "" {
+		return nil, errMsg
+	}
+
+	return recordScript(map[string]interface{}{"script_name": scriptName}, h.scripts, script, source)
+}
+
+// UpdateTestHandler updates registered tests, linking them to other scripts or recording
+// new revisions of their script.
+type UpdateTestHandler struct {
+	register *RegisterTestHandler
+}
+
+var _ ToolHandler = &UpdateTestHandler{}
+
+func NewUpdateTestHandler(tests *catalog.Store, scripts *history.Store, fetcher *scriptsource.Fetcher) *UpdateTestHandler {
+	return &UpdateTestHandler{register: NewRegisterTestHandler(tests, scripts, fetcher)}
+}
+
+func (h *UpdateTestHandler) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	tests := h.register.tests
+
+	name, err := request.RequireString("test_name")
+	if err != nil {
+		return mcp.NewToolResultError("Missing required parameter 'test_name'. Example: 'checkout-flow'"), nil
+	}
+
+	test, err := tests.Get(name)
+	if errors.Is(err, catalog.ErrTestNotFound) {
+		return mcp.NewToolResultError(fmt.Sprintf("No test is registered as %q. Register it with register_test.", name)), nil
+	}
+	if err != nil {
+		return mcp.NewToolResultError("Failed to read the test; reason: " + err.Error()), nil
+	}
+
+	var changes catalog.Changes
+	for param, field := range map[string]**string{"owner": &changes.Owner, "description": &changes.Description, "script_name": &changes.Script} {
+		if value, exists := args[param]; exists {
+			s, ok := value.(string)
+			if !ok {
+				return mcp.NewToolResultError(fmt.Sprintf("Parameter '%s' must be a string", param)), nil
+			}
+			*field = &s
+		}
+	}
+	if tagsValue, exists := args["tags"]; exists {
+		var tags []string
+		if err := decodeArg(tagsValue, &tags); err != nil {
+			return mcp.NewToolResultError("Parameter 'tags' must be an array of strings. Example: [\"checkout\", \"smoke\"]"), nil
+		}
+		changes.Tags = &tags
+	}
+
+	scriptName := test.Script
+	if changes.Script != nil {
+		scriptName = *changes.Script
+	}
+	var revision *ScriptRevisionRef
+	if changes.Script != nil || args["script"] != nil || args["script_url"] != nil {
+		var errMsg string
+		if revision, errMsg = h.register.recordTestScript(ctx, args, scriptName, "update_test"); errMsg != "" {
+			return mcp.NewToolResultError(errMsg), nil
+		}
+	}
+
+	updated, err := tests.Update(name, changes)
+	if err != nil {
+		return mcp.NewToolResultError("Failed to update the test; reason: " + err.Error()), nil
+	}
+
+	slog.InfoContext(ctx, "test updated", slog.String("test", name), slog.String("script", updated.Script))
+
+	return testResult(updated, revision)
+}
+
+// testResult returns the tool result of a registered or updated test.
+func testResult(test *catalog.Test, revision *ScriptRevisionRef) (*mcp.CallToolResult, error) {
+	resultJSON, err := json.MarshalIndent(TestResult{Test: test, Script: revision}, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("failed to serialize test"), err
+	}
+
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// GetTestResult is the result of the get_test tool.
+type GetTestResult struct {
+	Test *catalog.Test `json:"test"`
+	// LatestRevision is the latest revision of the script of the test, without its content.
+	LatestRevision *history.Revision  `json:"latest_revision,omitempty"`
+	Baseline       *baseline.Baseline `json:"baseline,omitempty"`
+	// Runs aggregates the recorded runs of the test, across the revisions and scripts it
+	// ran, with the trend of the most recent ones.
+	Runs *history.RunStats `json:"runs,omitempty"`
+}
+
+// GetTestHandler returns a registered test, with the latest revision of its script, its
+// baseline and the statistics of its runs.
+type GetTestHandler struct {
+	tests     *catalog.Store
+	scripts   *history.Store
+	baselines *baseline.Store
+}
+
+var _ ToolHandler = &GetTestHandler{}
+
+func NewGetTestHandler(tests *catalog.Store, scripts *history.Store, baselines *baseline.Store) *GetTestHandler {
+	return &GetTestHandler{tests: tests, scripts: scripts, baselines: baselines}
+}
+
+func (h *GetTestHandler) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	name, err := request.RequireString("test_name")
+	if err != nil {
+		return mcp.NewToolResultError("Missing required parameter 'test_name'. Example: 'checkout-flow'"), nil
+	}
+
+	test, err := h.tests.Get(name)
+	if errors.Is(err, catalog.ErrTestNotFound) {
+		return mcp.NewToolResultError(fmt.Sprintf("No test is registered as %q. Call list_tests to find its name.", name)), nil
+	}
+	if err != nil {
+		return mcp.NewToolResultError("Failed to read the test; reason: " + err.Error()), nil
+	}
+
+	result := GetTestResult{Test: test}
+
+	script, err := h.scripts.History(test.Script)
+	if err != nil && !errors.Is(err, history.ErrScriptNotFound) {
+		return mcp.NewToolResultError("Failed to read the script history; reason: " + err.Error()), nil
+	}
+	if script != nil && len(script.Revisions) > 0 {
+		result.LatestRevision = &script.Revisions[len(script.Revisions)-1]
+	}
+
+	result.Baseline, err = h.baselines.Get(name)
+	if err != nil && !errors.Is(err, baseline.ErrBaselineNotFound) {
+		return mcp.NewToolResultError("Failed to read the baseline; reason: " + err.Error()), nil
+	}
+
+	stats, err := h.scripts.RunStats(history.RunQuery{Test: name, Limit: testStatsPoints})
+	if err != nil {
+		return mcp.NewToolResultError("Failed to aggregate the runs of the test; reason: " + err.Error()), nil
+	}
+	if len(stats) > 0 {
+		result.Runs = &stats[0]
+	}
+
+	resultJSON, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("failed to serialize test"), err
+	}
+
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// ListTestsResult is the result of the list_tests tool.
+type ListTestsResult struct {
+	Tests []catalog.Test `json:"tests"`
+}
+
+// ListTestsHandler lists the registered tests.
+type ListTestsHandler struct {
+	tests *catalog.Store
+}
+
+var _ ToolHandler = &ListTestsHandler{}
+
+func NewListTestsHandler(tests *catalog.Store) *ListTestsHandler {
+	return &ListTestsHandler{tests: tests}
+}
+
+func (h *ListTestsHandler) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	tests, err := h.tests.List(catalog.Filter{
+		Owner:  request.GetString("owner", ""),
+		Tag:    request.GetString("tag", ""),
+		Script: request.GetString("script_name", ""),
+	})
+	if err != nil {
+		return mcp.NewToolResultError("Failed to list tests; reason: " + err.Error()), nil
+	}
+
+	resultJSON, err := json.MarshalIndent(ListTestsResult{Tests: tests}, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("failed to serialize tests"), err
+	}
+
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// DeleteTestHandler unregisters tests, keeping their runs, baseline and script history.
+type DeleteTestHandler struct {
+	tests *catalog.Store
+}
+
+var _ ToolHandler = &DeleteTestHandler{}
+
+func NewDeleteTestHandler(tests *catalog.Store) *DeleteTestHandler {
+	return &DeleteTestHandler{tests: tests}
+}
+
+func (h *DeleteTestHandler) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	name, err := request.RequireString("test_name")
+	if err != nil {
+		return mcp.NewToolResultError("Missing required parameter 'test_name'. Example: 'checkout-flow'"), nil
+	}
+
+	err = h.tests.Delete(name)
+	if errors.Is(err, catalog.ErrTestNotFound) {
+		return mcp.NewToolResultError(fmt.Sprintf("No test is registered as %q.", name)), nil
+	}
+	if err != nil {
+		return mcp.NewToolResultError("Failed to delete the test; reason: " + err.Error()), nil
+	}
+
+	slog.InfoContext(ctx, "test deleted", slog.String("test", name))
+
+	resultJSON, err := json.MarshalIndent(map[string]interface{}{
+		"deleted": name,
+		"message": "The test is no longer registered. Its recorded runs, baseline and script history are kept: registering a test under the same name attaches them again.",
+	}, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("failed to serialize result"), err
+	}
+
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/oleiade/k6-mcp/internal/checkgen"
+	"github.com/oleiade/k6-mcp/internal/codegen"
+	"github.com/oleiade/k6-mcp/internal/logging"
+	"github.com/oleiade/k6-mcp/internal/style"
+)
+
+// Sources of the responses checks are generated from.
+const (
+	checksSourceProbe  = "probe"
+	checksSourceSample = "sample"
+)
+
+// GenerateChecksResult is the result of the generate_checks tool.
+type GenerateChecksResult struct {
+	// Source is where the response came from: a probe request or the sample response.
+	Source string `json:"source"`
+	*checkgen.Inference
+	// ProbeDurationMs is the duration of the probe request, in milliseconds.
+	ProbeDurationMs int64 `json:"probe_duration_ms,omitempty"`
+	// Checks are the names of the generated checks, Code their check() call on the
+	// response res, and Import the statement importing check.
+	Checks []string `json:"checks"`
+	Import string   `json:"import"`
+	Code   string   `json:"code"`
+}
+
+// GenerateChecksHandler generates the k6 checks of the responses of an endpoint, from a
+// probe request or a sample response.
+type GenerateChecksHandler struct{}
+
+var _ ToolHandler = &GenerateChecksHandler{}
+
+func NewGenerateChecksHandler() *GenerateChecksHandler {
+	return &GenerateChecksHandler{}
+}
+
+func (h *GenerateChecksHandler) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+
+	maxFields := request.GetInt("max_fields", checkgen.DefaultMaxFields)
+	if maxFields < 1 || maxFields > checkgen.MaxFields {
+		return mcp.NewToolResultError(fmt.Sprintf("Parameter 'max_fields' must be between 1 and %d", checkgen.MaxFields)), nil
+	}
+
+	responseValue, hasResponse := args["response"]
+	urlValue, hasURL := args["url"]
+
+	var response checkgen.Response
+	result := GenerateChecksResult{}
+	switch {
+	case hasResponse && hasURL:
+		return mcp.NewToolResultError("Parameters 'url' and 'response' are mutually exclusive. Provide the URL to probe or a sample response, not both."), nil
+	case hasResponse:
+		if err := decodeArg(responseValue, &response); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Invalid response format: %s. Example: {\"status\": 200, \"headers\": {\"Content-Type\": \"application/json\"}, \"body\": \"{\\\"id\\\": 1}\"}", err.Error())), nil
+		}
+		if len(response.Body) > checkgen.MaxBodyBytes {
+			return mcp.NewToolResultError(fmt.Sprintf("The sample response body exceeds %d bytes: trim it to a representative excerpt.", checkgen.MaxBodyBytes)), nil
+		}
+		result.Source = checksSourceSample
+	case hasURL:
+		probe := checkgen.ProbeRequest{Method: request.GetString("method", http.MethodGet), Body: request.GetString("body", "")}
+		probe.URL, _ = urlValue.(string)
+		if headersValue, exists := args["headers"]; exists {
+			if err := decodeArg(headersValue, &probe.Headers); err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Invalid headers format: %s. Example: {\"Authorization\": \"Bearer <token>\"}", err.Error())), nil
+			}
+		}
+		if err := probe.Validate(); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		probed, elapsed, err := checkgen.Probe(ctx, probe)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("The probe request failed; reason: %s. Check that the URL is reachable from the server, or pass a sample response instead.", err)), nil
+		}
+		response = probed
+		result.Source = checksSourceProbe
+		result.ProbeDurationMs = elapsed.Milliseconds()
+	default:
+		return mcp.NewToolResultError("Missing required parameter 'url' or 'response'. Provide the URL of the endpoint to probe once, or a sample of its responses."), nil
+	}
+
+	inference, err := checkgen.Infer(response, maxFields)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if result.Source == checksSourceProbe && response.Status >= 400 {
+		inference.Notes = append(inference.Notes, fmt.Sprintf("The probe got a %d response: the checks assert it, so fix the request, e.g. its headers, unless this error is the expected outcome.", response.Status))
+	}
+
+	scriptStyle := style.Current()
+	result.Inference = inference
+	result.Import = scriptStyle.Import("k6", "", "check")
+	result.Code = codegen.RenderChecks(inference.Checks, scriptStyle)
+	for _, check := range inference.Checks {
+		result.Checks = append(result.Checks, scriptStyle.CheckName(check.Name))
+	}
+
+	logging.Default().InfoContext(ctx, "Checks generated",
+		slog.String("source", result.Source),
+		slog.Int("status", inference.Status),
+		slog.Int("checks", len(result.Checks)),
+	)
+
+	resultJSON, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("failed to serialize checks"), err
+	}
+
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/oleiade/k6-mcp/internal/cloud"
+	"github.com/oleiade/k6-mcp/internal/scriptsource"
+	"github.com/oleiade/k6-mcp/internal/validator"
+)
+
+// CloudTestResult is the result of the get_cloud_test tool.
+type CloudTestResult struct {
+	*cloud.Test
+
+	// ScriptURL references the test's script in the script_url parameter of the other tools,
+	// to validate or run it locally.
+	ScriptURL string `json:"script_url"`
+	Script    string `json:"script,omitempty"`
+}
+
+// UpdateCloudTestScriptResult is the result of the update_cloud_test_script tool.
+type UpdateCloudTestScriptResult struct {
+	TestID       int64  `json:"test_id"`
+	Updated      bool   `json:"updated"`
+	ScriptSHA256 string `json:"script_sha256"`
+	SizeBytes    int    `json:"size_bytes"`
+	// Warnings are the validation warnings of the pushed script.
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// GetCloudTestHandler returns the Grafana Cloud k6 tests, with their scripts.
+type GetCloudTestHandler struct {
+	client *cloud.Client
+}
+
+var _ ToolHandler = &GetCloudTestHandler{}
+
+func NewGetCloudTestHandler(client *cloud.Client) *GetCloudTestHandler {
+	return &GetCloudTestHandler{client: client}
+}
+
+func (h *GetCloudTestHandler) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	id, errMsg := cloudTestID(request)
+	if errMsg != "" {
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	test, err := h.client.Test(ctx, id)
+	if err != nil {
+		return mcp.NewToolResultError(cloudErrorMessage("get the cloud test", err)), nil
+	}
+	result := CloudTestResult{Test: test, ScriptURL: cloud.Scheme + strconv.FormatInt(id, 10)}
+
+	if request.GetBool("include_script", true) {
+		if result.Script, err = h.client.Script(ctx, id); err != nil {
+			return mcp.NewToolResultError(cloudErrorMessage("get the script of the cloud test", err)), nil
+		}
+	}
+
+	slog.InfoContext(ctx, "cloud test fetched",
+		slog.Int64("test_id", id),
+		slog.Int("script_size", len(result.Script)),
+	)
+
+	resultJSON, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("failed to serialize cloud test"), err
+	}
+
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// UpdateCloudTestScriptHandler pushes validated scripts to Grafana Cloud k6 tests.
+type UpdateCloudTestScriptHandler struct {
+	client  *cloud.Client
+	fetcher *scriptsource.Fetcher
+}
+
+var _ ToolHandler = &UpdateCloudTestScriptHandler{}
+
+func NewUpdateCloudTestScriptHandler(client *cloud.Client, fetcher *scriptsource.Fetcher) *UpdateCloudTestScriptHandler {
+	return &UpdateCloudTestScriptHandler{client: client, fetcher: fetcher}
+}
+
+func (h *UpdateCloudTestScriptHandler) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	id, errMsg := cloudTestID(request)
+	if errMsg != "" {
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	script, errMsg := resolveScript(ctx, request.GetArguments(), h.fetcher)
+	if errMsg != "" {
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	// Only push scripts k6 accepts, so that the cloud test keeps running
+	validation, err := validator.ValidateK6Script(ctx, script)
+	if err != nil || validation == nil || !validation.Valid {
+		return mcp.NewToolResultError("The script was not pushed: it fails validation. Fix the issues reported by the 'validate' tool first." + validationSummary(validation, err)), nil
+	}
+
+	if err := h.client.UpdateScript(ctx, id, script); err != nil {
+		return mcp.NewToolResultError(cloudErrorMessage("update the script of the cloud test", err)), nil
+	}
+
+	result := UpdateCloudTestScriptResult{
+		TestID:       id,
+		Updated:      true,
+		ScriptSHA256: scriptHash(script),
+		SizeBytes:    len(script),
+	}
+	for _, issue := range validation.Issues {
+		result.Warnings = append(result.Warnings, issue.Message)
+	}
+
+	slog.InfoContext(ctx, "cloud test script updated",
+		slog.Int64("test_id", id),
+		slog.Int("script_size", len(script)),
+	)
+
+	resultJSON, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("failed to serialize cloud test update"), err
+	}
+
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// cloudTestID returns the test_id argument, or an error message.
+func cloudTestID(request mcp.CallToolRequest) (int64, string) {
+	value, ok := request.GetArguments()["test_id"].(float64)
+	if !ok || value <= 0 || value != float64(int64(value)) {
+		return 0, "Parameter 'test_id' must be the positive integer ID of a Grafana Cloud k6 load test. Example: 1234"
+	}
+	return int64(value), ""
+}
+
+// cloudErrorMessage returns the tool error message of a failed API call.
+func cloudErrorMessage(action string, err error) string {
+	if errors.Is(err, cloud.ErrNotConfigured) {
+		return "Failed to " + action + ": " + err.Error()
+	}
+	return fmt.Sprintf("Failed to %s; reason: %v", action, err)
+}
+
+// validationSummary returns the reasons a script failed validation, prefixed with a space.
+func validationSummary(result *validator.ValidationResult, err error) string {
+	var reasons []string
+	if err != nil {
+		reasons = append(reasons, err.Error())
+	}
+	if result != nil {
+		for _, issue := range result.Issues {
+			reasons = append(reasons, issue.Message)
+		}
+	}
+	if len(reasons) == 0 {
+		return ""
+	}
+	return " Issues: " + strings.Join(reasons, "; ")
+}
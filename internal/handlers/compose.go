@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/oleiade/k6-mcp/internal/infra"
+)
+
+// ComposeHandler generates a docker-compose stack running a k6 script locally, with
+// Prometheus and Grafana for observability.
+type ComposeHandler struct{}
+
+var _ ToolHandler = &ComposeHandler{}
+
+func NewComposeHandler() *ComposeHandler {
+	return &ComposeHandler{}
+}
+
+func (h *ComposeHandler) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	script, err := request.RequireString("script")
+	if err != nil {
+		return mcp.NewToolResultError("Missing or invalid 'script' parameter. Please provide a k6 script as a string."), nil
+	}
+
+	spec := &infra.ComposeSpec{
+		Script:         script,
+		K6Image:        request.GetString("k6_image", ""),
+		GrafanaPort:    request.GetInt("grafana_port", 0),
+		PrometheusPort: request.GetInt("prometheus_port", 0),
+	}
+
+	if envValue, exists := request.GetArguments()["env"]; exists {
+		if err := decodeArg(envValue, &spec.Env); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Invalid env format: %s. Example: {\"BASE_URL\": \"https://test.k6.io\"}", err.Error())), nil
+		}
+	}
+
+	compose, err := infra.GenerateCompose(spec)
+	if err != nil {
+		return mcp.NewToolResultError("Failed to generate docker-compose stack; reason: " + err.Error()), nil
+	}
+
+	return mcp.NewToolResultText(compose), nil
+}
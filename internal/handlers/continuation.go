@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/oleiade/k6-mcp/internal/continuation"
+	"github.com/oleiade/k6-mcp/internal/federation"
+)
+
+// GetMoreOutputHandler returns the remainder of truncated tool response fields, in chunks.
+type GetMoreOutputHandler struct {
+	more *continuation.Store
+	// upstream holds the remainders of the documentation tool results it answered in
+	// place of the local index.
+	upstream *federation.Client
+}
+
+var _ ToolHandler = &GetMoreOutputHandler{}
+
+func NewGetMoreOutputHandler(more *continuation.Store, upstream *federation.Client) *GetMoreOutputHandler {
+	return &GetMoreOutputHandler{more: more, upstream: upstream}
+}
+
+func (h *GetMoreOutputHandler) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	token, err := request.RequireString("token")
+	if err != nil {
+		return mcp.NewToolResultError("Missing required parameter 'token'. Use the token of a '*_continuation' field of a run or search result."), nil
+	}
+
+	limit := request.GetInt("max_bytes", h.more.InlineBytes())
+	if limit <= 0 || limit > continuation.MaxChunkBytes {
+		return mcp.NewToolResultError(fmt.Sprintf("Parameter 'max_bytes' must be between 1 and %d", continuation.MaxChunkBytes)), nil
+	}
+
+	chunk, err := h.more.Next(token, limit)
+	if errors.Is(err, continuation.ErrExpired) && h.upstream.Configured() {
+		// Tokens unknown here may be those of the results of the upstream server
+		result, upstreamErr := h.upstream.CallTool(ctx, "get_more_output", request.GetArguments())
+		if upstreamErr == nil && !result.IsError {
+			return result, nil
+		}
+	}
+	if errors.Is(err, continuation.ErrExpired) {
+		return mcp.NewToolResultError("The continuation token expired or is unknown: truncated output is kept in memory for a limited time. Run the tool again to get a new token, or read the run's stored artifacts with get_artifact."), nil
+	}
+	if err != nil {
+		return mcp.NewToolResultError("Failed to read output; reason: " + err.Error()), nil
+	}
+
+	slog.InfoContext(ctx, "output continued",
+		slog.String("label", chunk.Label),
+		slog.Int("offset", chunk.Offset),
+		slog.Int("bytes", len(chunk.Content)),
+	)
+
+	resultJSON, err := json.MarshalIndent(chunk, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("failed to serialize output"), err
+	}
+
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/oleiade/k6-mcp/internal/k6api"
+	"github.com/oleiade/k6-mcp/internal/logging"
+	"github.com/oleiade/k6-mcp/internal/runner"
+)
+
+// Actions of the run control tools.
+const (
+	controlPause  = "pause"
+	controlResume = "resume"
+	controlScale  = "scale"
+)
+
+// RunControlResult is the result of the pause_test, resume_test and scale_test tools.
+type RunControlResult struct {
+	Action string `json:"action"`
+	// RunID is the ID of the run in progress, as listed by get_run_progress.
+	RunID  string       `json:"run_id"`
+	Status k6api.Status `json:"status"`
+}
+
+// RunControlHandler pauses, resumes or scales a run in progress through the REST API of k6.
+type RunControlHandler struct {
+	action string
+}
+
+var _ ToolHandler = &RunControlHandler{}
+
+func NewPauseTestHandler() *RunControlHandler {
+	return &RunControlHandler{action: controlPause}
+}
+
+func NewResumeTestHandler() *RunControlHandler {
+	return &RunControlHandler{action: controlResume}
+}
+
+func NewScaleTestHandler() *RunControlHandler {
+	return &RunControlHandler{action: controlScale}
+}
+
+func (h *RunControlHandler) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	runID := request.GetString("run_id", "")
+
+	vus, maxVUs := 0, 0
+	if h.action == controlScale {
+		vus = request.GetInt("vus", -1)
+		if vus < 0 || vus > runner.MaxVUs {
+			return mcp.NewToolResultError(fmt.Sprintf("Parameter 'vus' is required and must be between 0 and %d", runner.MaxVUs)), nil
+		}
+		maxVUs = request.GetInt("max_vus", 0)
+		if maxVUs < 0 || maxVUs > runner.MaxVUs {
+			return mcp.NewToolResultError(fmt.Sprintf("Parameter 'max_vus' must be between 1 and %d", runner.MaxVUs)), nil
+		}
+		if maxVUs > 0 && vus > maxVUs {
+			return mcp.NewToolResultError("Parameter 'vus' cannot exceed 'max_vus'"), nil
+		}
+	}
+
+	runID, api, err := runner.ControlRun(runID)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Cannot %s the run: %s. Call get_run_progress to list the runs in progress.", h.action, err)), nil
+	}
+
+	var status k6api.Status
+	switch h.action {
+	case controlPause:
+		status, err = api.Pause(ctx)
+	case controlResume:
+		status, err = api.Resume(ctx)
+	case controlScale:
+		status, err = api.Scale(ctx, vus, maxVUs)
+	}
+	if err != nil {
+		message := fmt.Sprintf("Failed to %s the run: %s", h.action, err)
+		if h.action == controlScale {
+			message += ". k6 only scales runs of the externally-controlled executor: give the script a scenario such as { executor: 'externally-controlled', vus: 1, maxVUs: 10, duration: '10m' }."
+		}
+		return mcp.NewToolResultError(message), nil
+	}
+
+	logging.Default().InfoContext(ctx, "Run controlled",
+		slog.String("action", h.action),
+		slog.String("run_id", runID),
+		slog.Bool("paused", status.Paused),
+		slog.Int("vus", status.VUs),
+	)
+
+	resultJSON, err := json.MarshalIndent(RunControlResult{Action: h.action, RunID: runID, Status: status}, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("failed to serialize run status"), err
+	}
+
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
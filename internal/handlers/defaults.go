@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/oleiade/k6-mcp/internal/defaults"
+)
+
+// DefaultsResult is the result of the set_defaults and get_defaults tools.
+type DefaultsResult struct {
+	// Session holds the defaults of the client session.
+	Session *defaults.Defaults `json:"session"`
+	// Project holds the defaults of the project, when one is named.
+	Project     *defaults.Defaults `json:"project,omitempty"`
+	ProjectName string             `json:"project_name,omitempty"`
+	// Effective holds the defaults runs of the project, or without a project, receive.
+	Effective *defaults.Defaults `json:"effective"`
+	Projects  []string           `json:"projects,omitempty"`
+}
+
+// SetDefaultsHandler stores default run options for the session or a project.
+type SetDefaultsHandler struct {
+	store *defaults.Store
+}
+
+var _ ToolHandler = &SetDefaultsHandler{}
+
+func NewSetDefaultsHandler(store *defaults.Store) *SetDefaultsHandler {
+	return &SetDefaultsHandler{store: store}
+}
+
+func (h *SetDefaultsHandler) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+
+	update := &defaults.Defaults{
+		VUs:        request.GetInt("vus", 0),
+		Duration:   request.GetString("duration", ""),
+		TargetHost: request.GetString("target_host", ""),
+		RateLimit:  request.GetFloat("rate_limit", 0),
+	}
+	if envValue, exists := args["env"]; exists {
+		if err := decodeArg(envValue, &update.Env); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Invalid env format: %s. Example: {\"API_KEY_NAME\": \"staging\"}", err.Error())), nil
+		}
+	}
+	if thresholdsValue, exists := args["thresholds"]; exists {
+		if err := decodeArg(thresholdsValue, &update.Thresholds); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Invalid thresholds format: %s. Example: {\"http_req_duration\": [\"p(95)<500\"], \"http_req_failed\": [\"rate<0.01\"]}", err.Error())), nil
+		}
+	}
+	replace := request.GetBool("replace", false)
+
+	project := request.GetString("project", "")
+	var err error
+	if project != "" {
+		_, err = h.store.SetProject(project, update, replace)
+	} else {
+		sessionID := sessionIDFromContext(ctx)
+		if sessionID == "" {
+			return mcp.NewToolResultError("No client session is attached to this request; name a 'project' to store the defaults under instead."), nil
+		}
+		_, err = h.store.SetSession(sessionID, update, replace)
+	}
+	if err != nil {
+		return mcp.NewToolResultError("Failed to set defaults; reason: " + err.Error()), nil
+	}
+
+	slog.InfoContext(ctx, "run defaults set",
+		slog.String("project", project),
+		slog.Bool("replace", replace),
+	)
+
+	return defaultsResult(ctx, h.store, project)
+}
+
+// GetDefaultsHandler returns the default run options of the session and a project.
+type GetDefaultsHandler struct {
+	store *defaults.Store
+}
+
+var _ ToolHandler = &GetDefaultsHandler{}
+
+func NewGetDefaultsHandler(store *defaults.Store) *GetDefaultsHandler {
+	return &GetDefaultsHandler{store: store}
+}
+
+func (h *GetDefaultsHandler) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return defaultsResult(ctx, h.store, request.GetString("project", ""))
+}
+
+// defaultsResult returns the defaults of the session of ctx and of the project.
+func defaultsResult(ctx context.Context, store *defaults.Store, project string) (*mcp.CallToolResult, error) {
+	result := DefaultsResult{
+		Session:     store.Session(sessionIDFromContext(ctx)),
+		ProjectName: project,
+	}
+
+	var err error
+	if project != "" {
+		if result.Project, err = store.Project(project); err != nil {
+			return mcp.NewToolResultError("Failed to read defaults; reason: " + err.Error()), nil
+		}
+	}
+	if result.Projects, err = store.Projects(); err != nil {
+		return mcp.NewToolResultError("Failed to read defaults; reason: " + err.Error()), nil
+	}
+	result.Effective = defaults.Effective(result.Project, result.Session)
+
+	resultJSON, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("failed to serialize defaults"), err
+	}
+
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// applyRunDefaults completes the run arguments with the defaults of the session of ctx
+// and of the project named by the 'project' argument, and returns the names of the
+// arguments the defaults were applied to, and the environment profile they come from.
+func applyRunDefaults(ctx context.Context, store *defaults.Store, args map[string]interface{}) (map[string]interface{}, []string, *defaults.Defaults, string) {
+	if store == nil {
+		return args, nil, nil, ""
+	}
+
+	var project *defaults.Defaults
+	if name, ok := args["project"].(string); ok && name != "" {
+		d, err := store.Project(name)
+		if err != nil {
+			return nil, nil, nil, "Failed to read the project defaults; reason: " + err.Error()
+		}
+		if d == nil {
+			return nil, nil, nil, fmt.Sprintf("No defaults are set for project %q. Set them with set_defaults, or omit 'project'.", name)
+		}
+		project = d
+	}
+
+	profile := defaults.Effective(project, store.Session(sessionIDFromContext(ctx)))
+	merged, applied := profile.Apply(args)
+	return merged, applied, profile, ""
+}
+
+// sessionIDFromContext returns the ID of the client session of ctx, or "".
+func sessionIDFromContext(ctx context.Context) string {
+	if session := server.ClientSessionFromContext(ctx); session != nil {
+		return session.SessionID()
+	}
+	return ""
+}
@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/oleiade/k6-mcp/internal/docs"
+	"github.com/oleiade/k6-mcp/internal/logging"
+)
+
+// DiffDocsHandler compares the documentation of an API or topic between two k6 versions.
+type DiffDocsHandler struct {
+	store *docs.Store
+}
+
+var _ ToolHandler = &DiffDocsHandler{}
+
+// NewDiffDocsHandler returns a DiffDocsHandler reading pages from db.
+func NewDiffDocsHandler(db *sql.DB) *DiffDocsHandler {
+	return &DiffDocsHandler{store: docs.NewStore(db)}
+}
+
+// DiffDocsResult is the structured response of the diff_docs tool.
+type DiffDocsResult struct {
+	*docs.VersionDiff
+	// Summary is a one-line summary of the changes.
+	Summary string `json:"summary"`
+}
+
+// Handle compares the pages under the requested path between the two versions.
+func (h *DiffDocsHandler) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	fromName := strings.TrimSpace(request.GetString("from", ""))
+	if fromName == "" {
+		return mcp.NewToolResultError("Missing required parameter 'from': the k6 version to compare from, e.g. '0.52' or 'v1.0.x'."), nil
+	}
+	toName := strings.TrimSpace(request.GetString("to", "latest"))
+	path := docs.NormalizePath(request.GetString("path", ""))
+
+	versions, err := h.store.Versions(ctx)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("diff failed: %v", err)), nil
+	}
+	if len(versions) < 2 {
+		return mcp.NewToolResultError("The documentation index holds a single k6 version. Rebuild it with 'prepare --doc-versions <n>' to index earlier versions to compare."), nil
+	}
+
+	from, errMsg := h.resolve(ctx, fromName, versions)
+	if errMsg != "" {
+		return mcp.NewToolResultError(errMsg), nil
+	}
+	to, errMsg := h.resolve(ctx, toName, versions)
+	if errMsg != "" {
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	diff, err := h.store.Diff(ctx, from, to, path)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("diff failed: %v", err)), nil
+	}
+	if len(diff.Added)+len(diff.Removed)+len(diff.Changed)+diff.Unchanged == 0 {
+		return mcp.NewToolResultError(fmt.Sprintf("No documentation pages found at or under '%s' in %s or %s. Use browse_documentation to find the path of the API or topic.", path, from.Name, to.Name)), nil
+	}
+
+	logging.WithComponent("handlers").DebugContext(ctx, "Compared documentation versions",
+		slog.String("from", from.Name),
+		slog.String("to", to.Name),
+		slog.String("path", path),
+		slog.Int("added", len(diff.Added)),
+		slog.Int("removed", len(diff.Removed)),
+		slog.Int("changed", len(diff.Changed)),
+	)
+
+	resultJSON, err := json.MarshalIndent(DiffDocsResult{VersionDiff: diff, Summary: summarizeDiff(diff)}, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("failed to serialize the documentation diff"), err
+	}
+
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// resolve resolves the version of the name, or returns a user-facing error message listing
+// the indexed versions.
+func (h *DiffDocsHandler) resolve(ctx context.Context, name string, versions []docs.Version) (docs.Version, string) {
+	version, err := h.store.ResolveVersion(ctx, name)
+	if err == nil {
+		return version, ""
+	}
+
+	names := make([]string, len(versions))
+	for i, v := range versions {
+		names[i] = v.Name
+	}
+	if errors.Is(err, docs.ErrVersionNotFound) {
+		return docs.Version{}, fmt.Sprintf("The documentation of k6 %s is not indexed. Indexed versions: %s.", strings.TrimPrefix(err.Error(), docs.ErrVersionNotFound.Error()+": "), strings.Join(names, ", "))
+	}
+	return docs.Version{}, fmt.Sprintf("%v. Indexed versions: %s.", err, strings.Join(names, ", "))
+}
+
+// summarizeDiff returns a one-line summary of the diff, naming the items added and removed.
+func summarizeDiff(diff *docs.VersionDiff) string {
+	scope := "the documentation"
+	if diff.Path != "" {
+		scope = "'" + diff.Path + "'"
+	}
+	if len(diff.Added)+len(diff.Removed)+len(diff.Changed) == 0 {
+		return fmt.Sprintf("No changes to %s between %s and %s.", scope, diff.From, diff.To)
+	}
+
+	summary := fmt.Sprintf("From %s to %s, %s has %d pages added, %d removed and %d changed.",
+		diff.From, diff.To, scope, len(diff.Added), len(diff.Removed), len(diff.Changed))
+
+	var added, removed []string
+	for _, change := range diff.Changed {
+		added = append(added, change.AddedItems...)
+		removed = append(removed, change.RemovedItems...)
+	}
+	for _, change := range diff.Added {
+		added = append(added, change.Title)
+	}
+	for _, change := range diff.Removed {
+		removed = append(removed, change.Title)
+	}
+	if len(added) > 0 {
+		summary += " New: " + joinLimited(added, 10) + "."
+	}
+	if len(removed) > 0 {
+		summary += " Removed: " + joinLimited(removed, 10) + "."
+	}
+
+	return summary
+}
+
+// joinLimited joins up to limit items, noting how many more there are.
+func joinLimited(items []string, limit int) string {
+	if len(items) <= limit {
+		return strings.Join(items, ", ")
+	}
+	return fmt.Sprintf("%s and %d more", strings.Join(items[:limit], ", "), len(items)-limit)
+}
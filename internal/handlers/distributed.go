@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/oleiade/k6-mcp/internal/archive"
+	"github.com/oleiade/k6-mcp/internal/distributed"
+	"github.com/oleiade/k6-mcp/internal/logging"
+	"github.com/oleiade/k6-mcp/internal/scriptsource"
+)
+
+// RunDistributedResult is the result of the run_distributed tool.
+type RunDistributedResult struct {
+	Success bool `json:"success"`
+	// ArchiveSHA256 identifies the archive every agent ran.
+	ArchiveSHA256 string                 `json:"archive_sha256"`
+	Duration      string                 `json:"duration"`
+	Merged        distributed.Merged     `json:"merged"`
+	Agents        []distributed.AgentRun `json:"agents"`
+}
+
+// RunDistributedHandler runs a script on several remote agents at once, from the same
+// archive, and merges the summaries of their results.
+type RunDistributedHandler struct {
+	fetcher     *scriptsource.Fetcher
+	coordinator *distributed.Coordinator
+}
+
+var _ ToolHandler = &RunDistributedHandler{}
+
+func NewRunDistributedHandler(fetcher *scriptsource.Fetcher, coordinator *distributed.Coordinator) *RunDistributedHandler {
+	return &RunDistributedHandler{fetcher: fetcher, coordinator: coordinator}
+}
+
+func (h *RunDistributedHandler) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+
+	script, errMsg := resolveScript(ctx, args, h.fetcher)
+	if errMsg != "" {
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	options, err := parseRunOptions(args)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid parameters: %v.", err)), nil
+	}
+
+	var names []string
+	if agentsValue, exists := args["agents"]; exists {
+		if err := decodeArg(agentsValue, &names); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Parameter 'agents' must be an array of agent names: %s. Example: [\"eu-west\", \"us-east\"]", err.Error())), nil
+		}
+	}
+	agents, err := h.coordinator.Select(names)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	// Package the script once, so that every agent runs the very same archive
+	startTime := time.Now()
+	archived, err := archive.CreateArchive(ctx, script, options.Files)
+	if err != nil || !archived.Success {
+		message := archived.Error
+		if message == "" && err != nil {
+			message = err.Error()
+		}
+		return mcp.NewToolResultError("Failed to archive the script; reason: " + message), nil
+	}
+
+	runs := h.coordinator.Dispatch(ctx, agents, distributed.RunRequest{
+		Archive:     archived.Archive,
+		VUs:         options.VUs,
+		Duration:    options.Duration,
+		Iterations:  options.Iterations,
+		Stages:      options.Stages,
+		Env:         options.Env,
+		Thresholds:  options.Thresholds,
+		Tags:        options.Tags,
+		TestName:    options.TestName,
+		Environment: options.Environment,
+	})
+	merged := distributed.Merge(runs)
+
+	result := RunDistributedResult{
+		Success:       merged.Success,
+		ArchiveSHA256: archived.SHA256,
+		Duration:      time.Since(startTime).Round(time.Millisecond).String(),
+		Merged:        merged,
+		Agents:        runs,
+	}
+
+	logging.Default().InfoContext(ctx, "Distributed run completed",
+		slog.Bool("success", result.Success),
+		slog.Int("agents", merged.Agents),
+		slog.Int("failed_agents", merged.Failed),
+		slog.Int("total_requests", merged.TotalRequests),
+	)
+
+	resultJSON, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("failed to serialize distributed run result"), err
+	}
+
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
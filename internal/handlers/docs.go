@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/oleiade/k6-mcp/internal/docs"
+	"github.com/oleiade/k6-mcp/internal/search"
+)
+
+// DocumentationURIPrefix is the URI prefix under which documentation pages are exposed as resources.
+const DocumentationURIPrefix = "docs://k6/pages/"
+
+// DocumentationResourceHandler serves k6 documentation pages from the embedded index database.
+type DocumentationResourceHandler struct {
+	store *docs.Store
+}
+
+var _ ResourceHandler = &DocumentationResourceHandler{}
+
+// NewDocumentationResourceHandler returns a DocumentationResourceHandler reading pages from db.
+func NewDocumentationResourceHandler(db *sql.DB) *DocumentationResourceHandler {
+	return &DocumentationResourceHandler{store: docs.NewStore(db)}
+}
+
+// Handle returns the markdown content of the documentation page addressed by the request URI.
+func (h *DocumentationResourceHandler) Handle(
+	ctx context.Context,
+	request mcp.ReadResourceRequest,
+) ([]mcp.ResourceContents, error) {
+	path, ok := strings.CutPrefix(request.Params.URI, DocumentationURIPrefix)
+	if !ok || docs.NormalizePath(path) == "" {
+		return nil, fmt.Errorf("invalid documentation URI %q; expected %s<path>", request.Params.URI, DocumentationURIPrefix)
+	}
+
+	page, err := h.store.Get(ctx, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read documentation page; reason: %w", err)
+	}
+
+	return []mcp.ResourceContents{
+		mcp.TextResourceContents{
+			URI:      request.Params.URI,
+			MIMEType: "text/markdown",
+			Text:     formatPage(page),
+		},
+	}, nil
+}
+
+// formatPage renders a page as markdown, prefixed with its title and description.
+func formatPage(page *docs.Page) string {
+	var b strings.Builder
+	b.WriteString("# " + page.Title + "\n\n")
+	if page.Description != "" {
+		b.WriteString("> " + page.Description + "\n\n")
+	}
+	b.WriteString(page.Content)
+	b.WriteString("\n")
+	return b.String()
+}
+
+const (
+	// WhatsNewURI is the URI of the resource listing the recent changes of the documentation.
+	WhatsNewURI = "docs://k6/whats_new"
+
+	// releaseNotesPrefix and experimentalPrefix are the documentation sections of the
+	// release notes and of the experimental modules.
+	releaseNotesPrefix = "release-notes"
+	experimentalPrefix = "javascript-api/k6-experimental"
+
+	// whatsNewReleaseNotes and whatsNewPages bound the release notes and the recently
+	// updated pages listed by the whats_new resource.
+	whatsNewReleaseNotes = 5
+	whatsNewPages        = 25
+)
+
+// WhatsNewResourceHandler serves the latest release notes, the experimental modules and
+// the recently updated pages of the documentation, so that clients learn about new features.
+type WhatsNewResourceHandler struct {
+	store *docs.Store
+}
+
+var _ ResourceHandler = &WhatsNewResourceHandler{}
+
+// NewWhatsNewResourceHandler returns a WhatsNewResourceHandler reading pages from db.
+func NewWhatsNewResourceHandler(db *sql.DB) *WhatsNewResourceHandler {
+	return &WhatsNewResourceHandler{store: docs.NewStore(db)}
+}
+
+// Handle returns the markdown list of the recent changes of the documentation.
+func (h *WhatsNewResourceHandler) Handle(
+	ctx context.Context,
+	request mcp.ReadResourceRequest,
+) ([]mcp.ResourceContents, error) {
+	latest, err := h.store.LatestUpdate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	releaseNotes, err := h.store.Recent(ctx, releaseNotesPrefix, time.Time{}, whatsNewReleaseNotes)
+	if err != nil {
+		return nil, err
+	}
+	experimental, err := h.store.Browse(ctx, experimentalPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	var b strings.Builder
+	b.WriteString("# What's new in k6\n\n")
+	if latest.IsZero() {
+		b.WriteString("The documentation index has no modification dates, so recently updated pages can't be listed. Rebuild it with `go run ./cmd/prepare`.\n")
+	} else {
+		fmt.Fprintf(&b, "The indexed documentation was last updated on %s.\n", latest.Format(time.DateOnly))
+	}
+
+	if len(releaseNotes) > 0 {
+		b.WriteString("\n## Release notes\n\n")
+		for _, page := range releaseNotes {
+			b.WriteString(formatPageItem(page.Path, page.Title, page.Updated, ""))
+		}
+	}
+
+	if len(experimental) > 0 {
+		b.WriteString("\n## Experimental modules\n\nExperimental modules are new APIs that may change, or become stable modules, in later k6 releases.\n\n")
+		for _, entry := range experimental {
+			b.WriteString(formatPageItem(entry.Path, entry.Title, time.Time{}, entry.Description))
+		}
+	}
+
+	if !latest.IsZero() {
+		since := latest.Add(-search.RecentWindow)
+		recent, err := h.store.Recent(ctx, "", since, whatsNewPages)
+		if err != nil {
+			return nil, err
+		}
+
+		fmt.Fprintf(&b, "\n## Recently updated pages\n\nThe pages updated since %s, the most recent first. Search them with the boost_recent or updated_since parameters of search_k6_documentation.\n\n", since.Format(time.DateOnly))
+		for _, page := range recent {
+			b.WriteString(formatPageItem(page.Path, page.Title, page.Updated, page.Description))
+		}
+	}
+
+	return []mcp.ResourceContents{
+		mcp.TextResourceContents{
+			URI:      WhatsNewURI,
+			MIMEType: "text/markdown",
+			Text:     b.String(),
+		},
+	}, nil
+}
+
+// formatPageItem renders a page as a markdown list item linking to its resource, with its
+// update date and description when known.
+func formatPageItem(path, title string, updated time.Time, description string) string {
+	item := fmt.Sprintf("- [%s](%s%s)", title, DocumentationURIPrefix, path)
+	if !updated.IsZero() {
+		item += " (" + updated.Format(time.DateOnly) + ")"
+	}
+	if description != "" {
+		item += ": " + description
+	}
+	return item + "\n"
+}
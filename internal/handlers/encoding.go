@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/oleiade/k6-mcp/internal/security"
+	"github.com/oleiade/k6-mcp/internal/workspace"
+)
+
+// Encodings of the content parameters, declared by the 'encoding' parameter of the tools.
+const (
+	encodingNone       = "none"
+	encodingBase64     = "base64"
+	encodingGzipBase64 = "gzip+base64"
+)
+
+// errContentTooLarge reports decoded content exceeding the size limit of its parameter.
+var errContentTooLarge = errors.New("content too large")
+
+// decodeContentArgs decodes the content parameters of the arguments as declared by their
+// 'encoding' parameter: the script, the values of the companion files, and a summary given
+// as a string. The decoded content is bounded by the size limit of its parameter while it is
+// decompressed, so that small payloads cannot expand past it. It returns the arguments with
+// their decoded content and without 'encoding', or a user-facing error message.
+func decodeContentArgs(args map[string]interface{}) (map[string]interface{}, string) {
+	value, ok := args["encoding"]
+	if !ok || value == nil {
+		return args, ""
+	}
+
+	encoding, ok := value.(string)
+	encoding = strings.ToLower(strings.TrimSpace(encoding))
+	switch {
+	case !ok:
+		return nil, "Parameter 'encoding' must be a string"
+	case encoding == "" || encoding == encodingNone:
+		decoded := maps.Clone(args)
+		delete(decoded, "encoding")
+		return decoded, ""
+	case encoding != encodingBase64 && encoding != encodingGzipBase64:
+		return nil, fmt.Sprintf("Unsupported encoding '%s': expected '%s', '%s' or '%s'", value, encodingNone, encodingBase64, encodingGzipBase64)
+	}
+
+	decoded := maps.Clone(args)
+	delete(decoded, "encoding")
+
+	if script, ok := args["script"].(string); ok {
+		content, errMsg := decodeTextParam("script", script, encoding, security.MaxScriptSizeBytes)
+		if errMsg != "" {
+			return nil, errMsg
+		}
+		decoded["script"] = content
+	}
+
+	if summary, ok := args["summary"].(string); ok {
+		content, errMsg := decodeTextParam("summary", summary, encoding, maxImportedSummaryBytes)
+		if errMsg != "" {
+			return nil, errMsg
+		}
+		decoded["summary"] = content
+	}
+
+	if files, ok := args["files"].(map[string]interface{}); ok {
+		// Decode the files in a stable order, so that the budget left runs out on the same file
+		paths := make([]string, 0, len(files))
+		for path := range files {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+
+		decodedFiles := maps.Clone(files)
+		remaining := workspace.MaxFilesSizeBytes
+		for _, path := range paths {
+			value, ok := files[path].(string)
+			if !ok {
+				continue
+			}
+			// Files may be binary, such as data files, unlike scripts and summaries
+			content, err := decodeContent(value, encoding, remaining)
+			if errors.Is(err, errContentTooLarge) {
+				return nil, fmt.Sprintf("The files exceed their maximum total size of %d bytes once decoded", workspace.MaxFilesSizeBytes)
+			}
+			if err != nil {
+				return nil, invalidContentMessage(fmt.Sprintf("files[%q]", path), encoding, err)
+			}
+			decodedFiles[path] = string(content)
+			remaining -= len(content)
+		}
+		decoded["files"] = decodedFiles
+	}
+
+	return decoded, ""
+}
+
+// decodeTextParam decodes the text value of the named parameter, of at most limit bytes
+// once decoded, and returns a user-facing error message when it cannot.
+func decodeTextParam(name, value, encoding string, limit int) (string, string) {
+	content, err := decodeContent(value, encoding, limit)
+	switch {
+	case errors.Is(err, errContentTooLarge):
+		return "", fmt.Sprintf("Parameter '%s' exceeds its maximum size of %d bytes once decoded", name, limit)
+	case err != nil:
+		return "", invalidContentMessage(name, encoding, err)
+	case !utf8.Valid(content):
+		return "", fmt.Sprintf("Parameter '%s' is not UTF-8 text once decoded", name)
+	}
+
+	return string(content), ""
+}
+
+// invalidContentMessage returns the user-facing message of content that failed to decode.
+func invalidContentMessage(name, encoding string, err error) string {
+	return fmt.Sprintf("Parameter '%s' is not valid %s content: %v. Encode content with e.g. 'gzip -c script.js | base64'.", name, encoding, err)
+}
+
+// decodeContent decodes the value from base64, then decompresses it with gzip for the
+// gzip+base64 encoding, reading at most limit bytes. Whitespace, such as the line breaks of
+// the base64 command, is ignored.
+func decodeContent(value, encoding string, limit int) ([]byte, error) {
+	raw, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(value), ""))
+	if err != nil {
+		return nil, fmt.Errorf("invalid base64: %w", err)
+	}
+
+	if encoding == encodingBase64 {
+		if len(raw) > limit {
+			return nil, errContentTooLarge
+		}
+		return raw, nil
+	}
+
+	reader, err := gzip.NewReader(bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("invalid gzip data: %w", err)
+	}
+	defer reader.Close()
+
+	content, err := io.ReadAll(io.LimitReader(reader, int64(limit)+1))
+	if err != nil {
+		return nil, fmt.Errorf("invalid gzip data: %w", err)
+	}
+	if len(content) > limit {
+		return nil, errContentTooLarge
+	}
+
+	return content, nil
+}
@@ -0,0 +1,234 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/oleiade/k6-mcp/internal/defaults"
+	"github.com/oleiade/k6-mcp/internal/runner"
+	"github.com/oleiade/k6-mcp/internal/scriptsource"
+)
+
+// DefaultCostPerGB is the default data transfer price, in USD per GB, used to estimate the
+// cost of a run: a typical cloud egress price.
+const DefaultCostPerGB = 0.09
+
+// bytesPerGB is the number of bytes in a (decimal) gigabyte.
+const bytesPerGB = 1e9
+
+// DryRunMeasurements are the measurements of the single iteration dry run of an estimate.
+type DryRunMeasurements struct {
+	Success              bool    `json:"success"`
+	Requests             int     `json:"requests"`
+	RequestsPerIteration float64 `json:"requests_per_iteration"`
+	IterationDurationMs  float64 `json:"iteration_duration_ms"`
+	DataReceivedBytes    int64   `json:"data_received_bytes"`
+	DataSentBytes        int64   `json:"data_sent_bytes"`
+	AvgResponseTimeMs    float64 `json:"avg_response_time_ms"`
+	Duration             string  `json:"duration"`
+	Error                string  `json:"error,omitempty"`
+	Stderr               string  `json:"stderr,omitempty"`
+}
+
+// RunProjection is the projection of a dry run to the full configuration of a run.
+type RunProjection struct {
+	Iterations        int64   `json:"iterations"`
+	Requests          int64   `json:"requests"`
+	RequestRate       float64 `json:"request_rate_per_second"`
+	PeakRequestRate   float64 `json:"peak_request_rate_per_second"`
+	DurationSeconds   float64 `json:"duration_seconds"`
+	DataReceivedBytes int64   `json:"data_received_bytes"`
+	DataSentBytes     int64   `json:"data_sent_bytes"`
+	DataReceived      string  `json:"data_received"`
+	DataSent          string  `json:"data_sent"`
+	CostPerGB         float64 `json:"cost_per_gb"`
+	EstimatedCost     float64 `json:"estimated_transfer_cost"`
+}
+
+// EstimateRunResult is the result of the estimate_run tool.
+type EstimateRunResult struct {
+	DryRun      DryRunMeasurements `json:"dry_run"`
+	Projection  *RunProjection     `json:"projection,omitempty"`
+	Assumptions []string           `json:"assumptions"`
+	// RateLimit checks the peak request rate against the rate limit of the environment
+	// profile, or the one given.
+	RateLimit *RateLimitCheck `json:"rate_limit,omitempty"`
+	// DefaultsApplied lists the parameters completed with the session or project defaults.
+	DefaultsApplied []string `json:"defaults_applied,omitempty"`
+}
+
+// EstimateRunHandler estimates the requests and data transfer of a run from a dry run.
+type EstimateRunHandler struct {
+	fetcher  *scriptsource.Fetcher
+	defaults *defaults.Store
+	dryRuns  *DryRuns
+}
+
+var _ ToolHandler = &EstimateRunHandler{}
+
+func NewEstimateRunHandler(fetcher *scriptsource.Fetcher, defaults *defaults.Store, dryRuns *DryRuns) *EstimateRunHandler {
+	return &EstimateRunHandler{fetcher: fetcher, defaults: defaults, dryRuns: dryRuns}
+}
+
+func (h *EstimateRunHandler) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+
+	script, errMsg := resolveScript(ctx, args, h.fetcher)
+	if errMsg != "" {
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	// Complete the arguments with the session and project defaults, as runs do
+	args, applied, runProfile, errMsg := applyRunDefaults(ctx, h.defaults, args)
+	if errMsg != "" {
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	options, err := parseRunOptions(args)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid parameters: %v. The estimate takes the same parameters as the run tool.", err)), nil
+	}
+
+	costPerGB := request.GetFloat("cost_per_gb", DefaultCostPerGB)
+	if costPerGB < 0 {
+		return mcp.NewToolResultError("cost_per_gb cannot be negative."), nil
+	}
+	var rateLimit float64
+	if runProfile != nil {
+		rateLimit = runProfile.RateLimit
+	}
+	rateLimit = request.GetFloat("rate_limit", rateLimit)
+	if rateLimit < 0 {
+		return mcp.NewToolResultError("rate_limit cannot be negative."), nil
+	}
+
+	// Measure a single iteration of a single VU
+	dryRunResult, _ := runner.RunK6Test(ctx, script, &runner.RunOptions{
+		VUs:        1,
+		Iterations: 1,
+		Files:      options.Files,
+		Env:        options.Env,
+	})
+
+	result := EstimateRunResult{DryRun: measureDryRun(dryRunResult), DefaultsApplied: applied}
+	if result.DryRun.Success {
+		result.Projection, result.Assumptions = projectRun(result.DryRun, options, costPerGB)
+		h.dryRuns.put(script, result.DryRun)
+	} else {
+		result.Assumptions = []string{"The dry run failed, so no projection can be made. Fix the script with the validation tool first."}
+	}
+	if rateLimit > 0 {
+		result.RateLimit = checkRateLimit(rateLimit, result.DryRun, options)
+	}
+
+	resultJSON, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("failed to serialize estimate"), err
+	}
+
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// measureDryRun extracts the measurements of a dry run.
+func measureDryRun(result *runner.RunResult) DryRunMeasurements {
+	if result == nil {
+		return DryRunMeasurements{Error: "the dry run produced no result"}
+	}
+
+	measurements := DryRunMeasurements{
+		Success:              result.Success,
+		Requests:             result.Summary.TotalRequests,
+		IterationDurationMs:  result.Summary.AvgIterationDuration,
+		DataReceivedBytes:    result.Summary.DataReceivedBytes,
+		DataSentBytes:        result.Summary.DataSentBytes,
+		AvgResponseTimeMs:    result.Summary.AvgResponseTime,
+		Duration:             result.Duration,
+		Error:                result.Error,
+		RequestsPerIteration: float64(result.Summary.TotalRequests),
+	}
+	if !result.Success {
+		measurements.Stderr = result.Stderr
+	}
+
+	return measurements
+}
+
+// projectRun projects the measurements of a single iteration dry run to the full run
+// configuration, and returns the assumptions the projection relies on.
+func projectRun(dryRun DryRunMeasurements, options *runner.RunOptions, costPerGB float64) (*RunProjection, []string) {
+	assumptions := []string{
+		"Every iteration makes the same requests and transfers the same data as the dry run iteration.",
+		"Response times stay as in the dry run; under load they usually grow, which lowers the iterations of duration-based runs.",
+		fmt.Sprintf("The transfer cost applies %.4f per GB to the data received and sent by the load generator.", costPerGB),
+	}
+
+	vus := float64(options.VUs)
+	if vus == 0 {
+		vus = runner.DefaultVUs
+	}
+	iterationSeconds := dryRun.IterationDurationMs / 1000
+	if iterationSeconds <= 0 {
+		iterationSeconds = 0.001
+	}
+
+	projection := &RunProjection{CostPerGB: costPerGB}
+	var iterations float64
+
+	switch {
+	case options.Iterations > 0:
+		iterations = float64(options.Iterations)
+		projection.DurationSeconds = math.Ceil(iterations/vus) * iterationSeconds
+	case len(options.Stages) > 0:
+		// VUs ramp linearly between stage targets
+		previous := 0.0
+		for _, stage := range options.Stages {
+			duration, _ := time.ParseDuration(stage.Duration)
+			target := float64(stage.Target)
+			iterations += (previous + target) / 2 * duration.Seconds() / iterationSeconds
+			projection.DurationSeconds += duration.Seconds()
+			previous = target
+		}
+		assumptions = append(assumptions, "VUs ramp linearly between the stage targets.")
+	case options.Pacing > 0:
+		duration := parseDurationOrDefault(options.Duration)
+		perVUSeconds := math.Max(60/options.Pacing, iterationSeconds)
+		iterations = vus * duration.Seconds() / perVUSeconds
+		projection.DurationSeconds = duration.Seconds()
+		assumptions = append(assumptions, "Paced iterations start at the pacing rate, or back to back when they take longer than the pacing interval.")
+	default:
+		duration := parseDurationOrDefault(options.Duration)
+		iterations = vus * duration.Seconds() / iterationSeconds
+		projection.DurationSeconds = duration.Seconds()
+		assumptions = append(assumptions, fmt.Sprintf("Each VU loops iterations of %.0fms back to back for the duration.", dryRun.IterationDurationMs))
+	}
+
+	projection.Iterations = int64(math.Round(iterations))
+	projection.Requests = int64(math.Round(iterations * dryRun.RequestsPerIteration))
+	projection.DataReceivedBytes = int64(iterations * float64(dryRun.DataReceivedBytes))
+	projection.DataSentBytes = int64(iterations * float64(dryRun.DataSentBytes))
+	projection.DataReceived = runner.FormatBytes(projection.DataReceivedBytes)
+	projection.DataSent = runner.FormatBytes(projection.DataSentBytes)
+	projection.PeakRequestRate = math.Round(peakRequestRate(dryRun, options)*10) / 10
+	if projection.DurationSeconds > 0 {
+		projection.RequestRate = math.Round(float64(projection.Requests)/projection.DurationSeconds*10) / 10
+	}
+
+	totalGB := float64(projection.DataReceivedBytes+projection.DataSentBytes) / bytesPerGB
+	projection.EstimatedCost = math.Round(totalGB*costPerGB*10000) / 10000
+
+	return projection, assumptions
+}
+
+// parseDurationOrDefault parses a run duration, falling back to the default one.
+func parseDurationOrDefault(value string) time.Duration {
+	if duration, err := time.ParseDuration(value); err == nil {
+		return duration
+	}
+
+	duration, _ := time.ParseDuration(runner.DefaultDuration)
+	return duration
+}
@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log/slog"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/oleiade/k6-mcp/internal/apiref"
+	"github.com/oleiade/k6-mcp/internal/scriptsource"
+)
+
+// maxExplainExcerptBytes bounds the documentation excerpt returned per API usage.
+const maxExplainExcerptBytes = 1200
+
+// ExplainedUsage is an API usage of a script, with its documentation.
+type ExplainedUsage struct {
+	apiref.Usage
+	Kind      string `json:"kind,omitempty"`
+	Signature string `json:"signature,omitempty"`
+	// DocPath is the path of the documentation page of the symbol, read in full from the
+	// docs://k6/pages/{path} resource, and Documentation an excerpt of the page.
+	DocPath       string `json:"doc_path,omitempty"`
+	Documentation string `json:"documentation,omitempty"`
+}
+
+// ExplainScriptResult is the result of the explain_script tool.
+type ExplainScriptResult struct {
+	Imports []apiref.Import  `json:"imports"`
+	Usages  []ExplainedUsage `json:"usages"`
+	// Undocumented lists the usages no symbol of the k6 API resolves, such as the members
+	// of extension modules, as module.name.
+	Undocumented []string `json:"undocumented,omitempty"`
+}
+
+// ExplainScriptHandler lists the imports and k6 API calls of scripts, with their documentation.
+type ExplainScriptHandler struct {
+	fetcher *scriptsource.Fetcher
+	db      *sql.DB
+	symbols *apiref.Store
+}
+
+var _ ToolHandler = &ExplainScriptHandler{}
+
+func NewExplainScriptHandler(fetcher *scriptsource.Fetcher, db *sql.DB) *ExplainScriptHandler {
+	return &ExplainScriptHandler{fetcher: fetcher, db: db, symbols: apiref.NewStore(db)}
+}
+
+func (h *ExplainScriptHandler) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	script, errMsg := resolveScript(ctx, request.GetArguments(), h.fetcher)
+	if errMsg != "" {
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	imports, usages := apiref.ScriptUsage(script)
+	result := ExplainScriptResult{Imports: imports, Usages: make([]ExplainedUsage, 0, len(usages))}
+	if result.Imports == nil {
+		result.Imports = []apiref.Import{}
+	}
+
+	for _, usage := range usages {
+		explained := ExplainedUsage{Usage: usage}
+
+		symbol, err := h.symbols.ResolveUsage(ctx, usage)
+		if errors.Is(err, apiref.ErrUnavailable) {
+			return mcp.NewToolResultError("The API symbol index is not available in this build. Use the search_k6_documentation tool instead."), nil
+		}
+		if err != nil {
+			return mcp.NewToolResultError("Failed to look up the script's API usages; reason: " + err.Error()), nil
+		}
+
+		if symbol == nil {
+			result.Undocumented = append(result.Undocumented, usage.Module+"."+usage.Name)
+		} else {
+			explained.Kind = symbol.Kind
+			explained.Signature = symbol.Signature
+			explained.DocPath = symbol.DocPath
+			explained.Documentation = h.excerpt(ctx, symbol)
+		}
+
+		result.Usages = append(result.Usages, explained)
+	}
+
+	slog.InfoContext(ctx, "script explained",
+		slog.Int("imports", len(result.Imports)),
+		slog.Int("usages", len(result.Usages)),
+		slog.Int("undocumented", len(result.Undocumented)),
+	)
+
+	resultJSON, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("failed to serialize script explanation"), err
+	}
+
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// excerpt returns the first documentation chunk of the symbol's page, or its doc comment
+// when the symbol has no documentation page.
+func (h *ExplainScriptHandler) excerpt(ctx context.Context, symbol *apiref.Symbol) string {
+	content := symbol.Doc
+	if symbol.DocPath != "" {
+		var chunk string
+		err := h.db.QueryRowContext(ctx, `SELECT content FROM documentation WHERE path = ? ORDER BY rowid LIMIT 1`, symbol.DocPath).Scan(&chunk)
+		if err == nil && chunk != "" {
+			content = chunk
+		}
+	}
+
+	return truncateExcerpt(content)
+}
+
+// truncateExcerpt cuts documentation longer than maxExplainExcerptBytes, at a rune boundary.
+func truncateExcerpt(content string) string {
+	if len(content) <= maxExplainExcerptBytes {
+		return content
+	}
+	cut := maxExplainExcerptBytes
+	for cut > 0 && content[cut]&0xC0 == 0x80 {
+		cut--
+	}
+	return content[:cut] + "…"
+}
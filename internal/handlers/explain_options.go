@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/oleiade/k6-mcp/internal/apiref"
+	"github.com/oleiade/k6-mcp/internal/scriptsource"
+)
+
+// ExplainedOption is an option set by a script, with its default and documentation.
+type ExplainedOption struct {
+	apiref.ScriptOption
+	// Known is false for options k6 does not define, which it ignores.
+	Known   bool   `json:"known"`
+	Default string `json:"default,omitempty"`
+	// DeviatesFromDefault is set when the script sets the option to a value other than its
+	// default, or sets an option without default, such as scenarios.
+	DeviatesFromDefault bool   `json:"deviates_from_default"`
+	Summary             string `json:"summary,omitempty"`
+	// Caution explains the risk of the deviation from the default.
+	Caution    string `json:"caution,omitempty"`
+	DidYouMean string `json:"did_you_mean,omitempty"`
+	Signature  string `json:"signature,omitempty"`
+	// DocPath is the path of the documentation page of the option, with the anchor of its
+	// section, and Documentation an excerpt of the section.
+	DocPath       string `json:"doc_path,omitempty"`
+	Documentation string `json:"documentation,omitempty"`
+}
+
+// ExplainOptionsResult is the result of the explain_options tool.
+type ExplainOptionsResult struct {
+	Options []ExplainedOption `json:"options"`
+	// Deviations lists the options deviating from their defaults, and Unknown the options
+	// k6 does not define.
+	Deviations []string `json:"deviations"`
+	Unknown    []string `json:"unknown,omitempty"`
+}
+
+// ExplainOptionsHandler explains the options of scripts, with their documentation.
+type ExplainOptionsHandler struct {
+	fetcher *scriptsource.Fetcher
+	symbols *apiref.Store
+}
+
+var _ ToolHandler = &ExplainOptionsHandler{}
+
+func NewExplainOptionsHandler(fetcher *scriptsource.Fetcher, db *sql.DB) *ExplainOptionsHandler {
+	return &ExplainOptionsHandler{fetcher: fetcher, symbols: apiref.NewStore(db)}
+}
+
+func (h *ExplainOptionsHandler) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	script, errMsg := resolveScript(ctx, request.GetArguments(), h.fetcher)
+	if errMsg != "" {
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	options, found := apiref.ScriptOptions(script)
+	if !found {
+		return mcp.NewToolResultError("The script has no options object, e.g. export const options = { vus: 10, duration: '30s' }: k6 runs it with the default options, a single VU running a single iteration."), nil
+	}
+
+	result := ExplainOptionsResult{Options: make([]ExplainedOption, 0, len(options)), Deviations: []string{}}
+	for _, option := range options {
+		explained := ExplainedOption{ScriptOption: option}
+
+		// Spreads, e.g. ...base, set options the script computes at runtime
+		if strings.HasPrefix(option.Name, "...") {
+			result.Options = append(result.Options, explained)
+			continue
+		}
+
+		known, ok := apiref.KnownOptions[option.Name]
+		if !ok {
+			explained.DidYouMean = apiref.ClosestOption(option.Name)
+			result.Unknown = append(result.Unknown, option.Name)
+			result.Options = append(result.Options, explained)
+			continue
+		}
+
+		explained.Known = true
+		explained.Default = known.Default
+		explained.Summary = known.Summary
+		explained.DeviatesFromDefault = !known.IsDefault(option.Value)
+		if explained.DeviatesFromDefault {
+			explained.Caution = known.Caution
+			result.Deviations = append(result.Deviations, option.Name)
+		}
+
+		symbols, err := h.symbols.Lookup(ctx, "Options."+option.Name)
+		if errors.Is(err, apiref.ErrUnavailable) {
+			return mcp.NewToolResultError("The API symbol index is not available in this build. Use the search_k6_documentation tool instead."), nil
+		}
+		if err != nil {
+			return mcp.NewToolResultError("Failed to look up the script's options; reason: " + err.Error()), nil
+		}
+		if len(symbols) > 0 {
+			explained.Signature = symbols[0].Signature
+		}
+
+		section, anchor, err := h.symbols.OptionDoc(ctx, known.Heading)
+		if err != nil {
+			return mcp.NewToolResultError("Failed to read the documentation of the options; reason: " + err.Error()), nil
+		}
+		if section != "" {
+			explained.DocPath = apiref.OptionsReferencePath + "#" + anchor
+			explained.Documentation = truncateExcerpt(section)
+		} else if len(symbols) > 0 {
+			explained.Documentation = truncateExcerpt(symbols[0].Doc)
+		}
+
+		result.Options = append(result.Options, explained)
+	}
+
+	slog.InfoContext(ctx, "options explained",
+		slog.Int("options", len(result.Options)),
+		slog.Int("deviations", len(result.Deviations)),
+		slog.Int("unknown", len(result.Unknown)),
+	)
+
+	resultJSON, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("failed to serialize options explanation"), err
+	}
+
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
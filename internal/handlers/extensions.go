@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/oleiade/k6-mcp/internal/extensions"
+)
+
+const (
+	// DefaultExtensionResults is the default number of extensions find_extension returns.
+	DefaultExtensionResults = 5
+	// MaxExtensionResults is the maximum number of extensions find_extension returns.
+	MaxExtensionResults = 20
+)
+
+// FindExtensionResult is the result of the find_extension tool.
+type FindExtensionResult struct {
+	Query      string             `json:"query"`
+	Extensions []extensions.Match `json:"extensions"`
+	Notes      []string           `json:"notes,omitempty"`
+}
+
+// FindExtensionHandler finds the k6 extensions of the registry adding a protocol or a
+// feature, such as Kafka, MQTT or SQL.
+type FindExtensionHandler struct {
+	extensions *extensions.Store
+}
+
+var _ ToolHandler = &FindExtensionHandler{}
+
+func NewFindExtensionHandler(db *sql.DB) *FindExtensionHandler {
+	return &FindExtensionHandler{extensions: extensions.NewStore(db)}
+}
+
+func (h *FindExtensionHandler) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	query, err := request.RequireString("query")
+	if err != nil || strings.TrimSpace(query) == "" {
+		return mcp.NewToolResultError("Missing required parameter 'query'. Examples: 'kafka', 'mqtt', 'sql postgres', 'can k6 test grpc streaming?'"), nil
+	}
+
+	opts := extensions.Options{
+		Tier:            request.GetString("tier", ""),
+		IncludeArchived: request.GetBool("include_archived", false),
+		MaxResults:      request.GetInt("max_results", DefaultExtensionResults),
+	}
+	switch opts.Tier {
+	case "", extensions.TierOfficial, extensions.TierPartner, extensions.TierCommunity:
+	default:
+		return mcp.NewToolResultError(fmt.Sprintf("tier must be one of %s, %s or %s.", extensions.TierOfficial, extensions.TierPartner, extensions.TierCommunity)), nil
+	}
+	if opts.MaxResults < 1 || opts.MaxResults > MaxExtensionResults {
+		return mcp.NewToolResultError(fmt.Sprintf("max_results must be between 1 and %d.", MaxExtensionResults)), nil
+	}
+
+	matches, err := h.extensions.Find(ctx, query, opts)
+	if errors.Is(err, extensions.ErrUnavailable) {
+		return mcp.NewToolResultError("The extension index is not available in this build. Browse the registry at https://grafana.com/docs/k6/latest/extensions/explore/ instead."), nil
+	}
+	if err != nil {
+		return mcp.NewToolResultError("Failed to search the extensions; reason: " + err.Error()), nil
+	}
+
+	slog.InfoContext(ctx, "extensions searched",
+		slog.String("query", query),
+		slog.Int("extensions", len(matches)),
+	)
+
+	result := FindExtensionResult{Query: query, Extensions: matches}
+	if len(matches) == 0 {
+		result.Notes = append(result.Notes, "No extension matches: check whether k6 supports it natively with search_k6_documentation, e.g. k6/net/grpc, k6/experimental/websockets or k6/browser")
+	}
+	for _, match := range matches {
+		if match.Status != extensions.StatusMaintained {
+			result.Notes = append(result.Notes, "Extensions with a stale or archived status may not build with recent k6 versions: prefer maintained ones")
+			break
+		}
+	}
+	if len(matches) > 0 {
+		result.Notes = append(result.Notes, "Scripts importing k6/x/ modules only run with a k6 binary built with their extension, so validate_k6_script and run_k6_script fail on them with the standard k6 binary")
+	}
+
+	resultJSON, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("failed to serialize extensions"), err
+	}
+
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/oleiade/k6-mcp/internal/federation"
+	"github.com/oleiade/k6-mcp/internal/logging"
+)
+
+// upstreamMetaKey is the key of the _meta field of the results of proxied tool calls,
+// naming the upstream server that answered them.
+const upstreamMetaKey = "k6-mcp/upstream"
+
+// upstreamHandler serves a documentation tool from the upstream server while the local
+// index can't answer it.
+type upstreamHandler struct {
+	name     string
+	local    ToolHandler
+	upstream *federation.Client
+	bypass   func(ctx context.Context) string
+}
+
+// WithUpstream serves the named tool from the upstream server when bypass returns why the
+// local index can't answer it, such as being unavailable or stale, and from local
+// otherwise, or when the upstream server fails.
+func WithUpstream(name string, local ToolHandler, upstream *federation.Client, bypass func(ctx context.Context) string) ToolHandler {
+	if !upstream.Configured() || bypass == nil {
+		return local
+	}
+	return upstreamHandler{name: name, local: local, upstream: upstream, bypass: bypass}
+}
+
+func (h upstreamHandler) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	reason := h.bypass(ctx)
+	if reason == "" {
+		return h.local.Handle(ctx, request)
+	}
+
+	logger := logging.Default()
+	result, err := h.upstream.CallTool(ctx, h.name, request.GetArguments())
+	if err != nil {
+		logger.WarnContext(ctx, "Upstream server failed, answering from the local index",
+			slog.String("reason", reason),
+			slog.String("error", err.Error()),
+		)
+		return h.local.Handle(ctx, request)
+	}
+
+	logger.InfoContext(ctx, "Answered from the upstream server",
+		slog.String("upstream", h.upstream.URL()),
+		slog.String("reason", reason),
+	)
+	if result.Meta == nil {
+		result.Meta = make(map[string]any)
+	}
+	result.Meta[upstreamMetaKey] = map[string]string{"url": h.upstream.URL(), "reason": reason}
+	return result, nil
+}
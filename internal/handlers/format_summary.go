@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/oleiade/k6-mcp/internal/artifacts"
+	"github.com/oleiade/k6-mcp/internal/report"
+	"github.com/oleiade/k6-mcp/internal/runner"
+)
+
+// summaryRun is the part of a run tool result chat summaries render.
+type summaryRun struct {
+	runner.RunResult
+	RunID     int                  `json:"run_id"`
+	Artifacts []artifacts.Artifact `json:"artifacts"`
+}
+
+// FormatSummaryHandler renders run results into Slack or Microsoft Teams messages.
+type FormatSummaryHandler struct{}
+
+var _ ToolHandler = &FormatSummaryHandler{}
+
+func NewFormatSummaryHandler() *FormatSummaryHandler {
+	return &FormatSummaryHandler{}
+}
+
+func (h *FormatSummaryHandler) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+
+	format, err := report.ParseChatFormat(request.GetString("format", ""))
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	runValue, exists := args["run"]
+	if !exists {
+		return mcp.NewToolResultError("Missing required parameter 'run'. Pass the JSON result of the run tool."), nil
+	}
+	var run summaryRun
+	if err := decodeArg(runValue, &run); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid run format: %s. Pass the JSON result of the run tool.", err.Error())), nil
+	}
+
+	summary := &report.ChatSummary{
+		Title:        request.GetString("title", ""),
+		Result:       run.RunResult,
+		RunID:        run.RunID,
+		Artifacts:    run.Artifacts,
+		ArtifactsURL: request.GetString("artifacts_url", ""),
+		Link:         request.GetString("link", ""),
+	}
+	for name, value := range map[string]string{"artifacts_url": summary.ArtifactsURL, "link": summary.Link} {
+		if value == "" {
+			continue
+		}
+		if u, err := url.Parse(value); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return mcp.NewToolResultError(fmt.Sprintf("Parameter '%s' must be an http or https URL. Received: %q", name, value)), nil
+		}
+	}
+
+	message, err := report.Chat(summary, format)
+	if err != nil {
+		return mcp.NewToolResultError("Failed to format summary; reason: " + err.Error()), nil
+	}
+
+	slog.InfoContext(ctx, "summary formatted",
+		slog.String("format", string(format)),
+		slog.Bool("success", run.Success),
+		slog.Int("thresholds", len(run.Thresholds)),
+		slog.Int("artifacts", len(run.Artifacts)),
+	)
+
+	return mcp.NewToolResultText(message), nil
+}
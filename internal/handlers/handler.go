@@ -14,3 +14,8 @@ type ToolHandler interface {
 type PromptHandler interface {
 	Handle(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error)
 }
+
+// ResourceHandler defines an interface for MCP resource read handlers.
+type ResourceHandler interface {
+	Handle(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error)
+}
@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/oleiade/k6-mcp/internal/history"
+)
+
+// ScriptRevisionRef identifies the revision of a named script a tool recorded.
+type ScriptRevisionRef struct {
+	Name        string           `json:"name"`
+	Revision    int              `json:"revision"`
+	NewRevision bool             `json:"new_revision"`
+	Changes     history.DiffStat `json:"changes"`
+}
+
+// recordScript records the script as a revision of the script named by the 'script_name'
+// argument, if any. It returns a user-facing error message when the name is invalid, or
+// the revision could not be recorded.
+func recordScript(args map[string]interface{}, store *history.Store, script, source string) (*ScriptRevisionRef, string) {
+	nameValue, exists := args["script_name"]
+	if !exists {
+		return nil, ""
+	}
+
+	name, ok := nameValue.(string)
+	if !ok || name == "" {
+		return nil, "Parameter 'script_name' must be a non-empty string. Example: 'checkout-flow'"
+	}
+
+	rev, created, err := store.Record(name, script, source)
+	if err != nil {
+		return nil, "Failed to record script revision; reason: " + err.Error()
+	}
+
+	return &ScriptRevisionRef{
+		Name:        name,
+		Revision:    rev.Number,
+		NewRevision: created,
+		Changes:     rev.Changes,
+	}, ""
+}
+
+// DuplicateWarning warns that a script is a near-duplicate of recorded scripts.
+type DuplicateWarning struct {
+	Message string               `json:"message"`
+	Scripts []history.Similarity `json:"similar_scripts"`
+}
+
+// findDuplicates returns a warning when the script is a near-duplicate of other named
+// scripts, unless it is a later revision of a named script: agents should then update the
+// existing test rather than add copies of it.
+func findDuplicates(ctx context.Context, store *history.Store, script string, revision *ScriptRevisionRef) *DuplicateWarning {
+	exclude := ""
+	if revision != nil {
+		if revision.Revision > 1 || !revision.NewRevision {
+			return nil
+		}
+		exclude = revision.Name
+	}
+
+	similar, err := store.Similar(script, exclude, history.DuplicateSimilarity)
+	if err != nil {
+		slog.WarnContext(ctx, "failed to compare the script to the recorded ones", slog.String("error", err.Error()))
+		return nil
+	}
+	if len(similar) == 0 {
+		return nil
+	}
+
+	closest := similar[0].Name
+	message := fmt.Sprintf("This script is a near-duplicate of the named script %q: pass script_name %q to record it as a revision of that script, instead of keeping copies of the same test.", closest, closest)
+	if revision != nil {
+		message = fmt.Sprintf("The new script %q is a near-duplicate of the named script %q: consider updating %q instead of keeping copies of the same test.", revision.Name, closest, closest)
+	}
+
+	return &DuplicateWarning{Message: message, Scripts: similar}
+}
+
+// GetScriptHistoryHandler lists the revisions of named scripts, or returns one of them. It
+// also returns the recorded runs of suites.
+type GetScriptHistoryHandler struct {
+	store *history.Store
+}
+
+var _ ToolHandler = &GetScriptHistoryHandler{}
+
+func NewGetScriptHistoryHandler(store *history.Store) *GetScriptHistoryHandler {
+	return &GetScriptHistoryHandler{store: store}
+}
+
+func (h *GetScriptHistoryHandler) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	name := request.GetString("script_name", "")
+
+	var result interface{}
+	switch {
+	case request.GetArguments()["suite_name"] != nil:
+		suite := request.GetString("suite_name", "")
+		runs, err := h.store.SuiteRuns(suite)
+		if err != nil {
+			return mcp.NewToolResultError("Failed to get suite runs; reason: " + err.Error()), nil
+		}
+		result = map[string]interface{}{"suite_name": suite, "runs": runs}
+	case name == "":
+		names, err := h.store.List()
+		if err != nil {
+			return mcp.NewToolResultError("Failed to list scripts; reason: " + err.Error()), nil
+		}
+		result = map[string]interface{}{"scripts": names}
+	case request.GetArguments()["revision"] != nil:
+		rev, err := h.store.Get(name, request.GetInt("revision", 0))
+		if err != nil {
+			return mcp.NewToolResultError("Failed to get script revision; reason: " + err.Error()), nil
+		}
+		result = rev
+	default:
+		script, err := h.store.History(name)
+		if err != nil {
+			return mcp.NewToolResultError("Failed to get script history; reason: " + err.Error() + ". Record revisions by passing 'script_name' to the validation and run tools."), nil
+		}
+		result = script
+	}
+
+	resultJSON, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("failed to serialize script history"), err
+	}
+
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// DiffScriptVersionsResult is the result of the diff_script_versions tool.
+type DiffScriptVersionsResult struct {
+	Name  string           `json:"script_name"`
+	From  int              `json:"from"`
+	To    int              `json:"to"`
+	Stat  history.DiffStat `json:"changes"`
+	Diff  string           `json:"diff"`
+	Equal bool             `json:"equal"`
+}
+
+// DiffScriptVersionsHandler compares two revisions of a named script.
+type DiffScriptVersionsHandler struct {
+	store *history.Store
+}
+
+var _ ToolHandler = &DiffScriptVersionsHandler{}
+
+func NewDiffScriptVersionsHandler(store *history.Store) *DiffScriptVersionsHandler {
+	return &DiffScriptVersionsHandler{store: store}
+}
+
+func (h *DiffScriptVersionsHandler) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	name, err := request.RequireString("script_name")
+	if err != nil {
+		return mcp.NewToolResultError("Missing required parameter 'script_name'. Example: 'checkout-flow'"), nil
+	}
+
+	// By default, the latest revision is compared to the previous one
+	to, err := h.store.Get(name, request.GetInt("to", 0))
+	if err != nil {
+		return mcp.NewToolResultError("Failed to get script revision; reason: " + err.Error()), nil
+	}
+	if _, hasFrom := request.GetArguments()["from"]; !hasFrom && to.Number == 1 {
+		return mcp.NewToolResultError(fmt.Sprintf("Revision 1 of script %q is its first; there is no previous revision to compare it to.", name)), nil
+	}
+	from, err := h.store.Get(name, request.GetInt("from", to.Number-1))
+	if err != nil {
+		return mcp.NewToolResultError("Failed to get script revision; reason: " + err.Error()), nil
+	}
+
+	diff, stat := history.Unified(
+		fmt.Sprintf("%s@%d", name, from.Number),
+		fmt.Sprintf("%s@%d", name, to.Number),
+		from.Content, to.Content,
+	)
+
+	resultJSON, err := json.MarshalIndent(DiffScriptVersionsResult{
+		Name:  name,
+		From:  from.Number,
+		To:    to.Number,
+		Stat:  stat,
+		Diff:  diff,
+		Equal: diff == "",
+	}, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("failed to serialize script diff"), err
+	}
+
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/oleiade/k6-mcp/internal/history"
+	"github.com/oleiade/k6-mcp/internal/runner"
+)
+
+// maxImportedSummaryBytes is the maximum size of the summaries import_results accepts.
+const maxImportedSummaryBytes = 5 * 1024 * 1024
+
+// environmentLabelPattern matches the labels of the environments imported runs ran in.
+var environmentLabelPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9 _.:/-]{0,63}$`)
+
+// sha256Pattern matches hex-encoded SHA-256 digests.
+var sha256Pattern = regexp.MustCompile(`^[0-9a-fA-F]{64}$`)
+
+// ImportResultsResult is the result of the import_results tool.
+type ImportResultsResult struct {
+	// RunID identifies the record of the imported run in the run history.
+	RunID  int               `json:"run_id"`
+	Format string            `json:"format"`
+	Run    history.RunRecord `json:"run"`
+	// Summary is in the format of run summaries, ready to pass to the baseline tools.
+	Summary    runner.TestSummary        `json:"summary"`
+	Thresholds []runner.ThresholdOutcome `json:"thresholds,omitempty"`
+	Checks     []runner.CheckOutcome     `json:"checks,omitempty"`
+	// Warnings are the warning thresholds of the server the summary exceeded.
+	Warnings []runner.SummaryWarning `json:"warnings"`
+	// Script identifies the recorded revision of the script, when it is named.
+	Script *ScriptRevisionRef `json:"script,omitempty"`
+}
+
+// ImportResultsHandler records the results of k6 runs executed outside the server, such
+// as CI or manual runs, in the run history.
+type ImportResultsHandler struct {
+	scripts *history.Store
+}
+
+var _ ToolHandler = &ImportResultsHandler{}
+
+func NewImportResultsHandler(scripts *history.Store) *ImportResultsHandler {
+	return &ImportResultsHandler{scripts: scripts}
+}
+
+func (h *ImportResultsHandler) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+
+	data, errMsg := summaryData(args["summary"])
+	if errMsg != "" {
+		return mcp.NewToolResultError(errMsg), nil
+	}
+	imported, err := runner.ParseSummary(data)
+	if err != nil {
+		return mcp.NewToolResultError("Invalid 'summary': " + err.Error()), nil
+	}
+
+	record := resultsRecord(imported.Summary, imported.Thresholds)
+	record.Source = "import_results"
+	record.Environment = request.GetString("environment", "")
+	if record.Environment != "" && !environmentLabelPattern.MatchString(record.Environment) {
+		return mcp.NewToolResultError("Parameter 'environment' must be up to 64 letters, digits, spaces and '_.:/-'. Example: 'github-actions'"), nil
+	}
+
+	record.StartedAt = time.Now().UTC()
+	if startedAt := request.GetString("started_at", ""); startedAt != "" {
+		t, err := time.Parse(time.RFC3339, startedAt)
+		if err != nil {
+			return mcp.NewToolResultError("Parameter 'started_at' must be an RFC 3339 time. Example: '2025-06-01T12:00:00Z'"), nil
+		}
+		record.StartedAt = t.UTC()
+	}
+	if imported.Duration > 0 {
+		record.Duration = imported.Duration.String()
+	}
+
+	// Without an exit code, the run passed when none of its thresholds was crossed
+	record.Success = imported.ThresholdsPassed()
+	if !record.Success {
+		record.ExitCode = k6ThresholdsExitCode
+	}
+	if _, exists := args["exit_code"]; exists {
+		record.ExitCode = request.GetInt("exit_code", 0)
+		record.Success = record.ExitCode == 0
+	}
+
+	// The script identifies the run: its content, or its digest when the content is not at hand
+	result := ImportResultsResult{Format: imported.Format, Summary: imported.Summary, Thresholds: imported.Thresholds, Checks: imported.Checks}
+	result.Warnings = runner.CurrentWarningThresholds().Evaluate(imported.Summary, imported.Checks)
+	if script, ok := args["script"].(string); ok && script != "" {
+		record.ScriptHash = scriptHash(script)
+		record.Targets = runTargets(script, nil)
+		if result.Script, errMsg = recordScript(args, h.scripts, script, "import_results"); errMsg != "" {
+			return mcp.NewToolResultError(errMsg), nil
+		}
+	} else {
+		record.ScriptHash = strings.ToLower(request.GetString("script_sha256", ""))
+		if record.ScriptHash != "" && !sha256Pattern.MatchString(record.ScriptHash) {
+			return mcp.NewToolResultError("Parameter 'script_sha256' must be a hex-encoded SHA-256 digest of 64 characters"), nil
+		}
+		if name := request.GetString("script_name", ""); name != "" {
+			record.Script = name
+			// The run is attributed to the latest revision, when the script has a history
+			if rev, err := h.scripts.Get(name, 0); err == nil && (record.ScriptHash == "" || rev.Hash == record.ScriptHash) {
+				record.Revision = rev.Number
+				record.ScriptHash = rev.Hash
+			} else if err != nil && !errors.Is(err, history.ErrScriptNotFound) {
+				return mcp.NewToolResultError("Failed to read the script history; reason: " + err.Error()), nil
+			}
+		}
+	}
+	if result.Script != nil {
+		record.Script = result.Script.Name
+		record.Revision = result.Script.Revision
+	}
+
+	if targetHost := request.GetString("target_host", ""); targetHost != "" {
+		host := targetHost
+		if u, err := url.Parse(targetHost); err == nil && u.Hostname() != "" {
+			host = u.Hostname()
+		}
+		record.Targets = appendTarget(record.Targets, strings.ToLower(host))
+	}
+
+	if err := h.scripts.RecordRun(&record); err != nil {
+		return mcp.NewToolResultError("Failed to record the run in history; reason: " + err.Error()), nil
+	}
+	result.RunID = record.ID
+	result.Run = record
+
+	slog.InfoContext(ctx, "run results imported",
+		slog.Int("run_id", record.ID),
+		slog.String("format", imported.Format),
+		slog.String("environment", record.Environment),
+		slog.Bool("success", record.Success),
+	)
+
+	resultJSON, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("failed to serialize imported results"), err
+	}
+
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// summaryData returns the JSON of the 'summary' argument: a JSON object, or a string
+// holding one, as read from a file. It returns a user-facing error message when the
+// summary is missing or too large.
+func summaryData(value interface{}) ([]byte, string) {
+	var data []byte
+	switch summary := value.(type) {
+	case nil:
+		return nil, "Missing required parameter 'summary'. Pass the content of a k6 --summary-export file, or the JSON of the data passed to handleSummary()."
+	case string:
+		data = []byte(summary)
+	default:
+		encoded, err := json.Marshal(summary)
+		if err != nil {
+			return nil, "Invalid 'summary': " + err.Error()
+		}
+		data = encoded
+	}
+
+	if len(data) > maxImportedSummaryBytes {
+		return nil, fmt.Sprintf("The summary is too large (%d bytes, maximum %d bytes)", len(data), maxImportedSummaryBytes)
+	}
+
+	return data, ""
+}
+
+// appendTarget appends host to the sorted targets, unless they hold it.
+func appendTarget(targets []string, host string) []string {
+	for i, target := range targets {
+		if target == host {
+			return targets
+		}
+		if target > host {
+			return append(targets[:i], append([]string{host}, targets[i:]...)...)
+		}
+	}
+	return append(targets, host)
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/oleiade/k6-mcp/internal/infra"
+)
+
+// InfrastructureHandler generates infrastructure-as-code definitions for Grafana Cloud k6
+// load tests, in the format selected by the tool's format argument.
+type InfrastructureHandler struct{}
+
+var _ ToolHandler = &InfrastructureHandler{}
+
+func NewInfrastructureHandler() *InfrastructureHandler {
+	return &InfrastructureHandler{}
+}
+
+func (h *InfrastructureHandler) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	format, err := infra.ParseFormat(request.GetString("format", ""))
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	spec, err := parseInfrastructureArgs(request.GetArguments())
+	if err != nil {
+		return mcp.NewToolResultError("Failed to parse template arguments; reason: " + err.Error()), nil
+	}
+
+	generated, err := infra.Generate(spec, format)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to generate %s code; reason: %s", format, err.Error())), nil
+	}
+
+	return mcp.NewToolResultText(generated), nil
+}
+
+// parseInfrastructureArgs parses the tool arguments into an infra.Spec.
+func parseInfrastructureArgs(args map[string]interface{}) (*infra.Spec, error) {
+	spec := &infra.Spec{}
+
+	// Helper function to reduce repetition
+	getStringArg := func(key string) (string, error) {
+		val, exists := args[key]
+		if !exists {
+			return "", fmt.Errorf("missing required parameter '%s'", key)
+		}
+		strVal, ok := val.(string)
+		if !ok || strVal == "" {
+			return "", fmt.Errorf("parameter '%s' must be a non-empty string", key)
+		}
+		return strVal, nil
+	}
+
+	var err error
+	if spec.LoadTestName, err = getStringArg("load_test_name"); err != nil {
+		return nil, err
+	}
+	if spec.LoadTestResourceName, err = getStringArg("load_test_resource_name"); err != nil {
+		return nil, err
+	}
+	if spec.Script, err = getStringArg("script"); err != nil {
+		return nil, err
+	}
+
+	// The project ID is only required when no environments define their own project,
+	// which infra.Spec.Resolve checks.
+	if projectIDValue, exists := args["project_id"]; exists {
+		projectID, ok := projectIDValue.(string)
+		if !ok {
+			return nil, fmt.Errorf("parameter 'project_id' must be a string")
+		}
+		spec.ProjectID = projectID
+	}
+
+	if notesValue, exists := args["notes"]; exists {
+		notes, ok := notesValue.(string)
+		if !ok {
+			return nil, fmt.Errorf("parameter 'notes' must be a string")
+		}
+		spec.Notes = notes
+	}
+
+	if scheduleValue, exists := args["schedule"]; exists {
+		spec.Schedule = &infra.Schedule{}
+		if err := decodeArg(scheduleValue, spec.Schedule); err != nil {
+			return nil, fmt.Errorf("invalid schedule format: %w. Example: {\"starts\": \"2025-01-01T08:00:00Z\", \"frequency\": \"DAILY\"}", err)
+		}
+	}
+
+	if environmentsValue, exists := args["environments"]; exists {
+		if err := decodeArg(environmentsValue, &spec.Environments); err != nil {
+			return nil, fmt.Errorf("invalid environments format: %w. Example: [{\"name\": \"staging\", \"project_id\": \"123\", \"env\": {\"BASE_URL\": \"https://staging.example.com\"}}]", err)
+		}
+	}
+
+	if perWorkspaceValue, exists := args["per_workspace"]; exists {
+		perWorkspace, ok := perWorkspaceValue.(bool)
+		if !ok {
+			return nil, fmt.Errorf("parameter 'per_workspace' must be a boolean")
+		}
+		spec.PerWorkspace = perWorkspace
+	}
+
+	return spec, nil
+}
+
+// decodeArg converts a loosely typed tool argument into the given target structure.
+func decodeArg(value interface{}, target interface{}) error {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(raw, target)
+}
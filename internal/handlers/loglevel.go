@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/oleiade/k6-mcp/internal/logging"
+)
+
+// SetLogLevelResult is the result of the set_log_level tool.
+type SetLogLevelResult struct {
+	Previous string `json:"previous"`
+	Current  string `json:"current"`
+}
+
+// SetLogLevelHandler changes the level of the server logs at runtime.
+type SetLogLevelHandler struct{}
+
+var _ ToolHandler = &SetLogLevelHandler{}
+
+func NewSetLogLevelHandler() *SetLogLevelHandler {
+	return &SetLogLevelHandler{}
+}
+
+func (h *SetLogLevelHandler) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	previous := logging.CurrentLevel()
+
+	if value := request.GetString("level", ""); value != "" {
+		level, err := logging.ParseLevel(value)
+		if err != nil {
+			return mcp.NewToolResultError("Invalid 'level': " + err.Error()), nil
+		}
+		logging.SetLevel(level)
+
+		// Logged at the highest of both levels, so that the change shows in the logs
+		logging.Default().Log(ctx, max(previous, level), "log level changed",
+			slog.String("previous", levelName(previous)),
+			slog.String("current", levelName(level)),
+		)
+	}
+
+	result := SetLogLevelResult{Previous: levelName(previous), Current: levelName(logging.CurrentLevel())}
+
+	resultJSON, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("failed to serialize log level"), err
+	}
+
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// levelName returns the name of a log level, as accepted by set_log_level and LOG_LEVEL.
+func levelName(level slog.Level) string {
+	return strings.ToLower(level.String())
+}
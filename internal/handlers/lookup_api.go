@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/oleiade/k6-mcp/internal/apiref"
+)
+
+// maxCandidates bounds the number of symbols suggested when a lookup finds nothing.
+const maxCandidates = 10
+
+// LookupAPIResult is the result of the lookup_api tool.
+type LookupAPIResult struct {
+	Name    string          `json:"name"`
+	Symbols []apiref.Symbol `json:"symbols"`
+}
+
+// LookupAPIHandler resolves k6 JavaScript API symbols by their exact names.
+type LookupAPIHandler struct {
+	symbols *apiref.Store
+}
+
+var _ ToolHandler = &LookupAPIHandler{}
+
+func NewLookupAPIHandler(db *sql.DB) *LookupAPIHandler {
+	return &LookupAPIHandler{symbols: apiref.NewStore(db)}
+}
+
+func (h *LookupAPIHandler) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	name, err := request.RequireString("name")
+	if err != nil || strings.TrimSpace(name) == "" {
+		return mcp.NewToolResultError("Missing required parameter 'name'. Examples: 'k6/http.batch', 'http.get', 'Options.thresholds', 'k6/metrics.Counter', or a module such as 'k6/crypto'."), nil
+	}
+
+	symbols, err := h.symbols.Lookup(ctx, name)
+	if errors.Is(err, apiref.ErrUnavailable) {
+		return mcp.NewToolResultError("The API symbol index is not available in this build. Use the search_k6_documentation tool, or read the types://k6/ type definitions resources."), nil
+	}
+	if err != nil {
+		return mcp.NewToolResultError("Failed to look up the symbol; reason: " + err.Error()), nil
+	}
+
+	if len(symbols) == 0 {
+		return mcp.NewToolResultError(h.notFound(ctx, name)), nil
+	}
+
+	slog.InfoContext(ctx, "api symbol looked up",
+		slog.String("name", name),
+		slog.Int("symbols", len(symbols)),
+	)
+
+	resultJSON, err := json.MarshalIndent(LookupAPIResult{Name: name, Symbols: symbols}, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("failed to serialize symbols"), err
+	}
+
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// notFound returns the message of lookups finding nothing, suggesting the symbols of the
+// same unqualified name.
+func (h *LookupAPIHandler) notFound(ctx context.Context, name string) string {
+	message := fmt.Sprintf("No k6 API symbol is named %q.", name)
+
+	candidates, err := h.symbols.Candidates(ctx, name)
+	if err != nil || len(candidates) == 0 {
+		return message + " Use search_k6_documentation for a fuzzy search of the documentation."
+	}
+
+	var names []string
+	for i, candidate := range candidates {
+		if i == maxCandidates {
+			break
+		}
+		names = append(names, candidate.Module+"."+candidate.Name)
+	}
+
+	return fmt.Sprintf("%s Did you mean: %s?", message, strings.Join(names, ", "))
+}
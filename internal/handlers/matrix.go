@@ -0,0 +1,312 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/oleiade/k6-mcp/internal/history"
+	"github.com/oleiade/k6-mcp/internal/notify"
+	"github.com/oleiade/k6-mcp/internal/runner"
+	"github.com/oleiade/k6-mcp/internal/scriptsource"
+)
+
+const (
+	// MaxMatrixRuns is the maximum number of parameter sets a matrix can run.
+	MaxMatrixRuns = 12
+	// PayloadSizeEnvVar is the environment variable exposing the payload size of a matrix
+	// parameter set to the script.
+	PayloadSizeEnvVar = "PAYLOAD_SIZE"
+)
+
+// MatrixEnvironment is a target environment of a matrix, exposed to the script as
+// environment variables.
+type MatrixEnvironment struct {
+	Name string            `json:"name"`
+	Env  map[string]string `json:"env"`
+}
+
+// MatrixRow is the outcome of one parameter set of a matrix.
+type MatrixRow struct {
+	VUs             int     `json:"vus"`
+	Environment     string  `json:"environment,omitempty"`
+	PayloadSize     int     `json:"payload_size,omitempty"`
+	Success         bool    `json:"success"`
+	ExitCode        int     `json:"exit_code"`
+	Grade           string  `json:"grade,omitempty"`
+	TotalRequests   int     `json:"total_requests"`
+	FailedRequests  int     `json:"failed_requests"`
+	ErrorRate       float64 `json:"error_rate_percent"`
+	AvgResponseTime float64 `json:"avg_response_time_ms"`
+	P95ResponseTime float64 `json:"p95_response_time_ms"`
+	P99ResponseTime float64 `json:"p99_response_time_ms"`
+	RequestRate     float64 `json:"request_rate_per_second"`
+	Duration        string  `json:"duration,omitempty"`
+	Error           string  `json:"error,omitempty"`
+}
+
+// RunMatrixResult is the result of the run_matrix tool.
+type RunMatrixResult struct {
+	Runs     int         `json:"runs"`
+	Passed   int         `json:"passed"`
+	Failed   int         `json:"failed"`
+	Duration string      `json:"duration"`
+	Rows     []MatrixRow `json:"rows"`
+	// Table is the comparison of the rows as a Markdown table.
+	Table  string             `json:"table"`
+	Script *ScriptRevisionRef `json:"script,omitempty"`
+}
+
+// matrixCell is one parameter set of a matrix.
+type matrixCell struct {
+	vus         int
+	environment *MatrixEnvironment
+	payloadSize int
+}
+
+// RunMatrixHandler runs the same script across a matrix of parameter sets.
+type RunMatrixHandler struct {
+	fetcher *scriptsource.Fetcher
+	scripts *history.Store
+}
+
+var _ ToolHandler = &RunMatrixHandler{}
+
+func NewRunMatrixHandler(fetcher *scriptsource.Fetcher, scripts *history.Store) *RunMatrixHandler {
+	return &RunMatrixHandler{fetcher: fetcher, scripts: scripts}
+}
+
+func (h *RunMatrixHandler) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+
+	script, errMsg := resolveScript(ctx, args, h.fetcher)
+	if errMsg != "" {
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	cells, errMsg := parseMatrix(args)
+	if errMsg != "" {
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	// The matrix dimensions replace the run parameters of the same name. Without a duration
+	// or iterations, each parameter set runs for the default duration.
+	baseArgs := make(map[string]interface{}, len(args))
+	for key, value := range args {
+		switch key {
+		case "vus", "environments", "payload_sizes":
+		default:
+			baseArgs[key] = value
+		}
+	}
+	if _, exists := baseArgs["stages"]; exists {
+		return mcp.NewToolResultError("Parameter 'stages' is not supported by run_matrix: the matrix sets the VUs of each run. Use 'duration' or 'iterations' instead."), nil
+	}
+
+	baseOptions, err := parseRunOptions(baseArgs)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid parameters: %v.", err)), nil
+	}
+
+	revision, errMsg := recordScript(args, h.scripts, script, "run_matrix")
+	if errMsg != "" {
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	startTime := time.Now()
+	result := RunMatrixResult{Runs: len(cells), Script: revision}
+
+	for i, cell := range cells {
+		options := *baseOptions
+		options.VUs = cell.vus
+		options.Env = make(map[string]string, len(baseOptions.Env))
+		for name, value := range baseOptions.Env {
+			options.Env[name] = value
+		}
+		if cell.environment != nil {
+			for name, value := range cell.environment.Env {
+				options.Env[name] = value
+			}
+		}
+		if cell.payloadSize > 0 {
+			options.Env[PayloadSizeEnvVar] = strconv.Itoa(cell.payloadSize)
+		}
+
+		row := MatrixRow{VUs: cell.vus, PayloadSize: cell.payloadSize}
+		if cell.environment != nil {
+			row.Environment = cell.environment.Name
+		}
+
+		notify.Send(ctx, mcp.LoggingLevelInfo, notify.EventRunStarted, "k6 matrix run started", map[string]any{
+			"run":          i + 1,
+			"runs":         len(cells),
+			"vus":          row.VUs,
+			"environment":  row.Environment,
+			"payload_size": row.PayloadSize,
+		})
+
+		// Parameter sets run one after the other, so that they don't compete for resources
+		runResult, _ := runner.RunK6Test(ctx, script, &options)
+		notifyRunFinished(ctx, runResult)
+
+		if runResult != nil {
+			row.Success = runResult.Success
+			row.ExitCode = runResult.ExitCode
+			row.Grade = runResult.Analysis.Grade
+			row.TotalRequests = runResult.Summary.TotalRequests
+			row.FailedRequests = runResult.Summary.FailedRequests
+			row.AvgResponseTime = runResult.Summary.AvgResponseTime
+			row.P95ResponseTime = runResult.Summary.P95ResponseTime
+			row.P99ResponseTime = runResult.Summary.P99ResponseTime
+			row.RequestRate = runResult.Summary.RequestRate
+			row.Duration = runResult.Duration
+			row.Error = runResult.Error
+			if row.TotalRequests > 0 {
+				row.ErrorRate = float64(row.FailedRequests) / float64(row.TotalRequests) * 100
+			}
+		}
+
+		if row.Success {
+			result.Passed++
+		} else {
+			result.Failed++
+		}
+		result.Rows = append(result.Rows, row)
+
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	result.Duration = time.Since(startTime).String()
+	result.Table = matrixTable(result.Rows)
+
+	resultJSON, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("failed to serialize matrix result"), err
+	}
+
+	slog.InfoContext(ctx, "matrix completed",
+		slog.Int("runs", len(result.Rows)),
+		slog.Int("passed", result.Passed),
+		slog.Int("failed", result.Failed),
+	)
+
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// parseMatrix parses the matrix dimensions from the tool arguments, and returns their
+// cartesian product. It returns a user-facing error message when they are invalid.
+func parseMatrix(args map[string]interface{}) ([]matrixCell, string) {
+	vus := []int{runner.DefaultVUs}
+	if value, exists := args["vus"]; exists {
+		if err := decodeArg(value, &vus); err != nil || len(vus) == 0 {
+			return nil, "Parameter 'vus' must be a non-empty array of numbers. Example: [5, 10, 20]"
+		}
+	}
+
+	environments := []*MatrixEnvironment{nil}
+	if value, exists := args["environments"]; exists {
+		var parsed []*MatrixEnvironment
+		if err := decodeArg(value, &parsed); err != nil || len(parsed) == 0 {
+			return nil, "Parameter 'environments' must be a non-empty array of {name, env} objects. Example: [{\"name\": \"staging\", \"env\": {\"BASE_URL\": \"https://staging.example.com\"}}]"
+		}
+		for i, environment := range parsed {
+			if environment == nil || environment.Name == "" {
+				return nil, fmt.Sprintf("Environment %d of 'environments' is missing its 'name'.", i+1)
+			}
+		}
+		environments = parsed
+	}
+
+	payloadSizes := []int{0}
+	if value, exists := args["payload_sizes"]; exists {
+		if err := decodeArg(value, &payloadSizes); err != nil || len(payloadSizes) == 0 {
+			return nil, "Parameter 'payload_sizes' must be a non-empty array of sizes in bytes. Example: [1024, 10240, 102400]"
+		}
+		for _, size := range payloadSizes {
+			if size <= 0 {
+				return nil, "Payload sizes must be positive numbers of bytes."
+			}
+		}
+	}
+
+	total := len(vus) * len(environments) * len(payloadSizes)
+	if total > MaxMatrixRuns {
+		return nil, fmt.Sprintf("The matrix has %d parameter sets (%d VU counts x %d environments x %d payload sizes), more than the maximum of %d. Reduce one of the dimensions.",
+			total, len(vus), len(environments), len(payloadSizes), MaxMatrixRuns)
+	}
+
+	cells := make([]matrixCell, 0, total)
+	for _, environment := range environments {
+		for _, payloadSize := range payloadSizes {
+			for _, v := range vus {
+				if v <= 0 || v > runner.MaxVUs {
+					return nil, fmt.Sprintf("VU counts must be between 1 and %d (received %d).", runner.MaxVUs, v)
+				}
+				cells = append(cells, matrixCell{vus: v, environment: environment, payloadSize: payloadSize})
+			}
+		}
+	}
+
+	return cells, ""
+}
+
+// matrixTable renders the rows of a matrix as a Markdown comparison table. The environment
+// and payload size columns are only included when the matrix has these dimensions.
+func matrixTable(rows []MatrixRow) string {
+	var withEnvironment, withPayload bool
+	for _, row := range rows {
+		withEnvironment = withEnvironment || row.Environment != ""
+		withPayload = withPayload || row.PayloadSize > 0
+	}
+
+	header := []string{"VUs"}
+	if withEnvironment {
+		header = append(header, "Environment")
+	}
+	if withPayload {
+		header = append(header, "Payload (bytes)")
+	}
+	header = append(header, "Result", "Requests", "Error rate", "Avg (ms)", "p95 (ms)", "p99 (ms)", "Req/s")
+
+	var b strings.Builder
+	b.WriteString("| " + strings.Join(header, " | ") + " |\n")
+	b.WriteString("|" + strings.Repeat("---|", len(header)) + "\n")
+
+	for _, row := range rows {
+		cells := []string{strconv.Itoa(row.VUs)}
+		if withEnvironment {
+			cells = append(cells, row.Environment)
+		}
+		if withPayload {
+			cells = append(cells, strconv.Itoa(row.PayloadSize))
+		}
+
+		outcome := "pass"
+		if !row.Success {
+			outcome = "fail"
+		}
+		if row.Grade != "" {
+			outcome += " (" + row.Grade + ")"
+		}
+
+		cells = append(cells,
+			outcome,
+			strconv.Itoa(row.TotalRequests),
+			fmt.Sprintf("%.2f%%", row.ErrorRate),
+			fmt.Sprintf("%.1f", row.AvgResponseTime),
+			fmt.Sprintf("%.1f", row.P95ResponseTime),
+			fmt.Sprintf("%.1f", row.P99ResponseTime),
+			fmt.Sprintf("%.1f", row.RequestRate),
+		)
+		b.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+	}
+
+	return b.String()
+}
@@ -2,15 +2,20 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/oleiade/k6-mcp/internal/budget"
 	"github.com/oleiade/k6-mcp/internal/logging"
+	"github.com/oleiade/k6-mcp/internal/usage"
 )
 
-// toolMiddleware wraps a ToolHandler to add correlation ID, logging and recovery.
+// toolMiddleware wraps a ToolHandler to add correlation ID, logging, usage statistics,
+// response budgeting and recovery.
 type toolMiddleware struct {
 	name string
 	next ToolHandler
@@ -18,26 +23,88 @@ type toolMiddleware struct {
 
 func (m toolMiddleware) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	// Correlation and timing
-	requestID := uuid.New().String()
-	ctx = logging.ContextWithRequestID(ctx, requestID)
+	ctx = logging.ContextWithRequest(ctx, logging.Request{
+		ID:      uuid.New().String(),
+		Tool:    m.name,
+		Session: sessionIDFromContext(ctx),
+	})
 	start := time.Now()
 
 	// Extract arguments for logging
 	args := request.GetArguments()
-	logging.RequestStart(ctx, m.name, args)
+	logging.RequestStart(ctx, args)
 
 	// Panic safety
 	defer func() {
 		if rec := recover(); rec != nil {
-			logging.RequestEnd(ctx, m.name, false, time.Since(start), fmt.Errorf("panic: %v", rec))
+			logging.RequestEnd(ctx, false, time.Since(start), fmt.Errorf("panic: %v", rec))
+			usage.Record(m.name, false, time.Since(start))
 		}
 	}()
 
+	// Decode the content sent compressed before the tool checks its size
+	decoded, errMsg := decodeContentArgs(args)
+	if errMsg != "" {
+		logging.RequestEnd(ctx, false, time.Since(start), errors.New(errMsg))
+		usage.Record(m.name, false, time.Since(start))
+		return mcp.NewToolResultError(errMsg), nil
+	}
+	if decoded != nil {
+		request.Params.Arguments = decoded
+	}
+
 	res, err := m.next.Handle(ctx, request)
-	logging.RequestEnd(ctx, m.name, err == nil, time.Since(start), err)
+	success := err == nil && (res == nil || !res.IsError)
+	if success && res != nil {
+		fitResponse(ctx, m.name, res)
+	}
+	logging.RequestEnd(ctx, success, time.Since(start), resultError(res, err))
+	usage.Record(m.name, success, time.Since(start))
 	return res, err
 }
 
+// fitResponse shortens the text contents of the result exceeding the response budget.
+func fitResponse(ctx context.Context, tool string, res *mcp.CallToolResult) {
+	b := budget.Current()
+	if b.MaxTokens <= 0 {
+		return
+	}
+
+	for i, content := range res.Content {
+		text, ok := content.(mcp.TextContent)
+		if !ok {
+			continue
+		}
+		fitted, truncation := b.Fit(tool, text.Text)
+		if truncation == nil {
+			continue
+		}
+		text.Text = fitted
+		res.Content[i] = text
+		logging.WithComponent("handlers").InfoContext(ctx, "Truncated response over budget",
+			slog.Int("original_bytes", truncation.OriginalBytes),
+			slog.Int("bytes", truncation.Bytes),
+			slog.Int("budget_tokens", truncation.BudgetTokens),
+			slog.Any("fields", truncation.Fields),
+			slog.String("artifact_id", truncation.ArtifactID),
+		)
+	}
+}
+
+// resultError returns the error of a tool call: err, or else the message of the error
+// result the tool returned.
+func resultError(res *mcp.CallToolResult, err error) error {
+	if err != nil || res == nil || !res.IsError {
+		return err
+	}
+	for _, content := range res.Content {
+		if text, ok := content.(mcp.TextContent); ok {
+			return errors.New(text.Text)
+		}
+	}
+	return errors.New("tool error")
+}
+
 // WithToolMiddleware decorates a ToolHandler with centralized boilerplate.
 func WithToolMiddleware(name string, h ToolHandler) ToolHandler {
 	return toolMiddleware{name: name, next: h}
@@ -50,8 +117,11 @@ type promptMiddleware struct {
 }
 
 func (m promptMiddleware) Handle(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
-	requestID := uuid.New().String()
-	ctx = logging.ContextWithRequestID(ctx, requestID)
+	ctx = logging.ContextWithRequest(ctx, logging.Request{
+		ID:      uuid.New().String(),
+		Prompt:  m.name,
+		Session: sessionIDFromContext(ctx),
+	})
 	start := time.Now()
 
 	// Normalize arguments to map[string]interface{} for logging
@@ -59,16 +129,16 @@ func (m promptMiddleware) Handle(ctx context.Context, request mcp.GetPromptReque
 	for k, v := range request.Params.Arguments {
 		args[k] = v
 	}
-	logging.RequestStart(ctx, m.name, args)
+	logging.RequestStart(ctx, args)
 
 	defer func() {
 		if rec := recover(); rec != nil {
-			logging.RequestEnd(ctx, m.name, false, time.Since(start), fmt.Errorf("panic: %v", rec))
+			logging.RequestEnd(ctx, false, time.Since(start), fmt.Errorf("panic: %v", rec))
 		}
 	}()
 
 	res, err := m.next.Handle(ctx, request)
-	logging.RequestEnd(ctx, m.name, err == nil, time.Since(start), err)
+	logging.RequestEnd(ctx, err == nil, time.Since(start), err)
 	return res, err
 }
 
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/oleiade/k6-mcp/internal/logging"
+	"github.com/oleiade/k6-mcp/internal/runner"
+)
+
+// Formats of the get_run_progress tool.
+const (
+	progressFormatJSON        = "json"
+	progressFormatOpenMetrics = "openmetrics"
+)
+
+// GetRunProgressResult is the result of the get_run_progress tool.
+type GetRunProgressResult struct {
+	Runs    []runner.Progress `json:"runs"`
+	Message string            `json:"message,omitempty"`
+}
+
+// GetRunProgressHandler reports the cumulative metrics of the runs in progress.
+type GetRunProgressHandler struct{}
+
+var _ ToolHandler = &GetRunProgressHandler{}
+
+func NewGetRunProgressHandler() *GetRunProgressHandler {
+	return &GetRunProgressHandler{}
+}
+
+func (h *GetRunProgressHandler) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	format := request.GetString("format", progressFormatJSON)
+	if format != progressFormatJSON && format != progressFormatOpenMetrics {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid format %q: expected %q or %q", format, progressFormatJSON, progressFormatOpenMetrics)), nil
+	}
+
+	var runs []runner.Progress
+	if id := request.GetString("run_id", ""); id != "" {
+		progress, ok := runner.RunProgress(id)
+		if !ok {
+			return mcp.NewToolResultError(fmt.Sprintf("No run with ID %q is in progress: it may have finished, in which case its result is that of the tool call that started it. Call get_run_progress without run_id to list the runs in progress.", id)), nil
+		}
+		runs = []runner.Progress{progress}
+	} else {
+		runs = runner.ActiveRuns()
+	}
+
+	logging.Default().InfoContext(ctx, "Run progress reported",
+		slog.Int("runs", len(runs)),
+		slog.String("format", format),
+	)
+
+	if format == progressFormatOpenMetrics {
+		return mcp.NewToolResultText(runner.OpenMetrics(runs)), nil
+	}
+
+	result := GetRunProgressResult{Runs: runs}
+	if len(runs) == 0 {
+		result.Message = "No run is in progress."
+	}
+	resultJSON, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("failed to serialize run progress"), err
+	}
+
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
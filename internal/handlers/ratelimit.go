@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+
+	"github.com/oleiade/k6-mcp/internal/runner"
+)
+
+const (
+	// rateLimitWarningRatio is the share of the rate limit of the environment profile
+	// beyond which planned request rates are warned about.
+	rateLimitWarningRatio = 0.8
+	// maxDryRuns is the number of scripts whose dry run measurements are remembered.
+	maxDryRuns = 100
+)
+
+// Statuses of rate limit checks.
+const (
+	rateLimitOK       = "ok"
+	rateLimitWarning  = "warning"
+	rateLimitExceeded = "exceeded"
+	rateLimitUnknown  = "unknown"
+)
+
+// RateLimitCheck compares the planned peak request rate of a run with the rate limit of
+// its environment profile.
+type RateLimitCheck struct {
+	Limit           float64 `json:"limit_per_second"`
+	PeakRequestRate float64 `json:"peak_request_rate_per_second,omitempty"`
+	// Utilization is the peak request rate, as a percentage of the limit.
+	Utilization float64 `json:"utilization_percent,omitempty"`
+	Status      string  `json:"status"`
+	Message     string  `json:"message"`
+	// MaxVUs is the number of VUs whose request rate stays within the warning ratio of
+	// the limit, for runs exceeding it.
+	MaxVUs int `json:"max_vus,omitempty"`
+	// Ignored is set for runs that went ahead despite exceeding the limit.
+	Ignored bool `json:"ignored,omitempty"`
+}
+
+// DryRuns remembers the single iteration dry runs of scripts, by script hash, so that the
+// request rates of their runs are checked against rate limits without measuring them again.
+type DryRuns struct {
+	mu           sync.Mutex
+	measurements map[string]DryRunMeasurements
+	order        []string
+}
+
+// NewDryRuns creates an empty DryRuns.
+func NewDryRuns() *DryRuns {
+	return &DryRuns{measurements: make(map[string]DryRunMeasurements)}
+}
+
+// get returns the remembered dry run of the script.
+func (d *DryRuns) get(script string) (DryRunMeasurements, bool) {
+	if d == nil {
+		return DryRunMeasurements{}, false
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	measurements, ok := d.measurements[scriptHash(script)]
+	return measurements, ok
+}
+
+// put remembers the successful dry run of the script, forgetting the oldest one beyond
+// maxDryRuns.
+func (d *DryRuns) put(script string, measurements DryRunMeasurements) {
+	if d == nil || !measurements.Success {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	hash := scriptHash(script)
+	if _, exists := d.measurements[hash]; !exists {
+		d.order = append(d.order, hash)
+		if len(d.order) > maxDryRuns {
+			delete(d.measurements, d.order[0])
+			d.order = d.order[1:]
+		}
+	}
+	d.measurements[hash] = measurements
+}
+
+// measure returns the remembered dry run of the script, or runs a single iteration of it
+// with 1 VU to measure its requests and duration.
+func (d *DryRuns) measure(ctx context.Context, script string, options *runner.RunOptions) DryRunMeasurements {
+	if measurements, ok := d.get(script); ok {
+		return measurements
+	}
+
+	result, _ := runner.RunK6Test(ctx, script, &runner.RunOptions{
+		VUs:        1,
+		Iterations: 1,
+		Files:      options.Files,
+		Env:        options.Env,
+		SecretEnv:  options.SecretEnv,
+	})
+	measurements := measureDryRun(result)
+	d.put(script, measurements)
+
+	return measurements
+}
+
+// peakRequestRate projects the highest request rate, per second, of a run of the options
+// from the measurements of a single iteration: when all the VUs of the run, or of its
+// highest stage, loop iterations as long as the dry run one, within the pacing.
+func peakRequestRate(dryRun DryRunMeasurements, options *runner.RunOptions) float64 {
+	iterationsPerVU := 1000 / math.Max(dryRun.IterationDurationMs, 1)
+	if options.Pacing > 0 {
+		iterationsPerVU = math.Min(iterationsPerVU, options.Pacing/60)
+	}
+
+	return float64(peakVUs(options)) * iterationsPerVU * dryRun.RequestsPerIteration
+}
+
+// peakVUs returns the highest number of VUs running at once in a run of the options.
+func peakVUs(options *runner.RunOptions) int {
+	if len(options.Stages) > 0 {
+		peak := 0
+		for _, stage := range options.Stages {
+			peak = max(peak, stage.Target)
+		}
+		return peak
+	}
+
+	vus := options.VUs
+	if vus == 0 {
+		vus = runner.DefaultVUs
+	}
+	if options.Iterations > 0 {
+		vus = min(vus, options.Iterations)
+	}
+	return vus
+}
+
+// checkRateLimit compares the peak request rate of a run of the options, projected from the
+// dry run, with the limit.
+func checkRateLimit(limit float64, dryRun DryRunMeasurements, options *runner.RunOptions) *RateLimitCheck {
+	check := &RateLimitCheck{Limit: limit}
+	if !dryRun.Success || dryRun.RequestsPerIteration == 0 {
+		check.Status = rateLimitUnknown
+		check.Message = "The request rate could not be measured, as the dry run iteration failed or made no requests; the run is not checked against the rate limit."
+		return check
+	}
+
+	peak := peakRequestRate(dryRun, options)
+	check.PeakRequestRate = math.Round(peak*10) / 10
+	check.Utilization = math.Round(peak/limit*1000) / 10
+
+	switch {
+	case peak > limit:
+		check.Status = rateLimitExceeded
+		perVU := peak / float64(max(peakVUs(options), 1))
+		check.MaxVUs = int(limit * rateLimitWarningRatio / perVU)
+		check.Message = fmt.Sprintf("The run would peak at %.1f requests/s, beyond the rate limit of %.0f requests/s of the target: its WAF or rate limiting would likely throttle or block the load generator, and the results would measure the rate limiting rather than the system.", peak, limit)
+		if check.MaxVUs > 0 {
+			check.Message += fmt.Sprintf(" Lower the load to %d VUs or fewer, or pace iterations.", check.MaxVUs)
+		} else {
+			check.Message += " Even a single VU exceeds it: pace iterations, or add sleep between requests."
+		}
+	case peak > limit*rateLimitWarningRatio:
+		check.Status = rateLimitWarning
+		check.Message = fmt.Sprintf("The run would peak at %.1f requests/s, %.0f%% of the rate limit of %.0f requests/s of the target; longer response times lower it, but retries or faster responses could trip it.", peak, peak/limit*100, limit)
+	default:
+		check.Status = rateLimitOK
+		check.Message = fmt.Sprintf("The run would peak at %.1f requests/s, within the rate limit of %.0f requests/s of the target.", peak, limit)
+	}
+
+	return check
+}
+
+// guardRateLimit checks a run of the options against the rate limit, measuring the script
+// first, and returns the error message blocking runs exceeding it, unless ignored. Previews
+// only use remembered measurements.
+func guardRateLimit(ctx context.Context, dryRuns *DryRuns, script string, options *runner.RunOptions, limit float64, preview, ignore bool) (*RateLimitCheck, string) {
+	var measurements DryRunMeasurements
+	if preview {
+		var ok bool
+		if measurements, ok = dryRuns.get(script); !ok {
+			return &RateLimitCheck{
+				Limit:   limit,
+				Status:  rateLimitUnknown,
+				Message: "The script was not measured yet; the run will measure a single iteration first to check its request rate against the rate limit, or estimate it with estimate_run.",
+			}, ""
+		}
+	} else {
+		measurements = dryRuns.measure(ctx, script, options)
+	}
+
+	check := checkRateLimit(limit, measurements, options)
+	if check.Status == rateLimitExceeded && !preview {
+		if !ignore {
+			return nil, "Run blocked: " + check.Message + " Set 'ignore_rate_limit' to run anyway, e.g. when the load generator is allow-listed."
+		}
+		check.Ignored = true
+	}
+
+	return check, ""
+}
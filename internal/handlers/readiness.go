@@ -0,0 +1,226 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/oleiade/k6-mcp/internal/defaults"
+	"github.com/oleiade/k6-mcp/internal/runner"
+	"github.com/oleiade/k6-mcp/internal/scriptsource"
+	"github.com/oleiade/k6-mcp/internal/security"
+	"github.com/oleiade/k6-mcp/internal/validator"
+)
+
+// ReadinessCheckResult is the result of the readiness_check tool.
+type ReadinessCheckResult struct {
+	runner.Readiness
+	// Project is the project whose defaults were checked, if any.
+	Project string `json:"project,omitempty"`
+	// Targets are the hosts the run would send requests to.
+	Targets []string `json:"targets"`
+}
+
+// ReadinessCheckHandler checks that a script is ready for scale-up runs.
+type ReadinessCheckHandler struct {
+	fetcher  *scriptsource.Fetcher
+	defaults *defaults.Store
+}
+
+var _ ToolHandler = &ReadinessCheckHandler{}
+
+func NewReadinessCheckHandler(fetcher *scriptsource.Fetcher, defaults *defaults.Store) *ReadinessCheckHandler {
+	return &ReadinessCheckHandler{fetcher: fetcher, defaults: defaults}
+}
+
+func (h *ReadinessCheckHandler) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+
+	script, errMsg := resolveScript(ctx, args, h.fetcher)
+	if errMsg != "" {
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	var allowed []string
+	if allowedValue, exists := args["allowed_targets"]; exists {
+		if err := decodeArg(allowedValue, &allowed); err != nil {
+			return mcp.NewToolResultError("Invalid allowed_targets format: expected an array of hosts. Example: [\"staging.example.com\", \"*.test.example.com\"]"), nil
+		}
+	}
+	var thresholds map[string][]string
+	if thresholdsValue, exists := args["thresholds"]; exists {
+		if thresholds, errMsg = thresholdsArg(thresholdsValue); errMsg != "" {
+			return mcp.NewToolResultError(errMsg), nil
+		}
+	}
+
+	// The environment profile is the defaults of the project, or of the session
+	projectName := request.GetString("project", "")
+	var project *defaults.Defaults
+	if projectName != "" {
+		d, err := h.defaults.Project(projectName)
+		if err != nil {
+			return mcp.NewToolResultError("Failed to read the project defaults; reason: " + err.Error()), nil
+		}
+		if d == nil {
+			return mcp.NewToolResultError(fmt.Sprintf("No defaults are set for project %q. Set them with set_defaults, or omit 'project'.", projectName)), nil
+		}
+		project = d
+	}
+	profile := defaults.Effective(project, h.defaults.Session(sessionIDFromContext(ctx)))
+	if profile != nil {
+		for metric, expressions := range profile.Thresholds {
+			if _, set := thresholds[metric]; !set {
+				if thresholds == nil {
+					thresholds = make(map[string][]string)
+				}
+				thresholds[metric] = expressions
+			}
+		}
+	}
+
+	targets := readinessTargets(script, profile)
+	items := []runner.ReadinessItem{
+		checkReadinessValidates(ctx, script),
+		runner.CheckReadinessThresholds(script, thresholds),
+		runner.CheckReadinessChecks(script),
+		runner.CheckReadinessData(script),
+		checkReadinessTargets(targets, allowed, profile),
+		runner.CheckReadinessPacing(script),
+		checkReadinessEnvironment(projectName, profile),
+	}
+	result := ReadinessCheckResult{Readiness: runner.ScoreReadiness(items), Project: projectName, Targets: targets}
+
+	slog.InfoContext(ctx, "readiness checked",
+		slog.Int("score", result.Score),
+		slog.Bool("ready", result.Ready),
+		slog.Int("blocking", len(result.Blocking)),
+	)
+
+	resultJSON, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("failed to serialize readiness check"), err
+	}
+
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// checkReadinessValidates validates the script with a single iteration.
+func checkReadinessValidates(ctx context.Context, script string) runner.ReadinessItem {
+	item := runner.ReadinessItem{ID: runner.ReadinessValidates, Blocking: true}
+
+	result, err := validator.ValidateK6Script(ctx, script)
+	switch {
+	case result != nil && result.Valid:
+		item.Passed = true
+		item.Message = "The script runs a single iteration without errors."
+	case result != nil:
+		item.Message = "The script fails validation: " + result.Summary.Description
+		item.Fix = "Run validate_k6_script for its issues and suggestions."
+	default:
+		item.Message = "The script could not be validated: " + err.Error()
+		item.Fix = "Run validate_k6_script for details."
+	}
+
+	return item
+}
+
+// readinessTargets returns the hosts of the URL literals of the script, and of the target
+// host of the profile.
+func readinessTargets(script string, profile *defaults.Defaults) []string {
+	targets := []string{}
+	seen := make(map[string]bool)
+	add := func(host string) {
+		if host != "" && !seen[host] {
+			seen[host] = true
+			targets = append(targets, host)
+		}
+	}
+
+	if profile != nil && profile.TargetHost != "" {
+		if u, err := url.Parse(profile.TargetHost); err == nil {
+			add(strings.ToLower(u.Hostname()))
+		}
+	}
+	for _, target := range security.Scan(script).Targets {
+		add(target.Host)
+	}
+
+	return targets
+}
+
+// checkReadinessTargets checks that every target is allow-listed: by the allowed hosts,
+// which may be wildcards such as *.example.com, or else by the target host of the profile.
+func checkReadinessTargets(targets, allowed []string, profile *defaults.Defaults) runner.ReadinessItem {
+	item := runner.ReadinessItem{ID: runner.ReadinessTarget, Blocking: true}
+
+	source := "allowed_targets"
+	if len(allowed) == 0 && profile != nil && profile.TargetHost != "" {
+		allowed, source = []string{profile.TargetHost}, "the target_host of the defaults"
+	}
+	if len(allowed) == 0 {
+		item.Message = "No target allow-list is set: nothing confirms the run targets a system meant to take the load."
+		item.Fix = "Pass the hosts approved for load in allowed_targets, or set target_host with set_defaults."
+		return item
+	}
+	if len(targets) == 0 {
+		item.Message = "The script builds its URLs at runtime: its targets can't be checked against the allow-list."
+		item.Fix = "Read the base URL from __ENV.BASE_URL, and set it with the target_host of set_defaults."
+		return item
+	}
+
+	var denied []string
+	for _, target := range targets {
+		if !hostAllowed(target, allowed) {
+			denied = append(denied, target)
+		}
+	}
+	if len(denied) > 0 {
+		item.Message = fmt.Sprintf("The script targets hosts not allowed by %s: %s.", source, strings.Join(denied, ", "))
+		item.Fix = "Point the script at an allowed environment, or add the hosts to allowed_targets once their owners approved the load."
+		return item
+	}
+
+	item.Passed = true
+	item.Message = fmt.Sprintf("Every target is allowed by %s.", source)
+	return item
+}
+
+// hostAllowed reports whether the host matches an allowed host, URL or *. wildcard.
+func hostAllowed(host string, allowed []string) bool {
+	for _, entry := range allowed {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if strings.Contains(entry, "://") {
+			if u, err := url.Parse(entry); err == nil {
+				entry = u.Hostname()
+			}
+		}
+		if host == entry || (strings.HasPrefix(entry, "*.") && strings.HasSuffix(host, entry[1:])) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkReadinessEnvironment checks that the run targets a selected environment profile:
+// the defaults of a project, or of the session, setting the target host.
+func checkReadinessEnvironment(projectName string, profile *defaults.Defaults) runner.ReadinessItem {
+	item := runner.ReadinessItem{ID: runner.ReadinessEnvironment}
+	if profile == nil || profile.TargetHost == "" {
+		item.Message = "No environment profile is selected: the script's hardcoded URLs decide what gets the load."
+		item.Fix = "Set the target_host of a project with set_defaults, and pass the project to the run."
+		return item
+	}
+
+	item.Passed = true
+	if projectName != "" {
+		item.Message = fmt.Sprintf("Project %q targets %s.", projectName, profile.TargetHost)
+	} else {
+		item.Message = fmt.Sprintf("The session defaults target %s.", profile.TargetHost)
+	}
+	return item
+}
@@ -0,0 +1,255 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/oleiade/k6-mcp/internal/codegen"
+	"github.com/oleiade/k6-mcp/internal/privacy"
+	"github.com/oleiade/k6-mcp/internal/recorder"
+	"github.com/oleiade/k6-mcp/internal/style"
+)
+
+// filenameUnsafePattern matches the runs of characters left out of file names.
+var filenameUnsafePattern = regexp.MustCompile(`[^a-z0-9_.-]+`)
+
+// StartRecordingResult is the result of the start_recording tool.
+type StartRecordingResult struct {
+	SessionID string `json:"session_id"`
+	// Proxy is the URL of the proxy to configure as the HTTP and HTTPS proxy of the client.
+	Proxy string `json:"proxy"`
+	// CACertificate is the path of the certificate of the authority the client must trust
+	// for HTTPS traffic to be recorded.
+	CACertificate string    `json:"ca_certificate"`
+	ExpiresAt     time.Time `json:"expires_at"`
+	Hosts         []string  `json:"hosts,omitempty"`
+	Instructions  []string  `json:"instructions"`
+}
+
+// StopRecordingResult is the result of the stop_recording tool.
+type StopRecordingResult struct {
+	SessionID string `json:"session_id"`
+	// Recorded is the number of requests recorded, of which Converted were converted and
+	// Skipped left out, as static assets or failed requests.
+	Recorded  int `json:"recorded"`
+	Converted int `json:"converted"`
+	Skipped   int `json:"skipped"`
+	// Dropped is the number of requests proxied but not recorded.
+	Dropped int `json:"dropped,omitempty"`
+	// RemovedHeaders are the credential headers left out of the script.
+	RemovedHeaders []string `json:"removed_headers,omitempty"`
+	// Correlations are the dynamic values the script extracts from responses, and
+	// Uncorrelated the values that look dynamic but that no response sets.
+	Correlations []recorder.Correlation  `json:"correlations,omitempty"`
+	Uncorrelated []recorder.Uncorrelated `json:"uncorrelated,omitempty"`
+	// Scrubbed counts the personal data masked in the recording before its conversion.
+	Scrubbed []privacy.Finding `json:"scrubbed,omitempty"`
+	Filename string            `json:"filename"`
+	Script   string            `json:"script"`
+	Notes    []string          `json:"notes,omitempty"`
+}
+
+// StartRecordingHandler starts recordings of the traffic users drive through a local
+// capture proxy.
+type StartRecordingHandler struct {
+	recorder *recorder.Recorder
+}
+
+var _ ToolHandler = &StartRecordingHandler{}
+
+func NewStartRecordingHandler(rec *recorder.Recorder) *StartRecordingHandler {
+	return &StartRecordingHandler{recorder: rec}
+}
+
+func (h *StartRecordingHandler) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	opts := recorder.StartOptions{
+		Name: request.GetString("name", ""),
+		Port: request.GetInt("port", 0),
+	}
+	if opts.Port < 0 || opts.Port > 65535 {
+		return mcp.NewToolResultError("Parameter 'port' must be between 1 and 65535, or 0 to pick a free port"), nil
+	}
+
+	if args := request.GetArguments(); args["hosts"] != nil {
+		if err := decodeArg(args["hosts"], &opts.Hosts); err != nil {
+			return mcp.NewToolResultError("Parameter 'hosts' must be an array of host names. Example: [\"test.k6.io\", \"*.example.com\"]"), nil
+		}
+	}
+
+	if maxDuration := request.GetString("max_duration", ""); maxDuration != "" {
+		d, err := time.ParseDuration(maxDuration)
+		if err != nil || d <= 0 || d > recorder.MaxDuration {
+			return mcp.NewToolResultError(fmt.Sprintf("Parameter 'max_duration' must be a duration of at most %s. Example: '15m'", recorder.MaxDuration)), nil
+		}
+		opts.MaxDuration = d
+	}
+
+	session, err := h.recorder.Start(opts)
+	if err != nil {
+		return mcp.NewToolResultError("Failed to start the recording; reason: " + err.Error()), nil
+	}
+	caPath, err := h.recorder.CACertificatePath()
+	if err != nil {
+		return mcp.NewToolResultError("Failed to read the recording certificate authority; reason: " + err.Error()), nil
+	}
+
+	proxyURL := "http://" + session.Addr
+	slog.InfoContext(ctx, "Recording started",
+		slog.String("session_id", session.ID),
+		slog.String("proxy", proxyURL),
+	)
+
+	result := StartRecordingResult{
+		SessionID:     session.ID,
+		Proxy:         proxyURL,
+		CACertificate: caPath,
+		ExpiresAt:     session.ExpiresAt,
+		Hosts:         session.Hosts,
+		Instructions: []string{
+			fmt.Sprintf("Configure %s as the HTTP and HTTPS proxy of the client, e.g. HTTP_PROXY=%s HTTPS_PROXY=%s, or in the proxy settings of the browser", proxyURL, proxyURL, proxyURL),
+			fmt.Sprintf("To record HTTPS traffic, trust the certificate authority %s in the client, e.g. curl --proxy %s --cacert %s, or import it in the certificate settings of the browser; remove it once done recording", caPath, proxyURL, caPath),
+			"Drive the user journey to record, then call stop_recording with the session_id to get the k6 script",
+		},
+	}
+
+	resultJSON, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal result: %w", err)
+	}
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// StopRecordingHandler stops recordings, and converts their requests into k6 scripts.
+type StopRecordingHandler struct {
+	recorder *recorder.Recorder
+}
+
+var _ ToolHandler = &StopRecordingHandler{}
+
+func NewStopRecordingHandler(rec *recorder.Recorder) *StopRecordingHandler {
+	return &StopRecordingHandler{recorder: rec}
+}
+
+func (h *StopRecordingHandler) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	sessionID, err := request.RequireString("session_id")
+	if err != nil {
+		return mcp.NewToolResultError("Missing required parameter 'session_id'"), nil
+	}
+
+	opts := recorder.ConvertOptions{
+		IncludeStatic: request.GetBool("include_static", false),
+		ThinkTime:     request.GetBool("think_time", true),
+		Correlate:     request.GetBool("correlate", true),
+		GroupGap:      time.Duration(request.GetFloat("group_gap_seconds", recorder.DefaultGroupGap.Seconds()) * float64(time.Second)),
+		FakePayloads:  request.GetBool("fake_payloads", false),
+	}
+	if opts.GroupGap <= 0 {
+		// Without a gap, requests are written outside groups
+		opts.GroupGap = -1
+	}
+
+	if args := request.GetArguments(); args["payload_hints"] != nil {
+		var hints map[string]string
+		if err := decodeArg(args["payload_hints"], &hints); err != nil {
+			return mcp.NewToolResultError("Parameter 'payload_hints' must be an object of field names or paths to kinds. Example: {\"email\": \"email\", \"user.age\": \"int:18-99\"}"), nil
+		}
+		opts.PayloadHints = make(map[string]codegen.Fake, len(hints))
+		for field, hint := range hints {
+			fake, err := codegen.ParseFake(hint)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Invalid payload hint for %q: %s", field, err.Error())), nil
+			}
+			opts.PayloadHints[field] = fake
+		}
+	}
+
+	recording, err := h.recorder.Stop(sessionID)
+	if errors.Is(err, recorder.ErrSessionNotFound) {
+		return mcp.NewToolResultError(fmt.Sprintf("No recording in progress has session_id %q; it may have been stopped already", sessionID)), nil
+	}
+	if err != nil {
+		return mcp.NewToolResultError("Failed to stop the recording; reason: " + err.Error()), nil
+	}
+	slog.InfoContext(ctx, "Recording stopped",
+		slog.String("session_id", recording.ID),
+		slog.Int("requests", len(recording.Entries)),
+	)
+
+	// Mask personal data before it reaches the script, and the client through it
+	var scrubbed []privacy.Finding
+	policy := privacy.Current()
+	if request.GetBool("scrub", policy.Recordings) {
+		scrubber := privacy.NewScrubber(policy.Rules)
+		recording.Scrub(scrubber)
+		scrubbed = scrubber.Findings()
+	}
+
+	conversion, err := recorder.Convert(recording, opts)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to convert the recording of %d requests; reason: %s", len(recording.Entries), err.Error())), nil
+	}
+
+	scriptStyle := style.Current()
+	script, err := codegen.Render(conversion.Script, scriptStyle)
+	if err != nil {
+		return mcp.NewToolResultError("Failed to generate the script; reason: " + err.Error()), nil
+	}
+
+	result := StopRecordingResult{
+		SessionID:      recording.ID,
+		Recorded:       len(recording.Entries),
+		Converted:      conversion.Requests,
+		Skipped:        conversion.Skipped,
+		Dropped:        recording.Dropped,
+		RemovedHeaders: conversion.RemovedHeaders,
+		Correlations:   conversion.Correlations,
+		Uncorrelated:   conversion.Uncorrelated,
+		Scrubbed:       scrubbed,
+		Filename:       recordingFilename(recording.Name) + scriptStyle.Extension(),
+		Script:         script,
+		Notes: []string{
+			"Review the script, and validate it with validate_k6_script before running it",
+		},
+	}
+	if len(conversion.RemovedHeaders) > 0 {
+		result.Notes = append(result.Notes, "The credential headers in removed_headers were left out of the script: set them from environment variables, e.g. headers: { Authorization: `Bearer ${__ENV.TOKEN}` }, or use an auth profile")
+	}
+	if len(conversion.Uncorrelated) > 0 {
+		result.Notes = append(result.Notes, "The values in uncorrelated look dynamic, but no earlier response sets them: the script replays their recorded values, which may have expired; set or compute them in the script")
+	}
+	if len(opts.PayloadHints) > 0 || opts.FakePayloads {
+		result.Notes = append(result.Notes, "Fields of the JSON payloads are generated on each iteration with the k6-utils helpers rather than replayed: check that the server accepts the generated values, e.g. for login forms expecting existing accounts")
+	}
+	if len(scrubbed) > 0 {
+		result.Notes = append(result.Notes, "Personal data counted in scrubbed was masked in the recorded requests and responses: the script sends the masked values, e.g. user1@example.com, which the server may reject; replace them with test accounts, or generate them with payload_hints")
+	}
+	if recording.Dropped > 0 {
+		result.Notes = append(result.Notes, fmt.Sprintf("%d requests were proxied but not recorded, being outside the recorded hosts or past the limit of %d requests", recording.Dropped, recorder.MaxEntries))
+	}
+
+	resultJSON, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal result: %w", err)
+	}
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// recordingFilename returns the base name of the script file of a recording, derived
+// from its name.
+func recordingFilename(name string) string {
+	base := strings.Trim(filenameUnsafePattern.ReplaceAllString(strings.ToLower(name), "-"), "-")
+	if len(base) > 64 {
+		base = strings.TrimRight(base[:64], "-")
+	}
+	if base == "" {
+		return "recording"
+	}
+	return base
+}
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/oleiade/k6-mcp/internal/artifacts"
+	"github.com/oleiade/k6-mcp/internal/report"
+)
+
+// GenerateReportResult is the result of the generate_report tool.
+type GenerateReportResult struct {
+	Format report.Format `json:"format"`
+	// Report is the rendered report, unless it was written to Path, or stored as Artifact.
+	Report   string              `json:"report,omitempty"`
+	Path     string              `json:"path,omitempty"`
+	Artifact *artifacts.Artifact `json:"artifact,omitempty"`
+}
+
+// reportRun is a labeled run tool result, as passed to the generate_report tool.
+type reportRun struct {
+	Label  string          `json:"label"`
+	Result json.RawMessage `json:"result"`
+}
+
+// GenerateReportHandler renders run results into Markdown or HTML reports.
+type GenerateReportHandler struct {
+	artifacts *artifacts.Store
+}
+
+var _ ToolHandler = &GenerateReportHandler{}
+
+func NewGenerateReportHandler(artifacts *artifacts.Store) *GenerateReportHandler {
+	return &GenerateReportHandler{artifacts: artifacts}
+}
+
+func (h *GenerateReportHandler) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+
+	format, err := report.ParseFormat(request.GetString("format", ""))
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	spec := &report.Spec{
+		Title: request.GetString("title", ""),
+		Notes: request.GetString("notes", ""),
+	}
+
+	if runValue, exists := args["run"]; exists {
+		var run report.Run
+		if err := decodeArg(runValue, &run.Result); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Invalid run format: %s. Pass the JSON result of the run tool.", err.Error())), nil
+		}
+		run.Label = request.GetString("label", "")
+		spec.Runs = append(spec.Runs, run)
+	}
+
+	if runsValue, exists := args["runs"]; exists {
+		var runs []reportRun
+		if err := decodeArg(runsValue, &runs); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Invalid runs format: %s. Example: [{\"label\": \"before\", \"result\": {...}}, {\"label\": \"after\", \"result\": {...}}]", err.Error())), nil
+		}
+		for i, r := range runs {
+			run := report.Run{Label: r.Label}
+			if err := json.Unmarshal(r.Result, &run.Result); err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Invalid result of runs[%d]: %s. Pass the JSON result of the run tool.", i, err.Error())), nil
+			}
+			spec.Runs = append(spec.Runs, run)
+		}
+	}
+
+	if len(spec.Runs) == 0 {
+		return mcp.NewToolResultError("Provide the result of a run with 'run', or the results of runs to compare with 'runs'."), nil
+	}
+	if len(spec.Runs) > report.MaxRuns {
+		return mcp.NewToolResultError(fmt.Sprintf("A report compares at most %d runs.", report.MaxRuns)), nil
+	}
+
+	content, err := report.Generate(spec, format)
+	if err != nil {
+		return mcp.NewToolResultError("Failed to generate report; reason: " + err.Error()), nil
+	}
+
+	result := GenerateReportResult{Format: format, Report: content}
+	if path := request.GetString("path", ""); path != "" {
+		workspace, wdErr := os.Getwd()
+		if wdErr != nil {
+			return mcp.NewToolResultError("Failed to resolve the workspace directory; reason: " + wdErr.Error()), nil
+		}
+		written, writeErr := report.WriteToWorkspace(workspace, path, content)
+		if writeErr != nil {
+			return mcp.NewToolResultError("Failed to write report; reason: " + writeErr.Error()), nil
+		}
+		result.Path = written
+		result.Report = ""
+	} else if request.GetBool("save_artifact", false) {
+		name, contentType := "report.md", "text/markdown; charset=utf-8"
+		if format == report.FormatHTML {
+			name, contentType = "report.html", "text/html; charset=utf-8"
+		}
+		artifact, saveErr := h.artifacts.Save(artifacts.Artifact{
+			Name:        name,
+			Kind:        artifacts.KindReport,
+			ContentType: contentType,
+			Source:      "generate_report",
+		}, strings.NewReader(content))
+		if saveErr != nil {
+			return mcp.NewToolResultError("Failed to store report; reason: " + saveErr.Error()), nil
+		}
+		result.Artifact = artifact
+		result.Report = ""
+	}
+
+	resultJSON, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("failed to serialize report"), err
+	}
+
+	slog.InfoContext(ctx, "report generated",
+		slog.String("format", string(format)),
+		slog.Int("runs", len(spec.Runs)),
+		slog.Bool("written", result.Path != ""),
+		slog.Bool("stored", result.Artifact != nil),
+	)
+
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
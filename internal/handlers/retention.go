@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/oleiade/k6-mcp/internal/retention"
+)
+
+// PurgeDataResult is the result of the purge_data tool.
+type PurgeDataResult struct {
+	retention.Report
+	// Policy is the retention policy the janitor applies in the background, and LastSweep
+	// the report of its latest sweep.
+	Policy    RetentionPolicy   `json:"policy"`
+	LastSweep *retention.Report `json:"last_sweep,omitempty"`
+}
+
+// RetentionPolicy describes a retention policy, with durations such as "720h0m0s". Empty
+// ages keep data.
+type RetentionPolicy struct {
+	MaxAge          string `json:"max_age,omitempty"`
+	HistoryMaxBytes int64  `json:"history_max_bytes,omitempty"`
+	WorkspaceMaxAge string `json:"workspace_max_age"`
+	Interval        string `json:"interval"`
+}
+
+// PurgeDataHandler removes stored data older than an age, on demand.
+type PurgeDataHandler struct {
+	janitor *retention.Janitor
+}
+
+var _ ToolHandler = &PurgeDataHandler{}
+
+func NewPurgeDataHandler(janitor *retention.Janitor) *PurgeDataHandler {
+	return &PurgeDataHandler{janitor: janitor}
+}
+
+func (h *PurgeDataHandler) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+
+	olderThanValue := request.GetString("older_than", "")
+	if olderThanValue == "" {
+		return mcp.NewToolResultError("Missing required parameter 'older_than'. Pass the age of the data to remove, e.g. '30d' or '36h'; '0' removes all of it."), nil
+	}
+	olderThan, err := retention.ParseAge(olderThanValue)
+	if err != nil {
+		return mcp.NewToolResultError("Invalid 'older_than': " + err.Error()), nil
+	}
+
+	opts := retention.PurgeOptions{OlderThan: olderThan, DryRun: request.GetBool("dry_run", false)}
+	if targetsValue, exists := args["targets"]; exists {
+		if err := decodeArg(targetsValue, &opts.Targets); err != nil {
+			return mcp.NewToolResultError("Invalid targets format: expected an array of targets. Example: [\"runs\", \"artifacts\"]"), nil
+		}
+	}
+
+	report, err := h.janitor.Purge(opts)
+	if err != nil {
+		return mcp.NewToolResultError("Invalid 'targets': " + err.Error()), nil
+	}
+	policy := h.janitor.Policy()
+	result := PurgeDataResult{
+		Report: report,
+		Policy: RetentionPolicy{
+			HistoryMaxBytes: policy.HistoryMaxBytes,
+			WorkspaceMaxAge: policy.WorkspaceMaxAge.String(),
+			Interval:        policy.Interval.String(),
+		},
+		LastSweep: h.janitor.Last(),
+	}
+	if policy.MaxAge > 0 {
+		result.Policy.MaxAge = policy.MaxAge.String()
+	}
+
+	slog.InfoContext(ctx, "data purged",
+		slog.Duration("older_than", olderThan),
+		slog.Bool("dry_run", opts.DryRun),
+		slog.Int("removed", report.Removed),
+		slog.Int64("bytes", report.Bytes),
+	)
+
+	resultJSON, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("failed to serialize purge report"), err
+	}
+
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
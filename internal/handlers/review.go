@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/oleiade/k6-mcp/internal/report"
+	"github.com/oleiade/k6-mcp/internal/scriptsource"
+	"github.com/oleiade/k6-mcp/internal/security"
+)
+
+// SecurityReviewHandler reviews the security risks of scripts, without executing them.
+type SecurityReviewHandler struct {
+	fetcher *scriptsource.Fetcher
+}
+
+var _ ToolHandler = &SecurityReviewHandler{}
+
+func NewSecurityReviewHandler(fetcher *scriptsource.Fetcher) *SecurityReviewHandler {
+	return &SecurityReviewHandler{fetcher: fetcher}
+}
+
+func (h *SecurityReviewHandler) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+
+	output := request.GetString("output_format", validationOutputJSON)
+	if output != validationOutputJSON && output != validationOutputSARIF {
+		return mcp.NewToolResultError(fmt.Sprintf("Parameter 'output_format' must be either %q or %q", validationOutputJSON, validationOutputSARIF)), nil
+	}
+
+	script, errMsg := resolveScript(ctx, args, h.fetcher)
+	if errMsg != "" {
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	review := security.ReviewScript(script)
+
+	slog.InfoContext(ctx, "script security reviewed",
+		slog.String("risk", review.Risk),
+		slog.Int("findings", len(review.Findings)),
+		slog.Int("external_hosts", review.ExternalHosts),
+	)
+
+	if output == validationOutputSARIF {
+		sarif, err := report.ReviewSARIF(review, request.GetString("script_path", ""))
+		if err != nil {
+			return mcp.NewToolResultError("failed to render SARIF report"), err
+		}
+		return mcp.NewToolResultText(sarif), nil
+	}
+
+	resultJSON, err := json.MarshalIndent(review, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("failed to serialize security review"), err
+	}
+
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
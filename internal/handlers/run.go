@@ -3,32 +3,158 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"math"
 	"strings"
+	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/oleiade/k6-mcp/internal/annotations"
+	"github.com/oleiade/k6-mcp/internal/artifacts"
+	"github.com/oleiade/k6-mcp/internal/auth"
+	"github.com/oleiade/k6-mcp/internal/baseline"
+	"github.com/oleiade/k6-mcp/internal/catalog"
+	"github.com/oleiade/k6-mcp/internal/continuation"
+	"github.com/oleiade/k6-mcp/internal/defaults"
+	"github.com/oleiade/k6-mcp/internal/history"
+	"github.com/oleiade/k6-mcp/internal/locale"
+	"github.com/oleiade/k6-mcp/internal/notify"
+	"github.com/oleiade/k6-mcp/internal/report"
 	"github.com/oleiade/k6-mcp/internal/runner"
+	"github.com/oleiade/k6-mcp/internal/scriptsource"
+	"github.com/oleiade/k6-mcp/internal/security"
 )
 
-type RunHandler struct{}
+const (
+	// runOutputJSON and runOutputJUnit are the output formats of the run tool.
+	runOutputJSON  = "json"
+	runOutputJUnit = "junit"
+
+	// runOutputSummaryOnly, runOutputStandard and runOutputFull are the output levels of
+	// the run tool: the summary and analysis only, with the output of k6 too, and with the
+	// raw metrics too.
+	runOutputSummaryOnly = "summary_only"
+	runOutputStandard    = "standard"
+	runOutputFull        = "full"
+)
+
+type RunHandler struct {
+	fetcher   *scriptsource.Fetcher
+	scripts   *history.Store
+	defaults  *defaults.Store
+	auth      *auth.Provider
+	artifacts *artifacts.Store
+	more      *continuation.Store
+	// annotations marks runs on Grafana dashboards, when configured.
+	annotations *annotations.Client
+	// dryRuns measures the request rates of scripts, to check runs against rate limits.
+	dryRuns *DryRuns
+	// tests resolves the registered tests runs are of, and baselines compares their runs
+	// to their baseline.
+	tests     *catalog.Store
+	baselines *baseline.Store
+}
 
-func NewRunHandler() *RunHandler {
-	return &RunHandler{}
+func NewRunHandler(fetcher *scriptsource.Fetcher, scripts *history.Store, defaults *defaults.Store, auth *auth.Provider, artifacts *artifacts.Store, more *continuation.Store, annotations *annotations.Client, dryRuns *DryRuns, tests *catalog.Store, baselines *baseline.Store) *RunHandler {
+	return &RunHandler{fetcher: fetcher, scripts: scripts, defaults: defaults, auth: auth, artifacts: artifacts, more: more, annotations: annotations, dryRuns: dryRuns, tests: tests, baselines: baselines}
+}
+
+// RunToolResult is the result of the run tool.
+type RunToolResult struct {
+	*runner.RunResult
+
+	// Script identifies the recorded revision of the script, when it is named.
+	Script *ScriptRevisionRef `json:"script,omitempty"`
+
+	// Test is the registered test the run is of, if any, and Baseline the comparison of the
+	// run to the baseline of the test, when it has one.
+	Test     *catalog.Test        `json:"test,omitempty"`
+	Baseline *baseline.Comparison `json:"baseline,omitempty"`
+
+	// Duplicates warns when the script is a near-duplicate of named scripts.
+	Duplicates *DuplicateWarning `json:"duplicates,omitempty"`
+
+	// DefaultsApplied lists the parameters completed with the session or project defaults.
+	DefaultsApplied []string `json:"defaults_applied,omitempty"`
+
+	// RunID identifies the record of the run in the run history.
+	RunID int `json:"run_id,omitempty"`
+
+	// Auth describes the token acquired with the auth profile of the run, if any.
+	Auth *AuthTokenRef `json:"auth,omitempty"`
+
+	// RateLimit checks the planned request rate of the run against the rate limit of its
+	// environment profile, if any.
+	RateLimit *RateLimitCheck `json:"rate_limit,omitempty"`
+
+	// Artifacts are the stored files of the run, such as its summary export, retrieved with
+	// get_artifact.
+	Artifacts []artifacts.Artifact `json:"artifacts,omitempty"`
+
+	// StdoutContinuation and StderrContinuation retrieve the remainder of the output of k6,
+	// when it was truncated, with get_more_output.
+	StdoutContinuation *continuation.Ref `json:"stdout_continuation,omitempty"`
+	StderrContinuation *continuation.Ref `json:"stderr_continuation,omitempty"`
+}
+
+// RunPreviewResult is the result of the run tool in preview mode.
+type RunPreviewResult struct {
+	*runner.RunPreview
+
+	// DefaultsApplied lists the parameters completed with the session or project defaults.
+	DefaultsApplied []string `json:"defaults_applied,omitempty"`
+
+	// RateLimit checks the planned request rate of the run against the rate limit of its
+	// environment profile, if any.
+	RateLimit *RateLimitCheck `json:"rate_limit,omitempty"`
 }
 
 func (r RunHandler) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	args := request.GetArguments()
 
-	// Extract script content from arguments
-	scriptValue, exists := args["script"]
-	if !exists {
-		return mcp.NewToolResultError("Missing required parameter 'script'. Please provide your k6 script content as a string. Tip: Use the 'validate' tool first to check your script before running."), nil
+	output := request.GetString("output_format", runOutputJSON)
+	if output != runOutputJSON && output != runOutputJUnit {
+		return mcp.NewToolResultError(fmt.Sprintf("Parameter 'output_format' must be either %q or %q", runOutputJSON, runOutputJUnit)), nil
+	}
+	level := request.GetString("output_level", runOutputStandard)
+	if level != runOutputSummaryOnly && level != runOutputStandard && level != runOutputFull {
+		return mcp.NewToolResultError(fmt.Sprintf("Parameter 'output_level' must be one of %q, %q or %q", runOutputSummaryOnly, runOutputStandard, runOutputFull)), nil
+	}
+
+	// Run the script of the registered test, its latest revision unless the script is given
+	test, args, errMsg := resolveTest(args, r.tests, r.scripts)
+	if errMsg != "" {
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	// Extract script content from arguments, fetching it if needed
+	script, errMsg := resolveScript(ctx, args, r.fetcher)
+	if errMsg != "" {
+		return mcp.NewToolResultError(errMsg + " " + locale.T("Tip: Use the 'validate' tool first to check your script before running.")), nil
+	}
+
+	// Browser runs take their VUs and duration from their scenarios, not the parameters
+	if ignored := browserIgnoredParameters(script, request.GetArguments()); len(ignored) > 0 {
+		return mcp.NewToolResultError(fmt.Sprintf("Parameters %s are not supported for browser scripts, whose scenarios set the VUs and duration of the run (at most %d VUs). Set them in the scenario instead, e.g. { executor: 'shared-iterations', vus: 1, iterations: 5, options: { browser: { type: 'chromium' } } }, and remove the parameters.",
+			strings.Join(ignored, ", "), runner.MaxBrowserVUs)), nil
 	}
 
-	script, ok := scriptValue.(string)
-	if !ok {
-		return mcp.NewToolResultError("Parameter 'script' must be a string containing your k6 script code. Received: " + fmt.Sprintf("%T", scriptValue)), nil
+	// Record the script revision when the script is named; previews record nothing
+	preview := request.GetBool("preview", false)
+	var revision *ScriptRevisionRef
+	if !preview {
+		revision, errMsg = recordScript(args, r.scripts, script, "run")
+		if errMsg != "" {
+			return mcp.NewToolResultError(errMsg), nil
+		}
+	}
+
+	// Complete the arguments with the session and project defaults
+	args, applied, runProfile, errMsg := applyRunDefaults(ctx, r.defaults, args)
+	if errMsg != "" {
+		return mcp.NewToolResultError(errMsg), nil
 	}
 
 	// Parse run options from arguments
@@ -46,15 +172,77 @@ func (r RunHandler) Handle(ctx context.Context, request mcp.CallToolRequest) (*m
 		}
 		return mcp.NewToolResultError(fmt.Sprintf("Invalid parameters: %v. Check parameter types and ranges.%s Use the 'search' tool with query 'run options' for more examples.", err, suggestionText)), nil
 	}
+	if test != nil {
+		options.TestName = test.Name
+	}
 
-	// Run the k6 test
+	// Acquire the token of the auth profile server-side, so that no secret goes through the conversation
+	var authRef *AuthTokenRef
+	if profile := request.GetString("auth_profile", ""); profile != "" {
+		if authRef, errMsg = applyAuthProfile(ctx, r.auth, profile, options, preview); errMsg != "" {
+			return mcp.NewToolResultError(errMsg), nil
+		}
+	}
+
+	// Check the planned request rate against the rate limit of the environment profile
+	var rateLimit *RateLimitCheck
+	if runProfile != nil && runProfile.RateLimit > 0 {
+		rateLimit, errMsg = guardRateLimit(ctx, r.dryRuns, script, options, runProfile.RateLimit, preview, request.GetBool("ignore_rate_limit", false))
+		if errMsg != "" {
+			return mcp.NewToolResultError(errMsg), nil
+		}
+	}
+
+	// Resolve what the run would execute, without executing it
+	if preview {
+		return previewRun(ctx, script, options, applied, rateLimit)
+	}
+
+	notify.Send(ctx, mcp.LoggingLevelInfo, notify.EventRunStarted, "k6 test run started", map[string]any{
+		"vus":        options.VUs,
+		"duration":   options.Duration,
+		"iterations": options.Iterations,
+		"stages":     len(options.Stages),
+	})
+
+	// Run the k6 test, marking its window on Grafana dashboards
+	startedAt := time.Now()
+	annotated := annotatedRun(revision, options)
+	annotationID := annotateRunStarted(ctx, r.annotations, startedAt, annotated)
 	result, runErr := runner.RunK6Test(ctx, script, options)
+	annotateRunFinished(ctx, r.annotations, annotationID, startedAt, annotated, result, runErr)
 	if runErr != nil {
 		// Return the run result even if there was an error; the result will contain details
+		var securityErr *security.Error
+		if errors.As(runErr, &securityErr) {
+			notify.Send(ctx, mcp.LoggingLevelWarning, notify.EventSecurityRejection, "Script rejected by security checks", map[string]any{
+				"tool":   "run",
+				"reason": securityErr.Message,
+			})
+		}
+	}
+	notifyRunFinished(ctx, result)
+	runID := recordRun(ctx, r.scripts, script, revision, test, options, startedAt, result, runErr)
+	stored := storeRunArtifacts(ctx, r.artifacts, runID, result)
+
+	// Report the thresholds and checks as JUnit XML, for CI systems
+	if output == runOutputJUnit {
+		junit, err := report.JUnit(result, options.TestName)
+		if err != nil {
+			return mcp.NewToolResultError("failed to render JUnit report"), err
+		}
+		return mcp.NewToolResultText(junit), nil
+	}
+
+	toolResult := RunToolResult{RunResult: result, Script: revision, Test: test, Baseline: compareToBaseline(ctx, r.baselines, test, result), Duplicates: findDuplicates(ctx, r.scripts, script, revision), DefaultsApplied: applied, RunID: runID, Auth: authRef, RateLimit: rateLimit, Artifacts: stored}
+	if result != nil {
+		applyOutputLevel(result, level)
+		result.Stdout, toolResult.StdoutContinuation = r.more.Truncate("stdout", result.Stdout)
+		result.Stderr, toolResult.StderrContinuation = r.more.Truncate("stderr", result.Stderr)
 	}
 
 	// Convert result to JSON for structured response
-	resultJSON, err := json.MarshalIndent(result, "", "  ")
+	resultJSON, err := json.MarshalIndent(toolResult, "", "  ")
 	if err != nil {
 		return mcp.NewToolResultError("failed to serialize run result"), err
 	}
@@ -68,6 +256,64 @@ func (r RunHandler) Handle(ctx context.Context, request mcp.CallToolRequest) (*m
 	return mcp.NewToolResultText(string(resultJSON)), nil
 }
 
+// applyOutputLevel leaves out of the result what the output level excludes: the raw
+// metrics, which the summary digests, below the full level, and the output of k6 at the
+// summary only level.
+func applyOutputLevel(result *runner.RunResult, level string) {
+	if level == runOutputFull {
+		return
+	}
+	result.Metrics = nil
+
+	if level == runOutputSummaryOnly {
+		result.Stdout = ""
+		result.Stderr = ""
+	}
+}
+
+// previewRun returns the preview of a run of the script with the options.
+func previewRun(ctx context.Context, script string, options *runner.RunOptions, applied []string, rateLimit *RateLimitCheck) (*mcp.CallToolResult, error) {
+	preview, err := runner.PreviewK6Test(script, options)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("The run would be rejected: %v", err)), nil
+	}
+
+	resultJSON, err := json.MarshalIndent(RunPreviewResult{RunPreview: preview, DefaultsApplied: applied, RateLimit: rateLimit}, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("failed to serialize run preview"), err
+	}
+
+	slog.InfoContext(ctx, "run previewed",
+		slog.Int("files", len(preview.Files)),
+		slog.Bool("browser", preview.Browser),
+	)
+
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// notifyRunFinished notifies the client of the outcome of a run.
+func notifyRunFinished(ctx context.Context, result *runner.RunResult) {
+	if result == nil {
+		return
+	}
+
+	level := mcp.LoggingLevelInfo
+	message := "k6 test run finished"
+	if !result.Success {
+		level = mcp.LoggingLevelWarning
+		message = "k6 test run failed"
+	}
+
+	notify.Send(ctx, level, notify.EventRunFinished, message, map[string]any{
+		"success":         result.Success,
+		"exit_code":       result.ExitCode,
+		"duration":        result.Duration,
+		"total_requests":  result.Summary.TotalRequests,
+		"failed_requests": result.Summary.FailedRequests,
+		"grade":           result.Analysis.Grade,
+	})
+}
+
 // parseRunOptions parses run options from the tool arguments.
 func parseRunOptions(args map[string]interface{}) (*runner.RunOptions, error) {
 	options := &runner.RunOptions{}
@@ -116,6 +362,104 @@ func parseRunOptions(args map[string]interface{}) (*runner.RunOptions, error) {
 		options.Stages = stages
 	}
 
+	// Parse companion files
+	if filesValue, exists := args["files"]; exists {
+		if err := decodeArg(filesValue, &options.Files); err != nil {
+			return nil, fmt.Errorf("invalid files format: %w. Example: {\"protos/hello.proto\": \"syntax = \\\"proto3\\\"; ...\", \"data/users.csv\": \"username\\nalice\"}", err)
+		}
+	}
+
+	// Parse environment variables
+	if envValue, exists := args["env"]; exists {
+		if err := decodeArg(envValue, &options.Env); err != nil {
+			return nil, fmt.Errorf("env must be an object of string values: %w. Example: {\"BASE_URL\": \"https://staging.example.com\"}", err)
+		}
+	}
+
+	// Parse thresholds
+	if thresholdsValue, exists := args["thresholds"]; exists {
+		if err := decodeArg(thresholdsValue, &options.Thresholds); err != nil {
+			return nil, fmt.Errorf("thresholds must be an object of threshold expression arrays: %w. Example: {\"http_req_duration\": [\"p(95)<500\"], \"http_req_failed\": [\"rate<0.01\"]}", err)
+		}
+	}
+
+	// Parse response debugging
+	if debugValue, exists := args["debug_responses"]; exists {
+		if debug, ok := debugValue.(bool); ok {
+			options.DebugResponses = debug
+		} else {
+			return nil, fmt.Errorf("debug_responses must be a boolean (received %T). Example: true", debugValue)
+		}
+	}
+
+	// Parse pacing
+	if pacingValue, exists := args["pacing"]; exists {
+		if pacing, ok := pacingValue.(float64); ok {
+			options.Pacing = pacing
+		} else {
+			return nil, fmt.Errorf("pacing must be a number of iterations per VU per minute (received %T). Example: 6", pacingValue)
+		}
+	}
+
+	// Parse the seed of reproducible runs
+	if seedValue, exists := args["seed"]; exists {
+		seed, ok := seedValue.(float64)
+		if !ok || seed != math.Trunc(seed) {
+			return nil, fmt.Errorf("seed must be an integer (received %v). Example: 42", seedValue)
+		}
+		n := int64(seed)
+		options.Seed = &n
+	}
+
+	// Parse the tags of the run's metrics, which the script name and environment complete
+	if tagsValue, exists := args["tags"]; exists {
+		if err := decodeArg(tagsValue, &options.Tags); err != nil {
+			return nil, fmt.Errorf("tags must be an object of string values: %w. Example: {\"team\": \"checkout\", \"release\": \"2.4.1\"}", err)
+		}
+	}
+	if environmentValue, exists := args["environment"]; exists {
+		environment, ok := environmentValue.(string)
+		if !ok {
+			return nil, fmt.Errorf("environment must be a string (received %T). Example: \"staging\"", environmentValue)
+		}
+		options.Environment = environment
+	}
+	if name, ok := args["script_name"].(string); ok {
+		options.TestName = name
+	}
+
+	// Parse client certificates
+	if certificatesValue, exists := args["client_certificates"]; exists {
+		if err := decodeArg(certificatesValue, &options.ClientCertificates); err != nil {
+			return nil, fmt.Errorf("client_certificates must be an array of {domains, cert, key} objects: %w. Example: [{\"domains\": [\"api.example.com\"], \"cert\": \"certs/client.crt\", \"key\": \"certs/client.key\"}]", err)
+		}
+	}
+
+	// Parse output presets
+	if outputsValue, exists := args["outputs"]; exists {
+		if err := decodeArg(outputsValue, &options.Outputs); err != nil {
+			return nil, fmt.Errorf("outputs must be an array of output presets: %w. Example: [{\"type\": \"datadog\", \"address\": \"localhost:8125\"}]", err)
+		}
+	}
+
+	// Parse target pre-checking
+	if precheckValue, exists := args["precheck"]; exists {
+		if precheck, ok := precheckValue.(bool); ok {
+			options.Precheck = precheck
+		} else {
+			return nil, fmt.Errorf("precheck must be a boolean (received %T). Example: true", precheckValue)
+		}
+	}
+
+	// Parse output saving
+	if saveOutputValue, exists := args["save_output"]; exists {
+		if saveOutput, ok := saveOutputValue.(bool); ok {
+			options.SaveOutput = saveOutput
+		} else {
+			return nil, fmt.Errorf("save_output must be a boolean (received %T). Example: true", saveOutputValue)
+		}
+	}
+
 	// Parse additional options
 	if optionsValue, exists := args["options"]; exists {
 		if opts, ok := optionsValue.(map[string]interface{}); ok {
@@ -128,6 +472,25 @@ func parseRunOptions(args map[string]interface{}) (*runner.RunOptions, error) {
 	return options, nil
 }
 
+// browserRunParameters are the run parameters browser runs ignore.
+var browserRunParameters = []string{"vus", "duration", "iterations", "stages"}
+
+// browserIgnoredParameters returns the run parameters of the arguments the run of the
+// script would ignore, when it is a browser script.
+func browserIgnoredParameters(script string, args map[string]any) []string {
+	if !runner.UsesBrowser(script) {
+		return nil
+	}
+
+	var ignored []string
+	for _, name := range browserRunParameters {
+		if args[name] != nil {
+			ignored = append(ignored, name)
+		}
+	}
+	return ignored
+}
+
 // applySmartDefaults applies context-aware defaults to run options
 func applySmartDefaults(options *runner.RunOptions, args map[string]interface{}) {
 	// Check if user specified any custom parameters
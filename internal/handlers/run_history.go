@@ -0,0 +1,264 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/oleiade/k6-mcp/internal/catalog"
+	"github.com/oleiade/k6-mcp/internal/history"
+	"github.com/oleiade/k6-mcp/internal/runner"
+	"github.com/oleiade/k6-mcp/internal/security"
+)
+
+// recordRun records a run in the history, and returns its ID, or 0 when the run was rejected
+// before k6 was started, or could not be recorded: recording failures don't fail runs. Runs
+// of registered tests are recorded under the name of the test.
+func recordRun(ctx context.Context, store *history.Store, script string, revision *ScriptRevisionRef, test *catalog.Test, options *runner.RunOptions, startedAt time.Time, result *runner.RunResult, runErr error) int {
+	if result == nil || rejectedRun(runErr) {
+		return 0
+	}
+
+	record := resultsRecord(result.Summary, result.Thresholds)
+	record.Source = "run_test"
+	record.ScriptHash = scriptHash(script)
+	record.Targets = runTargets(script, options.Env)
+	record.StartedAt = startedAt.UTC()
+	record.Duration = result.Duration
+	record.Success = result.Success
+	record.ExitCode = result.ExitCode
+	record.Grade = result.Analysis.Grade
+	record.Error = result.Error
+	record.VUs = options.VUs
+	record.Iterations = options.Iterations
+	record.Load = options.Duration
+	record.Seed = options.Seed
+	record.Environment = options.Environment
+	record.FailureClass = result.FailureClass
+	if revision != nil {
+		record.Script = revision.Name
+		record.Revision = revision.Revision
+	}
+	if test != nil {
+		record.Test = test.Name
+	}
+
+	if err := store.RecordRun(&record); err != nil {
+		slog.WarnContext(ctx, "failed to record run in history",
+			slog.String("error", err.Error()),
+		)
+		return 0
+	}
+
+	return record.ID
+}
+
+// resultsRecord returns a run record holding the results of a run summary and thresholds.
+func resultsRecord(summary runner.TestSummary, thresholds []runner.ThresholdOutcome) history.RunRecord {
+	record := history.RunRecord{
+		TotalRequests:   summary.TotalRequests,
+		FailedRequests:  summary.FailedRequests,
+		AvgResponseTime: summary.AvgResponseTime,
+		P95ResponseTime: summary.P95ResponseTime,
+		RequestRate:     summary.RequestRate,
+	}
+	if record.TotalRequests > 0 {
+		record.ErrorRate = float64(record.FailedRequests) / float64(record.TotalRequests)
+	}
+	for _, threshold := range thresholds {
+		if !threshold.Passed {
+			record.ThresholdsFailed++
+		}
+	}
+
+	return record
+}
+
+// scriptHash returns the hex-encoded SHA-256 of a script, as recorded in history.
+func scriptHash(script string) string {
+	sum := sha256.Sum256([]byte(script))
+	return hex.EncodeToString(sum[:])
+}
+
+// rejectedRun reports whether a run error rejected the run before k6 was started.
+func rejectedRun(err error) bool {
+	var securityErr *security.Error
+	var runErr *runner.RunError
+	switch {
+	case errors.As(err, &securityErr):
+		return true
+	case errors.As(err, &runErr):
+		return runErr.Type == "PARAMETER_VALIDATION" || runErr.Type == "INPUT_VALIDATION" || runErr.Type == "TARGET_UNREACHABLE"
+	}
+	return false
+}
+
+// runTargets returns the hosts a run targets: the hosts of the URL literals of the script,
+// and of the URL values of its environment variables, such as BASE_URL, sorted.
+func runTargets(script string, env map[string]string) []string {
+	hosts := make(map[string]bool)
+	for _, target := range security.Scan(script).Targets {
+		hosts[target.Host] = true
+	}
+	for _, value := range env {
+		if u, err := url.Parse(value); err == nil && u.Scheme != "" && u.Hostname() != "" {
+			hosts[strings.ToLower(u.Hostname())] = true
+		}
+	}
+
+	targets := make([]string, 0, len(hosts))
+	for host := range hosts {
+		targets = append(targets, host)
+	}
+	sort.Strings(targets)
+
+	return targets
+}
+
+// QueryRunHistoryResult is the result of the query_run_history tool.
+type QueryRunHistoryResult struct {
+	Runs []history.RunRecord `json:"runs"`
+	// Total counts the matching runs, of which Runs holds the most recent ones.
+	Total int `json:"total"`
+}
+
+// QueryRunHistoryHandler queries the recorded runs.
+type QueryRunHistoryHandler struct {
+	store *history.Store
+}
+
+var _ ToolHandler = &QueryRunHistoryHandler{}
+
+func NewQueryRunHistoryHandler(store *history.Store) *QueryRunHistoryHandler {
+	return &QueryRunHistoryHandler{store: store}
+}
+
+func (h *QueryRunHistoryHandler) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	query, errMsg := parseRunQuery(request)
+	if errMsg != "" {
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	runs, total, err := h.store.QueryRuns(query)
+	if err != nil {
+		return mcp.NewToolResultError("Failed to query run history; reason: " + err.Error()), nil
+	}
+
+	resultJSON, err := json.MarshalIndent(QueryRunHistoryResult{Runs: runs, Total: total}, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("failed to serialize run history"), err
+	}
+
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// HistoryStatsResult is the result of the history_stats tool.
+type HistoryStatsResult struct {
+	Scripts []history.RunStats `json:"scripts"`
+}
+
+// HistoryStatsHandler aggregates the recorded runs of named scripts into trends.
+type HistoryStatsHandler struct {
+	store *history.Store
+}
+
+var _ ToolHandler = &HistoryStatsHandler{}
+
+func NewHistoryStatsHandler(store *history.Store) *HistoryStatsHandler {
+	return &HistoryStatsHandler{store: store}
+}
+
+func (h *HistoryStatsHandler) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	query, errMsg := parseRunQuery(request)
+	if errMsg != "" {
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	stats, err := h.store.RunStats(query)
+	if err != nil {
+		return mcp.NewToolResultError("Failed to aggregate run history; reason: " + err.Error()), nil
+	}
+	if len(stats) == 0 {
+		return mcp.NewToolResultError("No recorded run of a named script or registered test matches. Runs are recorded when 'script_name' or 'test_name' is passed to the run tool."), nil
+	}
+
+	resultJSON, err := json.MarshalIndent(HistoryStatsResult{Scripts: stats}, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("failed to serialize run statistics"), err
+	}
+
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// parseRunQuery parses the run history filters of the tool arguments. It returns a
+// user-facing error message when a filter is invalid.
+func parseRunQuery(request mcp.CallToolRequest) (history.RunQuery, string) {
+	query := history.RunQuery{
+		Test:       request.GetString("test_name", ""),
+		Script:     request.GetString("script_name", ""),
+		ScriptHash: request.GetString("script_sha256", ""),
+		TargetHost: request.GetString("target_host", ""),
+		Limit:      request.GetInt("limit", 0),
+	}
+	if query.Limit < 0 || query.Limit > history.MaxRunQueryLimit {
+		return query, fmt.Sprintf("Parameter 'limit' must be between 1 and %d", history.MaxRunQueryLimit)
+	}
+
+	now := time.Now().UTC()
+	var err error
+	if since := request.GetString("since", ""); since != "" {
+		if query.Since, err = parseTimeBound(since, now, false); err != nil {
+			return query, fmt.Sprintf("Invalid 'since': %v", err)
+		}
+	}
+	if until := request.GetString("until", ""); until != "" {
+		if query.Until, err = parseTimeBound(until, now, true); err != nil {
+			return query, fmt.Sprintf("Invalid 'until': %v", err)
+		}
+	}
+
+	switch status := request.GetString("status", ""); status {
+	case "":
+	case "passed", "failed":
+		success := status == "passed"
+		query.Success = &success
+	default:
+		return query, "Parameter 'status' must be either \"passed\" or \"failed\""
+	}
+
+	return query, ""
+}
+
+// parseTimeBound parses a date range bound: an RFC 3339 time, a date, or a duration back
+// from now, such as '24h' or '7d'. Dates ending ranges include their whole day.
+func parseTimeBound(value string, now time.Time, end bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		if end {
+			t = t.Add(24*time.Hour - time.Nanosecond)
+		}
+		return t, nil
+	}
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+
+	return time.Time{}, fmt.Errorf("%q is not a time: expected an RFC 3339 time ('2025-06-01T12:00:00Z'), a date ('2025-06-01'), or a duration back from now ('24h', '7d')", value)
+}
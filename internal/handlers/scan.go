@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/oleiade/k6-mcp/internal/report"
+	"github.com/oleiade/k6-mcp/internal/scriptsource"
+	"github.com/oleiade/k6-mcp/internal/security"
+)
+
+// ScanScriptHandler runs the security checks of scripts, without executing them.
+type ScanScriptHandler struct {
+	fetcher *scriptsource.Fetcher
+}
+
+var _ ToolHandler = &ScanScriptHandler{}
+
+func NewScanScriptHandler(fetcher *scriptsource.Fetcher) *ScanScriptHandler {
+	return &ScanScriptHandler{fetcher: fetcher}
+}
+
+func (h *ScanScriptHandler) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+
+	output := request.GetString("output_format", validationOutputJSON)
+	if output != validationOutputJSON && output != validationOutputSARIF {
+		return mcp.NewToolResultError(fmt.Sprintf("Parameter 'output_format' must be either %q or %q", validationOutputJSON, validationOutputSARIF)), nil
+	}
+
+	script, errMsg := resolveScript(ctx, args, h.fetcher)
+	if errMsg != "" {
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	result := security.Scan(script)
+
+	slog.InfoContext(ctx, "script scanned",
+		slog.Bool("passed", result.Passed),
+		slog.Int("findings", len(result.Findings)),
+		slog.Int("targets", len(result.Targets)),
+	)
+
+	if output == validationOutputSARIF {
+		sarif, err := report.ScanSARIF(result, request.GetString("script_path", ""))
+		if err != nil {
+			return mcp.NewToolResultError("failed to render SARIF report"), err
+		}
+		return mcp.NewToolResultText(sarif), nil
+	}
+
+	resultJSON, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("failed to serialize scan result"), err
+	}
+
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/oleiade/k6-mcp/internal/locale"
+	"github.com/oleiade/k6-mcp/internal/scriptsource"
+)
+
+// resolveScript returns the script given inline through the 'script' argument, or
+// fetched from the 'script_url' argument. It returns a user-facing error message when
+// neither, or both, are provided, or when the script could not be fetched, in the
+// configured locale.
+func resolveScript(ctx context.Context, args map[string]interface{}, fetcher *scriptsource.Fetcher) (string, string) {
+	scriptValue, hasScript := args["script"]
+	urlValue, hasURL := args["script_url"]
+
+	switch {
+	case hasScript && hasURL:
+		return "", locale.T("Parameters 'script' and 'script_url' are mutually exclusive. Provide the script content or its URL, not both.")
+	case hasURL:
+		scriptURL, ok := urlValue.(string)
+		if !ok || scriptURL == "" {
+			return "", locale.T("Parameter 'script_url' must be a non-empty string. Example: 'https://raw.githubusercontent.com/org/repo/main/tests/load.js' or 'git+https://github.com/org/repo.git@main#tests/load.js'")
+		}
+		script, err := fetcher.Fetch(ctx, scriptURL)
+		if err != nil {
+			return "", locale.Sprintf("Failed to fetch script from 'script_url'; reason: %s", err)
+		}
+		return script, ""
+	case hasScript:
+		script, ok := scriptValue.(string)
+		if !ok {
+			return "", locale.Sprintf("Parameter 'script' must be a string containing your k6 script code. Received: %T", scriptValue)
+		}
+		return script, ""
+	default:
+		return "", locale.T("Missing required parameter 'script'. Please provide your k6 script content as a string, or its location through 'script_url'.")
+	}
+}
@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/oleiade/k6-mcp/internal/privacy"
+	"github.com/oleiade/k6-mcp/internal/security"
+	"github.com/oleiade/k6-mcp/internal/workspace"
+)
+
+// ScrubDataHandler masks the personal data of scripts and their companion files.
+type ScrubDataHandler struct{}
+
+var _ ToolHandler = &ScrubDataHandler{}
+
+func NewScrubDataHandler() *ScrubDataHandler {
+	return &ScrubDataHandler{}
+}
+
+// ScrubDataResult is the result of the scrub_data tool. It holds the masked content only,
+// never the values matched.
+type ScrubDataResult struct {
+	Script string            `json:"script,omitempty"`
+	Files  map[string]string `json:"files,omitempty"`
+	// Rules are the names of the rules applied, in order.
+	Rules    []string          `json:"rules"`
+	Findings []privacy.Finding `json:"findings"`
+	// ScrubbedFiles are the paths of the files in which data was masked.
+	ScrubbedFiles []string `json:"scrubbed_files,omitempty"`
+	Notes         []string `json:"notes,omitempty"`
+}
+
+func (h *ScrubDataHandler) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+	script := request.GetString("script", "")
+	if len(script) > security.MaxScriptSizeBytes {
+		return mcp.NewToolResultError(fmt.Sprintf("Parameter 'script' exceeds its maximum size of %d bytes", security.MaxScriptSizeBytes)), nil
+	}
+
+	var files map[string]string
+	if args["files"] != nil {
+		if err := decodeArg(args["files"], &files); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Invalid files format: %s. Example: {\"data/users.csv\": \"email,phone\\nalice@example.org,+1 415 555 2671\"}", err.Error())), nil
+		}
+	}
+	if len(files) > workspace.MaxFiles {
+		return mcp.NewToolResultError(fmt.Sprintf("Too many files (%d); at most %d files are supported", len(files), workspace.MaxFiles)), nil
+	}
+	totalSize := 0
+	for _, content := range files {
+		totalSize += len(content)
+	}
+	if totalSize > workspace.MaxFilesSizeBytes {
+		return mcp.NewToolResultError(fmt.Sprintf("The files exceed their maximum total size of %d bytes", workspace.MaxFilesSizeBytes)), nil
+	}
+	if script == "" && len(files) == 0 {
+		return mcp.NewToolResultError("Provide the content to scrub: a 'script', 'files', or both."), nil
+	}
+
+	rules := privacy.Current().Rules
+	if args["rules"] != nil {
+		var names []string
+		if err := decodeArg(args["rules"], &names); err != nil {
+			return mcp.NewToolResultError("Parameter 'rules' must be an array of rule names. Example: [\"email\", \"card\"]"), nil
+		}
+		selected, errMsg := selectRules(rules, names)
+		if errMsg != "" {
+			return mcp.NewToolResultError(errMsg), nil
+		}
+		rules = selected
+	}
+
+	// A single scrubber masks a value the same way in the script and every file, so that
+	// rows of data files still match the values the script expects
+	scrubber := privacy.NewScrubber(rules)
+	result := ScrubDataResult{
+		Script: scrubber.Scrub(script),
+		Rules:  make([]string, len(rules)),
+	}
+	for i, rule := range rules {
+		result.Rules[i] = rule.Name
+	}
+
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	if len(files) > 0 {
+		result.Files = make(map[string]string, len(files))
+	}
+	for _, path := range paths {
+		result.Files[path] = scrubber.Scrub(files[path])
+		if result.Files[path] != files[path] {
+			result.ScrubbedFiles = append(result.ScrubbedFiles, path)
+		}
+	}
+	result.Findings = scrubber.Findings()
+
+	if len(result.Findings) == 0 {
+		result.Notes = append(result.Notes, "No personal data was detected. Detection is pattern-based: review content for data of other kinds, such as names or addresses, and add rules for them to the scrubbing rules file")
+	} else {
+		result.Notes = append(result.Notes, "Use the scrubbed content in place of the original, e.g. in the files of run_k6_script; each distinct value is masked the same way everywhere, so lookups across the script and files keep matching")
+	}
+
+	slog.InfoContext(ctx, "Scrubbed data",
+		slog.Int("files", len(files)),
+		slog.Any("findings", result.Findings),
+	)
+
+	resultJSON, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("failed to serialize scrubbing result"), err
+	}
+
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// selectRules returns the rules of the names, in the order of rules, or a user-facing error
+// message naming the unknown ones.
+func selectRules(rules []*privacy.Rule, names []string) ([]*privacy.Rule, string) {
+	wanted := make(map[string]bool, len(names))
+	for _, name := range names {
+		wanted[strings.TrimSpace(name)] = true
+	}
+
+	selected := make([]*privacy.Rule, 0, len(names))
+	available := make([]string, 0, len(rules))
+	for _, rule := range rules {
+		available = append(available, rule.Name)
+		if wanted[rule.Name] {
+			selected = append(selected, rule)
+			delete(wanted, rule.Name)
+		}
+	}
+	if len(wanted) > 0 {
+		unknown := make([]string, 0, len(wanted))
+		for name := range wanted {
+			unknown = append(unknown, name)
+		}
+		sort.Strings(unknown)
+		return nil, fmt.Sprintf("Unknown rules: %s. Available rules: %s.", strings.Join(unknown, ", "), strings.Join(available, ", "))
+	}
+	if len(selected) == 0 {
+		return nil, "Parameter 'rules' must name at least one rule. Available rules: " + strings.Join(available, ", ") + "."
+	}
+
+	return selected, ""
+}
@@ -5,56 +5,90 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
-	"github.com/google/uuid"
 	"github.com/mark3labs/mcp-go/mcp"
-	"github.com/oleiade/k6-mcp/internal/logging"
+	"github.com/oleiade/k6-mcp/internal/buildinfo"
+	"github.com/oleiade/k6-mcp/internal/continuation"
+	"github.com/oleiade/k6-mcp/internal/notify"
 	"github.com/oleiade/k6-mcp/internal/search"
+	"strings"
 	"time"
 )
 
+const (
+	// StaleIndexAge is the age of the embedded documentation index, based on the build date,
+	// past which clients are told that search results may miss recent k6 features.
+	StaleIndexAge = 180 * 24 * time.Hour
+
+	// MaxSearchQueries is the maximum number of queries of a batched search.
+	MaxSearchQueries = 5
+)
+
 // FullTextSearchHandler Handlers aggregates all MCP tool handlers with their dependencies.
 type FullTextSearchHandler struct {
 	DB *sql.DB
+
+	searcher search.Search
+	more     *continuation.Store
+}
+
+// SearchResult is a result of the search tool.
+type SearchResult struct {
+	search.Result
+
+	// ContentContinuation retrieves the remainder of the content, when it was truncated,
+	// with get_more_output.
+	ContentContinuation *continuation.Ref `json:"content_continuation,omitempty"`
+}
+
+// SearchGroup holds the results of a query of a batched search, or the error it failed with.
+type SearchGroup struct {
+	Query   string         `json:"query"`
+	Results []SearchResult `json:"results"`
+	Error   string         `json:"error,omitempty"`
 }
 
 var _ ToolHandler = &FullTextSearchHandler{}
 
 // NewFullTextSearchHandler New returns a Handlers instance with provided dependencies.
-func NewFullTextSearchHandler(db *sql.DB) *FullTextSearchHandler {
-	return &FullTextSearchHandler{DB: db}
+func NewFullTextSearchHandler(db *sql.DB, more *continuation.Store) *FullTextSearchHandler {
+	return &FullTextSearchHandler{DB: db, searcher: search.NewFullTextSearcher(db), more: more}
 }
 
 // Handle HandleSearch handles the search tool requests.
 func (h *FullTextSearchHandler) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	// Add request correlation ID
-	requestID := uuid.New().String()
-	ctx = logging.ContextWithRequestID(ctx, requestID)
-	startTime := time.Now()
-
 	args := request.GetArguments()
 
-	// Log request start
-	logging.RequestStart(ctx, "search", args)
-
-	// Extract query from arguments
+	// Extract the query, or the queries of batched searches, from arguments
 	queryValue, exists := args["keywords"]
-	if !exists {
-		err := fmt.Errorf("missing required parameter: keywords")
-		logging.RequestEnd(ctx, "search", false, time.Since(startTime), err)
-		return mcp.NewToolResultError("Missing required parameter 'keywords'. Search for development workflow help: '\"script validation\"', '\"threshold setup\"', '\"HTTP patterns\"'. For troubleshooting: '\"debugging errors\"', '\"common issues\"'. For learning: '\"getting started\"', '\"examples\"', '\"best practices\"'."), nil
+	queriesValue, batched := args["queries"]
+	if exists && batched {
+		return mcp.NewToolResultError("Parameters 'keywords' and 'queries' are mutually exclusive. Use 'keywords' for a single query, or 'queries' for several related ones."), nil
 	}
 
-	query, ok := queryValue.(string)
-	if !ok {
-		err := fmt.Errorf("keywords parameter must be a string")
-		logging.RequestEnd(ctx, "search", false, time.Since(startTime), err)
-		return mcp.NewToolResultError("Parameter 'keywords' must be a string containing your search terms. Multi-word queries should be quoted. Received: " + fmt.Sprintf("%T", queryValue)), nil
-	}
+	var queries []string
+	if batched {
+		if err := decodeArg(queriesValue, &queries); err != nil || len(queries) == 0 || len(queries) > MaxSearchQueries {
+			return mcp.NewToolResultError(fmt.Sprintf("Parameter 'queries' must be an array of 1 to %d query strings. Example: [\"thresholds\", \"http batch\", \"ramping-arrival-rate\"]", MaxSearchQueries)), nil
+		}
+		for _, query := range queries {
+			if strings.TrimSpace(query) == "" {
+				return mcp.NewToolResultError("Queries cannot be empty strings."), nil
+			}
+		}
+	} else {
+		if !exists {
+			return mcp.NewToolResultError("Missing required parameter 'keywords'. Search for development workflow help: '\"script validation\"', '\"threshold setup\"', '\"HTTP patterns\"'. For troubleshooting: '\"debugging errors\"', '\"common issues\"'. For learning: '\"getting started\"', '\"examples\"', '\"best practices\"'."), nil
+		}
 
-	if query == "" {
-		err := fmt.Errorf("keywords parameter cannot be empty")
-		logging.RequestEnd(ctx, "search", false, time.Since(startTime), err)
-		return mcp.NewToolResultError("Keywords parameter cannot be empty. Search for development workflow help: '\"script validation\"', '\"threshold setup\"', '\"HTTP patterns\"'. For troubleshooting: '\"debugging errors\"', '\"common issues\"'. For learning: '\"getting started\"', '\"examples\"', '\"best practices\"'."), nil
+		query, ok := queryValue.(string)
+		if !ok {
+			return mcp.NewToolResultError("Parameter 'keywords' must be a string containing your search terms. Multi-word queries should be quoted. Received: " + fmt.Sprintf("%T", queryValue)), nil
+		}
+
+		if query == "" {
+			return mcp.NewToolResultError("Keywords parameter cannot be empty. Search for development workflow help: '\"script validation\"', '\"threshold setup\"', '\"HTTP patterns\"'. For troubleshooting: '\"debugging errors\"', '\"common issues\"'. For learning: '\"getting started\"', '\"examples\"', '\"best practices\"'."), nil
+		}
+		queries = []string{query}
 	}
 
 	// Parse search options
@@ -69,26 +103,100 @@ func (h *FullTextSearchHandler) Handle(ctx context.Context, request mcp.CallTool
 			}
 			options.MaxResults = maxResultsInt
 		} else {
-			err := fmt.Errorf("max_results must be a number")
-			logging.RequestEnd(ctx, "search", false, time.Since(startTime), err)
 			return mcp.NewToolResultError("Parameter 'max_results' must be a number between 1 and 20. Received: " + fmt.Sprintf("%T", maxResultsValue)), nil
 		}
 	}
 
-	results, err := search.NewFullTextSearcher(h.DB).Search(ctx, query, options)
-	if err != nil {
-		logging.RequestEnd(ctx, "search", false, time.Since(startTime), err)
-		return mcp.NewToolResultError(fmt.Sprintf("search failed: %v", err)), nil
+	// Search translated documentation when a language is requested
+	if language := request.GetString("language", ""); language != "" {
+		if _, err := search.LookupLanguage(language); err != nil {
+			return mcp.NewToolResultError("Invalid 'language': " + err.Error()), nil
+		}
+		options.Language = language
+	}
+
+	// Filter or boost the recently updated pages, to learn about new features
+	if updatedSince := request.GetString("updated_since", ""); updatedSince != "" {
+		since, err := time.Parse(time.DateOnly, updatedSince)
+		if err != nil {
+			return mcp.NewToolResultError("Parameter 'updated_since' must be a date formatted as YYYY-MM-DD. Example: '2025-01-31'"), nil
+		}
+		options.UpdatedSince = since
+	}
+	options.BoostRecent = request.GetBool("boost_recent", false)
+
+	notifyStaleIndex(ctx)
+
+	// Group the results of batched searches by query; a failing query doesn't fail the others
+	var response interface{}
+	if batched {
+		groups := make([]SearchGroup, len(queries))
+		for i, query := range queries {
+			groups[i].Query = query
+			results, err := h.search(ctx, query, options)
+			if err != nil {
+				groups[i].Results = []SearchResult{}
+				groups[i].Error = fmt.Sprintf("search failed: %v", err)
+				continue
+			}
+			groups[i].Results = results
+		}
+		response = groups
+	} else {
+		results, err := h.search(ctx, queries[0], options)
+		if err != nil && options.Language != "" {
+			indexed, _ := search.IndexedLanguages(ctx, h.DB)
+			return mcp.NewToolResultError(fmt.Sprintf("search failed: %v. The index has documentation in: %s.", err, strings.Join(indexed, ", "))), nil
+		}
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("search failed: %v", err)), nil
+		}
+		response = results
 	}
 
-	resultJSON, err := json.MarshalIndent(results, "", "  ")
+	resultJSON, err := json.MarshalIndent(response, "", "  ")
 	if err != nil {
-		logging.RequestEnd(ctx, "search", false, time.Since(startTime), err)
 		return mcp.NewToolResultError("failed to serialize search results"), err
 	}
 
-	// Log request completion
-	logging.RequestEnd(ctx, "search", true, time.Since(startTime), nil)
-
 	return mcp.NewToolResultText(string(resultJSON)), nil
 }
+
+// search runs the query, and truncates large pages, so that the results fit in the context
+// of clients.
+func (h *FullTextSearchHandler) search(ctx context.Context, query string, options search.Options) ([]SearchResult, error) {
+	results, err := h.searcher.Search(ctx, query, options)
+	if err != nil {
+		return nil, err
+	}
+
+	truncated := make([]SearchResult, len(results))
+	for i, result := range results {
+		truncated[i].Result = result
+		truncated[i].Content, truncated[i].ContentContinuation = h.more.Truncate(result.Path, result.Content)
+	}
+
+	return truncated, nil
+}
+
+// notifyStaleIndex notifies the client, once per session, when the documentation index is
+// older than StaleIndexAge.
+func notifyStaleIndex(ctx context.Context) {
+	built, err := time.Parse(time.RFC3339, buildinfo.Date)
+	if err != nil {
+		// Development builds have no build date
+		return
+	}
+
+	age := time.Since(built)
+	if age < StaleIndexAge {
+		return
+	}
+
+	notify.SendOnce(ctx, notify.EventIndexStale, mcp.LoggingLevelWarning, notify.EventIndexStale,
+		"The documentation index may be missing recent k6 features; update k6-mcp for fresher documentation",
+		map[string]any{
+			"built_at": buildinfo.Date,
+			"age_days": int(age.Hours() / 24),
+		})
+}
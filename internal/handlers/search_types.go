@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/oleiade/k6-mcp/internal/apiref"
+)
+
+// SearchTypesResult is the result of the search_types tool.
+type SearchTypesResult struct {
+	Query string `json:"query"`
+	// Total counts the matching declarations, of which Declarations holds the best ones.
+	Total        int                `json:"total"`
+	Declarations []apiref.TypeMatch `json:"declarations"`
+}
+
+// SearchTypesHandler searches the declarations of the embedded k6 type definitions.
+type SearchTypesHandler struct {
+	types *apiref.TypeIndex
+}
+
+var _ ToolHandler = &SearchTypesHandler{}
+
+func NewSearchTypesHandler(types *apiref.TypeIndex) *SearchTypesHandler {
+	return &SearchTypesHandler{types: types}
+}
+
+func (h *SearchTypesHandler) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	query := apiref.TypeQuery{
+		Text:   strings.TrimSpace(request.GetString("query", "")),
+		Module: request.GetString("module", ""),
+		Kind:   request.GetString("kind", ""),
+		Limit:  request.GetInt("max_results", apiref.DefaultTypeResults),
+	}
+	if query.Text == "" && query.Module == "" && query.Kind == "" {
+		return mcp.NewToolResultError("Provide a 'query', such as 'Counter add' or 'cookie jar', a 'module', such as 'k6/http', or a 'kind', such as 'interface'."), nil
+	}
+	if query.Limit <= 0 || query.Limit > apiref.MaxTypeResults {
+		return mcp.NewToolResultError(fmt.Sprintf("Parameter 'max_results' must be between 1 and %d", apiref.MaxTypeResults)), nil
+	}
+
+	matches, total := h.types.Search(query)
+
+	slog.InfoContext(ctx, "type definitions searched",
+		slog.String("query", query.Text),
+		slog.Int("matches", total),
+	)
+
+	resultJSON, err := json.MarshalIndent(SearchTypesResult{Query: query.Text, Total: total, Declarations: matches}, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("failed to serialize declarations"), err
+	}
+
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
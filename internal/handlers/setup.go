@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/oleiade/k6-mcp/internal/k6bin"
+	"github.com/oleiade/k6-mcp/internal/logging"
+)
+
+// SetupK6Handler downloads an official k6 release when k6 is not installed.
+type SetupK6Handler struct {
+	enabled bool
+}
+
+var _ ToolHandler = &SetupK6Handler{}
+
+// NewSetupK6Handler returns a SetupK6Handler. Downloads are refused unless enabled.
+func NewSetupK6Handler(enabled bool) *SetupK6Handler {
+	return &SetupK6Handler{enabled: enabled}
+}
+
+func (h *SetupK6Handler) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	if path, err := k6bin.Find(); err == nil {
+		return mcp.NewToolResultText("k6 is already available at " + path + "; no download is needed."), nil
+	}
+
+	if !h.enabled {
+		return mcp.NewToolResultError("Downloading k6 is disabled. Either install k6 (https://grafana.com/docs/k6/latest/set-up/install-k6/) and restart the server, or allow the server to download it by setting K6_MCP_K6_DOWNLOAD=true in its environment."), nil
+	}
+
+	result, err := k6bin.Install(ctx, request.GetString("version", "latest"))
+	if err != nil {
+		logging.Default().ErrorContext(ctx, "k6 installation error",
+			slog.String("error", err.Error()),
+		)
+		return mcp.NewToolResultError("Failed to install k6; reason: " + err.Error()), nil
+	}
+
+	logging.Default().InfoContext(ctx, "Installed k6",
+		slog.String("version", result.Version),
+		slog.String("path", result.Path),
+	)
+
+	resultJSON, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("failed to serialize installation result"), err
+	}
+
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/oleiade/k6-mcp/internal/buildinfo"
+	"github.com/oleiade/k6-mcp/internal/logging"
+	"github.com/oleiade/k6-mcp/internal/sandbox"
+)
+
+// States of the search index.
+const (
+	// IndexDisabled is the state of the index of servers without documentation tools.
+	IndexDisabled = "disabled"
+	// IndexLoading is the state of the index while it is written to disk, at startup:
+	// documentation tools wait for it.
+	IndexLoading = "loading"
+	IndexReady   = "ready"
+	// IndexFailed is the state of the index when it could not be written: documentation
+	// tools fail.
+	IndexFailed = "failed"
+)
+
+// IndexStatus is the status of the search index of the server.
+type IndexStatus struct {
+	State string `json:"state"`
+	Ready bool   `json:"ready"`
+	Error string `json:"error,omitempty"`
+	// LoadMs is the time it took to load the index, in milliseconds.
+	LoadMs int64 `json:"load_ms,omitempty"`
+}
+
+// ServerStatusResult is the result of the server_status tool.
+type ServerStatusResult struct {
+	Version   string      `json:"version"`
+	BuildDate string      `json:"build_date,omitempty"`
+	Index     IndexStatus `json:"index"`
+	// RunEnabled reports whether the tools executing load tests are enabled.
+	RunEnabled bool `json:"run_enabled"`
+	// LogLevel is the minimum level of the records the server logs.
+	LogLevel string `json:"log_level"`
+	// Sandbox is the backend of the sandbox k6 runs in.
+	Sandbox string `json:"sandbox"`
+}
+
+// ServerStatusHandler reports the version of the server and the state of its search index.
+type ServerStatusHandler struct {
+	index func() IndexStatus
+	run   bool
+}
+
+var _ ToolHandler = &ServerStatusHandler{}
+
+// NewServerStatusHandler returns a ServerStatusHandler reading the status of the index
+// from index, or reporting it disabled when index is nil.
+func NewServerStatusHandler(index func() IndexStatus, run bool) *ServerStatusHandler {
+	return &ServerStatusHandler{index: index, run: run}
+}
+
+func (h *ServerStatusHandler) Handle(_ context.Context, _ mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	result := ServerStatusResult{
+		Version:    buildinfo.Version,
+		BuildDate:  buildinfo.Date,
+		Index:      IndexStatus{State: IndexDisabled},
+		RunEnabled: h.run,
+		LogLevel:   levelName(logging.CurrentLevel()),
+		Sandbox:    sandbox.Default().Name(),
+	}
+	if h.index != nil {
+		result.Index = h.index()
+	}
+
+	resultJSON, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("failed to serialize server status"), err
+	}
+
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
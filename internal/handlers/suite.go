@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/oleiade/k6-mcp/internal/history"
+	"github.com/oleiade/k6-mcp/internal/notify"
+	"github.com/oleiade/k6-mcp/internal/runner"
+)
+
+// MaxSuiteScripts is the maximum number of scripts a suite can run.
+const MaxSuiteScripts = 10
+
+// SuiteStepResult is the outcome of one script of a suite, as returned by the run_suite tool.
+type SuiteStepResult struct {
+	history.SuiteStep
+
+	Summary *runner.TestSummary `json:"summary,omitempty"`
+	Issues  []runner.TestIssue  `json:"issues,omitempty"`
+}
+
+// RunSuiteResult is the result of the run_suite tool.
+type RunSuiteResult struct {
+	ID            int               `json:"id,omitempty"`
+	Suite         string            `json:"suite_name,omitempty"`
+	Success       bool              `json:"success"`
+	Stopped       bool              `json:"stopped_on_failure"`
+	Duration      string            `json:"duration"`
+	Passed        int               `json:"passed"`
+	Failed        int               `json:"failed"`
+	Skipped       int               `json:"skipped"`
+	Steps         []SuiteStepResult `json:"steps"`
+	RecordWarning string            `json:"record_warning,omitempty"`
+}
+
+// RunSuiteHandler runs an ordered list of named scripts as one suite.
+type RunSuiteHandler struct {
+	scripts *history.Store
+}
+
+var _ ToolHandler = &RunSuiteHandler{}
+
+func NewRunSuiteHandler(scripts *history.Store) *RunSuiteHandler {
+	return &RunSuiteHandler{scripts: scripts}
+}
+
+func (h *RunSuiteHandler) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+
+	var steps []map[string]interface{}
+	if err := decodeArg(args["scripts"], &steps); err != nil || len(steps) == 0 {
+		return mcp.NewToolResultError("Parameter 'scripts' must be a non-empty array of objects. Example: [{\"script_name\": \"smoke\"}, {\"script_name\": \"checkout-flow\", \"vus\": 10, \"duration\": \"1m\"}]"), nil
+	}
+	if len(steps) > MaxSuiteScripts {
+		return mcp.NewToolResultError(fmt.Sprintf("A suite can run at most %d scripts (received %d). Split it into several suites.", MaxSuiteScripts, len(steps))), nil
+	}
+
+	// Resolve every script and its options up front, so that a mistake in the last one
+	// doesn't surface after the first ones ran.
+	scripts := make([]string, len(steps))
+	options := make([]*runner.RunOptions, len(steps))
+	for i, step := range steps {
+		name, _ := step["script_name"].(string)
+		if name == "" {
+			return mcp.NewToolResultError(fmt.Sprintf("Script %d of the suite is missing 'script_name'. Record scripts by passing 'script_name' to the validation and run tools.", i+1)), nil
+		}
+
+		revisionNumber := 0
+		if revisionValue, exists := step["revision"]; exists {
+			revision, ok := revisionValue.(float64)
+			if !ok {
+				return mcp.NewToolResultError(fmt.Sprintf("Script %d of the suite: revision must be a number (received %T).", i+1, revisionValue)), nil
+			}
+			revisionNumber = int(revision)
+		}
+
+		rev, err := h.scripts.Get(name, revisionNumber)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Script %d of the suite: %v.", i+1, err)), nil
+		}
+		steps[i]["revision"] = float64(rev.Number)
+		scripts[i] = rev.Content
+
+		opts, err := parseRunOptions(step)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Script %d of the suite (%s): invalid parameters: %v.", i+1, name, err)), nil
+		}
+		options[i] = opts
+	}
+
+	stopOnFailure := request.GetBool("stop_on_failure", true)
+	suite := request.GetString("suite_name", "")
+
+	record := history.SuiteRun{
+		Suite:         suite,
+		StartedAt:     time.Now().UTC(),
+		Success:       true,
+		StopOnFailure: stopOnFailure,
+	}
+	result := RunSuiteResult{Suite: suite, Success: true}
+
+	for i, step := range steps {
+		stepResult := SuiteStepResult{SuiteStep: history.SuiteStep{
+			Script:   step["script_name"].(string),
+			Revision: int(step["revision"].(float64)),
+		}}
+
+		if result.Stopped {
+			stepResult.Skipped = true
+			result.Skipped++
+			result.Steps = append(result.Steps, stepResult)
+			record.Steps = append(record.Steps, stepResult.SuiteStep)
+			continue
+		}
+
+		notify.Send(ctx, mcp.LoggingLevelInfo, notify.EventRunStarted, "k6 suite script started", map[string]any{
+			"suite":    suite,
+			"script":   stepResult.Script,
+			"revision": stepResult.Revision,
+			"step":     i + 1,
+			"steps":    len(steps),
+		})
+
+		// A failing script is reported in its step, the suite carries on
+		runResult, _ := runner.RunK6Test(ctx, scripts[i], options[i])
+		notifyRunFinished(ctx, runResult)
+
+		if runResult != nil {
+			stepResult.Success = runResult.Success
+			stepResult.ExitCode = runResult.ExitCode
+			stepResult.Duration = runResult.Duration
+			stepResult.Grade = runResult.Analysis.Grade
+			stepResult.Error = runResult.Error
+			stepResult.TotalRequests = runResult.Summary.TotalRequests
+			stepResult.FailedRequests = runResult.Summary.FailedRequests
+			stepResult.P95ResponseTime = runResult.Summary.P95ResponseTime
+			stepResult.Summary = &runResult.Summary
+			stepResult.Issues = runResult.Issues
+		}
+
+		if stepResult.Success {
+			result.Passed++
+		} else {
+			result.Failed++
+			result.Success = false
+			result.Stopped = stopOnFailure && i < len(steps)-1
+		}
+
+		result.Steps = append(result.Steps, stepResult)
+		record.Steps = append(record.Steps, stepResult.SuiteStep)
+	}
+
+	duration := time.Since(record.StartedAt).String()
+	result.Duration = duration
+	record.Duration = duration
+	record.Success = result.Success
+
+	if err := h.scripts.RecordSuiteRun(&record); err != nil {
+		result.RecordWarning = "The suite run could not be recorded in history: " + err.Error()
+	} else {
+		result.ID = record.ID
+	}
+
+	resultJSON, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("failed to serialize suite result"), err
+	}
+
+	slog.InfoContext(ctx, "suite completed",
+		slog.String("suite", suite),
+		slog.Bool("success", result.Success),
+		slog.Int("passed", result.Passed),
+		slog.Int("failed", result.Failed),
+		slog.Int("skipped", result.Skipped),
+	)
+
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
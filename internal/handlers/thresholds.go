@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/oleiade/k6-mcp/internal/runner"
+	"github.com/oleiade/k6-mcp/internal/scriptsource"
+)
+
+// ValidateThresholdsResult is the result of the validate_thresholds tool.
+type ValidateThresholdsResult struct {
+	// Valid is false when k6 would reject a threshold.
+	Valid bool `json:"valid"`
+	// Thresholds are the checked thresholds, keyed by metric.
+	Thresholds map[string][]string `json:"thresholds"`
+	// CustomMetrics are the types of the custom metrics the thresholds were checked
+	// against, keyed by name.
+	CustomMetrics map[string]string       `json:"custom_metrics,omitempty"`
+	Issues        []runner.ThresholdIssue `json:"issues"`
+}
+
+// ValidateThresholdsHandler checks threshold expressions against the metrics they apply
+// to, without running k6.
+type ValidateThresholdsHandler struct {
+	fetcher *scriptsource.Fetcher
+}
+
+var _ ToolHandler = &ValidateThresholdsHandler{}
+
+func NewValidateThresholdsHandler(fetcher *scriptsource.Fetcher) *ValidateThresholdsHandler {
+	return &ValidateThresholdsHandler{fetcher: fetcher}
+}
+
+func (h *ValidateThresholdsHandler) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+
+	_, hasThresholds := args["thresholds"]
+	_, hasScript := args["script"]
+	_, hasURL := args["script_url"]
+	if !hasThresholds && !hasScript && !hasURL {
+		return mcp.NewToolResultError("Provide 'thresholds', e.g. {\"http_req_duration\": [\"p(95)<500\"]}, or a 'script' whose options define thresholds."), nil
+	}
+
+	result := ValidateThresholdsResult{Thresholds: map[string][]string{}, CustomMetrics: map[string]string{}}
+
+	// The script provides its thresholds, unless they are given, and its custom metrics
+	if hasScript || hasURL {
+		script, errMsg := resolveScript(ctx, args, h.fetcher)
+		if errMsg != "" {
+			return mcp.NewToolResultError(errMsg), nil
+		}
+		result.Thresholds, result.CustomMetrics = runner.ScriptThresholds(script)
+	}
+	if hasThresholds {
+		thresholds, errMsg := thresholdsArg(args["thresholds"])
+		if errMsg != "" {
+			return mcp.NewToolResultError(errMsg), nil
+		}
+		result.Thresholds = thresholds
+	}
+	if metricsValue, exists := args["custom_metrics"]; exists {
+		var metrics map[string]string
+		if err := decodeArg(metricsValue, &metrics); err != nil {
+			return mcp.NewToolResultError("Invalid custom_metrics format: expected the types of custom metrics keyed by name. Example: {\"login_time\": \"trend\", \"orders\": \"counter\"}"), nil
+		}
+		for name, metricType := range metrics {
+			switch metricType {
+			case runner.MetricCounter, runner.MetricGauge, runner.MetricRate, runner.MetricTrend:
+				result.CustomMetrics[name] = metricType
+			default:
+				return mcp.NewToolResultError(fmt.Sprintf("Invalid type %q of custom metric %s: use counter, gauge, rate or trend", metricType, name)), nil
+			}
+		}
+	}
+	if len(result.Thresholds) == 0 {
+		return mcp.NewToolResultError("No thresholds to validate: the script's options define none as string literals."), nil
+	}
+
+	result.Issues = runner.CheckThresholds(result.Thresholds, result.CustomMetrics)
+	if result.Issues == nil {
+		result.Issues = []runner.ThresholdIssue{}
+	}
+	result.Valid = true
+	for _, issue := range result.Issues {
+		if issue.Severity == runner.ThresholdIssueError {
+			result.Valid = false
+		}
+	}
+
+	slog.InfoContext(ctx, "thresholds validated",
+		slog.Int("metrics", len(result.Thresholds)),
+		slog.Int("issues", len(result.Issues)),
+		slog.Bool("valid", result.Valid),
+	)
+
+	resultJSON, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("failed to serialize threshold validation"), err
+	}
+
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// thresholdsArg decodes thresholds keyed by metric, whose values are an expression, or an
+// array of expressions or of objects holding their expression in their threshold field, as
+// in the options of scripts.
+func thresholdsArg(value interface{}) (map[string][]string, string) {
+	const formatMessage = "Invalid thresholds format: expected threshold expressions keyed by metric. Example: {\"http_req_duration\": [\"p(95)<500\"], \"http_req_failed\": [{\"threshold\": \"rate<0.01\", \"abortOnFail\": true}]}"
+
+	var raw map[string]json.RawMessage
+	if err := decodeArg(value, &raw); err != nil || len(raw) == 0 {
+		return nil, formatMessage
+	}
+
+	thresholds := make(map[string][]string, len(raw))
+	for metric, entry := range raw {
+		var expression string
+		if err := json.Unmarshal(entry, &expression); err == nil {
+			thresholds[metric] = []string{expression}
+			continue
+		}
+
+		var items []json.RawMessage
+		if err := json.Unmarshal(entry, &items); err != nil {
+			return nil, formatMessage
+		}
+		for _, item := range items {
+			var object struct {
+				Threshold string `json:"threshold"`
+			}
+			if err := json.Unmarshal(item, &expression); err == nil {
+				thresholds[metric] = append(thresholds[metric], expression)
+			} else if err := json.Unmarshal(item, &object); err == nil && object.Threshold != "" {
+				thresholds[metric] = append(thresholds[metric], object.Threshold)
+			} else {
+				return nil, formatMessage
+			}
+		}
+	}
+
+	return thresholds, ""
+}
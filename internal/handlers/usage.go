@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/oleiade/k6-mcp/internal/usage"
+)
+
+// UsageStatsURI is the URI of the resource serving the usage statistics of the tools.
+const UsageStatsURI = "stats://k6/usage"
+
+// UsageStatsHandler reports the usage statistics of the tools since the server started.
+type UsageStatsHandler struct{}
+
+var _ ToolHandler = &UsageStatsHandler{}
+
+func NewUsageStatsHandler() *UsageStatsHandler {
+	return &UsageStatsHandler{}
+}
+
+func (h *UsageStatsHandler) Handle(_ context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	stats := usage.Snapshot()
+
+	// Report a single tool, e.g. to follow the failures of one tool
+	if tool := request.GetString("tool", ""); tool != "" {
+		tools := stats.Tools
+		stats.Tools = []usage.ToolStats{}
+		for _, t := range tools {
+			if t.Tool == tool {
+				stats.Tools = append(stats.Tools, t)
+			}
+		}
+	}
+
+	resultJSON, err := json.MarshalIndent(stats, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("failed to serialize usage statistics"), err
+	}
+
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// UsageStatsResourceHandler serves the usage statistics of the tools as a resource.
+type UsageStatsResourceHandler struct{}
+
+var _ ResourceHandler = &UsageStatsResourceHandler{}
+
+func NewUsageStatsResourceHandler() *UsageStatsResourceHandler {
+	return &UsageStatsResourceHandler{}
+}
+
+func (h *UsageStatsResourceHandler) Handle(_ context.Context, _ mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	content, err := json.MarshalIndent(usage.Snapshot(), "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize usage statistics: %w", err)
+	}
+
+	return []mcp.ResourceContents{
+		mcp.TextResourceContents{
+			URI:      UsageStatsURI,
+			MIMEType: "application/json",
+			Text:     string(content),
+		},
+	}, nil
+}
@@ -3,69 +3,120 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"github.com/google/uuid"
 	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/oleiade/k6-mcp/internal/history"
 	"github.com/oleiade/k6-mcp/internal/logging"
+	"github.com/oleiade/k6-mcp/internal/notify"
+	"github.com/oleiade/k6-mcp/internal/report"
+	"github.com/oleiade/k6-mcp/internal/scriptsource"
+	"github.com/oleiade/k6-mcp/internal/security"
 	"github.com/oleiade/k6-mcp/internal/validator"
 	"log/slog"
-	"time"
 )
 
-type ValidationHandler struct{}
+const (
+	// validationOutputJSON and validationOutputSARIF are the output formats of the validation tool.
+	validationOutputJSON  = "json"
+	validationOutputSARIF = "sarif"
+)
 
-func NewValidationHandler() *ValidationHandler {
-	return &ValidationHandler{}
+type ValidationHandler struct {
+	fetcher *scriptsource.Fetcher
+	scripts *history.Store
 }
 
-func (v ValidationHandler) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	// Add request correlation ID
-	requestID := uuid.New().String()
-	ctx = logging.ContextWithRequestID(ctx, requestID)
-	startTime := time.Now()
+func NewValidationHandler(fetcher *scriptsource.Fetcher, scripts *history.Store) *ValidationHandler {
+	return &ValidationHandler{fetcher: fetcher, scripts: scripts}
+}
+
+// ValidationToolResult is the result of the validation tool.
+type ValidationToolResult struct {
+	*validator.ValidationResult
+
+	// Script identifies the recorded revision of the script, when it is named.
+	Script *ScriptRevisionRef `json:"script,omitempty"`
 
+	// Duplicates warns when the script is a near-duplicate of named scripts.
+	Duplicates *DuplicateWarning `json:"duplicates,omitempty"`
+}
+
+func (v ValidationHandler) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	args := request.GetArguments()
 
-	// Log request start
-	logging.RequestStart(ctx, "validate", args)
+	output := request.GetString("output_format", validationOutputJSON)
+	if output != validationOutputJSON && output != validationOutputSARIF {
+		return mcp.NewToolResultError(fmt.Sprintf("Parameter 'output_format' must be either %q or %q", validationOutputJSON, validationOutputSARIF)), nil
+	}
+	mode := request.GetString("mode", validator.ModeFull)
+	if mode != validator.ModeFull && mode != validator.ModeFast {
+		return mcp.NewToolResultError(fmt.Sprintf("Parameter 'mode' must be either %q or %q", validator.ModeFull, validator.ModeFast)), nil
+	}
 
-	// Extract script content from arguments
-	scriptValue, exists := args["script"]
-	if !exists {
-		err := fmt.Errorf("missing required parameter: script")
-		logging.RequestEnd(ctx, "validate", false, time.Since(startTime), err)
-		return mcp.NewToolResultError("Missing required parameter 'script'. Please provide your k6 script content as a string. Example: {\"script\": \"import http from 'k6/http'; export default function() { http.get('https://httpbin.org/get'); }\"}"), nil
+	// Extract script content from arguments, fetching it if needed
+	script, errMsg := resolveScript(ctx, args, v.fetcher)
+	if errMsg != "" {
+		return mcp.NewToolResultError(errMsg), nil
 	}
 
-	script, ok := scriptValue.(string)
-	if !ok {
-		err := fmt.Errorf("script parameter must be a string")
-		logging.RequestEnd(ctx, "validate", false, time.Since(startTime), err)
-		return mcp.NewToolResultError("Parameter 'script' must be a string containing your k6 script code. Received: " + fmt.Sprintf("%T", scriptValue)), nil
+	// Record the script revision when the script is named
+	revision, errMsg := recordScript(args, v.scripts, script, "validate")
+	if errMsg != "" {
+		return mcp.NewToolResultError(errMsg), nil
 	}
 
 	// Validate the k6 script
-	result, err := validator.ValidateK6Script(ctx, script)
+	validate := validator.ValidateK6Script
+	if mode == validator.ModeFast {
+		validate = validator.ValidateK6ScriptFast
+	}
+	result, err := validate(ctx, script)
 	if err != nil {
-		logging.WithContext(ctx).Error("Validation processing error",
+		logging.Default().ErrorContext(ctx, "Validation processing error",
 			slog.String("error", err.Error()),
 			slog.String("error_type", "validation_error"),
 		)
+		notifySecurityRejection(ctx, err)
 		// Return the validation result even if there was an error
 		// The result will contain error details for the client
 	}
 
+	// Report the issues as SARIF, for code scanning UIs
+	if output == validationOutputSARIF && result != nil {
+		sarif, err := report.SARIF(result, request.GetString("script_path", ""))
+		if err != nil {
+			return mcp.NewToolResultError("failed to render SARIF report"), err
+		}
+		return mcp.NewToolResultText(sarif), nil
+	}
+
 	// Convert result to JSON for structured response
-	resultJSON, err := json.MarshalIndent(result, "", "  ")
+	resultJSON, err := json.MarshalIndent(ValidationToolResult{ValidationResult: result, Script: revision, Duplicates: findDuplicates(ctx, v.scripts, script, revision)}, "", "  ")
 	if err != nil {
-		logging.RequestEnd(ctx, "validate", false, time.Since(startTime), err)
 		return mcp.NewToolResultError("failed to serialize validation result"), err
 	}
 
-	// Log request completion
-	success := result != nil && result.Valid
-	logging.RequestEnd(ctx, "validate", success, time.Since(startTime), nil)
-
 	// Return structured result
 	return mcp.NewToolResultText(string(resultJSON)), nil
 }
+
+// notifySecurityRejection notifies the client when the script was rejected by security checks.
+func notifySecurityRejection(ctx context.Context, err error) {
+	reason := ""
+	var securityErr *security.Error
+	var validationErr *validator.ValidationError
+	switch {
+	case errors.As(err, &securityErr):
+		reason = securityErr.Message
+	case errors.As(err, &validationErr) && validationErr.Type == "SECURITY_VALIDATION":
+		reason = validationErr.Message
+	default:
+		return
+	}
+
+	notify.Send(ctx, mcp.LoggingLevelWarning, notify.EventSecurityRejection, "Script rejected by security checks", map[string]any{
+		"tool":   "validate",
+		"reason": reason,
+	})
+}
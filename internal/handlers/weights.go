@@ -0,0 +1,352 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/oleiade/k6-mcp/internal/codegen"
+	"github.com/oleiade/k6-mcp/internal/style"
+)
+
+const (
+	// MaxWeightedScenarios is the maximum number of entries of a traffic distribution.
+	MaxWeightedScenarios = 50
+	// DefaultWeightedDuration is the default duration of weighted scenarios.
+	DefaultWeightedDuration = "5m"
+	// DefaultIterationDurationMs is the default duration of an iteration, in milliseconds,
+	// the VUs of weighted scenarios are allocated for.
+	DefaultIterationDurationMs = 1000.0
+
+	// maxRateError is the relative rounding error of a scenario rate beyond which the rate
+	// is expressed in a longer time unit.
+	maxRateError = 0.01
+	// vuHeadroom is the margin of the pre-allocated VUs over the VUs the rate needs.
+	vuHeadroom = 1.2
+)
+
+// endpointPattern matches the traffic entries naming an endpoint, such as GET /products.
+var endpointPattern = regexp.MustCompile(`(?i)^(GET|POST|PUT|PATCH|DELETE|HEAD|OPTIONS)\s+(\S+)$`)
+
+// scenarioNameUnsafePattern matches the runs of characters left out of scenario names.
+var scenarioNameUnsafePattern = regexp.MustCompile(`[^a-z0-9]+`)
+
+// rateUnit is a time unit of arrival rates.
+type rateUnit struct {
+	name     string
+	duration time.Duration
+}
+
+// rateUnits are the time units of arrival rates, from the shortest.
+var rateUnits = []rateUnit{
+	{"1s", time.Second},
+	{"1m", time.Minute},
+	{"1h", time.Hour},
+}
+
+// TrafficShare is an entry of a traffic distribution: an endpoint, such as GET /products,
+// or a user journey, such as checkout, with its share of the traffic.
+type TrafficShare struct {
+	Name  string  `json:"name"`
+	Share float64 `json:"share"`
+}
+
+// WeightedScenario is a scenario of the traffic distribution.
+type WeightedScenario struct {
+	// Scenario is the name of the scenario, and of the function it runs.
+	Scenario string `json:"scenario"`
+	Source   string `json:"source"`
+	// SharePercent is the share of the entry in the distribution, and ActualSharePercent
+	// the share of the rounded rate of the scenario.
+	SharePercent       float64 `json:"share_percent"`
+	ActualSharePercent float64 `json:"actual_share_percent"`
+	Rate               int     `json:"rate"`
+	TimeUnit           string  `json:"time_unit"`
+	RatePerSecond      float64 `json:"rate_per_second"`
+	PreAllocatedVUs    int     `json:"pre_allocated_vus"`
+	MaxVUs             int     `json:"max_vus"`
+	Endpoint           bool    `json:"endpoint"`
+}
+
+// WeightScenariosResult is the result of the generate_weighted_scenarios tool.
+type WeightScenariosResult struct {
+	Scenarios []WeightedScenario `json:"scenarios"`
+	// Options is the options statement of the scenarios, and Script the whole script when
+	// every entry is an endpoint and a base URL is given.
+	Options  string   `json:"options"`
+	Script   string   `json:"script,omitempty"`
+	Filename string   `json:"filename,omitempty"`
+	Notes    []string `json:"notes"`
+}
+
+// weightedLoad holds the parameters of the load shared among weighted scenarios.
+type weightedLoad struct {
+	totalRate   float64
+	timeUnit    time.Duration
+	duration    string
+	rampUp      string
+	iterationMs float64
+}
+
+// WeightScenariosHandler generates the scenarios of a load matching a production traffic
+// distribution, such as one exported from analytics.
+type WeightScenariosHandler struct{}
+
+var _ ToolHandler = &WeightScenariosHandler{}
+
+func NewWeightScenariosHandler() *WeightScenariosHandler {
+	return &WeightScenariosHandler{}
+}
+
+func (h *WeightScenariosHandler) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	traffic, errMsg := parseTraffic(request.GetArguments()["traffic"])
+	if errMsg != "" {
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	load := weightedLoad{
+		totalRate:   request.GetFloat("total_rate", 0),
+		duration:    request.GetString("duration", DefaultWeightedDuration),
+		rampUp:      request.GetString("ramp_up", ""),
+		iterationMs: request.GetFloat("iteration_duration_ms", DefaultIterationDurationMs),
+	}
+	if load.totalRate <= 0 {
+		return mcp.NewToolResultError("Missing or invalid 'total_rate' parameter: provide the total iterations per time unit of all scenarios, e.g. 120."), nil
+	}
+	if load.iterationMs <= 0 {
+		return mcp.NewToolResultError("iteration_duration_ms must be positive."), nil
+	}
+	unit := request.GetString("time_unit", "1s")
+	for _, candidate := range rateUnits {
+		if candidate.name == unit {
+			load.timeUnit = candidate.duration
+		}
+	}
+	if load.timeUnit == 0 {
+		return mcp.NewToolResultError("time_unit must be one of 1s, 1m or 1h."), nil
+	}
+	if d, err := time.ParseDuration(load.duration); err != nil || d <= 0 {
+		return mcp.NewToolResultError("duration must be a positive duration, e.g. '5m'."), nil
+	}
+	if d, err := time.ParseDuration(load.rampUp); load.rampUp != "" && (err != nil || d <= 0) {
+		return mcp.NewToolResultError("ramp_up must be a positive duration, e.g. '1m'."), nil
+	}
+
+	scenarios, script := weightScenarios(traffic, load)
+
+	scriptStyle := style.Current()
+	options, err := codegen.RenderOptions(script, scriptStyle)
+	if err != nil {
+		return mcp.NewToolResultError("Failed to generate the options; reason: " + err.Error()), nil
+	}
+
+	result := WeightScenariosResult{
+		Scenarios: scenarios,
+		Options:   options,
+		Notes: []string{
+			"Each scenario runs its own exported function, named after the scenario, at its share of the total rate with an arrival-rate executor, so that the mix holds whatever the response times",
+			fmt.Sprintf("VUs are allocated for iterations of %.0fms: raise iteration_duration_ms if k6 reports insufficient VUs", load.iterationMs),
+		},
+	}
+
+	baseURL := strings.TrimRight(request.GetString("base_url", ""), "/")
+	endpoints := true
+	for _, scenario := range scenarios {
+		endpoints = endpoints && scenario.Endpoint
+	}
+	switch {
+	case endpoints && baseURL != "":
+		script.BaseURL = baseURL
+		for i, entry := range traffic {
+			match := endpointPattern.FindStringSubmatch(entry.Name)
+			url := match[2]
+			if strings.HasPrefix(url, "/") {
+				url = baseURL + url
+			}
+			script.Scenarios[i].Flow = codegen.Flow{Groups: []codegen.Group{{Requests: []codegen.Request{{
+				Method: match[1],
+				URL:    url,
+				Checks: []codegen.Check{{Name: "status is 2xx", Condition: codegen.Raw("r.status >= 200 && r.status < 300")}},
+			}}}}}
+		}
+		script.Comment = "Scenarios weighted after the traffic distribution of production"
+		result.Script, err = codegen.Render(script, scriptStyle)
+		if err != nil {
+			return mcp.NewToolResultError("Failed to generate the script; reason: " + err.Error()), nil
+		}
+		result.Filename = "weighted-scenarios" + scriptStyle.Extension()
+		result.Notes = append(result.Notes, "Requests are sent without bodies: add the payloads of POST, PUT and PATCH requests, and replace the path parameters, such as {id}, with values")
+	case endpoints:
+		result.Notes = append(result.Notes, "Pass base_url to also get a script requesting each endpoint")
+	default:
+		result.Notes = append(result.Notes, "Export a function for each scenario, named after it, running its journey")
+	}
+	if scriptStyle.Language == style.LanguageTypeScript && scriptStyle.Modules == style.ModulesESM {
+		result.Notes = append(result.Notes, "The options are typed: import { Options } from 'k6/options'")
+	}
+
+	resultJSON, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal result: %w", err)
+	}
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// parseTraffic parses a traffic distribution, either an array of entries or an object of
+// shares by name, returning an error message when it is invalid. Shares are normalized to
+// add up to 1, so that they can be percentages, fractions or hit counts.
+func parseTraffic(value any) ([]TrafficShare, string) {
+	const example = "Example: [{\"name\": \"GET /products\", \"share\": 70}, {\"name\": \"checkout\", \"share\": 30}]"
+
+	var traffic []TrafficShare
+	if value == nil {
+		return nil, "Missing 'traffic' parameter. " + example
+	}
+	if shares, ok := value.(map[string]any); ok {
+		var byName map[string]float64
+		if err := decodeArg(shares, &byName); err != nil {
+			return nil, "Invalid 'traffic' parameter: shares must be numbers. " + example
+		}
+		for name, share := range byName {
+			traffic = append(traffic, TrafficShare{Name: name, Share: share})
+		}
+		// Objects have no order: sort by decreasing share
+		sort.Slice(traffic, func(i, j int) bool {
+			if traffic[i].Share != traffic[j].Share {
+				return traffic[i].Share > traffic[j].Share
+			}
+			return traffic[i].Name < traffic[j].Name
+		})
+	} else if err := decodeArg(value, &traffic); err != nil {
+		return nil, fmt.Sprintf("Invalid 'traffic' parameter: %s. %s", err.Error(), example)
+	}
+
+	if len(traffic) == 0 || len(traffic) > MaxWeightedScenarios {
+		return nil, fmt.Sprintf("'traffic' must have 1 to %d entries. %s", MaxWeightedScenarios, example)
+	}
+
+	var total float64
+	for i, entry := range traffic {
+		traffic[i].Name = strings.TrimSpace(entry.Name)
+		if traffic[i].Name == "" || entry.Share <= 0 {
+			return nil, fmt.Sprintf("Entry %d of 'traffic' must have a name and a positive share. %s", i+1, example)
+		}
+		total += entry.Share
+	}
+	for i := range traffic {
+		traffic[i].Share /= total
+	}
+
+	return traffic, ""
+}
+
+// weightScenarios returns the scenarios sharing the load after the traffic distribution,
+// with the script of their options. Scenarios have no flow.
+func weightScenarios(traffic []TrafficShare, load weightedLoad) ([]WeightedScenario, *codegen.Script) {
+	script := &codegen.Script{}
+	scenarios := make([]WeightedScenario, 0, len(traffic))
+	seen := make(map[string]bool)
+
+	totalPerSecond := load.totalRate / load.timeUnit.Seconds()
+	var roundedPerSecond []float64
+	var roundedTotal float64
+
+	for _, entry := range traffic {
+		name := scenarioName(entry.Name, seen)
+		perSecond := totalPerSecond * entry.Share
+		rate, unit := scenarioRate(perSecond, load.timeUnit)
+		actualPerSecond := float64(rate) / unit.duration.Seconds()
+		roundedPerSecond = append(roundedPerSecond, actualPerSecond)
+		roundedTotal += actualPerSecond
+
+		vus := max(int(math.Ceil(actualPerSecond*load.iterationMs/1000*vuHeadroom)), 1)
+		scenario := codegen.Scenario{
+			Name:            name,
+			Executor:        codegen.ExecutorConstantArrivalRate,
+			Rate:            rate,
+			TimeUnit:        unit.name,
+			Duration:        load.duration,
+			PreAllocatedVUs: vus,
+			MaxVUs:          vus * 2,
+		}
+		if load.rampUp != "" {
+			// Ramping arrival rates start from startRate, 0 by default, and take no rate
+			scenario.Executor = codegen.ExecutorRampingArrivalRate
+			scenario.Rate, scenario.Duration = 0, ""
+			scenario.Stages = []codegen.Stage{
+				{Duration: load.rampUp, Target: rate},
+				{Duration: load.duration, Target: rate},
+			}
+		}
+		script.Scenarios = append(script.Scenarios, scenario)
+
+		scenarios = append(scenarios, WeightedScenario{
+			Scenario:        name,
+			Source:          entry.Name,
+			SharePercent:    math.Round(entry.Share*10000) / 100,
+			Rate:            rate,
+			TimeUnit:        unit.name,
+			RatePerSecond:   math.Round(actualPerSecond*1000) / 1000,
+			PreAllocatedVUs: vus,
+			MaxVUs:          vus * 2,
+			Endpoint:        endpointPattern.MatchString(entry.Name),
+		})
+	}
+
+	for i := range scenarios {
+		if roundedTotal > 0 {
+			scenarios[i].ActualSharePercent = math.Round(roundedPerSecond[i]/roundedTotal*10000) / 100
+		}
+	}
+
+	return scenarios, script
+}
+
+// scenarioRate returns the integer rate of an arrival-rate scenario running perSecond
+// iterations per second, in the shortest time unit, from unit, rounding it by less than
+// maxRateError, or else in the longest one.
+func scenarioRate(perSecond float64, unit time.Duration) (int, rateUnit) {
+	var candidates []rateUnit
+	for _, candidate := range rateUnits {
+		if candidate.duration >= unit {
+			candidates = append(candidates, candidate)
+		}
+	}
+
+	for _, candidate := range candidates {
+		exact := perSecond * candidate.duration.Seconds()
+		rounded := math.Round(exact)
+		if rounded >= 1 && math.Abs(rounded-exact)/exact <= maxRateError {
+			return int(rounded), candidate
+		}
+	}
+
+	longest := candidates[len(candidates)-1]
+	return max(int(math.Round(perSecond*longest.duration.Seconds())), 1), longest
+}
+
+// scenarioName returns the name of the scenario of a traffic entry, a JavaScript
+// identifier unique among the seen ones, such as get_products for GET /products.
+func scenarioName(source string, seen map[string]bool) string {
+	base := strings.Trim(scenarioNameUnsafePattern.ReplaceAllString(strings.ToLower(source), "_"), "_")
+	if len(base) > 48 {
+		base = strings.TrimRight(base[:48], "_")
+	}
+	if base == "" || base == "default" || (base[0] >= '0' && base[0] <= '9') {
+		base = strings.TrimRight("scenario_"+base, "_")
+	}
+
+	name := base
+	for i := 2; seen[name]; i++ {
+		name = fmt.Sprintf("%s_%d", base, i)
+	}
+	seen[name] = true
+
+	return name
+}
@@ -0,0 +1,488 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/oleiade/k6-mcp/internal/history"
+	"github.com/oleiade/k6-mcp/internal/notify"
+	"github.com/oleiade/k6-mcp/internal/report"
+	"github.com/oleiade/k6-mcp/internal/runner"
+	"github.com/oleiade/k6-mcp/internal/scriptsource"
+	"github.com/oleiade/k6-mcp/internal/security"
+	"github.com/oleiade/k6-mcp/internal/validator"
+)
+
+// Stages of the workflow, in the order they run.
+const (
+	WorkflowLint     = "lint"
+	WorkflowValidate = "validate"
+	WorkflowSmoke    = "smoke"
+	WorkflowLoad     = "load"
+	WorkflowReport   = "report"
+)
+
+// workflowStages are the stages of the workflow, in the order they run.
+var workflowStages = []string{WorkflowLint, WorkflowValidate, WorkflowSmoke, WorkflowLoad, WorkflowReport}
+
+// Outcomes of the stages of a workflow.
+const (
+	stagePassed  = "passed"
+	stageFailed  = "failed"
+	stageSkipped = "skipped"
+)
+
+// Lint gates of the workflow.
+const (
+	// lintGateBlocking fails the lint stage on the findings that would make runs reject the script.
+	lintGateBlocking = "blocking"
+	// lintGateAny fails the lint stage on any finding.
+	lintGateAny = "any"
+	// lintGateOff reports the findings without failing the lint stage.
+	lintGateOff = "off"
+)
+
+const (
+	// DefaultSmokeDuration is the duration of the 1 VU smoke run of workflows.
+	DefaultSmokeDuration = "10s"
+	// DefaultWorkflowLoadVUs is the number of VUs of the load run of workflows.
+	DefaultWorkflowLoadVUs = 5
+	// MaxWorkflowLoadVUs is the maximum number of VUs of the load run of workflows, which
+	// only checks the script holds under a small load.
+	MaxWorkflowLoadVUs = 20
+	// DefaultWorkflowLoadDuration is the duration of the load run of workflows.
+	DefaultWorkflowLoadDuration = "30s"
+	// MaxWorkflowRunDuration is the maximum duration of the runs of workflows.
+	MaxWorkflowRunDuration = 5 * time.Minute
+)
+
+// WorkflowStage is the outcome of a stage of a workflow.
+type WorkflowStage struct {
+	Stage  string `json:"stage"`
+	Status string `json:"status"` // "passed", "failed" or "skipped"
+	// Gate describes what the stage must pass for the workflow to carry on.
+	Gate     string `json:"gate,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+	Duration string `json:"duration,omitempty"`
+
+	Findings   []security.Finding          `json:"findings,omitempty"`
+	Validation *WorkflowValidation         `json:"validation,omitempty"`
+	Run        *WorkflowRun                `json:"run,omitempty"`
+	Report     *GenerateReportResult       `json:"report,omitempty"`
+	Issues     []validator.ValidationIssue `json:"issues,omitempty"`
+}
+
+// WorkflowValidation is the digest of the validation of a workflow.
+type WorkflowValidation struct {
+	Valid    bool   `json:"valid"`
+	ExitCode int    `json:"exit_code"`
+	Error    string `json:"error,omitempty"`
+	Summary  string `json:"summary"`
+}
+
+// WorkflowRun is the digest of a run of a workflow.
+type WorkflowRun struct {
+	RunID     int                 `json:"run_id,omitempty"`
+	VUs       int                 `json:"vus"`
+	Duration  string              `json:"duration"`
+	Success   bool                `json:"success"`
+	ExitCode  int                 `json:"exit_code"`
+	Grade     string              `json:"grade,omitempty"`
+	Error     string              `json:"error,omitempty"`
+	ErrorRate float64             `json:"error_rate_percent"`
+	Summary   *runner.TestSummary `json:"summary,omitempty"`
+	Issues    []runner.TestIssue  `json:"issues,omitempty"`
+}
+
+// RunWorkflowResult is the result of the run_workflow tool.
+type RunWorkflowResult struct {
+	Passed bool `json:"passed"`
+	// StoppedAt is the stage whose gate failed, stopping the workflow.
+	StoppedAt string             `json:"stopped_at,omitempty"`
+	Summary   string             `json:"summary"`
+	Duration  string             `json:"duration"`
+	Stages    []WorkflowStage    `json:"stages"`
+	Script    *ScriptRevisionRef `json:"script,omitempty"`
+	NextSteps []string           `json:"next_steps"`
+}
+
+// workflowGates holds the gates between the stages of a workflow.
+type workflowGates struct {
+	lint         string
+	maxErrorRate float64
+	maxP95       float64
+}
+
+// RunWorkflowHandler runs the recommended pipeline of a script: lint, validate, a 1 VU
+// smoke run, a small load run and a report, stopping at the first stage failing its gate.
+type RunWorkflowHandler struct {
+	fetcher *scriptsource.Fetcher
+	scripts *history.Store
+}
+
+var _ ToolHandler = &RunWorkflowHandler{}
+
+func NewRunWorkflowHandler(fetcher *scriptsource.Fetcher, scripts *history.Store) *RunWorkflowHandler {
+	return &RunWorkflowHandler{fetcher: fetcher, scripts: scripts}
+}
+
+func (h *RunWorkflowHandler) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.GetArguments()
+
+	script, errMsg := resolveScript(ctx, args, h.fetcher)
+	if errMsg != "" {
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	selected, errMsg := workflowSelection(args)
+	if errMsg != "" {
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	gates := workflowGates{
+		lint:         request.GetString("lint_gate", lintGateBlocking),
+		maxErrorRate: request.GetFloat("max_error_rate_percent", DefaultMaxErrorRatePercent),
+		maxP95:       request.GetFloat("max_p95_ms", 0),
+	}
+	switch {
+	case gates.lint != lintGateBlocking && gates.lint != lintGateAny && gates.lint != lintGateOff:
+		return mcp.NewToolResultError(fmt.Sprintf("Parameter 'lint_gate' must be one of %q, %q or %q.", lintGateBlocking, lintGateAny, lintGateOff)), nil
+	case gates.maxErrorRate < 0 || gates.maxErrorRate > 100:
+		return mcp.NewToolResultError("max_error_rate_percent must be between 0 and 100."), nil
+	case gates.maxP95 < 0:
+		return mcp.NewToolResultError("max_p95_ms cannot be negative."), nil
+	}
+
+	format, err := report.ParseFormat(request.GetString("report_format", ""))
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	// Resolve the options of both runs up front, so that a mistake in the load options
+	// doesn't surface after the smoke run
+	smokeOptions, errMsg := workflowRunOptions(args, 1, request.GetString("smoke_duration", DefaultSmokeDuration), "smoke_duration")
+	if errMsg != "" {
+		return mcp.NewToolResultError(errMsg), nil
+	}
+	loadVUs := request.GetInt("load_vus", DefaultWorkflowLoadVUs)
+	if loadVUs < 1 || loadVUs > MaxWorkflowLoadVUs {
+		return mcp.NewToolResultError(fmt.Sprintf("load_vus must be between 1 and %d: the workflow only checks the script holds under a small load. Use run_k6_script or find_breaking_point for larger loads.", MaxWorkflowLoadVUs)), nil
+	}
+	loadOptions, errMsg := workflowRunOptions(args, loadVUs, request.GetString("load_duration", DefaultWorkflowLoadDuration), "load_duration")
+	if errMsg != "" {
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	revision, errMsg := recordScript(args, h.scripts, script, "run_workflow")
+	if errMsg != "" {
+		return mcp.NewToolResultError(errMsg), nil
+	}
+
+	startTime := time.Now()
+	result := RunWorkflowResult{Passed: true, Script: revision}
+	var runs []report.Run
+
+	for _, name := range workflowStages {
+		stage := WorkflowStage{Stage: name, Status: stageSkipped}
+		switch {
+		case !selected[name]:
+			stage.Reason = "not requested"
+		case name == WorkflowReport:
+			// The report covers the runs made, including the one that stopped the workflow
+			h.reportStage(&stage, runs, format, request.GetString("title", ""))
+		case result.StoppedAt != "":
+			stage.Reason = fmt.Sprintf("the %s stage failed its gate", result.StoppedAt)
+		default:
+			stageStart := time.Now()
+			switch name {
+			case WorkflowLint:
+				lintStage(&stage, script, gates)
+			case WorkflowValidate:
+				validateStage(ctx, &stage, script)
+			case WorkflowSmoke, WorkflowLoad:
+				options := smokeOptions
+				if name == WorkflowLoad {
+					options = loadOptions
+				}
+				if run := h.runStage(ctx, &stage, script, revision, options, gates); run != nil {
+					runs = append(runs, report.Run{Label: workflowRunLabel(name, options), Result: *run})
+				}
+			}
+			stage.Duration = time.Since(stageStart).String()
+		}
+
+		if stage.Status == stageFailed && result.StoppedAt == "" {
+			result.Passed = false
+			result.StoppedAt = name
+		}
+		result.Stages = append(result.Stages, stage)
+	}
+
+	result.Duration = time.Since(startTime).String()
+	result.Summary = workflowSummary(&result)
+	result.NextSteps = workflowNextSteps(&result)
+
+	resultJSON, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("failed to serialize workflow result"), err
+	}
+
+	slog.InfoContext(ctx, "workflow completed",
+		slog.Bool("passed", result.Passed),
+		slog.String("stopped_at", result.StoppedAt),
+		slog.Int("runs", len(runs)),
+	)
+
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// workflowSelection returns the stages to run, all of them unless 'stages' lists some, or
+// a user-facing error message.
+func workflowSelection(args map[string]interface{}) (map[string]bool, string) {
+	selected := make(map[string]bool, len(workflowStages))
+	stagesValue, exists := args["stages"]
+	if !exists {
+		for _, name := range workflowStages {
+			selected[name] = true
+		}
+		return selected, ""
+	}
+
+	var names []string
+	if err := decodeArg(stagesValue, &names); err != nil || len(names) == 0 {
+		return nil, fmt.Sprintf("Parameter 'stages' must be a non-empty array of stages among %s. Example: [\"lint\", \"validate\", \"smoke\"]", strings.Join(workflowStages, ", "))
+	}
+	for _, name := range names {
+		known := false
+		for _, stage := range workflowStages {
+			known = known || stage == name
+		}
+		if !known {
+			return nil, fmt.Sprintf("Unknown workflow stage %q: expected stages among %s.", name, strings.Join(workflowStages, ", "))
+		}
+		selected[name] = true
+	}
+
+	return selected, ""
+}
+
+// workflowRunOptions returns the options of a run of the workflow, with the companion files
+// and environment variables of the arguments, or a user-facing error message.
+func workflowRunOptions(args map[string]interface{}, vus int, duration, durationParam string) (*runner.RunOptions, string) {
+	if parsed, err := time.ParseDuration(duration); err != nil || parsed <= 0 || parsed > MaxWorkflowRunDuration {
+		return nil, fmt.Sprintf("%s must be a valid duration of at most %v, e.g. '30s'.", durationParam, MaxWorkflowRunDuration)
+	}
+
+	runArgs := map[string]interface{}{"vus": float64(vus), "duration": duration}
+	for _, key := range []string{"files", "env"} {
+		if value, exists := args[key]; exists {
+			runArgs[key] = value
+		}
+	}
+	options, err := parseRunOptions(runArgs)
+	if err != nil {
+		return nil, fmt.Sprintf("Invalid parameters: %v.", err)
+	}
+
+	return options, ""
+}
+
+// lintStage runs the security checks of the script, failing on the findings of the gate.
+func lintStage(stage *WorkflowStage, script string, gates workflowGates) {
+	scan := security.Scan(script)
+	stage.Findings = scan.Findings
+
+	var failing int
+	switch gates.lint {
+	case lintGateBlocking:
+		stage.Gate = "no blocking security finding"
+		for _, finding := range scan.Findings {
+			if finding.Blocking {
+				failing++
+			}
+		}
+	case lintGateAny:
+		stage.Gate = "no security finding"
+		failing = len(scan.Findings)
+	default:
+		stage.Gate = "none: findings are reported only"
+	}
+
+	stage.Status = stagePassed
+	if failing > 0 {
+		stage.Status = stageFailed
+		stage.Reason = fmt.Sprintf("%d security findings fail the gate", failing)
+	}
+}
+
+// validateStage validates the script with a single iteration.
+func validateStage(ctx context.Context, stage *WorkflowStage, script string) {
+	stage.Gate = "the script runs a single iteration without errors"
+
+	result, err := validator.ValidateK6Script(ctx, script)
+	if result == nil {
+		stage.Status = stageFailed
+		stage.Reason = "the script could not be validated: " + err.Error()
+		return
+	}
+
+	stage.Validation = &WorkflowValidation{
+		Valid:    result.Valid,
+		ExitCode: result.ExitCode,
+		Error:    result.Error,
+		Summary:  result.Summary.Description,
+	}
+	stage.Issues = result.Issues
+	stage.Status = stagePassed
+	if !result.Valid {
+		stage.Status = stageFailed
+		stage.Reason = "the script fails validation: " + result.Summary.Description
+	}
+}
+
+// runStage runs the script with the options, recording the run in history, and checks it
+// against the gates. It returns the result of the run, if it produced one.
+func (h *RunWorkflowHandler) runStage(ctx context.Context, stage *WorkflowStage, script string, revision *ScriptRevisionRef, options *runner.RunOptions, gates workflowGates) *runner.RunResult {
+	stage.Gate = fmt.Sprintf("the run succeeds, without crossed thresholds, with an error rate of at most %v%%", gates.maxErrorRate)
+	if stage.Stage == WorkflowLoad && gates.maxP95 > 0 {
+		stage.Gate += fmt.Sprintf(" and a p95 response time of at most %vms", gates.maxP95)
+	}
+
+	notify.Send(ctx, mcp.LoggingLevelInfo, notify.EventRunStarted, "k6 workflow run started", map[string]any{
+		"stage":    stage.Stage,
+		"vus":      options.VUs,
+		"duration": options.Duration,
+	})
+
+	startedAt := time.Now()
+	runOptions := *options
+	result, runErr := runner.RunK6Test(ctx, script, &runOptions)
+	notifyRunFinished(ctx, result)
+	runID := recordRun(ctx, h.scripts, script, revision, nil, &runOptions, startedAt, result, runErr)
+
+	stage.Status = stageFailed
+	if result == nil {
+		stage.Reason = "the run produced no result"
+		if runErr != nil {
+			stage.Reason += ": " + runErr.Error()
+		}
+		return nil
+	}
+
+	run := &WorkflowRun{
+		RunID:    runID,
+		VUs:      options.VUs,
+		Duration: result.Duration,
+		Success:  result.Success,
+		ExitCode: result.ExitCode,
+		Grade:    result.Analysis.Grade,
+		Error:    result.Error,
+		Summary:  &result.Summary,
+		Issues:   result.Issues,
+	}
+	if result.Summary.TotalRequests > 0 {
+		run.ErrorRate = float64(result.Summary.FailedRequests) / float64(result.Summary.TotalRequests) * 100
+	}
+	stage.Run = run
+
+	switch {
+	case result.ExitCode == k6ThresholdsExitCode:
+		stage.Reason = "the script's thresholds were crossed"
+	case !result.Success:
+		stage.Reason = "the run failed: " + result.Error
+	case run.ErrorRate > gates.maxErrorRate:
+		stage.Reason = fmt.Sprintf("error rate %.2f%% exceeds %.2f%%", run.ErrorRate, gates.maxErrorRate)
+	case stage.Stage == WorkflowLoad && gates.maxP95 > 0 && result.Summary.P95ResponseTime > gates.maxP95:
+		stage.Reason = fmt.Sprintf("p95 response time %.1fms exceeds %.1fms", result.Summary.P95ResponseTime, gates.maxP95)
+	default:
+		stage.Status = stagePassed
+	}
+
+	return result
+}
+
+// workflowRunLabel labels a run of the workflow in its report, e.g. "load (5 VUs, 30s)".
+func workflowRunLabel(stage string, options *runner.RunOptions) string {
+	vus := fmt.Sprintf("%d VUs", options.VUs)
+	if options.VUs == 1 {
+		vus = "1 VU"
+	}
+	return fmt.Sprintf("%s (%s, %s)", stage, vus, options.Duration)
+}
+
+// reportStage renders the report of the runs of the workflow. It has no gate.
+func (h *RunWorkflowHandler) reportStage(stage *WorkflowStage, runs []report.Run, format report.Format, title string) {
+	if len(runs) == 0 {
+		stage.Reason = "no run to report on"
+		return
+	}
+	if title == "" {
+		title = "k6 workflow report"
+	}
+
+	content, err := report.Generate(&report.Spec{Title: title, Runs: runs}, format)
+	if err != nil {
+		stage.Status = stageFailed
+		stage.Reason = "failed to generate the report: " + err.Error()
+		return
+	}
+
+	stage.Status = stagePassed
+	stage.Report = &GenerateReportResult{Format: format, Report: content}
+}
+
+// workflowSummary summarizes the outcome of the workflow in a sentence.
+func workflowSummary(result *RunWorkflowResult) string {
+	var passed []string
+	for _, stage := range result.Stages {
+		if stage.Status == stagePassed && stage.Stage != WorkflowReport {
+			passed = append(passed, stage.Stage)
+		}
+	}
+
+	if result.Passed {
+		if len(passed) == 0 {
+			return "The workflow ran no gated stage."
+		}
+		return fmt.Sprintf("The workflow passed: %s.", strings.Join(passed, " → "))
+	}
+
+	for _, stage := range result.Stages {
+		if stage.Stage == result.StoppedAt {
+			summary := fmt.Sprintf("The workflow stopped at the %s stage: %s.", stage.Stage, stage.Reason)
+			if len(passed) > 0 {
+				summary += fmt.Sprintf(" Passed: %s.", strings.Join(passed, " → "))
+			}
+			return summary
+		}
+	}
+
+	return "The workflow failed."
+}
+
+// workflowNextSteps suggests what to do after the workflow.
+func workflowNextSteps(result *RunWorkflowResult) []string {
+	switch result.StoppedAt {
+	case "":
+		return []string{
+			"The script is ready for larger loads: use run_k6_script with the target load, or find_breaking_point to estimate the capacity of the system",
+			"Set the load run as the baseline of a named test with set_baseline, to catch regressions",
+		}
+	case WorkflowLint:
+		return []string{"Fix the security findings, or run scan_script for their suggestions, then run the workflow again"}
+	case WorkflowValidate:
+		return []string{"Fix the issues of the validation, using validate_k6_script with mode 'fast' to iterate quickly, then run the workflow again"}
+	case WorkflowSmoke:
+		return []string{"The script fails with a single VU: check its requests, checks and thresholds against the target, then run the workflow again"}
+	default:
+		return []string{
+			"The script holds with a single VU but not under load: compare the smoke and load runs in the report",
+			"Check the capacity of the target, or raise the gates if the failures are expected under load",
+		}
+	}
+}
@@ -0,0 +1,276 @@
+package history
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// diffContextLines is the number of unchanged lines shown around changes.
+	diffContextLines = 3
+	// maxEditDistance bounds the work spent diffing; beyond it, the changed region is
+	// reported as replaced as a whole.
+	maxEditDistance = 1000
+)
+
+// opKind is the kind of a diff operation.
+type opKind int
+
+const (
+	opEqual opKind = iota
+	opDelete
+	opInsert
+)
+
+// diffOp is a line-level diff operation. For deletions and equal lines, line is the line
+// of the old text; for insertions, the line of the new text.
+type diffOp struct {
+	kind opKind
+	line string
+}
+
+// DiffStat counts the lines added and removed between two texts.
+type DiffStat struct {
+	Added   int `json:"lines_added"`
+	Removed int `json:"lines_removed"`
+}
+
+// Unified returns the unified diff turning a into b, labelled with the given names, and its stats.
+// The diff is empty when the texts are equal.
+func Unified(aName, bName, a, b string) (string, DiffStat) {
+	ops := diffLines(splitLines(a), splitLines(b))
+
+	var stat DiffStat
+	for _, op := range ops {
+		switch op.kind {
+		case opInsert:
+			stat.Added++
+		case opDelete:
+			stat.Removed++
+		}
+	}
+	if stat.Added == 0 && stat.Removed == 0 {
+		return "", stat
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", aName, bName)
+	for _, h := range hunks(ops) {
+		writeHunk(&out, ops, h)
+	}
+
+	return out.String(), stat
+}
+
+// Stat returns the diff stats between two texts.
+func Stat(a, b string) DiffStat {
+	_, stat := Unified("", "", a, b)
+	return stat
+}
+
+// splitLines splits a text into lines, without their line terminators.
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines computes the shortest edit script between a and b with Myers' algorithm,
+// after trimming their common prefix and suffix.
+func diffLines(a, b []string) []diffOp {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	ops := make([]diffOp, 0, len(a)+len(b))
+	for _, line := range a[:prefix] {
+		ops = append(ops, diffOp{kind: opEqual, line: line})
+	}
+	ops = append(ops, myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, line := range a[len(a)-suffix:] {
+		ops = append(ops, diffOp{kind: opEqual, line: line})
+	}
+
+	return ops
+}
+
+// myers returns the shortest edit script between a and b. The furthest reaching x of each
+// diagonal k is kept for every edit distance d, in trace[d][k+d], to backtrack the path.
+func myers(a, b []string) []diffOp {
+	n, m := len(a), len(b)
+	if n == 0 || m == 0 {
+		return replaceAll(a, b)
+	}
+
+	var trace [][]int
+	prev := func(d, k int) int {
+		if d == 0 {
+			return 0
+		}
+		return trace[d-1][k+d-1]
+	}
+
+	for d := 0; d <= n+m; d++ {
+		if d > maxEditDistance {
+			return replaceAll(a, b)
+		}
+
+		v := make([]int, 2*d+1)
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && prev(d, k-1) < prev(d, k+1)) {
+				x = prev(d, k+1)
+			} else {
+				x = prev(d, k-1) + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[k+d] = x
+
+			if x >= n && y >= m {
+				trace = append(trace, v)
+				return backtrack(a, b, trace)
+			}
+		}
+		trace = append(trace, v)
+	}
+
+	return replaceAll(a, b)
+}
+
+// backtrack rebuilds the edit script from the trace of Myers' algorithm.
+func backtrack(a, b []string, trace [][]int) []diffOp {
+	var reversed []diffOp
+	x, y := len(a), len(b)
+
+	for d := len(trace) - 1; d > 0; d-- {
+		k := x - y
+		previous := trace[d-1]
+		var prevK int
+		if k == -d || (k != d && previous[k-1+d-1] < previous[k+1+d-1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := previous[prevK+d-1]
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			x--
+			y--
+			reversed = append(reversed, diffOp{kind: opEqual, line: a[x]})
+		}
+		if prevK == k+1 {
+			y--
+			reversed = append(reversed, diffOp{kind: opInsert, line: b[y]})
+		} else {
+			x--
+			reversed = append(reversed, diffOp{kind: opDelete, line: a[x]})
+		}
+	}
+	for x > 0 && y > 0 {
+		x--
+		y--
+		reversed = append(reversed, diffOp{kind: opEqual, line: a[x]})
+	}
+
+	ops := make([]diffOp, len(reversed))
+	for i, op := range reversed {
+		ops[len(reversed)-1-i] = op
+	}
+	return ops
+}
+
+// replaceAll returns the edit script deleting all of a and inserting all of b.
+func replaceAll(a, b []string) []diffOp {
+	ops := make([]diffOp, 0, len(a)+len(b))
+	for _, line := range a {
+		ops = append(ops, diffOp{kind: opDelete, line: line})
+	}
+	for _, line := range b {
+		ops = append(ops, diffOp{kind: opInsert, line: line})
+	}
+	return ops
+}
+
+// hunk is a range of diff operations, [start, end), shown together.
+type hunk struct {
+	start, end int
+}
+
+// hunks groups the changed operations with their context lines, merging overlapping groups.
+func hunks(ops []diffOp) []hunk {
+	var result []hunk
+	for i, op := range ops {
+		if op.kind == opEqual {
+			continue
+		}
+
+		start := max(0, i-diffContextLines)
+		end := min(len(ops), i+1+diffContextLines)
+		if len(result) > 0 && start <= result[len(result)-1].end {
+			result[len(result)-1].end = end
+		} else {
+			result = append(result, hunk{start: start, end: end})
+		}
+	}
+	return result
+}
+
+// writeHunk writes a hunk in unified format.
+func writeHunk(out *strings.Builder, ops []diffOp, h hunk) {
+	// Line numbers of the hunk start, counted over the operations before it
+	aLine, bLine := 1, 1
+	for _, op := range ops[:h.start] {
+		if op.kind != opInsert {
+			aLine++
+		}
+		if op.kind != opDelete {
+			bLine++
+		}
+	}
+
+	aCount, bCount := 0, 0
+	for _, op := range ops[h.start:h.end] {
+		if op.kind != opInsert {
+			aCount++
+		}
+		if op.kind != opDelete {
+			bCount++
+		}
+	}
+	// Empty ranges are numbered after the line they follow, as in diff -u
+	if aCount == 0 {
+		aLine--
+	}
+	if bCount == 0 {
+		bLine--
+	}
+
+	fmt.Fprintf(out, "@@ -%d,%d +%d,%d @@\n", aLine, aCount, bLine, bCount)
+	for _, op := range ops[h.start:h.end] {
+		switch op.kind {
+		case opEqual:
+			out.WriteString(" ")
+		case opDelete:
+			out.WriteString("-")
+		case opInsert:
+			out.WriteString("+")
+		}
+		out.WriteString(op.line)
+		out.WriteString("\n")
+	}
+}
@@ -0,0 +1,125 @@
+package history
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// Export returns the named script with the content of its revisions, to copy it to
+// another data directory with Import.
+func (s *Store) Export(name string) (*Script, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.load(name)
+}
+
+// Import adds the history of a script returned by Export, unless the script already has
+// a history and replace is not set. It reports whether the script was imported.
+func (s *Store) Import(script *Script, replace bool) (bool, error) {
+	if !scriptNamePattern.MatchString(script.Name) {
+		return false, fmt.Errorf("invalid script name %q: use up to 128 letters, digits, spaces and '_.:/-'", script.Name)
+	}
+	if len(script.Revisions) == 0 {
+		return false, fmt.Errorf("script %q has no revision", script.Name)
+	}
+	for _, revision := range script.Revisions {
+		sum := sha256.Sum256([]byte(revision.Content))
+		if hex.EncodeToString(sum[:]) != revision.Hash {
+			return false, fmt.Errorf("revision %d of script %q does not match its SHA-256", revision.Number, script.Name)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.load(script.Name); err == nil && !replace {
+		return false, nil
+	} else if err != nil && !errors.Is(err, ErrScriptNotFound) {
+		return false, err
+	}
+
+	imported := *script
+	sort.Slice(imported.Revisions, func(i, j int) bool { return imported.Revisions[i].Number < imported.Revisions[j].Number })
+	if len(imported.Revisions) > MaxRevisions {
+		imported.Revisions = imported.Revisions[len(imported.Revisions)-MaxRevisions:]
+	}
+
+	if err := s.save(&imported); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// ExportRuns returns the runs of the scripts include accepts, oldest first. Runs of
+// unnamed scripts are passed as "".
+func (s *Store) ExportRuns(include func(script string) bool) ([]RunRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	runs, err := s.loadRuns()
+	if err != nil {
+		return nil, err
+	}
+
+	var exported []RunRecord
+	for _, run := range runs.Runs {
+		if include(run.Script) {
+			exported = append(exported, run)
+		}
+	}
+
+	return exported, nil
+}
+
+// ImportRuns adds runs returned by ExportRuns, assigning their IDs, and returns the number
+// of runs added. Runs already recorded, of the same script hash and start time, are
+// skipped, so that importing the same runs twice adds them once.
+func (s *Store) ImportRuns(imported []RunRecord) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	runs, err := s.loadRuns()
+	if err != nil {
+		return 0, err
+	}
+
+	type runKey struct {
+		hash    string
+		started int64
+	}
+	recorded := make(map[runKey]bool, len(runs.Runs))
+	nextID := 1
+	for _, run := range runs.Runs {
+		recorded[runKey{run.ScriptHash, run.StartedAt.UnixNano()}] = true
+		nextID = max(nextID, run.ID+1)
+	}
+
+	added := 0
+	for _, run := range imported {
+		key := runKey{run.ScriptHash, run.StartedAt.UnixNano()}
+		if recorded[key] {
+			continue
+		}
+		recorded[key] = true
+
+		run.ID = nextID
+		nextID++
+		runs.Runs = append(runs.Runs, run)
+		added++
+	}
+	if added == 0 {
+		return 0, nil
+	}
+
+	sort.SliceStable(runs.Runs, func(i, j int) bool { return runs.Runs[i].StartedAt.Before(runs.Runs[j].StartedAt) })
+	if len(runs.Runs) > MaxRunRecords {
+		runs.Runs = runs.Runs[len(runs.Runs)-MaxRunRecords:]
+	}
+
+	return added, s.saveRuns(runs)
+}
@@ -0,0 +1,238 @@
+// Package history keeps the revisions of named scripts, so that agents can see how a
+// script evolved, compare versions, and revert bad edits, and the records of runs, so
+// that they can query past results and follow their trends.
+package history
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/oleiade/k6-mcp/internal/jsonfile"
+)
+
+const (
+	// MaxRevisions is the number of revisions kept per script; older ones are dropped.
+	MaxRevisions = 50
+	// historyDirName is the name of the directory, within the data directory, holding the
+	// history files, one per script.
+	historyDirName = "scripts"
+)
+
+// ErrScriptNotFound is returned when a script has no recorded revision.
+var ErrScriptNotFound = errors.New("script not found")
+
+// ErrRevisionNotFound is returned when a script has no revision of the requested number.
+var ErrRevisionNotFound = errors.New("revision not found")
+
+// scriptNamePattern matches valid script names.
+var scriptNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9 _.:/-]{0,127}$`)
+
+// Revision is a recorded version of a script.
+type Revision struct {
+	Number    int       `json:"revision"`
+	Hash      string    `json:"sha256"`
+	CreatedAt time.Time `json:"created_at"`
+	Source    string    `json:"source,omitempty"` // the tool the revision was recorded by
+	Size      int       `json:"size_bytes"`
+	// Fingerprint identifies the structure of the script, see Fingerprint.
+	Fingerprint string `json:"fingerprint,omitempty"`
+
+	// Changes counts the lines changed since the previous revision.
+	Changes DiffStat `json:"changes"`
+
+	Content string `json:"content,omitempty"`
+}
+
+// Script is the recorded history of a named script.
+type Script struct {
+	Name      string     `json:"name"`
+	Revisions []Revision `json:"revisions"`
+}
+
+// Store persists script histories in JSON files, one per script, and the records of runs
+// and suite runs.
+type Store struct {
+	dir        string
+	suitesPath string
+	runsPath   string
+	mu         sync.Mutex
+}
+
+// NewStore creates a Store keeping its histories in the scripts directory of dir, suite
+// runs in its suites.json file, and runs in its runs.json file.
+func NewStore(dir string) *Store {
+	return &Store{
+		dir:        filepath.Join(dir, historyDirName),
+		suitesPath: filepath.Join(dir, suitesFileName),
+		runsPath:   filepath.Join(dir, runsFileName),
+	}
+}
+
+// Record records content as a new revision of the named script, and returns it. When the
+// content is unchanged since the latest revision, that revision is returned instead, and
+// created is false.
+func (s *Store) Record(name, content, source string) (revision *Revision, created bool, err error) {
+	if !scriptNamePattern.MatchString(name) {
+		return nil, false, fmt.Errorf("invalid script name %q: use up to 128 letters, digits, spaces and '_.:/-'", name)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	script, err := s.load(name)
+	if errors.Is(err, ErrScriptNotFound) {
+		script = &Script{Name: name}
+	} else if err != nil {
+		return nil, false, err
+	}
+
+	sum := sha256.Sum256([]byte(content))
+	hash := hex.EncodeToString(sum[:])
+
+	rev := Revision{
+		Number:      1,
+		Hash:        hash,
+		CreatedAt:   time.Now().UTC(),
+		Source:      source,
+		Size:        len(content),
+		Fingerprint: Fingerprint(content),
+		Content:     content,
+	}
+	if n := len(script.Revisions); n > 0 {
+		latest := script.Revisions[n-1]
+		if latest.Hash == hash {
+			return &latest, false, nil
+		}
+		rev.Number = latest.Number + 1
+		rev.Changes = Stat(latest.Content, content)
+	} else {
+		rev.Changes = Stat("", content)
+	}
+
+	script.Revisions = append(script.Revisions, rev)
+	if len(script.Revisions) > MaxRevisions {
+		script.Revisions = script.Revisions[len(script.Revisions)-MaxRevisions:]
+	}
+
+	if err := s.save(script); err != nil {
+		return nil, false, err
+	}
+
+	return &rev, true, nil
+}
+
+// History returns the revisions of the named script, oldest first, without their content.
+func (s *Store) History(name string) (*Script, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	script, err := s.load(name)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range script.Revisions {
+		script.Revisions[i].Content = ""
+	}
+
+	return script, nil
+}
+
+// Get returns a revision of the named script, with its content. A zero number returns the
+// latest revision, and negative numbers count back from it: -1 is the one before the latest.
+func (s *Store) Get(name string, number int) (*Revision, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	script, err := s.load(name)
+	if err != nil {
+		return nil, err
+	}
+
+	latest := script.Revisions[len(script.Revisions)-1].Number
+	if number <= 0 {
+		number += latest
+	}
+
+	for i := range script.Revisions {
+		if script.Revisions[i].Number == number {
+			return &script.Revisions[i], nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: script %q has no revision %d (kept revisions: %d to %d)",
+		ErrRevisionNotFound, name, number, script.Revisions[0].Number, latest)
+}
+
+// List returns the names of the scripts having a history, sorted.
+func (s *Store) List() ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries, err := os.ReadDir(s.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list script histories: %w", err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		script, err := s.read(filepath.Join(s.dir, entry.Name()))
+		if err != nil {
+			continue
+		}
+		names = append(names, script.Name)
+	}
+	sort.Strings(names)
+
+	return names, nil
+}
+
+// path returns the path of the history file of the named script. Names are hashed, so that
+// they can contain characters file names can't.
+func (s *Store) path(name string) string {
+	sum := sha256.Sum256([]byte(name))
+	return filepath.Join(s.dir, hex.EncodeToString(sum[:8])+".json")
+}
+
+func (s *Store) load(name string) (*Script, error) {
+	script, err := s.read(s.path(name))
+	if err != nil {
+		return nil, err
+	}
+	if len(script.Revisions) == 0 {
+		return nil, fmt.Errorf("%w: no history for script %q", ErrScriptNotFound, name)
+	}
+	return script, nil
+}
+
+func (s *Store) read(path string) (*Script, error) {
+	var script Script
+	if err := jsonfile.Load(path, &script); err != nil {
+		return nil, fmt.Errorf("failed to read script history: %w", err)
+	}
+
+	return &script, nil
+}
+
+// save atomically replaces the history file of the script.
+func (s *Store) save(script *Script) error {
+	if err := jsonfile.SaveAtomic(s.path(script.Name), script); err != nil {
+		return fmt.Errorf("failed to write script history: %w", err)
+	}
+
+	return nil
+}
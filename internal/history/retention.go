@@ -0,0 +1,131 @@
+package history
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// PruneRuns removes the run records started before the cutoff, unless it is zero, then
+// the oldest ones until the encoded records fit in maxBytes, unless it is zero. It returns
+// the number of records removed and their encoded size. With dryRun, nothing is removed.
+func (s *Store) PruneRuns(before time.Time, maxBytes int64, dryRun bool) (int, int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	runs, err := s.loadRuns()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	sizes := make([]int64, len(runs.Runs))
+	var total int64
+	for i := range runs.Runs {
+		data, err := json.Marshal(&runs.Runs[i])
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to encode run records: %w", err)
+		}
+		sizes[i] = int64(len(data))
+		total += sizes[i]
+	}
+
+	// Runs are ordered by start time, so the pruned runs are the first ones
+	var removed int
+	var freed int64
+	for removed < len(runs.Runs) {
+		expired := !before.IsZero() && runs.Runs[removed].StartedAt.Before(before)
+		if !expired && (maxBytes <= 0 || total <= maxBytes) {
+			break
+		}
+		total -= sizes[removed]
+		freed += sizes[removed]
+		removed++
+	}
+
+	if removed == 0 || dryRun {
+		return removed, freed, nil
+	}
+	runs.Runs = runs.Runs[removed:]
+
+	return removed, freed, s.saveRuns(runs)
+}
+
+// PruneSuiteRuns removes the suite runs started before the cutoff, and returns their
+// number. With dryRun, nothing is removed.
+func (s *Store) PruneSuiteRuns(before time.Time, dryRun bool) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	runs, err := s.loadSuiteRuns()
+	if err != nil {
+		return 0, err
+	}
+
+	kept := runs.Runs[:0:0]
+	for _, run := range runs.Runs {
+		if !run.StartedAt.Before(before) {
+			kept = append(kept, run)
+		}
+	}
+	removed := len(runs.Runs) - len(kept)
+
+	if removed == 0 || dryRun {
+		return removed, nil
+	}
+	runs.Runs = kept
+
+	return removed, s.saveSuiteRuns(runs)
+}
+
+// PruneRevisions removes the script revisions recorded before the cutoff, but the latest
+// revision of each script, so that scripts are never lost. It returns the number of
+// revisions removed and the size of their content. With dryRun, nothing is removed.
+func (s *Store) PruneRevisions(before time.Time, dryRun bool) (int, int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries, err := os.ReadDir(s.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, 0, nil
+	}
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to list script histories: %w", err)
+	}
+
+	var removed int
+	var freed int64
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		script, err := s.read(filepath.Join(s.dir, entry.Name()))
+		if err != nil || len(script.Revisions) < 2 {
+			continue
+		}
+
+		// Revisions are ordered by number, and so by creation time
+		latest := len(script.Revisions) - 1
+		expired := 0
+		for expired < latest && script.Revisions[expired].CreatedAt.Before(before) {
+			freed += int64(script.Revisions[expired].Size)
+			expired++
+		}
+		if expired == 0 {
+			continue
+		}
+		removed += expired
+
+		if dryRun {
+			continue
+		}
+		script.Revisions = script.Revisions[expired:]
+		if err := s.save(script); err != nil {
+			return removed, freed, err
+		}
+	}
+
+	return removed, freed, nil
+}
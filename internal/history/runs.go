@@ -0,0 +1,382 @@
+package history
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/oleiade/k6-mcp/internal/jsonfile"
+)
+
+const (
+	// MaxRunRecords is the number of run records kept, across all scripts; older ones are dropped.
+	MaxRunRecords = 1000
+	// DefaultRunQueryLimit is the number of runs queries return when no limit is given.
+	DefaultRunQueryLimit = 20
+	// MaxRunQueryLimit is the maximum number of runs queries return.
+	MaxRunQueryLimit = 200
+	// runsFileName is the name of the file, within the data directory, holding run records.
+	runsFileName = "runs.json"
+
+	// minTrendRuns is the number of runs trends need to be computed.
+	minTrendRuns = 3
+	// stableTrendRatio is the relative change over the runs of a trend under which it is stable.
+	stableTrendRatio = 0.05
+)
+
+// Trend directions of run statistics.
+const (
+	TrendImproving        = "improving"
+	TrendDegrading        = "degrading"
+	TrendStable           = "stable"
+	TrendInsufficientData = "insufficient_data"
+)
+
+// RunRecord is the record of a run.
+type RunRecord struct {
+	ID int `json:"id"`
+	// Source is the tool the run was recorded by: run_test, or import_results for runs
+	// executed outside the server. Environment names where they ran, e.g. "ci", or the
+	// environment label of runs, e.g. "staging".
+	Source      string `json:"source,omitempty"`
+	Environment string `json:"environment,omitempty"`
+	// Test is the name of the registered test the run is of, if any: its runs stay keyed
+	// by it as the script of the test changes.
+	Test string `json:"test_name,omitempty"`
+	// Script is the name of the script, when it is named, and Revision its revision.
+	Script     string    `json:"script_name,omitempty"`
+	Revision   int       `json:"revision,omitempty"`
+	ScriptHash string    `json:"script_sha256"`
+	Targets    []string  `json:"targets,omitempty"` // the hosts targeted by the run
+	StartedAt  time.Time `json:"started_at"`
+	Duration   string    `json:"duration"`
+	Success    bool      `json:"success"`
+	ExitCode   int       `json:"exit_code"`
+	Grade      string    `json:"grade,omitempty"`
+	Error      string    `json:"error,omitempty"`
+
+	VUs        int    `json:"vus,omitempty"`
+	Iterations int    `json:"iterations,omitempty"`
+	Load       string `json:"load_duration,omitempty"`
+	// Seed is the seed of seeded runs, to reproduce them with.
+	Seed *int64 `json:"seed,omitempty"`
+	// FailureClass classifies the cause of failed runs, e.g. threshold_breach.
+	FailureClass string `json:"failure_class,omitempty"`
+
+	TotalRequests   int     `json:"total_requests"`
+	FailedRequests  int     `json:"failed_requests"`
+	ErrorRate       float64 `json:"error_rate"`
+	AvgResponseTime float64 `json:"avg_response_time_ms"`
+	P95ResponseTime float64 `json:"p95_response_time_ms"`
+	RequestRate     float64 `json:"request_rate_per_second"`
+	// ThresholdsFailed counts the thresholds the run crossed.
+	ThresholdsFailed int `json:"thresholds_failed,omitempty"`
+}
+
+// RunQuery filters run records. Zero fields match every run.
+type RunQuery struct {
+	Test   string
+	Script string
+	// ScriptHash matches the runs whose script SHA-256 starts with it.
+	ScriptHash string
+	// TargetHost matches the runs targeting the host, or one of its subdomains.
+	TargetHost string
+	Since      time.Time
+	Until      time.Time
+	Success    *bool
+	Limit      int
+}
+
+// matches reports whether the run matches the query.
+func (q *RunQuery) matches(run *RunRecord) bool {
+	switch {
+	case q.Test != "" && run.Test != q.Test:
+		return false
+	case q.Script != "" && run.Script != q.Script:
+		return false
+	case q.ScriptHash != "" && !strings.HasPrefix(run.ScriptHash, strings.ToLower(q.ScriptHash)):
+		return false
+	case q.TargetHost != "" && !targetsHost(run.Targets, strings.ToLower(q.TargetHost)):
+		return false
+	case !q.Since.IsZero() && run.StartedAt.Before(q.Since):
+		return false
+	case !q.Until.IsZero() && run.StartedAt.After(q.Until):
+		return false
+	case q.Success != nil && run.Success != *q.Success:
+		return false
+	}
+	return true
+}
+
+// targetsHost reports whether the targets include the host, or one of its subdomains.
+func targetsHost(targets []string, host string) bool {
+	for _, target := range targets {
+		if target == host || strings.HasSuffix(target, "."+host) {
+			return true
+		}
+	}
+	return false
+}
+
+// runRecords is the content of the runs file.
+type runRecords struct {
+	Runs []RunRecord `json:"runs"`
+}
+
+// RecordRun records a run, assigning its ID. Runs are kept ordered by start time, so that
+// runs imported after the fact take their place among the others.
+func (s *Store) RecordRun(run *RunRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	runs, err := s.loadRuns()
+	if err != nil {
+		return err
+	}
+
+	run.ID = 1
+	for i := range runs.Runs {
+		run.ID = max(run.ID, runs.Runs[i].ID+1)
+	}
+
+	at := sort.Search(len(runs.Runs), func(i int) bool { return runs.Runs[i].StartedAt.After(run.StartedAt) })
+	runs.Runs = append(runs.Runs, RunRecord{})
+	copy(runs.Runs[at+1:], runs.Runs[at:])
+	runs.Runs[at] = *run
+	if len(runs.Runs) > MaxRunRecords {
+		runs.Runs = runs.Runs[len(runs.Runs)-MaxRunRecords:]
+	}
+
+	return s.saveRuns(runs)
+}
+
+// QueryRuns returns the runs matching the query, most recent first, up to its limit, and
+// the number of matching runs.
+func (s *Store) QueryRuns(query RunQuery) ([]RunRecord, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	runs, err := s.loadRuns()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	limit := query.Limit
+	if limit <= 0 {
+		limit = DefaultRunQueryLimit
+	}
+	limit = min(limit, MaxRunQueryLimit)
+
+	matching := []RunRecord{}
+	total := 0
+	for i := len(runs.Runs) - 1; i >= 0; i-- {
+		if !query.matches(&runs.Runs[i]) {
+			continue
+		}
+		total++
+		if len(matching) < limit {
+			matching = append(matching, runs.Runs[i])
+		}
+	}
+
+	return matching, total, nil
+}
+
+// TrendPoint is a run of a trend line.
+type TrendPoint struct {
+	RunID           int       `json:"run_id"`
+	StartedAt       time.Time `json:"started_at"`
+	Revision        int       `json:"revision,omitempty"`
+	Success         bool      `json:"success"`
+	P95ResponseTime float64   `json:"p95_response_time_ms"`
+	ErrorRate       float64   `json:"error_rate"`
+}
+
+// MetricStats aggregates a metric over runs.
+type MetricStats struct {
+	Latest float64 `json:"latest"`
+	Min    float64 `json:"min"`
+	Max    float64 `json:"max"`
+	Avg    float64 `json:"avg"`
+	// Slope is the change of the metric per run, fitted by least squares.
+	Slope float64 `json:"slope_per_run"`
+	// Trend is the direction of the metric over the runs: lower values are improvements.
+	Trend string `json:"trend"`
+}
+
+// RunStats aggregates the runs of a named script, or of a registered test.
+type RunStats struct {
+	Test      string       `json:"test_name,omitempty"`
+	Script    string       `json:"script_name"`
+	Runs      int          `json:"runs"`
+	Passed    int          `json:"passed"`
+	Failed    int          `json:"failed"`
+	PassRate  float64      `json:"pass_rate"`
+	FirstRun  time.Time    `json:"first_run"`
+	LastRun   time.Time    `json:"last_run"`
+	P95       MetricStats  `json:"p95_response_time_ms"`
+	ErrorRate MetricStats  `json:"error_rate"`
+	Points    []TrendPoint `json:"trend"`
+}
+
+// RunStats aggregates the runs of the named scripts matching the query, one RunStats per
+// script, sorted by name. An empty query script aggregates every named script. The limit
+// of the query bounds the trend points of each script, the most recent ones being kept;
+// the aggregates cover all matching runs. A query test aggregates the runs of the test into
+// a single RunStats, whatever the scripts it ran, Script being the latest one.
+func (s *Store) RunStats(query RunQuery) ([]RunStats, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	runs, err := s.loadRuns()
+	if err != nil {
+		return nil, err
+	}
+
+	if query.Test != "" {
+		var testRuns []RunRecord
+		for i := range runs.Runs {
+			if query.matches(&runs.Runs[i]) {
+				testRuns = append(testRuns, runs.Runs[i])
+			}
+		}
+		if len(testRuns) == 0 {
+			return []RunStats{}, nil
+		}
+		stats := aggregateRuns(testRuns[len(testRuns)-1].Script, testRuns, query.Limit)
+		stats.Test = query.Test
+		return []RunStats{stats}, nil
+	}
+
+	byScript := make(map[string][]RunRecord)
+	for i := range runs.Runs {
+		run := &runs.Runs[i]
+		if run.Script == "" || !query.matches(run) {
+			continue
+		}
+		byScript[run.Script] = append(byScript[run.Script], *run)
+	}
+
+	stats := make([]RunStats, 0, len(byScript))
+	for script, scriptRuns := range byScript {
+		stats = append(stats, aggregateRuns(script, scriptRuns, query.Limit))
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Script < stats[j].Script })
+
+	return stats, nil
+}
+
+// aggregateRuns aggregates the runs of a script, oldest first, keeping up to limit trend points.
+func aggregateRuns(script string, runs []RunRecord, limit int) RunStats {
+	stats := RunStats{
+		Script:   script,
+		Runs:     len(runs),
+		FirstRun: runs[0].StartedAt,
+		LastRun:  runs[len(runs)-1].StartedAt,
+	}
+
+	p95 := make([]float64, 0, len(runs))
+	errorRates := make([]float64, 0, len(runs))
+	for _, run := range runs {
+		if run.Success {
+			stats.Passed++
+		} else {
+			stats.Failed++
+		}
+		// Runs that made no request, e.g. rejected ones, carry no latency or error rate
+		if run.TotalRequests > 0 {
+			p95 = append(p95, run.P95ResponseTime)
+			errorRates = append(errorRates, run.ErrorRate)
+		}
+		stats.Points = append(stats.Points, TrendPoint{
+			RunID:           run.ID,
+			StartedAt:       run.StartedAt,
+			Revision:        run.Revision,
+			Success:         run.Success,
+			P95ResponseTime: run.P95ResponseTime,
+			ErrorRate:       run.ErrorRate,
+		})
+	}
+	stats.PassRate = float64(stats.Passed) / float64(stats.Runs)
+	stats.P95 = metricStats(p95)
+	stats.ErrorRate = metricStats(errorRates)
+
+	if limit <= 0 {
+		limit = MaxRunQueryLimit
+	}
+	if len(stats.Points) > limit {
+		stats.Points = stats.Points[len(stats.Points)-limit:]
+	}
+
+	return stats
+}
+
+// metricStats aggregates the values of a metric, oldest first.
+func metricStats(values []float64) MetricStats {
+	stats := MetricStats{Trend: TrendInsufficientData}
+	if len(values) == 0 {
+		return stats
+	}
+
+	stats.Latest = values[len(values)-1]
+	stats.Min, stats.Max = values[0], values[0]
+	sum := 0.0
+	for _, value := range values {
+		stats.Min = math.Min(stats.Min, value)
+		stats.Max = math.Max(stats.Max, value)
+		sum += value
+	}
+	stats.Avg = sum / float64(len(values))
+
+	if len(values) < minTrendRuns {
+		return stats
+	}
+
+	// Least squares fit of the values against the run index
+	n := float64(len(values))
+	meanX := (n - 1) / 2
+	var covariance, variance float64
+	for i, value := range values {
+		dx := float64(i) - meanX
+		covariance += dx * (value - stats.Avg)
+		variance += dx * dx
+	}
+	stats.Slope = covariance / variance
+
+	// The change over the runs is compared to the average, or to the range when it is zero
+	change := stats.Slope * (n - 1)
+	scale := math.Abs(stats.Avg)
+	if scale == 0 {
+		scale = stats.Max - stats.Min
+	}
+	switch {
+	case scale == 0 || math.Abs(change) <= stableTrendRatio*scale:
+		stats.Trend = TrendStable
+	case change > 0:
+		stats.Trend = TrendDegrading
+	default:
+		stats.Trend = TrendImproving
+	}
+
+	return stats
+}
+
+func (s *Store) loadRuns() (*runRecords, error) {
+	var runs runRecords
+	if err := jsonfile.Load(s.runsPath, &runs); err != nil {
+		return nil, fmt.Errorf("failed to read run records: %w", err)
+	}
+
+	return &runs, nil
+}
+
+// saveRuns atomically replaces the runs file.
+func (s *Store) saveRuns(runs *runRecords) error {
+	if err := jsonfile.SaveAtomic(s.runsPath, runs); err != nil {
+		return fmt.Errorf("failed to write run records: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,213 @@
+package history
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	// DuplicateSimilarity is the similarity from which scripts are near-duplicates.
+	DuplicateSimilarity = 0.75
+	// MaxSimilarScripts is the maximum number of similar scripts Similar returns.
+	MaxSimilarScripts = 5
+	// shingleSize is the number of tokens of the shingles compared by Similar.
+	shingleSize = 3
+)
+
+// jsKeywords are the keywords kept as is by normalization, unlike other identifiers.
+var jsKeywords = map[string]bool{
+	"async": true, "await": true, "break": true, "case": true, "catch": true, "class": true,
+	"const": true, "continue": true, "default": true, "delete": true, "do": true, "else": true,
+	"export": true, "extends": true, "false": true, "finally": true, "for": true, "from": true,
+	"function": true, "if": true, "import": true, "in": true, "instanceof": true, "let": true,
+	"new": true, "null": true, "of": true, "require": true, "return": true, "switch": true,
+	"this": true, "throw": true, "true": true, "try": true, "typeof": true, "undefined": true,
+	"var": true, "void": true, "while": true, "yield": true,
+}
+
+// Similarity is a recorded script similar to another script.
+type Similarity struct {
+	Name     string `json:"script_name"`
+	Revision int    `json:"revision"`
+	// Score is the share of code structure the scripts have in common, from 0 to 1.
+	Score float64 `json:"similarity"`
+	// Identical is set when the scripts only differ by names, literals, comments and
+	// formatting.
+	Identical bool `json:"identical"`
+}
+
+// Fingerprint returns the SHA-256 of the normalized tokens of the script: scripts only
+// differing by their local names, literals, comments and formatting share fingerprints.
+func Fingerprint(content string) string {
+	sum := sha256.Sum256([]byte(strings.Join(normalizeTokens(content), " ")))
+	return hex.EncodeToString(sum[:])
+}
+
+// Similar returns the recorded scripts whose latest revision is at least threshold similar
+// to content, most similar first, except the script named exclude.
+func (s *Store) Similar(content, exclude string, threshold float64) ([]Similarity, error) {
+	tokens := normalizeTokens(content)
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+	sum := sha256.Sum256([]byte(strings.Join(tokens, " ")))
+	fingerprint := hex.EncodeToString(sum[:])
+	shingles := shingle(tokens)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries, err := os.ReadDir(s.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list script histories: %w", err)
+	}
+
+	var similar []Similarity
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		script, err := s.read(filepath.Join(s.dir, entry.Name()))
+		if err != nil || script.Name == exclude || len(script.Revisions) == 0 {
+			continue
+		}
+
+		latest := script.Revisions[len(script.Revisions)-1]
+		if latest.Fingerprint == "" {
+			latest.Fingerprint = Fingerprint(latest.Content)
+		}
+		match := Similarity{Name: script.Name, Revision: latest.Number, Identical: latest.Fingerprint == fingerprint}
+		if match.Identical {
+			match.Score = 1
+		} else {
+			match.Score = jaccard(shingles, shingle(normalizeTokens(latest.Content)))
+		}
+		if match.Score >= threshold {
+			match.Score = math.Round(match.Score*100) / 100
+			similar = append(similar, match)
+		}
+	}
+
+	sort.Slice(similar, func(i, j int) bool {
+		if similar[i].Score != similar[j].Score {
+			return similar[i].Score > similar[j].Score
+		}
+		return similar[i].Name < similar[j].Name
+	})
+	if len(similar) > MaxSimilarScripts {
+		similar = similar[:MaxSimilarScripts]
+	}
+
+	return similar, nil
+}
+
+// normalizeTokens splits the script into tokens, without comments and whitespace, and
+// normalizes them: identifiers become $, except keywords and property names, such as get in
+// http.get; strings become "", except module specifiers; numbers become 0.
+func normalizeTokens(content string) []string {
+	var tokens []string
+	previous := func() string {
+		if len(tokens) == 0 {
+			return ""
+		}
+		return tokens[len(tokens)-1]
+	}
+
+	for i := 0; i < len(content); {
+		c := content[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '/' && i+1 < len(content) && content[i+1] == '/':
+			for i < len(content) && content[i] != '\n' {
+				i++
+			}
+		case c == '/' && i+1 < len(content) && content[i+1] == '*':
+			end := strings.Index(content[i+2:], "*/")
+			if end < 0 {
+				i = len(content)
+			} else {
+				i += end + 4
+			}
+		case c == '\'' || c == '"' || c == '`':
+			start := i
+			for i++; i < len(content) && content[i] != c; i++ {
+				if content[i] == '\\' {
+					i++
+				}
+			}
+			i = min(i+1, len(content))
+			// Module specifiers tell what the script does, e.g. k6/http or k6/browser
+			if p := previous(); p == "from" || p == "import" || (p == "(" && len(tokens) > 1 && tokens[len(tokens)-2] == "require") {
+				tokens = append(tokens, content[start:i])
+			} else {
+				tokens = append(tokens, `""`)
+			}
+		case c >= '0' && c <= '9':
+			for i < len(content) && (isIdentifierByte(content[i]) || content[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, "0")
+		case isIdentifierByte(c):
+			start := i
+			for i < len(content) && isIdentifierByte(content[i]) {
+				i++
+			}
+			word := content[start:i]
+			if jsKeywords[word] || previous() == "." {
+				tokens = append(tokens, word)
+			} else {
+				tokens = append(tokens, "$")
+			}
+		default:
+			tokens = append(tokens, string(c))
+			i++
+		}
+	}
+
+	return tokens
+}
+
+func isIdentifierByte(c byte) bool {
+	return c == '_' || c == '$' || c >= 0x80 || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// shingle returns the hashes of the sequences of shingleSize consecutive tokens.
+func shingle(tokens []string) map[uint64]struct{} {
+	shingles := make(map[uint64]struct{})
+	for i := 0; i == 0 || i+shingleSize <= len(tokens); i++ {
+		h := fnv.New64a()
+		for _, token := range tokens[i:min(i+shingleSize, len(tokens))] {
+			h.Write([]byte(token))
+			h.Write([]byte{0})
+		}
+		shingles[h.Sum64()] = struct{}{}
+	}
+	return shingles
+}
+
+// jaccard returns the Jaccard index of the sets: the size of their intersection over the
+// size of their union.
+func jaccard(a, b map[uint64]struct{}) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 1
+	}
+	common := 0
+	for h := range a {
+		if _, ok := b[h]; ok {
+			common++
+		}
+	}
+	return float64(common) / float64(len(a)+len(b)-common)
+}
@@ -0,0 +1,109 @@
+package history
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/oleiade/k6-mcp/internal/jsonfile"
+)
+
+const (
+	// MaxSuiteRuns is the number of suite runs kept, across all suites; older ones are dropped.
+	MaxSuiteRuns = 100
+	// suitesFileName is the name of the file, within the data directory, holding suite runs.
+	suitesFileName = "suites.json"
+)
+
+// SuiteStep is the outcome of one script of a suite run.
+type SuiteStep struct {
+	Script   string `json:"script_name"`
+	Revision int    `json:"revision,omitempty"`
+	Success  bool   `json:"success"`
+	Skipped  bool   `json:"skipped,omitempty"`
+	ExitCode int    `json:"exit_code"`
+	Duration string `json:"duration,omitempty"`
+	Grade    string `json:"grade,omitempty"`
+	Error    string `json:"error,omitempty"`
+
+	TotalRequests   int     `json:"total_requests"`
+	FailedRequests  int     `json:"failed_requests"`
+	P95ResponseTime float64 `json:"p95_response_time_ms"`
+}
+
+// SuiteRun is the record of a suite run.
+type SuiteRun struct {
+	ID            int         `json:"id"`
+	Suite         string      `json:"suite_name,omitempty"`
+	StartedAt     time.Time   `json:"started_at"`
+	Duration      string      `json:"duration"`
+	Success       bool        `json:"success"`
+	StopOnFailure bool        `json:"stop_on_failure"`
+	Steps         []SuiteStep `json:"steps"`
+}
+
+// suiteRuns is the content of the suites file.
+type suiteRuns struct {
+	Runs []SuiteRun `json:"runs"`
+}
+
+// RecordSuiteRun records a suite run, assigning its ID.
+func (s *Store) RecordSuiteRun(run *SuiteRun) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	runs, err := s.loadSuiteRuns()
+	if err != nil {
+		return err
+	}
+
+	run.ID = 1
+	if n := len(runs.Runs); n > 0 {
+		run.ID = runs.Runs[n-1].ID + 1
+	}
+
+	runs.Runs = append(runs.Runs, *run)
+	if len(runs.Runs) > MaxSuiteRuns {
+		runs.Runs = runs.Runs[len(runs.Runs)-MaxSuiteRuns:]
+	}
+
+	return s.saveSuiteRuns(runs)
+}
+
+// SuiteRuns returns the recorded runs of the named suite, most recent first. An empty name
+// returns the runs of all suites.
+func (s *Store) SuiteRuns(suite string) ([]SuiteRun, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	runs, err := s.loadSuiteRuns()
+	if err != nil {
+		return nil, err
+	}
+
+	var matching []SuiteRun
+	for i := len(runs.Runs) - 1; i >= 0; i-- {
+		if suite == "" || runs.Runs[i].Suite == suite {
+			matching = append(matching, runs.Runs[i])
+		}
+	}
+
+	return matching, nil
+}
+
+func (s *Store) loadSuiteRuns() (*suiteRuns, error) {
+	var runs suiteRuns
+	if err := jsonfile.Load(s.suitesPath, &runs); err != nil {
+		return nil, fmt.Errorf("failed to read suite runs: %w", err)
+	}
+
+	return &runs, nil
+}
+
+// saveSuiteRuns atomically replaces the suites file.
+func (s *Store) saveSuiteRuns(runs *suiteRuns) error {
+	if err := jsonfile.SaveAtomic(s.suitesPath, runs); err != nil {
+		return fmt.Errorf("failed to write suite runs: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,125 @@
+package infra
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/oleiade/k6-mcp/internal/resources"
+)
+
+const (
+	// DefaultK6Image is the k6 image used by the docker-compose stack.
+	DefaultK6Image = "grafana/k6:latest"
+
+	// DefaultPrometheusImage is the Prometheus image used by the docker-compose stack.
+	DefaultPrometheusImage = "prom/prometheus:latest"
+
+	// DefaultGrafanaImage is the Grafana image used by the docker-compose stack.
+	DefaultGrafanaImage = "grafana/grafana:latest"
+
+	// DefaultGrafanaPort is the host port Grafana is exposed on.
+	DefaultGrafanaPort = 3000
+
+	// DefaultPrometheusPort is the host port Prometheus is exposed on.
+	DefaultPrometheusPort = 9090
+
+	// dashboardPath is the path of the embedded Grafana dashboard provisioned in the stack.
+	dashboardPath = "resources/templates/k6_prometheus_dashboard.json"
+)
+
+// ComposeSpec describes a local docker-compose stack running a k6 script, with its
+// metrics written to Prometheus and visualized in Grafana.
+type ComposeSpec struct {
+	// Script is the k6 script to run.
+	Script string
+
+	// Env holds environment variables exposed to the script through __ENV.
+	Env map[string]string
+
+	// K6Image, PrometheusImage and GrafanaImage are the images of the stack's services.
+	// They default to the latest published images.
+	K6Image         string
+	PrometheusImage string
+	GrafanaImage    string
+
+	// GrafanaPort and PrometheusPort are the host ports the services are exposed on.
+	GrafanaPort    int
+	PrometheusPort int
+
+	// Dashboard holds the Grafana dashboard provisioned in the stack; it is set by GenerateCompose.
+	Dashboard string
+}
+
+// GenerateCompose renders a docker-compose.yaml running the spec's script with k6,
+// sending its metrics to Prometheus through remote write, and provisioning Grafana
+// with a k6 dashboard. The whole stack is self-contained: the script and Grafana
+// provisioning files are inlined as compose configs.
+func GenerateCompose(spec *ComposeSpec) (string, error) {
+	if strings.TrimSpace(spec.Script) == "" {
+		return "", fmt.Errorf("script must not be empty")
+	}
+
+	for name := range spec.Env {
+		if !envVarNamePattern.MatchString(name) {
+			return "", fmt.Errorf("invalid environment variable name %q", name)
+		}
+	}
+
+	if spec.K6Image == "" {
+		spec.K6Image = DefaultK6Image
+	}
+	if spec.PrometheusImage == "" {
+		spec.PrometheusImage = DefaultPrometheusImage
+	}
+	if spec.GrafanaImage == "" {
+		spec.GrafanaImage = DefaultGrafanaImage
+	}
+	if spec.GrafanaPort == 0 {
+		spec.GrafanaPort = DefaultGrafanaPort
+	}
+	if spec.PrometheusPort == 0 {
+		spec.PrometheusPort = DefaultPrometheusPort
+	}
+	for _, port := range []int{spec.GrafanaPort, spec.PrometheusPort} {
+		if port < 1 || port > 65535 {
+			return "", fmt.Errorf("invalid port %d; ports must be between 1 and 65535", port)
+		}
+	}
+	if spec.GrafanaPort == spec.PrometheusPort {
+		return "", fmt.Errorf("grafana and prometheus must be exposed on different ports")
+	}
+
+	dashboard, err := resources.ReadFile(dashboardPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read Grafana dashboard: %w", err)
+	}
+	spec.Dashboard = string(dashboard)
+
+	return renderData("docker_compose.yaml.tmpl", spec)
+}
+
+// composeEscape escapes the '$' characters of s, which docker compose would otherwise
+// interpret as variable interpolations.
+func composeEscape(s string) string {
+	return strings.ReplaceAll(s, "$", "$$")
+}
+
+// yamlString renders s as a double-quoted YAML string.
+func yamlString(s string) string {
+	// JSON string literals are valid YAML double-quoted scalars.
+	return jsString(s)
+}
+
+// yamlBlock renders s as a literal block scalar indented with prefix. Content whose
+// first line starts with whitespace can't be represented as a block scalar without an
+// indentation indicator, and is rendered as a double-quoted string instead.
+func yamlBlock(prefix string, s string) string {
+	s = strings.TrimRight(s, "\n")
+	if s == "" || strings.HasPrefix(s, " ") || strings.HasPrefix(s, "\t") {
+		encoded, _ := json.Marshal(s + "\n")
+		return string(encoded)
+	}
+
+	return "|\n" + indent(prefix, s)
+}
@@ -0,0 +1,21 @@
+package infra
+
+import (
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+)
+
+// validateHCL parses a generated HCL configuration, and returns the diagnostics of its
+// syntax errors, if any.
+//
+// It catches the mistakes template rendering can introduce (e.g. a script line matching
+// the heredoc delimiter, or an unescaped quote) before the configuration is handed over
+// to the user.
+func validateHCL(src string) error {
+	_, diags := hclwrite.ParseConfig([]byte(src), "main.tf", hcl.InitialPos)
+	if diags.HasErrors() {
+		return diags
+	}
+
+	return nil
+}
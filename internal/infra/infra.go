@@ -0,0 +1,369 @@
+// Package infra generates infrastructure-as-code definitions provisioning and scheduling
+// Grafana Cloud k6 load tests, in several formats (Terraform, Pulumi, AWS CDK), as well
+// as local docker-compose stacks to run k6 tests with full observability.
+package infra
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/oleiade/k6-mcp/internal/resources"
+)
+
+// Format is an infrastructure-as-code output format.
+type Format string
+
+const (
+	// FormatTerraform renders Terraform configuration for the Grafana provider.
+	FormatTerraform Format = "terraform"
+
+	// FormatPulumiTypeScript renders a Pulumi program in TypeScript, using the @pulumiverse/grafana provider.
+	FormatPulumiTypeScript Format = "pulumi-typescript"
+
+	// FormatPulumiPython renders a Pulumi program in Python, using the pulumiverse_grafana provider.
+	FormatPulumiPython Format = "pulumi-python"
+
+	// FormatCDKTypeScript renders an AWS CDK stack in TypeScript scheduling runs of existing
+	// Grafana Cloud k6 load tests through EventBridge and Lambda.
+	FormatCDKTypeScript Format = "cdk-typescript"
+)
+
+// Formats lists the supported output formats.
+var Formats = []Format{FormatTerraform, FormatPulumiTypeScript, FormatPulumiPython, FormatCDKTypeScript}
+
+// templateNames maps each format to its embedded template.
+var templateNames = map[Format]string{
+	FormatTerraform:        "terraform_load_test.tf.tmpl",
+	FormatPulumiTypeScript: "pulumi_load_test.ts.tmpl",
+	FormatPulumiPython:     "pulumi_load_test.py.tmpl",
+	FormatCDKTypeScript:    "cdk_load_test_schedule.ts.tmpl",
+}
+
+// ParseFormat returns the Format matching s. An empty string selects FormatTerraform.
+func ParseFormat(s string) (Format, error) {
+	if s == "" {
+		return FormatTerraform, nil
+	}
+
+	for _, f := range Formats {
+		if Format(strings.ToLower(s)) == f {
+			return f, nil
+		}
+	}
+
+	names := make([]string, len(Formats))
+	for i, f := range Formats {
+		names[i] = string(f)
+	}
+	return "", fmt.Errorf("unsupported format %q; expected one of %s", s, strings.Join(names, ", "))
+}
+
+// Spec describes the Grafana Cloud k6 load tests to generate infrastructure code for.
+type Spec struct {
+	LoadTestName         string
+	LoadTestResourceName string
+	Script               string
+	ProjectID            string
+
+	// Notes (optional) are rendered as a comment block at the top of the generated code.
+	Notes string
+
+	// Schedule (optional) schedules runs of each load test.
+	Schedule *Schedule
+
+	// Environments (optional) renders one load test per environment, each with its own
+	// project and environment variables. When empty, a single load test is rendered
+	// in ProjectID.
+	Environments []Environment
+
+	// PerWorkspace gates each environment's resources on the Terraform workspace (or
+	// Pulumi stack) of the same name, so that each one only provisions its own environment.
+	PerWorkspace bool
+
+	// LoadTests holds the resolved load tests to render; it is derived from the fields above by Resolve.
+	LoadTests []LoadTest
+}
+
+// Schedule describes when Grafana Cloud k6 should run a load test.
+//
+// Either Cron or Frequency must be set.
+type Schedule struct {
+	// Starts is the RFC3339 date at which the schedule starts.
+	Starts string `json:"starts"`
+
+	// Frequency is the recurrence frequency: HOURLY, DAILY, WEEKLY, MONTHLY, or YEARLY.
+	Frequency string `json:"frequency,omitempty"`
+
+	// Interval is the number of Frequency units between two runs. Defaults to 1, the only
+	// interval YEARLY schedules support.
+	Interval int `json:"interval,omitempty"`
+
+	// Count (optional) is the number of runs after which the schedule ends.
+	Count int `json:"count,omitempty"`
+
+	// Until (optional) is the RFC3339 date at which the schedule ends.
+	Until string `json:"until,omitempty"`
+
+	// Cron (optional) is a cron expression, used instead of the recurrence rule.
+	Cron string `json:"cron,omitempty"`
+
+	// TimeZone is the time zone the cron expression is evaluated in. Defaults to UTC.
+	TimeZone string `json:"time_zone,omitempty"`
+}
+
+// Environment describes an environment the load test should be provisioned for.
+type Environment struct {
+	// Name is the environment name, e.g. "staging". It suffixes the load test name and resource name.
+	Name string `json:"name"`
+
+	// ProjectID is the Grafana Cloud k6 project to create the load test in.
+	ProjectID string `json:"project_id,omitempty"`
+
+	// ProjectName (optional) creates a new project with this name instead of using ProjectID.
+	ProjectName string `json:"project_name,omitempty"`
+
+	// Env holds environment variables exposed to the script through __ENV.
+	Env map[string]string `json:"env,omitempty"`
+
+	// Notes (optional) are rendered as a comment above the environment's resources.
+	Notes string `json:"notes,omitempty"`
+}
+
+// LoadTest is a fully resolved load test, ready to be rendered.
+type LoadTest struct {
+	ResourceName string
+	Name         string
+	ProjectID    string
+	ProjectName  string
+	Script       string
+	Workspace    string
+	Notes        string
+}
+
+var (
+	// identifierPattern matches valid resource names.
+	identifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+	// projectIDPattern matches Grafana Cloud k6 project IDs, which are rendered as bare
+	// numbers.
+	projectIDPattern = regexp.MustCompile(`^[0-9]+$`)
+
+	// envVarNamePattern matches environment variable names that can safely be set on __ENV.
+	envVarNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+	// scheduleFrequencies are the supported recurrence frequencies.
+	scheduleFrequencies = []string{"HOURLY", "DAILY", "WEEKLY", "MONTHLY", "YEARLY"}
+)
+
+// Generate renders the spec in the given format.
+//
+// The spec is resolved (validated, and its LoadTests computed) first. Terraform output is
+// additionally parsed as HCL before being returned.
+func Generate(spec *Spec, format Format) (string, error) {
+	templateName, ok := templateNames[format]
+	if !ok {
+		return "", fmt.Errorf("unsupported format %q", format)
+	}
+
+	if err := spec.Resolve(); err != nil {
+		return "", err
+	}
+
+	if format == FormatCDKTypeScript && spec.Schedule == nil {
+		return "", fmt.Errorf("the %s format schedules existing load tests and requires a schedule", format)
+	}
+
+	rendered, err := renderData(templateName, spec)
+	if err != nil {
+		return "", err
+	}
+
+	if format == FormatTerraform {
+		if err := validateHCL(rendered); err != nil {
+			return "", fmt.Errorf("generated Terraform configuration is invalid: %w", err)
+		}
+	}
+
+	return rendered, nil
+}
+
+// Resolve validates the spec and computes the load tests to render, one per environment
+// or a single one when no environments are defined.
+func (s *Spec) Resolve() error {
+	if !identifierPattern.MatchString(s.LoadTestResourceName) {
+		return fmt.Errorf("load_test_resource_name %q is not a valid resource identifier", s.LoadTestResourceName)
+	}
+
+	if s.Schedule != nil {
+		if err := s.Schedule.validate(); err != nil {
+			return err
+		}
+	}
+
+	s.LoadTests = nil
+	if len(s.Environments) == 0 {
+		if s.PerWorkspace {
+			return fmt.Errorf("per_workspace requires at least one environment")
+		}
+		if s.ProjectID == "" {
+			return fmt.Errorf("missing required parameter 'project_id'")
+		}
+		if !projectIDPattern.MatchString(s.ProjectID) {
+			return fmt.Errorf("project_id %q must be a numeric Grafana Cloud k6 project ID", s.ProjectID)
+		}
+		s.LoadTests = []LoadTest{{
+			ResourceName: s.LoadTestResourceName,
+			Name:         s.LoadTestName,
+			ProjectID:    s.ProjectID,
+			Script:       s.Script,
+		}}
+		return nil
+	}
+
+	seen := make(map[string]bool, len(s.Environments))
+	for _, env := range s.Environments {
+		if !identifierPattern.MatchString(env.Name) {
+			return fmt.Errorf("environment name %q must be a valid identifier (letters, digits, '_' and '-')", env.Name)
+		}
+		if seen[env.Name] {
+			return fmt.Errorf("environment %q is defined more than once", env.Name)
+		}
+		seen[env.Name] = true
+
+		test := LoadTest{
+			ResourceName: s.LoadTestResourceName + "_" + env.Name,
+			Name:         fmt.Sprintf("%s (%s)", s.LoadTestName, env.Name),
+			Notes:        env.Notes,
+			ProjectName:  env.ProjectName,
+		}
+		if s.PerWorkspace {
+			test.Workspace = env.Name
+		}
+
+		switch {
+		case env.ProjectName != "":
+			// The project is created along with the load test, which references it by its
+			// resource rather than by ID.
+		case env.ProjectID != "":
+			test.ProjectID = env.ProjectID
+		case s.ProjectID != "":
+			test.ProjectID = s.ProjectID
+		default:
+			return fmt.Errorf("environment %q requires either a project_id or a project_name", env.Name)
+		}
+		if test.ProjectID != "" && !projectIDPattern.MatchString(test.ProjectID) {
+			return fmt.Errorf("environment %q: project_id %q must be a numeric Grafana Cloud k6 project ID", env.Name, test.ProjectID)
+		}
+
+		script, err := withEnvironmentVariables(s.Script, env.Env)
+		if err != nil {
+			return fmt.Errorf("environment %q: %w", env.Name, err)
+		}
+		test.Script = script
+
+		s.LoadTests = append(s.LoadTests, test)
+	}
+
+	return nil
+}
+
+// validate checks the schedule is complete, and applies its defaults.
+func (sc *Schedule) validate() error {
+	if _, err := time.Parse(time.RFC3339, sc.Starts); err != nil {
+		return fmt.Errorf("schedule 'starts' must be an RFC3339 date, e.g. '2025-01-01T08:00:00Z'")
+	}
+
+	if sc.Cron != "" {
+		if len(strings.Fields(sc.Cron)) != cronFieldCount {
+			return fmt.Errorf("schedule 'cron' must have %d fields (minute hour day-of-month month day-of-week)", cronFieldCount)
+		}
+		if sc.TimeZone == "" {
+			sc.TimeZone = "UTC"
+		}
+		return nil
+	}
+
+	sc.Frequency = strings.ToUpper(sc.Frequency)
+	if !containsString(scheduleFrequencies, sc.Frequency) {
+		return fmt.Errorf("schedule requires either 'cron' or a 'frequency' among %s", strings.Join(scheduleFrequencies, ", "))
+	}
+	if sc.Interval <= 0 {
+		sc.Interval = 1
+	}
+	if sc.Frequency == "YEARLY" && sc.Interval > 1 {
+		return fmt.Errorf("schedule 'interval' is not supported with a YEARLY frequency: yearly schedules run every year")
+	}
+	if sc.Until != "" {
+		if _, err := time.Parse(time.RFC3339, sc.Until); err != nil {
+			return fmt.Errorf("schedule 'until' must be an RFC3339 date, e.g. '2025-12-31T08:00:00Z'")
+		}
+	}
+
+	return nil
+}
+
+// withEnvironmentVariables prepends the script with default values for the given
+// environment variables, so that the cloud test sees them through __ENV unless they
+// are explicitly overridden.
+func withEnvironmentVariables(script string, env map[string]string) (string, error) {
+	if len(env) == 0 {
+		return script, nil
+	}
+
+	names := make([]string, 0, len(env))
+	for name := range env {
+		if !envVarNamePattern.MatchString(name) {
+			return "", fmt.Errorf("invalid environment variable name %q", name)
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString("// Environment defaults generated by k6-mcp.\n")
+	for _, name := range names {
+		value, err := json.Marshal(env[name])
+		if err != nil {
+			return "", fmt.Errorf("invalid value for environment variable %q: %w", name, err)
+		}
+		fmt.Fprintf(&b, "if (__ENV.%s === undefined) { __ENV.%s = %s; }\n", name, name, value)
+	}
+	b.WriteString("\n")
+	b.WriteString(script)
+
+	return b.String(), nil
+}
+
+// renderData renders the named template, embedded or overridden, with the given data.
+func renderData(templateName string, data any) (string, error) {
+	tmpl, err := template.New(templateName).
+		Funcs(funcMap).
+		ParseFS(resources.FS(), "resources/templates/"+templateName)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse template %s: %w", templateName, err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to execute template %s: %w", templateName, err)
+	}
+
+	return strings.TrimSpace(buf.String()) + "\n", nil
+}
+
+// containsString reports whether values contains s.
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,119 @@
+package infra
+
+import (
+	"strings"
+	"testing"
+)
+
+const testScript = "import http from 'k6/http';\n\nexport default function () {\n  http.get(`${__ENV.BASE_URL}/\"quoted\"`);\n}\n"
+
+func TestGenerateTerraformIsValidHCL(t *testing.T) {
+	t.Parallel()
+
+	spec := &Spec{
+		LoadTestName:         "Checkout",
+		LoadTestResourceName: "checkout",
+		Script:               testScript,
+		ProjectID:            "3688954",
+		Notes:                "Owned by the checkout team.",
+		Schedule:             &Schedule{Starts: "2026-01-01T00:00:00Z", Frequency: "DAILY"},
+		Environments: []Environment{
+			{Name: "staging", Env: map[string]string{"BASE_URL": "https://staging.example.com"}},
+			{Name: "production", ProjectName: "Checkout \"prod\" ${var.x}"},
+		},
+		PerWorkspace: true,
+	}
+
+	rendered, err := Generate(spec, FormatTerraform)
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if !strings.Contains(rendered, "project_id = 3688954") {
+		t.Errorf("rendered configuration lacks the project ID:\n%s", rendered)
+	}
+}
+
+func TestValidateHCL(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		src     string
+		wantErr bool
+	}{
+		{name: "valid", src: "resource \"a\" \"b\" {\n  name = \"x\"\n}\n"},
+		{name: "heredoc", src: "resource \"a\" \"b\" {\n  script = <<-EOT\n    x\n  EOT\n}\n"},
+		{name: "unterminated string", src: "resource \"a\" \"b\" {\n  name = \"x\n}\n", wantErr: true},
+		{name: "unclosed block", src: "resource \"a\" \"b\" {\n  name = \"x\"\n", wantErr: true},
+		{name: "unterminated heredoc", src: "resource \"a\" \"b\" {\n  script = <<-EOT\n    x\n}\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if err := validateHCL(tt.src); (err != nil) != tt.wantErr {
+				t.Errorf("validateHCL() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestResolveRejectsNonNumericProjectID(t *testing.T) {
+	t.Parallel()
+
+	injected := "1\n}\nresource \"null_resource\" \"x\" {"
+	tests := []struct {
+		name string
+		spec Spec
+	}{
+		{name: "project", spec: Spec{LoadTestResourceName: "test", ProjectID: injected}},
+		{name: "environment", spec: Spec{
+			LoadTestResourceName: "test",
+			Environments:         []Environment{{Name: "staging", ProjectID: injected}},
+		}},
+		{name: "inherited by environment", spec: Spec{
+			LoadTestResourceName: "test",
+			ProjectID:            "abc",
+			Environments:         []Environment{{Name: "staging"}},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if err := tt.spec.Resolve(); err == nil || !strings.Contains(err.Error(), "project_id") {
+				t.Errorf("Resolve() error = %v, want a project_id error", err)
+			}
+		})
+	}
+}
+
+func TestResolveSchedule(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		schedule Schedule
+		wantErr  bool
+	}{
+		{name: "yearly", schedule: Schedule{Starts: "2026-01-01T00:00:00Z", Frequency: "yearly"}},
+		{name: "yearly every year", schedule: Schedule{Starts: "2026-01-01T00:00:00Z", Frequency: "YEARLY", Interval: 1}},
+		{name: "yearly every two years", schedule: Schedule{Starts: "2026-01-01T00:00:00Z", Frequency: "YEARLY", Interval: 2}, wantErr: true},
+		{name: "monthly every two months", schedule: Schedule{Starts: "2026-01-01T00:00:00Z", Frequency: "MONTHLY", Interval: 2}},
+		{name: "unknown frequency", schedule: Schedule{Starts: "2026-01-01T00:00:00Z", Frequency: "SECONDLY"}, wantErr: true},
+		{name: "invalid start", schedule: Schedule{Starts: "tomorrow", Frequency: "DAILY"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			spec := Spec{LoadTestResourceName: "test", ProjectID: "1", Schedule: &tt.schedule}
+			if err := spec.Resolve(); (err != nil) != tt.wantErr {
+				t.Errorf("Resolve() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,255 @@
+package infra
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+	"unicode"
+)
+
+// cronFieldCount is the number of fields of the supported cron expressions.
+const cronFieldCount = 5
+
+// funcMap defines the custom functions available to the templates. Functions whose
+// rendering depends on the target language are prefixed with it.
+var funcMap = template.FuncMap{
+	"indent":        indent,
+	"hclQuote":      hclQuote,
+	"hclHeredoc":    hclHeredocEscape,
+	"hclComment":    hclComment,
+	"jsString":      jsString,
+	"jsTemplate":    jsTemplateEscape,
+	"jsComment":     jsComment,
+	"pyString":      jsString,
+	"pyTripleQuote": pyTripleQuoteEscape,
+	"pyComment":     hclComment,
+	"identifier":    identifier,
+	"pascal":        pascal,
+	"awsSchedule":   awsScheduleExpression,
+	"awsDate":       awsDate,
+	"yamlString":    yamlString,
+	"yamlBlock":     yamlBlock,
+	"composeEscape": composeEscape,
+}
+
+// indent prefixes each non-empty line of content with prefix.
+func indent(prefix string, content string) string {
+	// Split the content into lines
+	lines := strings.Split(content, "\n")
+
+	// Add the prefix to each line
+	for i, line := range lines {
+		// We don't want to indent empty lines, which could happen with a trailing newline
+		if len(strings.TrimSpace(line)) > 0 {
+			lines[i] = prefix + line
+		}
+	}
+
+	// Join the lines back together
+	return strings.Join(lines, "\n")
+}
+
+// hclQuote renders s as a quoted HCL string literal, escaping template sequences.
+func hclQuote(s string) string {
+	escaped := strings.NewReplacer(
+		`\`, `\\`,
+		`"`, `\"`,
+		"\n", `\n`,
+		"\r", `\r`,
+		"\t", `\t`,
+	).Replace(hclHeredocEscape(s))
+
+	return `"` + escaped + `"`
+}
+
+// hclHeredocEscape escapes the HCL template sequences (${ and %{) found in s, so that
+// JavaScript template literals in scripts are not interpreted by Terraform.
+func hclHeredocEscape(s string) string {
+	return strings.NewReplacer("${", "$${", "%{", "%%{").Replace(s)
+}
+
+// hclComment renders s as a block of '#' line comments, as used by HCL and Python.
+func hclComment(s string) string {
+	return lineComment("#", s)
+}
+
+// jsComment renders s as a block of '//' line comments.
+func jsComment(s string) string {
+	return lineComment("//", s)
+}
+
+func lineComment(marker string, s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(marker+" "+line, " ")
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// jsString renders s as a double-quoted string literal, valid in both JavaScript and Python.
+func jsString(s string) string {
+	// JSON string literals are valid JavaScript and Python string literals, and
+	// encoding a string can't fail.
+	encoded, _ := json.Marshal(s)
+
+	return string(encoded)
+}
+
+// jsTemplateEscape escapes s so that it can be embedded verbatim in a JavaScript template literal.
+func jsTemplateEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "`", "\\`", "${", `\${`).Replace(s)
+}
+
+// pyTripleQuoteEscape escapes s so that it can be embedded verbatim in a Python
+// triple-quoted string literal.
+func pyTripleQuoteEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"""`, `\"\"\"`).Replace(s)
+}
+
+// identifier converts a resource name into a valid JavaScript and Python identifier.
+func identifier(name string) string {
+	return strings.ReplaceAll(name, "-", "_")
+}
+
+// pascal converts a resource name into PascalCase, e.g. "api_smoke-test" becomes "ApiSmokeTest".
+func pascal(name string) string {
+	var b strings.Builder
+	for _, part := range strings.FieldsFunc(name, func(r rune) bool { return r == '_' || r == '-' }) {
+		runes := []rune(part)
+		runes[0] = unicode.ToUpper(runes[0])
+		b.WriteString(string(runes))
+	}
+
+	return b.String()
+}
+
+// awsDate converts an RFC3339 date into the UTC date format expected by EventBridge Scheduler.
+func awsDate(s string) (string, error) {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return "", err
+	}
+
+	return t.UTC().Format("2006-01-02T15:04:05Z"), nil
+}
+
+// awsScheduleExpression converts a schedule into an EventBridge Scheduler expression.
+//
+// Recurrence rules in hours, days and weeks become rate expressions; monthly and yearly
+// ones become cron expressions anchored on the schedule's start date.
+func awsScheduleExpression(sc *Schedule) (string, error) {
+	if sc.Cron != "" {
+		return awsCron(sc.Cron)
+	}
+
+	if sc.Count > 0 {
+		return "", fmt.Errorf("EventBridge Scheduler does not support a run count; use 'until' instead")
+	}
+
+	switch sc.Frequency {
+	case "HOURLY":
+		return awsRate(sc.Interval, "hour"), nil
+	case "DAILY":
+		return awsRate(sc.Interval, "day"), nil
+	case "WEEKLY":
+		return awsRate(sc.Interval*7, "day"), nil
+	}
+
+	starts, err := time.Parse(time.RFC3339, sc.Starts)
+	if err != nil {
+		return "", err
+	}
+	starts = starts.UTC()
+
+	month := "*"
+	if sc.Frequency == "YEARLY" {
+		month = strconv.Itoa(int(starts.Month()))
+	} else if sc.Interval > 1 {
+		month = fmt.Sprintf("%d/%d", int(starts.Month()), sc.Interval)
+	}
+
+	return fmt.Sprintf("cron(%d %d %d %s ? *)", starts.Minute(), starts.Hour(), starts.Day(), month), nil
+}
+
+// awsRate renders a rate expression, using the singular unit when value is 1 as required by AWS.
+func awsRate(value int, unit string) string {
+	if value != 1 {
+		unit += "s"
+	}
+
+	return fmt.Sprintf("rate(%d %s)", value, unit)
+}
+
+// awsCron converts a standard five-field cron expression into the AWS six-field syntax,
+// in which one of day-of-month and day-of-week must be '?' and days of the week are
+// numbered from 1 (Sunday) to 7.
+func awsCron(expr string) (string, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != cronFieldCount {
+		return "", fmt.Errorf("cron expression %q must have %d fields", expr, cronFieldCount)
+	}
+
+	dom, dow := fields[2], fields[4]
+	switch {
+	case dow == "*":
+		dow = "?"
+	case dom == "*":
+		dom = "?"
+		converted, err := awsDaysOfWeek(dow)
+		if err != nil {
+			return "", err
+		}
+		dow = converted
+	default:
+		return "", fmt.Errorf("cron expression %q restricts both the day of month and the day of week, which AWS does not support", expr)
+	}
+
+	return fmt.Sprintf("cron(%s %s %s %s %s *)", fields[0], fields[1], dom, fields[3], dow), nil
+}
+
+// awsDaysOfWeek shifts the numeric days of a cron day-of-week field from the 0-6
+// (Sunday first) numbering to AWS' 1-7 one. Step values and day names are left untouched.
+func awsDaysOfWeek(field string) (string, error) {
+	base, step, hasStep := strings.Cut(field, "/")
+
+	var b strings.Builder
+	start := -1
+	flush := func(end int) error {
+		if start < 0 {
+			return nil
+		}
+		day, err := strconv.Atoi(base[start:end])
+		if err != nil || day > 7 {
+			return fmt.Errorf("invalid day of week %q", base[start:end])
+		}
+		b.WriteString(strconv.Itoa(day%7 + 1))
+		start = -1
+		return nil
+	}
+
+	for i, r := range base {
+		if unicode.IsDigit(r) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if err := flush(i); err != nil {
+			return "", err
+		}
+		b.WriteRune(r)
+	}
+	if err := flush(len(base)); err != nil {
+		return "", err
+	}
+
+	if hasStep {
+		return b.String() + "/" + step, nil
+	}
+
+	return b.String(), nil
+}
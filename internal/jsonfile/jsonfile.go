@@ -0,0 +1,68 @@
+// Package jsonfile reads and writes the JSON files the stores of the server keep their
+// records in. Files are replaced atomically, so that readers, including other server
+// processes sharing the data directory, never see a partial write.
+package jsonfile
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/oleiade/k6-mcp/internal/security"
+)
+
+// Load decodes the JSON file at path into v. A missing file is not an error: v is left as
+// is, so that callers initialize it with the empty value of their records.
+func Load(path string, v any) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// SaveAtomic encodes v as indented JSON, and atomically replaces the file at path with it,
+// creating its directory if needed. The file and directory are only accessible to their
+// owner.
+//
+// The content is written to a uniquely named temporary file next to path, then renamed into
+// place, so that concurrent writers never clobber each other's in-flight writes: the last
+// rename wins.
+func SaveAtomic(path string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", filepath.Base(path), err)
+	}
+
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, security.SecureDirMode); err != nil {
+		return fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+
+	// Temporary files are created with mode 0600, the mode of the stores
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
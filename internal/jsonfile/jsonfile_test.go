@@ -0,0 +1,106 @@
+package jsonfile
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/oleiade/k6-mcp/internal/security"
+)
+
+func TestSaveAtomicAndLoad(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "store", "records.json")
+	want := map[string]int{"a": 1, "b": 2}
+	if err := SaveAtomic(path, want); err != nil {
+		t.Fatalf("SaveAtomic: %v", err)
+	}
+
+	got := map[string]int{}
+	if err := Load(path, &got); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(got) != len(want) || got["a"] != 1 || got["b"] != 2 {
+		t.Errorf("Load() = %v, want %v", got, want)
+	}
+
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("Stat: %v", err)
+		}
+		if mode := info.Mode().Perm(); mode != security.SecureFileMode {
+			t.Errorf("file mode = %o, want %o", mode, security.SecureFileMode)
+		}
+	}
+}
+
+func TestLoadMissingFile(t *testing.T) {
+	t.Parallel()
+
+	got := map[string]int{"kept": 1}
+	if err := Load(filepath.Join(t.TempDir(), "missing.json"), &got); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(got) != 1 || got["kept"] != 1 {
+		t.Errorf("Load() changed the value to %v", got)
+	}
+}
+
+func TestLoadInvalidFile(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "invalid.json")
+	if err := os.WriteFile(path, []byte("{"), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	var got map[string]int
+	if err := Load(path, &got); err == nil {
+		t.Error("Load() of an invalid file returned no error")
+	}
+}
+
+func TestSaveAtomicConcurrentWriters(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "records.json")
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- SaveAtomic(path, map[string]string{"writer": strconv.Itoa(i)})
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("SaveAtomic: %v", err)
+		}
+	}
+
+	var got map[string]string
+	if err := Load(path, &got); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if got["writer"] == "" {
+		t.Errorf("Load() = %v, want the records of one of the writers", got)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ReadDir: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("directory holds %d entries, want only the file: temporary files were left behind", len(entries))
+	}
+}
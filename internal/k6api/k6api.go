@@ -0,0 +1,162 @@
+// Package k6api controls k6 runs through the REST API k6 serves on its --address while
+// it runs: it reads their status, pauses and resumes them, and scales their VUs.
+package k6api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// requestTimeout bounds API requests, which k6 answers from memory.
+	requestTimeout = 5 * time.Second
+	// maxResponseBytes is the maximum size of API responses.
+	maxResponseBytes = 64 * 1024
+	// statusPath is the path of the status resource of the API.
+	statusPath = "/v1/status"
+)
+
+// Status is the execution status of a k6 run.
+type Status struct {
+	// Paused reports whether the run is paused, Running whether its scenarios are
+	// executing, and Stopped whether it was stopped.
+	Paused  bool `json:"paused"`
+	Running bool `json:"running"`
+	Stopped bool `json:"stopped"`
+	// Tainted reports whether a threshold of the run has already failed.
+	Tainted bool `json:"tainted"`
+	// VUs is the number of active virtual users, and VUsMax the number allocated.
+	VUs    int `json:"vus"`
+	VUsMax int `json:"vus-max"`
+}
+
+// statusUpdate holds the attributes of a status change; unset attributes are unchanged.
+type statusUpdate struct {
+	Paused *bool `json:"paused,omitempty"`
+	VUs    *int  `json:"vus,omitempty"`
+	VUsMax *int  `json:"vus-max,omitempty"`
+}
+
+// document is a JSON:API document of the status resource, as k6 reads and writes it.
+type document[T any] struct {
+	Data struct {
+		Type       string `json:"type"`
+		ID         string `json:"id"`
+		Attributes T      `json:"attributes"`
+	} `json:"data"`
+}
+
+// errorDocument is a JSON:API document of the errors of a request.
+type errorDocument struct {
+	Errors []struct {
+		Title  string `json:"title"`
+		Detail string `json:"detail"`
+	} `json:"errors"`
+}
+
+// Client calls the REST API of a k6 process.
+type Client struct {
+	baseURL string
+	client  *http.Client
+}
+
+// NewClient returns a client of the API k6 serves on address, as passed to its --address
+// flag, e.g. 127.0.0.1:6565.
+func NewClient(address string) *Client {
+	return &Client{baseURL: "http://" + address, client: &http.Client{Timeout: requestTimeout}}
+}
+
+// Status returns the execution status of the run.
+func (c *Client) Status(ctx context.Context) (Status, error) {
+	return c.do(ctx, http.MethodGet, nil)
+}
+
+// Pause pauses the run: VUs finish their iteration in progress, then start no other
+// until the run is resumed. Paused time counts towards the duration of the run.
+func (c *Client) Pause(ctx context.Context) (Status, error) {
+	paused := true
+	return c.do(ctx, http.MethodPatch, &statusUpdate{Paused: &paused})
+}
+
+// Resume resumes the paused run.
+func (c *Client) Resume(ctx context.Context) (Status, error) {
+	paused := false
+	return c.do(ctx, http.MethodPatch, &statusUpdate{Paused: &paused})
+}
+
+// Scale sets the active VUs of the run and, when maxVUs is positive, the VUs allocated.
+// k6 only scales runs of the externally-controlled executor.
+func (c *Client) Scale(ctx context.Context, vus, maxVUs int) (Status, error) {
+	update := &statusUpdate{VUs: &vus}
+	if maxVUs > 0 {
+		update.VUsMax = &maxVUs
+	}
+	return c.do(ctx, http.MethodPatch, update)
+}
+
+// do calls the status resource, with the update when there is one, and returns the status
+// of the response.
+func (c *Client) do(ctx context.Context, method string, update *statusUpdate) (Status, error) {
+	var body io.Reader
+	if update != nil {
+		payload := document[*statusUpdate]{}
+		payload.Data.Type, payload.Data.ID, payload.Data.Attributes = "status", "default", update
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return Status{}, fmt.Errorf("failed to encode status update: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+statusPath, body)
+	if err != nil {
+		return Status{}, fmt.Errorf("failed to build request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return Status{}, fmt.Errorf("k6 API request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return Status{}, fmt.Errorf("failed to read k6 API response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return Status{}, fmt.Errorf("k6 API returned %s: %s", resp.Status, errorMessage(data))
+	}
+
+	var response document[Status]
+	if err := json.Unmarshal(data, &response); err != nil {
+		return Status{}, fmt.Errorf("unexpected k6 API response: %w", err)
+	}
+	return response.Data.Attributes, nil
+}
+
+// errorMessage returns the message of the JSON:API errors of a response body, or the body
+// itself when it holds none.
+func errorMessage(body []byte) string {
+	var errs errorDocument
+	if err := json.Unmarshal(body, &errs); err == nil && len(errs.Errors) > 0 {
+		messages := make([]string, 0, len(errs.Errors))
+		for _, e := range errs.Errors {
+			message := e.Title
+			if e.Detail != "" {
+				message += ": " + e.Detail
+			}
+			messages = append(messages, message)
+		}
+		return strings.Join(messages, "; ")
+	}
+	return strings.TrimSpace(string(body))
+}
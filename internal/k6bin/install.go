@@ -0,0 +1,322 @@
+package k6bin
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultTimeout is the default timeout for downloading and installing k6.
+	DefaultTimeout = 5 * time.Minute
+	// MaxDownloadBytes is the maximum size of a downloaded k6 release archive.
+	MaxDownloadBytes = 200 * 1024 * 1024
+
+	releasesURL      = "https://github.com/grafana/k6/releases/download"
+	latestReleaseURL = "https://api.github.com/repos/grafana/k6/releases/latest"
+	versionTimeout   = 10 * time.Second
+)
+
+// Error represents errors that occur while installing k6.
+type Error struct {
+	Type    string
+	Message string
+	Cause   error
+}
+
+func (e *Error) Error() string {
+	if e.Cause != nil {
+		return fmt.Sprintf("%s: %s (caused by: %v)", e.Type, e.Message, e.Cause)
+	}
+	return fmt.Sprintf("%s: %s", e.Type, e.Message)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Cause
+}
+
+// InstallResult describes an installed k6 release.
+type InstallResult struct {
+	Version string `json:"version"`
+	Path    string `json:"path"`
+	Asset   string `json:"asset"`
+	SHA256  string `json:"sha256"`
+	Output  string `json:"k6_version_output,omitempty"`
+}
+
+// Install downloads the given k6 release (e.g. "1.2.0", or "latest" when empty) for the
+// current platform from GitHub, verifies it against the release checksums, and installs
+// its executable into the managed directory.
+func Install(ctx context.Context, version string) (*InstallResult, error) {
+	dir := ManagedDir()
+	if dir == "" {
+		return nil, &Error{Type: "NOT_CONFIGURED", Message: "no directory configured to install k6 into"}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, DefaultTimeout)
+	defer cancel()
+
+	client := &http.Client{Timeout: DefaultTimeout}
+
+	version = strings.TrimPrefix(strings.TrimSpace(version), "v")
+	if version == "" || version == "latest" {
+		latest, err := latestVersion(ctx, client)
+		if err != nil {
+			return nil, err
+		}
+		version = latest
+	}
+
+	asset, err := assetName(version)
+	if err != nil {
+		return nil, err
+	}
+
+	checksums, err := download(ctx, client, fmt.Sprintf("%s/v%s/k6-v%s-checksums.txt", releasesURL, version, version), nil)
+	if err != nil {
+		return nil, err
+	}
+	expected, err := checksumFor(checksums.body, asset)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, &Error{Type: "INSTALL_ERROR", Message: "failed to create the k6 directory", Cause: err}
+	}
+
+	archiveFile, err := os.CreateTemp(dir, asset+".tmp-*")
+	if err != nil {
+		return nil, &Error{Type: "INSTALL_ERROR", Message: "failed to create the download file", Cause: err}
+	}
+	defer func() {
+		_ = archiveFile.Close()
+		_ = os.Remove(archiveFile.Name())
+	}()
+
+	downloaded, err := download(ctx, client, fmt.Sprintf("%s/v%s/%s", releasesURL, version, asset), archiveFile)
+	if err != nil {
+		return nil, err
+	}
+	if downloaded.sha256 != expected {
+		return nil, &Error{
+			Type:    "CHECKSUM_MISMATCH",
+			Message: fmt.Sprintf("checksum of %s is %s, expected %s", asset, downloaded.sha256, expected),
+		}
+	}
+
+	executable := filepath.Join(dir, executableName())
+	if err := extractExecutable(archiveFile, asset, executable); err != nil {
+		return nil, err
+	}
+
+	result := &InstallResult{
+		Version: version,
+		Path:    executable,
+		Asset:   asset,
+		SHA256:  downloaded.sha256,
+	}
+
+	// Check that the installed executable runs on this platform
+	versionCtx, versionCancel := context.WithTimeout(ctx, versionTimeout)
+	defer versionCancel()
+	// #nosec G204 - the executable was verified against the release checksums
+	output, err := exec.CommandContext(versionCtx, executable, "version").CombinedOutput()
+	if err != nil {
+		return nil, &Error{Type: "INSTALL_ERROR", Message: "the installed k6 executable failed to run", Cause: err}
+	}
+	result.Output = strings.TrimSpace(string(output))
+
+	return result, nil
+}
+
+// assetName returns the name of the release archive of the given version for the current platform.
+func assetName(version string) (string, error) {
+	var platform, extension string
+	switch runtime.GOOS {
+	case "linux":
+		platform, extension = "linux", ".tar.gz"
+	case "darwin":
+		platform, extension = "macos", ".zip"
+	case "windows":
+		platform, extension = "windows", ".zip"
+	default:
+		return "", &Error{Type: "UNSUPPORTED_PLATFORM", Message: "no k6 release is published for " + runtime.GOOS}
+	}
+
+	switch runtime.GOARCH {
+	case "amd64", "arm64":
+	default:
+		return "", &Error{Type: "UNSUPPORTED_PLATFORM", Message: "no k6 release is published for " + runtime.GOARCH}
+	}
+
+	return fmt.Sprintf("k6-v%s-%s-%s%s", version, platform, runtime.GOARCH, extension), nil
+}
+
+// latestVersion returns the version of the latest k6 release.
+func latestVersion(ctx context.Context, client *http.Client) (string, error) {
+	response, err := download(ctx, client, latestReleaseURL, nil)
+	if err != nil {
+		return "", err
+	}
+
+	var release struct {
+		TagName string `json:"tag_name"`
+	}
+	if err := json.Unmarshal(response.body, &release); err != nil || release.TagName == "" {
+		return "", &Error{Type: "DOWNLOAD_ERROR", Message: "failed to read the latest k6 release", Cause: err}
+	}
+
+	return strings.TrimPrefix(release.TagName, "v"), nil
+}
+
+// checksumFor returns the SHA-256 checksum of the given asset listed in a checksums file.
+func checksumFor(checksums []byte, asset string) (string, error) {
+	scanner := bufio.NewScanner(strings.NewReader(string(checksums)))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && fields[1] == asset {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+
+	return "", &Error{Type: "CHECKSUM_MISSING", Message: "no checksum is published for " + asset}
+}
+
+// downloaded is a downloaded file. Its body is only kept when download had no destination.
+type downloaded struct {
+	body   []byte
+	sha256 string
+}
+
+// download fetches the given URL into dst, or in memory when dst is nil.
+func download(ctx context.Context, client *http.Client, url string, dst io.Writer) (*downloaded, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, &Error{Type: "DOWNLOAD_ERROR", Message: "invalid download URL", Cause: err}
+	}
+	request.Header.Set("User-Agent", "k6-mcp")
+
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, &Error{Type: "DOWNLOAD_ERROR", Message: "failed to download " + path.Base(url), Cause: err}
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, &Error{Type: "DOWNLOAD_ERROR", Message: fmt.Sprintf("failed to download %s: HTTP %d", path.Base(url), response.StatusCode)}
+	}
+
+	hash := sha256.New()
+	result := &downloaded{}
+	body := io.LimitReader(response.Body, MaxDownloadBytes+1)
+
+	var written int64
+	if dst == nil {
+		result.body, err = io.ReadAll(io.TeeReader(body, hash))
+		written = int64(len(result.body))
+	} else {
+		written, err = io.Copy(io.MultiWriter(dst, hash), body)
+	}
+	if err != nil {
+		return nil, &Error{Type: "DOWNLOAD_ERROR", Message: "failed to download " + path.Base(url), Cause: err}
+	}
+	if written > MaxDownloadBytes {
+		return nil, &Error{Type: "DOWNLOAD_ERROR", Message: fmt.Sprintf("%s exceeds %d bytes", path.Base(url), MaxDownloadBytes)}
+	}
+
+	result.sha256 = hex.EncodeToString(hash.Sum(nil))
+	return result, nil
+}
+
+// extractExecutable extracts the k6 executable from the downloaded release archive to dst.
+func extractExecutable(archiveFile *os.File, asset, dst string) error {
+	if _, err := archiveFile.Seek(0, io.SeekStart); err != nil {
+		return &Error{Type: "INSTALL_ERROR", Message: "failed to read the release archive", Cause: err}
+	}
+
+	var executable io.Reader
+	if strings.HasSuffix(asset, ".zip") {
+		info, err := archiveFile.Stat()
+		if err != nil {
+			return &Error{Type: "INSTALL_ERROR", Message: "failed to read the release archive", Cause: err}
+		}
+		reader, err := zip.NewReader(archiveFile, info.Size())
+		if err != nil {
+			return &Error{Type: "INSTALL_ERROR", Message: "invalid release archive", Cause: err}
+		}
+		for _, file := range reader.File {
+			if path.Base(file.Name) == executableName() && !file.FileInfo().IsDir() {
+				rc, err := file.Open()
+				if err != nil {
+					return &Error{Type: "INSTALL_ERROR", Message: "failed to read the release archive", Cause: err}
+				}
+				defer rc.Close()
+				executable = rc
+				break
+			}
+		}
+	} else {
+		gz, err := gzip.NewReader(archiveFile)
+		if err != nil {
+			return &Error{Type: "INSTALL_ERROR", Message: "invalid release archive", Cause: err}
+		}
+		defer gz.Close()
+		reader := tar.NewReader(gz)
+		for {
+			header, err := reader.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return &Error{Type: "INSTALL_ERROR", Message: "invalid release archive", Cause: err}
+			}
+			if header.Typeflag == tar.TypeReg && path.Base(header.Name) == executableName() {
+				executable = reader
+				break
+			}
+		}
+	}
+	if executable == nil {
+		return &Error{Type: "INSTALL_ERROR", Message: "the release archive contains no k6 executable"}
+	}
+
+	// Write next to the destination and rename, so that concurrent runs never see a partial executable
+	tmpFile, err := os.CreateTemp(filepath.Dir(dst), executableName()+".tmp-*")
+	if err != nil {
+		return &Error{Type: "INSTALL_ERROR", Message: "failed to create the k6 executable", Cause: err}
+	}
+	defer func() { _ = os.Remove(tmpFile.Name()) }()
+
+	if _, err := io.Copy(tmpFile, io.LimitReader(executable, MaxDownloadBytes)); err != nil {
+		_ = tmpFile.Close()
+		return &Error{Type: "INSTALL_ERROR", Message: "failed to write the k6 executable", Cause: err}
+	}
+	if err := tmpFile.Close(); err != nil {
+		return &Error{Type: "INSTALL_ERROR", Message: "failed to write the k6 executable", Cause: err}
+	}
+	// #nosec G302 - the executable must be executable
+	if err := os.Chmod(tmpFile.Name(), 0o755); err != nil {
+		return &Error{Type: "INSTALL_ERROR", Message: "failed to make k6 executable", Cause: err}
+	}
+	if err := os.Rename(tmpFile.Name(), dst); err != nil {
+		return &Error{Type: "INSTALL_ERROR", Message: "failed to install the k6 executable", Cause: err}
+	}
+
+	return nil
+}
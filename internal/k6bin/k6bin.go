@@ -0,0 +1,63 @@
+// Package k6bin locates the k6 executable used to validate, run and archive scripts, and
+// installs official k6 releases into a directory managed by the server when k6 is missing.
+package k6bin
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sync"
+)
+
+// NotFoundMessage describes a missing k6 executable, and how to get one.
+const NotFoundMessage = "k6 executable not found in PATH. Install k6 (https://grafana.com/docs/k6/latest/set-up/install-k6/), or use the setup_k6 tool to download it"
+
+// ErrNotFound is returned when neither k6 from PATH nor a managed k6 is available.
+var ErrNotFound = errors.New("k6 executable not found")
+
+var (
+	mu         sync.RWMutex
+	managedDir string
+)
+
+// SetManagedDir sets the directory k6 is installed into by Install, and looked up in by
+// Find when it isn't in PATH.
+func SetManagedDir(dir string) {
+	mu.Lock()
+	defer mu.Unlock()
+	managedDir = dir
+}
+
+// ManagedDir returns the directory k6 is installed into.
+func ManagedDir() string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return managedDir
+}
+
+// Find returns the path of the k6 executable: the one in PATH if any, otherwise the one
+// installed in the managed directory.
+func Find() (string, error) {
+	if path, err := exec.LookPath("k6"); err == nil {
+		return path, nil
+	}
+
+	if dir := ManagedDir(); dir != "" {
+		path := filepath.Join(dir, executableName())
+		if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+			return path, nil
+		}
+	}
+
+	return "", ErrNotFound
+}
+
+// executableName returns the file name of the k6 executable on the current platform.
+func executableName() string {
+	if runtime.GOOS == "windows" {
+		return "k6.exe"
+	}
+	return "k6"
+}
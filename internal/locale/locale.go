@@ -0,0 +1,111 @@
+// Package locale translates the guidance of the server, such as the recommendations and
+// next steps of validations and runs, and the hints of tool errors, into the language of
+// the configured locale. Messages are keyed by their English text: messages missing from
+// the catalog of the locale are left in English.
+package locale
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Default is the locale of the server when none is configured.
+const Default = "en"
+
+//go:embed catalogs/*.json
+var catalogFiles embed.FS
+
+// catalogs are the translations of messages, keyed by English message, by locale.
+var catalogs = mustLoadCatalogs()
+
+var (
+	mu      sync.RWMutex
+	current = Default
+)
+
+// Supported returns the supported locales, sorted.
+func Supported() []string {
+	locales := []string{Default}
+	for locale := range catalogs {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// Parse returns the supported locale of a locale identifier such as fr, fr-FR or
+// fr_FR.UTF-8.
+func Parse(value string) (string, error) {
+	locale := strings.ToLower(strings.TrimSpace(value))
+	locale, _, _ = strings.Cut(locale, ".")
+	locale, _, _ = strings.Cut(locale, "@")
+	locale, _, _ = strings.Cut(strings.ReplaceAll(locale, "_", "-"), "-")
+
+	if _, ok := catalogs[locale]; ok || locale == Default {
+		return locale, nil
+	}
+	return "", fmt.Errorf("unsupported locale %q; supported locales are %s", value, strings.Join(Supported(), ", "))
+}
+
+// Set sets the locale messages are translated into.
+func Set(locale string) {
+	mu.Lock()
+	defer mu.Unlock()
+	current = locale
+}
+
+// Current returns the configured locale, or Default.
+func Current() string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current
+}
+
+// T translates a message into the configured locale.
+func T(message string) string {
+	if translated, ok := catalogs[Current()][message]; ok {
+		return translated
+	}
+	return message
+}
+
+// Sprintf formats the translation of format into the configured locale.
+func Sprintf(format string, args ...any) string {
+	return fmt.Sprintf(T(format), args...)
+}
+
+// List translates messages into the configured locale, in place, and returns them.
+func List(messages []string) []string {
+	for i, message := range messages {
+		messages[i] = T(message)
+	}
+	return messages
+}
+
+// mustLoadCatalogs reads the embedded catalogs, named after their locale.
+func mustLoadCatalogs() map[string]map[string]string {
+	entries, err := catalogFiles.ReadDir("catalogs")
+	if err != nil {
+		panic(err)
+	}
+
+	catalogs := make(map[string]map[string]string, len(entries))
+	for _, entry := range entries {
+		data, err := catalogFiles.ReadFile(path.Join("catalogs", entry.Name()))
+		if err != nil {
+			panic(err)
+		}
+		var catalog map[string]string
+		if err := json.Unmarshal(data, &catalog); err != nil {
+			panic(fmt.Sprintf("invalid catalog %s: %v", entry.Name(), err))
+		}
+		catalogs[strings.TrimSuffix(entry.Name(), ".json")] = catalog
+	}
+
+	return catalogs
+}
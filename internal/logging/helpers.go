@@ -6,13 +6,15 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"sort"
 	"strings"
 	"time"
 )
 
-// RequestStart logs the beginning of an MCP request
-func RequestStart(ctx context.Context, toolName string, params map[string]interface{}) {
-	logger := WithTool(toolName)
+// RequestStart logs the beginning of an MCP request, identified by the Request of the
+// context.
+func RequestStart(ctx context.Context, params map[string]interface{}) {
+	logger := WithComponent("mcp")
 
 	// Sanitize parameters for logging (exclude large script content)
 	sanitizedParams := sanitizeParams(params)
@@ -23,9 +25,10 @@ func RequestStart(ctx context.Context, toolName string, params map[string]interf
 	)
 }
 
-// RequestEnd logs the completion of an MCP request
-func RequestEnd(ctx context.Context, toolName string, success bool, duration time.Duration, err error) {
-	logger := WithTool(toolName)
+// RequestEnd logs the completion of an MCP request, identified by the Request of the
+// context.
+func RequestEnd(ctx context.Context, success bool, duration time.Duration, err error) {
+	logger := WithComponent("mcp")
 
 	if err != nil {
 		logger.ErrorContext(ctx, "MCP request failed",
@@ -177,19 +180,37 @@ func FileOperation(ctx context.Context, component string, operation string, path
 	if err != nil {
 		logger.ErrorContext(ctx, "File operation failed",
 			slog.String("operation", operation),
-			slog.String("path_type", getPathType(path)), // Avoid logging full paths
+			slog.String("path_type", PathType(path)), // Avoid logging full paths
 			slog.String("error", err.Error()),
 			slog.String("error_type", getErrorType(err)),
 		)
 	} else {
 		logger.DebugContext(ctx, "File operation completed",
 			slog.String("operation", operation),
-			slog.String("path_type", getPathType(path)), // Avoid logging full paths
+			slog.String("path_type", PathType(path)), // Avoid logging full paths
 		)
 	}
 }
 
-// sanitizeParams removes or truncates large parameters for logging
+// contentParams are the parameters carrying content, such as scripts, the data files
+// scrub_data masks, responses, and the headers, environment variables and tokens holding
+// credentials, such as the Authorization headers of generate_checks. Only their size, or
+// their keys, are logged.
+var contentParams = map[string]bool{
+	"script":        true,
+	"files":         true,
+	"response":      true,
+	"body":          true,
+	"bundle_base64": true,
+	"headers":       true,
+	"env":           true,
+	"token":         true,
+	"outputs":       true,
+}
+
+// sanitizeParams removes or truncates large parameters for logging, and redacts the
+// content parameters, including those nested in other parameters such as the scripts
+// of a suite.
 func sanitizeParams(params map[string]interface{}) map[string]interface{} {
 	if params == nil {
 		return nil
@@ -198,23 +219,56 @@ func sanitizeParams(params map[string]interface{}) map[string]interface{} {
 	sanitized := make(map[string]interface{})
 
 	for key, value := range params {
-		switch key {
-		case "script":
-			// For script content, only log metadata
-			if str, ok := value.(string); ok {
-				sanitized[key] = map[string]interface{}{
-					"length":      len(str),
-					"has_content": len(str) > 0,
-				}
-			}
-		default:
-			sanitized[key] = value
+		if contentParams[key] {
+			sanitized[key] = contentMetadata(value)
+			continue
 		}
+		sanitized[key] = sanitizeValue(value)
 	}
 
 	return sanitized
 }
 
+// sanitizeValue sanitizes the parameters nested in value.
+func sanitizeValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return sanitizeParams(v)
+	case []interface{}:
+		sanitized := make([]interface{}, len(v))
+		for i, item := range v {
+			sanitized[i] = sanitizeValue(item)
+		}
+		return sanitized
+	default:
+		return value
+	}
+}
+
+// contentMetadata returns what is logged of a content parameter: the length of strings,
+// the sorted keys of objects, such as file paths and header names, and nothing of other
+// values.
+func contentMetadata(value interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		return map[string]interface{}{
+			"length":      len(v),
+			"has_content": len(v) > 0,
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		return map[string]interface{}{"keys": keys}
+	case nil:
+		return nil
+	default:
+		return map[string]interface{}{"redacted": true}
+	}
+}
+
 // getErrorType extracts error type for classification
 func getErrorType(err error) string {
 	if err == nil {
@@ -242,9 +296,11 @@ func getErrorType(err error) string {
 	return "unknown"
 }
 
-// getPathType returns a safe representation of file paths
-func getPathType(path string) string {
-	if strings.Contains(path, "temp") || strings.Contains(path, "tmp") {
+// PathType returns a safe representation of file paths, for logs.
+func PathType(path string) string {
+	// Windows temporary directories are spelled "Temp"
+	lower := strings.ToLower(path)
+	if strings.Contains(lower, "temp") || strings.Contains(lower, "tmp") {
 		return "temporary"
 	} else if strings.HasSuffix(path, ".js") {
 		return "javascript"
@@ -0,0 +1,57 @@
+package logging
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestSanitizeParams(t *testing.T) {
+	t.Parallel()
+
+	secrets := []string{"alice@example.org", "+1 415 555 2671", "4111 1111 1111 1111", "Bearer s3cr3t", "hunter2", "influx-token"}
+	params := map[string]interface{}{
+		"script": "export default function () {}",
+		"files": map[string]interface{}{
+			"data/users.csv": "email,phone,card\nalice@example.org,+1 415 555 2671,4111 1111 1111 1111",
+		},
+		"headers":       map[string]interface{}{"Authorization": "Bearer s3cr3t"},
+		"env":           map[string]interface{}{"PASSWORD": "hunter2"},
+		"response":      `{"email": "alice@example.org"}`,
+		"body":          `{"password": "hunter2"}`,
+		"bundle_base64": "aHVudGVyMg==",
+		"outputs":       []interface{}{map[string]interface{}{"type": "influxdb", "token": "influx-token"}},
+		"scripts": []interface{}{
+			map[string]interface{}{"script_name": "checkout", "env": map[string]interface{}{"PASSWORD": "hunter2"}},
+		},
+		"vus": float64(10),
+	}
+
+	sanitized := sanitizeParams(params)
+	data, err := json.Marshal(sanitized)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	logged := string(data)
+
+	for _, secret := range secrets {
+		if strings.Contains(logged, secret) {
+			t.Errorf("sanitized parameters contain %q: %s", secret, logged)
+		}
+	}
+
+	// Sizes, file paths and names are kept, as are the other parameters
+	for _, kept := range []string{`"length":29`, `"data/users.csv"`, `"Authorization"`, `"PASSWORD"`, `"script_name":"checkout"`, `"vus":10`} {
+		if !strings.Contains(logged, kept) {
+			t.Errorf("sanitized parameters lack %s: %s", kept, logged)
+		}
+	}
+}
+
+func TestSanitizeParamsNil(t *testing.T) {
+	t.Parallel()
+
+	if got := sanitizeParams(nil); got != nil {
+		t.Errorf("sanitizeParams(nil) = %v, want nil", got)
+	}
+}
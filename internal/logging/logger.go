@@ -3,6 +3,7 @@ package logging
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
 	"os"
 	"strings"
@@ -10,26 +11,37 @@ import (
 	"github.com/oleiade/k6-mcp/internal/buildinfo"
 )
 
-const (
-	// ServiceName is the service identifier for Loki labels
-	ServiceName = "k6-mcp"
+// ServiceName is the service identifier for Loki labels
+const ServiceName = "k6-mcp"
 
-	// ContextKey for request correlation
-	requestIDKey = "request_id"
-)
+// requestKey is the context key of the Request being served.
+type requestKey struct{}
 
 // defaultLogger is the package-level logger instance
 var defaultLogger *slog.Logger
 
+// level is the minimum level of the records of the default logger, which can be changed
+// while the server runs.
+var level = new(slog.LevelVar)
+
 // LogConfig holds logging configuration
 type LogConfig struct {
 	Level  slog.Level
 	Format string // "json" or "text"
 }
 
+// Request identifies the MCP request being served, for its log records.
+type Request struct {
+	ID      string
+	Tool    string
+	Prompt  string
+	Session string
+}
+
 // init initializes the default logger based on environment variables
 func init() {
 	config := getConfigFromEnv()
+	level.Set(config.Level)
 	defaultLogger = newLogger(config)
 }
 
@@ -40,17 +52,10 @@ func getConfigFromEnv() LogConfig {
 		Format: "json",         // Default to JSON for Loki compatibility
 	}
 
-	// Parse LOG_LEVEL environment variable
+	// Parse LOG_LEVEL environment variable, ignoring invalid levels
 	if levelStr := os.Getenv("LOG_LEVEL"); levelStr != "" {
-		switch strings.ToUpper(levelStr) {
-		case "DEBUG":
-			config.Level = slog.LevelDebug
-		case "INFO":
-			config.Level = slog.LevelInfo
-		case "WARN", "WARNING":
-			config.Level = slog.LevelWarn
-		case "ERROR":
-			config.Level = slog.LevelError
+		if l, err := ParseLevel(levelStr); err == nil {
+			config.Level = l
 		}
 	}
 
@@ -64,12 +69,13 @@ func getConfigFromEnv() LogConfig {
 	return config
 }
 
-// newLogger creates a new slog.Logger with the given configuration
+// newLogger creates a new slog.Logger with the given configuration, filtering records
+// with the level of the package.
 func newLogger(config LogConfig) *slog.Logger {
 	var handler slog.Handler
 
 	handlerOpts := &slog.HandlerOptions{
-		Level: config.Level,
+		Level: level,
 	}
 
 	if config.Format == "text" {
@@ -79,29 +85,41 @@ func newLogger(config LogConfig) *slog.Logger {
 	}
 
 	// Create logger with service-level attributes
-	return slog.New(handler).With(
+	return slog.New(contextHandler{handler}).With(
 		slog.String("service", ServiceName),
 		slog.String("version", buildinfo.Version),
 	)
 }
 
-// Default returns the default logger instance
-func Default() *slog.Logger {
-	return defaultLogger
+// ParseLevel parses a log level: debug, info, warn (or warning) or error.
+func ParseLevel(value string) (slog.Level, error) {
+	switch strings.ToUpper(strings.TrimSpace(value)) {
+	case "DEBUG":
+		return slog.LevelDebug, nil
+	case "INFO":
+		return slog.LevelInfo, nil
+	case "WARN", "WARNING":
+		return slog.LevelWarn, nil
+	case "ERROR":
+		return slog.LevelError, nil
+	}
+	return slog.LevelInfo, fmt.Errorf("unknown log level %q; expected debug, info, warn or error", value)
 }
 
-// WithContext returns a logger with context-specific attributes
-func WithContext(ctx context.Context) *slog.Logger {
-	logger := defaultLogger
+// SetLevel sets the minimum level of the records of the default logger, and of the
+// loggers derived from it.
+func SetLevel(l slog.Level) {
+	level.Set(l)
+}
 
-	// Add request ID if available in context
-	if requestID := ctx.Value(requestIDKey); requestID != nil {
-		if id, ok := requestID.(string); ok {
-			logger = logger.With(slog.String("request_id", id))
-		}
-	}
+// CurrentLevel returns the minimum level of the records of the default logger.
+func CurrentLevel() slog.Level {
+	return level.Level()
+}
 
-	return logger
+// Default returns the default logger instance
+func Default() *slog.Logger {
+	return defaultLogger
 }
 
 // WithComponent returns a logger with component-specific attributes
@@ -109,14 +127,6 @@ func WithComponent(component string) *slog.Logger {
 	return defaultLogger.With(slog.String("component", component))
 }
 
-// WithTool returns a logger with tool-specific attributes for MCP requests
-func WithTool(toolName string) *slog.Logger {
-	return defaultLogger.With(
-		slog.String("tool", toolName),
-		slog.String("component", "mcp"),
-	)
-}
-
 // WithOperation returns a logger with operation-specific attributes
 func WithOperation(component, operation string) *slog.Logger {
 	return defaultLogger.With(
@@ -125,27 +135,61 @@ func WithOperation(component, operation string) *slog.Logger {
 	)
 }
 
-// ContextWithRequestID adds a request ID to the context for log correlation
-func ContextWithRequestID(ctx context.Context, requestID string) context.Context {
-	return context.WithValue(ctx, requestIDKey, requestID)
+// ContextWithRequest returns a context carrying the request, whose attributes the loggers
+// of the package add to the records logged with the context.
+func ContextWithRequest(ctx context.Context, request Request) context.Context {
+	return context.WithValue(ctx, requestKey{}, request)
+}
+
+// RequestFromContext returns the request the context carries, if any.
+func RequestFromContext(ctx context.Context) (Request, bool) {
+	request, ok := ctx.Value(requestKey{}).(Request)
+	return request, ok
 }
 
 // GetRequestID extracts the request ID from context
 func GetRequestID(ctx context.Context) string {
-	if requestID := ctx.Value(requestIDKey); requestID != nil {
-		if id, ok := requestID.(string); ok {
-			return id
+	request, _ := RequestFromContext(ctx)
+	return request.ID
+}
+
+// contextHandler adds the attributes of the request carried by the context of records to
+// them, so that loggers are built once and shared by concurrent requests.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if request, ok := RequestFromContext(ctx); ok {
+		record = record.Clone()
+		record.AddAttrs(slog.String("request_id", request.ID))
+		if request.Tool != "" {
+			record.AddAttrs(slog.String("tool", request.Tool))
+		}
+		if request.Prompt != "" {
+			record.AddAttrs(slog.String("prompt", request.Prompt))
+		}
+		if request.Session != "" {
+			record.AddAttrs(slog.String("session_id", request.Session))
 		}
 	}
-	return ""
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
 }
 
 // LogAttrs is a helper for performance-critical logging with structured attributes
 func LogAttrs(ctx context.Context, level slog.Level, msg string, attrs ...slog.Attr) {
-	WithContext(ctx).LogAttrs(ctx, level, msg, attrs...)
+	defaultLogger.LogAttrs(ctx, level, msg, attrs...)
 }
 
 // Enabled checks if the given log level is enabled
 func Enabled(ctx context.Context, level slog.Level) bool {
-	return WithContext(ctx).Enabled(ctx, level)
+	return defaultLogger.Enabled(ctx, level)
 }
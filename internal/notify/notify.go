@@ -0,0 +1,118 @@
+// Package notify forwards significant server events, such as test runs starting and
+// finishing, to the connected MCP client as logging notifications, so that users see what
+// happens inside the server without reading its logs.
+package notify
+
+import (
+	"context"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// LoggerName is the logger name notifications are sent under.
+const LoggerName = "k6-mcp"
+
+// Events forwarded to clients.
+const (
+	EventRunStarted        = "run_started"
+	EventRunFinished       = "run_finished"
+	EventIndexStale        = "index_stale"
+	EventSecurityRejection = "security_rejection"
+)
+
+// DefaultLevel is the minimum level of the notifications sent to clients that did not
+// choose one with logging/setLevel.
+const DefaultLevel = mcp.LoggingLevelInfo
+
+// levelSeverity orders logging levels from the least to the most severe.
+var levelSeverity = map[mcp.LoggingLevel]int{
+	mcp.LoggingLevelDebug:     0,
+	mcp.LoggingLevelInfo:      1,
+	mcp.LoggingLevelNotice:    2,
+	mcp.LoggingLevelWarning:   3,
+	mcp.LoggingLevelError:     4,
+	mcp.LoggingLevelCritical:  5,
+	mcp.LoggingLevelAlert:     6,
+	mcp.LoggingLevelEmergency: 7,
+}
+
+var (
+	// levelSet holds the IDs of the sessions that chose their level with logging/setLevel.
+	levelSet sync.Map
+	// sent holds the once-per-session notifications already sent, keyed by session ID and key.
+	sent sync.Map
+)
+
+// RegisterHooks registers the server hooks tracking the logging levels chosen by clients.
+func RegisterHooks(hooks *server.Hooks) {
+	hooks.AddAfterSetLevel(func(ctx context.Context, _ any, _ *mcp.SetLevelRequest, _ *mcp.EmptyResult) {
+		if session := server.ClientSessionFromContext(ctx); session != nil {
+			levelSet.Store(session.SessionID(), true)
+		}
+	})
+	hooks.AddOnUnregisterSession(func(_ context.Context, session server.ClientSession) {
+		levelSet.Delete(session.SessionID())
+		sent.Range(func(key, _ any) bool {
+			if key.(sessionKey).session == session.SessionID() {
+				sent.Delete(key)
+			}
+			return true
+		})
+	})
+}
+
+// sessionKey identifies a once-per-session notification.
+type sessionKey struct {
+	session string
+	key     string
+}
+
+// Send sends an event to the client of the request in ctx, when the client's level lets it
+// through. Notifications are best effort: they are dropped when no client session is
+// attached to ctx, or when the client's notification channel is full.
+func Send(ctx context.Context, level mcp.LoggingLevel, event, message string, fields map[string]any) {
+	srv := server.ServerFromContext(ctx)
+	session := server.ClientSessionFromContext(ctx)
+	if srv == nil || session == nil || !session.Initialized() {
+		return
+	}
+
+	minLevel := DefaultLevel
+	if logging, ok := session.(server.SessionWithLogging); ok {
+		if _, chosen := levelSet.Load(session.SessionID()); chosen {
+			minLevel = logging.GetLogLevel()
+		}
+	}
+	if levelSeverity[level] < levelSeverity[minLevel] {
+		return
+	}
+
+	data := make(map[string]any, len(fields)+2)
+	for name, value := range fields {
+		data[name] = value
+	}
+	data["event"] = event
+	data["message"] = message
+
+	_ = srv.SendNotificationToClient(ctx, "notifications/message", map[string]any{
+		"level":  level,
+		"logger": LoggerName,
+		"data":   data,
+	})
+}
+
+// SendOnce is Send, but sends the notification identified by key at most once per session.
+func SendOnce(ctx context.Context, key string, level mcp.LoggingLevel, event, message string, fields map[string]any) {
+	session := server.ClientSessionFromContext(ctx)
+	if session == nil {
+		return
+	}
+
+	if _, loaded := sent.LoadOrStore(sessionKey{session: session.SessionID(), key: key}, true); loaded {
+		return
+	}
+
+	Send(ctx, level, event, message, fields)
+}
@@ -0,0 +1,501 @@
+// Package prepare builds the distribution files the server embeds: the documentation
+// index database, with its API symbol and extension indexes, and the k6 type definitions.
+package prepare
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/oleiade/k6-mcp/internal"
+	"github.com/oleiade/k6-mcp/internal/apiref"
+	"github.com/oleiade/k6-mcp/internal/extensions"
+	"github.com/oleiade/k6-mcp/internal/search"
+)
+
+const (
+	dirPermissions = 0o750
+
+	// registryTimeout bounds the download of the extension registry.
+	registryTimeout = time.Minute
+
+	// maxCommitFiles is the number of pages from which commits are considered bulk changes,
+	// such as the copy of the documentation of a new k6 version, which don't date pages.
+	maxCommitFiles = 50
+
+	// DefaultVersions is the number of k6 versions before the latest one whose
+	// documentation is indexed by default.
+	DefaultVersions = 3
+)
+
+// IndexOptions configures the documentation indexing.
+type IndexOptions struct {
+	// Recreate drops and recreates the tables of the index database before indexing.
+	Recreate bool
+
+	// Translations are the directories of translated documentation to index alongside the
+	// English documentation, keyed by language code (see ParseTranslations).
+	Translations map[string]string
+
+	// RegistryURL is the URL of the k6 extension registry to index; extensions are not
+	// indexed when it is empty.
+	RegistryURL string
+
+	// Versions is the number of k6 versions before the latest one whose documentation is
+	// indexed, for diff_docs to compare.
+	Versions int
+}
+
+// Flags registers the flags of the indexing options on flags, returning the function
+// reading the options once the flags are parsed.
+func Flags(flags *flag.FlagSet) func() (IndexOptions, error) {
+	recreate := flags.Bool("recreate-db", true, "Drop and recreate the FTS5 table before indexing")
+	translated := flags.String("translations", "", "Comma-separated language=directory pairs of translated documentation to index, e.g. fr=./docs-fr")
+	registry := flags.String("extensions-registry", extensions.DefaultRegistryURL, "URL of the k6 extension registry to index, or empty to skip indexing extensions")
+	versions := flags.Int("doc-versions", DefaultVersions, "Number of k6 versions before the latest one whose documentation is indexed, to compare versions")
+
+	return func() (IndexOptions, error) {
+		translations, err := ParseTranslations(*translated)
+		if err != nil {
+			return IndexOptions{}, fmt.Errorf("invalid --translations: %w", err)
+		}
+		if *versions < 0 {
+			return IndexOptions{}, fmt.Errorf("invalid --doc-versions: %d is negative", *versions)
+		}
+		return IndexOptions{Recreate: *recreate, Translations: translations, RegistryURL: *registry, Versions: *versions}, nil
+	}
+}
+
+// Run collects the type definitions into the dist directory of workDir, then indexes the
+// documentation, so that indexing builds the API symbol index from the definitions.
+func Run(workDir string, opts IndexOptions) error {
+	log.Println("Starting type definitions collection...")
+	if err := Collect(workDir); err != nil {
+		return fmt.Errorf("type definitions collection failed: %w", err)
+	}
+	log.Println("Type definitions collection completed successfully")
+
+	log.Println("Starting documentation indexing...")
+	if err := Index(workDir, opts); err != nil {
+		return fmt.Errorf("documentation indexing failed: %w", err)
+	}
+	log.Println("Documentation indexing completed successfully")
+
+	return nil
+}
+
+// ParseTranslations parses comma-separated language=directory pairs of translated
+// documentation, e.g. fr=./docs-fr,ja=./docs-ja.
+func ParseTranslations(value string) (map[string]string, error) {
+	translations := make(map[string]string)
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		code, dir, found := strings.Cut(pair, "=")
+		if !found || dir == "" {
+			return nil, fmt.Errorf("expected language=directory, got %q", pair)
+		}
+		language, err := search.LookupLanguage(code)
+		if err != nil {
+			return nil, err
+		}
+		if language.Code == search.DefaultLanguage {
+			return nil, fmt.Errorf("the English documentation is indexed from the k6-docs repository")
+		}
+		translations[language.Code] = dir
+	}
+	return translations, nil
+}
+
+// Index indexes the latest documentation of the k6-docs repository, and the differences of
+// the previous versions, into the index database in the dist directory of workDir,
+// alongside the translated documentation, the API symbols of the collected type
+// definitions and the extensions of the registry.
+func Index(workDir string, opts IndexOptions) error {
+	translations, registryURL := opts.Translations, opts.RegistryURL
+
+	const (
+		k6DocsRepo     = "https://github.com/grafana/k6-docs.git"
+		docsSourcePath = "docs/sources/k6"
+		databaseName   = "index.db"
+		distDir        = "dist"
+	)
+
+	tempDir, err := os.MkdirTemp("", "k6-docs-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tempDir); removeErr != nil {
+			log.Printf("Warning: Failed to clean up temporary directory %s: %v", tempDir, removeErr)
+		}
+	}()
+
+	log.Printf("Cloning k6 documentation repository...")
+	if err := cloneRepository(k6DocsRepo, tempDir); err != nil {
+		return fmt.Errorf("failed to clone k6-docs repository: %w", err)
+	}
+
+	docsDir := filepath.Join(tempDir, docsSourcePath)
+	versions, err := findVersions(docsDir)
+	if err != nil {
+		return fmt.Errorf("failed to find latest version: %w", err)
+	}
+	latestVersion := versions[0]
+
+	log.Printf("Using k6 documentation version: %s", latestVersion)
+	docsPath := filepath.Join(docsDir, latestVersion)
+
+	updated, err := modificationTimes(tempDir, docsSourcePath+"/"+latestVersion)
+	if err != nil {
+		return fmt.Errorf("failed to read the modification times of the documentation: %w", err)
+	}
+	log.Printf("Dated %d documentation pages from the repository history", len(updated))
+
+	distPath := filepath.Join(workDir, distDir)
+	if err := os.MkdirAll(distPath, dirPermissions); err != nil {
+		return fmt.Errorf("failed to create dist directory: %w", err)
+	}
+
+	databasePath := filepath.Join(distPath, databaseName)
+	log.Printf("Generating SQLite database at: %s", databasePath)
+
+	codes := make([]string, 0, len(translations))
+	for code := range translations {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	db, err := search.InitSQLiteDB(databasePath, opts.Recreate, codes...)
+	if err != nil {
+		return fmt.Errorf("failed to initialize SQLite database: %w", err)
+	}
+	defer func() {
+		if closeErr := db.Close(); closeErr != nil {
+			log.Printf("Warning: Failed to close database: %v", closeErr)
+		}
+	}()
+
+	indexer := search.NewSQLiteIndexer(db)
+	indexer.Updated = updated
+	count, err := indexer.IndexDirectory(docsPath)
+	if err != nil {
+		return fmt.Errorf("failed to index documents: %w", err)
+	}
+
+	if err := indexer.RecordVersion(latestVersion, true); err != nil {
+		return fmt.Errorf("failed to record the documentation version: %w", err)
+	}
+
+	// Index the differences of the previous versions, for diff_docs
+	for _, version := range versions[1:min(len(versions), opts.Versions+1)] {
+		versionCount, err := indexer.IndexVersion(filepath.Join(docsDir, version), version)
+		if err != nil {
+			return fmt.Errorf("failed to index the %s documentation: %w", version, err)
+		}
+		log.Printf("Indexed %d pages differing in the %s documentation", versionCount, version)
+	}
+
+	for _, code := range codes {
+		translator := search.NewSQLiteIndexer(db)
+		translator.Language = code
+		translatedCount, err := translator.IndexDirectory(translations[code])
+		if err != nil {
+			return fmt.Errorf("failed to index %s documents: %w", code, err)
+		}
+		log.Printf("Indexed %d %s documents", translatedCount, code)
+	}
+
+	// Index the symbols of the API from the collected type definitions, for lookup_api
+	definitionsDir := filepath.Join(workDir, internal.DefinitionsPath)
+	if _, err := os.Stat(definitionsDir); err == nil {
+		symbols, err := apiref.Build(db, os.DirFS(definitionsDir))
+		if err != nil {
+			return fmt.Errorf("failed to index API symbols: %w", err)
+		}
+		log.Printf("Indexed %d API symbols", symbols)
+	} else {
+		log.Printf("Warning: No type definitions at %s; run collect first to index API symbols", definitionsDir)
+	}
+
+	// Index the extensions of the registry, for find_extension. The documentation index is
+	// still usable without them, so failing to download the registry is not fatal.
+	if registryURL != "" {
+		if count, err := indexExtensions(db, registryURL); err != nil {
+			log.Printf("Warning: Failed to index the extension registry: %v", err)
+		} else {
+			log.Printf("Indexed %d extensions", count)
+		}
+	}
+
+	if err := search.OptimizeSQLiteDB(db); err != nil {
+		return fmt.Errorf("failed to optimize database: %w", err)
+	}
+
+	log.Printf("Successfully generated database with %d documents at: %s", count, databasePath)
+	return nil
+}
+
+// indexExtensions downloads the extension registry at registryURL and indexes its
+// extensions in the database.
+func indexExtensions(db *sql.DB, registryURL string) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), registryTimeout)
+	defer cancel()
+
+	registry, err := extensions.Fetch(ctx, &http.Client{}, registryURL)
+	if err != nil {
+		return 0, err
+	}
+
+	return extensions.Build(db, registry)
+}
+
+// Collect collects the k6 type definitions of the DefinitelyTyped repository into the dist
+// directory of workDir, replacing the previously collected ones.
+func Collect(workDir string) error {
+	const (
+		typesRepo    = "https://github.com/DefinitelyTyped/DefinitelyTyped.git"
+		typesRepoDir = "DefinitelyTyped"
+	)
+
+	destDir := filepath.Join(workDir,
+		internal.DistFolderName,
+		internal.DistDefinitionsFolderName,
+		internal.DistTypesFolderName,
+		internal.DistK6FolderName)
+
+	if _, err := os.Stat(destDir); !os.IsNotExist(err) {
+		log.Printf("Removing existing dist definitions directory: %s", destDir)
+		if err := os.RemoveAll(destDir); err != nil {
+			return fmt.Errorf("failed to remove existing directory: %w", err)
+		}
+	}
+
+	if err := cloneTypesRepository(typesRepo, destDir); err != nil {
+		return fmt.Errorf("failed to clone types repository: %w", err)
+	}
+
+	if err := cleanUpTypesRepository(destDir); err != nil {
+		return fmt.Errorf("failed to clean up types repository: %w", err)
+	}
+
+	log.Printf("Successfully collected type definitions to: %s", destDir)
+	return nil
+}
+
+// cloneRepository clones a git repository to the target directory, with the history of
+// its commits but only the file contents of the checked out revision.
+func cloneRepository(repoURL, targetDir string) error {
+	cmd := exec.Command("git", "clone", "--filter=blob:none", repoURL, targetDir)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("git command failed: %w", err)
+	}
+	return nil
+}
+
+// modificationTimes returns the times of the last commits changing the markdown files
+// under dir, a directory relative to the root of the repository at repoDir, keyed by
+// their document path relative to dir. Bulk commits, changing more than maxCommitFiles
+// pages, are ignored: pages only changed by them are left undated.
+func modificationTimes(repoDir, dir string) (map[string]time.Time, error) {
+	cmd := exec.Command("git", "-C", repoDir, "log", "--format=%x00%ct", "--name-only", "--no-renames", "--", dir)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git log failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	// Commits are listed the most recent first, each as its time followed by its files
+	times := make(map[string]time.Time)
+	for _, commit := range strings.Split(string(output), "\x00")[1:] {
+		lines := strings.Split(strings.TrimSpace(commit), "\n")
+		seconds, err := strconv.ParseInt(lines[0], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid commit time %q: %w", lines[0], err)
+		}
+
+		var pages []string
+		for _, line := range lines[1:] {
+			relPath, ok := strings.CutPrefix(strings.TrimSpace(line), dir+"/")
+			if ok && strings.HasSuffix(relPath, ".md") {
+				pages = append(pages, search.DocumentPath(relPath))
+			}
+		}
+		if len(pages) > maxCommitFiles {
+			continue
+		}
+
+		for _, page := range pages {
+			if _, dated := times[page]; !dated {
+				times[page] = time.Unix(seconds, 0).UTC()
+			}
+		}
+	}
+
+	return times, nil
+}
+
+// findVersions finds the k6 version directories in the docs, the latest first.
+func findVersions(docsDir string) ([]string, error) {
+	type Version struct {
+		Original string
+		Major    int
+		Minor    int
+	}
+
+	entries, err := os.ReadDir(docsDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read docs directory: %w", err)
+	}
+
+	var versions []Version
+	versionRegex := regexp.MustCompile(`^v(\d+)\.(\d+)\.x$`)
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		name := entry.Name()
+		if name == "next" {
+			continue
+		}
+
+		matches := versionRegex.FindStringSubmatch(name)
+		if matches == nil {
+			continue
+		}
+
+		major, err := strconv.Atoi(matches[1])
+		if err != nil {
+			continue
+		}
+
+		minor, err := strconv.Atoi(matches[2])
+		if err != nil {
+			continue
+		}
+
+		versions = append(versions, Version{
+			Original: name,
+			Major:    major,
+			Minor:    minor,
+		})
+	}
+
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("no valid version directories found")
+	}
+
+	sort.Slice(versions, func(i, j int) bool {
+		if versions[i].Major != versions[j].Major {
+			return versions[i].Major > versions[j].Major
+		}
+		return versions[i].Minor > versions[j].Minor
+	})
+
+	names := make([]string, len(versions))
+	for i, version := range versions {
+		names[i] = version.Original
+	}
+
+	return names, nil
+}
+
+// cloneTypesRepository clones the types repository and sets sparse checkout to k6 types
+func cloneTypesRepository(repoURL, repoDir string) error {
+	cmd := exec.Command("git", "clone", "--filter=blob:none", "--sparse", repoURL, repoDir)
+	var cloneStderr bytes.Buffer
+	cmd.Stderr = &cloneStderr
+	err := cmd.Run()
+	if err != nil {
+		return fmt.Errorf("failed to clone types repository; reason: %s", cloneStderr.String())
+	}
+
+	cmd = exec.Command("git", "-C", repoDir, "sparse-checkout", "set", "types/k6")
+	var sparseStderr bytes.Buffer
+	cmd.Stderr = &sparseStderr
+	err = cmd.Run()
+	if err != nil {
+		return fmt.Errorf("failed to set sparse checkout; reason: %s", sparseStderr.String())
+	}
+
+	// Move the checked-out subtree (types/k6) up to repoDir so that repoDir mirrors the k6 types folder
+	srcDir := filepath.Join(repoDir, "types", "k6")
+	tmpDir := repoDir + ".tmp"
+	if err := os.Rename(srcDir, tmpDir); err != nil {
+		return fmt.Errorf("failed to move %s to temporary location %s: %w", srcDir, tmpDir, err)
+	}
+	if err := os.RemoveAll(repoDir); err != nil {
+		return fmt.Errorf("failed to clear repository directory %s: %w", repoDir, err)
+	}
+	if err := os.Rename(tmpDir, repoDir); err != nil {
+		return fmt.Errorf("failed to move temporary directory back to %s: %w", repoDir, err)
+	}
+
+	return nil
+}
+
+// cleanUpTypesRepository removes non-.d.ts files and empty directories
+func cleanUpTypesRepository(repoDir string) error {
+	// First pass: remove any file that does not end with .d.ts
+	removeNonDTS := func(path string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if !strings.HasSuffix(d.Name(), internal.DistDTSFileSuffix) {
+			if err := os.Remove(path); err != nil {
+				return fmt.Errorf("failed to remove file %s: %w", path, err)
+			}
+		}
+		return nil
+	}
+
+	if err := filepath.WalkDir(repoDir, removeNonDTS); err != nil {
+		return fmt.Errorf("failed to walk directory for cleanup: %w", err)
+	}
+
+	// Second pass: gather directories and prune empty ones from deepest to root
+	var directories []string
+	collectDirs := func(path string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		if d.IsDir() {
+			directories = append(directories, path)
+		}
+		return nil
+	}
+
+	if err := filepath.WalkDir(repoDir, collectDirs); err != nil {
+		return fmt.Errorf("failed to collect directories: %w", err)
+	}
+
+	sort.Slice(directories, func(i, j int) bool { return len(directories[i]) > len(directories[j]) })
+	for _, dir := range directories {
+		_ = os.Remove(dir) // remove only if empty
+	}
+
+	return nil
+}
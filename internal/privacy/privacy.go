@@ -0,0 +1,399 @@
+// Package privacy detects and masks personal data, such as emails, phone numbers and card
+// numbers, in the fixtures and recorded traffic test scripts are built from, before they are
+// stored in workspaces or returned to clients.
+package privacy
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Names of the built-in rules.
+const (
+	RuleEmail = "email"
+	RulePhone = "phone"
+	RuleCard  = "card"
+)
+
+// Data scrubbed automatically, as listed by ParseTargets.
+const (
+	// TargetFiles scrubs the data files of the scripts, and the scripts along with them,
+	// before they are written to workspaces.
+	TargetFiles = "files"
+	// TargetRecordings scrubs recorded requests and responses before they are converted into
+	// scripts.
+	TargetRecordings = "recordings"
+)
+
+// Rule detects a kind of personal data, and masks it.
+type Rule struct {
+	// Name identifies the rule in findings, and in the rules of the scrub_data tool.
+	Name string `json:"name"`
+	// Pattern is the regular expression matching the data.
+	Pattern string `json:"pattern"`
+	// Replacement replaces the matches, "{n}" being replaced by the number of the distinct
+	// value matched, so that a value is masked the same way wherever it appears. Defaults
+	// to "<name>-{n}".
+	Replacement string `json:"replacement,omitempty"`
+
+	re *regexp.Regexp
+	// valid filters out the matches that are not the data, e.g. failing a checksum.
+	valid func(text string, start, end int) bool
+	// mask masks the nth distinct value matched.
+	mask func(value string, n int) string
+}
+
+var (
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}`)
+	// phonePattern matches international numbers, and numbers whose area code is set apart
+	// by parentheses or a separator, leaving out bare runs of digits such as timestamps.
+	phonePattern = regexp.MustCompile(`\+\d{1,3}[ -]?(?:\(\d{1,4}\)[ -]?)?\d{2,4}(?:[ -]?\d{2,4}){1,3}|(?:\(\d{2,4}\)[ -]?|\b\d{2,4}[ -])\d{3,4}[ -]?\d{3,4}\b`)
+	cardPattern  = regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`)
+)
+
+// Builtin returns the built-in rules: emails, masked as userN@example.com; phone numbers;
+// and card numbers passing the Luhn checksum. Phone and card numbers keep their format,
+// their digits replaced by the number of the value.
+func Builtin() []*Rule {
+	return []*Rule{
+		{
+			Name: RuleCard, Pattern: cardPattern.String(), re: cardPattern,
+			valid: func(text string, start, end int) bool {
+				return standalone(text, start, end) && luhn(digits(text[start:end]))
+			},
+			mask: maskDigits,
+		},
+		{
+			Name: RuleEmail, Pattern: emailPattern.String(), re: emailPattern,
+			mask: func(_ string, n int) string { return fmt.Sprintf("user%d@example.com", n) },
+		},
+		{
+			Name: RulePhone, Pattern: phonePattern.String(), re: phonePattern,
+			valid: func(text string, start, end int) bool {
+				n := len(digits(text[start:end]))
+				return standalone(text, start, end) && n >= 7 && n <= 15
+			},
+			mask: maskDigits,
+		},
+	}
+}
+
+// Config is the configuration of the rules, read from a JSON file.
+type Config struct {
+	// Disable lists the built-in rules not applied.
+	Disable []string `json:"disable,omitempty"`
+	// Rules are rules applied after the built-in ones; a rule named after a built-in one
+	// replaces it.
+	Rules []Rule `json:"rules,omitempty"`
+}
+
+// Load reads the rules configuration from a JSON file, and returns the rules it selects.
+func Load(path string) ([]*Rule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the scrubbing rules file: %w", err)
+	}
+
+	var config Config
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("invalid scrubbing rules file: %w", err)
+	}
+
+	return config.Compile()
+}
+
+// Compile returns the built-in rules the configuration keeps, followed by its own rules.
+func (c Config) Compile() ([]*Rule, error) {
+	builtin := Builtin()
+	names := make(map[string]bool, len(builtin))
+	for _, rule := range builtin {
+		names[rule.Name] = true
+	}
+	disabled := make(map[string]bool, len(c.Disable))
+	for _, name := range c.Disable {
+		if !names[name] {
+			return nil, fmt.Errorf("unknown built-in rule %q: expected %s, %s or %s", name, RuleEmail, RulePhone, RuleCard)
+		}
+		disabled[name] = true
+	}
+
+	custom := make([]*Rule, 0, len(c.Rules))
+	for i := range c.Rules {
+		rule := c.Rules[i]
+		if rule.Name == "" {
+			return nil, fmt.Errorf("rule %d has no name", i+1)
+		}
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern of rule %q: %w", rule.Name, err)
+		}
+		if re.MatchString("") {
+			return nil, fmt.Errorf("the pattern of rule %q matches empty text", rule.Name)
+		}
+		rule.re = re
+		disabled[rule.Name] = true
+		custom = append(custom, &rule)
+	}
+
+	rules := make([]*Rule, 0, len(builtin)+len(custom))
+	for _, rule := range builtin {
+		if !disabled[rule.Name] {
+			rules = append(rules, rule)
+		}
+	}
+
+	return append(rules, custom...), nil
+}
+
+// ParseTargets parses a comma-separated list of the data scrubbed automatically, such as
+// "files,recordings".
+func ParseTargets(value string) (files, recordings bool, err error) {
+	for _, target := range strings.Split(value, ",") {
+		switch strings.ToLower(strings.TrimSpace(target)) {
+		case "":
+		case TargetFiles:
+			files = true
+		case TargetRecordings:
+			recordings = true
+		default:
+			return false, false, fmt.Errorf("unknown scrubbing target %q: expected %s or %s", target, TargetFiles, TargetRecordings)
+		}
+	}
+
+	return files, recordings, nil
+}
+
+// Policy is what the server scrubs, and how.
+type Policy struct {
+	Rules []*Rule
+	// Files and Recordings scrub the data files of the scripts and the recordings
+	// automatically (see TargetFiles and TargetRecordings).
+	Files      bool
+	Recordings bool
+}
+
+var (
+	mu      sync.RWMutex
+	current = Policy{Rules: Builtin()}
+)
+
+// Set sets the scrubbing policy of the server.
+func Set(policy Policy) {
+	mu.Lock()
+	defer mu.Unlock()
+	current = policy
+}
+
+// Current returns the scrubbing policy of the server: by default, the built-in rules applied
+// on request only.
+func Current() Policy {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current
+}
+
+// Finding counts the matches of a rule.
+type Finding struct {
+	Rule    string `json:"rule"`
+	Matches int    `json:"matches"`
+	// Distinct is the number of distinct values matched.
+	Distinct int `json:"distinct"`
+}
+
+// Scrubber masks the matches of rules across several contents, masking each value the same
+// way wherever it appears, e.g. in a request and the response echoing it. It is not safe
+// for concurrent use.
+type Scrubber struct {
+	rules   []*Rule
+	values  map[string]map[string]int
+	matches map[string]int
+}
+
+// NewScrubber returns a Scrubber applying the rules, in order: the matches of a rule
+// overlapping the matches of an earlier rule are left out.
+func NewScrubber(rules []*Rule) *Scrubber {
+	return &Scrubber{
+		rules:   rules,
+		values:  make(map[string]map[string]int),
+		matches: make(map[string]int),
+	}
+}
+
+// span is a match of a rule in a content.
+type span struct {
+	start, end int
+	rule       *Rule
+}
+
+// Scrub returns the content with the matches of the rules masked. Binary content is returned
+// as is, since masks could corrupt it.
+func (s *Scrubber) Scrub(content string) string {
+	if content == "" || !utf8.ValidString(content) {
+		return content
+	}
+
+	var spans []span
+	for _, rule := range s.rules {
+		for _, loc := range rule.re.FindAllStringIndex(content, -1) {
+			if loc[0] == loc[1] || (rule.valid != nil && !rule.valid(content, loc[0], loc[1])) {
+				continue
+			}
+			if overlaps(spans, loc[0], loc[1]) {
+				continue
+			}
+			spans = append(spans, span{start: loc[0], end: loc[1], rule: rule})
+		}
+	}
+	if len(spans) == 0 {
+		return content
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+
+	var b strings.Builder
+	b.Grow(len(content))
+	last := 0
+	for _, sp := range spans {
+		b.WriteString(content[last:sp.start])
+		b.WriteString(s.mask(sp.rule, content[sp.start:sp.end]))
+		last = sp.end
+	}
+	b.WriteString(content[last:])
+
+	return b.String()
+}
+
+// Findings returns the matches of each rule scrubbed so far, in the order of the rules.
+func (s *Scrubber) Findings() []Finding {
+	findings := []Finding{}
+	for _, rule := range s.rules {
+		if s.matches[rule.Name] > 0 {
+			findings = append(findings, Finding{
+				Rule:     rule.Name,
+				Matches:  s.matches[rule.Name],
+				Distinct: len(s.values[rule.Name]),
+			})
+		}
+	}
+	return findings
+}
+
+// mask returns the mask of the value matched by the rule, numbering its distinct values.
+func (s *Scrubber) mask(rule *Rule, value string) string {
+	values, ok := s.values[rule.Name]
+	if !ok {
+		values = make(map[string]int)
+		s.values[rule.Name] = values
+	}
+	n, ok := values[value]
+	if !ok {
+		n = len(values) + 1
+		values[value] = n
+	}
+	s.matches[rule.Name]++
+
+	if rule.mask != nil {
+		return rule.mask(value, n)
+	}
+	replacement := rule.Replacement
+	if replacement == "" {
+		replacement = rule.Name + "-{n}"
+	}
+	return strings.ReplaceAll(replacement, "{n}", strconv.Itoa(n))
+}
+
+// overlaps reports whether the range overlaps one of the spans.
+func overlaps(spans []span, start, end int) bool {
+	for _, sp := range spans {
+		if start < sp.end && sp.start < end {
+			return true
+		}
+	}
+	return false
+}
+
+// standalone reports whether the match is not part of a longer token, such as an
+// identifier, a UUID or a dotted version, nor of a longer run of digit groups.
+func standalone(text string, start, end int) bool {
+	if start > 0 {
+		if c := text[start-1]; isWordChar(c) || c == '-' || c == '.' {
+			return false
+		} else if c == ' ' && start > 1 && isDigit(text[start-2]) {
+			return false
+		}
+	}
+	if end < len(text) {
+		c := text[end]
+		if isWordChar(c) {
+			return false
+		}
+		if end+1 < len(text) {
+			next := text[end+1]
+			if (c == '-' || c == '.') && isWordChar(next) || c == ' ' && isDigit(next) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+func isWordChar(c byte) bool {
+	return c == '_' || isDigit(c) || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+// digits returns the digits of the text.
+func digits(text string) string {
+	var b strings.Builder
+	for i := 0; i < len(text); i++ {
+		if isDigit(text[i]) {
+			b.WriteByte(text[i])
+		}
+	}
+	return b.String()
+}
+
+// luhn reports whether the digits pass the Luhn checksum of card numbers.
+func luhn(number string) bool {
+	sum := 0
+	for i := len(number) - 1; i >= 0; i-- {
+		d := int(number[i] - '0')
+		if (len(number)-1-i)%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return sum%10 == 0 && strings.Trim(number, "0") != ""
+}
+
+// maskDigits replaces the digits of the value by the number n, zero-padded to their count,
+// keeping its separators and leading plus sign.
+func maskDigits(value string, n int) string {
+	count := len(digits(value))
+	replacement := fmt.Sprintf("%0*d", count, n)
+	if len(replacement) > count {
+		replacement = replacement[len(replacement)-count:]
+	}
+
+	b := []byte(value)
+	next := 0
+	for i := range b {
+		if isDigit(b[i]) {
+			b[i] = replacement[next]
+			next++
+		}
+	}
+	return string(b)
+}
@@ -0,0 +1,200 @@
+package recorder
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/oleiade/k6-mcp/internal/security"
+)
+
+const (
+	caCertFileName = "ca.pem"
+	caKeyFileName  = "ca-key.pem"
+
+	// caValidity is the validity of the generated certificate authority, and leafValidity
+	// the validity of the certificates it issues for the recorded hosts.
+	caValidity   = 365 * 24 * time.Hour
+	leafValidity = 7 * 24 * time.Hour
+)
+
+// authority is the certificate authority issuing the certificates of the HTTPS hosts the
+// proxy intercepts. It is generated once and kept in the data directory, so that clients
+// trust it once.
+type authority struct {
+	cert     *x509.Certificate
+	key      *ecdsa.PrivateKey
+	certPath string
+
+	mu      sync.Mutex
+	leafKey *ecdsa.PrivateKey
+	leaves  map[string]*tls.Certificate
+}
+
+// loadAuthority reads the certificate authority of dir, generating it when it is missing
+// or expires within a day.
+func loadAuthority(dir string) (*authority, error) {
+	certPath := filepath.Join(dir, caCertFileName)
+	keyPath := filepath.Join(dir, caKeyFileName)
+
+	a, err := readAuthority(certPath, keyPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if a == nil || time.Until(a.cert.NotAfter) < 24*time.Hour {
+		if a, err = generateAuthority(certPath, keyPath); err != nil {
+			return nil, err
+		}
+	}
+
+	leafKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate certificate key: %w", err)
+	}
+	a.leafKey = leafKey
+	a.leaves = make(map[string]*tls.Certificate)
+
+	return a, nil
+}
+
+// readAuthority reads the certificate authority of the files.
+func readAuthority(certPath, keyPath string) (*authority, error) {
+	certPEM, err := os.ReadFile(certPath)
+	if err != nil {
+		return nil, err
+	}
+	keyPEM, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, err
+	}
+
+	certBlock, _ := pem.Decode(certPEM)
+	keyBlock, _ := pem.Decode(keyPEM)
+	if certBlock == nil || keyBlock == nil {
+		return nil, fmt.Errorf("invalid recording certificate authority in %s", filepath.Dir(certPath))
+	}
+	cert, err := x509.ParseCertificate(certBlock.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid recording CA certificate: %w", err)
+	}
+	key, err := x509.ParseECPrivateKey(keyBlock.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid recording CA key: %w", err)
+	}
+
+	return &authority{cert: cert, key: key, certPath: certPath}, nil
+}
+
+// generateAuthority generates a certificate authority and writes it to the files.
+func generateAuthority(certPath, keyPath string) (*authority, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate CA key: %w", err)
+	}
+	serial, err := serialNumber()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: "k6-mcp recording CA", Organization: []string{"k6-mcp"}},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(caValidity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLenZero:        true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create CA certificate: %w", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse CA certificate: %w", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode CA key: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(certPath), security.SecureDirMode); err != nil {
+		return nil, fmt.Errorf("failed to create recorder directory: %w", err)
+	}
+	if err := os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), security.SecureFileMode); err != nil {
+		return nil, fmt.Errorf("failed to write CA key: %w", err)
+	}
+	if err := os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), security.SecureFileMode); err != nil {
+		return nil, fmt.Errorf("failed to write CA certificate: %w", err)
+	}
+
+	return &authority{cert: cert, key: key, certPath: certPath}, nil
+}
+
+// certificate returns the certificate of the host, issuing it on first use.
+func (a *authority) certificate(host string) (*tls.Certificate, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if leaf, ok := a.leaves[host]; ok && time.Until(leaf.Leaf.NotAfter) > time.Hour {
+		return leaf, nil
+	}
+
+	serial, err := serialNumber()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: host},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(leafValidity),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	if template.NotAfter.After(a.cert.NotAfter) {
+		template.NotAfter = a.cert.NotAfter
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		template.IPAddresses = []net.IP{ip}
+	} else {
+		template.DNSNames = []string{host}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, a.cert, &a.leafKey.PublicKey, a.key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to issue certificate for %s: %w", host, err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse certificate for %s: %w", host, err)
+	}
+
+	leaf := &tls.Certificate{Certificate: [][]byte{der, a.cert.Raw}, PrivateKey: a.leafKey, Leaf: cert}
+	a.leaves[host] = leaf
+	return leaf, nil
+}
+
+// serialNumber returns a random certificate serial number.
+func serialNumber() (*big.Int, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate certificate serial number: %w", err)
+	}
+	return serial, nil
+}
@@ -0,0 +1,297 @@
+package recorder
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/oleiade/k6-mcp/internal/codegen"
+)
+
+const (
+	// DefaultGroupGap is the default pause between requests beyond which a new group, such
+	// as a page navigation, starts.
+	DefaultGroupGap = 2 * time.Second
+	// maxThinkTime is the longest pause converted into a think time, in seconds.
+	maxThinkTime = 10.0
+)
+
+// ErrNothingRecorded is returned when a recording holds no request to convert.
+var ErrNothingRecorded = errors.New("no request to convert: drive traffic through the proxy before stopping the recording")
+
+// ConvertOptions configures the conversion of recordings.
+type ConvertOptions struct {
+	// IncludeStatic keeps the requests of static assets, such as images, stylesheets,
+	// scripts and fonts.
+	IncludeStatic bool
+	// GroupGap is the pause between requests beyond which a new group starts; a negative
+	// gap writes the requests outside groups.
+	GroupGap time.Duration
+	// ThinkTime converts the pauses between groups into sleeps.
+	ThinkTime bool
+	// Correlate extracts the dynamic values responses set, such as session IDs and CSRF
+	// tokens, for the later requests sending them.
+	Correlate bool
+	// PayloadHints are the fields of JSON payloads, by key or by dotted path, replaced by
+	// values generated on each iteration, such as unique emails, instead of their recorded
+	// values. FakePayloads also replaces the string fields named as emails, UUIDs,
+	// usernames, names and phone numbers.
+	PayloadHints map[string]codegen.Fake
+	FakePayloads bool
+}
+
+// Conversion is a script converted from a recording.
+type Conversion struct {
+	Script *codegen.Script
+	// Requests is the number of requests converted, and Skipped the number of static assets
+	// and failed requests left out.
+	Requests int
+	Skipped  int
+	// RemovedHeaders are the credential headers left out of the script, such as Cookie and
+	// Authorization, which must be set from secrets instead. Credentials set by earlier
+	// responses are correlated instead.
+	RemovedHeaders []string
+	// Correlations are the dynamic values extracted from responses, and Uncorrelated the
+	// values of requests that look dynamic, but that no response sets.
+	Correlations []Correlation
+	Uncorrelated []Uncorrelated
+}
+
+// skippedHeaders are the headers left out of the requests of the script: k6 sets them
+// itself, or they only matter to the recording client.
+var skippedHeaders = map[string]bool{
+	"Accept-Encoding":   true,
+	"Content-Length":    true,
+	"Host":              true,
+	"If-Modified-Since": true,
+	"If-None-Match":     true,
+	"User-Agent":        true,
+}
+
+// credentialHeaders are the headers of credentials, left out of the script rather than
+// written in clear.
+var credentialHeaders = map[string]bool{
+	"Authorization": true,
+	"Cookie":        true,
+	"X-Api-Key":     true,
+	"X-Auth-Token":  true,
+	"X-Csrf-Token":  true,
+}
+
+// staticExtensions are the extensions of the paths of static assets.
+var staticExtensions = map[string]bool{
+	".css": true, ".js": true, ".mjs": true, ".map": true,
+	".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".svg": true, ".webp": true, ".avif": true, ".ico": true,
+	".woff": true, ".woff2": true, ".ttf": true, ".otf": true, ".eot": true,
+	".mp4": true, ".webm": true, ".mp3": true,
+}
+
+// Convert converts the recording into a script replaying its requests, grouped by the
+// pauses between them, with the status they received checked.
+func Convert(recording *Recording, opts ConvertOptions) (*Conversion, error) {
+	if opts.GroupGap == 0 {
+		opts.GroupGap = DefaultGroupGap
+	}
+
+	conversion := &Conversion{}
+	removed := make(map[string]bool)
+
+	var entries []Entry
+	for _, entry := range recording.Entries {
+		if entry.Error != "" || (!opts.IncludeStatic && isStatic(entry)) {
+			conversion.Skipped++
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if len(entries) == 0 {
+		return nil, ErrNothingRecorded
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].StartedAt.Before(entries[j].StartedAt) })
+
+	var correlations *correlator
+	if opts.Correlate {
+		correlations = correlate(entries)
+		conversion.Correlations, conversion.Uncorrelated = correlations.results()
+	}
+
+	script := &codegen.Script{
+		Comment: recordingComment(recording, len(entries)),
+		BaseURL: mainOrigin(entries),
+		Options: codegen.Options{
+			VUs:        1,
+			Iterations: 1,
+			Thresholds: map[string][]string{"http_req_failed": {"rate<0.01"}},
+		},
+	}
+
+	var group *codegen.Group
+	var groupEnd time.Time
+	for i, entry := range entries {
+		if group == nil || (opts.GroupGap > 0 && entry.StartedAt.Sub(groupEnd) > opts.GroupGap) {
+			if group != nil && opts.ThinkTime {
+				last := &group.Requests[len(group.Requests)-1]
+				last.ThinkTime = thinkTime(entry.StartedAt.Sub(groupEnd))
+			}
+			script.Flow.Groups = append(script.Flow.Groups, codegen.Group{})
+			group = &script.Flow.Groups[len(script.Flow.Groups)-1]
+			if opts.GroupGap > 0 {
+				group.Name = groupName(entry)
+			}
+		}
+
+		var substitutions []codegen.Substitution
+		if correlations != nil {
+			substitutions = correlations.substitutions(i)
+		}
+		req := request(entry, substitutions, removed, opts)
+		if correlations != nil {
+			req.Extractions = correlations.extractions(i)
+		}
+		group.Requests = append(group.Requests, req)
+		if end := entry.StartedAt.Add(entry.Duration); end.After(groupEnd) {
+			groupEnd = end
+		}
+	}
+
+	conversion.Script = script
+	conversion.Requests = len(entries)
+	for header := range removed {
+		conversion.RemovedHeaders = append(conversion.RemovedHeaders, header)
+	}
+	sort.Strings(conversion.RemovedHeaders)
+
+	return conversion, nil
+}
+
+// request returns the request of the script replaying the entry, with the substitutions
+// of its correlated values and the fake values of its payload, recording the credential
+// headers it leaves out.
+func request(entry Entry, substitutions []codegen.Substitution, removed map[string]bool, opts ConvertOptions) codegen.Request {
+	req := codegen.Request{
+		Method:        entry.Method,
+		URL:           entry.URL,
+		Headers:       make(map[string]string),
+		Substitutions: substitutions,
+	}
+	if entry.Status > 0 {
+		req.Checks = []codegen.Check{codegen.StatusCheck(entry.Status)}
+	}
+
+	for name, values := range entry.Headers {
+		name = http.CanonicalHeaderKey(name)
+		switch {
+		case skippedHeaders[name] || strings.HasPrefix(name, "Sec-") || len(values) == 0:
+		case credentialHeaders[name] && (name == "Cookie" || !substituted(values[0], substitutions)):
+			removed[name] = true
+		default:
+			req.Headers[name] = strings.Join(values, ", ")
+		}
+	}
+
+	if len(entry.Body) == 0 {
+		return req
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(entry.Headers.Get("Content-Type"))
+	switch {
+	case entry.BodyTruncated:
+		req.Comment = fmt.Sprintf("The body of this request was larger than %dKB: replace it with a complete payload", maxRecordedBodyBytes/1024)
+		req.Body = string(bytes.ToValidUTF8(entry.Body, nil))
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		decoder := json.NewDecoder(bytes.NewReader(entry.Body))
+		decoder.UseNumber()
+		var body any
+		if err := decoder.Decode(&body); err == nil {
+			req.JSONBody = codegen.JSON(body)
+			if len(opts.PayloadHints) > 0 || opts.FakePayloads {
+				req.JSONBody = codegen.Parameterize(req.JSONBody, opts.PayloadHints, opts.FakePayloads)
+			}
+			return req
+		}
+		req.Body = string(entry.Body)
+	case utf8.Valid(entry.Body):
+		req.Body = string(entry.Body)
+	default:
+		req.Comment = fmt.Sprintf("The binary body of this request (%d bytes) was left out: load it with open() in the init context", len(entry.Body))
+	}
+
+	return req
+}
+
+// substituted reports whether a substitution applies to the value.
+func substituted(value string, substitutions []codegen.Substitution) bool {
+	for _, substitution := range substitutions {
+		if strings.Contains(value, substitution.Value) {
+			return true
+		}
+	}
+	return false
+}
+
+// isStatic reports whether the entry is the request of a static asset.
+func isStatic(entry Entry) bool {
+	mediaType, _, _ := mime.ParseMediaType(entry.ResponseContentType)
+	switch {
+	case strings.HasPrefix(mediaType, "image/"), strings.HasPrefix(mediaType, "font/"), strings.HasPrefix(mediaType, "video/"),
+		mediaType == "text/css", mediaType == "text/javascript", mediaType == "application/javascript":
+		return true
+	}
+
+	u, err := url.Parse(entry.URL)
+	if err != nil {
+		return false
+	}
+	return entry.Method == http.MethodGet && staticExtensions[strings.ToLower(path.Ext(u.Path))]
+}
+
+// mainOrigin returns the origin most requests were sent to.
+func mainOrigin(entries []Entry) string {
+	counts := make(map[string]int)
+	var origin string
+	for _, entry := range entries {
+		u, err := url.Parse(entry.URL)
+		if err != nil {
+			continue
+		}
+		o := u.Scheme + "://" + u.Host
+		counts[o]++
+		if counts[o] > counts[origin] {
+			origin = o
+		}
+	}
+	return origin
+}
+
+// groupName names a group after the request starting it, such as "GET /login".
+func groupName(entry Entry) string {
+	u, err := url.Parse(entry.URL)
+	if err != nil || u.Path == "" {
+		return entry.Method + " /"
+	}
+	return entry.Method + " " + u.Path
+}
+
+// thinkTime returns the pause in seconds, rounded to a tenth and capped at maxThinkTime.
+func thinkTime(pause time.Duration) float64 {
+	return math.Min(math.Round(pause.Seconds()*10)/10, maxThinkTime)
+}
+
+// recordingComment returns the header comment of scripts converted from the recording.
+func recordingComment(recording *Recording, requests int) string {
+	comment := fmt.Sprintf("Recorded with k6-mcp on %s: %d requests.", recording.StartedAt.Format(time.RFC3339), requests)
+	if recording.Name != "" {
+		comment = fmt.Sprintf("%s\nRecording: %s", comment, recording.Name)
+	}
+	return comment + "\nRun against another target with: k6 run -e BASE_URL=https://staging.example.com <script>"
+}
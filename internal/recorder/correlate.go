@@ -0,0 +1,694 @@
+package recorder
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/json"
+	"html"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/oleiade/k6-mcp/internal/codegen"
+)
+
+// Extractors of correlated values.
+const (
+	ExtractorJSON   = "json"
+	ExtractorHTML   = "html"
+	ExtractorHeader = "header"
+	ExtractorRegexp = "regexp"
+)
+
+const (
+	// maxCorrelations and maxUncorrelated bound the values correlated and reported.
+	maxCorrelations = 50
+	maxUncorrelated = 50
+	// maxJSONDepth and maxJSONLeaves bound the walk of JSON documents for values.
+	maxJSONDepth  = 8
+	maxJSONLeaves = 500
+	// maxDecodedBytes is the maximum size of decoded response bodies.
+	maxDecodedBytes = 1024 * 1024
+)
+
+// Correlation is a dynamic value a response sets and later requests send, such as a
+// session ID or a CSRF token, which the script extracts instead of replaying.
+type Correlation struct {
+	Variable string `json:"variable"`
+	// Source is the request whose response sets the value, e.g. "POST https://test.k6.io/login".
+	Source    string `json:"source"`
+	Extractor string `json:"extractor"`
+	// Path locates the value in the response: a JSON path, a CSS selector, a header name or
+	// a regular expression, following Extractor.
+	Path string `json:"path"`
+	// Uses is the number of later requests sending the value.
+	Uses int `json:"uses"`
+}
+
+// Uncorrelated is a value of a request that looks dynamic, but that no earlier response
+// sets, such as a value computed by client-side code.
+type Uncorrelated struct {
+	Request  string `json:"request"`
+	Location string `json:"location"`
+	Reason   string `json:"reason"`
+}
+
+var (
+	// inputPattern and metaPattern match the input and meta elements of HTML documents, and
+	// attributePattern their attributes.
+	inputPattern     = regexp.MustCompile(`(?is)<input\b[^>]*>`)
+	metaPattern      = regexp.MustCompile(`(?is)<meta\b[^>]*>`)
+	attributePattern = regexp.MustCompile(`(?is)\b([a-z-]+)\s*=\s*(?:"([^"]*)"|'([^']*)')`)
+	// tokenValuePattern matches the values regular expressions extract.
+	tokenValuePattern = regexp.MustCompile(`^[A-Za-z0-9._~+/=-]+$`)
+	// authSchemePattern matches the scheme of Authorization header values.
+	authSchemePattern = regexp.MustCompile(`(?i)^(Bearer|Basic|Token|Digest)\s+`)
+	// asciiWordPattern matches the words variable names are made of.
+	asciiWordPattern = regexp.MustCompile(`^[A-Za-z0-9]+$`)
+)
+
+// dynamicWords are the words of the names of dynamic values.
+var dynamicWords = map[string]bool{
+	"auth": true, "authorization": true, "code": true, "csrf": true, "guid": true, "id": true,
+	"jwt": true, "key": true, "nonce": true, "otp": true, "secret": true, "session": true,
+	"sid": true, "sig": true, "signature": true, "state": true, "ticket": true, "token": true,
+	"uuid": true, "viewstate": true, "xsrf": true,
+}
+
+// genericNames are the names of values qualified by the name of their parent.
+var genericNames = map[string]bool{"id": true, "uuid": true, "guid": true, "key": true, "value": true}
+
+// reservedNames are the names variables cannot take: JavaScript keywords and the names of
+// generated scripts.
+var reservedNames = map[string]bool{
+	"BASE_URL": true, "await": true, "break": true, "case": true, "catch": true, "check": true,
+	"class": true, "const": true, "continue": true, "debugger": true, "default": true,
+	"delete": true, "do": true, "else": true, "enum": true, "export": true, "extends": true,
+	"false": true, "finally": true, "for": true, "function": true, "group": true, "http": true,
+	"if": true, "implements": true, "import": true, "in": true, "instanceof": true,
+	"interface": true, "let": true, "new": true, "null": true, "options": true, "package": true,
+	"private": true, "protected": true, "public": true, "res": true, "return": true,
+	"sleep": true, "static": true, "super": true, "switch": true, "this": true, "throw": true,
+	"true": true, "try": true, "typeof": true, "var": true, "void": true, "while": true,
+	"with": true, "yield": true,
+}
+
+// candidate is a value of a response that requests may send back.
+type candidate struct {
+	// name is the name the value is known by, from which its variable is named.
+	name       string
+	value      string
+	extractor  string
+	path       string
+	extraction func(variable string) codegen.Extraction
+}
+
+// correlation is a correlation, with the value it replaces.
+type correlation struct {
+	Correlation
+	value      string
+	source     int
+	extraction codegen.Extraction
+}
+
+// correlator correlates the dynamic values of entries.
+type correlator struct {
+	entries []Entry
+	// responses are the decoded response bodies of the entries, and requests the text of
+	// their URL, headers and body, in which values are searched.
+	responses []string
+	requests  []string
+
+	correlations []*correlation
+	variables    map[string]bool
+	uncorrelated []Uncorrelated
+	reported     map[string]bool
+}
+
+// correlate finds the values responses set that later requests send, and reports the
+// values of requests that look dynamic but that no response sets.
+func correlate(entries []Entry) *correlator {
+	c := &correlator{
+		entries:   entries,
+		responses: make([]string, len(entries)),
+		requests:  make([]string, len(entries)),
+		variables: make(map[string]bool),
+		reported:  make(map[string]bool),
+	}
+	for i, entry := range entries {
+		c.responses[i] = responseText(entry)
+		c.requests[i] = requestText(entry)
+	}
+
+	// Values found in the structure of responses, used by later requests
+	for i, entry := range entries {
+		for _, cand := range candidates(entry, c.responses[i]) {
+			if !isDynamic(cand.name, cand.value) || c.covered(cand.value) || c.sentBefore(cand.value, i) {
+				continue
+			}
+			if uses := c.uses(cand.value, i); uses > 0 {
+				c.add(cand, i, uses)
+			}
+		}
+	}
+
+	// Values of requests that look dynamic, searched in the text of earlier responses
+	for j, entry := range entries {
+		for _, value := range requestValues(entry) {
+			if c.covered(value.value) || c.sentBefore(value.value, j-1) {
+				continue
+			}
+			if cand, i, ok := c.search(value, j); ok {
+				c.add(cand, i, c.uses(cand.value, i))
+				continue
+			}
+			c.report(entry, value)
+		}
+	}
+
+	return c
+}
+
+// extractions returns the extractions following the request of the entry.
+func (c *correlator) extractions(i int) []codegen.Extraction {
+	var extractions []codegen.Extraction
+	for _, corr := range c.correlations {
+		if corr.source == i {
+			extractions = append(extractions, corr.extraction)
+		}
+	}
+	return extractions
+}
+
+// substitutions returns the substitutions of the values the request of the entry sends.
+func (c *correlator) substitutions(j int) []codegen.Substitution {
+	var substitutions []codegen.Substitution
+	for _, corr := range c.correlations {
+		if corr.source >= j {
+			continue
+		}
+		if strings.Contains(c.requests[j], corr.value) {
+			substitutions = append(substitutions, codegen.Substitution{Value: corr.value, Expression: corr.Variable})
+		}
+		if escaped := url.QueryEscape(corr.value); escaped != corr.value && strings.Contains(c.requests[j], escaped) {
+			substitutions = append(substitutions, codegen.Substitution{Value: escaped, Expression: "encodeURIComponent(" + corr.Variable + ")"})
+		}
+	}
+	return substitutions
+}
+
+// results returns the correlations, and the values reported uncorrelated.
+func (c *correlator) results() ([]Correlation, []Uncorrelated) {
+	correlations := make([]Correlation, len(c.correlations))
+	for i, corr := range c.correlations {
+		correlations[i] = corr.Correlation
+	}
+	return correlations, c.uncorrelated
+}
+
+// add adds the correlation of the candidate, set by the response of entry i.
+func (c *correlator) add(cand candidate, i, uses int) {
+	if len(c.correlations) >= maxCorrelations {
+		return
+	}
+
+	variable := c.variable(cand.name)
+	c.correlations = append(c.correlations, &correlation{
+		Correlation: Correlation{
+			Variable:  variable,
+			Source:    c.entries[i].Method + " " + c.entries[i].URL,
+			Extractor: cand.extractor,
+			Path:      cand.path,
+			Uses:      uses,
+		},
+		value:      cand.value,
+		source:     i,
+		extraction: cand.extraction(variable),
+	})
+}
+
+// report reports the value of the request of the entry as uncorrelated.
+func (c *correlator) report(entry Entry, value requestValue) {
+	if c.reported[value.value] || len(c.uncorrelated) >= maxUncorrelated {
+		return
+	}
+	c.reported[value.value] = true
+
+	reason := "Not set by an earlier response: it may be computed by client-side code, or be a credential; set it from an environment variable, or compute it in the script"
+	if value.credential {
+		reason = "Credential not set by an earlier response: set it from an environment variable or an auth profile"
+	}
+	c.uncorrelated = append(c.uncorrelated, Uncorrelated{
+		Request:  entry.Method + " " + entry.URL,
+		Location: value.location,
+		Reason:   reason,
+	})
+}
+
+// covered reports whether the value is, or holds, a correlated value.
+func (c *correlator) covered(value string) bool {
+	for _, corr := range c.correlations {
+		if strings.Contains(value, corr.value) {
+			return true
+		}
+	}
+	return false
+}
+
+// sentBefore reports whether a request up to entry i sent the value, which the client
+// then knew before the responses after it.
+func (c *correlator) sentBefore(value string, i int) bool {
+	escaped := url.QueryEscape(value)
+	for k := 0; k <= i && k < len(c.requests); k++ {
+		if strings.Contains(c.requests[k], value) || strings.Contains(c.requests[k], escaped) {
+			return true
+		}
+	}
+	return false
+}
+
+// uses returns the number of requests after entry i sending the value.
+func (c *correlator) uses(value string, i int) int {
+	escaped := url.QueryEscape(value)
+	uses := 0
+	for k := i + 1; k < len(c.requests); k++ {
+		if strings.Contains(c.requests[k], value) || strings.Contains(c.requests[k], escaped) {
+			uses++
+		}
+	}
+	return uses
+}
+
+// search searches the value of the request of entry j in the headers and bodies of the
+// earlier responses, the latest first.
+func (c *correlator) search(value requestValue, j int) (candidate, int, bool) {
+	for i := j - 1; i >= 0; i-- {
+		for name, values := range c.entries[i].ResponseHeaders {
+			if len(values) == 0 || values[0] != value.value || name == "Set-Cookie" {
+				continue
+			}
+			header := http.CanonicalHeaderKey(name)
+			return headerCandidate(value.name, header, value.value), i, true
+		}
+
+		pattern, ok := valuePattern(c.responses[i], value.value)
+		if !ok {
+			continue
+		}
+		return candidate{
+			name:      value.name,
+			value:     value.value,
+			extractor: ExtractorRegexp,
+			path:      pattern,
+			extraction: func(variable string) codegen.Extraction {
+				return codegen.RegexpExtraction(variable, pattern)
+			},
+		}, i, true
+	}
+	return candidate{}, 0, false
+}
+
+// variable returns a unique variable name for a value known by the name.
+func (c *correlator) variable(name string) string {
+	words := nameWords(name)
+	if len(words) > 1 && strings.EqualFold(words[0], "x") {
+		words = words[1:]
+	}
+
+	var b strings.Builder
+	for _, word := range words {
+		if !asciiWordPattern.MatchString(word) {
+			continue
+		}
+		word = strings.ToLower(word)
+		if b.Len() > 0 {
+			word = strings.ToUpper(word[:1]) + word[1:]
+		}
+		b.WriteString(word)
+	}
+	base := b.String()
+	if base == "" || !unicode.IsLetter(rune(base[0])) {
+		base = "value" + strings.ToUpper(base[:min(1, len(base))]) + base[min(1, len(base)):]
+	}
+	if reservedNames[base] {
+		base += "Value"
+	}
+
+	variable := base
+	for n := 2; c.variables[variable]; n++ {
+		variable = base + strconv.Itoa(n)
+	}
+	c.variables[variable] = true
+	return variable
+}
+
+// candidates returns the values of the response of the entry, located in its structure:
+// JSON fields, HTML hidden inputs and meta elements, and headers of dynamic names.
+func candidates(entry Entry, body string) []candidate {
+	var cands []candidate
+
+	for name, values := range entry.ResponseHeaders {
+		header := http.CanonicalHeaderKey(name)
+		if header == "Set-Cookie" || len(values) == 0 || !isDynamicName(header) {
+			continue
+		}
+		cands = append(cands, headerCandidate(header, header, values[0]))
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(entry.ResponseContentType)
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		decoder := json.NewDecoder(strings.NewReader(body))
+		decoder.UseNumber()
+		var document any
+		if err := decoder.Decode(&document); err == nil {
+			leaves := 0
+			walkJSON(document, "", "", 0, &leaves, func(name, path, value string) {
+				cands = append(cands, candidate{
+					name:      name,
+					value:     value,
+					extractor: ExtractorJSON,
+					path:      path,
+					extraction: func(variable string) codegen.Extraction {
+						return codegen.JSONPathExtraction(variable, path)
+					},
+				})
+			})
+		}
+	case mediaType == "text/html" || mediaType == "application/xhtml+xml":
+		for _, input := range inputPattern.FindAllString(body, -1) {
+			attrs := attributes(input)
+			if !strings.EqualFold(attrs["type"], "hidden") || attrs["name"] == "" || attrs["value"] == "" {
+				continue
+			}
+			cands = append(cands, selectorCandidate(attrs["name"], `input[name="`+attrs["name"]+`"]`, "value", attrs["value"]))
+		}
+		for _, meta := range metaPattern.FindAllString(body, -1) {
+			attrs := attributes(meta)
+			if attrs["name"] == "" || attrs["content"] == "" || !isDynamicName(attrs["name"]) {
+				continue
+			}
+			cands = append(cands, selectorCandidate(attrs["name"], `meta[name="`+attrs["name"]+`"]`, "content", attrs["content"]))
+		}
+	}
+
+	return cands
+}
+
+// headerCandidate returns the candidate of a response header.
+func headerCandidate(name, header, value string) candidate {
+	return candidate{
+		name:      name,
+		value:     value,
+		extractor: ExtractorHeader,
+		path:      header,
+		extraction: func(variable string) codegen.Extraction {
+			return codegen.HeaderExtraction(variable, header)
+		},
+	}
+}
+
+// selectorCandidate returns the candidate of an attribute of an HTML element.
+func selectorCandidate(name, selector, attribute, value string) candidate {
+	return candidate{
+		name:      name,
+		value:     value,
+		extractor: ExtractorHTML,
+		path:      selector,
+		extraction: func(variable string) codegen.Extraction {
+			return codegen.SelectorExtraction(variable, selector, attribute)
+		},
+	}
+}
+
+// walkJSON calls fn with the name, the path in the syntax of res.json(), and the value of
+// the string and number fields of the document.
+func walkJSON(v any, name, path string, depth int, leaves *int, fn func(name, path, value string)) {
+	if depth > maxJSONDepth || *leaves >= maxJSONLeaves {
+		return
+	}
+
+	join := func(key string) string {
+		if path == "" {
+			return key
+		}
+		return path + "." + key
+	}
+
+	switch v := v.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			// Keys holding the special characters of paths cannot be addressed
+			if key == "" || strings.ContainsAny(key, `.*?#|@\`) {
+				continue
+			}
+			// Generic names are qualified by their parent: data.user.id is a user ID
+			keyName := key
+			if genericNames[strings.ToLower(key)] && name != "" {
+				keyName = name + "_" + key
+			}
+			walkJSON(v[key], keyName, join(key), depth+1, leaves, fn)
+		}
+	case []any:
+		for i, element := range v {
+			walkJSON(element, name, join(strconv.Itoa(i)), depth+1, leaves, fn)
+		}
+	case string:
+		*leaves++
+		fn(name, path, v)
+	case json.Number:
+		// Numbers are only values of their own when named so, such as IDs
+		if isDynamicName(name) {
+			*leaves++
+			fn(name, path, v.String())
+		}
+	}
+}
+
+// requestValue is a value of a request that looks dynamic.
+type requestValue struct {
+	name     string
+	value    string
+	location string
+	// credential reports whether the value is sent as a credential, such as a bearer token.
+	credential bool
+}
+
+// requestValues returns the values of the request of the entry that look dynamic: the
+// query parameters, form fields and JSON fields of dynamic names, and the credential
+// headers, but the cookies the cookie jar of k6 replays.
+func requestValues(entry Entry) []requestValue {
+	var values []requestValue
+	add := func(name, value, location string, credential bool) {
+		if credential || isDynamic(name, value) && isDynamicName(name) {
+			values = append(values, requestValue{name: name, value: value, location: location, credential: credential})
+		}
+	}
+
+	if u, err := url.Parse(entry.URL); err == nil {
+		for _, name := range sortedKeys(u.Query()) {
+			add(name, u.Query().Get(name), "query parameter "+name, false)
+		}
+	}
+
+	for _, name := range sortedKeys(entry.Headers) {
+		header := http.CanonicalHeaderKey(name)
+		value := entry.Headers.Get(name)
+		switch {
+		case header == "Cookie" || value == "":
+		case credentialHeaders[header]:
+			if scheme := authSchemePattern.FindString(value); scheme != "" && !strings.HasPrefix(strings.ToLower(scheme), "basic") {
+				value = value[len(scheme):]
+			}
+			add(header, value, "header "+header, true)
+		default:
+			add(header, value, "header "+header, false)
+		}
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(entry.Headers.Get("Content-Type"))
+	switch {
+	case mediaType == "application/x-www-form-urlencoded":
+		if form, err := url.ParseQuery(string(entry.Body)); err == nil {
+			for _, name := range sortedKeys(form) {
+				add(name, form.Get(name), "form field "+name, false)
+			}
+		}
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		decoder := json.NewDecoder(bytes.NewReader(entry.Body))
+		decoder.UseNumber()
+		var document any
+		if err := decoder.Decode(&document); err == nil {
+			leaves := 0
+			walkJSON(document, "", "", 0, &leaves, func(name, path, value string) {
+				add(name, value, "JSON field "+path, false)
+			})
+		}
+	}
+
+	return values
+}
+
+// valuePattern returns a regular expression extracting the value from the text, anchored
+// on the text preceding its first occurrence.
+func valuePattern(text, value string) (string, bool) {
+	if !tokenValuePattern.MatchString(value) {
+		return "", false
+	}
+	index := strings.Index(text, value)
+	if index < 0 {
+		return "", false
+	}
+
+	prefix := text[max(0, index-24):index]
+	if newline := strings.LastIndexAny(prefix, "\r\n"); newline >= 0 {
+		prefix = prefix[newline+1:]
+	}
+	if len(strings.TrimSpace(prefix)) < 3 {
+		return "", false
+	}
+
+	pattern := regexp.QuoteMeta(prefix) + `([A-Za-z0-9._~+/=-]+)`
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return "", false
+	}
+	if match := re.FindStringSubmatch(text); match == nil || match[1] != value {
+		return "", false
+	}
+	return pattern, true
+}
+
+// isDynamic reports whether the value, known by the name, looks generated by the server:
+// long enough, and holding digits unless it is long, or named as a dynamic value.
+func isDynamic(name, value string) bool {
+	if strings.Contains(value, "://") || strings.ContainsAny(value, " \t\r\n") {
+		return false
+	}
+	hasDigit := strings.ContainsAny(value, "0123456789")
+	if isDynamicName(name) {
+		return len(value) >= 4 && (hasDigit || len(value) >= 16)
+	}
+	return len(value) >= 12 && hasDigit
+}
+
+// isDynamicName reports whether the name is the name of dynamic values, such as
+// csrf_token, sessionId or X-Request-Id.
+func isDynamicName(name string) bool {
+	for _, word := range nameWords(name) {
+		if dynamicWords[strings.ToLower(word)] {
+			return true
+		}
+	}
+	return false
+}
+
+// nameWords returns the words of a name, splitting camelCase, snake_case, kebab-case and
+// dotted words.
+func nameWords(name string) []string {
+	var words []string
+	var word []rune
+	flush := func() {
+		if len(word) > 0 {
+			words = append(words, string(word))
+			word = word[:0]
+		}
+	}
+
+	runes := []rune(name)
+	for i, r := range runes {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			flush()
+		case unicode.IsUpper(r) && i > 0 && unicode.IsLower(runes[i-1]):
+			flush()
+			word = append(word, r)
+		default:
+			word = append(word, r)
+		}
+	}
+	flush()
+
+	return words
+}
+
+// attributes returns the attributes of an HTML tag, by lower-case name, unescaped.
+func attributes(tag string) map[string]string {
+	attrs := make(map[string]string)
+	for _, match := range attributePattern.FindAllStringSubmatch(tag, -1) {
+		value := match[2]
+		if value == "" {
+			value = match[3]
+		}
+		attrs[strings.ToLower(match[1])] = html.UnescapeString(value)
+	}
+	return attrs
+}
+
+// requestText returns the URL, headers and body of the request of the entry, in which
+// the values it sends are searched. Cookies are left out, being replayed by k6.
+func requestText(entry Entry) string {
+	var b strings.Builder
+	b.WriteString(entry.URL)
+	for name, values := range entry.Headers {
+		if http.CanonicalHeaderKey(name) == "Cookie" {
+			continue
+		}
+		for _, value := range values {
+			b.WriteString("\n" + value)
+		}
+	}
+	b.WriteString("\n")
+	b.Write(entry.Body)
+	return b.String()
+}
+
+// responseText returns the response body of the entry, decoded from its content encoding.
+// Truncated bodies are decoded as far as they go.
+func responseText(entry Entry) string {
+	var reader io.Reader
+	switch strings.ToLower(entry.ResponseHeaders.Get("Content-Encoding")) {
+	case "":
+		return string(entry.ResponseBody)
+	case "gzip":
+		gz, err := gzip.NewReader(bytes.NewReader(entry.ResponseBody))
+		if err != nil {
+			return ""
+		}
+		reader = gz
+	case "deflate":
+		if zr, err := zlib.NewReader(bytes.NewReader(entry.ResponseBody)); err == nil {
+			reader = zr
+		} else {
+			reader = flate.NewReader(bytes.NewReader(entry.ResponseBody))
+		}
+	default:
+		return ""
+	}
+
+	decoded, _ := io.ReadAll(io.LimitReader(reader, maxDecodedBytes))
+	return string(decoded)
+}
+
+// sortedKeys returns the keys of the map, sorted.
+func sortedKeys(m map[string][]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
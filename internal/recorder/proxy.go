@@ -0,0 +1,296 @@
+package recorder
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// maxForwardedBodyBytes is the maximum size of the request bodies the proxy forwards,
+	// and maxRecordedBodyBytes the size of the part of the bodies recorded.
+	maxForwardedBodyBytes = 32 * 1024 * 1024
+	maxRecordedBodyBytes  = 64 * 1024
+	// maxRecordedResponseBytes is the size of the part of textual response bodies recorded.
+	maxRecordedResponseBytes = 128 * 1024
+)
+
+// hopHeaders are the hop-by-hop headers, which the proxy does not forward.
+var hopHeaders = []string{
+	"Connection",
+	"Keep-Alive",
+	"Proxy-Authenticate",
+	"Proxy-Authorization",
+	"Proxy-Connection",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+// proxy is the HTTP proxy of a recording. Plain HTTP requests are forwarded as is; HTTPS
+// connections, opened with CONNECT, are intercepted with certificates of the authority.
+type proxy struct {
+	session   *Session
+	authority *authority
+	transport *http.Transport
+
+	mu      sync.Mutex
+	tunnels map[net.Conn]struct{}
+	closed  bool
+}
+
+func newProxy(session *Session, authority *authority) *proxy {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// Bodies are passed through as encoded by the servers, as the clients negotiated
+	transport.DisableCompression = true
+	transport.ForceAttemptHTTP2 = false
+
+	return &proxy{
+		session:   session,
+		authority: authority,
+		transport: transport,
+		tunnels:   make(map[net.Conn]struct{}),
+	}
+}
+
+func (p *proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.Method == http.MethodConnect:
+		p.tunnel(w, r)
+	case r.URL.IsAbs():
+		p.forward(w, r, r.URL.Scheme, r.URL.Host)
+	default:
+		http.Error(w, "k6-mcp recording proxy: configure this address as the HTTP and HTTPS proxy of the client", http.StatusBadRequest)
+	}
+}
+
+// tunnel intercepts the HTTPS connection the client opened to the host of the request,
+// serving the requests it sends over it.
+func (p *proxy) tunnel(w http.ResponseWriter, r *http.Request) {
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "connection cannot be intercepted", http.StatusInternalServerError)
+		return
+	}
+	conn, _, err := hijacker.Hijack()
+	if err != nil {
+		return
+	}
+	if !p.track(conn) {
+		_ = conn.Close()
+		return
+	}
+	defer p.untrack(conn)
+	defer conn.Close()
+
+	if _, err := io.WriteString(conn, "HTTP/1.1 200 Connection Established\r\n\r\n"); err != nil {
+		return
+	}
+
+	host := r.URL.Hostname()
+	tlsConn := tls.Server(conn, &tls.Config{
+		GetCertificate: func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+			if hello.ServerName != "" {
+				return p.authority.certificate(hello.ServerName)
+			}
+			return p.authority.certificate(host)
+		},
+		NextProtos: []string{"http/1.1"},
+	})
+	_ = tlsConn.SetDeadline(time.Now().Add(30 * time.Second))
+	if err := tlsConn.Handshake(); err != nil {
+		return
+	}
+	_ = tlsConn.SetDeadline(time.Time{})
+
+	authority := r.Host
+	server := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			p.forward(w, r, "https", authority)
+		}),
+		ReadHeaderTimeout: 30 * time.Second,
+	}
+	_ = server.Serve(newConnListener(tlsConn))
+}
+
+// forward sends the request to the host, writes its response, and records both.
+func (p *proxy) forward(w http.ResponseWriter, r *http.Request, scheme, host string) {
+	if r.Header.Get("Upgrade") != "" {
+		http.Error(w, "k6-mcp recording proxy: protocol upgrades, such as WebSockets, are not supported", http.StatusNotImplemented)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxForwardedBodyBytes+1))
+	if err != nil {
+		http.Error(w, "failed to read the request body", http.StatusBadRequest)
+		return
+	}
+	if len(body) > maxForwardedBodyBytes {
+		http.Error(w, fmt.Sprintf("k6-mcp recording proxy: request bodies are limited to %d bytes", maxForwardedBodyBytes), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	out := r.Clone(r.Context())
+	out.RequestURI = ""
+	out.URL.Scheme, out.URL.Host = scheme, host
+	out.Host = host
+	out.Body = io.NopCloser(bytes.NewReader(body))
+	out.ContentLength = int64(len(body))
+	if len(body) == 0 {
+		out.Body = http.NoBody
+	}
+	for _, header := range hopHeaders {
+		out.Header.Del(header)
+	}
+
+	entry := Entry{
+		Method:    r.Method,
+		URL:       out.URL.String(),
+		Headers:   out.Header.Clone(),
+		StartedAt: time.Now().UTC(),
+	}
+	entry.Body = body
+	if len(body) > maxRecordedBodyBytes {
+		entry.Body, entry.BodyTruncated = body[:maxRecordedBodyBytes:maxRecordedBodyBytes], true
+	}
+
+	resp, err := p.transport.RoundTrip(out)
+	if err != nil {
+		entry.Error = err.Error()
+		entry.Duration = time.Since(entry.StartedAt)
+		p.session.record(entry, out.URL.Hostname())
+		http.Error(w, "k6-mcp recording proxy: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+
+	entry.Status = resp.StatusCode
+	entry.ResponseContentType = resp.Header.Get("Content-Type")
+	entry.ResponseHeaders = resp.Header.Clone()
+	entry.Duration = time.Since(entry.StartedAt)
+
+	for _, header := range hopHeaders {
+		resp.Header.Del(header)
+	}
+	for name, values := range resp.Header {
+		w.Header()[name] = values
+	}
+	w.WriteHeader(resp.StatusCode)
+
+	// Textual responses are recorded as they are relayed, for their dynamic values
+	var respBody io.Reader = resp.Body
+	captured := &limitedBuffer{limit: maxRecordedResponseBytes}
+	if isTextual(entry.ResponseContentType) {
+		respBody = io.TeeReader(resp.Body, captured)
+	}
+	_, _ = io.Copy(w, respBody)
+
+	entry.ResponseBody = captured.buf
+	p.session.record(entry, out.URL.Hostname())
+}
+
+// isTextual reports whether responses of the content type are text, such as JSON, HTML or
+// XML documents.
+func isTextual(contentType string) bool {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	return strings.HasPrefix(mediaType, "text/") && mediaType != "text/css" && mediaType != "text/javascript" ||
+		strings.HasSuffix(mediaType, "json") || strings.HasSuffix(mediaType, "xml") ||
+		mediaType == "application/x-www-form-urlencoded"
+}
+
+// limitedBuffer keeps the beginning of what is written to it, up to its limit.
+type limitedBuffer struct {
+	buf   []byte
+	limit int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - len(b.buf); room > 0 {
+		b.buf = append(b.buf, p[:min(room, len(p))]...)
+	}
+	return len(p), nil
+}
+
+// track tracks an intercepted connection, unless the proxy is closed.
+func (p *proxy) track(conn net.Conn) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return false
+	}
+	p.tunnels[conn] = struct{}{}
+	return true
+}
+
+func (p *proxy) untrack(conn net.Conn) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.tunnels, conn)
+}
+
+// close closes the intercepted connections, which the server of the recording no longer
+// tracks once hijacked.
+func (p *proxy) close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	for conn := range p.tunnels {
+		_ = conn.Close()
+	}
+	p.transport.CloseIdleConnections()
+}
+
+// connListener is a listener accepting a single connection, to serve the requests of an
+// intercepted connection with an http.Server.
+type connListener struct {
+	conn      net.Conn
+	once      sync.Once
+	closeOnce sync.Once
+	done      chan struct{}
+}
+
+func newConnListener(conn net.Conn) *connListener {
+	l := &connListener{done: make(chan struct{})}
+	l.conn = &closeNotifyConn{Conn: conn, closed: l.Close}
+	return l
+}
+
+func (l *connListener) Accept() (net.Conn, error) {
+	var conn net.Conn
+	l.once.Do(func() { conn = l.conn })
+	if conn != nil {
+		return conn, nil
+	}
+	<-l.done
+	return nil, net.ErrClosed
+}
+
+func (l *connListener) Close() error {
+	l.closeOnce.Do(func() { close(l.done) })
+	return nil
+}
+
+func (l *connListener) Addr() net.Addr {
+	return l.conn.LocalAddr()
+}
+
+// closeNotifyConn is a connection closing its listener once closed.
+type closeNotifyConn struct {
+	net.Conn
+	closeOnce sync.Once
+	closed    func() error
+}
+
+func (c *closeNotifyConn) Close() error {
+	err := c.Conn.Close()
+	c.closeOnce.Do(func() { _ = c.closed() })
+	return err
+}
@@ -0,0 +1,303 @@
+// Package recorder records the HTTP traffic users drive through a local capture proxy,
+// such as a browser session or an API client, and converts the recordings into k6 scripts.
+// HTTPS traffic is intercepted with certificates issued by a certificate authority the
+// recorder generates, which clients must trust.
+package recorder
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// MaxSessions is the maximum number of recordings in progress.
+	MaxSessions = 4
+	// MaxEntries is the maximum number of requests a recording keeps; later requests are
+	// still proxied, but not recorded.
+	MaxEntries = 5000
+	// DefaultMaxDuration and MaxDuration bound how long a recording runs before its proxy
+	// stops by itself. Its requests are kept until the recording is stopped.
+	DefaultMaxDuration = 30 * time.Minute
+	MaxDuration        = 4 * time.Hour
+
+	// recorderDirName is the name of the directory, within the data directory, holding the
+	// certificate authority.
+	recorderDirName = "recorder"
+)
+
+// ErrSessionNotFound is returned when no recording has the requested ID.
+var ErrSessionNotFound = errors.New("recording not found")
+
+// Entry is a recorded request, with the response it received.
+type Entry struct {
+	Method  string      `json:"method"`
+	URL     string      `json:"url"`
+	Headers http.Header `json:"headers,omitempty"`
+	Body    []byte      `json:"-"`
+	// BodyTruncated reports whether the body was larger than the recorded part.
+	BodyTruncated bool `json:"body_truncated,omitempty"`
+
+	Status              int    `json:"status,omitempty"`
+	ResponseContentType string `json:"response_content_type,omitempty"`
+	// ResponseHeaders and ResponseBody are the headers and the beginning of the body of the
+	// response, as encoded by the server, from which dynamic values are correlated.
+	ResponseHeaders http.Header `json:"-"`
+	ResponseBody    []byte      `json:"-"`
+	// Error is the reason the request failed without response.
+	Error string `json:"error,omitempty"`
+
+	StartedAt time.Time     `json:"started_at"`
+	Duration  time.Duration `json:"duration"`
+}
+
+// Recording is the result of a stopped recording.
+type Recording struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name,omitempty"`
+	StartedAt time.Time `json:"started_at"`
+	StoppedAt time.Time `json:"stopped_at"`
+	Entries   []Entry   `json:"entries"`
+	// Dropped is the number of requests proxied past MaxEntries, or outside the hosts of the
+	// recording, which were not recorded.
+	Dropped int `json:"dropped,omitempty"`
+}
+
+// StartOptions configures a recording.
+type StartOptions struct {
+	// Name labels the recording, and the script converted from it.
+	Name string
+	// Port is the local port the proxy listens on; 0 picks a free port.
+	Port int
+	// Hosts are the hosts whose requests are recorded; empty records every host. Entries
+	// starting with "*." also match any subdomain.
+	Hosts []string
+	// MaxDuration is how long the proxy runs before stopping by itself.
+	MaxDuration time.Duration
+}
+
+// Session is a recording in progress.
+type Session struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name,omitempty"`
+	Addr      string    `json:"addr"`
+	StartedAt time.Time `json:"started_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Hosts     []string  `json:"hosts,omitempty"`
+
+	mu      sync.Mutex
+	entries []Entry
+	dropped int
+	server  *http.Server
+	proxy   *proxy
+	timer   *time.Timer
+}
+
+// Recorder runs recordings, each through its own proxy.
+type Recorder struct {
+	dir string
+
+	mu        sync.Mutex
+	authority *authority
+	sessions  map[string]*Session
+}
+
+// New creates a Recorder keeping its certificate authority in the recorder directory of
+// dir.
+func New(dir string) *Recorder {
+	return &Recorder{dir: filepath.Join(dir, recorderDirName), sessions: make(map[string]*Session)}
+}
+
+// CACertificatePath returns the path of the PEM certificate of the certificate authority
+// clients must trust to record HTTPS traffic, generating it if needed.
+func (r *Recorder) CACertificatePath() (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	authority, err := r.loadAuthority()
+	if err != nil {
+		return "", err
+	}
+	return authority.certPath, nil
+}
+
+// Start starts a recording, listening on the loopback interface.
+func (r *Recorder) Start(opts StartOptions) (*Session, error) {
+	if opts.Port < 0 || opts.Port > 65535 {
+		return nil, fmt.Errorf("invalid port %d", opts.Port)
+	}
+	if opts.MaxDuration <= 0 {
+		opts.MaxDuration = DefaultMaxDuration
+	}
+	if opts.MaxDuration > MaxDuration {
+		return nil, fmt.Errorf("recordings last at most %s", MaxDuration)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.sessions) >= MaxSessions {
+		return nil, fmt.Errorf("%d recordings are already in progress; stop one first", MaxSessions)
+	}
+	authority, err := r.loadAuthority()
+	if err != nil {
+		return nil, err
+	}
+	id, err := newID()
+	if err != nil {
+		return nil, err
+	}
+
+	listener, err := net.Listen("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(opts.Port)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on port %d: %w", opts.Port, err)
+	}
+
+	now := time.Now().UTC()
+	session := &Session{
+		ID:        id,
+		Name:      opts.Name,
+		Addr:      listener.Addr().String(),
+		StartedAt: now,
+		ExpiresAt: now.Add(opts.MaxDuration),
+		Hosts:     opts.Hosts,
+	}
+	session.proxy = newProxy(session, authority)
+	session.server = &http.Server{
+		Handler:           session.proxy,
+		ReadHeaderTimeout: 30 * time.Second,
+	}
+	go func() {
+		_ = session.server.Serve(listener)
+	}()
+	session.timer = time.AfterFunc(opts.MaxDuration, session.shutdown)
+
+	r.sessions[id] = session
+	return session, nil
+}
+
+// Stop stops a recording, and returns its requests.
+func (r *Recorder) Stop(id string) (*Recording, error) {
+	r.mu.Lock()
+	session, ok := r.sessions[id]
+	delete(r.sessions, id)
+	r.mu.Unlock()
+	if !ok {
+		return nil, ErrSessionNotFound
+	}
+
+	session.timer.Stop()
+	session.shutdown()
+
+	session.mu.Lock()
+	defer session.mu.Unlock()
+	return &Recording{
+		ID:        session.ID,
+		Name:      session.Name,
+		StartedAt: session.StartedAt,
+		StoppedAt: time.Now().UTC(),
+		Entries:   session.entries,
+		Dropped:   session.dropped,
+	}, nil
+}
+
+// Sessions returns the recordings in progress.
+func (r *Recorder) Sessions() []*Session {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	sessions := make([]*Session, 0, len(r.sessions))
+	for _, session := range r.sessions {
+		sessions = append(sessions, session)
+	}
+	return sessions
+}
+
+// Close stops the recordings in progress, discarding their requests.
+func (r *Recorder) Close() {
+	r.mu.Lock()
+	sessions := r.sessions
+	r.sessions = make(map[string]*Session)
+	r.mu.Unlock()
+
+	for _, session := range sessions {
+		session.timer.Stop()
+		session.shutdown()
+	}
+}
+
+// Entries returns the number of requests recorded so far.
+func (s *Session) Entries() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.entries)
+}
+
+// record records the request, unless its host is not recorded or the recording is full.
+func (s *Session) record(entry Entry, host string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.entries) >= MaxEntries || !s.recordsHost(host) {
+		s.dropped++
+		return
+	}
+	s.entries = append(s.entries, entry)
+}
+
+// recordsHost reports whether the requests to the host are recorded.
+func (s *Session) recordsHost(host string) bool {
+	if len(s.Hosts) == 0 {
+		return true
+	}
+
+	host = strings.ToLower(host)
+	for _, pattern := range s.Hosts {
+		pattern = strings.ToLower(pattern)
+		if host == pattern || (strings.HasPrefix(pattern, "*.") && strings.HasSuffix(host, pattern[1:])) {
+			return true
+		}
+	}
+	return false
+}
+
+// shutdown stops the proxy of the recording, closing its intercepted connections and
+// waiting for the other requests in flight.
+func (s *Session) shutdown() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	s.proxy.close()
+	if err := s.server.Shutdown(ctx); err != nil {
+		_ = s.server.Close()
+	}
+}
+
+// loadAuthority loads the certificate authority on first use. The caller holds r.mu.
+func (r *Recorder) loadAuthority() (*authority, error) {
+	if r.authority == nil {
+		authority, err := loadAuthority(r.dir)
+		if err != nil {
+			return nil, err
+		}
+		r.authority = authority
+	}
+	return r.authority, nil
+}
+
+// newID returns a random recording ID.
+func newID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate recording ID: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,31 @@
+package recorder
+
+import (
+	"github.com/oleiade/k6-mcp/internal/privacy"
+)
+
+// Scrub masks the personal data of the requests and responses of the recording, before it
+// is converted, so that scripts neither replay nor expose it. The scrubber masks a value the
+// same way in requests and responses, keeping the values correlated.
+func (r *Recording) Scrub(scrubber *privacy.Scrubber) {
+	for i := range r.Entries {
+		entry := &r.Entries[i]
+		entry.URL = scrubber.Scrub(entry.URL)
+		entry.Body = []byte(scrubber.Scrub(string(entry.Body)))
+
+		if len(entry.ResponseBody) == 0 {
+			continue
+		}
+		// Compressed responses are scrubbed decoded, and kept so
+		if encoding := entry.ResponseHeaders.Get("Content-Encoding"); encoding != "" {
+			text := responseText(*entry)
+			if text == "" {
+				continue
+			}
+			entry.ResponseHeaders = entry.ResponseHeaders.Clone()
+			entry.ResponseHeaders.Del("Content-Encoding")
+			entry.ResponseBody = []byte(text)
+		}
+		entry.ResponseBody = []byte(scrubber.Scrub(string(entry.ResponseBody)))
+	}
+}
@@ -0,0 +1,292 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/oleiade/k6-mcp/internal/artifacts"
+	"github.com/oleiade/k6-mcp/internal/runner"
+)
+
+// ChatFormat is the format of the chat messages run summaries are rendered into.
+type ChatFormat string
+
+const (
+	// ChatSlack renders a Slack Block Kit message.
+	ChatSlack ChatFormat = "slack"
+	// ChatTeams renders a Microsoft Teams message holding an Adaptive Card.
+	ChatTeams ChatFormat = "teams"
+
+	// maxChatThresholds is the number of thresholds listed in chat messages; failed ones
+	// come first.
+	maxChatThresholds = 15
+)
+
+// ParseChatFormat returns the ChatFormat matching s.
+func ParseChatFormat(s string) (ChatFormat, error) {
+	switch ChatFormat(strings.ToLower(s)) {
+	case ChatSlack:
+		return ChatSlack, nil
+	case ChatTeams, "msteams":
+		return ChatTeams, nil
+	}
+	return "", fmt.Errorf("unsupported chat format %q; expected %s or %s", s, ChatSlack, ChatTeams)
+}
+
+// ChatSummary is a run summarized in a chat message.
+type ChatSummary struct {
+	Title  string
+	Result runner.RunResult
+	// RunID is the ID of the run in the run history, if any.
+	RunID int
+	// Artifacts are the stored files of the run. ArtifactsURL, when set, is the base URL
+	// they are served under, by ID, to link them.
+	Artifacts    []artifacts.Artifact
+	ArtifactsURL string
+	// Link is a URL to open from the message, such as a dashboard or a report.
+	Link string
+}
+
+// chatFact is a labeled value of a chat message.
+type chatFact struct {
+	Title string
+	Value string
+}
+
+// Chat renders the summary as the JSON payload of a chat message, to post to the incoming
+// webhook or the API of the chat.
+func Chat(summary *ChatSummary, format ChatFormat) (string, error) {
+	var payload any
+	switch format {
+	case ChatSlack:
+		payload = slackMessage(summary)
+	case ChatTeams:
+		payload = teamsMessage(summary)
+	default:
+		return "", fmt.Errorf("unsupported chat format %q", format)
+	}
+
+	data, err := json.MarshalIndent(payload, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode %s message: %w", format, err)
+	}
+	return string(data), nil
+}
+
+// chatHeadline returns the title and status line of the summary.
+func chatHeadline(summary *ChatSummary) (string, string) {
+	title := summary.Title
+	if title == "" {
+		title = "k6 load test"
+	}
+
+	view := newRunView("", &summary.Result)
+	status := "✅ Passed"
+	if !summary.Result.Success {
+		status = "❌ Failed"
+		if view.Thresholds == "crossed" {
+			status = "❌ Thresholds crossed"
+		}
+	}
+	if view.Description != "" {
+		status += ": " + view.Description
+	}
+	return title, status
+}
+
+// chatFacts returns the key figures of the run.
+func chatFacts(summary *ChatSummary) []chatFact {
+	view := newRunView("", &summary.Result)
+	var facts []chatFact
+	if view.Duration != "" {
+		facts = append(facts, chatFact{"Duration", view.Duration})
+	}
+	facts = append(facts, []chatFact{
+		{"Requests", fmt.Sprintf("%d (%d failed, %.2f%%)", view.Requests, view.Failed, view.ErrorRate)},
+		{"p95 response time", fmt.Sprintf("%.1f ms", view.P95)},
+		{"Request rate", fmt.Sprintf("%.1f/s", view.RequestRate)},
+	}...)
+	if view.Grade != "" {
+		facts = append([]chatFact{{"Grade", view.Grade}}, facts...)
+	}
+	if summary.RunID != 0 {
+		facts = append(facts, chatFact{"Run", fmt.Sprintf("#%d", summary.RunID)})
+	}
+	if checks := summary.Result.Checks; len(checks) > 0 {
+		var passes, total int
+		for _, check := range checks {
+			passes += check.Passes
+			total += check.Passes + check.Fails
+		}
+		facts = append(facts, chatFact{"Checks", fmt.Sprintf("%d/%d passed", passes, total)})
+	}
+	return facts
+}
+
+// chatThresholds returns the thresholds of the run, failed ones first, up to
+// maxChatThresholds, and the number of thresholds left out.
+func chatThresholds(result *runner.RunResult) ([]runner.ThresholdOutcome, int) {
+	var thresholds []runner.ThresholdOutcome
+	for _, passed := range []bool{false, true} {
+		for _, threshold := range result.Thresholds {
+			if threshold.Passed == passed {
+				thresholds = append(thresholds, threshold)
+			}
+		}
+	}
+	if len(thresholds) > maxChatThresholds {
+		return thresholds[:maxChatThresholds], len(thresholds) - maxChatThresholds
+	}
+	return thresholds, 0
+}
+
+// thresholdMark returns the mark of a threshold outcome.
+func thresholdMark(threshold runner.ThresholdOutcome) string {
+	if threshold.Passed {
+		return "✅"
+	}
+	return "❌"
+}
+
+// chatArtifacts returns the artifacts of the summary as a title and a URL, or the ID to
+// read them with get_artifact when no base URL serves them.
+func chatArtifacts(summary *ChatSummary) []chatFact {
+	var links []chatFact
+	for _, artifact := range summary.Artifacts {
+		if summary.ArtifactsURL != "" {
+			links = append(links, chatFact{artifact.Name, strings.TrimRight(summary.ArtifactsURL, "/") + "/" + artifact.ID})
+		} else {
+			links = append(links, chatFact{artifact.Name, ""})
+		}
+	}
+	return links
+}
+
+// slackMessage renders the summary as a Slack Block Kit message.
+func slackMessage(summary *ChatSummary) map[string]any {
+	title, status := chatHeadline(summary)
+
+	var fields []map[string]any
+	for _, fact := range chatFacts(summary) {
+		fields = append(fields, slackText("*"+fact.Title+"*\n"+fact.Value))
+	}
+	blocks := []map[string]any{
+		{"type": "header", "text": map[string]any{"type": "plain_text", "text": title, "emoji": true}},
+		{"type": "section", "text": slackText(status)},
+		{"type": "section", "fields": fields},
+	}
+
+	if thresholds, omitted := chatThresholds(&summary.Result); len(thresholds) > 0 {
+		var table strings.Builder
+		for _, threshold := range thresholds {
+			fmt.Fprintf(&table, "%s %s: %s\n", thresholdMark(threshold), threshold.Metric, threshold.Threshold)
+		}
+		if omitted > 0 {
+			fmt.Fprintf(&table, "… and %d more\n", omitted)
+		}
+		blocks = append(blocks,
+			map[string]any{"type": "divider"},
+			map[string]any{"type": "section", "text": slackText("*Thresholds*\n```" + strings.TrimSuffix(table.String(), "\n") + "```")},
+		)
+	}
+
+	if links := chatArtifacts(summary); len(links) > 0 {
+		// Context blocks hold at most 10 elements, the first of which is the label
+		links = links[:min(len(links), 9)]
+		var elements []map[string]any
+		for _, link := range links {
+			if link.Value != "" {
+				elements = append(elements, slackText(fmt.Sprintf("<%s|%s>", link.Value, link.Title)))
+			} else {
+				elements = append(elements, slackText(link.Title))
+			}
+		}
+		blocks = append(blocks, map[string]any{"type": "context", "elements": append([]map[string]any{slackText("*Artifacts:*")}, elements...)})
+	}
+
+	if summary.Link != "" {
+		blocks = append(blocks, map[string]any{
+			"type": "actions",
+			"elements": []map[string]any{{
+				"type": "button",
+				"text": map[string]any{"type": "plain_text", "text": "Open"},
+				"url":  summary.Link,
+			}},
+		})
+	}
+
+	// The text is the fallback of notifications and clients not rendering blocks
+	return map[string]any{"text": title + ": " + status, "blocks": blocks}
+}
+
+func slackText(text string) map[string]any {
+	return map[string]any{"type": "mrkdwn", "text": text}
+}
+
+// teamsMessage renders the summary as a Microsoft Teams message holding an Adaptive Card.
+func teamsMessage(summary *ChatSummary) map[string]any {
+	title, status := chatHeadline(summary)
+	color := "Good"
+	if !summary.Result.Success {
+		color = "Attention"
+	}
+
+	var facts []map[string]any
+	for _, fact := range chatFacts(summary) {
+		facts = append(facts, map[string]any{"title": fact.Title, "value": fact.Value})
+	}
+	body := []map[string]any{
+		{"type": "TextBlock", "text": title, "size": "Large", "weight": "Bolder", "wrap": true},
+		{"type": "TextBlock", "text": status, "color": color, "wrap": true},
+		{"type": "FactSet", "facts": facts},
+	}
+
+	if thresholds, omitted := chatThresholds(&summary.Result); len(thresholds) > 0 {
+		var rows []map[string]any
+		for _, threshold := range thresholds {
+			rows = append(rows, map[string]any{"title": thresholdMark(threshold) + " " + threshold.Metric, "value": threshold.Threshold})
+		}
+		if omitted > 0 {
+			rows = append(rows, map[string]any{"title": "…", "value": fmt.Sprintf("%d more", omitted)})
+		}
+		body = append(body,
+			map[string]any{"type": "TextBlock", "text": "Thresholds", "weight": "Bolder", "separator": true},
+			map[string]any{"type": "FactSet", "facts": rows},
+		)
+	}
+
+	var actions []map[string]any
+	var unlinked []string
+	for _, link := range chatArtifacts(summary) {
+		if link.Value != "" {
+			actions = append(actions, map[string]any{"type": "Action.OpenUrl", "title": link.Title, "url": link.Value})
+		} else {
+			unlinked = append(unlinked, link.Title)
+		}
+	}
+	if len(unlinked) > 0 {
+		body = append(body, map[string]any{"type": "TextBlock", "text": "Artifacts: " + strings.Join(unlinked, ", "), "isSubtle": true, "wrap": true})
+	}
+	if summary.Link != "" {
+		actions = append([]map[string]any{{"type": "Action.OpenUrl", "title": "Open", "url": summary.Link}}, actions...)
+	}
+
+	card := map[string]any{
+		"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+		"type":    "AdaptiveCard",
+		"version": "1.4",
+		"body":    body,
+	}
+	if len(actions) > 0 {
+		card["actions"] = actions
+	}
+
+	return map[string]any{
+		"type": "message",
+		"attachments": []map[string]any{{
+			"contentType": "application/vnd.microsoft.card.adaptive",
+			"content":     card,
+		}},
+	}
+}
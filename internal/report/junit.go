@@ -0,0 +1,126 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/oleiade/k6-mcp/internal/runner"
+)
+
+// junitTestSuites is the root element of a JUnit XML report.
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+// junitTestSuite is a suite of a JUnit XML report.
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Errors   int             `xml:"errors,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+// junitTestCase is a test case of a JUnit XML report.
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitProblem `xml:"failure,omitempty"`
+	Error     *junitProblem `xml:"error,omitempty"`
+}
+
+// junitProblem is the failure or error of a JUnit test case.
+type junitProblem struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// JUnit renders the threshold and check outcomes of a run as a JUnit XML report: each
+// threshold and check is a test case, and runs that failed for other reasons than their
+// thresholds have an errored "run" test case. name names the report, e.g. after the script.
+func JUnit(result *runner.RunResult, name string) (string, error) {
+	if name == "" {
+		name = "k6"
+	}
+
+	run := junitTestSuite{Name: name + ".run"}
+	runCase := junitTestCase{Name: "run", ClassName: run.Name}
+	if !result.Success && result.ExitCode != thresholdsExitCode {
+		message := result.Error
+		if message == "" {
+			message = fmt.Sprintf("k6 exited with code %d", result.ExitCode)
+		}
+		runCase.Error = &junitProblem{Message: message, Type: "run", Text: result.Stderr}
+		run.Errors++
+	}
+	run.Cases = append(run.Cases, runCase)
+	run.Tests++
+
+	thresholds := junitTestSuite{Name: name + ".thresholds"}
+	for _, threshold := range result.Thresholds {
+		testCase := junitTestCase{Name: fmt.Sprintf("%s: %s", threshold.Metric, threshold.Threshold), ClassName: thresholds.Name}
+		if !threshold.Passed {
+			testCase.Failure = &junitProblem{
+				Message: fmt.Sprintf("threshold %q of metric %s was crossed", threshold.Threshold, threshold.Metric),
+				Type:    "threshold",
+			}
+			thresholds.Failures++
+		}
+		thresholds.Cases = append(thresholds.Cases, testCase)
+		thresholds.Tests++
+	}
+
+	checks := junitTestSuite{Name: name + ".checks"}
+	for _, check := range result.Checks {
+		testCase := junitTestCase{Name: check.Name, ClassName: checks.Name}
+		if group := strings.TrimPrefix(check.Group, "::"); group != "" {
+			testCase.ClassName = checks.Name + "." + strings.ReplaceAll(group, "::", ".")
+		}
+		if check.Fails > 0 {
+			total := check.Passes + check.Fails
+			testCase.Failure = &junitProblem{
+				Message: fmt.Sprintf("%d of %d evaluations failed (%.2f%%)", check.Fails, total, float64(check.Fails)/float64(total)*100),
+				Type:    "check",
+			}
+			checks.Failures++
+		}
+		checks.Cases = append(checks.Cases, testCase)
+		checks.Tests++
+	}
+
+	report := junitTestSuites{Name: name, Time: junitSeconds(result.Duration)}
+	for _, suite := range []junitTestSuite{run, thresholds, checks} {
+		if suite.Tests == 0 {
+			continue
+		}
+		report.Suites = append(report.Suites, suite)
+		report.Tests += suite.Tests
+		report.Failures += suite.Failures
+		report.Errors += suite.Errors
+	}
+
+	data, err := xml.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode JUnit report: %w", err)
+	}
+
+	return xml.Header + string(data) + "\n", nil
+}
+
+// junitSeconds formats a run duration as the seconds of JUnit time attributes.
+func junitSeconds(duration string) string {
+	d, err := time.ParseDuration(duration)
+	if err != nil {
+		return "0"
+	}
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
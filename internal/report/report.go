@@ -0,0 +1,425 @@
+// Package report renders k6 run results into Markdown or HTML load test reports, suitable
+// for pasting into tickets or wikis, and validation and run outcomes into the SARIF and
+// JUnit XML reports CI systems consume.
+package report
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/oleiade/k6-mcp/internal/resources"
+	"github.com/oleiade/k6-mcp/internal/runner"
+	"github.com/oleiade/k6-mcp/internal/security"
+	"github.com/oleiade/k6-mcp/internal/workspace"
+)
+
+// Format is a report output format.
+type Format string
+
+const (
+	// FormatMarkdown renders a Markdown report, with ASCII charts.
+	FormatMarkdown Format = "markdown"
+	// FormatHTML renders a standalone HTML report, with inline SVG charts.
+	FormatHTML Format = "html"
+
+	// MaxRuns is the maximum number of runs a report can compare.
+	MaxRuns = 10
+
+	// thresholdsExitCode is the exit code of k6 runs whose thresholds were crossed.
+	thresholdsExitCode = 99
+	// asciiChartWidth is the width of the bars of ASCII charts, in characters.
+	asciiChartWidth = 40
+)
+
+// templateNames maps each format to its embedded template.
+var templateNames = map[Format]string{
+	FormatMarkdown: "report.md.tmpl",
+	FormatHTML:     "report.html.tmpl",
+}
+
+// ParseFormat returns the Format matching s. An empty string selects FormatMarkdown.
+func ParseFormat(s string) (Format, error) {
+	switch Format(strings.ToLower(s)) {
+	case "", FormatMarkdown, "md":
+		return FormatMarkdown, nil
+	case FormatHTML:
+		return FormatHTML, nil
+	}
+	return "", fmt.Errorf("unsupported format %q; expected %s or %s", s, FormatMarkdown, FormatHTML)
+}
+
+// Run is a labeled run result to report on.
+type Run struct {
+	Label  string
+	Result runner.RunResult
+}
+
+// Spec describes a report.
+type Spec struct {
+	Title string
+	Runs  []Run
+	// Notes (optional) are rendered as an introduction.
+	Notes string
+}
+
+// view is the data the report templates render.
+type view struct {
+	Title       string
+	Notes       string
+	GeneratedAt string
+	Runs        []runView
+	Comparison  []comparisonRow
+	// Baseline is the label of the run the others are compared to.
+	Baseline string
+	Chart    string
+	SVG      htmltemplate.HTML
+}
+
+// runView is a run, as rendered in reports.
+type runView struct {
+	Label           string
+	Status          string
+	Grade           string
+	Description     string
+	Duration        string
+	ExitCode        int
+	Thresholds      string
+	Requests        int
+	Failed          int
+	ErrorRate       float64
+	Avg             float64
+	Med             float64
+	P90             float64
+	P95             float64
+	P99             float64
+	Max             float64
+	RequestRate     float64
+	DataReceived    string
+	DataSent        string
+	Issues          []runner.TestIssue
+	Recommendations []string
+	Scenarios       []scenarioView
+	WebVitals       []webVitalView
+}
+
+// scenarioView is a scenario of a run, as rendered in reports.
+type scenarioView struct {
+	Name      string
+	Requests  int
+	ErrorRate float64
+	Avg       float64
+	P95       float64
+}
+
+// webVitalView is a web vital of a browser run, as rendered in reports.
+type webVitalView struct {
+	Name string
+	Avg  float64
+	P75  float64
+}
+
+// comparisonRow compares a metric across runs.
+type comparisonRow struct {
+	Metric string
+	Values []string
+	// Deltas are the changes of each run after the first, relative to it.
+	Deltas []string
+}
+
+// chartMetrics are the response time statistics charted in reports.
+var chartMetrics = []struct {
+	name  string
+	value func(runView) float64
+}{
+	{"avg", func(r runView) float64 { return r.Avg }},
+	{"med", func(r runView) float64 { return r.Med }},
+	{"p90", func(r runView) float64 { return r.P90 }},
+	{"p95", func(r runView) float64 { return r.P95 }},
+	{"p99", func(r runView) float64 { return r.P99 }},
+}
+
+var funcMap = template.FuncMap{
+	"f1": func(v float64) string { return fmt.Sprintf("%.1f", v) },
+	"f2": func(v float64) string { return fmt.Sprintf("%.2f", v) },
+	"mdCell": func(s string) string {
+		return strings.NewReplacer("|", `\|`, "\n", " ").Replace(s)
+	},
+}
+
+// Generate renders the report in the given format.
+func Generate(spec *Spec, format Format) (string, error) {
+	templateName, ok := templateNames[format]
+	if !ok {
+		return "", fmt.Errorf("unsupported format %q", format)
+	}
+	if len(spec.Runs) == 0 {
+		return "", fmt.Errorf("a report needs at least one run")
+	}
+	if len(spec.Runs) > MaxRuns {
+		return "", fmt.Errorf("a report compares at most %d runs (received %d)", MaxRuns, len(spec.Runs))
+	}
+
+	v := view{
+		Title:       spec.Title,
+		Notes:       spec.Notes,
+		GeneratedAt: time.Now().UTC().Format(time.RFC3339),
+	}
+	if v.Title == "" {
+		v.Title = "k6 load test report"
+	}
+
+	for i, run := range spec.Runs {
+		label := run.Label
+		if label == "" {
+			label = fmt.Sprintf("Run %d", i+1)
+		}
+		v.Runs = append(v.Runs, newRunView(label, &run.Result))
+	}
+
+	if len(v.Runs) > 1 {
+		v.Baseline = v.Runs[0].Label
+		v.Comparison = compare(v.Runs)
+	}
+	v.Chart = asciiChart(v.Runs)
+	v.SVG = svgChart(v.Runs)
+
+	path := "resources/templates/" + templateName
+	var buf bytes.Buffer
+	if format == FormatHTML {
+		tmpl, err := htmltemplate.New(templateName).Funcs(htmltemplate.FuncMap(funcMap)).ParseFS(resources.FS(), path)
+		if err != nil {
+			return "", fmt.Errorf("failed to parse template %s: %w", templateName, err)
+		}
+		if err := tmpl.Execute(&buf, v); err != nil {
+			return "", fmt.Errorf("failed to execute template %s: %w", templateName, err)
+		}
+	} else {
+		tmpl, err := template.New(templateName).Funcs(funcMap).ParseFS(resources.FS(), path)
+		if err != nil {
+			return "", fmt.Errorf("failed to parse template %s: %w", templateName, err)
+		}
+		if err := tmpl.Execute(&buf, v); err != nil {
+			return "", fmt.Errorf("failed to execute template %s: %w", templateName, err)
+		}
+	}
+
+	return strings.TrimSpace(buf.String()) + "\n", nil
+}
+
+// newRunView prepares a run result for rendering.
+func newRunView(label string, result *runner.RunResult) runView {
+	summary := result.Summary
+	r := runView{
+		Label:           label,
+		Status:          "passed",
+		Grade:           result.Analysis.Grade,
+		Description:     result.Analysis.Description,
+		Duration:        result.Duration,
+		ExitCode:        result.ExitCode,
+		Thresholds:      "passed",
+		Requests:        summary.TotalRequests,
+		Failed:          summary.FailedRequests,
+		Avg:             summary.AvgResponseTime,
+		Med:             summary.MedResponseTime,
+		P90:             summary.P90ResponseTime,
+		P95:             summary.P95ResponseTime,
+		P99:             summary.P99ResponseTime,
+		Max:             summary.MaxResponseTime,
+		RequestRate:     summary.RequestRate,
+		DataReceived:    summary.DataReceived,
+		DataSent:        summary.DataSent,
+		Issues:          result.Issues,
+		Recommendations: result.Recommendations,
+	}
+	if !result.Success {
+		r.Status = "failed"
+		r.Thresholds = "not evaluated"
+	}
+	if result.ExitCode == thresholdsExitCode {
+		r.Thresholds = "crossed"
+	}
+	if summary.TotalRequests > 0 {
+		r.ErrorRate = float64(summary.FailedRequests) / float64(summary.TotalRequests) * 100
+	}
+
+	for name, scenario := range summary.Scenarios {
+		s := scenarioView{Name: name, Requests: scenario.TotalRequests, Avg: scenario.AvgResponseTime, P95: scenario.P95ResponseTime}
+		if scenario.TotalRequests > 0 {
+			s.ErrorRate = float64(scenario.FailedRequests) / float64(scenario.TotalRequests) * 100
+		}
+		r.Scenarios = append(r.Scenarios, s)
+	}
+	sort.Slice(r.Scenarios, func(i, j int) bool { return r.Scenarios[i].Name < r.Scenarios[j].Name })
+
+	for name, vital := range summary.WebVitals {
+		r.WebVitals = append(r.WebVitals, webVitalView{Name: name, Avg: vital.Avg, P75: vital.P75})
+	}
+	sort.Slice(r.WebVitals, func(i, j int) bool { return r.WebVitals[i].Name < r.WebVitals[j].Name })
+
+	return r
+}
+
+// compare builds the comparison table of the runs, relative to the first one.
+func compare(runs []runView) []comparisonRow {
+	metrics := []struct {
+		name   string
+		value  func(runView) float64
+		format string
+	}{
+		{"Requests", func(r runView) float64 { return float64(r.Requests) }, "%.0f"},
+		{"Error rate (%)", func(r runView) float64 { return r.ErrorRate }, "%.2f"},
+		{"Avg response time (ms)", func(r runView) float64 { return r.Avg }, "%.1f"},
+		{"p95 response time (ms)", func(r runView) float64 { return r.P95 }, "%.1f"},
+		{"p99 response time (ms)", func(r runView) float64 { return r.P99 }, "%.1f"},
+		{"Request rate (req/s)", func(r runView) float64 { return r.RequestRate }, "%.1f"},
+	}
+
+	rows := make([]comparisonRow, 0, len(metrics))
+	for _, metric := range metrics {
+		row := comparisonRow{Metric: metric.name}
+		base := metric.value(runs[0])
+		for i, run := range runs {
+			value := metric.value(run)
+			row.Values = append(row.Values, fmt.Sprintf(metric.format, value))
+			if i == 0 {
+				continue
+			}
+			row.Deltas = append(row.Deltas, delta(base, value))
+		}
+		rows = append(rows, row)
+	}
+
+	return rows
+}
+
+// delta formats the relative change from base to value.
+func delta(base, value float64) string {
+	switch {
+	case base == value:
+		return "="
+	case base == 0:
+		return "n/a"
+	}
+	return fmt.Sprintf("%+.1f%%", (value-base)/base*100)
+}
+
+// chartScale returns the largest charted value of the runs.
+func chartScale(runs []runView) float64 {
+	var highest float64
+	for _, run := range runs {
+		for _, metric := range chartMetrics {
+			highest = math.Max(highest, metric.value(run))
+		}
+	}
+	return highest
+}
+
+// asciiChart renders the response time statistics of the runs as horizontal ASCII bars.
+func asciiChart(runs []runView) string {
+	highest := chartScale(runs)
+	if highest == 0 {
+		return ""
+	}
+
+	labelWidth := 0
+	for _, run := range runs {
+		labelWidth = max(labelWidth, len(run.Label))
+	}
+
+	var b strings.Builder
+	for i, run := range runs {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		if len(runs) > 1 {
+			fmt.Fprintf(&b, "%s\n", run.Label)
+		}
+		for _, metric := range chartMetrics {
+			value := metric.value(run)
+			width := int(math.Round(value / highest * asciiChartWidth))
+			fmt.Fprintf(&b, "  %-3s |%s%s %.1f ms\n", metric.name,
+				strings.Repeat("█", width), strings.Repeat(" ", asciiChartWidth-width), value)
+		}
+	}
+
+	return b.String()
+}
+
+// svgPalette holds the bar colors of the runs of SVG charts.
+var svgPalette = []string{"#7d64ff", "#f2a93b", "#3ba8f2", "#e5534b", "#57ab5a", "#986ee2", "#c69026", "#39c5cf", "#ec775c", "#8ddb8c"}
+
+// svgChart renders the response time statistics of the runs as an inline SVG bar chart.
+func svgChart(runs []runView) htmltemplate.HTML {
+	highest := chartScale(runs)
+	if highest == 0 {
+		return ""
+	}
+
+	const (
+		chartHeight = 200
+		barWidth    = 18
+		groupGap    = 24
+		top         = 20
+		left        = 10
+		legendRow   = 18
+	)
+	groupWidth := barWidth*len(runs) + groupGap
+	width := left*2 + groupWidth*len(chartMetrics)
+	height := top + chartHeight + 30 + legendRow*len(runs)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" role="img" aria-label="Response times (ms)" font-family="sans-serif" font-size="11">`, width, height)
+	for m, metric := range chartMetrics {
+		x0 := left + m*groupWidth
+		for i, run := range runs {
+			value := metric.value(run)
+			barHeight := int(math.Round(value / highest * chartHeight))
+			x := x0 + i*barWidth
+			y := top + chartHeight - barHeight
+			fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s"><title>%s %s: %.1f ms</title></rect>`,
+				x, y, barWidth-2, barHeight, svgPalette[i%len(svgPalette)], htmltemplate.HTMLEscapeString(run.Label), metric.name, value)
+		}
+		fmt.Fprintf(&b, `<text x="%d" y="%d" text-anchor="middle">%s</text>`, x0+barWidth*len(runs)/2, top+chartHeight+16, metric.name)
+	}
+	for i, run := range runs {
+		y := top + chartHeight + 30 + legendRow*i
+		fmt.Fprintf(&b, `<rect x="%d" y="%d" width="12" height="12" fill="%s"/><text x="%d" y="%d">%s</text>`,
+			left, y, svgPalette[i%len(svgPalette)], left+18, y+10, htmltemplate.HTMLEscapeString(run.Label))
+	}
+	b.WriteString(`</svg>`)
+
+	// #nosec G203 - labels are escaped, and the rest of the markup is generated
+	return htmltemplate.HTML(b.String())
+}
+
+// WriteToWorkspace writes the report to the relative path name within the workspace
+// directory dir, and returns the absolute path it was written to.
+func WriteToWorkspace(dir, name, report string) (string, error) {
+	cleaned, err := workspace.CleanRelativePath(name)
+	if err != nil {
+		return "", fmt.Errorf("invalid output path %q: %w", name, err)
+	}
+
+	target, err := filepath.Abs(filepath.Join(dir, filepath.FromSlash(cleaned)))
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve output path: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), 0o750); err != nil {
+		return "", fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	if err := os.WriteFile(target, []byte(report), security.SecureFileMode); err != nil {
+		return "", fmt.Errorf("failed to write report: %w", err)
+	}
+
+	return target, nil
+}
@@ -0,0 +1,184 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/oleiade/k6-mcp/internal/buildinfo"
+	"github.com/oleiade/k6-mcp/internal/security"
+	"github.com/oleiade/k6-mcp/internal/validator"
+)
+
+const (
+	// sarifVersion and sarifSchema identify the version of SARIF reports.
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	// sarifToolName is the name of the tool producing SARIF reports.
+	sarifToolName = "k6-mcp"
+	// DefaultSARIFArtifact is the script location of SARIF reports when none is given.
+	DefaultSARIFArtifact = "script.js"
+)
+
+// sarifLog is the root object of a SARIF report.
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name    string      `json:"name"`
+	Version string      `json:"version"`
+	Rules   []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+// sarifLevels maps validation issue severities to SARIF result levels.
+var sarifLevels = map[string]string{
+	"critical": "error",
+	"high":     "error",
+	"medium":   "warning",
+	"low":      "note",
+	"info":     "note",
+}
+
+// SARIF renders the issues of a validation as a SARIF 2.1.0 report, for code scanning
+// UIs. artifact is the location of the script the results point to, e.g. its path in the
+// repository; it defaults to DefaultSARIFArtifact.
+func SARIF(result *validator.ValidationResult, artifact string) (string, error) {
+	issues := result.Issues
+	// Validations failing without a diagnosed issue still report a result
+	if len(issues) == 0 && !result.Valid {
+		message := result.Error
+		if message == "" {
+			message = result.Summary.Description
+		}
+		issues = []validator.ValidationIssue{{Type: "validation", Severity: "critical", Message: message}}
+	}
+
+	return sarifReport(issues, artifact)
+}
+
+// ScanSARIF renders the findings of a security scan as a SARIF 2.1.0 report, like SARIF.
+// Their rules are the scan rules, under k6/security/.
+func ScanSARIF(result *security.ScanResult, artifact string) (string, error) {
+	issues := make([]validator.ValidationIssue, 0, len(result.Findings))
+	for _, finding := range result.Findings {
+		issues = append(issues, validator.ValidationIssue{
+			Type:       "security/" + finding.RuleID,
+			Severity:   finding.Severity,
+			Message:    finding.Message,
+			Suggestion: finding.Suggestion,
+			LineNumber: finding.Line,
+		})
+	}
+
+	return sarifReport(issues, artifact)
+}
+
+// ReviewSARIF renders the findings of a security review as a SARIF 2.1.0 report, as
+// ScanSARIF does those of a scan.
+func ReviewSARIF(review *security.Review, artifact string) (string, error) {
+	return ScanSARIF(&security.ScanResult{Findings: review.Findings}, artifact)
+}
+
+// sarifReport renders issues as a SARIF report, with a rule per issue type.
+func sarifReport(issues []validator.ValidationIssue, artifact string) (string, error) {
+	if artifact == "" {
+		artifact = DefaultSARIFArtifact
+	}
+
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:    sarifToolName,
+			Version: buildinfo.Version,
+			Rules:   []sarifRule{},
+		}},
+		Results: []sarifResult{},
+	}
+
+	rules := make(map[string]bool)
+	for _, issue := range issues {
+		ruleID := "k6/" + issue.Type
+		if !rules[ruleID] {
+			rules[ruleID] = true
+			run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{
+				ID:               ruleID,
+				ShortDescription: sarifMessage{Text: fmt.Sprintf("k6 script %s issue", issue.Type)},
+			})
+		}
+
+		level, ok := sarifLevels[issue.Severity]
+		if !ok {
+			level = "warning"
+		}
+		text := issue.Message
+		if issue.Suggestion != "" {
+			text += ". " + issue.Suggestion
+		}
+
+		location := sarifLocation{PhysicalLocation: sarifPhysicalLocation{
+			ArtifactLocation: sarifArtifactLocation{URI: artifact},
+		}}
+		if issue.LineNumber > 0 {
+			location.PhysicalLocation.Region = &sarifRegion{StartLine: issue.LineNumber}
+		}
+
+		run.Results = append(run.Results, sarifResult{
+			RuleID:    ruleID,
+			Level:     level,
+			Message:   sarifMessage{Text: text},
+			Locations: []sarifLocation{location},
+		})
+	}
+	sort.Slice(run.Tool.Driver.Rules, func(i, j int) bool { return run.Tool.Driver.Rules[i].ID < run.Tool.Driver.Rules[j].ID })
+
+	data, err := json.MarshalIndent(sarifLog{Schema: sarifSchema, Version: sarifVersion, Runs: []sarifRun{run}}, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode SARIF report: %w", err)
+	}
+
+	return string(data), nil
+}
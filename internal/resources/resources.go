@@ -0,0 +1,114 @@
+// Package resources serves the prompt, resource and code generation templates embedded in
+// the binary, overridden by the files of an override directory, so that operators can
+// encode their own conventions without rebuilding the server.
+package resources
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	k6mcp "github.com/oleiade/k6-mcp"
+)
+
+// root is the directory of the embedded resources; override directories mirror its layout,
+// e.g. prompts/generate_script.md overrides resources/prompts/generate_script.md.
+const root = "resources"
+
+var (
+	mu          sync.RWMutex
+	overrideDir string
+)
+
+// SetOverrideDir sets the directory whose files override the embedded resources. An empty
+// directory disables overrides.
+func SetOverrideDir(dir string) {
+	mu.Lock()
+	defer mu.Unlock()
+	overrideDir = dir
+}
+
+// FS returns the resources, rooted like the embedded ones: resources/prompts/...,
+// resources/practices/... and resources/templates/....
+func FS() fs.FS {
+	mu.RLock()
+	defer mu.RUnlock()
+	return overlay{dir: overrideDir}
+}
+
+// ReadFile reads the named resource, e.g. resources/prompts/generate_script.md.
+func ReadFile(name string) ([]byte, error) {
+	return fs.ReadFile(FS(), name)
+}
+
+// Overrides lists the embedded resources the override directory overrides.
+func Overrides() []string {
+	fsys := FS().(overlay)
+	if fsys.dir == "" {
+		return nil
+	}
+
+	var overridden []string
+	_ = fs.WalkDir(k6mcp.Resources, root, func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		if file, err := fsys.openOverride(name); err == nil {
+			_ = file.Close()
+			overridden = append(overridden, name)
+		}
+		return nil
+	})
+	sort.Strings(overridden)
+
+	return overridden
+}
+
+// overlay is a file system serving the files of the override directory in place of the
+// embedded resources of the same path.
+type overlay struct {
+	dir string
+}
+
+// Open implements fs.FS.
+func (o overlay) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+
+	file, err := o.openOverride(name)
+	if err == nil {
+		return file, nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	return k6mcp.Resources.Open(name)
+}
+
+// openOverride opens the regular file overriding the named resource.
+func (o overlay) openOverride(name string) (fs.File, error) {
+	if o.dir == "" || !strings.HasPrefix(name, root+"/") {
+		return nil, fs.ErrNotExist
+	}
+
+	relative := strings.TrimPrefix(name, root+"/")
+	file, err := os.DirFS(o.dir).Open(path.Clean(relative))
+	if err != nil {
+		return nil, err
+	}
+
+	// Directories are served from the embedded resources, which list every resource
+	info, err := file.Stat()
+	if err != nil || !info.Mode().IsRegular() {
+		_ = file.Close()
+		return nil, fs.ErrNotExist
+	}
+
+	return file, nil
+}
@@ -0,0 +1,260 @@
+// Package retention keeps the data long-running servers accumulate on disk bounded: a
+// janitor periodically removes the run records, suite runs, script revisions and artifacts
+// older than the retention policy, trims the run history to its size limit, and removes the
+// workspaces processes killed mid-run left behind. Purge removes data on demand.
+package retention
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/oleiade/k6-mcp/internal/artifacts"
+	"github.com/oleiade/k6-mcp/internal/history"
+	"github.com/oleiade/k6-mcp/internal/workspace"
+)
+
+const (
+	// DefaultWorkspaceMaxAge is the age beyond which leftover workspaces are removed.
+	DefaultWorkspaceMaxAge = 24 * time.Hour
+	// MinWorkspaceAge is the age under which workspaces are never removed, as the runs
+	// using them may still be in progress.
+	MinWorkspaceAge = time.Hour
+	// DefaultInterval is the interval between the sweeps of the janitor.
+	DefaultInterval = time.Hour
+)
+
+// Targets of sweeps.
+const (
+	TargetRuns       = "runs"
+	TargetSuiteRuns  = "suite_runs"
+	TargetRevisions  = "script_revisions"
+	TargetArtifacts  = "artifacts"
+	TargetWorkspaces = "workspaces"
+)
+
+// Targets are the data sweeps remove.
+var Targets = []string{TargetRuns, TargetSuiteRuns, TargetRevisions, TargetArtifacts, TargetWorkspaces}
+
+// Policy bounds the data kept on disk. Zero values keep data, within the count limits of
+// the stores.
+type Policy struct {
+	// MaxAge is the age beyond which run records, suite runs, script revisions but the
+	// latest of each script, and artifacts are removed.
+	MaxAge time.Duration
+
+	// HistoryMaxBytes is the size of the run history beyond which the oldest runs are
+	// removed.
+	HistoryMaxBytes int64
+
+	// WorkspaceMaxAge is the age beyond which leftover workspaces are removed.
+	WorkspaceMaxAge time.Duration
+
+	// Interval is the interval between the sweeps of the janitor.
+	Interval time.Duration
+}
+
+// Sweep is the outcome of the removal of a target.
+type Sweep struct {
+	Target  string `json:"target"`
+	Removed int    `json:"removed"`
+	// Bytes is the size of the removed data; for run records, their encoded size.
+	Bytes int64  `json:"bytes"`
+	Error string `json:"error,omitempty"`
+}
+
+// Report is the outcome of a sweep of all the targets.
+type Report struct {
+	DryRun  bool      `json:"dry_run,omitempty"`
+	At      time.Time `json:"at"`
+	Sweeps  []Sweep   `json:"sweeps"`
+	Removed int       `json:"removed"`
+	Bytes   int64     `json:"bytes"`
+}
+
+// PurgeOptions selects the data Purge removes.
+type PurgeOptions struct {
+	// OlderThan is the age beyond which data is removed.
+	OlderThan time.Duration
+	// Targets are the targets to purge, all of them when empty.
+	Targets []string
+	DryRun  bool
+}
+
+// Janitor applies a retention policy to the stores of the server.
+type Janitor struct {
+	policy    Policy
+	history   *history.Store
+	artifacts *artifacts.Store
+	logger    *slog.Logger
+
+	mu   sync.Mutex
+	last *Report
+}
+
+// NewJanitor creates a Janitor applying the policy to the stores, defaulting the workspace
+// max age and the interval of the policy. Workspace max ages under MinWorkspaceAge are
+// raised to it.
+func NewJanitor(policy Policy, history *history.Store, artifacts *artifacts.Store, logger *slog.Logger) *Janitor {
+	if policy.WorkspaceMaxAge <= 0 {
+		policy.WorkspaceMaxAge = DefaultWorkspaceMaxAge
+	}
+	policy.WorkspaceMaxAge = max(policy.WorkspaceMaxAge, MinWorkspaceAge)
+	if policy.Interval <= 0 {
+		policy.Interval = DefaultInterval
+	}
+	return &Janitor{policy: policy, history: history, artifacts: artifacts, logger: logger}
+}
+
+// Policy returns the policy the janitor applies.
+func (j *Janitor) Policy() Policy {
+	return j.policy
+}
+
+// Last returns the report of the latest sweep of the janitor, or nil before the first one.
+func (j *Janitor) Last() *Report {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.last
+}
+
+// Run sweeps the stores at once, then at every interval of the policy, until ctx is done.
+func (j *Janitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.policy.Interval)
+	defer ticker.Stop()
+
+	for {
+		report := j.Sweep(time.Now())
+		if report.Removed > 0 {
+			j.logger.Info("Removed data beyond the retention policy",
+				slog.Int("removed", report.Removed),
+				slog.Int64("bytes", report.Bytes),
+			)
+		}
+		for _, sweep := range report.Sweeps {
+			if sweep.Error != "" {
+				j.logger.Warn("Retention sweep failed", slog.String("target", sweep.Target), slog.String("error", sweep.Error))
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sweep removes the data beyond the policy, as of now.
+func (j *Janitor) Sweep(now time.Time) Report {
+	var before time.Time
+	if j.policy.MaxAge > 0 {
+		before = now.Add(-j.policy.MaxAge)
+	}
+
+	report := j.sweep(now, Targets, before, now.Add(-j.policy.WorkspaceMaxAge), j.policy.HistoryMaxBytes, false)
+
+	j.mu.Lock()
+	j.last = &report
+	j.mu.Unlock()
+
+	return report
+}
+
+// Purge removes the data of the targets older than opts.OlderThan. Workspaces younger
+// than MinWorkspaceAge are kept.
+func (j *Janitor) Purge(opts PurgeOptions) (Report, error) {
+	targets := opts.Targets
+	if len(targets) == 0 {
+		targets = Targets
+	}
+	for _, target := range targets {
+		if !isTarget(target) {
+			return Report{}, fmt.Errorf("unknown target %q; expected one of %s", target, strings.Join(Targets, ", "))
+		}
+	}
+	if opts.OlderThan < 0 {
+		return Report{}, fmt.Errorf("the age must not be negative")
+	}
+
+	now := time.Now()
+	before := now.Add(-opts.OlderThan)
+	return j.sweep(now, targets, before, now.Add(-max(opts.OlderThan, MinWorkspaceAge)), 0, opts.DryRun), nil
+}
+
+// sweep removes the data of the targets created before the cutoff, or for workspaces
+// before workspacesBefore, and trims the run history to historyMaxBytes. A zero cutoff
+// removes no data by age.
+func (j *Janitor) sweep(now time.Time, targets []string, before, workspacesBefore time.Time, historyMaxBytes int64, dryRun bool) Report {
+	report := Report{DryRun: dryRun, At: now.UTC(), Sweeps: []Sweep{}}
+
+	for _, target := range targets {
+		sweep := Sweep{Target: target}
+		var err error
+		switch target {
+		case TargetRuns:
+			if !before.IsZero() || historyMaxBytes > 0 {
+				sweep.Removed, sweep.Bytes, err = j.history.PruneRuns(before, historyMaxBytes, dryRun)
+			}
+		case TargetSuiteRuns:
+			if !before.IsZero() {
+				sweep.Removed, err = j.history.PruneSuiteRuns(before, dryRun)
+			}
+		case TargetRevisions:
+			if !before.IsZero() {
+				sweep.Removed, sweep.Bytes, err = j.history.PruneRevisions(before, dryRun)
+			}
+		case TargetArtifacts:
+			if !before.IsZero() {
+				sweep.Removed, sweep.Bytes, err = j.artifacts.Prune(before, dryRun)
+			}
+		case TargetWorkspaces:
+			sweep.Removed, sweep.Bytes, err = workspace.PruneStale(workspacesBefore, dryRun)
+		}
+		if err != nil {
+			sweep.Error = err.Error()
+		}
+
+		report.Sweeps = append(report.Sweeps, sweep)
+		report.Removed += sweep.Removed
+		report.Bytes += sweep.Bytes
+	}
+
+	return report
+}
+
+func isTarget(target string) bool {
+	for _, t := range Targets {
+		if t == target {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseAge parses an age: a Go duration such as "36h", or a number of days such as "30d".
+// "0" and "none" parse to zero, keeping data.
+func ParseAge(value string) (time.Duration, error) {
+	value = strings.TrimSpace(strings.ToLower(value))
+	if value == "" || value == "0" || value == "none" {
+		return 0, nil
+	}
+
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.ParseFloat(days, 64)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid age %q: expected a number of days such as 30d, or a duration such as 36h", value)
+		}
+		return time.Duration(n * float64(24*time.Hour)), nil
+	}
+
+	age, err := time.ParseDuration(value)
+	if err != nil || age < 0 {
+		return 0, fmt.Errorf("invalid age %q: expected a number of days such as 30d, or a duration such as 36h", value)
+	}
+	return age, nil
+}
@@ -0,0 +1,167 @@
+package runner
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/oleiade/k6-mcp/internal/logging"
+	"github.com/oleiade/k6-mcp/internal/security"
+)
+
+const (
+	// MaxArchiveBytes is the maximum size of the k6 archives runs accept.
+	MaxArchiveBytes = 50 * 1024 * 1024 // 50MB
+	// archiveFileName is the name archives are written under in the workspace of their run.
+	archiveFileName = "archive.tar"
+)
+
+// RunK6Archive executes a k6 archive, as produced by k6 archive, with the specified options.
+// The modules of the archive are checked as scripts are; options set nothing that requires
+// rewriting the script, such as pacing or seeds, nor companion files, which the archive holds.
+func RunK6Archive(ctx context.Context, archive []byte, options *RunOptions) (*RunResult, error) {
+	startTime := time.Now()
+	logger := logging.WithComponent("runner")
+
+	if options == nil {
+		options = &RunOptions{}
+	}
+	if err := validateArchiveInput(archive, options); err != nil {
+		logger.WarnContext(ctx, "Archive input validation failed",
+			slog.String("error", err.Error()),
+		)
+		return &RunResult{
+			Success:  false,
+			Error:    err.Error(),
+			Duration: time.Since(startTime).String(),
+		}, err
+	}
+
+	// Write the archive, and the configuration of the run, to a private temporary workspace
+	dir, err := os.MkdirTemp("", "k6-run-*")
+	if err != nil {
+		runErr := &RunError{Type: "FILE_CREATION", Message: "failed to create temporary workspace", Cause: err}
+		return &RunResult{
+			Success:  false,
+			Error:    fmt.Sprintf("failed to create temporary workspace: %v", err),
+			Duration: time.Since(startTime).String(),
+		}, runErr
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	archivePath := filepath.Join(dir, archiveFileName)
+	files := map[string][]byte{archiveFileName: archive}
+	config, err := runConfig(nil, options)
+	if err != nil {
+		return &RunResult{
+			Success:  false,
+			Error:    err.Error(),
+			Duration: time.Since(startTime).String(),
+		}, err
+	}
+	if config != "" {
+		files[runConfigName] = []byte(config)
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), content, security.SecureFileMode); err != nil {
+			runErr := &RunError{Type: "FILE_CREATION", Message: "failed to write " + name, Cause: err}
+			return &RunResult{
+				Success:  false,
+				Error:    runErr.Error(),
+				Duration: time.Since(startTime).String(),
+			}, runErr
+		}
+	}
+
+	// Execute the archive, tagging its metrics with its hash
+	tagged := withRunTags(string(archive), options)
+	result, err := executeK6Test(ctx, archivePath, tagged, false)
+	elapsed := time.Since(startTime)
+	result.Duration = elapsed.String()
+	result.DurationMS = float64(elapsed.Microseconds()) / 1000
+	result.Tags = tagged.Tags
+	enhanceRunResult(result, options)
+
+	logger.InfoContext(ctx, "k6 archive execution completed",
+		slog.Bool("success", result.Success),
+		slog.Int("exit_code", result.ExitCode),
+		slog.Int("archive_size", len(archive)),
+		slog.Int("total_requests", result.Summary.TotalRequests),
+	)
+
+	return result, err
+}
+
+// validateArchiveInput checks the archive and the options of its run.
+func validateArchiveInput(archive []byte, options *RunOptions) error {
+	if len(archive) == 0 {
+		return &RunError{Type: "INPUT_VALIDATION", Message: "the archive is empty"}
+	}
+	if len(archive) > MaxArchiveBytes {
+		return &RunError{
+			Type:    "INPUT_VALIDATION",
+			Message: fmt.Sprintf("the archive size (%d bytes) exceeds the maximum allowed size (%d bytes)", len(archive), MaxArchiveBytes),
+		}
+	}
+	if err := validateArchiveModules(archive); err != nil {
+		return &RunError{Type: "INPUT_VALIDATION", Message: "archive validation failed", Cause: err}
+	}
+
+	switch {
+	case len(options.Files) > 0:
+		return &RunError{Type: "PARAMETER_VALIDATION", Message: "archives hold their files; no companion files can be added"}
+	case options.Pacing > 0, options.Seed != nil, options.Precheck, len(options.ClientCertificates) > 0:
+		return &RunError{Type: "PARAMETER_VALIDATION", Message: "pacing, seeds, pre-checks and client certificates are not supported for archives"}
+	}
+
+	return validateRunOptions(options)
+}
+
+// validateArchiveModules checks that the archive is a k6 archive, and runs the script
+// security validation on its JavaScript and TypeScript modules.
+func validateArchiveModules(archive []byte) error {
+	reader := tar.NewReader(bytes.NewReader(archive))
+	hasMetadata := false
+	for {
+		header, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("not a tar archive: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		name := path.Clean(header.Name)
+		if name == "metadata.json" {
+			hasMetadata = true
+			continue
+		}
+		switch strings.ToLower(path.Ext(name)) {
+		case ".js", ".mjs", ".cjs", ".ts":
+			content, err := io.ReadAll(io.LimitReader(reader, MaxArchiveBytes))
+			if err != nil {
+				return fmt.Errorf("failed to read %s: %w", name, err)
+			}
+			if err := security.ValidateScriptContent(string(content)); err != nil {
+				return fmt.Errorf("module %s failed security validation: %w", name, err)
+			}
+		}
+	}
+	if !hasMetadata {
+		return errors.New("not a k6 archive: it holds no metadata.json")
+	}
+
+	return nil
+}
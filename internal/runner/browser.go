@@ -0,0 +1,214 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/oleiade/k6-mcp/internal/execx"
+	"github.com/oleiade/k6-mcp/internal/sandbox"
+	"github.com/oleiade/k6-mcp/internal/security"
+)
+
+const (
+	// MaxBrowserVUs is the maximum number of virtual users allowed in browser scenarios,
+	// each of which drives a full browser instance.
+	MaxBrowserVUs = 5
+
+	// browserWebVitalPrefix prefixes the Web Vitals metrics reported by k6/browser.
+	browserWebVitalPrefix = "browser_web_vital_"
+
+	// WebVitalPercentile is the percentile Web Vitals are assessed at, as recommended by web.dev.
+	WebVitalPercentile = 0.75
+
+	// browserInspectTimeout bounds the k6 inspect run computing the VUs of browser scripts.
+	browserInspectTimeout = 30 * time.Second
+)
+
+var (
+	// browserImportPattern matches imports of the k6 browser module.
+	browserImportPattern = regexp.MustCompile(`['"]k6/(?:experimental/)?browser['"]`)
+
+	// browserScenarioPattern matches the browser type option of a scenario.
+	browserScenarioPattern = regexp.MustCompile(`browser\s*:\s*\{[^}]*type\s*:\s*['"]chromium['"]`)
+
+	// browserExecutables are the names of the browser executables looked up in PATH.
+	browserExecutables = []string{
+		"chromium",
+		"chromium-browser",
+		"google-chrome",
+		"google-chrome-stable",
+		"chrome",
+	}
+
+	// browserExecutablePaths are well-known browser installation paths outside of PATH.
+	browserExecutablePaths = []string{
+		"/Applications/Google Chrome.app/Contents/MacOS/Google Chrome",
+		"/Applications/Chromium.app/Contents/MacOS/Chromium",
+		`C:\Program Files\Google\Chrome\Application\chrome.exe`,
+		`C:\Program Files (x86)\Google\Chrome\Application\chrome.exe`,
+		`C:\Program Files (x86)\Microsoft\Edge\Application\msedge.exe`,
+	}
+)
+
+// WebVitalSummary summarizes a Web Vital metric reported by k6/browser.
+type WebVitalSummary struct {
+	Samples int     `json:"samples"`
+	Avg     float64 `json:"avg"`
+	P75     float64 `json:"p75"`
+}
+
+// UsesBrowser reports whether the script imports the k6 browser module.
+func UsesBrowser(script string) bool {
+	return browserImportPattern.MatchString(script)
+}
+
+// validateBrowserScript checks that a browser script declares a browser scenario. Its VUs
+// are checked against the browser VU cap by checkBrowserVUs, once it is in its workspace.
+func validateBrowserScript(script string) error {
+	if !browserScenarioPattern.MatchString(script) {
+		return &RunError{
+			Type: "BROWSER_CONFIGURATION",
+			Message: "browser scripts must define a scenario using the chromium browser, which also controls the VUs and duration of the run. " +
+				"Example: export const options = { scenarios: { ui: { executor: 'shared-iterations', options: { browser: { type: 'chromium' } } } } };",
+		}
+	}
+
+	return nil
+}
+
+// executionRequirements are the execution requirements of a script, as reported by
+// k6 inspect --execution-requirements.
+type executionRequirements struct {
+	MaxVUs int `json:"maxVUs"`
+}
+
+// checkBrowserVUs checks that a browser script stays within the browser VU cap. The VUs
+// are those k6 computes from the options of the script, with its environment, so that the
+// cap holds however they are declared, e.g. from constants, expressions or __ENV.
+func checkBrowserVUs(ctx context.Context, k6Path, scriptPath string, options *RunOptions) error {
+	args := []string{"inspect", "--execution-requirements"}
+	env := security.SecureEnvironment()
+	if options != nil {
+		args = append(args, envArgs(runEnv(options))...)
+		env = append(env, secretEnvironment(options.SecretEnv)...)
+	}
+	args = append(args, scriptPath)
+
+	execution, err := sandbox.Default().Run(ctx, sandbox.Command{Path: k6Path, Args: args, Env: env, Mounts: []string{filepath.Dir(scriptPath)}},
+		execx.WithTimeout(browserInspectTimeout),
+	)
+	if err != nil {
+		message := "failed to compute the VUs of the browser script with k6 inspect"
+		if execution != nil && strings.TrimSpace(execution.Stderr) != "" {
+			message += ": " + lastLines(execution.Stderr, 5)
+		}
+		return &RunError{Type: "BROWSER_CONFIGURATION", Message: message, Cause: err}
+	}
+
+	var requirements executionRequirements
+	if err := json.Unmarshal([]byte(execution.Stdout), &requirements); err != nil {
+		return &RunError{
+			Type:    "BROWSER_CONFIGURATION",
+			Message: "failed to read the execution requirements of the browser script reported by k6 inspect",
+			Cause:   err,
+		}
+	}
+	if requirements.MaxVUs > MaxBrowserVUs {
+		return &RunError{
+			Type:    "PARAMETER_VALIDATION",
+			Message: fmt.Sprintf("browser scripts cannot use more than %d VUs, each VU runs a full browser; the scenarios of the script use up to %d VUs", MaxBrowserVUs, requirements.MaxVUs),
+		}
+	}
+
+	return nil
+}
+
+// browserEnvironment returns the environment variables configuring k6/browser: runs are
+// always headless, and the browser executable is discovered when not configured.
+func browserEnvironment() ([]string, error) {
+	env := []string{"K6_BROWSER_HEADLESS=true"}
+
+	// Browser arguments are forwarded, e.g. no-sandbox when running in containers
+	if args := os.Getenv("K6_BROWSER_ARGS"); args != "" {
+		env = append(env, "K6_BROWSER_ARGS="+args)
+	}
+
+	executable, err := findBrowserExecutable()
+	if err != nil {
+		return nil, err
+	}
+
+	return append(env, "K6_BROWSER_EXECUTABLE_PATH="+executable), nil
+}
+
+// findBrowserExecutable returns the path of the browser k6 should drive: the one set in
+// K6_BROWSER_EXECUTABLE_PATH, or the first Chromium-based browser found.
+func findBrowserExecutable() (string, error) {
+	if executable := os.Getenv("K6_BROWSER_EXECUTABLE_PATH"); executable != "" {
+		return executable, nil
+	}
+
+	for _, name := range browserExecutables {
+		if executable, err := exec.LookPath(name); err == nil {
+			return executable, nil
+		}
+	}
+
+	executablePaths := browserExecutablePaths
+	if localAppData := os.Getenv("LOCALAPPDATA"); localAppData != "" {
+		// Per-user Chrome installations on Windows
+		executablePaths = append(executablePaths, filepath.Join(localAppData, "Google", "Chrome", "Application", "chrome.exe"))
+	}
+
+	for _, executable := range executablePaths {
+		if info, err := os.Stat(executable); err == nil && !info.IsDir() {
+			return executable, nil
+		}
+	}
+
+	return "", &RunError{
+		Type: "BROWSER_NOT_FOUND",
+		Message: fmt.Sprintf("no Chromium-based browser found (looked for %s in PATH); install Chromium or Google Chrome, or set K6_BROWSER_EXECUTABLE_PATH",
+			strings.Join(browserExecutables, ", ")),
+	}
+}
+
+// summarizeWebVitals computes the Web Vitals summaries from their collected samples,
+// keyed by the metric name without its browser_web_vital_ prefix, e.g. "lcp".
+func summarizeWebVitals(samples map[string]*trendDigest) map[string]WebVitalSummary {
+	if len(samples) == 0 {
+		return nil
+	}
+
+	vitals := make(map[string]WebVitalSummary, len(samples))
+	for name, digest := range samples {
+		vitals[strings.TrimPrefix(name, browserWebVitalPrefix)] = WebVitalSummary{
+			Samples: digest.Count(),
+			Avg:     digest.Avg(),
+			P75:     digest.Quantile(WebVitalPercentile),
+		}
+	}
+
+	return vitals
+}
+
+// lastLines returns the last n non-empty lines of the output, joined on one line.
+func lastLines(output string, n int) string {
+	var lines []string
+	for _, line := range strings.Split(output, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "; ")
+}
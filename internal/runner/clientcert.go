@@ -0,0 +1,140 @@
+package runner
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"regexp"
+	"time"
+)
+
+const (
+	// MaxClientCertificates is the maximum number of client certificates of a run.
+	MaxClientCertificates = 10
+	// maxClientCertificateDomains is the maximum number of domains of a client certificate.
+	maxClientCertificateDomains = 20
+)
+
+// clientCertificateDomainPattern matches the domains client certificates are presented to,
+// optionally with a wildcard first label, e.g. "*.example.com".
+var clientCertificateDomainPattern = regexp.MustCompile(`^(\*\.)?[A-Za-z0-9]([A-Za-z0-9-]{0,62}\.)*[A-Za-z0-9-]{1,63}$`)
+
+// ClientCertificate is a client certificate k6 presents to the hosts of its domains, for
+// mutual TLS. The certificate and key are companion files of the run, written to the
+// workspace with owner-only permissions, and passed to k6's tlsAuth option.
+type ClientCertificate struct {
+	Domains []string `json:"domains"`
+	// Cert and Key are the paths of the PEM certificate (chain) and private key files, as
+	// keyed in the run's files.
+	Cert string `json:"cert"`
+	Key  string `json:"key"`
+	// Password decrypts encrypted private keys.
+	Password string `json:"password,omitempty"`
+}
+
+// ValidateClientCertificates validates client certificates against the companion files
+// holding them: certificates must parse, match their key, and be currently valid.
+func ValidateClientCertificates(certificates []ClientCertificate, files map[string]string) error {
+	if len(certificates) > MaxClientCertificates {
+		return &RunError{
+			Type:    "PARAMETER_VALIDATION",
+			Message: fmt.Sprintf("at most %d client certificates can be set", MaxClientCertificates),
+		}
+	}
+
+	for i, certificate := range certificates {
+		if err := validateClientCertificate(certificate, files); err != nil {
+			return &RunError{
+				Type:    "PARAMETER_VALIDATION",
+				Message: fmt.Sprintf("invalid client certificate %d: %s", i+1, err.Error()),
+			}
+		}
+	}
+
+	return nil
+}
+
+func validateClientCertificate(certificate ClientCertificate, files map[string]string) error {
+	if len(certificate.Domains) == 0 || len(certificate.Domains) > maxClientCertificateDomains {
+		return fmt.Errorf("it must have between 1 and %d domains", maxClientCertificateDomains)
+	}
+	for _, domain := range certificate.Domains {
+		if !clientCertificateDomainPattern.MatchString(domain) {
+			return fmt.Errorf("%q is not a domain, e.g. 'api.example.com' or '*.example.com'", domain)
+		}
+	}
+
+	certPEM, ok := files[certificate.Cert]
+	if !ok {
+		return fmt.Errorf("the certificate file %q is not among the run's files", certificate.Cert)
+	}
+	keyPEM, ok := files[certificate.Key]
+	if !ok {
+		return fmt.Errorf("the key file %q is not among the run's files", certificate.Key)
+	}
+
+	var leaf *x509.Certificate
+	if certificate.Password == "" {
+		pair, err := tls.X509KeyPair([]byte(certPEM), []byte(keyPEM))
+		if err != nil {
+			return fmt.Errorf("the certificate and key don't form a valid pair: %w", err)
+		}
+		if leaf, err = x509.ParseCertificate(pair.Certificate[0]); err != nil {
+			return fmt.Errorf("failed to parse the certificate: %w", err)
+		}
+	} else {
+		// Encrypted keys are left to k6, only the certificate is checked
+		block, _ := pem.Decode([]byte(certPEM))
+		if block == nil || block.Type != "CERTIFICATE" {
+			return fmt.Errorf("the certificate file %q holds no PEM certificate", certificate.Cert)
+		}
+		var err error
+		if leaf, err = x509.ParseCertificate(block.Bytes); err != nil {
+			return fmt.Errorf("failed to parse the certificate: %w", err)
+		}
+	}
+
+	if now := time.Now(); now.After(leaf.NotAfter) || now.Before(leaf.NotBefore) {
+		return fmt.Errorf("the certificate is only valid from %s to %s",
+			leaf.NotBefore.UTC().Format(time.DateOnly), leaf.NotAfter.UTC().Format(time.DateOnly))
+	}
+
+	return nil
+}
+
+// tlsAuthConfig returns the tlsAuth option of client certificates, whose certificates and
+// keys are read from the companion files.
+func tlsAuthConfig(certificates []ClientCertificate, files map[string]string) []map[string]interface{} {
+	tlsAuth := make([]map[string]interface{}, len(certificates))
+	for i, certificate := range certificates {
+		entry := map[string]interface{}{
+			"domains": certificate.Domains,
+			"cert":    files[certificate.Cert],
+			"key":     files[certificate.Key],
+		}
+		if certificate.Password != "" {
+			entry["password"] = certificate.Password
+		}
+		tlsAuth[i] = entry
+	}
+
+	return tlsAuth
+}
+
+// redactTLSAuth redacts the certificates, keys and passwords of the tlsAuth option of a
+// decoded configuration file.
+func redactTLSAuth(config map[string]interface{}) {
+	entries, _ := config["tlsAuth"].([]interface{})
+	for _, entry := range entries {
+		fields, ok := entry.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for _, field := range []string{"cert", "key", "password"} {
+			if _, set := fields[field]; set {
+				fields[field] = "[REDACTED]"
+			}
+		}
+	}
+}
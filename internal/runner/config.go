@@ -0,0 +1,108 @@
+package runner
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const (
+	// MaxThresholdMetrics is the maximum number of metrics runs can set thresholds on.
+	MaxThresholdMetrics = 20
+	// MaxThresholdsPerMetric is the maximum number of thresholds per metric.
+	MaxThresholdsPerMetric = 5
+	// runConfigName is the name of the k6 configuration file holding the options of the run
+	// that have no command line flag, written next to the script.
+	runConfigName = ".k6-mcp-config.json"
+)
+
+// thresholdMetricPattern matches metric names, optionally followed by a tag filter,
+// e.g. "http_req_duration{scenario:checkout}".
+var thresholdMetricPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]{0,127}(\{[^{}]{1,256}\})?$`)
+
+// ValidateThresholds validates thresholds, keyed by metric name.
+func ValidateThresholds(thresholds map[string][]string) error {
+	if len(thresholds) > MaxThresholdMetrics {
+		return &RunError{
+			Type:    "PARAMETER_VALIDATION",
+			Message: fmt.Sprintf("thresholds can be set on at most %d metrics", MaxThresholdMetrics),
+		}
+	}
+
+	for metric, expressions := range thresholds {
+		if !thresholdMetricPattern.MatchString(metric) {
+			return &RunError{
+				Type:    "PARAMETER_VALIDATION",
+				Message: fmt.Sprintf("invalid threshold metric %q: expected a metric name, optionally with a tag filter, e.g. http_req_duration{scenario:checkout}", metric),
+			}
+		}
+		if len(expressions) == 0 || len(expressions) > MaxThresholdsPerMetric {
+			return &RunError{
+				Type:    "PARAMETER_VALIDATION",
+				Message: fmt.Sprintf("metric %s must have between 1 and %d thresholds", metric, MaxThresholdsPerMetric),
+			}
+		}
+		for _, expression := range expressions {
+			if strings.TrimSpace(expression) == "" || len(expression) > 128 {
+				return &RunError{
+					Type:    "PARAMETER_VALIDATION",
+					Message: fmt.Sprintf("invalid threshold %q of metric %s: expected an expression such as 'p(95)<500' or 'rate<0.01'", expression, metric),
+				}
+			}
+		}
+	}
+
+	// Catch the expressions k6 would reject, such as p95<500, before starting it
+	for _, issue := range CheckThresholds(thresholds, nil) {
+		if issue.Severity != ThresholdIssueError {
+			continue
+		}
+		message := fmt.Sprintf("invalid threshold metric %s: %s", issue.Metric, issue.Message)
+		if issue.Expression != "" {
+			message = fmt.Sprintf("invalid threshold %q of metric %s: %s", issue.Expression, issue.Metric, issue.Message)
+		}
+		if issue.Suggestion != "" {
+			message += fmt.Sprintf(" (did you mean %q?)", issue.Suggestion)
+		}
+		return &RunError{Type: "PARAMETER_VALIDATION", Message: message}
+	}
+
+	return nil
+}
+
+// runConfig returns the content of the k6 configuration file of the run, holding the
+// scenario of paced runs, and the thresholds and client certificates of the options, or ""
+// when the run needs none.
+// The script's own options take precedence over the configuration file.
+func runConfig(pacing *PacingPlan, options *RunOptions) (string, error) {
+	config := make(map[string]interface{})
+	if pacing != nil {
+		config["scenarios"] = map[string]interface{}{pacingScenarioName: pacing.Scenario}
+	}
+	if options != nil && len(options.Thresholds) > 0 {
+		config["thresholds"] = options.Thresholds
+	}
+	if options != nil && len(options.ClientCertificates) > 0 {
+		config["tlsAuth"] = tlsAuthConfig(options.ClientCertificates, options.Files)
+	}
+	if len(config) == 0 {
+		return "", nil
+	}
+
+	data, err := json.Marshal(config)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode run configuration: %w", err)
+	}
+
+	return string(data), nil
+}
+
+// configArgs returns the k6 flags loading the configuration file of the run, when it has one.
+func configArgs(scriptPath string, options *RunOptions) []string {
+	if options.Pacing == 0 && len(options.Thresholds) == 0 && len(options.ClientCertificates) == 0 {
+		return nil
+	}
+	return []string{"--config", filepath.Join(filepath.Dir(scriptPath), runConfigName)}
+}
@@ -0,0 +1,62 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/oleiade/k6-mcp/internal/k6api"
+)
+
+// controlAddress returns a free loopback address for the REST API of a k6 process, or ""
+// when there is none. Runs get their own address, rather than k6's default 6565, so that
+// concurrent runs can be controlled separately.
+func controlAddress() string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return ""
+	}
+	address := listener.Addr().String()
+	_ = listener.Close()
+	return address
+}
+
+// addressArgs inserts the --address flag serving the REST API of k6 on address into the
+// arguments of a k6 run.
+func addressArgs(args []string, address string) []string {
+	if address == "" || len(args) == 0 {
+		return args
+	}
+	withAddress := make([]string, 0, len(args)+2)
+	withAddress = append(withAddress, args[0], "--address", address)
+	return append(withAddress, args[1:]...)
+}
+
+// ControlRun returns the ID and the client of the REST API of the run in progress with the
+// ID, or of the only run in progress when the ID is empty.
+func ControlRun(id string) (string, *k6api.Client, error) {
+	activeRuns.Lock()
+	defer activeRuns.Unlock()
+
+	run := activeRuns.runs[id]
+	if id == "" {
+		switch len(activeRuns.runs) {
+		case 0:
+			return "", nil, errors.New("no run is in progress")
+		case 1:
+			for _, only := range activeRuns.runs {
+				run = only
+			}
+		default:
+			return "", nil, fmt.Errorf("%d runs are in progress: pass the ID of the run to control", len(activeRuns.runs))
+		}
+	}
+	if run == nil {
+		return "", nil, fmt.Errorf("no run with ID %q is in progress", id)
+	}
+
+	if run.api == nil {
+		return "", nil, errors.New("the run serves no REST API to control it: no loopback port was available when it started")
+	}
+	return run.progress.ID, run.api, nil
+}
@@ -0,0 +1,252 @@
+package runner
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	// MaxResponseSamples is the maximum number of failing request/response pairs captured
+	// by runs debugging responses.
+	MaxResponseSamples = 5
+	// MaxSampleBodyBytes is the maximum size of the request and response bodies of a sample.
+	MaxSampleBodyBytes = 2048
+	// maxPendingDebugRequests bounds the requests waiting for their response.
+	maxPendingDebugRequests = 1000
+	// httpDebugSource is the source field of k6 --http-debug log entries.
+	httpDebugSource = "http-debug"
+)
+
+// sensitiveHeaderPattern matches the names of headers whose values are redacted.
+var sensitiveHeaderPattern = regexp.MustCompile(`(?i)^(authorization|proxy-authorization|cookie|set-cookie)$|token|secret|api-?key|password|session`)
+
+// sensitiveJSONFieldPattern and sensitiveFormFieldPattern match the JSON and form fields
+// whose values are redacted from bodies.
+var (
+	sensitiveJSONFieldPattern = regexp.MustCompile(`(?i)("[a-z_-]*(?:password|passwd|secret|token|api_?key)[a-z_-]*"\s*:\s*)"[^"]*"`)
+	sensitiveFormFieldPattern = regexp.MustCompile(`(?i)(\b[a-z_-]*(?:password|passwd|secret|token|api_?key)[a-z_-]*=)[^&\s]*`)
+)
+
+// ResponseSample is a failing request and its response, captured with k6's HTTP debugging.
+type ResponseSample struct {
+	Method          string            `json:"method"`
+	URL             string            `json:"url"`
+	Status          int               `json:"status"`
+	Scenario        string            `json:"scenario,omitempty"`
+	Group           string            `json:"group,omitempty"`
+	RequestHeaders  map[string]string `json:"request_headers,omitempty"`
+	RequestBody     string            `json:"request_body,omitempty"`
+	ResponseHeaders map[string]string `json:"response_headers,omitempty"`
+	ResponseBody    string            `json:"response_body,omitempty"`
+}
+
+// DebugResponses reports the failing responses of a run debugging responses.
+type DebugResponses struct {
+	// FailedResponses counts the responses with a 4xx or 5xx status.
+	FailedResponses int              `json:"failed_responses"`
+	Samples         []ResponseSample `json:"samples"`
+	Note            string           `json:"note"`
+}
+
+// debugLogEntry is a k6 log entry, with --log-format=json.
+type debugLogEntry struct {
+	Level     string `json:"level"`
+	Msg       string `json:"msg"`
+	Source    string `json:"source"`
+	RequestID string `json:"request_id"`
+	Scenario  string `json:"scenario"`
+	Group     string `json:"group"`
+}
+
+// responseCapture is an io.Writer parsing k6 stderr line by line, with --http-debug=full
+// and --log-format=json: it pairs the HTTP debugging entries of requests and responses,
+// and samples failing ones. Other log entries are written to next as plain text.
+type responseCapture struct {
+	next    io.Writer
+	pending []byte
+
+	requests map[string]string
+	result   DebugResponses
+}
+
+// newResponseCapture returns a response capture writing other output to next.
+func newResponseCapture(next io.Writer) *responseCapture {
+	return &responseCapture{
+		next:     next,
+		requests: make(map[string]string),
+		result:   DebugResponses{Samples: []ResponseSample{}},
+	}
+}
+
+// debugArgs returns the k6 flags enabling HTTP debugging when the options ask for it.
+func debugArgs(options *RunOptions) []string {
+	if options == nil || !options.DebugResponses {
+		return nil
+	}
+	return []string{"--http-debug=full", "--log-format", "json"}
+}
+
+// Write implements io.Writer. It never fails.
+func (c *responseCapture) Write(p []byte) (int, error) {
+	c.pending = append(c.pending, p...)
+
+	for {
+		i := bytes.IndexByte(c.pending, '\n')
+		if i < 0 {
+			break
+		}
+		c.line(c.pending[:i])
+		c.pending = c.pending[i+1:]
+	}
+
+	// Drop runaway lines rather than buffering them whole
+	if len(c.pending) > maxLineBytes {
+		c.pending = c.pending[:0]
+	}
+
+	return len(p), nil
+}
+
+// Flush processes the last line, if it has no trailing newline.
+func (c *responseCapture) Flush() {
+	if len(c.pending) > 0 {
+		c.line(c.pending)
+		c.pending = nil
+	}
+}
+
+// results returns the captured responses.
+func (c *responseCapture) results() *DebugResponses {
+	result := c.result
+	result.Note = fmt.Sprintf("Up to %d failing (4xx/5xx) request/response pairs captured with k6's HTTP debugging; "+
+		"credentials, cookies and sensitive fields are redacted, and bodies are capped at %d bytes. "+
+		"Failed checks on successful responses are not captured.", MaxResponseSamples, MaxSampleBodyBytes)
+	return &result
+}
+
+func (c *responseCapture) line(line []byte) {
+	var entry debugLogEntry
+	if len(line) == 0 || line[0] != '{' || json.Unmarshal(line, &entry) != nil {
+		_, _ = c.next.Write(append(line, '\n'))
+		return
+	}
+
+	if entry.Source != httpDebugSource {
+		_, _ = fmt.Fprintf(c.next, "%s: %s\n", entry.Level, entry.Msg)
+		return
+	}
+
+	switch {
+	case strings.HasPrefix(entry.Msg, "Request:"):
+		if len(c.requests) >= maxPendingDebugRequests {
+			clear(c.requests)
+		}
+		c.requests[entry.RequestID] = strings.TrimPrefix(entry.Msg, "Request:\n")
+	case strings.HasPrefix(entry.Msg, "Response:"):
+		request := c.requests[entry.RequestID]
+		delete(c.requests, entry.RequestID)
+		c.response(entry, request, strings.TrimPrefix(entry.Msg, "Response:\n"))
+	}
+}
+
+// response records a response, and samples it when it failed.
+func (c *responseCapture) response(entry debugLogEntry, rawRequest, rawResponse string) {
+	status := responseStatus(rawResponse)
+	if status < http.StatusBadRequest {
+		return
+	}
+
+	c.result.FailedResponses++
+	if len(c.result.Samples) >= MaxResponseSamples {
+		return
+	}
+
+	sample := ResponseSample{
+		Status:   status,
+		Scenario: entry.Scenario,
+		Group:    entry.Group,
+	}
+
+	requestLine, requestHeaders, requestBody := splitHTTPDump(rawRequest)
+	if fields := strings.Fields(requestLine); len(fields) >= 2 {
+		sample.Method = fields[0]
+		sample.URL = fields[1]
+		if host := requestHeaders["Host"]; host != "" && strings.HasPrefix(sample.URL, "/") {
+			sample.URL = host + sample.URL
+		}
+	}
+	sample.RequestHeaders = redactHeaders(requestHeaders)
+	sample.RequestBody = redactBody(requestBody)
+
+	_, responseHeaders, responseBody := splitHTTPDump(rawResponse)
+	sample.ResponseHeaders = redactHeaders(responseHeaders)
+	sample.ResponseBody = redactBody(responseBody)
+
+	c.result.Samples = append(c.result.Samples, sample)
+}
+
+// responseStatus returns the status code of an HTTP response dump, or 0.
+func responseStatus(dump string) int {
+	statusLine, _, _ := strings.Cut(dump, "\n")
+	fields := strings.Fields(statusLine)
+	if len(fields) < 2 {
+		return 0
+	}
+
+	status, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return 0
+	}
+	return status
+}
+
+// splitHTTPDump splits an HTTP request or response dump into its first line, headers and body.
+func splitHTTPDump(dump string) (firstLine string, headers map[string]string, body string) {
+	dump = strings.ReplaceAll(dump, "\r\n", "\n")
+	head, body, _ := strings.Cut(dump, "\n\n")
+
+	scanner := bufio.NewScanner(strings.NewReader(head))
+	scanner.Buffer(make([]byte, 0, 4096), maxLineBytes)
+	if scanner.Scan() {
+		firstLine = scanner.Text()
+	}
+
+	headers = make(map[string]string)
+	for scanner.Scan() {
+		name, value, found := strings.Cut(scanner.Text(), ":")
+		if !found {
+			continue
+		}
+		headers[http.CanonicalHeaderKey(strings.TrimSpace(name))] = strings.TrimSpace(value)
+	}
+
+	return firstLine, headers, body
+}
+
+// redactHeaders redacts the values of sensitive headers.
+func redactHeaders(headers map[string]string) map[string]string {
+	for name := range headers {
+		if sensitiveHeaderPattern.MatchString(name) {
+			headers[name] = "[REDACTED]"
+		}
+	}
+	return headers
+}
+
+// redactBody redacts sensitive fields from a body, and caps its size.
+func redactBody(body string) string {
+	body = sensitiveJSONFieldPattern.ReplaceAllString(body, `${1}"[REDACTED]"`)
+	body = sensitiveFormFieldPattern.ReplaceAllString(body, `${1}[REDACTED]`)
+
+	if len(body) > MaxSampleBodyBytes {
+		return body[:MaxSampleBodyBytes] + fmt.Sprintf("[... %d bytes truncated ...]", len(body)-MaxSampleBodyBytes)
+	}
+	return body
+}
@@ -0,0 +1,355 @@
+package runner
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// maxDiagnosedHosts is the maximum number of hosts the diagnostics of a run check.
+	maxDiagnosedHosts = 5
+	// diagnosticTimeout bounds the diagnostics of each host.
+	diagnosticTimeout = 5 * time.Second
+	// certificateExpiryWarningDays is the number of days before their expiry from which
+	// certificates are reported as expiring.
+	certificateExpiryWarningDays = 14
+)
+
+// Kinds of network errors diagnostics are triggered by.
+const (
+	NetworkErrorDNS        = "dns"
+	NetworkErrorConnection = "connection"
+	NetworkErrorTLS        = "tls"
+)
+
+// Verdicts of network diagnostics.
+const (
+	// DiagnosticVerdictEnvironment means the diagnostics found an environment issue
+	// explaining the failures: an unresolvable host, a refused connection, an invalid
+	// certificate...
+	DiagnosticVerdictEnvironment = "environment"
+	// DiagnosticVerdictInconclusive means the hosts looked healthy from the server.
+	DiagnosticVerdictInconclusive = "inconclusive"
+)
+
+var (
+	dnsErrorPattern        = regexp.MustCompile(`(?i)no such host|server misbehaving|name resolution|lookup \S+ on \S+`)
+	tlsErrorPattern        = regexp.MustCompile(`(?i)x509: |tls: |remote error: tls|certificate (?:has expired|is not valid|signed by unknown authority)`)
+	connectionErrorPattern = regexp.MustCompile(`(?i)connection refused|connection reset by peer|i/o timeout|no route to host|network is unreachable|dial tcp`)
+
+	// failedURLPattern matches the URLs of failed requests in k6 logs, whose quotes may be escaped,
+	// e.g. error="Get \"https://api.example.com/\": dial tcp: ...".
+	failedURLPattern = regexp.MustCompile(`(?i)\b(?:get|post|put|patch|delete|head|options) \\?"((?:https?|wss?)://[^"\\\s]+)`)
+	// lookupHostPattern matches the hosts of failed DNS lookups in k6 logs.
+	lookupHostPattern = regexp.MustCompile(`\blookup ([A-Za-z0-9.-]+?)(?: on |: )`)
+)
+
+// NetworkDiagnostics is the post-mortem of the network failures of a run: the DNS
+// resolution, reachability and certificates of the hosts the failing requests targeted, as
+// seen from the server, to distinguish environment issues from script issues.
+type NetworkDiagnostics struct {
+	// Errors lists the kinds of network errors found in the output of the run.
+	Errors  []string          `json:"errors"`
+	Hosts   []HostDiagnostics `json:"hosts"`
+	Verdict string            `json:"verdict"`
+	Summary string            `json:"summary"`
+}
+
+// HostDiagnostics are the diagnostics of a host.
+type HostDiagnostics struct {
+	// Target is the address the diagnostics connected to, e.g. "https://api.example.com:443",
+	// or the host alone when only its DNS resolution failed.
+	Target     string                 `json:"target"`
+	DNS        DNSDiagnostics         `json:"dns"`
+	Connection *ConnectionDiagnostics `json:"connection,omitempty"`
+	TLS        *TLSDiagnostics        `json:"tls,omitempty"`
+	// Findings describe the issues found, which the verdict is based on.
+	Findings []string `json:"findings,omitempty"`
+}
+
+// DNSDiagnostics is the DNS resolution of a host.
+type DNSDiagnostics struct {
+	Resolved  bool     `json:"resolved"`
+	Addresses []string `json:"addresses,omitempty"`
+	Error     string   `json:"error,omitempty"`
+}
+
+// ConnectionDiagnostics is the outcome of a TCP connection to a host.
+type ConnectionDiagnostics struct {
+	Reachable bool    `json:"reachable"`
+	LatencyMs float64 `json:"latency_ms,omitempty"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// TLSDiagnostics is the outcome of a TLS handshake with a host, and its certificate.
+type TLSDiagnostics struct {
+	Handshake bool   `json:"handshake"`
+	Error     string `json:"error,omitempty"`
+	Version   string `json:"version,omitempty"`
+
+	Subject       string    `json:"subject,omitempty"`
+	Issuer        string    `json:"issuer,omitempty"`
+	NotBefore     time.Time `json:"not_before,omitempty"`
+	NotAfter      time.Time `json:"not_after,omitempty"`
+	ExpiresInDays int       `json:"expires_in_days,omitempty"`
+	Expired       bool      `json:"expired"`
+	SANs          []string  `json:"sans,omitempty"`
+	// HostnameMatch reports whether the certificate is valid for the host.
+	HostnameMatch bool `json:"hostname_match"`
+	// Trusted reports whether the certificate chain verifies against the system roots.
+	Trusted     bool   `json:"trusted"`
+	VerifyError string `json:"verify_error,omitempty"`
+}
+
+// diagnoseNetworkFailures diagnoses the hosts of a failed run whose output reports DNS,
+// connection or TLS errors. It returns nil for successful runs, and runs without such errors.
+func diagnoseNetworkFailures(ctx context.Context, script string, env map[string]string, result *RunResult) *NetworkDiagnostics {
+	if result == nil || result.Success {
+		return nil
+	}
+
+	output := strings.Join([]string{result.Stderr, result.Stdout, result.Error}, "\n")
+	var kinds []string
+	for _, kind := range []struct {
+		name    string
+		pattern *regexp.Regexp
+	}{
+		{NetworkErrorDNS, dnsErrorPattern},
+		{NetworkErrorConnection, connectionErrorPattern},
+		{NetworkErrorTLS, tlsErrorPattern},
+	} {
+		if kind.pattern.MatchString(output) {
+			kinds = append(kinds, kind.name)
+		}
+	}
+	if len(kinds) == 0 {
+		return nil
+	}
+
+	addresses := failedAddresses(output)
+	if len(addresses) == 0 {
+		// The logs don't name the hosts: the script's targets are diagnosed instead
+		addresses = precheckAddresses(script, env)
+	}
+	if len(addresses) > maxDiagnosedHosts {
+		addresses = addresses[:maxDiagnosedHosts]
+	}
+
+	diagnostics := &NetworkDiagnostics{
+		Errors:  kinds,
+		Hosts:   make([]HostDiagnostics, len(addresses)),
+		Verdict: DiagnosticVerdictInconclusive,
+	}
+	var wg sync.WaitGroup
+	for i, address := range addresses {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			diagnostics.Hosts[i] = diagnoseHost(ctx, address)
+		}()
+	}
+	wg.Wait()
+
+	var findings []string
+	for _, host := range diagnostics.Hosts {
+		findings = append(findings, host.Findings...)
+	}
+	switch {
+	case len(findings) > 0:
+		diagnostics.Verdict = DiagnosticVerdictEnvironment
+		diagnostics.Summary = "The failures come from the environment rather than the script: " + strings.Join(findings, "; ") + "."
+	case len(addresses) == 0:
+		diagnostics.Summary = "The run reported " + strings.Join(kinds, ", ") + " errors, but no target host could be identified to diagnose."
+	default:
+		diagnostics.Summary = "The target hosts resolve, accept connections from the server and present valid certificates: the failures may be intermittent, caused by the load, or come from the script, e.g. wrong URLs, ports or TLS options."
+	}
+
+	return diagnostics
+}
+
+// failedAddresses returns the addresses of the failed requests and lookups of the output.
+// Hosts known only from failed lookups have no scheme, and are only resolved.
+func failedAddresses(output string) []precheckAddress {
+	seen := make(map[string]bool)
+	var addresses []precheckAddress
+	for _, match := range failedURLPattern.FindAllStringSubmatch(output, -1) {
+		u, err := url.Parse(match[1])
+		if err != nil || u.Hostname() == "" {
+			continue
+		}
+		scheme := strings.ToLower(u.Scheme)
+		port := u.Port()
+		if port == "" {
+			port = "80"
+			if scheme == "https" || scheme == "wss" {
+				port = "443"
+			}
+		}
+		address := precheckAddress{scheme: scheme, host: strings.ToLower(u.Hostname()), port: port}
+		if !seen[address.host] {
+			seen[address.host] = true
+			addresses = append(addresses, address)
+		}
+	}
+	for _, match := range lookupHostPattern.FindAllStringSubmatch(output, -1) {
+		host := strings.ToLower(match[1])
+		if !seen[host] {
+			seen[host] = true
+			addresses = append(addresses, precheckAddress{host: host})
+		}
+	}
+
+	sort.SliceStable(addresses, func(i, j int) bool { return addresses[i].host < addresses[j].host })
+	return addresses
+}
+
+// diagnoseHost resolves the host of the address, connects to it, and completes a TLS
+// handshake for encrypted schemes, inspecting the certificate.
+func diagnoseHost(ctx context.Context, address precheckAddress) HostDiagnostics {
+	diagnostics := HostDiagnostics{Target: address.host}
+	if address.scheme != "" {
+		diagnostics.Target = address.String()
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, diagnosticTimeout)
+	defer cancel()
+
+	if ip := net.ParseIP(address.host); ip != nil {
+		diagnostics.DNS = DNSDiagnostics{Resolved: true, Addresses: []string{ip.String()}}
+	} else {
+		addrs, err := net.DefaultResolver.LookupHost(ctx, address.host)
+		if err != nil {
+			diagnostics.DNS.Error = err.Error()
+			diagnostics.Findings = append(diagnostics.Findings, fmt.Sprintf("%s does not resolve (%s)", address.host, err.Error()))
+			return diagnostics
+		}
+		diagnostics.DNS.Resolved = true
+		diagnostics.DNS.Addresses = addrs[:min(len(addrs), 5)]
+	}
+	if address.scheme == "" {
+		return diagnostics
+	}
+
+	hostPort := net.JoinHostPort(address.host, address.port)
+	start := time.Now()
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", hostPort)
+	if err != nil {
+		diagnostics.Connection = &ConnectionDiagnostics{Error: err.Error()}
+		diagnostics.Findings = append(diagnostics.Findings, fmt.Sprintf("%s is unreachable (%s)", hostPort, err.Error()))
+		return diagnostics
+	}
+	defer func() { _ = conn.Close() }()
+	diagnostics.Connection = &ConnectionDiagnostics{
+		Reachable: true,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+
+	if address.scheme == "https" || address.scheme == "wss" {
+		diagnostics.TLS = diagnoseTLS(ctx, conn, address.host)
+		diagnostics.Findings = append(diagnostics.Findings, tlsFindings(address.host, diagnostics.TLS)...)
+	}
+
+	return diagnostics
+}
+
+// diagnoseTLS completes a TLS handshake over conn, and inspects the certificate of the host.
+func diagnoseTLS(ctx context.Context, conn net.Conn, host string) *TLSDiagnostics {
+	diagnostics := &TLSDiagnostics{}
+
+	client := tls.Client(conn, &tls.Config{
+		ServerName: host,
+		// #nosec G402 - the certificate is verified below, to report why it is invalid
+		InsecureSkipVerify: true,
+	})
+	if err := client.HandshakeContext(ctx); err != nil {
+		diagnostics.Error = err.Error()
+		return diagnostics
+	}
+
+	state := client.ConnectionState()
+	diagnostics.Handshake = true
+	diagnostics.Version = tls.VersionName(state.Version)
+	if len(state.PeerCertificates) == 0 {
+		return diagnostics
+	}
+
+	leaf := state.PeerCertificates[0]
+	now := time.Now()
+	diagnostics.Subject = leaf.Subject.String()
+	diagnostics.Issuer = leaf.Issuer.String()
+	diagnostics.NotBefore = leaf.NotBefore.UTC()
+	diagnostics.NotAfter = leaf.NotAfter.UTC()
+	diagnostics.ExpiresInDays = int(leaf.NotAfter.Sub(now).Hours() / 24)
+	diagnostics.Expired = now.After(leaf.NotAfter) || now.Before(leaf.NotBefore)
+	diagnostics.SANs = leaf.DNSNames
+	for _, ip := range leaf.IPAddresses {
+		diagnostics.SANs = append(diagnostics.SANs, ip.String())
+	}
+	diagnostics.HostnameMatch = leaf.VerifyHostname(host) == nil
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range state.PeerCertificates[1:] {
+		intermediates.AddCert(cert)
+	}
+	if _, err := leaf.Verify(x509.VerifyOptions{Intermediates: intermediates, CurrentTime: now}); err != nil {
+		diagnostics.VerifyError = err.Error()
+	} else {
+		diagnostics.Trusted = true
+	}
+
+	return diagnostics
+}
+
+// tlsFindings describes the issues of the TLS diagnostics of a host.
+func tlsFindings(host string, diagnostics *TLSDiagnostics) []string {
+	if !diagnostics.Handshake {
+		return []string{fmt.Sprintf("the TLS handshake with %s fails (%s)", host, diagnostics.Error)}
+	}
+
+	var findings []string
+	switch {
+	case diagnostics.Expired:
+		findings = append(findings, fmt.Sprintf("the certificate of %s is expired or not yet valid (valid from %s to %s)",
+			host, diagnostics.NotBefore.Format(time.DateOnly), diagnostics.NotAfter.Format(time.DateOnly)))
+	case diagnostics.ExpiresInDays < certificateExpiryWarningDays:
+		findings = append(findings, fmt.Sprintf("the certificate of %s expires in %d days", host, diagnostics.ExpiresInDays))
+	}
+	if !diagnostics.HostnameMatch {
+		findings = append(findings, fmt.Sprintf("the certificate of %s is not valid for this name (SANs: %s)", host, strings.Join(diagnostics.SANs, ", ")))
+	}
+	if !diagnostics.Trusted && !diagnostics.Expired && diagnostics.HostnameMatch {
+		findings = append(findings, fmt.Sprintf("the certificate of %s is not trusted (%s)", host, diagnostics.VerifyError))
+	}
+
+	return findings
+}
+
+// networkIssues reports the findings of network diagnostics as run issues.
+func networkIssues(diagnostics *NetworkDiagnostics) []TestIssue {
+	if diagnostics == nil {
+		return nil
+	}
+
+	var issues []TestIssue
+	for _, host := range diagnostics.Hosts {
+		for _, finding := range host.Findings {
+			issues = append(issues, TestIssue{
+				Type:       "network",
+				Severity:   "high",
+				Message:    finding,
+				Suggestion: "Fix the environment rather than the script: check the DNS records, firewall and VPN, or the certificate of the host, or target another environment.",
+			})
+		}
+	}
+	return issues
+}
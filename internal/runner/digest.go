@@ -0,0 +1,120 @@
+package runner
+
+import (
+	"math"
+	"sort"
+)
+
+// DigestRelativeAccuracy is the relative accuracy of the quantiles computed by trend digests:
+// a reported percentile is within 1% of the exact sample value.
+const DigestRelativeAccuracy = 0.01
+
+// digestMinValue is the smallest positive value tracked by trend digests; smaller values,
+// including zero and negative values, are counted as zero.
+const digestMinValue = 1e-9
+
+// trendDigest accumulates the samples of a k6 trend metric in logarithmically sized buckets,
+// so that its memory is bounded by the range of the values rather than their number, while
+// quantiles stay within DigestRelativeAccuracy of the exact ones.
+type trendDigest struct {
+	gamma     float64
+	logGamma  float64
+	buckets   map[int]uint64
+	zeroCount uint64
+	count     uint64
+	sum       float64
+	min       float64
+	max       float64
+}
+
+// newTrendDigest returns an empty trend digest.
+func newTrendDigest() *trendDigest {
+	gamma := (1 + DigestRelativeAccuracy) / (1 - DigestRelativeAccuracy)
+	return &trendDigest{
+		gamma:    gamma,
+		logGamma: math.Log(gamma),
+		buckets:  make(map[int]uint64),
+	}
+}
+
+// Add records a sample.
+func (d *trendDigest) Add(value float64) {
+	if math.IsNaN(value) {
+		return
+	}
+
+	if d.count == 0 || value < d.min {
+		d.min = value
+	}
+	if d.count == 0 || value > d.max {
+		d.max = value
+	}
+	d.count++
+	d.sum += value
+
+	if value < digestMinValue {
+		d.zeroCount++
+		return
+	}
+	d.buckets[int(math.Ceil(math.Log(value)/d.logGamma))]++
+}
+
+// Count returns the number of samples.
+func (d *trendDigest) Count() int {
+	return int(d.count)
+}
+
+// Avg returns the exact average of the samples.
+func (d *trendDigest) Avg() float64 {
+	if d.count == 0 {
+		return 0
+	}
+	return d.sum / float64(d.count)
+}
+
+// Min returns the smallest sample.
+func (d *trendDigest) Min() float64 {
+	return d.min
+}
+
+// Max returns the largest sample.
+func (d *trendDigest) Max() float64 {
+	return d.max
+}
+
+// Quantile returns the value below which the given fraction (0 to 1) of the samples fall,
+// using a nearest-rank definition.
+func (d *trendDigest) Quantile(q float64) float64 {
+	if d.count == 0 {
+		return 0
+	}
+	if q <= 0 {
+		return d.min
+	}
+	if q >= 1 {
+		return d.max
+	}
+
+	rank := uint64(q * float64(d.count-1))
+	if rank < d.zeroCount {
+		return math.Min(0, d.max)
+	}
+
+	indexes := make([]int, 0, len(d.buckets))
+	for index := range d.buckets {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+
+	seen := d.zeroCount
+	for _, index := range indexes {
+		seen += d.buckets[index]
+		if seen > rank {
+			// The bucket midpoint, in relative terms, is within the accuracy of every value in it
+			value := 2 * math.Pow(d.gamma, float64(index)) / (d.gamma + 1)
+			return math.Max(d.min, math.Min(value, d.max))
+		}
+	}
+
+	return d.max
+}
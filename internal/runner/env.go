@@ -0,0 +1,112 @@
+package runner
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	// MaxEnvVars is the maximum number of environment variables passed to a run.
+	MaxEnvVars = 50
+	// MaxEnvValueBytes is the maximum size of an environment variable value.
+	MaxEnvValueBytes = 4096
+)
+
+// envNamePattern matches valid environment variable names.
+var envNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ValidateEnv validates environment variables exposed to the script through __ENV.
+func ValidateEnv(env map[string]string) error {
+	if len(env) > MaxEnvVars {
+		return &RunError{
+			Type:    "PARAMETER_VALIDATION",
+			Message: fmt.Sprintf("cannot pass more than %d environment variables", MaxEnvVars),
+		}
+	}
+
+	for name, value := range env {
+		if !envNamePattern.MatchString(name) {
+			return &RunError{
+				Type:    "PARAMETER_VALIDATION",
+				Message: fmt.Sprintf("invalid environment variable name %q: use letters, digits and underscores", name),
+			}
+		}
+		if len(value) > MaxEnvValueBytes {
+			return &RunError{
+				Type:    "PARAMETER_VALIDATION",
+				Message: fmt.Sprintf("environment variable %s exceeds %d bytes", name, MaxEnvValueBytes),
+			}
+		}
+		if strings.ContainsRune(value, 0) {
+			return &RunError{
+				Type:    "PARAMETER_VALIDATION",
+				Message: fmt.Sprintf("environment variable %s contains a NUL character", name),
+			}
+		}
+	}
+
+	return nil
+}
+
+// envArgs returns the --env flags exposing env to the script, sorted by name. k6 scripts
+// read them from __ENV, and they are not inherited by the k6 process itself.
+func envArgs(env map[string]string) []string {
+	names := make([]string, 0, len(env))
+	for name := range env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	args := make([]string, 0, 2*len(names))
+	for _, name := range names {
+		args = append(args, "--env", name+"="+env[name])
+	}
+
+	return args
+}
+
+// secretEnvironment returns the process environment variables of secret env, sorted by
+// name. k6 runs expose the variables of their environment to scripts through __ENV.
+func secretEnvironment(env map[string]string) []string {
+	names := make([]string, 0, len(env))
+	for name := range env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	variables := make([]string, len(names))
+	for i, name := range names {
+		variables[i] = name + "=" + env[name]
+	}
+
+	return variables
+}
+
+// redactSecrets returns output with the values of secret env redacted.
+func redactSecrets(output string, env map[string]string) string {
+	for _, value := range env {
+		if value != "" {
+			output = strings.ReplaceAll(output, value, "[REDACTED]")
+		}
+	}
+	return output
+}
+
+// redactEnvArgs returns a copy of args with the values of --env flags redacted, for logging.
+func redactEnvArgs(args []string) []string {
+	redacted := make([]string, len(args))
+	copy(redacted, args)
+
+	for i := 1; i < len(redacted); i++ {
+		if redacted[i-1] != "--env" {
+			continue
+		}
+		if name, _, found := strings.Cut(redacted[i], "="); found {
+			redacted[i] = name + "=[REDACTED]"
+		}
+	}
+
+	return redacted
+}
@@ -0,0 +1,153 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Failure classes of failed runs, from the most to the least specific cause. Clients
+// branch their next action on them: fix the script, lower the load, raise the client's
+// limits, or relax or investigate the thresholds.
+const (
+	// FailureScriptError is a script that threw an exception or could not be loaded.
+	FailureScriptError = "script_error"
+	// FailureThresholdBreach is a run whose thresholds were crossed.
+	FailureThresholdBreach = "threshold_breach"
+	// FailureTargetSaturation is a target answering with 5xx statuses, timing out or
+	// resetting connections under the load.
+	FailureTargetSaturation = "target_saturation"
+	// FailureResourceExhaustion is a load generator running out of file descriptors,
+	// memory, ports or VUs.
+	FailureResourceExhaustion = "resource_exhaustion"
+	// FailureTimeout is a run, or its setup or teardown, exceeding its time limit.
+	FailureTimeout = "timeout"
+	// FailureCanceled is a run cancelled by the client.
+	FailureCanceled = "canceled"
+	// FailureUnknown is a failure none of the heuristics recognized.
+	FailureUnknown = "unknown"
+)
+
+// Exit codes of k6 the failure classes are derived from.
+const (
+	exitThresholdsFailed = 99
+	exitSetupTimeout     = 100
+	exitTeardownTimeout  = 101
+	exitGenericTimeout   = 102
+	exitInvalidConfig    = 104
+	exitScriptException  = 107
+)
+
+// saturationErrorRate is the share of 5xx responses from which a target is considered
+// saturated.
+const saturationErrorRate = 0.05
+
+var (
+	// scriptErrorPattern matches the JavaScript exceptions k6 logs.
+	scriptErrorPattern = regexp.MustCompile(`\b(SyntaxError|ReferenceError|TypeError|RangeError|GoError)\b`)
+
+	// exhaustionPatterns match the errors of load generators out of resources, with the
+	// reason reported for them.
+	exhaustionPatterns = []struct {
+		pattern *regexp.Regexp
+		reason  string
+	}{
+		{regexp.MustCompile(`(?i)too many open files`), "the load generator ran out of file descriptors (too many open files)"},
+		{regexp.MustCompile(`(?i)cannot allocate memory|out of memory`), "the load generator ran out of memory"},
+		{regexp.MustCompile(`(?i)cannot assign requested address`), "the load generator ran out of local ports (cannot assign requested address)"},
+		{regexp.MustCompile(`(?i)insufficient VUs`), "the scenario ran out of VUs to start its iterations (insufficient VUs)"},
+	}
+
+	// saturationPattern matches the request errors of targets that cannot keep up.
+	saturationPattern = regexp.MustCompile(`(?i)request timeout|i/o timeout|connection reset by peer`)
+)
+
+// failureNextSteps are the next steps of failed runs, by failure class.
+var failureNextSteps = map[string]string{
+	FailureScriptError:        "Fix the script error, then check the script with the 'validate' tool before running it again",
+	FailureThresholdBreach:    "Review the crossed thresholds: investigate the regression, or relax the thresholds if they are too strict",
+	FailureTargetSaturation:   "Lower the load (vus, stages or pacing) to find the target's capacity, or scale the target",
+	FailureResourceExhaustion: "Raise the load generator's limits (ulimit -n, memory, preAllocatedVUs), or lower the load",
+	FailureTimeout:            "Shorten the test or its setup and teardown, or raise their timeouts",
+}
+
+// classifyFailure returns the failure class of a failed run, and the reason it was
+// classified so, from its error, the exit code of k6, its summary, including the metrics
+// of failed runs, and its stderr.
+func classifyFailure(result *RunResult, summary TestSummary, runErr error) (string, string) {
+	var runError *RunError
+	if errors.As(runErr, &runError) {
+		switch runError.Type {
+		case "CANCELED":
+			return FailureCanceled, "the run was cancelled before k6 finished"
+		case "TIMEOUT":
+			return FailureTimeout, fmt.Sprintf("the run exceeded the %v time limit", DefaultTimeout)
+		}
+	}
+
+	switch result.ExitCode {
+	case exitSetupTimeout:
+		return FailureTimeout, "the setup function exceeded its time limit (setupTimeout)"
+	case exitTeardownTimeout:
+		return FailureTimeout, "the teardown function exceeded its time limit (teardownTimeout)"
+	case exitGenericTimeout:
+		return FailureTimeout, "k6 exceeded a time limit"
+	case exitScriptException:
+		return FailureScriptError, "the script threw an exception" + firstMatch(scriptErrorPattern, result.Stderr)
+	case exitInvalidConfig:
+		return FailureScriptError, "the options of the script are invalid"
+	}
+
+	for _, exhaustion := range exhaustionPatterns {
+		if exhaustion.pattern.MatchString(result.Stderr) {
+			return FailureResourceExhaustion, exhaustion.reason
+		}
+	}
+
+	if summary.TotalRequests > 0 {
+		serverErrorRate := float64(summary.ServerErrors) / float64(summary.TotalRequests)
+		if serverErrorRate >= saturationErrorRate {
+			return FailureTargetSaturation, fmt.Sprintf("%.1f%% of the requests got a 5xx response", serverErrorRate*100)
+		}
+		if summary.FailedRequests > 0 && saturationPattern.MatchString(result.Stderr) {
+			return FailureTargetSaturation, "requests timed out or their connections were reset by the target"
+		}
+	}
+
+	if result.ExitCode == exitThresholdsFailed {
+		return FailureThresholdBreach, "thresholds were crossed" + failedThresholds(result.Thresholds)
+	}
+
+	if scriptErrorPattern.MatchString(result.Stderr) {
+		return FailureScriptError, "the script threw an exception" + firstMatch(scriptErrorPattern, result.Stderr)
+	}
+
+	return FailureUnknown, fmt.Sprintf("k6 exited with code %d", result.ExitCode)
+}
+
+// firstMatch returns ": " and the line of output the pattern first matches, or "".
+func firstMatch(pattern *regexp.Regexp, output string) string {
+	for _, line := range strings.Split(output, "\n") {
+		if pattern.MatchString(line) {
+			return ": " + strings.TrimSpace(line)
+		}
+	}
+	return ""
+}
+
+// failedThresholds returns ": " and the metrics of the crossed thresholds, or "".
+func failedThresholds(thresholds []ThresholdOutcome) string {
+	var metrics []string
+	for _, threshold := range thresholds {
+		if !threshold.Passed {
+			metrics = append(metrics, threshold.Metric)
+		}
+	}
+	if len(metrics) == 0 {
+		return ""
+	}
+	sort.Strings(metrics)
+	return ": " + strings.Join(removeDuplicateStrings(metrics), ", ")
+}
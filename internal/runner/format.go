@@ -0,0 +1,110 @@
+package runner
+
+import (
+	"fmt"
+	"math"
+)
+
+// FormattedSummary holds the sizes, durations and rates of a test summary as
+// human-readable strings, so that clients render them consistently. Numbers use a dot as
+// decimal separator and SI units, whatever the locale of the server.
+type FormattedSummary struct {
+	AvgResponseTime      string `json:"avg_response_time,omitempty"`
+	MedResponseTime      string `json:"med_response_time,omitempty"`
+	P90ResponseTime      string `json:"p90_response_time,omitempty"`
+	P95ResponseTime      string `json:"p95_response_time,omitempty"`
+	P99ResponseTime      string `json:"p99_response_time,omitempty"`
+	MaxResponseTime      string `json:"max_response_time,omitempty"`
+	AvgIterationDuration string `json:"avg_iteration_duration,omitempty"`
+	RequestRate          string `json:"request_rate,omitempty"`
+	DataReceived         string `json:"data_received"`
+	DataSent             string `json:"data_sent"`
+	ErrorRate            string `json:"error_rate,omitempty"`
+}
+
+// FormatBytes formats a number of bytes with a decimal unit, as k6 does, e.g. "1.2 MB".
+func FormatBytes(n int64) string {
+	const unit = 1000
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit && exp < 4; m /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "kMGTP"[exp])
+}
+
+// FormatDuration formats a duration in milliseconds with the largest fitting unit, as k6
+// does, e.g. "850µs", "123.45ms", "2.5s" or "1m30s".
+func FormatDuration(ms float64) string {
+	switch {
+	case ms <= 0:
+		return "0s"
+	// The units change where the rounded values would reach the next unit
+	case ms < 0.9995:
+		return fmt.Sprintf("%.0fµs", ms*1000)
+	case ms < 999.995:
+		return trimZeros(fmt.Sprintf("%.2f", ms)) + "ms"
+	case ms < 59995:
+		return trimZeros(fmt.Sprintf("%.2f", ms/1000)) + "s"
+	}
+
+	total := math.Round(ms / 1000)
+	hours, minutes, seconds := int(total)/3600, int(total)%3600/60, int(total)%60
+	if hours > 0 {
+		return fmt.Sprintf("%dh%dm%ds", hours, minutes, seconds)
+	}
+	return fmt.Sprintf("%dm%ds", minutes, seconds)
+}
+
+// FormatRate formats a rate per second, e.g. "12.5/s".
+func FormatRate(perSecond float64) string {
+	return trimZeros(fmt.Sprintf("%.2f", perSecond)) + "/s"
+}
+
+// trimZeros removes the trailing zeros of the decimals of a formatted number.
+func trimZeros(number string) string {
+	for i := len(number) - 1; i > 0; i-- {
+		switch number[i] {
+		case '0':
+			continue
+		case '.':
+			return number[:i]
+		default:
+			return number[:i+1]
+		}
+	}
+	return number
+}
+
+// formatSummary sets the human-readable strings of the summary.
+func formatSummary(summary *TestSummary) {
+	summary.DataReceived = FormatBytes(summary.DataReceivedBytes)
+	summary.DataSent = FormatBytes(summary.DataSentBytes)
+
+	formatted := &FormattedSummary{
+		DataReceived: summary.DataReceived,
+		DataSent:     summary.DataSent,
+	}
+	if summary.TotalRequests > 0 {
+		formatted.AvgResponseTime = FormatDuration(summary.AvgResponseTime)
+		formatted.MedResponseTime = FormatDuration(summary.MedResponseTime)
+		formatted.P90ResponseTime = FormatDuration(summary.P90ResponseTime)
+		formatted.P95ResponseTime = FormatDuration(summary.P95ResponseTime)
+		formatted.P99ResponseTime = FormatDuration(summary.P99ResponseTime)
+		formatted.MaxResponseTime = FormatDuration(summary.MaxResponseTime)
+		errorRate := float64(summary.FailedRequests) / float64(summary.TotalRequests) * 100
+		formatted.ErrorRate = trimZeros(fmt.Sprintf("%.2f", errorRate)) + "%"
+	}
+	if summary.RequestRate > 0 {
+		formatted.RequestRate = FormatRate(summary.RequestRate)
+	}
+	if summary.AvgIterationDuration > 0 {
+		formatted.AvgIterationDuration = FormatDuration(summary.AvgIterationDuration)
+	}
+	summary.Formatted = formatted
+}
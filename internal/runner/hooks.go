@@ -0,0 +1,290 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/oleiade/k6-mcp/internal/logging"
+)
+
+// Built-in result hooks.
+const (
+	// HookSLO checks runs against service level objectives, given in the format of the
+	// warning thresholds, e.g. "p95=300ms,error_rate=0.1%".
+	HookSLO = "slo"
+	// HookTransferCost prices the data runs transferred, at a price per GB.
+	HookTransferCost = "transfer_cost"
+)
+
+// DefaultTransferCostPerGB is the data transfer price of the transfer_cost hook, in USD per
+// GB, when it is not configured: a typical cloud egress price, as estimate_run assumes.
+const DefaultTransferCostPerGB = 0.09
+
+// ResultHook post-processes the results of runs before they are returned, to enrich them
+// with the analyses of a deployment, such as company SLO policies or cost calculators.
+type ResultHook interface {
+	// Name returns the name of the hook, under which its report is added to results.
+	Name() string
+	// Process analyzes the result of a run and returns its report, or nil for none. Hooks
+	// may also append issues and recommendations to the result.
+	Process(ctx context.Context, result *RunResult, options *RunOptions) (any, error)
+}
+
+// ResultHookFactory creates a result hook from its settings, which are empty when the
+// deployment configures none.
+type ResultHookFactory func(settings string) (ResultHook, error)
+
+// hookFailure is the report of hooks that failed to process a result.
+type hookFailure struct {
+	Error string `json:"error"`
+}
+
+var (
+	hooksMu       sync.RWMutex
+	hookFactories = map[string]ResultHookFactory{
+		HookSLO:          newSLOHook,
+		HookTransferCost: newTransferCostHook,
+	}
+	resultHooks []ResultHook
+)
+
+// RegisterResultHook registers the factory of a hook, replacing any hook of the same name,
+// so that deployments can add their own hooks to the built-in ones.
+func RegisterResultHook(name string, factory ResultHookFactory) {
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+	hookFactories[name] = factory
+}
+
+// ResultHookNames returns the names of the registered hooks, sorted.
+func ResultHookNames() []string {
+	hooksMu.RLock()
+	defer hooksMu.RUnlock()
+
+	names := make([]string, 0, len(hookFactories))
+	for name := range hookFactories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// NewResultHook creates the registered hook of the name from its settings.
+func NewResultHook(name, settings string) (ResultHook, error) {
+	hooksMu.RLock()
+	factory, ok := hookFactories[name]
+	hooksMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown result hook %q: expected one of %s", name, strings.Join(ResultHookNames(), ", "))
+	}
+
+	hook, err := factory(settings)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s result hook: %w", name, err)
+	}
+
+	return hook, nil
+}
+
+// SetResultHooks sets the hooks the results of runs are post-processed with, in order.
+func SetResultHooks(hooks []ResultHook) {
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+	resultHooks = hooks
+}
+
+// CurrentResultHooks returns the hooks set with SetResultHooks.
+func CurrentResultHooks() []ResultHook {
+	hooksMu.RLock()
+	defer hooksMu.RUnlock()
+	return resultHooks
+}
+
+// applyResultHooks post-processes the result with the configured hooks, adding their
+// reports to its Hooks. Hooks that fail or panic are reported as such rather than failing
+// the run.
+func applyResultHooks(ctx context.Context, result *RunResult, options *RunOptions) {
+	hooks := CurrentResultHooks()
+	if len(hooks) == 0 {
+		return
+	}
+
+	logger := logging.WithComponent("runner")
+	for _, hook := range hooks {
+		report, err := processResult(ctx, hook, result, options)
+		if err != nil {
+			logger.WarnContext(ctx, "Result hook failed",
+				slog.String("hook", hook.Name()),
+				slog.String("error", err.Error()),
+			)
+			report = hookFailure{Error: err.Error()}
+		}
+		if report == nil {
+			continue
+		}
+
+		if result.Hooks == nil {
+			result.Hooks = make(map[string]any, len(hooks))
+		}
+		result.Hooks[hook.Name()] = report
+	}
+}
+
+// processResult processes the result with the hook, recovering its panics.
+func processResult(ctx context.Context, hook ResultHook, result *RunResult, options *RunOptions) (report any, err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			report, err = nil, fmt.Errorf("panic: %v", rec)
+		}
+	}()
+
+	return hook.Process(ctx, result, options)
+}
+
+// SLOReport is the report of the slo hook.
+type SLOReport struct {
+	// Met reports whether the run met all the objectives it has values for.
+	Met        bool           `json:"met"`
+	Objectives []SLOObjective `json:"objectives"`
+}
+
+// SLOObjective is the outcome of a service level objective, whose limit the value of the
+// metric must not exceed. Rates are percentages, and response times milliseconds.
+type SLOObjective struct {
+	Metric string  `json:"metric"`
+	Limit  float64 `json:"limit"`
+	// Value is the value of the run, unless it has none, e.g. without requests.
+	Value *float64 `json:"value,omitempty"`
+	Met   bool     `json:"met"`
+}
+
+// sloHook checks runs against service level objectives.
+type sloHook struct {
+	objectives WarningThresholds
+}
+
+func newSLOHook(settings string) (ResultHook, error) {
+	if strings.TrimSpace(settings) == "" {
+		return nil, fmt.Errorf("objectives are required, e.g. \"p95=300ms,error_rate=0.1%%\"")
+	}
+
+	objectives, err := parseWarningLimits(WarningThresholds{}, settings)
+	if err != nil {
+		return nil, err
+	}
+
+	return &sloHook{objectives: objectives}, nil
+}
+
+func (h *sloHook) Name() string {
+	return HookSLO
+}
+
+func (h *sloHook) Process(_ context.Context, result *RunResult, _ *RunOptions) (any, error) {
+	summary := result.Summary
+	var errorRate, checkFailureRate *float64
+	if summary.TotalRequests > 0 {
+		rate := float64(summary.FailedRequests) / float64(summary.TotalRequests) * 100
+		errorRate = &rate
+	}
+	passes, fails := 0, 0
+	for _, check := range result.Checks {
+		passes += check.Passes
+		fails += check.Fails
+	}
+	if total := passes + fails; total > 0 {
+		rate := float64(fails) / float64(total) * 100
+		checkFailureRate = &rate
+	}
+	latency := func(value float64) *float64 {
+		if summary.TotalRequests == 0 {
+			return nil
+		}
+		return &value
+	}
+
+	metrics := []struct {
+		name  string
+		limit float64
+		value *float64
+	}{
+		{WarningErrorRate, h.objectives.ErrorRate, errorRate},
+		{WarningP95ResponseTime, h.objectives.P95ResponseTime, latency(summary.P95ResponseTime)},
+		{WarningP99ResponseTime, h.objectives.P99ResponseTime, latency(summary.P99ResponseTime)},
+		{WarningAvgResponseTime, h.objectives.AvgResponseTime, latency(summary.AvgResponseTime)},
+		{WarningCheckFailures, h.objectives.CheckFailureRate, checkFailureRate},
+	}
+
+	report := &SLOReport{Met: true, Objectives: []SLOObjective{}}
+	for _, metric := range metrics {
+		if metric.limit <= 0 {
+			continue
+		}
+
+		objective := SLOObjective{Metric: metric.name, Limit: metric.limit, Value: metric.value, Met: true}
+		if metric.value != nil && *metric.value > metric.limit {
+			objective.Met, report.Met = false, false
+			result.Issues = append(result.Issues, TestIssue{
+				Type:       "slo",
+				Severity:   "high",
+				Message:    fmt.Sprintf("The %s of the run (%.2f) breaches its service level objective (%g)", metric.name, *metric.value, metric.limit),
+				Suggestion: "Investigate the regression before releasing: compare the run to its baseline, and check the target's resources and recent changes",
+				Value:      *metric.value,
+				Threshold:  metric.limit,
+			})
+		}
+		report.Objectives = append(report.Objectives, objective)
+	}
+
+	return report, nil
+}
+
+// TransferCostReport is the report of the transfer_cost hook.
+type TransferCostReport struct {
+	DataBytes int64   `json:"data_bytes"`
+	CostPerGB float64 `json:"cost_per_gb"`
+	Cost      float64 `json:"cost"`
+}
+
+// transferCostHook prices the data received and sent by runs.
+type transferCostHook struct {
+	costPerGB float64
+}
+
+func newTransferCostHook(settings string) (ResultHook, error) {
+	costPerGB := DefaultTransferCostPerGB
+	if settings = strings.TrimSpace(settings); settings != "" {
+		value, err := strconv.ParseFloat(settings, 64)
+		if err != nil || value < 0 {
+			return nil, fmt.Errorf("%q is not a price per GB, e.g. 0.09", settings)
+		}
+		costPerGB = value
+	}
+
+	return &transferCostHook{costPerGB: costPerGB}, nil
+}
+
+func (h *transferCostHook) Name() string {
+	return HookTransferCost
+}
+
+func (h *transferCostHook) Process(_ context.Context, result *RunResult, _ *RunOptions) (any, error) {
+	data := result.Summary.DataReceivedBytes + result.Summary.DataSentBytes
+	if data == 0 {
+		return nil, nil
+	}
+
+	return &TransferCostReport{
+		DataBytes: data,
+		CostPerGB: h.costPerGB,
+		// Decimal gigabytes, as cloud providers bill them
+		Cost: math.Round(float64(data)/1e9*h.costPerGB*10000) / 10000,
+	}, nil
+}
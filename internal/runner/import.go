@@ -0,0 +1,203 @@
+package runner
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Formats of the summaries ParseSummary reads.
+const (
+	// SummaryFormatExport is the format of the files k6 writes with --summary-export.
+	SummaryFormatExport = "summary-export"
+	// SummaryFormatHandleSummary is the format of the data k6 passes to handleSummary(),
+	// as written with JSON.stringify(data).
+	SummaryFormatHandleSummary = "handle-summary"
+)
+
+// ImportedSummary is a k6 end-of-test summary produced outside the server.
+type ImportedSummary struct {
+	Format     string             `json:"format"`
+	Summary    TestSummary        `json:"summary"`
+	Thresholds []ThresholdOutcome `json:"thresholds,omitempty"`
+	Checks     []CheckOutcome     `json:"checks,omitempty"`
+	// Duration is the duration of the test, which only handleSummary data holds.
+	Duration time.Duration `json:"-"`
+}
+
+// ThresholdsPassed reports whether none of the thresholds of the summary was crossed.
+func (s *ImportedSummary) ThresholdsPassed() bool {
+	for _, threshold := range s.Thresholds {
+		if !threshold.Passed {
+			return false
+		}
+	}
+	return true
+}
+
+// handleSummaryData is the part of handleSummary data the summary is read from.
+type handleSummaryData struct {
+	State struct {
+		TestRunDurationMs float64 `json:"testRunDurationMs"`
+	} `json:"state"`
+	RootGroup handleSummaryGroup `json:"root_group"`
+	Metrics   map[string]struct {
+		Values     map[string]float64 `json:"values"`
+		Thresholds map[string]struct {
+			OK bool `json:"ok"`
+		} `json:"thresholds"`
+	} `json:"metrics"`
+}
+
+// handleSummaryGroup is a group of handleSummary data, whose groups and checks are arrays.
+type handleSummaryGroup struct {
+	Path   string               `json:"path"`
+	Groups []handleSummaryGroup `json:"groups"`
+	Checks []struct {
+		Name   string `json:"name"`
+		Passes int    `json:"passes"`
+		Fails  int    `json:"fails"`
+	} `json:"checks"`
+}
+
+// summaryExportData is the part of a --summary-export file the summary is read from, whose
+// metrics hold their values next to their thresholds.
+type summaryExportData struct {
+	RootGroup summaryGroup                          `json:"root_group"`
+	Metrics   map[string]map[string]json.RawMessage `json:"metrics"`
+}
+
+// ParseSummary parses a k6 end-of-test summary, either a --summary-export file or
+// handleSummary data, into the summary of runs and its threshold and check outcomes.
+func ParseSummary(data []byte) (*ImportedSummary, error) {
+	var probe struct {
+		Metrics map[string]map[string]json.RawMessage `json:"metrics"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return nil, fmt.Errorf("failed to parse k6 summary: %w", err)
+	}
+	if len(probe.Metrics) == 0 {
+		return nil, errors.New("the summary holds no metrics: expected a k6 --summary-export file, or the JSON of the data passed to handleSummary()")
+	}
+
+	// handleSummary data nests the values of each metric, summary exports don't
+	for _, metric := range probe.Metrics {
+		if _, nested := metric["values"]; nested {
+			return parseHandleSummary(data)
+		}
+	}
+
+	return parseSummaryExport(data)
+}
+
+func parseHandleSummary(data []byte) (*ImportedSummary, error) {
+	var parsed handleSummaryData
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse handleSummary data: %w", err)
+	}
+
+	values := make(map[string]map[string]float64, len(parsed.Metrics))
+	imported := &ImportedSummary{
+		Format:   SummaryFormatHandleSummary,
+		Duration: time.Duration(parsed.State.TestRunDurationMs * float64(time.Millisecond)),
+	}
+	for name, metric := range parsed.Metrics {
+		values[name] = metric.Values
+		for threshold, outcome := range metric.Thresholds {
+			imported.Thresholds = append(imported.Thresholds, ThresholdOutcome{Metric: name, Threshold: threshold, Passed: outcome.OK})
+		}
+	}
+	imported.Summary = summaryFromValues(values)
+	collectHandleSummaryChecks(parsed.RootGroup, &imported.Checks)
+	sortOutcomes(imported.Thresholds, imported.Checks)
+
+	return imported, nil
+}
+
+// collectHandleSummaryChecks appends the checks of the group and its subgroups to checks.
+func collectHandleSummaryChecks(group handleSummaryGroup, checks *[]CheckOutcome) {
+	for _, check := range group.Checks {
+		*checks = append(*checks, CheckOutcome{
+			Name:   check.Name,
+			Group:  group.Path,
+			Passes: check.Passes,
+			Fails:  check.Fails,
+		})
+	}
+	for _, subgroup := range group.Groups {
+		collectHandleSummaryChecks(subgroup, checks)
+	}
+}
+
+func parseSummaryExport(data []byte) (*ImportedSummary, error) {
+	var parsed summaryExportData
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse summary export: %w", err)
+	}
+
+	values := make(map[string]map[string]float64, len(parsed.Metrics))
+	imported := &ImportedSummary{Format: SummaryFormatExport}
+	for name, fields := range parsed.Metrics {
+		values[name] = make(map[string]float64, len(fields))
+		for field, raw := range fields {
+			if field == "thresholds" {
+				// k6 reports true for crossed thresholds in summary exports
+				var thresholds map[string]bool
+				if err := json.Unmarshal(raw, &thresholds); err != nil {
+					return nil, fmt.Errorf("failed to parse the thresholds of metric %s: %w", name, err)
+				}
+				for threshold, crossed := range thresholds {
+					imported.Thresholds = append(imported.Thresholds, ThresholdOutcome{Metric: name, Threshold: threshold, Passed: !crossed})
+				}
+				continue
+			}
+			var value float64
+			if json.Unmarshal(raw, &value) == nil {
+				values[name][field] = value
+			}
+		}
+	}
+	imported.Summary = summaryFromValues(values)
+	collectChecks(parsed.RootGroup, &imported.Checks)
+	sortOutcomes(imported.Thresholds, imported.Checks)
+
+	return imported, nil
+}
+
+// summaryFromValues builds the summary of runs from the values of the metrics of a k6
+// summary, keyed by metric name then statistic, e.g. "http_req_duration" then "p(95)".
+func summaryFromValues(values map[string]map[string]float64) TestSummary {
+	var summary TestSummary
+
+	requests := values["http_reqs"]
+	summary.TotalRequests = int(requests["count"])
+	summary.RequestRate = requests["rate"]
+
+	// Rate metrics count their non-zero values as passes: failed requests, for http_req_failed
+	summary.FailedRequests = int(values["http_req_failed"]["passes"])
+
+	duration := values["http_req_duration"]
+	summary.AvgResponseTime = duration["avg"]
+	summary.P95ResponseTime = duration["p(95)"]
+	summary.MedResponseTime = duration["med"]
+	summary.P90ResponseTime = duration["p(90)"]
+	summary.P99ResponseTime = duration["p(99)"]
+	summary.MaxResponseTime = duration["max"]
+
+	summary.DataReceivedBytes = int64(values["data_received"]["count"])
+	summary.DataSentBytes = int64(values["data_sent"]["count"])
+
+	summary.Iterations = int(values["iterations"]["count"])
+	summary.DroppedIterations = int(values["dropped_iterations"]["count"])
+	summary.AvgIterationDuration = values["iteration_duration"]["avg"]
+
+	if grpc, ok := values["grpc_req_duration"]; ok {
+		summary.GRPCAvgResponseTime = grpc["avg"]
+		summary.GRPCP95ResponseTime = grpc["p(95)"]
+		summary.GRPCP99ResponseTime = grpc["p(99)"]
+	}
+	formatSummary(&summary)
+
+	return summary
+}
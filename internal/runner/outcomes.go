@@ -0,0 +1,161 @@
+package runner
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// summaryExportName is the name of the file k6 exports the end-of-test summary to, written
+// next to the script. It holds the outcomes of the thresholds and checks of the run.
+const summaryExportName = ".k6-mcp-summary.json"
+
+// ThresholdOutcome is the outcome of a threshold of a run.
+type ThresholdOutcome struct {
+	Metric    string `json:"metric"`
+	Threshold string `json:"threshold"`
+	Passed    bool   `json:"passed"`
+}
+
+// CheckOutcome is the outcome of a check of a run, over all its evaluations.
+type CheckOutcome struct {
+	Name string `json:"name"`
+	// Group is the path of the group the check belongs to, e.g. "::login", or empty.
+	Group  string `json:"group,omitempty"`
+	Passes int    `json:"passes"`
+	Fails  int    `json:"fails"`
+}
+
+// summaryExport is the part of the k6 --summary-export file holding thresholds and checks.
+type summaryExport struct {
+	RootGroup summaryGroup `json:"root_group"`
+	Metrics   map[string]struct {
+		// Thresholds maps threshold expressions to whether they were crossed: k6 reports
+		// true for failed thresholds in summary exports.
+		Thresholds map[string]bool `json:"thresholds"`
+	} `json:"metrics"`
+}
+
+// summaryGroup is a group of a k6 summary export.
+type summaryGroup struct {
+	Path   string                  `json:"path"`
+	Groups map[string]summaryGroup `json:"groups"`
+	Checks map[string]struct {
+		Name   string `json:"name"`
+		Passes int    `json:"passes"`
+		Fails  int    `json:"fails"`
+	} `json:"checks"`
+}
+
+// summaryExportArgs returns the k6 flags exporting the end-of-test summary next to the script.
+func summaryExportArgs(scriptPath string) []string {
+	return []string{"--summary-export", filepath.Join(filepath.Dir(scriptPath), summaryExportName)}
+}
+
+// readOutcomes reads the threshold and check outcomes of the summary exported next to the
+// script, and returns them with the content of the export. Runs that failed before the end
+// of the test export no summary, and have none.
+func readOutcomes(scriptPath string) ([]ThresholdOutcome, []CheckOutcome, []byte, error) {
+	// #nosec G304 - the path is within the private workspace of the run
+	data, err := os.ReadFile(filepath.Join(filepath.Dir(scriptPath), summaryExportName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil, nil, nil
+		}
+		return nil, nil, nil, fmt.Errorf("failed to read summary export: %w", err)
+	}
+
+	var export summaryExport
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to parse summary export: %w", err)
+	}
+
+	var thresholds []ThresholdOutcome
+	for metric, values := range export.Metrics {
+		for threshold, crossed := range values.Thresholds {
+			thresholds = append(thresholds, ThresholdOutcome{Metric: metric, Threshold: threshold, Passed: !crossed})
+		}
+	}
+
+	var checks []CheckOutcome
+	collectChecks(export.RootGroup, &checks)
+	sortOutcomes(thresholds, checks)
+
+	return thresholds, checks, data, nil
+}
+
+// sortOutcomes sorts thresholds by metric and expression, and checks by group and name.
+func sortOutcomes(thresholds []ThresholdOutcome, checks []CheckOutcome) {
+	sort.Slice(thresholds, func(i, j int) bool {
+		if thresholds[i].Metric != thresholds[j].Metric {
+			return thresholds[i].Metric < thresholds[j].Metric
+		}
+		return thresholds[i].Threshold < thresholds[j].Threshold
+	})
+	sort.SliceStable(checks, func(i, j int) bool {
+		if checks[i].Group != checks[j].Group {
+			return checks[i].Group < checks[j].Group
+		}
+		return checks[i].Name < checks[j].Name
+	})
+}
+
+// collectChecks appends the checks of the group and its subgroups to checks.
+func collectChecks(group summaryGroup, checks *[]CheckOutcome) {
+	for name, check := range group.Checks {
+		if check.Name != "" {
+			name = check.Name
+		}
+		*checks = append(*checks, CheckOutcome{
+			Name:   name,
+			Group:  group.Path,
+			Passes: check.Passes,
+			Fails:  check.Fails,
+		})
+	}
+	for _, subgroup := range group.Groups {
+		collectChecks(subgroup, checks)
+	}
+}
+
+// identifyOutcomeIssues reports the crossed thresholds and failing checks of a run.
+func identifyOutcomeIssues(result *RunResult) []TestIssue {
+	var issues []TestIssue
+
+	var crossed []string
+	for _, threshold := range result.Thresholds {
+		if !threshold.Passed {
+			crossed = append(crossed, fmt.Sprintf("%s: %s", threshold.Metric, threshold.Threshold))
+		}
+	}
+	if len(crossed) > 0 {
+		issues = append(issues, TestIssue{
+			Type:       "threshold",
+			Severity:   "high",
+			Message:    fmt.Sprintf("%d threshold(s) crossed: %s", len(crossed), strings.Join(crossed, ", ")),
+			Suggestion: "Investigate the metrics of the crossed thresholds, or revisit the thresholds if they don't reflect the system's objectives.",
+			Count:      len(crossed),
+		})
+	}
+
+	var failing []string
+	for _, check := range result.Checks {
+		if check.Fails > 0 {
+			failing = append(failing, fmt.Sprintf("%q (%d of %d failed)", check.Name, check.Fails, check.Passes+check.Fails))
+		}
+	}
+	if len(failing) > 0 {
+		issues = append(issues, TestIssue{
+			Type:       "check",
+			Severity:   "medium",
+			Message:    fmt.Sprintf("%d check(s) failed: %s", len(failing), strings.Join(failing, ", ")),
+			Suggestion: "Run again with debug_responses to capture failing requests and responses.",
+			Count:      len(failing),
+		})
+	}
+
+	return issues
+}
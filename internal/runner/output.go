@@ -0,0 +1,141 @@
+package runner
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+
+	"github.com/oleiade/k6-mcp/internal/execx"
+)
+
+const (
+	// MaxOutputTailBytes is the amount of k6 text output (stdout lines that are not JSON
+	// metrics, and stderr) kept for the run result. Earlier output is dropped.
+	MaxOutputTailBytes = 64 * 1024
+
+	// MaxRawMetrics is the maximum number of JSON metric lines included in the run result's
+	// raw metrics. All lines contribute to the summary.
+	MaxRawMetrics = 1000
+
+	// maxLineBytes bounds the length of a single output line; longer lines are treated as text.
+	maxLineBytes = 1024 * 1024
+)
+
+// outputParser is an io.Writer parsing k6 stdout line by line as it is produced: JSON
+// metric lines feed the summary, and other lines are kept in a bounded text buffer.
+// When spill is set, the complete output is also copied to it.
+type outputParser struct {
+	pending      []byte
+	text         *execx.TailBuffer
+	collector    *summaryCollector
+	rawMetrics   []map[string]interface{}
+	metricsCount int
+
+	spill    *os.File
+	spillErr error
+
+	// debug, when set, captures failing responses from stderr.
+	debug *responseCapture
+	// progress, when set, tracks the cumulative metrics of the run as they are produced.
+	progress *runProgress
+}
+
+// newOutputParser returns an output parser with empty results.
+func newOutputParser() *outputParser {
+	return &outputParser{
+		text:      execx.NewTailBuffer(MaxOutputTailBytes),
+		collector: newSummaryCollector(),
+	}
+}
+
+// Write implements io.Writer. It never fails, so that the k6 process is never blocked.
+func (p *outputParser) Write(data []byte) (int, error) {
+	if p.spill != nil && p.spillErr == nil {
+		_, p.spillErr = p.spill.Write(data)
+	}
+
+	p.pending = append(p.pending, data...)
+	for {
+		i := bytes.IndexByte(p.pending, '\n')
+		if i < 0 {
+			break
+		}
+		p.handleLine(p.pending[:i+1])
+		p.pending = p.pending[i+1:]
+	}
+
+	if len(p.pending) > maxLineBytes {
+		p.handleLine(p.pending)
+		p.pending = nil
+	} else if len(p.pending) > 0 {
+		// Move the incomplete line to the start of the buffer so it doesn't grow forever
+		p.pending = append([]byte(nil), p.pending...)
+	} else {
+		p.pending = p.pending[:0]
+	}
+
+	return len(data), nil
+}
+
+// Flush handles the last output line when it isn't terminated by a newline.
+func (p *outputParser) Flush() {
+	if len(p.pending) > 0 {
+		p.handleLine(p.pending)
+		p.pending = nil
+	}
+}
+
+// handleLine parses a single output line.
+func (p *outputParser) handleLine(line []byte) {
+	trimmed := bytes.TrimSpace(line)
+	if len(trimmed) == 0 {
+		return
+	}
+
+	if trimmed[0] == '{' && len(trimmed) <= maxLineBytes {
+		var metric map[string]interface{}
+		if err := json.Unmarshal(trimmed, &metric); err == nil {
+			p.metricsCount++
+			p.collector.add(metric)
+			if p.progress != nil {
+				p.progress.add(metric)
+			}
+			if len(p.rawMetrics) < MaxRawMetrics {
+				p.rawMetrics = append(p.rawMetrics, metric)
+			}
+			return
+		}
+	}
+
+	_, _ = p.text.Write(line)
+	if line[len(line)-1] != '\n' {
+		_, _ = p.text.Write([]byte{'\n'})
+	}
+}
+
+// results returns the metrics and summary parsed from the output.
+func (p *outputParser) results() (map[string]interface{}, TestSummary) {
+	metrics := make(map[string]interface{})
+	if p.metricsCount == 0 {
+		return metrics, TestSummary{}
+	}
+
+	metrics["raw_metrics"] = p.rawMetrics
+	metrics["metrics_count"] = p.metricsCount
+	if p.metricsCount > len(p.rawMetrics) {
+		metrics["raw_metrics_truncated"] = true
+	}
+
+	return metrics, p.collector.summary()
+}
+
+// spillPath returns the path of the file the complete output was copied to, if any.
+func (p *outputParser) spillPath() string {
+	if p.spill == nil || p.spillErr != nil {
+		return ""
+	}
+	return p.spill.Name()
+}
+
+var _ io.Writer = &outputParser{}
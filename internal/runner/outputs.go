@@ -0,0 +1,215 @@
+package runner
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Output presets.
+const (
+	// OutputInfluxDB streams metrics to an InfluxDB v2 bucket, with the xk6-output-influxdb
+	// extension.
+	OutputInfluxDB = "influxdb"
+	// OutputDatadog streams metrics to a Datadog agent over DogStatsD, with the
+	// xk6-output-statsd extension.
+	OutputDatadog = "datadog"
+
+	// influxDBOutputName and statsdOutputName are the names k6 binaries built with the
+	// output extensions know them by, passed to --out.
+	influxDBOutputName = "xk6-influxdb"
+	statsdOutputName   = "output-statsd"
+
+	// defaultStatsdAddress is the address the Datadog agent listens to DogStatsD on.
+	defaultStatsdAddress = "localhost:8125"
+	// defaultStatsdNamespace prefixes the names of the metrics sent to Datadog.
+	defaultStatsdNamespace = "k6."
+)
+
+// OutputPresets are the supported output presets.
+var OutputPresets = []string{OutputInfluxDB, OutputDatadog}
+
+// Output is an output preset, streaming the metrics of runs to a time series database in
+// addition to the JSON output the server parses. Presets translate their connection
+// parameters into the --out flag and the K6_* environment of the k6 output, which the k6
+// binary must be built with.
+type Output struct {
+	// Type is the preset: influxdb or datadog.
+	Type string `json:"type"`
+
+	// URL, Organization, Bucket and Token locate and authorize the InfluxDB v2 bucket.
+	// InsecureSkipTLSVerify skips the verification of the certificate of the URL.
+	URL                   string `json:"url,omitempty"`
+	Organization          string `json:"organization,omitempty"`
+	Bucket                string `json:"bucket,omitempty"`
+	Token                 string `json:"token,omitempty"`
+	InsecureSkipTLSVerify bool   `json:"insecure_skip_tls_verify,omitempty"`
+
+	// Address is the host:port of the DogStatsD listener of the Datadog agent, and
+	// Namespace the prefix of the metric names.
+	Address   string `json:"address,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+
+	// PushInterval is the interval at which metrics are flushed, e.g. "1s".
+	PushInterval string `json:"push_interval,omitempty"`
+}
+
+// ValidateOutputs validates output presets: their type must be supported, at most once,
+// and their required connection parameters set and well-formed.
+func ValidateOutputs(outputs []Output) error {
+	seen := make(map[string]bool)
+	for i, output := range outputs {
+		if seen[output.Type] {
+			return &RunError{
+				Type:    "PARAMETER_VALIDATION",
+				Message: fmt.Sprintf("output %d: the %s output is set more than once", i+1, output.Type),
+			}
+		}
+		seen[output.Type] = true
+
+		if err := validateOutput(output); err != nil {
+			return &RunError{
+				Type:    "PARAMETER_VALIDATION",
+				Message: fmt.Sprintf("invalid output %d: %s", i+1, err.Error()),
+			}
+		}
+	}
+
+	return nil
+}
+
+func validateOutput(output Output) error {
+	if output.PushInterval != "" {
+		interval, err := time.ParseDuration(output.PushInterval)
+		if err != nil || interval < 100*time.Millisecond || interval > time.Minute {
+			return fmt.Errorf("push_interval %q must be a duration between 100ms and 1m, e.g. '1s'", output.PushInterval)
+		}
+	}
+
+	switch output.Type {
+	case OutputInfluxDB:
+		u, err := url.Parse(output.URL)
+		if output.URL == "" || err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("the influxdb output requires the http or https 'url' of the InfluxDB server, e.g. 'http://localhost:8086'")
+		}
+		if u.User != nil {
+			return fmt.Errorf("the influxdb url must not hold credentials; pass the API token as 'token'")
+		}
+		var missing []string
+		for name, value := range map[string]string{"organization": output.Organization, "bucket": output.Bucket, "token": output.Token} {
+			if strings.TrimSpace(value) == "" {
+				missing = append(missing, "'"+name+"'")
+			}
+		}
+		if len(missing) > 0 {
+			sort.Strings(missing)
+			return fmt.Errorf("the influxdb output is missing %s", strings.Join(missing, ", "))
+		}
+		if output.Address != "" || output.Namespace != "" {
+			return fmt.Errorf("'address' and 'namespace' only apply to the datadog output")
+		}
+
+	case OutputDatadog:
+		if output.Address != "" {
+			host, port, err := net.SplitHostPort(output.Address)
+			if err != nil || host == "" {
+				return fmt.Errorf("address %q must be the host:port of the DogStatsD listener, e.g. 'localhost:8125'", output.Address)
+			}
+			if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+				return fmt.Errorf("address %q has an invalid port", output.Address)
+			}
+		}
+		if output.URL != "" || output.Organization != "" || output.Bucket != "" || output.Token != "" || output.InsecureSkipTLSVerify {
+			return fmt.Errorf("'url', 'organization', 'bucket', 'token' and 'insecure_skip_tls_verify' only apply to the influxdb output; the Datadog agent holds the API key")
+		}
+
+	default:
+		return fmt.Errorf("unsupported type %q; expected one of %s", output.Type, strings.Join(OutputPresets, ", "))
+	}
+
+	return nil
+}
+
+// outputArgs returns the --out flags of the output presets.
+func outputArgs(outputs []Output) []string {
+	var args []string
+	for _, output := range outputs {
+		switch output.Type {
+		case OutputInfluxDB:
+			args = append(args, "--out", influxDBOutputName+"="+output.URL)
+		case OutputDatadog:
+			args = append(args, "--out", statsdOutputName)
+		}
+	}
+	return args
+}
+
+// outputEnvironment returns the process environment variables configuring the k6 outputs
+// of the presets, sorted by name. They are passed in the environment rather than on the
+// command line, so that the InfluxDB token appears in neither the command line nor the
+// arguments of the script.
+func outputEnvironment(outputs []Output) []string {
+	env := make(map[string]string)
+	for _, output := range outputs {
+		switch output.Type {
+		case OutputInfluxDB:
+			env["K6_INFLUXDB_ORGANIZATION"] = output.Organization
+			env["K6_INFLUXDB_BUCKET"] = output.Bucket
+			env["K6_INFLUXDB_TOKEN"] = output.Token
+			if output.InsecureSkipTLSVerify {
+				env["K6_INFLUXDB_INSECURE"] = "true"
+			}
+			if output.PushInterval != "" {
+				env["K6_INFLUXDB_PUSH_INTERVAL"] = output.PushInterval
+			}
+		case OutputDatadog:
+			env["K6_STATSD_ADDR"] = defaultStatsdAddress
+			if output.Address != "" {
+				env["K6_STATSD_ADDR"] = output.Address
+			}
+			env["K6_STATSD_NAMESPACE"] = defaultStatsdNamespace
+			if output.Namespace != "" {
+				env["K6_STATSD_NAMESPACE"] = output.Namespace
+			}
+			// DogStatsD supports tags, which carry the tags of the run
+			env["K6_STATSD_ENABLE_TAGS"] = "true"
+			if output.PushInterval != "" {
+				env["K6_STATSD_PUSH_INTERVAL"] = output.PushInterval
+			}
+		}
+	}
+
+	return secretEnvironment(env)
+}
+
+// outputSecrets returns the secrets of the output presets, redacted from the output of runs.
+func outputSecrets(outputs []Output) map[string]string {
+	secrets := make(map[string]string)
+	for _, output := range outputs {
+		if output.Token != "" {
+			secrets["K6_INFLUXDB_TOKEN"] = output.Token
+		}
+	}
+	return secrets
+}
+
+// describeOutput describes where the output preset sends metrics.
+func describeOutput(output Output) string {
+	switch output.Type {
+	case OutputInfluxDB:
+		return fmt.Sprintf("the InfluxDB bucket %s of %s at %s (--out %s, from the xk6-output-influxdb extension)",
+			output.Bucket, output.Organization, output.URL, influxDBOutputName)
+	case OutputDatadog:
+		address := output.Address
+		if address == "" {
+			address = defaultStatsdAddress
+		}
+		return fmt.Sprintf("the Datadog agent at %s over DogStatsD (--out %s, from the xk6-output-statsd extension)", address, statsdOutputName)
+	default:
+		return output.Type
+	}
+}
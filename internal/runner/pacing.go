@@ -0,0 +1,186 @@
+package runner
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+const (
+	// MaxPacing is the maximum pacing, in iterations per VU per minute.
+	MaxPacing = 600
+	// PacingExecutor is the k6 executor paced runs use.
+	PacingExecutor = "constant-arrival-rate"
+	// pacingScenarioName is the name of the scenario of paced runs.
+	pacingScenarioName = "paced"
+)
+
+var (
+	// sleepCallPattern matches sleep() calls with a constant duration, in seconds.
+	sleepCallPattern = regexp.MustCompile(`\bsleep\(\s*([0-9]*\.?[0-9]+)\s*\)`)
+	// executionOptionsPattern matches script options controlling the execution, which take
+	// precedence over the scenario of paced runs.
+	executionOptionsPattern = regexp.MustCompile(`\b(scenarios|stages|vus|duration|iterations)\s*:`)
+)
+
+// PacingPlan describes how a pacing is applied to a run.
+type PacingPlan struct {
+	// Pacing is the requested pacing, in iterations per VU per minute.
+	Pacing float64 `json:"iterations_per_vu_per_minute"`
+	// IntervalSeconds is the time each VU has to complete an iteration.
+	IntervalSeconds float64 `json:"interval_seconds"`
+	// Scenario is the k6 scenario the run uses.
+	Scenario map[string]interface{} `json:"scenario"`
+	// Guidance explains how to write the script for the pacing.
+	Guidance string   `json:"guidance"`
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// planPacing translates the pacing of the options into a constant arrival rate scenario:
+// k6 starts VUs × pacing iterations per minute, whatever their duration. The plan warns
+// when the script can't keep up with the pacing, or would override the scenario.
+func planPacing(script string, options *RunOptions) (*PacingPlan, error) {
+	if options == nil || options.Pacing == 0 {
+		return nil, nil
+	}
+
+	if err := validatePacing(options); err != nil {
+		return nil, err
+	}
+	if UsesBrowser(script) {
+		return nil, &RunError{
+			Type:    "PARAMETER_VALIDATION",
+			Message: "pacing is not supported for browser scripts, which are controlled by their own scenarios",
+		}
+	}
+
+	vus := options.VUs
+	if vus == 0 {
+		vus = DefaultVUs
+	}
+	duration := options.Duration
+	if duration == "" {
+		duration = DefaultDuration
+	}
+
+	// Arrival rates are whole numbers of iterations per time unit
+	perMinute := float64(vus) * options.Pacing
+	rate, timeUnit := int(perMinute), "1m"
+	if perMinute != math.Trunc(perMinute) {
+		rate, timeUnit = int(math.Round(perMinute*60)), "1h"
+	}
+	if rate < 1 {
+		return nil, &RunError{
+			Type:    "PARAMETER_VALIDATION",
+			Message: "pacing is too low: the VUs must start at least one iteration per hour in total",
+		}
+	}
+
+	interval := 60 / options.Pacing
+	plan := &PacingPlan{
+		Pacing:          options.Pacing,
+		IntervalSeconds: interval,
+		Scenario: map[string]interface{}{
+			"executor":        PacingExecutor,
+			"rate":            rate,
+			"timeUnit":        timeUnit,
+			"duration":        duration,
+			"preAllocatedVUs": vus,
+			"maxVUs":          vus,
+		},
+		Guidance: fmt.Sprintf("The %s executor starts an iteration every %s per VU, whatever the time iterations take: "+
+			"don't sleep at the end of the iteration to pace it, and keep think time between requests "+
+			"well under %s, so that iterations complete before the next one is due.",
+			PacingExecutor, formatSeconds(interval), formatSeconds(interval)),
+	}
+
+	if sleeps := scriptSleepSeconds(script); sleeps >= interval {
+		plan.Warnings = append(plan.Warnings, fmt.Sprintf(
+			"The pacing is infeasible: the script sleeps %s per iteration, more than the %s each VU has per iteration. "+
+				"k6 will drop the iterations the VUs can't start; lower the pacing to at most %.1f, or remove sleep() calls.",
+			formatSeconds(sleeps), formatSeconds(interval), math.Floor(600/sleeps)/10))
+	} else if sleeps > interval/2 {
+		plan.Warnings = append(plan.Warnings, fmt.Sprintf(
+			"The script sleeps %s per iteration, over half of the %s each VU has per iteration: "+
+				"slow responses will make VUs fall behind the pacing.",
+			formatSeconds(sleeps), formatSeconds(interval)))
+	}
+
+	if executionOptionsPattern.MatchString(script) {
+		plan.Warnings = append(plan.Warnings,
+			"The script appears to set execution options (scenarios, stages, vus, duration or iterations), "+
+				"which take precedence over the paced scenario. Remove them from the script's options for the pacing to apply.")
+	}
+
+	return plan, nil
+}
+
+// validatePacing validates the pacing of the options.
+func validatePacing(options *RunOptions) error {
+	switch {
+	case options.Pacing < 0 || options.Pacing > MaxPacing:
+		return &RunError{
+			Type:    "PARAMETER_VALIDATION",
+			Message: fmt.Sprintf("pacing must be between 0 and %d iterations per VU per minute", MaxPacing),
+		}
+	case options.Iterations > 0 || len(options.Stages) > 0:
+		return &RunError{
+			Type:    "PARAMETER_VALIDATION",
+			Message: "pacing cannot be combined with iterations or stages: paced runs last for the duration",
+		}
+	}
+
+	return nil
+}
+
+// scriptSleepSeconds returns the total duration of the constant sleep() calls of the
+// script, as an estimate of the think time of an iteration.
+func scriptSleepSeconds(script string) float64 {
+	var total float64
+	for _, match := range sleepCallPattern.FindAllStringSubmatch(script, -1) {
+		if seconds, err := strconv.ParseFloat(match[1], 64); err == nil {
+			total += seconds
+		}
+	}
+
+	return total
+}
+
+// formatSeconds formats a number of seconds as a duration.
+func formatSeconds(seconds float64) string {
+	return time.Duration(seconds * float64(time.Second)).Round(time.Millisecond).String()
+}
+
+// identifyPacingIssues reports runs whose VUs could not keep up with the pacing.
+func identifyPacingIssues(result *RunResult, options *RunOptions) []TestIssue {
+	if options == nil || options.Pacing == 0 {
+		return nil
+	}
+
+	var issues []TestIssue
+	if result.Summary.DroppedIterations > 0 {
+		issues = append(issues, TestIssue{
+			Type:       "pacing",
+			Severity:   "high",
+			Message:    fmt.Sprintf("%d iterations were dropped: the VUs could not keep up with the pacing", result.Summary.DroppedIterations),
+			Suggestion: "Add VUs, lower the pacing, or reduce the think time and response times of the iterations.",
+			Count:      result.Summary.DroppedIterations,
+		})
+	}
+
+	interval := 60 / options.Pacing * 1000
+	if result.Summary.AvgIterationDuration > interval {
+		issues = append(issues, TestIssue{
+			Type:       "pacing",
+			Severity:   "medium",
+			Message:    fmt.Sprintf("Iterations take %.0fms on average, longer than the %.0fms pacing interval", result.Summary.AvgIterationDuration, interval),
+			Suggestion: "Lower the pacing, or shorten the iterations.",
+			Value:      result.Summary.AvgIterationDuration,
+			Threshold:  interval,
+		})
+	}
+
+	return issues
+}
@@ -0,0 +1,158 @@
+package runner
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/oleiade/k6-mcp/internal/security"
+)
+
+const (
+	// MaxPrecheckTargets is the maximum number of targets a pre-check connects to.
+	MaxPrecheckTargets = 10
+	// precheckTimeout bounds the connection to each target of a pre-check.
+	precheckTimeout = 5 * time.Second
+)
+
+// TargetCheck is the outcome of the pre-check of a target host.
+type TargetCheck struct {
+	// Target is the address the pre-check connected to, e.g. "https://api.example.com:443".
+	Target string `json:"target"`
+	// Method is "tcp" for a TCP connection, or "tls" for a TCP connection and TLS handshake.
+	Method    string  `json:"method"`
+	Reachable bool    `json:"reachable"`
+	LatencyMs float64 `json:"latency_ms,omitempty"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// precheckAddress is a target of a pre-check.
+type precheckAddress struct {
+	scheme string
+	host   string
+	port   string
+}
+
+func (a precheckAddress) String() string {
+	return a.scheme + "://" + net.JoinHostPort(a.host, a.port)
+}
+
+// precheckTargets checks that the hosts targeted by the script are reachable before the
+// load starts, with a TCP connection to each, and a TLS handshake for https and wss
+// targets: no request reaches the service. Targets are read from the URL literals of the
+// script and the URL values of the environment variables, up to MaxPrecheckTargets.
+func precheckTargets(ctx context.Context, script string, env map[string]string) []TargetCheck {
+	addresses := precheckAddresses(script, env)
+
+	checks := make([]TargetCheck, len(addresses))
+	var wg sync.WaitGroup
+	for i, address := range addresses {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			checks[i] = checkTarget(ctx, address)
+		}()
+	}
+	wg.Wait()
+
+	return checks
+}
+
+// precheckAddresses returns the addresses targeted by the script and environment, sorted.
+func precheckAddresses(script string, env map[string]string) []precheckAddress {
+	seen := make(map[string]bool)
+	var addresses []precheckAddress
+	add := func(scheme, host, port string) {
+		if port == "" {
+			port = "80"
+			if scheme == "https" || scheme == "wss" {
+				port = "443"
+			}
+		}
+		address := precheckAddress{scheme: scheme, host: host, port: port}
+		if !seen[address.String()] {
+			seen[address.String()] = true
+			addresses = append(addresses, address)
+		}
+	}
+
+	for _, target := range security.Scan(script).Targets {
+		add(target.Scheme, target.Host, target.Port)
+	}
+	for _, value := range env {
+		u, err := url.Parse(value)
+		if err != nil || u.Hostname() == "" {
+			continue
+		}
+		switch scheme := strings.ToLower(u.Scheme); scheme {
+		case "http", "https", "ws", "wss":
+			add(scheme, strings.ToLower(u.Hostname()), u.Port())
+		}
+	}
+
+	sort.Slice(addresses, func(i, j int) bool { return addresses[i].String() < addresses[j].String() })
+	if len(addresses) > MaxPrecheckTargets {
+		addresses = addresses[:MaxPrecheckTargets]
+	}
+
+	return addresses
+}
+
+// checkTarget connects to the address, completing a TLS handshake for encrypted schemes.
+func checkTarget(ctx context.Context, address precheckAddress) TargetCheck {
+	check := TargetCheck{Target: address.String(), Method: "tcp"}
+
+	ctx, cancel := context.WithTimeout(ctx, precheckTimeout)
+	defer cancel()
+
+	start := time.Now()
+	hostPort := net.JoinHostPort(address.host, address.port)
+	var conn net.Conn
+	var err error
+	if address.scheme == "https" || address.scheme == "wss" {
+		check.Method = "tls"
+		dialer := &tls.Dialer{Config: &tls.Config{
+			ServerName: address.host,
+			// #nosec G402 - the handshake only checks reachability; certificates are k6's concern
+			InsecureSkipVerify: true,
+		}}
+		conn, err = dialer.DialContext(ctx, "tcp", hostPort)
+	} else {
+		var dialer net.Dialer
+		conn, err = dialer.DialContext(ctx, "tcp", hostPort)
+	}
+	if err != nil {
+		check.Error = err.Error()
+		return check
+	}
+	_ = conn.Close()
+
+	check.Reachable = true
+	check.LatencyMs = float64(time.Since(start).Microseconds()) / 1000
+
+	return check
+}
+
+// precheckError returns the error of a run whose pre-check found unreachable targets, or nil.
+func precheckError(checks []TargetCheck) *RunError {
+	var unreachable []string
+	for _, check := range checks {
+		if !check.Reachable {
+			unreachable = append(unreachable, check.Target)
+		}
+	}
+	if len(unreachable) == 0 {
+		return nil
+	}
+
+	return &RunError{
+		Type:    "TARGET_UNREACHABLE",
+		Message: fmt.Sprintf("target unreachable: %s; the load was not started", strings.Join(unreachable, ", ")),
+	}
+}
@@ -0,0 +1,165 @@
+package runner
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/oleiade/k6-mcp/internal/k6bin"
+	"github.com/oleiade/k6-mcp/internal/security"
+	"github.com/oleiade/k6-mcp/internal/workspace"
+)
+
+// previewWorkspaceDir stands for the temporary workspace directory in previews: its name
+// is only known once the run creates it. Command lines expand it as a shell variable.
+const previewWorkspaceDir = "$WORKSPACE"
+
+// shellSafePattern matches the command line arguments that need no quoting.
+var shellSafePattern = regexp.MustCompile(`^[A-Za-z0-9_./:=@%+,-]+$`)
+
+// RunPreview describes what a run would execute, without executing it.
+type RunPreview struct {
+	// Command is the k6 invocation, executable first. Environment variable values are
+	// redacted, and the workspace directory is the WorkspaceDir placeholder.
+	Command     []string `json:"command"`
+	CommandLine string   `json:"command_line"`
+	// WorkspaceDir stands for the private temporary directory the run creates.
+	WorkspaceDir string `json:"workspace_dir"`
+	// Files are the files written to the workspace before the run.
+	Files []workspace.File `json:"files"`
+	// Options are the resolved run options, and Config the k6 configuration file passed
+	// to k6 with --config, if any.
+	Options *RunOptions            `json:"options"`
+	Config  map[string]interface{} `json:"config,omitempty"`
+	// Environment lists the names of the environment variables of the k6 process.
+	Environment []string    `json:"environment"`
+	Browser     bool        `json:"browser"`
+	Pacing      *PacingPlan `json:"pacing,omitempty"`
+	Timeout     string      `json:"timeout"`
+	Notes       []string    `json:"notes"`
+}
+
+// PreviewK6Test resolves the k6 invocation, configuration and workspace layout a run of the
+// script with the options would use, without creating the workspace or executing k6. The
+// script and options go through the same validation as runs.
+func PreviewK6Test(script string, options *RunOptions) (*RunPreview, error) {
+	if err := validateRunInput(script, options); err != nil {
+		return nil, err
+	}
+	pacing, err := planPacing(script, options)
+	if err != nil {
+		return nil, err
+	}
+	options = withRunTags(script, options)
+
+	preview := &RunPreview{
+		WorkspaceDir: previewWorkspaceDir,
+		Options:      options,
+		Browser:      UsesBrowser(script),
+		Pacing:       pacing,
+		Timeout:      DefaultTimeout.String(),
+	}
+
+	files := options.Files
+	config, err := runConfig(pacing, options)
+	if err != nil {
+		return nil, err
+	}
+	if config != "" {
+		files = make(map[string]string, len(options.Files)+1)
+		for name, content := range options.Files {
+			files[name] = content
+		}
+		files[runConfigName] = config
+		if err := json.Unmarshal([]byte(config), &preview.Config); err != nil {
+			return nil, fmt.Errorf("failed to decode run configuration: %w", err)
+		}
+		redactTLSAuth(preview.Config)
+	}
+	seededScript, files := seedScript(script, files, options)
+	if preview.Files, err = workspace.Layout(seededScript, files); err != nil {
+		return nil, err
+	}
+
+	k6Path, err := k6bin.Find()
+	if err != nil {
+		k6Path = "k6"
+		preview.Notes = append(preview.Notes, "k6 was not found: "+k6bin.NotFoundMessage)
+	}
+
+	// Previews use slash-separated paths, whatever the platform
+	scriptPath := path.Join(previewWorkspaceDir, workspace.ScriptName)
+	args := redactEnvArgs(buildK6Args(scriptPath, options, preview.Browser))
+	for i, arg := range args {
+		args[i] = strings.ReplaceAll(arg, "\\", "/")
+	}
+	preview.Command = append([]string{k6Path}, args...)
+	preview.CommandLine = shellJoin(preview.Command)
+
+	environment := append(security.SecureEnvironment(), secretEnvironment(options.SecretEnv)...)
+	for _, variable := range append(environment, outputEnvironment(options.Outputs)...) {
+		name, _, _ := strings.Cut(variable, "=")
+		preview.Environment = append(preview.Environment, name)
+	}
+	if preview.Browser {
+		env, err := browserEnvironment()
+		if err != nil {
+			preview.Notes = append(preview.Notes, "The run would fail: "+err.Error())
+		}
+		for _, variable := range env {
+			name, _, _ := strings.Cut(variable, "=")
+			preview.Environment = append(preview.Environment, name)
+		}
+	}
+
+	preview.Notes = append(preview.Notes,
+		fmt.Sprintf("The workspace is a private temporary directory, removed after the run; k6 also writes the end-of-test summary to %s in it.", summaryExportName),
+		"k6 streams JSON metrics to stdout, which the server parses into the run summary.",
+	)
+	if options.Seed != nil {
+		preview.Notes = append(preview.Notes, fmt.Sprintf("The script imports %s first, which seeds Math.random with %d and the VU number.", seedModuleName, *options.Seed))
+	}
+	if options.Precheck {
+		addresses := precheckAddresses(script, options.Env)
+		targets := make([]string, len(addresses))
+		for i, address := range addresses {
+			targets[i] = address.String()
+		}
+		if len(targets) == 0 {
+			preview.Notes = append(preview.Notes, "The pre-check would find no target URL to connect to.")
+		} else {
+			preview.Notes = append(preview.Notes, "Before starting k6, the run would check these targets are reachable: "+strings.Join(targets, ", ")+".")
+		}
+	}
+	for _, output := range options.Outputs {
+		preview.Notes = append(preview.Notes, "Metrics are also streamed to "+describeOutput(output)+"; the k6 binary must be built with the extension.")
+	}
+	if options.SaveOutput {
+		preview.Notes = append(preview.Notes, "The complete output is also copied to a temporary file, kept after the run.")
+	}
+	if options.Options != nil {
+		preview.Notes = append(preview.Notes, "The 'options' parameter is not passed to k6; set thresholds with the 'thresholds' parameter, or other options in the script.")
+	}
+
+	return preview, nil
+}
+
+// shellJoin joins a command into a POSIX shell command line, quoting its arguments.
+// Paths within the workspace are double quoted, so that the shell expands its placeholder.
+func shellJoin(command []string) string {
+	quoted := make([]string, len(command))
+	for i, arg := range command {
+		rest, inWorkspace := strings.CutPrefix(arg, previewWorkspaceDir)
+		switch {
+		case shellSafePattern.MatchString(arg):
+			quoted[i] = arg
+		case inWorkspace && (rest == "" || shellSafePattern.MatchString(rest)):
+			quoted[i] = `"` + arg + `"`
+		default:
+			quoted[i] = "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+		}
+	}
+	return strings.Join(quoted, " ")
+}
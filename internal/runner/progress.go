@@ -0,0 +1,231 @@
+package runner
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/oleiade/k6-mcp/internal/k6api"
+)
+
+// Progress is a snapshot of the cumulative metrics of a run in progress, parsed from the
+// k6 output as it is produced.
+type Progress struct {
+	// ID identifies the run while it is in progress; it is not its run history ID.
+	ID           string    `json:"id"`
+	TestName     string    `json:"test_name,omitempty"`
+	ScriptSHA256 string    `json:"script_sha256,omitempty"`
+	StartedAt    time.Time `json:"started_at"`
+	// ElapsedSeconds is the time since k6 started, and PlannedDuration the duration of the
+	// run, when it was set with the duration option.
+	ElapsedSeconds  float64 `json:"elapsed_seconds"`
+	PlannedDuration string  `json:"planned_duration,omitempty"`
+
+	Requests       int     `json:"requests"`
+	FailedRequests int     `json:"failed_requests"`
+	ErrorRate      float64 `json:"error_rate"`
+	RequestRate    float64 `json:"request_rate"`
+	// AvgResponseTime is the average HTTP response time so far, in milliseconds.
+	AvgResponseTime float64 `json:"avg_response_time_ms"`
+	Iterations      int     `json:"iterations"`
+	ChecksPassed    int     `json:"checks_passed"`
+	ChecksFailed    int     `json:"checks_failed"`
+	// VUs is the number of active virtual users at the last sample k6 reported, every
+	// second, and VUsMax the number of virtual users allocated.
+	VUs    int `json:"vus"`
+	VUsMax int `json:"vus_max"`
+
+	// Controllable reports whether the run can be paused, resumed and scaled through the
+	// REST API of k6.
+	Controllable bool `json:"controllable"`
+}
+
+// runProgress accumulates the progress of a run from its k6 JSON metric lines. It is
+// written by the output parser and read by progress requests, concurrently.
+type runProgress struct {
+	mu               sync.Mutex
+	progress         Progress
+	responseTimeSum  float64
+	responseTimeSeen int
+
+	// api is the client of the REST API of the k6 process, if it serves one.
+	api *k6api.Client
+}
+
+// activeRuns holds the progress of the runs in progress, by ID.
+var activeRuns = struct {
+	sync.Mutex
+	runs map[string]*runProgress
+}{runs: make(map[string]*runProgress)}
+
+// trackRun registers the progress of a run starting with the options, serving its REST
+// API on address unless it is empty, until the returned function is called.
+func trackRun(options *RunOptions, address string) (*runProgress, func()) {
+	tracked := &runProgress{progress: Progress{ID: newProgressID(), StartedAt: time.Now().UTC()}}
+	if address != "" {
+		tracked.api = k6api.NewClient(address)
+		tracked.progress.Controllable = true
+	}
+	if options != nil {
+		tracked.progress.TestName = options.Tags[TagTestName]
+		tracked.progress.ScriptSHA256 = options.Tags[TagScriptHash]
+		tracked.progress.PlannedDuration = options.Duration
+	}
+
+	activeRuns.Lock()
+	activeRuns.runs[tracked.progress.ID] = tracked
+	activeRuns.Unlock()
+
+	return tracked, func() {
+		activeRuns.Lock()
+		delete(activeRuns.runs, tracked.progress.ID)
+		activeRuns.Unlock()
+	}
+}
+
+// ActiveRuns returns the progress of the runs in progress, the oldest first.
+func ActiveRuns() []Progress {
+	activeRuns.Lock()
+	tracked := make([]*runProgress, 0, len(activeRuns.runs))
+	for _, run := range activeRuns.runs {
+		tracked = append(tracked, run)
+	}
+	activeRuns.Unlock()
+
+	runs := make([]Progress, 0, len(tracked))
+	for _, run := range tracked {
+		runs = append(runs, run.snapshot())
+	}
+	sort.Slice(runs, func(i, j int) bool {
+		return runs[i].StartedAt.Before(runs[j].StartedAt)
+	})
+	return runs
+}
+
+// RunProgress returns the progress of the run in progress with the ID, if any.
+func RunProgress(id string) (Progress, bool) {
+	activeRuns.Lock()
+	run, ok := activeRuns.runs[id]
+	activeRuns.Unlock()
+	if !ok {
+		return Progress{}, false
+	}
+	return run.snapshot(), true
+}
+
+// add records a k6 JSON metric line.
+func (r *runProgress) add(metric map[string]interface{}) {
+	if metricType, ok := metric["type"].(string); !ok || metricType != "Point" {
+		return
+	}
+	metricName, _ := metric["metric"].(string)
+	data, ok := metric["data"].(map[string]interface{})
+	if !ok {
+		return
+	}
+	value, _ := data["value"].(float64)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	switch metricName {
+	case "http_reqs":
+		r.progress.Requests++
+	case "http_req_failed":
+		if value > 0 {
+			r.progress.FailedRequests++
+		}
+	case "http_req_duration":
+		r.responseTimeSum += value
+		r.responseTimeSeen++
+	case "iterations":
+		r.progress.Iterations++
+	case "checks":
+		if value > 0 {
+			r.progress.ChecksPassed++
+		} else {
+			r.progress.ChecksFailed++
+		}
+	case "vus":
+		r.progress.VUs = int(value)
+	case "vus_max":
+		r.progress.VUsMax = int(value)
+	}
+}
+
+// snapshot returns the progress of the run so far.
+func (r *runProgress) snapshot() Progress {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	progress := r.progress
+	elapsed := time.Since(progress.StartedAt).Seconds()
+	progress.ElapsedSeconds = roundTo(elapsed, 1)
+	if progress.Requests > 0 {
+		progress.ErrorRate = roundTo(float64(progress.FailedRequests)/float64(progress.Requests), 4)
+	}
+	// The rate is only meaningful once k6 has run for a while
+	if elapsed >= 1 {
+		progress.RequestRate = roundTo(float64(progress.Requests)/elapsed, 2)
+	}
+	if r.responseTimeSeen > 0 {
+		progress.AvgResponseTime = roundTo(r.responseTimeSum/float64(r.responseTimeSeen), 2)
+	}
+	return progress
+}
+
+// OpenMetrics renders the progress of the runs in the OpenMetrics text format, labeled
+// with their ID and test name, so that it can be read by Prometheus tooling.
+func OpenMetrics(runs []Progress) string {
+	type family struct {
+		name, kind, help string
+		value            func(Progress) float64
+	}
+	families := []family{
+		{"k6_run_elapsed_seconds", "gauge", "Time since the run started.", func(p Progress) float64 { return p.ElapsedSeconds }},
+		{"k6_http_reqs", "counter", "HTTP requests sent.", func(p Progress) float64 { return float64(p.Requests) }},
+		{"k6_http_req_failed", "counter", "HTTP requests that failed.", func(p Progress) float64 { return float64(p.FailedRequests) }},
+		{"k6_http_req_error_rate", "gauge", "Ratio of the HTTP requests that failed.", func(p Progress) float64 { return p.ErrorRate }},
+		{"k6_http_req_duration_avg_milliseconds", "gauge", "Average HTTP response time.", func(p Progress) float64 { return p.AvgResponseTime }},
+		{"k6_iterations", "counter", "Iterations completed.", func(p Progress) float64 { return float64(p.Iterations) }},
+		{"k6_checks_passed", "counter", "Checks that passed.", func(p Progress) float64 { return float64(p.ChecksPassed) }},
+		{"k6_checks_failed", "counter", "Checks that failed.", func(p Progress) float64 { return float64(p.ChecksFailed) }},
+		{"k6_vus", "gauge", "Active virtual users.", func(p Progress) float64 { return float64(p.VUs) }},
+		{"k6_vus_max", "gauge", "Allocated virtual users.", func(p Progress) float64 { return float64(p.VUsMax) }},
+	}
+
+	var b strings.Builder
+	for _, f := range families {
+		fmt.Fprintf(&b, "# TYPE %s %s\n# HELP %s %s\n", f.name, f.kind, f.name, f.help)
+		sample := f.name
+		if f.kind == "counter" {
+			sample += "_total"
+		}
+		for _, run := range runs {
+			fmt.Fprintf(&b, "%s{run=\"%s\",test_name=\"%s\"} %g\n", sample, run.ID, labelEscaper.Replace(run.TestName), f.value(run))
+		}
+	}
+	b.WriteString("# EOF\n")
+	return b.String()
+}
+
+// labelEscaper escapes OpenMetrics label values.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// roundTo rounds the value to the number of decimal places.
+func roundTo(value float64, places int) float64 {
+	scale := math.Pow(10, float64(places))
+	return math.Round(value*scale) / scale
+}
+
+// newProgressID returns a random ID for a run in progress.
+func newProgressID() string {
+	b := make([]byte, 6)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,93 @@
+package runner
+
+// WebSocketSummary summarizes the ws_* metrics of scripts using k6/ws or k6/experimental/websockets.
+type WebSocketSummary struct {
+	Sessions           int     `json:"sessions"`
+	MessagesSent       int     `json:"messages_sent"`
+	MessagesReceived   int     `json:"messages_received"`
+	AvgSessionDuration float64 `json:"avg_session_duration_ms"`
+	P95SessionDuration float64 `json:"p95_session_duration_ms"`
+	AvgConnecting      float64 `json:"avg_connecting_ms"`
+	P95Connecting      float64 `json:"p95_connecting_ms"`
+}
+
+// SSESummary summarizes the sse_* metrics of scripts using the xk6-sse extension.
+type SSESummary struct {
+	Events int `json:"events"`
+	Errors int `json:"errors"`
+}
+
+// protocolCollector accumulates the samples of WebSocket and SSE metrics.
+type protocolCollector struct {
+	wsSessions         float64
+	wsMessagesSent     float64
+	wsMessagesReceived float64
+	wsSessionDurations *trendDigest
+	wsConnecting       *trendDigest
+
+	sseEvents float64
+	sseErrors float64
+}
+
+// add records a metric sample, and reports whether the metric belongs to WebSocket or SSE.
+func (c *protocolCollector) add(metricName string, value float64) bool {
+	switch metricName {
+	case "ws_sessions":
+		c.wsSessions += value
+	case "ws_msgs_sent":
+		c.wsMessagesSent += value
+	case "ws_msgs_received":
+		c.wsMessagesReceived += value
+	case "ws_session_duration":
+		if c.wsSessionDurations == nil {
+			c.wsSessionDurations = newTrendDigest()
+		}
+		c.wsSessionDurations.Add(value)
+	case "ws_connecting":
+		if c.wsConnecting == nil {
+			c.wsConnecting = newTrendDigest()
+		}
+		c.wsConnecting.Add(value)
+	case "sse_event":
+		c.sseEvents += value
+	case "sse_error":
+		c.sseErrors += value
+	default:
+		return false
+	}
+
+	return true
+}
+
+// apply sets the WebSocket and SSE sections of the summary, when the run used them.
+func (c *protocolCollector) apply(summary *TestSummary) {
+	if c.wsSessions > 0 || c.wsSessionDurations != nil {
+		ws := &WebSocketSummary{
+			Sessions:         int(c.wsSessions),
+			MessagesSent:     int(c.wsMessagesSent),
+			MessagesReceived: int(c.wsMessagesReceived),
+		}
+		if c.wsSessionDurations != nil {
+			ws.AvgSessionDuration = c.wsSessionDurations.Avg()
+			ws.P95SessionDuration = c.wsSessionDurations.Quantile(P95Percentile)
+		}
+		if c.wsConnecting != nil {
+			ws.AvgConnecting = c.wsConnecting.Avg()
+			ws.P95Connecting = c.wsConnecting.Quantile(P95Percentile)
+		}
+		summary.WebSocket = ws
+	}
+
+	if c.sseEvents > 0 || c.sseErrors > 0 {
+		summary.SSE = &SSESummary{
+			Events: int(c.sseEvents),
+			Errors: int(c.sseErrors),
+		}
+	}
+}
+
+// hasNonHTTPActivity reports whether the run exercised a protocol other than HTTP, so
+// that the absence of HTTP requests is not mistaken for a failure.
+func hasNonHTTPActivity(summary TestSummary) bool {
+	return summary.GRPCRequests > 0 || summary.WebSocket != nil || summary.SSE != nil || len(summary.WebVitals) > 0
+}
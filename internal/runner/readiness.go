@@ -0,0 +1,178 @@
+package runner
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// IDs of the items of readiness checklists.
+const (
+	ReadinessValidates   = "script_validates"
+	ReadinessThresholds  = "thresholds_present"
+	ReadinessChecks      = "checks_present"
+	ReadinessData        = "data_parameterized"
+	ReadinessTarget      = "target_allowed"
+	ReadinessPacing      = "pacing_sane"
+	ReadinessEnvironment = "environment_selected"
+)
+
+// readinessWeights are the weights of the items in the score of readiness checklists,
+// adding up to 100.
+var readinessWeights = map[string]int{
+	ReadinessValidates:   25,
+	ReadinessThresholds:  20,
+	ReadinessTarget:      20,
+	ReadinessChecks:      10,
+	ReadinessData:        10,
+	ReadinessPacing:      10,
+	ReadinessEnvironment: 5,
+}
+
+var (
+	checkCallPattern = regexp.MustCompile(`\bcheck\s*\(`)
+	// testDataPattern matches the sources of varying test data: data files, shared arrays,
+	// per-VU and per-iteration values, and random values.
+	testDataPattern = regexp.MustCompile(`\b(SharedArray|open\s*\(|papaparse|exec\.vu|exec\.scenario\.iterationInTest|__VU|__ITER|Math\.random|randomItem|randomIntBetween|randomString|uuidv4|randomUUID)`)
+	// anySleepCallPattern matches sleep() calls, with constant or computed durations.
+	anySleepCallPattern   = regexp.MustCompile(`\bsleep\s*\(\s*([^)\s][^)]*)\)`)
+	arrivalRatePattern    = regexp.MustCompile(`['"](constant|ramping)-arrival-rate['"]`)
+	minIterationPattern   = regexp.MustCompile(`\bminIterationDuration\s*:`)
+	zeroSleepValuePattern = regexp.MustCompile(`^0*\.?0*$`)
+)
+
+// ReadinessItem is an item of a readiness checklist.
+type ReadinessItem struct {
+	ID     string `json:"id"`
+	Passed bool   `json:"passed"`
+	// Blocking items must pass before scaling the test up.
+	Blocking bool   `json:"blocking"`
+	Weight   int    `json:"weight"`
+	Message  string `json:"message"`
+	Fix      string `json:"fix,omitempty"`
+}
+
+// Readiness is a scored readiness checklist.
+type Readiness struct {
+	// Ready is false when a blocking item failed.
+	Ready bool `json:"ready"`
+	// Score is the sum of the weights of the passed items, out of 100.
+	Score int             `json:"score"`
+	Items []ReadinessItem `json:"items"`
+	// Blocking lists the IDs of the failed blocking items.
+	Blocking []string `json:"blocking"`
+}
+
+// ScoreReadiness weighs the items, sorted by weight, and scores the checklist.
+func ScoreReadiness(items []ReadinessItem) Readiness {
+	readiness := Readiness{Ready: true, Items: items, Blocking: []string{}}
+	for i := range readiness.Items {
+		item := &readiness.Items[i]
+		item.Weight = readinessWeights[item.ID]
+		if item.Passed {
+			readiness.Score += item.Weight
+		} else if item.Blocking {
+			readiness.Ready = false
+			readiness.Blocking = append(readiness.Blocking, item.ID)
+		}
+	}
+
+	sort.SliceStable(readiness.Items, func(i, j int) bool {
+		return readiness.Items[i].Weight > readiness.Items[j].Weight
+	})
+
+	return readiness
+}
+
+// CheckReadinessThresholds checks that the script's options, or the extra thresholds the
+// run adds, define thresholds, and that k6 would accept them.
+func CheckReadinessThresholds(script string, extra map[string][]string) ReadinessItem {
+	item := ReadinessItem{ID: ReadinessThresholds, Blocking: true}
+
+	thresholds, custom := ScriptThresholds(script)
+	for metric, expressions := range extra {
+		thresholds[metric] = append(thresholds[metric], expressions...)
+	}
+	if len(thresholds) == 0 {
+		item.Message = "No thresholds are defined: the test can't pass or fail on its own."
+		item.Fix = "Add thresholds to the options, e.g. thresholds: { http_req_failed: ['rate<0.01'], http_req_duration: ['p(95)<500'] }, or set them with set_defaults."
+		return item
+	}
+
+	for _, issue := range CheckThresholds(thresholds, custom) {
+		if issue.Severity == ThresholdIssueError {
+			item.Message = fmt.Sprintf("Threshold %q of %s is invalid: %s", issue.Expression, issue.Metric, issue.Message)
+			item.Fix = "Fix the expression; validate_thresholds lists every issue."
+			return item
+		}
+	}
+
+	metrics := make([]string, 0, len(thresholds))
+	for metric := range thresholds {
+		metrics = append(metrics, metric)
+	}
+	sort.Strings(metrics)
+	item.Passed = true
+	item.Message = "Thresholds are defined on " + strings.Join(metrics, ", ") + "."
+
+	return item
+}
+
+// CheckReadinessChecks checks that the script verifies its responses with checks.
+func CheckReadinessChecks(script string) ReadinessItem {
+	item := ReadinessItem{ID: ReadinessChecks}
+	if count := len(checkCallPattern.FindAllStringIndex(script, -1)); count > 0 {
+		item.Passed = true
+		item.Message = fmt.Sprintf("The script calls check() %d time(s).", count)
+		return item
+	}
+
+	item.Message = "The script makes no check(): errors returning 200, such as error pages, go unnoticed."
+	item.Fix = "Check the status and the content of responses, e.g. check(res, { 'status is 200': (r) => r.status === 200 })."
+	return item
+}
+
+// CheckReadinessData checks that the script varies its test data across VUs or iterations.
+func CheckReadinessData(script string) ReadinessItem {
+	item := ReadinessItem{ID: ReadinessData}
+	if match := testDataPattern.FindString(script); match != "" {
+		item.Passed = true
+		item.Message = fmt.Sprintf("The script varies its data, e.g. with %s.", strings.TrimSpace(strings.TrimSuffix(match, "(")))
+		return item
+	}
+
+	item.Message = "Every VU sends the same requests: caches and hot rows make the results look better than production."
+	item.Fix = "Parameterize the data, e.g. load users from a file into a SharedArray and pick one per VU or iteration."
+	return item
+}
+
+// CheckReadinessPacing checks that iterations are paced, by sleep() calls, a minimum
+// iteration duration or an arrival-rate executor, so that VUs don't hammer the target in
+// a tight loop. Browser scripts are paced by their page loads.
+func CheckReadinessPacing(script string) ReadinessItem {
+	item := ReadinessItem{ID: ReadinessPacing, Passed: true}
+	switch {
+	case arrivalRatePattern.MatchString(script):
+		item.Message = "An arrival-rate executor sets the pace of iterations."
+		return item
+	case minIterationPattern.MatchString(script):
+		item.Message = "The minIterationDuration option paces iterations."
+		return item
+	case UsesBrowser(script):
+		item.Message = "Browser iterations are paced by their page loads."
+		return item
+	}
+
+	for _, match := range anySleepCallPattern.FindAllStringSubmatch(script, -1) {
+		if !zeroSleepValuePattern.MatchString(strings.TrimSpace(match[1])) {
+			item.Message = "The script sleeps between requests."
+			return item
+		}
+	}
+
+	item.Passed = false
+	item.Message = "Iterations run back to back without think time: each VU sends requests as fast as the target answers."
+	item.Fix = "Add sleep() between requests, e.g. sleep(1), or model the request rate with a constant-arrival-rate scenario."
+	return item
+}
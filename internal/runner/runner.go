@@ -3,18 +3,26 @@ package runner
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"log/slog"
+	"maps"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"runtime"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/oleiade/k6-mcp/internal/execx"
+	"github.com/oleiade/k6-mcp/internal/k6bin"
+	"github.com/oleiade/k6-mcp/internal/locale"
 	"github.com/oleiade/k6-mcp/internal/logging"
+	"github.com/oleiade/k6-mcp/internal/sandbox"
 	"github.com/oleiade/k6-mcp/internal/security"
+	"github.com/oleiade/k6-mcp/internal/workspace"
 )
 
 const (
@@ -30,6 +38,10 @@ const (
 	DefaultDuration = "30s"
 	// P95Percentile represents the 95th percentile for response time calculations.
 	P95Percentile = 0.95
+	// MedPercentile, P90Percentile and P99Percentile are the other reported response time percentiles.
+	MedPercentile = 0.5
+	P90Percentile = 0.9
+	P99Percentile = 0.99
 )
 
 // RunOptions contains configuration options for running k6 tests.
@@ -39,6 +51,61 @@ type RunOptions struct {
 	Iterations int                    `json:"iterations,omitempty"`
 	Stages     []Stage                `json:"stages,omitempty"`
 	Options    map[string]interface{} `json:"options,omitempty"`
+
+	// Files holds companion files (local modules, data files, gRPC proto definitions)
+	// keyed by their path relative to the script.
+	Files map[string]string `json:"-"`
+
+	// SaveOutput keeps the complete k6 JSON output in a file, reported as the result's
+	// output file, in addition to the bounded output included in the result.
+	SaveOutput bool `json:"-"`
+
+	// Env holds environment variables exposed to the script through __ENV, passed to k6
+	// with --env.
+	Env map[string]string `json:"-"`
+
+	// DebugResponses captures a sample of failing requests and responses with k6's HTTP
+	// debugging, reported as the result's debug responses.
+	DebugResponses bool `json:"-"`
+
+	// Pacing, when set, is the number of iterations each VU starts per minute. Paced runs
+	// use a constant arrival rate scenario instead of the VUs and duration flags.
+	Pacing float64 `json:"pacing,omitempty"`
+
+	// Thresholds maps metric names to threshold expressions, e.g. {"http_req_duration":
+	// ["p(95)<500"]}, passed to k6 in a configuration file. The script's own thresholds
+	// take precedence.
+	Thresholds map[string][]string `json:"thresholds,omitempty"`
+
+	// Precheck checks that the hosts targeted by the script are reachable before starting
+	// the load, and aborts the run when one is not.
+	Precheck bool `json:"-"`
+
+	// SecretEnv holds environment variables exposed to the script through __ENV, such as
+	// access tokens, passed in the environment of the k6 process rather than with --env, so
+	// that their values appear neither in its command line nor in the output of the run.
+	SecretEnv map[string]string `json:"-"`
+
+	// ClientCertificates are presented by k6 to the hosts of their domains, for mutual TLS.
+	// Their certificates and keys are companion files.
+	ClientCertificates []ClientCertificate `json:"-"`
+
+	// Outputs are output presets streaming the metrics of the run to InfluxDB or Datadog,
+	// in addition to the JSON output the server parses.
+	Outputs []Output `json:"-"`
+
+	// Seed, when set, seeds Math.random in each VU, and is exposed to the script as
+	// __ENV.K6_MCP_SEED, so that runs of scripts drawing random data can be reproduced.
+	Seed *int64 `json:"seed,omitempty"`
+
+	// Tags tag all the metrics of the run, in addition to, and overriding, the automatic
+	// tags: the test name, the script hash, the environment and the server version.
+	Tags map[string]string `json:"tags,omitempty"`
+
+	// TestName is the name of the script, and Environment the label of the environment the
+	// run targets, such as staging; both tag the metrics of the run.
+	TestName    string `json:"-"`
+	Environment string `json:"environment,omitempty"`
 }
 
 // Stage represents a load testing stage with target VUs and duration.
@@ -53,15 +120,55 @@ type RunResult struct {
 	ExitCode        int                    `json:"exit_code"`
 	Stdout          string                 `json:"stdout"`
 	Stderr          string                 `json:"stderr"`
+	OutputFile      string                 `json:"output_file,omitempty"`
 	Error           string                 `json:"error,omitempty"`
 	Duration        string                 `json:"duration"`
+	DurationMS      float64                `json:"duration_ms,omitempty"`
 	Metrics         map[string]interface{} `json:"metrics,omitempty"`
 	Summary         TestSummary            `json:"summary,omitempty"`
 	Analysis        TestAnalysis           `json:"analysis"`
 	Issues          []TestIssue            `json:"issues,omitempty"`
+	Warnings        []SummaryWarning       `json:"warnings"`
 	Recommendations []string               `json:"recommendations,omitempty"`
 	NextSteps       []string               `json:"next_steps,omitempty"`
 	Performance     PerformanceInsights    `json:"performance"`
+
+	// Pacing describes the scenario of paced runs.
+	Pacing *PacingPlan `json:"pacing,omitempty"`
+
+	// Seed is the seed of seeded runs, with which they can be reproduced.
+	Seed *int64 `json:"seed,omitempty"`
+
+	// FailureClass classifies the cause of failed runs, e.g. threshold_breach or
+	// target_saturation, and FailureReason explains the classification.
+	FailureClass  string `json:"failure_class,omitempty"`
+	FailureReason string `json:"failure_reason,omitempty"`
+
+	// Tags are the tags of the metrics of the run, with which exported metrics can be
+	// attributed to it.
+	Tags map[string]string `json:"tags,omitempty"`
+
+	// DebugResponses holds the failing responses sampled by runs debugging responses.
+	DebugResponses *DebugResponses `json:"debug_responses,omitempty"`
+
+	// Thresholds and Checks hold the outcomes of the thresholds and checks of the script,
+	// when the run reached the end of the test.
+	Thresholds []ThresholdOutcome `json:"thresholds,omitempty"`
+	Checks     []CheckOutcome     `json:"checks,omitempty"`
+
+	// Precheck holds the outcomes of the pre-check of the targets, for runs pre-checking them.
+	Precheck []TargetCheck `json:"precheck,omitempty"`
+
+	// SummaryExport is the content of the end-of-test summary k6 exported, when the run
+	// reached the end of the test.
+	SummaryExport []byte `json:"-"`
+
+	// NetworkDiagnostics holds the diagnostics of the target hosts, for failed runs whose
+	// output reports DNS, connection or TLS errors.
+	NetworkDiagnostics *NetworkDiagnostics `json:"network_diagnostics,omitempty"`
+
+	// Hooks holds the reports of the result hooks of the server, keyed by hook name.
+	Hooks map[string]any `json:"hooks,omitempty"`
 }
 
 // TestSummary contains a summary of the test execution results.
@@ -70,9 +177,47 @@ type TestSummary struct {
 	FailedRequests  int     `json:"failed_requests"`
 	AvgResponseTime float64 `json:"avg_response_time_ms"`
 	P95ResponseTime float64 `json:"p95_response_time_ms"`
+	MedResponseTime float64 `json:"med_response_time_ms,omitempty"`
+	P90ResponseTime float64 `json:"p90_response_time_ms,omitempty"`
+	P99ResponseTime float64 `json:"p99_response_time_ms,omitempty"`
+	MaxResponseTime float64 `json:"max_response_time_ms,omitempty"`
 	RequestRate     float64 `json:"request_rate_per_second"`
 	DataReceived    string  `json:"data_received"`
 	DataSent        string  `json:"data_sent"`
+
+	DataReceivedBytes int64 `json:"data_received_bytes,omitempty"`
+	DataSentBytes     int64 `json:"data_sent_bytes,omitempty"`
+
+	// ServerErrors counts the requests answered with a 5xx status.
+	ServerErrors int `json:"server_errors,omitempty"`
+
+	// Iterations counts the completed iterations, and DroppedIterations those k6 could not
+	// start in time, e.g. because the VUs could not keep up with an arrival rate.
+	Iterations           int     `json:"iterations,omitempty"`
+	DroppedIterations    int     `json:"dropped_iterations,omitempty"`
+	AvgIterationDuration float64 `json:"avg_iteration_duration_ms,omitempty"`
+
+	// gRPC metrics, reported when the script makes gRPC requests with k6/net/grpc.
+	GRPCRequests        int     `json:"grpc_requests,omitempty"`
+	GRPCAvgResponseTime float64 `json:"grpc_avg_response_time_ms,omitempty"`
+	GRPCP95ResponseTime float64 `json:"grpc_p95_response_time_ms,omitempty"`
+	GRPCP99ResponseTime float64 `json:"grpc_p99_response_time_ms,omitempty"`
+
+	// WebVitals summarizes the browser_web_vital_* metrics of browser scripts, keyed
+	// by vital name (lcp, fcp, cls, inp, ttfb, fid). Durations are in milliseconds.
+	WebVitals map[string]WebVitalSummary `json:"web_vitals,omitempty"`
+
+	// WebSocket and SSE summarize the sessions and messages of scripts using these protocols.
+	WebSocket *WebSocketSummary `json:"websocket,omitempty"`
+	SSE       *SSESummary       `json:"sse,omitempty"`
+
+	// Scenarios splits the summary by scenario, keyed by scenario name, when the test ran
+	// several scenarios.
+	Scenarios map[string]TestSummary `json:"scenarios,omitempty"`
+
+	// Formatted holds the sizes, durations and rates of the summary as human-readable
+	// strings, next to the raw numbers, in milliseconds and bytes, of the fields above.
+	Formatted *FormattedSummary `json:"formatted,omitempty"`
 }
 
 // TestAnalysis provides high-level analysis of test execution.
@@ -166,29 +311,105 @@ func RunK6Test(ctx context.Context, script string, options *RunOptions) (*RunRes
 		}, err
 	}
 
+	// Translate the pacing into a scenario, passed to k6 as a configuration file
+	pacing, err := planPacing(script, options)
+	if err != nil {
+		logger.WarnContext(ctx, "Test pacing validation failed",
+			slog.String("error", err.Error()),
+		)
+		return &RunResult{
+			Success:  false,
+			Error:    err.Error(),
+			Duration: time.Since(startTime).String(),
+		}, err
+	}
+
 	logger.DebugContext(ctx, "Test input validation passed")
 
-	// Create secure temporary file
-	tempFile, cleanup, err := createSecureTempFile(script)
+	// Check that the targets are reachable, rather than running a test full of connection errors
+	var precheck []TargetCheck
+	if options != nil && options.Precheck {
+		precheck = precheckTargets(ctx, script, options.Env)
+		if runErr := precheckError(precheck); runErr != nil {
+			logger.WarnContext(ctx, "Test target pre-check failed",
+				slog.String("error", runErr.Message),
+			)
+			return &RunResult{
+				Success:  false,
+				Error:    runErr.Message,
+				Duration: time.Since(startTime).String(),
+				Precheck: precheck,
+				NextSteps: locale.List([]string{
+					"Check that the target hosts are up and reachable from this machine (DNS, firewall, VPN)",
+					"Check the URLs of the script and of its environment variables, such as BASE_URL",
+				}),
+			}, runErr
+		}
+	}
+
+	// Materialize the script and its companion files in a private temporary workspace
+	var files map[string]string
+	if options != nil {
+		files = options.Files
+	}
+	config, err := runConfig(pacing, options)
 	if err != nil {
-		logging.FileOperation(ctx, "runner", "create_temp_file", tempFile, err)
 		return &RunResult{
 			Success:  false,
-			Error:    fmt.Sprintf("failed to create temporary file: %v", err),
+			Error:    err.Error(),
 			Duration: time.Since(startTime).String(),
 		}, err
 	}
-	defer cleanup()
+	if config != "" {
+		files = make(map[string]string, len(options.Files)+1)
+		for name, content := range options.Files {
+			files[name] = content
+		}
+		files[runConfigName] = config
+	}
+	seededScript, files := seedScript(script, files, options)
+	ws, err := workspace.Create("k6-run-", seededScript, files)
+	if err != nil {
+		logging.FileOperation(ctx, "runner", "create_workspace", "", err)
+		runErr := &RunError{
+			Type:    "FILE_CREATION",
+			Message: "failed to create temporary workspace",
+			Cause:   err,
+		}
+		return &RunResult{
+			Success:  false,
+			Error:    fmt.Sprintf("failed to create temporary workspace: %v", err),
+			Duration: time.Since(startTime).String(),
+		}, runErr
+	}
+	defer ws.Cleanup()
 
-	logging.FileOperation(ctx, "runner", "create_temp_file", tempFile, nil)
+	logging.FileOperation(ctx, "runner", "create_workspace", ws.ScriptPath, nil)
+
+	// Execute k6 test, tagging its metrics
+	tagged := withRunTags(script, options)
+	result, err := executeK6Test(ctx, ws.ScriptPath, tagged, UsesBrowser(script))
+	elapsed := time.Since(startTime)
+	result.Duration = elapsed.String()
+	result.DurationMS = float64(elapsed.Microseconds()) / 1000
+	result.Tags = tagged.Tags
+	result.Pacing = pacing
+	result.Precheck = precheck
+	if options != nil {
+		result.Seed = options.Seed
+	}
 
-	// Execute k6 test
-	result, err := executeK6Test(ctx, tempFile, options)
-	result.Duration = time.Since(startTime).String()
+	// Tell environment issues from script issues when requests failed to connect
+	var env map[string]string
+	if options != nil {
+		env = options.Env
+	}
+	result.NetworkDiagnostics = diagnoseNetworkFailures(ctx, script, env, result)
 
 	// Enhance result with analysis if execution completed
 	if result != nil {
 		enhanceRunResult(result, options)
+		applyResultHooks(ctx, result, options)
 	}
 
 	logger.InfoContext(ctx, "k6 test execution completed",
@@ -214,11 +435,41 @@ func validateRunInput(script string, options *RunOptions) error {
 		}
 	}
 
+	// Browser scripts are controlled by their own scenarios
+	if UsesBrowser(script) {
+		if err := validateBrowserScript(script); err != nil {
+			return err
+		}
+	}
+
 	// Set defaults if options is nil
 	if options == nil {
 		return nil
 	}
 
+	// Validate companion files
+	if err := workspace.ValidateFiles(options.Files); err != nil {
+		return &RunError{
+			Type:    "PARAMETER_VALIDATION",
+			Message: "invalid files",
+			Cause:   err,
+		}
+	}
+	for _, reserved := range []string{summaryExportName, runConfigName} {
+		if options.Files[reserved] != "" {
+			return &RunError{
+				Type:    "PARAMETER_VALIDATION",
+				Message: fmt.Sprintf("the file name %s is reserved for the run's configuration and summary", reserved),
+			}
+		}
+	}
+	if err := ValidateClientCertificates(options.ClientCertificates, options.Files); err != nil {
+		return err
+	}
+	if err := ValidateOutputs(options.Outputs); err != nil {
+		return err
+	}
+
 	return validateRunOptions(options)
 }
 
@@ -232,6 +483,31 @@ func validateRunOptions(options *RunOptions) error {
 		return err
 	}
 
+	if err := ValidateEnv(options.Env); err != nil {
+		return err
+	}
+	if err := ValidateEnv(options.SecretEnv); err != nil {
+		return err
+	}
+
+	if err := ValidateThresholds(options.Thresholds); err != nil {
+		return err
+	}
+
+	if err := validateSeed(options.Seed); err != nil {
+		return err
+	}
+
+	if err := ValidateTags(options.Tags); err != nil {
+		return err
+	}
+	if err := validateTagValue("test name", options.TestName); err != nil {
+		return err
+	}
+	if err := validateTagValue("environment", options.Environment); err != nil {
+		return err
+	}
+
 	return validateStages(options.Stages)
 }
 
@@ -307,157 +583,164 @@ func validateStages(stages []Stage) error {
 	return nil
 }
 
-// createSecureTempFile creates a secure temporary file with the script content.
-func createSecureTempFile(script string) (string, func(), error) {
-	tmpFile, err := os.CreateTemp("", "k6-run-*.js")
-	if err != nil {
-		return "", nil, &RunError{
-			Type:    "FILE_CREATION",
-			Message: "failed to create temporary file",
-			Cause:   err,
-		}
-	}
-
-	filename := tmpFile.Name()
-	cleanup := func() {
-		if removeErr := os.Remove(filename); removeErr != nil {
-			logging.WithComponent("runner").Warn("Failed to remove temporary file",
-				slog.String("operation", "cleanup"),
-				slog.String("error", removeErr.Error()),
-			)
-		}
-	}
-
-	if err := setupTempFile(tmpFile, script); err != nil {
-		cleanupTempFile(tmpFile)
-		return "", nil, err
-	}
-
-	return filename, cleanup, nil
-}
-
-// setupTempFile configures and writes to the temporary file.
-func setupTempFile(tmpFile *os.File, script string) error {
-	// Set secure permissions (owner read/write only)
-	const secureFileMode = 0o600
-	if err := tmpFile.Chmod(secureFileMode); err != nil {
-		return &RunError{
-			Type:    "FILE_PERMISSION",
-			Message: "failed to set secure file permissions",
-			Cause:   err,
-		}
-	}
-
-	// Write script content
-	if _, err := tmpFile.WriteString(script); err != nil {
-		return &RunError{
-			Type:    "FILE_WRITE",
-			Message: "failed to write script to temporary file",
-			Cause:   err,
-		}
-	}
-
-	if err := tmpFile.Close(); err != nil {
-		return &RunError{
-			Type:    "FILE_CLOSE",
-			Message: "failed to close temporary file",
-			Cause:   err,
-		}
-	}
-
-	return nil
-}
-
-// cleanupTempFile safely cleans up a temporary file.
-func cleanupTempFile(tmpFile *os.File) {
-	logger := logging.WithComponent("runner")
-
-	if closeErr := tmpFile.Close(); closeErr != nil {
-		logger.Warn("Failed to close temp file",
-			slog.String("operation", "cleanup"),
-			slog.String("error", closeErr.Error()),
-		)
-	}
-	if removeErr := os.Remove(tmpFile.Name()); removeErr != nil {
-		logger.Warn("Failed to remove temp file",
-			slog.String("operation", "cleanup"),
-			slog.String("error", removeErr.Error()),
-		)
-	}
-}
-
-// executeK6Test executes k6 with the given script file and options.
-func executeK6Test(ctx context.Context, scriptPath string, options *RunOptions) (*RunResult, error) {
+// executeK6Test executes k6 with the given script file and options. Browser scripts are
+// run headless, with the browser executable discovered and their VUs checked beforehand.
+func executeK6Test(ctx context.Context, scriptPath string, options *RunOptions, browser bool) (*RunResult, error) {
 	logger := logging.WithComponent("runner")
 	startTime := time.Now()
 
-	// Create context with timeout
-	cmdCtx, cancel := context.WithTimeout(ctx, DefaultTimeout)
-	defer cancel()
-
 	// Check if k6 is available
-	if err := security.ValidateEnvironment(); err != nil {
+	k6Path, err := k6bin.Find()
+	if err != nil {
 		logger.ErrorContext(ctx, "k6 executable not found",
 			slog.String("error", err.Error()),
 		)
 		return &RunResult{
 				Success: false,
-				Error:   "k6 executable not found in PATH",
+				Error:   k6bin.NotFoundMessage,
 			}, &RunError{
 				Type:    "K6_NOT_FOUND",
-				Message: "k6 executable not found in PATH",
+				Message: k6bin.NotFoundMessage,
 				Cause:   err,
 			}
 	}
 
-	// Build k6 command arguments
-	args := buildK6Args(scriptPath, options)
+	// Configure the browser module
+	var browserEnv []string
+	if browser {
+		env, err := browserEnvironment()
+		if err != nil {
+			logger.ErrorContext(ctx, "Browser executable not found",
+				slog.String("error", err.Error()),
+			)
+			return &RunResult{
+				Success: false,
+				Error:   err.Error(),
+			}, err
+		}
+		browserEnv = env
+
+		// Enforce the browser VU cap on the VUs k6 computes from the script's scenarios
+		if err := checkBrowserVUs(ctx, k6Path, scriptPath, options); err != nil {
+			logger.WarnContext(ctx, "Browser script validation failed",
+				slog.String("error", err.Error()),
+			)
+			return &RunResult{
+				Success: false,
+				Error:   err.Error(),
+			}, err
+		}
+	}
+
+	// Build k6 command arguments, serving the REST API controlling the run
+	address := controlAddress()
+	args := addressArgs(buildK6Args(scriptPath, options, browser), address)
 
 	logger.DebugContext(ctx, "Executing k6 test command",
-		slog.Any("args", args),
-		slog.String("script_path", getPathType(scriptPath)),
+		slog.Any("args", redactEnvArgs(args)),
+		slog.String("script_path", logging.PathType(scriptPath)),
 	)
 
-	// Prepare k6 command
-	// #nosec G204 - k6 binary is validated to exist, args are sanitized
-	cmd := exec.CommandContext(cmdCtx, "k6", args...)
-
 	// Set secure environment
-	cmd.Env = security.SecureEnvironment()
+	env := append(security.SecureEnvironment(), browserEnv...)
+	env = append(env, secretEnvironment(options.SecretEnv)...)
+	env = append(env, outputEnvironment(options.Outputs)...)
+
+	// Stream the output through the parser, so that it is never buffered whole
+	parser := newOutputParser()
+	var untrack func()
+	parser.progress, untrack = trackRun(options, address)
+	defer untrack()
+	if options.DebugResponses {
+		parser.debug = newResponseCapture(nil)
+	}
+	if options.SaveOutput {
+		outputFile, err := os.CreateTemp("", "k6-mcp-run-output-*.jsonl")
+		if err != nil {
+			logger.WarnContext(ctx, "Failed to create run output file",
+				slog.String("error", err.Error()),
+			)
+		} else {
+			defer func() { _ = outputFile.Close() }()
+			parser.spill = outputFile
+		}
+	}
+
+	// HTTP debugging entries are captured rather than kept with the rest of stderr
+	stderrBuf := execx.NewTailBuffer(MaxOutputTailBytes)
+	var stderrOutput io.Writer = stderrBuf
+	if parser.debug != nil {
+		parser.debug.next = stderrBuf
+		stderrOutput = parser.debug
+	}
 
 	// Execute command and capture output
-	stdout, stderr, exitCode, err := executeCommand(cmd)
+	execution, err := sandbox.Default().Run(ctx, sandbox.Command{Path: k6Path, Args: args, Env: env, Mounts: []string{filepath.Dir(scriptPath)}},
+		execx.WithTimeout(DefaultTimeout),
+		execx.WithStdout(parser),
+		execx.WithStderr(stderrOutput),
+	)
+	parser.Flush()
+	if parser.debug != nil {
+		parser.debug.Flush()
+	}
+	exitCode := execution.ExitCode
 
 	// Log execution results
 	logging.ExecutionEvent(ctx, "runner", "k6 run", time.Since(startTime), exitCode, err)
 
 	// Sanitize output to prevent information leakage
-	stdout = security.SanitizeOutput(stdout)
-	stderr = security.SanitizeOutput(stderr)
+	secrets := outputSecrets(options.Outputs)
+	maps.Copy(secrets, options.SecretEnv)
+	stdout := redactSecrets(security.SanitizeOutput(parser.text.String()), secrets)
+	stderr := redactSecrets(security.SanitizeOutput(stderrBuf.String()), secrets)
 
 	result := &RunResult{
-		Success:  exitCode == 0,
-		ExitCode: exitCode,
-		Stdout:   stdout,
-		Stderr:   stderr,
+		Success:    exitCode == 0,
+		ExitCode:   exitCode,
+		Stdout:     stdout,
+		Stderr:     stderr,
+		OutputFile: parser.spillPath(),
+	}
+	if parser.debug != nil {
+		result.DebugResponses = parser.debug.results()
 	}
 
-	// Parse metrics and summary from output
+	// Report the outcomes of the thresholds and checks, which matter most to failed runs
+	thresholds, checks, export, outcomesErr := readOutcomes(scriptPath)
+	if outcomesErr != nil {
+		logger.WarnContext(ctx, "Failed to read run outcomes",
+			slog.String("error", outcomesErr.Error()),
+		)
+	}
+	result.Thresholds, result.Checks, result.SummaryExport = thresholds, checks, export
+
+	// Report the metrics and summary parsed from the output
+	metrics, summary := parser.results()
 	if result.Success {
-		result.Metrics, result.Summary = parseK6Output(stdout)
+		result.Metrics, result.Summary = metrics, summary
 	}
 
 	// Handle different types of errors
+	var runErr error
 	if err != nil {
 		switch {
-		case errors.Is(err, context.DeadlineExceeded):
+		case errors.Is(err, execx.ErrTimeout):
 			// Command timed out
 			result.Error = fmt.Sprintf("k6 test timed out after %v", DefaultTimeout)
-			return result, &RunError{
+			runErr = &RunError{
 				Type:    "TIMEOUT",
 				Message: fmt.Sprintf("k6 test timed out after %v", DefaultTimeout),
 				Cause:   err,
 			}
+		case errors.Is(err, execx.ErrCanceled):
+			// The request was cancelled, or the client disconnected
+			result.Error = "k6 test was cancelled"
+			runErr = &RunError{
+				Type:    "CANCELED",
+				Message: "k6 test was cancelled",
+				Cause:   err,
+			}
 		default:
 			var exitError *exec.ExitError
 			if errors.As(err, &exitError) {
@@ -466,7 +749,7 @@ func executeK6Test(ctx context.Context, scriptPath string, options *RunOptions)
 			} else {
 				// Other execution errors
 				result.Error = fmt.Sprintf("failed to execute k6: %v", err)
-				return result, &RunError{
+				runErr = &RunError{
 					Type:    "EXECUTION_ERROR",
 					Message: "failed to execute k6 command",
 					Cause:   err,
@@ -475,12 +758,33 @@ func executeK6Test(ctx context.Context, scriptPath string, options *RunOptions)
 		}
 	}
 
-	return result, nil
+	// Classify the cause of failures, so that clients can branch on it
+	if !result.Success {
+		result.FailureClass, result.FailureReason = classifyFailure(result, summary, runErr)
+	}
+
+	return result, runErr
 }
 
 // buildK6Args builds the command line arguments for k6 based on the provided options.
-func buildK6Args(scriptPath string, options *RunOptions) []string {
+//
+// Execution options are not passed for browser scripts: they would replace the script's
+// scenarios, and with them the browser option browser tests require.
+func buildK6Args(scriptPath string, options *RunOptions, browser bool) []string {
 	args := []string{"run"}
+	args = append(args, tagArgs(options)...)
+
+	if browser {
+		if options != nil {
+			args = append(args, configArgs(scriptPath, options)...)
+			args = append(args, envArgs(runEnv(options))...)
+		}
+		args = append(args, summaryExportArgs(scriptPath)...)
+		if options != nil {
+			args = append(args, outputArgs(options.Outputs)...)
+		}
+		return append(args, "--out", jsonOutput(runtime.GOOS), scriptPath)
+	}
 
 	// Set defaults if options is nil
 	if options == nil {
@@ -490,6 +794,20 @@ func buildK6Args(scriptPath string, options *RunOptions) []string {
 		}
 	}
 
+	// Capture failing responses with HTTP debugging
+	args = append(args, debugArgs(options)...)
+
+	// Pass the options that have no flag in a configuration file
+	args = append(args, configArgs(scriptPath, options)...)
+
+	// Paced runs get their VUs and duration from the scenario of the configuration file
+	if options.Pacing > 0 {
+		args = append(args, envArgs(runEnv(options))...)
+		args = append(args, summaryExportArgs(scriptPath)...)
+		args = append(args, outputArgs(options.Outputs)...)
+		return append(args, "--out", jsonOutput(runtime.GOOS), scriptPath)
+	}
+
 	// Set VUs (default to 1 if not specified)
 	vus := options.VUs
 	if vus == 0 {
@@ -514,8 +832,17 @@ func buildK6Args(scriptPath string, options *RunOptions) []string {
 		args = append(args, "--stage", stagesStr)
 	}
 
+	// Expose environment variables to the script
+	args = append(args, envArgs(runEnv(options))...)
+
+	// Export the summary, for the outcomes of thresholds and checks
+	args = append(args, summaryExportArgs(scriptPath)...)
+
+	// Stream metrics to the outputs of the presets
+	args = append(args, outputArgs(options.Outputs)...)
+
 	// Add JSON output for metrics parsing
-	args = append(args, "--out", "json=/dev/stdout")
+	args = append(args, "--out", jsonOutput(runtime.GOOS))
 
 	// Add script path
 	args = append(args, scriptPath)
@@ -523,6 +850,15 @@ func buildK6Args(scriptPath string, options *RunOptions) []string {
 	return args
 }
 
+// jsonOutput returns the k6 --out flag value streaming JSON metrics to stdout on the given
+// operating system: Windows has no /dev/stdout, and k6 writes to stdout when given "-" instead.
+func jsonOutput(goos string) string {
+	if goos == "windows" {
+		return "json=-"
+	}
+	return "json=/dev/stdout"
+}
+
 // buildStagesString creates a stages configuration string for k6.
 func buildStagesString(stages []Stage) string {
 	var stageStrings []string
@@ -533,132 +869,163 @@ func buildStagesString(stages []Stage) string {
 	return strings.Join(stageStrings, ",")
 }
 
-// executeCommand executes a command and returns stdout, stderr, exit code, and error.
-func executeCommand(cmd *exec.Cmd) (stdout, stderr string, exitCode int, err error) {
-	var stdoutBuf, stderrBuf strings.Builder
-	cmd.Stdout = &stdoutBuf
-	cmd.Stderr = &stderrBuf
-
-	err = cmd.Run()
-	stdout = stdoutBuf.String()
-	stderr = stderrBuf.String()
-
-	if err != nil {
-		var exitError *exec.ExitError
-		if errors.As(err, &exitError) {
-			exitCode = exitError.ExitCode()
-		} else {
-			exitCode = -1
-		}
-	}
+// summaryCollector accumulates k6 JSON metrics into a test summary, one metric at a time.
+type summaryCollector struct {
+	httpReqs          int
+	httpFailures      int
+	serverErrors      int
+	iterations        int
+	droppedIterations int
+	dataReceived      float64
+	dataSent          float64
+	iterationDuration *trendDigest
+	responseTimes     *trendDigest
+	grpcResponseTimes *trendDigest
+	webVitals         map[string]*trendDigest
+	protocols         protocolCollector
+
+	// scenarios holds the collectors of each scenario; it is nil in those collectors.
+	scenarios map[string]*summaryCollector
+}
 
-	if err != nil {
-		return stdout, stderr, exitCode, fmt.Errorf("command execution failed: %w", err)
+// newSummaryCollector returns an empty summary collector.
+func newSummaryCollector() *summaryCollector {
+	return &summaryCollector{
+		responseTimes:     newTrendDigest(),
+		grpcResponseTimes: newTrendDigest(),
+		iterationDuration: newTrendDigest(),
+		webVitals:         make(map[string]*trendDigest),
+		scenarios:         make(map[string]*summaryCollector),
 	}
-	return stdout, stderr, exitCode, nil
 }
 
-// parseK6Output parses k6 output to extract metrics and summary information.
-func parseK6Output(output string) (map[string]interface{}, TestSummary) {
-	metrics := make(map[string]interface{})
-	summary := TestSummary{}
-
-	// Split output into lines and parse JSON metrics
-	lines := strings.Split(output, "\n")
-	var jsonMetrics []map[string]interface{}
-
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" {
-			continue
-		}
-
-		// Try to parse as JSON metric
-		var metric map[string]interface{}
-		if err := json.Unmarshal([]byte(line), &metric); err == nil {
-			jsonMetrics = append(jsonMetrics, metric)
-		}
+// add records a k6 JSON metric line.
+func (c *summaryCollector) add(metric map[string]interface{}) {
+	if metricType, ok := metric["type"].(string); !ok || metricType != "Point" {
+		return
 	}
-
-	// Extract summary metrics from JSON output
-	if len(jsonMetrics) > 0 {
-		summary = extractSummaryFromMetrics(jsonMetrics)
-		metrics["raw_metrics"] = jsonMetrics
-		metrics["metrics_count"] = len(jsonMetrics)
+	metricName, ok := metric["metric"].(string)
+	if !ok {
+		return
 	}
 
-	return metrics, summary
-}
-
-// extractSummaryFromMetrics extracts test summary information from k6 JSON metrics.
-func extractSummaryFromMetrics(jsonMetrics []map[string]interface{}) TestSummary {
-	summary := TestSummary{}
+	if c.scenarios != nil {
+		c.addScenario(metric)
+	}
 
-	// Count HTTP requests and calculate statistics
-	httpReqs := 0
-	httpFailures := 0
-	var responseTimes []float64
-
-	for _, metric := range jsonMetrics {
-		if metricType, ok := metric["type"].(string); ok && metricType == "Point" {
-			if metricName, ok := metric["metric"].(string); ok {
-				switch metricName {
-				case "http_reqs":
-					httpReqs++
-				case "http_req_failed":
-					if value, ok := metric["data"].(map[string]interface{}); ok {
-						if failed, ok := value["value"].(float64); ok && failed > 0 {
-							httpFailures++
-						}
-					}
-				case "http_req_duration":
-					if value, ok := metric["data"].(map[string]interface{}); ok {
-						if duration, ok := value["value"].(float64); ok {
-							responseTimes = append(responseTimes, duration)
-						}
-					}
+	switch metricName {
+	case "http_reqs":
+		c.httpReqs++
+		if value, ok := metric["data"].(map[string]interface{}); ok {
+			if tags, ok := value["tags"].(map[string]interface{}); ok {
+				if status, ok := tags["status"].(string); ok && strings.HasPrefix(status, "5") {
+					c.serverErrors++
 				}
 			}
 		}
+	case "http_req_failed":
+		if value, ok := metric["data"].(map[string]interface{}); ok {
+			if failed, ok := value["value"].(float64); ok && failed > 0 {
+				c.httpFailures++
+			}
+		}
+	case "http_req_duration":
+		if value, ok := metric["data"].(map[string]interface{}); ok {
+			if duration, ok := value["value"].(float64); ok {
+				c.responseTimes.Add(duration)
+			}
+		}
+	case "data_received", "data_sent":
+		if value, ok := metric["data"].(map[string]interface{}); ok {
+			if size, ok := value["value"].(float64); ok {
+				if metricName == "data_received" {
+					c.dataReceived += size
+				} else {
+					c.dataSent += size
+				}
+			}
+		}
+	case "iterations":
+		c.iterations++
+	case "dropped_iterations":
+		if value, ok := metric["data"].(map[string]interface{}); ok {
+			if dropped, ok := value["value"].(float64); ok {
+				c.droppedIterations += int(dropped)
+			}
+		}
+	case "iteration_duration":
+		if value, ok := metric["data"].(map[string]interface{}); ok {
+			if duration, ok := value["value"].(float64); ok {
+				c.iterationDuration.Add(duration)
+			}
+		}
+	case "grpc_req_duration":
+		if value, ok := metric["data"].(map[string]interface{}); ok {
+			if duration, ok := value["value"].(float64); ok {
+				c.grpcResponseTimes.Add(duration)
+			}
+		}
+	default:
+		value, ok := metric["data"].(map[string]interface{})
+		if !ok {
+			break
+		}
+		v, ok := value["value"].(float64)
+		if !ok {
+			break
+		}
+		if strings.HasPrefix(metricName, browserWebVitalPrefix) {
+			digest, ok := c.webVitals[metricName]
+			if !ok {
+				digest = newTrendDigest()
+				c.webVitals[metricName] = digest
+			}
+			digest.Add(v)
+		} else {
+			c.protocols.add(metricName, v)
+		}
 	}
+}
 
-	summary.TotalRequests = httpReqs
-	summary.FailedRequests = httpFailures
+// summary returns the test summary of the metrics collected so far.
+func (c *summaryCollector) summary() TestSummary {
+	summary := TestSummary{}
 
-	// Calculate response time statistics
-	if len(responseTimes) > 0 {
-		summary.AvgResponseTime = calculateAverage(responseTimes)
-		summary.P95ResponseTime = calculatePercentile(responseTimes, P95Percentile)
+	summary.TotalRequests = c.httpReqs
+	summary.FailedRequests = c.httpFailures
+	summary.ServerErrors = c.serverErrors
+	summary.Iterations = c.iterations
+	summary.DataReceivedBytes = int64(c.dataReceived)
+	summary.DataSentBytes = int64(c.dataSent)
+	summary.DroppedIterations = c.droppedIterations
+	if c.iterationDuration.Count() > 0 {
+		summary.AvgIterationDuration = c.iterationDuration.Avg()
 	}
 
-	return summary
-}
-
-// calculateAverage calculates the average of a slice of float64 values.
-func calculateAverage(values []float64) float64 {
-	if len(values) == 0 {
-		return 0
+	// Calculate response time statistics
+	if c.responseTimes.Count() > 0 {
+		summary.AvgResponseTime = c.responseTimes.Avg()
+		summary.P95ResponseTime = c.responseTimes.Quantile(P95Percentile)
+		summary.MedResponseTime = c.responseTimes.Quantile(MedPercentile)
+		summary.P90ResponseTime = c.responseTimes.Quantile(P90Percentile)
+		summary.P99ResponseTime = c.responseTimes.Quantile(P99Percentile)
+		summary.MaxResponseTime = c.responseTimes.Max()
 	}
 
-	var sum float64
-	for _, v := range values {
-		sum += v
+	// Each grpc_req_duration point corresponds to a gRPC request
+	if c.grpcResponseTimes.Count() > 0 {
+		summary.GRPCRequests = c.grpcResponseTimes.Count()
+		summary.GRPCAvgResponseTime = c.grpcResponseTimes.Avg()
+		summary.GRPCP95ResponseTime = c.grpcResponseTimes.Quantile(P95Percentile)
+		summary.GRPCP99ResponseTime = c.grpcResponseTimes.Quantile(P99Percentile)
 	}
-	return sum / float64(len(values))
-}
 
-// calculatePercentile calculates the specified percentile of a slice of float64 values.
-func calculatePercentile(values []float64, percentile float64) float64 {
-	if len(values) == 0 {
-		return 0
-	}
+	summary.WebVitals = summarizeWebVitals(c.webVitals)
+	c.protocols.apply(&summary)
+	summary.Scenarios = c.scenarioSummaries()
+	formatSummary(&summary)
 
-	// Simple percentile calculation (would be more accurate with sorting)
-	index := int(float64(len(values)) * percentile)
-	if index >= len(values) {
-		index = len(values) - 1
-	}
-	return values[index]
+	return summary
 }
 
 // sanitizeRunOptions removes sensitive information from run options for logging
@@ -673,21 +1040,13 @@ func sanitizeRunOptions(options *RunOptions) interface{} {
 		"iterations":  options.Iterations,
 		"stages":      options.Stages,
 		"has_options": options.Options != nil,
+		"file_count":  len(options.Files),
+		"env_count":   len(options.Env),
+		"pacing":      options.Pacing,
+		"debug":       options.DebugResponses,
 	}
 }
 
-// getPathType returns a safe representation of file paths for logging
-func getPathType(path string) string {
-	if strings.Contains(path, "temp") || strings.Contains(path, "tmp") {
-		return "temporary"
-	} else if strings.HasSuffix(path, ".js") {
-		return "javascript"
-	} else if strings.HasSuffix(path, ".ts") {
-		return "typescript"
-	}
-	return "other"
-}
-
 // enhanceRunResult adds comprehensive analysis to the run result
 func enhanceRunResult(result *RunResult, options *RunOptions) {
 	if result == nil {
@@ -713,6 +1072,9 @@ func enhanceRunResult(result *RunResult, options *RunOptions) {
 	issues := identifyTestIssues(result, options)
 	result.Issues = append(result.Issues, issues...)
 
+	// Apply the server's warning thresholds, whatever the thresholds of the script
+	result.Warnings = CurrentWarningThresholds().Evaluate(result.Summary, result.Checks)
+
 	// Generate recommendations
 	result.Recommendations = generateRecommendations(result, options)
 
@@ -721,6 +1083,19 @@ func enhanceRunResult(result *RunResult, options *RunOptions) {
 
 	// Add workflow integration suggestions
 	addRunWorkflowIntegrationSuggestions(result, options)
+
+	localizeRunResult(result)
+}
+
+// localizeRunResult translates the suggestions, recommendations and next steps of the
+// result into the configured locale.
+func localizeRunResult(result *RunResult) {
+	for i := range result.Issues {
+		result.Issues[i].Suggestion = locale.T(result.Issues[i].Suggestion)
+	}
+	result.Performance.Recommendations = locale.List(result.Performance.Recommendations)
+	result.Recommendations = locale.List(result.Recommendations)
+	result.NextSteps = locale.List(result.NextSteps)
 }
 
 // analyzePerformance analyzes performance metrics and provides insights
@@ -914,6 +1289,9 @@ func generateTestAnalysis(result *RunResult) TestAnalysis {
 	// Calculate success rate
 	if result.Summary.TotalRequests > 0 {
 		analysis.SuccessRate = float64(result.Summary.TotalRequests-result.Summary.FailedRequests) / float64(result.Summary.TotalRequests) * 100
+	} else if hasNonHTTPActivity(result.Summary) {
+		// Runs exercising other protocols have no failed HTTP requests
+		analysis.SuccessRate = 100
 	} else {
 		analysis.SuccessRate = 0
 	}
@@ -1021,6 +1399,18 @@ func identifyTestIssues(result *RunResult, options *RunOptions) []TestIssue {
 		})
 	}
 
+	// Check scenarios whose errors the overall error rate hides
+	issues = append(issues, identifyScenarioIssues(result)...)
+
+	// Check whether the VUs kept up with the pacing
+	issues = append(issues, identifyPacingIssues(result, options)...)
+
+	// Check the thresholds and checks of the script
+	issues = append(issues, identifyOutcomeIssues(result)...)
+
+	// Report the environment issues found by the network diagnostics
+	issues = append(issues, networkIssues(result.NetworkDiagnostics)...)
+
 	return issues
 }
 
@@ -1171,7 +1561,9 @@ func generateRunNextSteps(result *RunResult) []string {
 	} else {
 		steps = append(steps, "Fix test execution issues before analyzing performance")
 
-		if result.ExitCode != 0 {
+		if step, ok := failureNextSteps[result.FailureClass]; ok {
+			steps = append(steps, step)
+		} else if result.ExitCode != 0 {
 			steps = append(steps, "Check k6 script syntax and target server availability")
 		}
 	}
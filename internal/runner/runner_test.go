@@ -0,0 +1,26 @@
+package runner
+
+import "testing"
+
+func TestJSONOutput(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		goos string
+		want string
+	}{
+		{goos: "linux", want: "json=/dev/stdout"},
+		{goos: "darwin", want: "json=/dev/stdout"},
+		{goos: "windows", want: "json=-"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.goos, func(t *testing.T) {
+			t.Parallel()
+
+			if got := jsonOutput(tt.goos); got != tt.want {
+				t.Errorf("jsonOutput(%q) = %q, want %q", tt.goos, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package runner
+
+import (
+	"fmt"
+	"sort"
+)
+
+const (
+	// MaxScenarioBreakdowns is the maximum number of scenarios the summary is split by.
+	// Metrics of further scenarios only count in the overall summary.
+	MaxScenarioBreakdowns = 20
+	// scenarioErrorRateThreshold is the error rate, in percent, above which a scenario is
+	// reported as an issue.
+	scenarioErrorRateThreshold = 5.0
+)
+
+// scenarioTag returns the scenario a k6 JSON metric point was emitted by, if any.
+func scenarioTag(metric map[string]interface{}) string {
+	data, ok := metric["data"].(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	tags, ok := data["tags"].(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	scenario, _ := tags["scenario"].(string)
+	return scenario
+}
+
+// addScenario records a metric point in the summary of its scenario.
+func (c *summaryCollector) addScenario(metric map[string]interface{}) {
+	scenario := scenarioTag(metric)
+	if scenario == "" {
+		return
+	}
+
+	collector, ok := c.scenarios[scenario]
+	if !ok {
+		if len(c.scenarios) >= MaxScenarioBreakdowns {
+			return
+		}
+		collector = newSummaryCollector()
+		collector.scenarios = nil
+		c.scenarios[scenario] = collector
+	}
+
+	collector.add(metric)
+}
+
+// scenarioSummaries returns the summaries of the scenarios, when more than one ran: a
+// single scenario's summary is the overall one.
+func (c *summaryCollector) scenarioSummaries() map[string]TestSummary {
+	if len(c.scenarios) < 2 {
+		return nil
+	}
+
+	summaries := make(map[string]TestSummary, len(c.scenarios))
+	for name, collector := range c.scenarios {
+		summaries[name] = collector.summary()
+	}
+	return summaries
+}
+
+// identifyScenarioIssues reports scenarios whose error rate is high, which the overall
+// error rate of mixed workloads can hide.
+func identifyScenarioIssues(result *RunResult) []TestIssue {
+	names := make([]string, 0, len(result.Summary.Scenarios))
+	for name := range result.Summary.Scenarios {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var issues []TestIssue
+	for _, name := range names {
+		scenario := result.Summary.Scenarios[name]
+		if scenario.TotalRequests == 0 {
+			continue
+		}
+
+		errorRate := float64(scenario.FailedRequests) / float64(scenario.TotalRequests) * 100
+		if errorRate > scenarioErrorRateThreshold {
+			issues = append(issues, TestIssue{
+				Type:       "error",
+				Severity:   "high",
+				Message:    fmt.Sprintf("High error rate in scenario %q: %.1f%%", name, errorRate),
+				Suggestion: "Investigate the requests of this scenario; see summary.scenarios for its own statistics.",
+				Value:      errorRate,
+				Threshold:  scenarioErrorRateThreshold,
+				Count:      scenario.FailedRequests,
+			})
+		}
+	}
+
+	return issues
+}
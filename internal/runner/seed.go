@@ -0,0 +1,78 @@
+package runner
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+const (
+	// MaxSeed is the largest seed of seeded runs.
+	MaxSeed = math.MaxUint32
+
+	// SeedEnvName is the environment variable exposing the seed of seeded runs to the
+	// script, for scripts generating their own random data.
+	SeedEnvName = "K6_MCP_SEED"
+
+	// seedModuleName is the name of the module seeding Math.random in the workspace of
+	// seeded runs, imported by the script before its own imports.
+	seedModuleName = ".k6-mcp-seed.js"
+)
+
+// seedModule replaces Math.random with a mulberry32 generator seeded with the seed and the
+// VU number, so that each VU draws the same sequence on every run with the seed. The
+// module is evaluated in the init context of each VU, before the modules of the script.
+const seedModule = `// Seeds Math.random for a reproducible run (k6-mcp).
+const seed = (%d ^ Math.imul(__VU, 0x9e3779b1)) >>> 0;
+let state = seed;
+Math.random = function () {
+  state = (state + 0x6d2b79f5) | 0;
+  let t = Math.imul(state ^ (state >>> 15), 1 | state);
+  t = (t + Math.imul(t ^ (t >>> 7), 61 | t)) ^ t;
+  return ((t ^ (t >>> 14)) >>> 0) / 4294967296;
+};
+`
+
+// validateSeed checks that the seed, if any, is within the seed range.
+func validateSeed(seed *int64) error {
+	if seed != nil && (*seed < 0 || *seed > MaxSeed) {
+		return &RunError{
+			Type:    "PARAMETER_VALIDATION",
+			Message: fmt.Sprintf("seed must be an integer between 0 and %d", int64(MaxSeed)),
+		}
+	}
+	return nil
+}
+
+// seedScript returns the script and files of seeded runs: the script imports the module
+// seeding Math.random first, on its first line so that the line numbers of errors are
+// unchanged, and the files hold the module. Unseeded runs are returned as is.
+func seedScript(script string, files map[string]string, options *RunOptions) (string, map[string]string) {
+	if options == nil || options.Seed == nil {
+		return script, files
+	}
+
+	seeded := make(map[string]string, len(files)+1)
+	for name, content := range files {
+		seeded[name] = content
+	}
+	seeded[seedModuleName] = fmt.Sprintf(seedModule, *options.Seed)
+
+	return "import './" + seedModuleName + "'; " + script, seeded
+}
+
+// runEnv returns the environment variables exposed to the script with --env: those of the
+// options, and the seed of seeded runs.
+func runEnv(options *RunOptions) map[string]string {
+	if options.Seed == nil {
+		return options.Env
+	}
+
+	env := make(map[string]string, len(options.Env)+1)
+	for name, value := range options.Env {
+		env[name] = value
+	}
+	env[SeedEnvName] = strconv.FormatInt(*options.Seed, 10)
+
+	return env
+}
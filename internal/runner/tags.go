@@ -0,0 +1,135 @@
+package runner
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/oleiade/k6-mcp/internal/buildinfo"
+)
+
+const (
+	// MaxTags is the maximum number of user tags of a run.
+	MaxTags = 20
+	// MaxTagValueBytes is the maximum size of a tag value.
+	MaxTagValueBytes = 256
+
+	// TagTestName, TagScriptHash, TagEnvironment and TagServerVersion are the tags every
+	// run's metrics carry, so that exported metrics can be attributed to the test, the
+	// version of its script, the environment and the server that ran it.
+	TagTestName      = "test_name"
+	TagScriptHash    = "script_sha256"
+	TagEnvironment   = "environment"
+	TagServerVersion = "k6_mcp_version"
+
+	// scriptHashTagLength is the number of hexadecimal digits of the script hash tag, a
+	// prefix of the script hash of the run history.
+	scriptHashTagLength = 12
+)
+
+// tagNamePattern matches valid tag names.
+var tagNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*$`)
+
+// systemTags are the tags k6 sets on metrics itself; user tags cannot replace them.
+var systemTags = map[string]bool{
+	"proto": true, "subproto": true, "status": true, "method": true, "url": true,
+	"name": true, "group": true, "check": true, "error": true, "error_code": true,
+	"tls_version": true, "scenario": true, "service": true, "expected_response": true,
+	"vu": true, "iter": true, "ip": true, "ocsp_status": true,
+}
+
+// ValidateTags validates user tags of the metrics of a run.
+func ValidateTags(tags map[string]string) error {
+	if len(tags) > MaxTags {
+		return &RunError{
+			Type:    "PARAMETER_VALIDATION",
+			Message: fmt.Sprintf("cannot pass more than %d tags", MaxTags),
+		}
+	}
+
+	for name, value := range tags {
+		if !tagNamePattern.MatchString(name) {
+			return &RunError{
+				Type:    "PARAMETER_VALIDATION",
+				Message: fmt.Sprintf("invalid tag name %q: use letters, digits, underscores, dots and dashes", name),
+			}
+		}
+		if systemTags[name] {
+			return &RunError{
+				Type:    "PARAMETER_VALIDATION",
+				Message: fmt.Sprintf("tag %s is set by k6 itself and cannot be overridden", name),
+			}
+		}
+		if err := validateTagValue("tag "+name, value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateTagValue checks that value, named what in errors, can be passed with --tag.
+func validateTagValue(what, value string) error {
+	if len(value) > MaxTagValueBytes {
+		return &RunError{
+			Type:    "PARAMETER_VALIDATION",
+			Message: fmt.Sprintf("%s exceeds %d bytes", what, MaxTagValueBytes),
+		}
+	}
+	if strings.ContainsRune(value, 0) {
+		return &RunError{
+			Type:    "PARAMETER_VALIDATION",
+			Message: what + " contains a NUL character",
+		}
+	}
+	return nil
+}
+
+// withRunTags returns a copy of the options whose tags are the automatic tags of the run
+// (test name, script hash, environment and server version) overridden by the user tags.
+func withRunTags(script string, options *RunOptions) *RunOptions {
+	tagged := &RunOptions{}
+	if options != nil {
+		*tagged = *options
+	}
+
+	sum := sha256.Sum256([]byte(script))
+	tags := map[string]string{
+		TagScriptHash:    hex.EncodeToString(sum[:])[:scriptHashTagLength],
+		TagServerVersion: buildinfo.Version,
+	}
+	if tagged.TestName != "" {
+		tags[TagTestName] = tagged.TestName
+	}
+	if tagged.Environment != "" {
+		tags[TagEnvironment] = tagged.Environment
+	}
+	for name, value := range tagged.Tags {
+		tags[name] = value
+	}
+	tagged.Tags = tags
+
+	return tagged
+}
+
+// tagArgs returns the --tag flags tagging all the metrics of the run, sorted by name.
+func tagArgs(options *RunOptions) []string {
+	if options == nil {
+		return nil
+	}
+
+	names := make([]string, 0, len(options.Tags))
+	for name := range options.Tags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	args := make([]string, 0, 2*len(names))
+	for _, name := range names {
+		args = append(args, "--tag", name+"="+options.Tags[name])
+	}
+	return args
+}
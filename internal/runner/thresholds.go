@@ -0,0 +1,397 @@
+package runner
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/oleiade/k6-mcp/internal/fuzzy"
+)
+
+// Types of k6 metrics, which decide the aggregation methods of their thresholds.
+const (
+	MetricCounter = "counter"
+	MetricGauge   = "gauge"
+	MetricRate    = "rate"
+	MetricTrend   = "trend"
+)
+
+// Severities of threshold issues: errors are rejected by k6, warnings are likely mistakes.
+const (
+	ThresholdIssueError   = "error"
+	ThresholdIssueWarning = "warning"
+)
+
+// BuiltinMetrics are the types of the metrics k6 and its modules emit, keyed by name.
+var BuiltinMetrics = map[string]string{
+	"checks":                     MetricRate,
+	"data_received":              MetricCounter,
+	"data_sent":                  MetricCounter,
+	"dropped_iterations":         MetricCounter,
+	"iteration_duration":         MetricTrend,
+	"iterations":                 MetricCounter,
+	"vus":                        MetricGauge,
+	"vus_max":                    MetricGauge,
+	"group_duration":             MetricTrend,
+	"http_req_blocked":           MetricTrend,
+	"http_req_connecting":        MetricTrend,
+	"http_req_duration":          MetricTrend,
+	"http_req_failed":            MetricRate,
+	"http_req_receiving":         MetricTrend,
+	"http_req_sending":           MetricTrend,
+	"http_req_tls_handshaking":   MetricTrend,
+	"http_req_waiting":           MetricTrend,
+	"http_reqs":                  MetricCounter,
+	"grpc_req_duration":          MetricTrend,
+	"grpc_streams":               MetricCounter,
+	"grpc_streams_msgs_received": MetricCounter,
+	"grpc_streams_msgs_sent":     MetricCounter,
+	"ws_connecting":              MetricTrend,
+	"ws_msgs_received":           MetricCounter,
+	"ws_msgs_sent":               MetricCounter,
+	"ws_ping":                    MetricTrend,
+	"ws_session_duration":        MetricTrend,
+	"ws_sessions":                MetricCounter,
+	"browser_data_received":      MetricCounter,
+	"browser_data_sent":          MetricCounter,
+	"browser_http_req_duration":  MetricTrend,
+	"browser_http_req_failed":    MetricRate,
+	"browser_web_vital_cls":      MetricTrend,
+	"browser_web_vital_fcp":      MetricTrend,
+	"browser_web_vital_fid":      MetricTrend,
+	"browser_web_vital_inp":      MetricTrend,
+	"browser_web_vital_lcp":      MetricTrend,
+	"browser_web_vital_ttfb":     MetricTrend,
+}
+
+// thresholdAggregations are the aggregation methods of each type of metric, except the
+// percentiles of trends, p(N).
+var thresholdAggregations = map[string][]string{
+	MetricCounter: {"count", "rate"},
+	MetricGauge:   {"value"},
+	MetricRate:    {"rate"},
+	MetricTrend:   {"avg", "min", "max", "med"},
+}
+
+// aggregationTypos maps the aggregation methods agents commonly write to the k6 ones.
+var aggregationTypos = map[string]string{
+	"average": "avg", "mean": "avg", "median": "med", "minimum": "min", "maximum": "max",
+	"total": "count", "counter": "count", "sum": "count", "error_rate": "rate", "fail_rate": "rate",
+	"failure_rate": "rate", "rates": "rate", "percent": "rate",
+}
+
+var (
+	thresholdExpressionPattern = regexp.MustCompile(`^\s*([A-Za-z_][A-Za-z0-9_.]*)(?:\(\s*([^)]*?)\s*\))?\s*(===|==|!=|>=|<=|>|<|=)\s*(\S+?)\s*$`)
+	shortPercentilePattern     = regexp.MustCompile(`^[pP](\d+(?:\.\d+)?)$`)
+	thresholdValueUnitPattern  = regexp.MustCompile(`^(-?\d+(?:\.\d+)?)(ms|s|m|%)$`)
+	customMetricPattern        = regexp.MustCompile(`\bnew\s+(Counter|Gauge|Rate|Trend)\s*\(\s*['"]([A-Za-z_][A-Za-z0-9_]*)['"]`)
+	thresholdsBlockPattern     = regexp.MustCompile(`\bthresholds\s*:\s*\{`)
+	thresholdEntryPattern      = regexp.MustCompile(`(?s)(?:['"]([^'"]+)['"]|([A-Za-z_][A-Za-z0-9_]*))\s*:\s*(\[|['"])`)
+	thresholdObjectPattern     = regexp.MustCompile(`\bthreshold\s*:\s*['"]([^'"]*)['"]`)
+	objectLiteralPattern       = regexp.MustCompile(`\{[^{}]*\}`)
+	stringLiteralPattern       = regexp.MustCompile(`'([^']*)'|"([^"]*)"`)
+	tagFilterPattern           = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*:[^,]+$`)
+)
+
+// ThresholdExpression is a parsed threshold expression, e.g. p(95)<500.
+type ThresholdExpression struct {
+	Aggregation string  `json:"aggregation"`
+	Percentile  float64 `json:"percentile,omitempty"`
+	Operator    string  `json:"operator"`
+	Value       float64 `json:"value"`
+}
+
+// ThresholdIssue is a problem of a threshold.
+type ThresholdIssue struct {
+	Metric     string `json:"metric"`
+	Expression string `json:"expression,omitempty"`
+	Severity   string `json:"severity"`
+	Message    string `json:"message"`
+	// Suggestion is the corrected expression or metric, when the mistake is a known one.
+	Suggestion string `json:"suggestion,omitempty"`
+}
+
+// ParseThresholdExpression parses a threshold expression: an aggregation method, such as
+// avg, rate or p(95), an operator and a number.
+func ParseThresholdExpression(expression string) (*ThresholdExpression, *ThresholdIssue) {
+	m := thresholdExpressionPattern.FindStringSubmatch(expression)
+	if m == nil {
+		return nil, &ThresholdIssue{
+			Expression: expression,
+			Severity:   ThresholdIssueError,
+			Message:    "not a threshold expression: expected an aggregation method, an operator and a number, e.g. 'p(95)<500' or 'rate<0.01'",
+		}
+	}
+	expression = strings.TrimSpace(expression)
+	aggregation, argument, operator, value := m[1], m[2], m[3], m[4]
+
+	parsed := &ThresholdExpression{Aggregation: aggregation, Operator: operator}
+	suggested := func(aggregation string) string {
+		return aggregation + operator + value
+	}
+
+	switch {
+	case aggregation == "p":
+		percentile, err := strconv.ParseFloat(strings.TrimSuffix(argument, "%"), 64)
+		if err != nil || percentile < 0 || percentile > 100 {
+			return nil, &ThresholdIssue{Expression: expression, Severity: ThresholdIssueError, Message: fmt.Sprintf("invalid percentile %q: expected a number between 0 and 100, e.g. p(95) or p(99.9)", argument)}
+		}
+		if strings.HasSuffix(argument, "%") {
+			return nil, &ThresholdIssue{Expression: expression, Severity: ThresholdIssueError, Message: "percentiles are plain numbers", Suggestion: fmt.Sprintf("p(%s)%s%s", strings.TrimSuffix(argument, "%"), operator, value)}
+		}
+		parsed.Percentile = percentile
+	case shortPercentilePattern.MatchString(aggregation):
+		percentile := shortPercentilePattern.FindStringSubmatch(aggregation)[1]
+		return nil, &ThresholdIssue{Expression: expression, Severity: ThresholdIssueError, Message: fmt.Sprintf("%s is not an aggregation method: percentiles are written p(N)", aggregation), Suggestion: suggested("p(" + percentile + ")")}
+	case aggregation == "P":
+		return nil, &ThresholdIssue{Expression: expression, Severity: ThresholdIssueError, Message: "aggregation methods are lowercase", Suggestion: suggested("p(" + argument + ")")}
+	case argument != "" || strings.Contains(expression, "("):
+		return nil, &ThresholdIssue{Expression: expression, Severity: ThresholdIssueError, Message: fmt.Sprintf("only percentiles take an argument, not %s", aggregation)}
+	case isAggregation(aggregation):
+		// A valid method, whose type is checked against the metric by CheckThresholds
+	case aggregationTypos[strings.ToLower(aggregation)] != "":
+		return nil, &ThresholdIssue{Expression: expression, Severity: ThresholdIssueError, Message: fmt.Sprintf("%s is not an aggregation method", aggregation), Suggestion: suggested(aggregationTypos[strings.ToLower(aggregation)])}
+	case isAggregation(strings.ToLower(aggregation)):
+		return nil, &ThresholdIssue{Expression: expression, Severity: ThresholdIssueError, Message: "aggregation methods are lowercase", Suggestion: suggested(strings.ToLower(aggregation))}
+	default:
+		return nil, &ThresholdIssue{Expression: expression, Severity: ThresholdIssueError, Message: fmt.Sprintf("unknown aggregation method %q: use count, rate, value, avg, min, max, med or p(N)", aggregation)}
+	}
+
+	if operator == "=" {
+		return nil, &ThresholdIssue{Expression: expression, Severity: ThresholdIssueError, Message: "= is not a comparison operator", Suggestion: strings.Replace(expression, "=", "==", 1)}
+	}
+
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		issue := &ThresholdIssue{Expression: expression, Severity: ThresholdIssueError, Message: fmt.Sprintf("%q is not a number", value)}
+		if m := thresholdValueUnitPattern.FindStringSubmatch(value); m != nil {
+			issue.Message = "threshold values are plain numbers, in milliseconds for durations and fractions for rates"
+			number, _ := strconv.ParseFloat(m[1], 64)
+			switch m[2] {
+			case "s":
+				number *= 1000
+			case "m":
+				number *= 60000
+			case "%":
+				number /= 100
+			}
+			issue.Suggestion = strings.TrimSuffix(expression, value) + strconv.FormatFloat(number, 'f', -1, 64)
+		}
+		return nil, issue
+	}
+	parsed.Value = number
+
+	return parsed, nil
+}
+
+// isAggregation reports whether name is an aggregation method, other than p(N).
+func isAggregation(name string) bool {
+	for _, aggregations := range thresholdAggregations {
+		for _, aggregation := range aggregations {
+			if aggregation == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// CheckThresholds checks thresholds, keyed by metric name, against the types of the
+// built-in metrics and of the custom metrics, keyed by name. Metrics of unknown types are
+// only checked for syntax.
+func CheckThresholds(thresholds map[string][]string, custom map[string]string) []ThresholdIssue {
+	metrics := make([]string, 0, len(thresholds))
+	for metric := range thresholds {
+		metrics = append(metrics, metric)
+	}
+	sort.Strings(metrics)
+
+	var issues []ThresholdIssue
+	for _, metric := range metrics {
+		name, filter, hasFilter := strings.Cut(metric, "{")
+		if !thresholdMetricPattern.MatchString(metric) {
+			issues = append(issues, ThresholdIssue{Metric: metric, Severity: ThresholdIssueError, Message: "invalid metric: expected a metric name, optionally with a tag filter, e.g. http_req_duration{scenario:checkout}"})
+			continue
+		}
+		if hasFilter {
+			for _, tag := range strings.Split(strings.TrimSuffix(filter, "}"), ",") {
+				if !tagFilterPattern.MatchString(strings.TrimSpace(tag)) {
+					issues = append(issues, ThresholdIssue{Metric: metric, Severity: ThresholdIssueError, Message: fmt.Sprintf("invalid tag filter %q: tag filters are written name:value, e.g. {status:200}", tag), Suggestion: name + "{" + strings.Replace(strings.TrimSpace(tag), "=", ":", 1) + "}"})
+				}
+			}
+		}
+
+		metricType, known := BuiltinMetrics[name]
+		if customType, ok := custom[name]; ok {
+			metricType, known = customType, true
+		}
+		if !known {
+			issue := ThresholdIssue{Metric: metric, Severity: ThresholdIssueWarning, Message: fmt.Sprintf("%s is neither a built-in metric nor a custom metric of the script: k6 fails on thresholds of metrics no script declares", name)}
+			if closest := closestMetric(name); closest != "" {
+				issue.Suggestion = strings.Replace(metric, name, closest, 1)
+			}
+			issues = append(issues, issue)
+		}
+
+		for _, expression := range thresholds[metric] {
+			parsed, issue := ParseThresholdExpression(expression)
+			if issue != nil {
+				issue.Metric = metric
+				issues = append(issues, *issue)
+				continue
+			}
+			if !known {
+				continue
+			}
+			if issue := checkAggregation(metricType, parsed); issue != nil {
+				issue.Metric, issue.Expression = metric, expression
+				issues = append(issues, *issue)
+			}
+		}
+	}
+
+	return issues
+}
+
+// checkAggregation checks that the aggregation method of the expression applies to the
+// type of its metric, and that rates are fractions.
+func checkAggregation(metricType string, expression *ThresholdExpression) *ThresholdIssue {
+	allowed := thresholdAggregations[metricType]
+	supported := expression.Aggregation == "p" && metricType == MetricTrend
+	for _, aggregation := range allowed {
+		supported = supported || aggregation == expression.Aggregation
+	}
+	if !supported {
+		methods := strings.Join(allowed, ", ")
+		if metricType == MetricTrend {
+			methods += " and p(N)"
+		}
+		aggregation := expression.Aggregation
+		if aggregation == "p" {
+			aggregation = fmt.Sprintf("p(%g)", expression.Percentile)
+		}
+		issue := &ThresholdIssue{Severity: ThresholdIssueError, Message: fmt.Sprintf("%s is not an aggregation method of %s metrics, whose methods are %s", aggregation, metricType, methods)}
+		if metricType == MetricRate {
+			issue.Suggestion = fmt.Sprintf("rate%s%g", expression.Operator, expression.Value)
+		}
+		return issue
+	}
+
+	if metricType == MetricRate && (expression.Value < 0 || expression.Value > 1) {
+		return &ThresholdIssue{Severity: ThresholdIssueWarning, Message: fmt.Sprintf("rates are fractions between 0 and 1, so %g is likely a percentage", expression.Value), Suggestion: fmt.Sprintf("rate%s%g", expression.Operator, expression.Value/100)}
+	}
+
+	return nil
+}
+
+// closestMetric returns the built-in metric whose name is closest to name, within two
+// edits, or "".
+func closestMetric(name string) string {
+	closest, best := "", 3
+	for metric := range BuiltinMetrics {
+		if d := fuzzy.EditDistance(name, metric); d < best || (d == best && metric < closest) {
+			closest, best = metric, d
+		}
+	}
+	return closest
+}
+
+// ScriptThresholds returns the thresholds of the options of the script, keyed by metric,
+// and the custom metrics it declares, keyed by name, with their types. Thresholds are read
+// from their string literals: expressions computed at runtime are left out.
+func ScriptThresholds(script string) (map[string][]string, map[string]string) {
+	custom := make(map[string]string)
+	for _, m := range customMetricPattern.FindAllStringSubmatch(script, -1) {
+		custom[m[2]] = strings.ToLower(m[1])
+	}
+
+	thresholds := make(map[string][]string)
+	loc := thresholdsBlockPattern.FindStringIndex(script)
+	if loc == nil {
+		return thresholds, custom
+	}
+	block := script[loc[1]:]
+	if end := matchingBrace(block); end >= 0 {
+		block = block[:end]
+	}
+
+	for offset := 0; offset < len(block); {
+		m := thresholdEntryPattern.FindStringSubmatchIndex(block[offset:])
+		if m == nil {
+			break
+		}
+		var metric string
+		if m[2] >= 0 {
+			metric = block[offset+m[2] : offset+m[3]]
+		} else {
+			metric = block[offset+m[4] : offset+m[5]]
+		}
+
+		// The value is an array of expressions or objects, or a single expression
+		start := offset + m[6]
+		var value string
+		if block[start] == '[' {
+			end := matchingBracket(block[start+1:])
+			if end < 0 {
+				break
+			}
+			value = block[start+1 : start+1+end]
+			offset = start + 1 + end + 1
+		} else {
+			quote := block[start]
+			end := strings.IndexByte(block[start+1:], quote)
+			if end < 0 {
+				break
+			}
+			value = block[start : start+end+2]
+			offset = start + end + 2
+		}
+
+		// Objects hold their expression in their threshold field, next to abortOnFail
+		for _, object := range objectLiteralPattern.FindAllString(value, -1) {
+			if m := thresholdObjectPattern.FindStringSubmatch(object); m != nil {
+				thresholds[metric] = append(thresholds[metric], m[1])
+			}
+		}
+		value = objectLiteralPattern.ReplaceAllString(value, "")
+		for _, literal := range stringLiteralPattern.FindAllStringSubmatch(value, -1) {
+			thresholds[metric] = append(thresholds[metric], literal[1]+literal[2])
+		}
+	}
+
+	return thresholds, custom
+}
+
+// matchingBrace returns the offset of the } closing the block s starts within, or -1.
+func matchingBrace(s string) int {
+	return matchingDelimiter(s, '{', '}')
+}
+
+// matchingBracket returns the offset of the ] closing the array s starts within, or -1.
+func matchingBracket(s string) int {
+	return matchingDelimiter(s, '[', ']')
+}
+
+func matchingDelimiter(s string, open, closing byte) int {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '\'', '"', '`':
+			for i++; i < len(s) && s[i] != c; i++ {
+				if s[i] == '\\' {
+					i++
+				}
+			}
+		case open:
+			depth++
+		case closing:
+			if depth == 0 {
+				return i
+			}
+			depth--
+		}
+	}
+	return -1
+}
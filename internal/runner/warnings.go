@@ -0,0 +1,236 @@
+package runner
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Metrics of the summary warning thresholds apply to.
+const (
+	WarningErrorRate       = "error_rate"
+	WarningP95ResponseTime = "p95_response_time_ms"
+	WarningP99ResponseTime = "p99_response_time_ms"
+	WarningAvgResponseTime = "avg_response_time_ms"
+	WarningCheckFailures   = "check_failure_rate"
+)
+
+// WarningThresholds are limits applied to the summary of every run, independently of the
+// thresholds of the script, so that all tests get the same guardrails. Zero limits are
+// disabled. Rates are percentages, and response times milliseconds.
+type WarningThresholds struct {
+	ErrorRate        float64 `json:"error_rate,omitempty"`
+	P95ResponseTime  float64 `json:"p95_response_time_ms,omitempty"`
+	P99ResponseTime  float64 `json:"p99_response_time_ms,omitempty"`
+	AvgResponseTime  float64 `json:"avg_response_time_ms,omitempty"`
+	CheckFailureRate float64 `json:"check_failure_rate,omitempty"`
+}
+
+// SummaryWarning is a warning threshold a run summary exceeded.
+type SummaryWarning struct {
+	Metric string `json:"metric"`
+	// Scenario is the scenario whose summary exceeded the limit, or empty for the whole run.
+	Scenario string  `json:"scenario,omitempty"`
+	Value    float64 `json:"value"`
+	Limit    float64 `json:"limit"`
+	Message  string  `json:"message"`
+}
+
+// DefaultWarningThresholds returns the warning thresholds of servers that configure none:
+// more than 1% of failed requests, or a p95 response time above 1s.
+func DefaultWarningThresholds() WarningThresholds {
+	return WarningThresholds{ErrorRate: 1, P95ResponseTime: 1000}
+}
+
+var (
+	warningsMu        sync.RWMutex
+	warningThresholds = DefaultWarningThresholds()
+)
+
+// SetWarningThresholds sets the warning thresholds applied to the summary of runs.
+func SetWarningThresholds(thresholds WarningThresholds) {
+	warningsMu.Lock()
+	defer warningsMu.Unlock()
+	warningThresholds = thresholds
+}
+
+// CurrentWarningThresholds returns the configured warning thresholds, or the default ones.
+func CurrentWarningThresholds() WarningThresholds {
+	warningsMu.RLock()
+	defer warningsMu.RUnlock()
+	return warningThresholds
+}
+
+// ParseWarningThresholds parses a comma-separated list of limits, such as
+// "error_rate=1%,p95=1s,p99=2500", overriding the default thresholds. The metrics are
+// error_rate and check_failure_rate, in percent, and p95, p99 and avg, the response times,
+// in milliseconds unless they have a unit. A limit of 0 or off disables the metric, and
+// "none" disables every metric.
+func ParseWarningThresholds(spec string) (WarningThresholds, error) {
+	thresholds := DefaultWarningThresholds()
+	spec = strings.TrimSpace(spec)
+	if strings.EqualFold(spec, "none") {
+		return WarningThresholds{}, nil
+	}
+	if spec == "" {
+		return thresholds, nil
+	}
+
+	return parseWarningLimits(thresholds, spec)
+}
+
+// parseWarningLimits parses the comma-separated limits of the spec, overriding those of the
+// thresholds.
+func parseWarningLimits(thresholds WarningThresholds, spec string) (WarningThresholds, error) {
+	for _, entry := range strings.Split(spec, ",") {
+		key, value, found := strings.Cut(strings.TrimSpace(entry), "=")
+		key, value = strings.ToLower(strings.TrimSpace(key)), strings.TrimSpace(value)
+		if !found || value == "" {
+			return WarningThresholds{}, fmt.Errorf("invalid warning threshold %q: expected <metric>=<limit>, e.g. error_rate=1%% or p95=1s", entry)
+		}
+
+		var limit float64
+		var err error
+		switch key {
+		case "error_rate", "check_failure_rate":
+			limit, err = parseWarningRate(value)
+		case "p95", "p99", "avg":
+			limit, err = parseWarningDuration(value)
+		default:
+			return WarningThresholds{}, fmt.Errorf("unknown warning threshold metric %q: use error_rate, check_failure_rate, p95, p99 or avg", key)
+		}
+		if err != nil {
+			return WarningThresholds{}, fmt.Errorf("invalid %s warning threshold: %w", key, err)
+		}
+
+		switch key {
+		case "error_rate":
+			thresholds.ErrorRate = limit
+		case "check_failure_rate":
+			thresholds.CheckFailureRate = limit
+		case "p95":
+			thresholds.P95ResponseTime = limit
+		case "p99":
+			thresholds.P99ResponseTime = limit
+		case "avg":
+			thresholds.AvgResponseTime = limit
+		}
+	}
+
+	return thresholds, nil
+}
+
+// parseWarningRate parses a percentage, with or without its % sign.
+func parseWarningRate(value string) (float64, error) {
+	if strings.EqualFold(value, "off") {
+		return 0, nil
+	}
+	rate, err := strconv.ParseFloat(strings.TrimSuffix(value, "%"), 64)
+	if err != nil || rate < 0 || rate > 100 {
+		return 0, fmt.Errorf("%q is not a percentage between 0 and 100", value)
+	}
+	return rate, nil
+}
+
+// parseWarningDuration parses a duration, such as 1s or 250ms, or a number of
+// milliseconds, into milliseconds.
+func parseWarningDuration(value string) (float64, error) {
+	if strings.EqualFold(value, "off") {
+		return 0, nil
+	}
+	if ms, err := strconv.ParseFloat(value, 64); err == nil && ms >= 0 {
+		return ms, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("%q is not a duration, e.g. 1s or 250ms", value)
+	}
+	return float64(d) / float64(time.Millisecond), nil
+}
+
+// Evaluate returns the warnings of the summary, and of its scenarios, exceeding the
+// thresholds, or an empty list. Checks are those of the whole run.
+func (t WarningThresholds) Evaluate(summary TestSummary, checks []CheckOutcome) []SummaryWarning {
+	warnings := append([]SummaryWarning{}, t.evaluateSummary(summary, "")...)
+
+	names := make([]string, 0, len(summary.Scenarios))
+	for name := range summary.Scenarios {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		warnings = append(warnings, t.evaluateSummary(summary.Scenarios[name], name)...)
+	}
+
+	if t.CheckFailureRate > 0 {
+		passes, fails := 0, 0
+		for _, check := range checks {
+			passes += check.Passes
+			fails += check.Fails
+		}
+		if total := passes + fails; total > 0 {
+			rate := float64(fails) / float64(total) * 100
+			if rate > t.CheckFailureRate {
+				warnings = append(warnings, SummaryWarning{
+					Metric:  WarningCheckFailures,
+					Value:   rate,
+					Limit:   t.CheckFailureRate,
+					Message: fmt.Sprintf("%.2f%% of checks failed, above the %g%% warning threshold", rate, t.CheckFailureRate),
+				})
+			}
+		}
+	}
+
+	return warnings
+}
+
+// evaluateSummary returns the warnings of a summary, of the scenario of the name when set.
+func (t WarningThresholds) evaluateSummary(summary TestSummary, scenario string) []SummaryWarning {
+	if summary.TotalRequests == 0 {
+		return nil
+	}
+
+	subject := "the run"
+	if scenario != "" {
+		subject = fmt.Sprintf("scenario %q", scenario)
+	}
+
+	var warnings []SummaryWarning
+	if t.ErrorRate > 0 {
+		rate := float64(summary.FailedRequests) / float64(summary.TotalRequests) * 100
+		if rate > t.ErrorRate {
+			warnings = append(warnings, SummaryWarning{
+				Metric:   WarningErrorRate,
+				Scenario: scenario,
+				Value:    rate,
+				Limit:    t.ErrorRate,
+				Message:  fmt.Sprintf("The error rate of %s is %.2f%%, above the %g%% warning threshold", subject, rate, t.ErrorRate),
+			})
+		}
+	}
+
+	latencies := []struct {
+		metric, label string
+		value, limit  float64
+	}{
+		{WarningP95ResponseTime, "p95", summary.P95ResponseTime, t.P95ResponseTime},
+		{WarningP99ResponseTime, "p99", summary.P99ResponseTime, t.P99ResponseTime},
+		{WarningAvgResponseTime, "average", summary.AvgResponseTime, t.AvgResponseTime},
+	}
+	for _, latency := range latencies {
+		if latency.limit > 0 && latency.value > latency.limit {
+			warnings = append(warnings, SummaryWarning{
+				Metric:   latency.metric,
+				Scenario: scenario,
+				Value:    latency.value,
+				Limit:    latency.limit,
+				Message:  fmt.Sprintf("The %s response time of %s is %.0fms, above the %gms warning threshold", latency.label, subject, latency.value, latency.limit),
+			})
+		}
+	}
+
+	return warnings
+}
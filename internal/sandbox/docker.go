@@ -0,0 +1,166 @@
+package sandbox
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/oleiade/k6-mcp/internal/execx"
+)
+
+const (
+	// DefaultImage is the k6 image of container backends.
+	DefaultImage = "grafana/k6:latest"
+	// DefaultNetwork is the Docker network of containers.
+	DefaultNetwork = "host"
+
+	// removeTimeout bounds the removal of the containers of killed commands.
+	removeTimeout = 30 * time.Second
+)
+
+// dockerClientVariables are the variables of the server's environment the Docker client
+// needs to reach the daemon, which commands do not get.
+var dockerClientVariables = []string{
+	"PATH", "HOME", "DOCKER_HOST", "DOCKER_CONTEXT", "DOCKER_CONFIG", "DOCKER_CERT_PATH", "DOCKER_TLS_VERIFY", "XDG_RUNTIME_DIR",
+}
+
+// dockerSandbox runs commands in Docker containers, removed once they exit.
+type dockerSandbox struct {
+	name    string
+	docker  string
+	runtime string
+	cfg     Config
+}
+
+func newDockerSandbox(name, containerRuntime string, cfg Config) (Sandbox, error) {
+	if runtime.GOOS == "windows" {
+		// Mounts keep their host path in containers, which Windows paths cannot be
+		return nil, fmt.Errorf("the %s sandbox is not available on Windows: use the %s sandbox instead", name, BackendExec)
+	}
+
+	docker, err := exec.LookPath("docker")
+	if err != nil {
+		return nil, fmt.Errorf("the %s sandbox needs the docker command: %w", name, err)
+	}
+
+	if cfg.Image == "" {
+		cfg.Image = DefaultImage
+	}
+	if cfg.Network == "" {
+		cfg.Network = DefaultNetwork
+	}
+
+	return &dockerSandbox{name: name, docker: docker, runtime: containerRuntime, cfg: cfg}, nil
+}
+
+func (s *dockerSandbox) Name() string {
+	return s.name
+}
+
+func (s *dockerSandbox) Run(ctx context.Context, cmd Command, opts ...execx.Option) (*execx.Result, error) {
+	container := "k6-mcp-" + randomSuffix()
+
+	result, err := execx.Run(ctx, s.docker, s.args(container, cmd), commandOptions(cmd, s.clientEnv(cmd.Env), opts)...)
+	if errors.Is(err, execx.ErrTimeout) || errors.Is(err, execx.ErrCanceled) {
+		// Killing the client leaves the container running: remove it
+		removeCtx, cancel := context.WithTimeout(context.Background(), removeTimeout)
+		defer cancel()
+		_, _ = execx.Run(removeCtx, s.docker, []string{"rm", "--force", container}, execx.WithEnv(s.clientEnv(nil)))
+	}
+
+	return result, err
+}
+
+// args returns the arguments of the docker command running the command in a container
+// named container. The container runs as the user of the server, so that the files it
+// writes in its mounts belong to the server, without capabilities, on a read-only root
+// file system. The variables of the command are passed by name, so that their values,
+// such as secrets, do not appear in the arguments of the docker command.
+func (s *dockerSandbox) args(container string, cmd Command) []string {
+	args := []string{
+		"run", "--rm", "--interactive",
+		"--name", container,
+		"--network", s.cfg.Network,
+		"--cap-drop", "ALL",
+		"--security-opt", "no-new-privileges",
+		"--read-only",
+		"--tmpfs", "/tmp",
+		"--env", "HOME=/tmp",
+	}
+	if s.runtime != "" {
+		args = append(args, "--runtime", s.runtime)
+	}
+	if uid, gid := os.Getuid(), os.Getgid(); uid >= 0 {
+		args = append(args, "--user", strconv.Itoa(uid)+":"+strconv.Itoa(gid))
+	}
+
+	limits := s.cfg.Limits
+	if limits.MemoryBytes > 0 {
+		memory := strconv.FormatInt(limits.MemoryBytes, 10)
+		args = append(args, "--memory", memory, "--memory-swap", memory)
+	}
+	if limits.CPUs > 0 {
+		args = append(args, "--cpus", strconv.FormatFloat(limits.CPUs, 'f', -1, 64))
+	}
+	if limits.Processes > 0 {
+		args = append(args, "--pids-limit", strconv.Itoa(limits.Processes))
+	}
+	if limits.OpenFiles > 0 {
+		args = append(args, "--ulimit", fmt.Sprintf("nofile=%d:%d", limits.OpenFiles, limits.OpenFiles))
+	}
+
+	mounts := cmd.Mounts
+	if cmd.Dir != "" {
+		mounts = append([]string{cmd.Dir}, mounts...)
+		args = append(args, "--workdir", cmd.Dir)
+	}
+	mounted := map[string]bool{}
+	for _, dir := range mounts {
+		if !mounted[dir] {
+			mounted[dir] = true
+			args = append(args, "--volume", dir+":"+dir)
+		}
+	}
+
+	for _, variable := range cmd.Env {
+		name, _, _ := strings.Cut(variable, "=")
+		if name != "PATH" && name != "HOME" {
+			args = append(args, "--env", name)
+		}
+	}
+
+	return append(append(args, s.cfg.Image), cmd.Args...)
+}
+
+// clientEnv returns the environment of the docker command: the variables of the command,
+// which the container gets by name, and those the Docker client needs.
+func (s *dockerSandbox) clientEnv(env []string) []string {
+	client := make([]string, 0, len(env)+len(dockerClientVariables))
+	for _, variable := range env {
+		name, _, _ := strings.Cut(variable, "=")
+		if name != "PATH" && name != "HOME" {
+			client = append(client, variable)
+		}
+	}
+	for _, name := range dockerClientVariables {
+		if value, ok := os.LookupEnv(name); ok && value != "" {
+			client = append(client, name+"="+value)
+		}
+	}
+	return client
+}
+
+// randomSuffix returns a random suffix of container names.
+func randomSuffix() string {
+	b := make([]byte, 6)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
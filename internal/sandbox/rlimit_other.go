@@ -0,0 +1,10 @@
+//go:build !unix
+
+package sandbox
+
+import "fmt"
+
+// newRlimitSandbox fails on platforms without resource limits, such as Windows.
+func newRlimitSandbox(Config) (Sandbox, error) {
+	return nil, fmt.Errorf("the %s sandbox is only available on Unix systems: use the %s or %s sandbox instead", BackendRlimit, BackendExec, BackendDocker)
+}
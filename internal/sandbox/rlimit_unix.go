@@ -0,0 +1,49 @@
+//go:build unix
+
+package sandbox
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/oleiade/k6-mcp/internal/execx"
+)
+
+// rlimitShell applies the limits given as arguments before executing the command, so that
+// the limits apply to the command alone rather than to the server.
+const rlimitShell = "/bin/sh"
+
+// rlimitSandbox runs commands through a shell setting their resource limits.
+type rlimitSandbox struct {
+	limits Limits
+}
+
+func newRlimitSandbox(cfg Config) (Sandbox, error) {
+	return &rlimitSandbox{limits: cfg.Limits}, nil
+}
+
+func (s *rlimitSandbox) Name() string {
+	return BackendRlimit
+}
+
+func (s *rlimitSandbox) Run(ctx context.Context, cmd Command, opts ...execx.Option) (*execx.Result, error) {
+	args := append([]string{"-c", s.script(), "k6-sandbox", cmd.Path}, cmd.Args...)
+	return execx.Run(ctx, rlimitShell, args, commandOptions(cmd, cmd.Env, opts)...)
+}
+
+// script returns the shell script setting the limits, then replacing the shell with the
+// command given as its arguments. The data segment bounds the memory k6 allocates, unlike
+// the address space, which the Go runtime reserves generously.
+func (s *rlimitSandbox) script() string {
+	script := ""
+	if s.limits.MemoryBytes > 0 {
+		script += "ulimit -d " + strconv.FormatInt(max(s.limits.MemoryBytes/1024, 1), 10) + " && "
+	}
+	if s.limits.CPUSeconds > 0 {
+		script += "ulimit -t " + strconv.Itoa(s.limits.CPUSeconds) + " && "
+	}
+	if s.limits.OpenFiles > 0 {
+		script += "ulimit -n " + strconv.Itoa(s.limits.OpenFiles) + " && "
+	}
+	return script + `exec "$@"`
+}
@@ -0,0 +1,200 @@
+// Package sandbox runs the k6 processes the server spawns at the isolation level the server
+// is configured with: plain processes, processes bounded by resource limits, or containers.
+// The runner, the validator and the archiver run k6 through the Sandbox interface, so that
+// backends can be added, e.g. microVMs, without changing them.
+package sandbox
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/oleiade/k6-mcp/internal/execx"
+)
+
+// Backends of the sandboxes.
+const (
+	// BackendExec runs commands as plain processes, isolated by their minimal environment
+	// and process group only.
+	BackendExec = "exec"
+	// BackendRlimit runs commands as processes bounded by resource limits, on Unix systems.
+	BackendRlimit = "rlimit"
+	// BackendDocker runs commands in Docker containers of the k6 image.
+	BackendDocker = "docker"
+	// BackendGVisor runs commands in Docker containers of the gVisor runtime, runsc, which
+	// intercepts their system calls in a user-space kernel.
+	BackendGVisor = "gvisor"
+)
+
+// Command is a command to run in a sandbox.
+type Command struct {
+	// Path is the path of the k6 executable of the host. Container backends run the k6 of
+	// their image instead.
+	Path string
+	Args []string
+	// Env is the complete environment of the command.
+	Env []string
+	// Dir is the working directory of the command, if any.
+	Dir string
+	// Mounts are the host directories the command reads and writes, such as the directory
+	// of the script, which container backends share with it at the same path.
+	Mounts []string
+}
+
+// Limits bound the resources of sandboxed commands. Zero fields are not limited, and each
+// backend applies the limits it supports.
+type Limits struct {
+	// MemoryBytes bounds the memory of the command: its data segment with rlimit, and its
+	// memory with containers.
+	MemoryBytes int64 `json:"memory_bytes,omitempty"`
+	// CPUSeconds bounds the CPU time of the command, with rlimit.
+	CPUSeconds int `json:"cpu_seconds,omitempty"`
+	// CPUs bounds the number of CPUs containers use, e.g. 1.5.
+	CPUs float64 `json:"cpus,omitempty"`
+	// OpenFiles bounds the number of files, including sockets, the command opens.
+	OpenFiles int `json:"open_files,omitempty"`
+	// Processes bounds the number of processes and threads of containers.
+	Processes int `json:"processes,omitempty"`
+}
+
+// Config selects and configures the sandbox.
+type Config struct {
+	// Backend is the name of the backend, BackendExec by default.
+	Backend string
+	Limits  Limits
+	// Image is the k6 image of container backends, DefaultImage by default.
+	Image string
+	// Network is the Docker network of containers, DefaultNetwork by default: the host
+	// network lets runs reach local targets and serve their REST API on the loopback
+	// address.
+	Network string
+}
+
+// Sandbox runs commands at an isolation level.
+type Sandbox interface {
+	// Name returns the name of the backend of the sandbox.
+	Name() string
+	// Run runs the command in the sandbox, as execx.Run runs commands: the options
+	// configure its timeout and output, while its environment and working directory are
+	// those of the command.
+	Run(ctx context.Context, cmd Command, opts ...execx.Option) (*execx.Result, error)
+}
+
+// Factory creates the sandbox of a backend from the configuration, or returns an error
+// when the backend is unavailable, e.g. on this platform.
+type Factory func(cfg Config) (Sandbox, error)
+
+var (
+	mu       sync.RWMutex
+	backends = map[string]Factory{
+		BackendExec:   func(Config) (Sandbox, error) { return execSandbox{}, nil },
+		BackendRlimit: newRlimitSandbox,
+		BackendDocker: func(cfg Config) (Sandbox, error) { return newDockerSandbox(BackendDocker, "", cfg) },
+		BackendGVisor: func(cfg Config) (Sandbox, error) { return newDockerSandbox(BackendGVisor, "runsc", cfg) },
+	}
+	current Sandbox = execSandbox{}
+)
+
+// Register registers the factory of a backend, replacing any backend of the same name.
+func Register(name string, factory Factory) {
+	mu.Lock()
+	defer mu.Unlock()
+	backends[name] = factory
+}
+
+// Backends returns the names of the registered backends, sorted.
+func Backends() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	names := make([]string, 0, len(backends))
+	for name := range backends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// New creates the sandbox of the configured backend.
+func New(cfg Config) (Sandbox, error) {
+	name := strings.ToLower(strings.TrimSpace(cfg.Backend))
+	if name == "" {
+		name = BackendExec
+	}
+
+	mu.RLock()
+	factory, ok := backends[name]
+	mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown sandbox backend %q: expected one of %s", cfg.Backend, strings.Join(Backends(), ", "))
+	}
+
+	if err := validateLimits(cfg.Limits); err != nil {
+		return nil, err
+	}
+
+	return factory(cfg)
+}
+
+// Configure creates the sandbox of the configured backend and sets it as the one k6 runs
+// in, logging the backend when it isolates k6. Both the server and the agent command call
+// it, so that the archives agents receive run at the same isolation level as scripts.
+func Configure(cfg Config, logger *slog.Logger) error {
+	sb, err := New(cfg)
+	if err != nil {
+		return err
+	}
+	Set(sb)
+	if sb.Name() != BackendExec {
+		logger.Info("Running k6 in a sandbox", slog.String("backend", sb.Name()), slog.Any("limits", cfg.Limits))
+	}
+	return nil
+}
+
+// Set sets the sandbox the server runs k6 in.
+func Set(sandbox Sandbox) {
+	mu.Lock()
+	defer mu.Unlock()
+	current = sandbox
+}
+
+// Default returns the sandbox the server runs k6 in, set with Set, or the exec sandbox.
+func Default() Sandbox {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current
+}
+
+// validateLimits checks the limits are not negative.
+func validateLimits(limits Limits) error {
+	if limits.MemoryBytes < 0 || limits.CPUSeconds < 0 || limits.CPUs < 0 || limits.OpenFiles < 0 || limits.Processes < 0 {
+		return fmt.Errorf("sandbox limits must not be negative")
+	}
+	return nil
+}
+
+// execSandbox runs commands as plain processes.
+type execSandbox struct{}
+
+func (execSandbox) Name() string {
+	return BackendExec
+}
+
+func (execSandbox) Run(ctx context.Context, cmd Command, opts ...execx.Option) (*execx.Result, error) {
+	return execx.Run(ctx, cmd.Path, cmd.Args, commandOptions(cmd, cmd.Env, opts)...)
+}
+
+// commandOptions returns the options running the command with the environment, in its
+// working directory, after the options of the caller.
+func commandOptions(cmd Command, env []string, opts []execx.Option) []execx.Option {
+	options := append([]execx.Option{}, opts...)
+	options = append(options, execx.WithEnv(env))
+	if cmd.Dir != "" {
+		options = append(options, execx.WithDir(cmd.Dir))
+	}
+	return options
+}
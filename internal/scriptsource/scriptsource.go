@@ -0,0 +1,312 @@
+// Package scriptsource fetches k6 scripts from remote locations: HTTPS URLs, Git
+// repositories and Grafana Cloud k6 tests, subject to host and size restrictions.
+package scriptsource
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/oleiade/k6-mcp/internal/cloud"
+	"github.com/oleiade/k6-mcp/internal/config"
+	"github.com/oleiade/k6-mcp/internal/logging"
+	"github.com/oleiade/k6-mcp/internal/security"
+)
+
+const (
+	// DefaultTimeout is the default timeout for fetching a script.
+	DefaultTimeout = 30 * time.Second
+	// maxRedirects is the maximum number of HTTP redirects followed when fetching a script.
+	maxRedirects = 5
+	// gitScheme prefixes Git references, e.g. git+https://github.com/org/repo.git@main#tests/load.js.
+	gitScheme = "git+"
+)
+
+// Error represents errors that occur while fetching a script.
+type Error struct {
+	Type    string
+	Message string
+	Cause   error
+}
+
+func (e *Error) Error() string {
+	if e.Cause != nil {
+		return fmt.Sprintf("%s: %s (caused by: %v)", e.Type, e.Message, e.Cause)
+	}
+	return fmt.Sprintf("%s: %s", e.Type, e.Message)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Cause
+}
+
+// Fetcher fetches scripts from remote locations.
+type Fetcher struct {
+	allowedHosts []string
+	maxBytes     int64
+	client       *http.Client
+	cloud        *cloud.Client
+}
+
+// NewFetcher creates a Fetcher enforcing the script URL restrictions of the given configuration.
+func NewFetcher(cfg config.Config) *Fetcher {
+	f := &Fetcher{
+		allowedHosts: cfg.ScriptURLAllowedHosts,
+		maxBytes:     cfg.ScriptURLMaxBytes,
+		cloud:        cloud.NewClient(cfg.Cloud),
+	}
+
+	f.client = &http.Client{
+		Timeout: DefaultTimeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxRedirects)
+			}
+			return f.checkURL(req.URL)
+		},
+	}
+
+	return f
+}
+
+// Fetch returns the content of the script at rawURL, which is either an HTTPS URL, a Git
+// reference of the form git+https://host/repo.git[@ref]#path/to/script.js, or a Grafana
+// Cloud k6 test reference of the form k6cloud://<test id>.
+func (f *Fetcher) Fetch(ctx context.Context, rawURL string) (string, error) {
+	logger := logging.WithComponent("scriptsource")
+	startTime := time.Now()
+
+	var (
+		script string
+		err    error
+	)
+	switch {
+	case strings.HasPrefix(rawURL, gitScheme):
+		script, err = f.fetchGit(ctx, strings.TrimPrefix(rawURL, gitScheme))
+	case strings.HasPrefix(rawURL, cloud.Scheme):
+		script, err = f.fetchCloud(ctx, rawURL)
+	default:
+		script, err = f.fetchHTTPS(ctx, rawURL)
+	}
+	if err != nil {
+		logger.WarnContext(ctx, "Failed to fetch script",
+			slog.String("error", err.Error()),
+		)
+		return "", err
+	}
+
+	logger.InfoContext(ctx, "Fetched script",
+		slog.Int("script_size", len(script)),
+		slog.Duration("duration", time.Since(startTime)),
+	)
+
+	return script, nil
+}
+
+// fetchHTTPS downloads the script at rawURL.
+func (f *Fetcher) fetchHTTPS(ctx context.Context, rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", &Error{Type: "INVALID_URL", Message: "script_url is not a valid URL", Cause: err}
+	}
+	if err := f.checkURL(u); err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return "", &Error{Type: "INVALID_URL", Message: "failed to build request", Cause: err}
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return "", &Error{Type: "FETCH_ERROR", Message: "failed to download script", Cause: err}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", &Error{Type: "FETCH_ERROR", Message: fmt.Sprintf("failed to download script: server responded with %s", resp.Status)}
+	}
+	if resp.ContentLength > f.maxBytes {
+		return "", f.sizeError(resp.ContentLength)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, f.maxBytes+1))
+	if err != nil {
+		return "", &Error{Type: "FETCH_ERROR", Message: "failed to read script", Cause: err}
+	}
+	if int64(len(body)) > f.maxBytes {
+		return "", f.sizeError(int64(len(body)))
+	}
+
+	return string(body), nil
+}
+
+// fetchCloud downloads the script of a Grafana Cloud k6 test.
+func (f *Fetcher) fetchCloud(ctx context.Context, ref string) (string, error) {
+	id, err := cloud.ParseReference(ref)
+	if err != nil {
+		return "", &Error{Type: "INVALID_URL", Message: "script_url is not a valid cloud test reference", Cause: err}
+	}
+
+	script, err := f.cloud.Script(ctx, id)
+	if err != nil {
+		return "", &Error{Type: "FETCH_ERROR", Message: "failed to download the script of the cloud test", Cause: err}
+	}
+	if int64(len(script)) > f.maxBytes {
+		return "", f.sizeError(int64(len(script)))
+	}
+
+	return script, nil
+}
+
+// fetchGit shallow-fetches the reference of a Git repository, and reads the script from it.
+func (f *Fetcher) fetchGit(ctx context.Context, ref string) (string, error) {
+	repo, revision, filePath, err := parseGitReference(ref)
+	if err != nil {
+		return "", err
+	}
+
+	u, err := url.Parse(repo)
+	if err != nil {
+		return "", &Error{Type: "INVALID_URL", Message: "script_url is not a valid Git reference", Cause: err}
+	}
+	if err := f.checkURL(u); err != nil {
+		return "", err
+	}
+
+	if _, err := exec.LookPath("git"); err != nil {
+		return "", &Error{Type: "GIT_NOT_FOUND", Message: "git executable not found in PATH", Cause: err}
+	}
+
+	workDir, err := os.MkdirTemp("", "k6-git-*")
+	if err != nil {
+		return "", &Error{Type: "FILE_CREATION", Message: "failed to create temporary directory", Cause: err}
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(workDir); removeErr != nil {
+			logging.WithComponent("scriptsource").Warn("Failed to remove temporary directory",
+				slog.String("operation", "cleanup"),
+				slog.String("error", removeErr.Error()),
+			)
+		}
+	}()
+
+	cmdCtx, cancel := context.WithTimeout(ctx, DefaultTimeout)
+	defer cancel()
+
+	if _, err := f.git(cmdCtx, workDir, "init", "--quiet"); err != nil {
+		return "", err
+	}
+	if _, err := f.git(cmdCtx, workDir, "fetch", "--quiet", "--depth", "1", "--no-tags", "--", repo, revision); err != nil {
+		return "", err
+	}
+	object := "FETCH_HEAD:" + filePath
+	size, err := f.git(cmdCtx, workDir, "cat-file", "-s", object)
+	if err != nil {
+		return "", err
+	}
+	var n int64
+	if _, err := fmt.Sscan(size, &n); err == nil && n > f.maxBytes {
+		return "", f.sizeError(n)
+	}
+
+	return f.git(cmdCtx, workDir, "cat-file", "blob", object)
+}
+
+// git runs a git command in dir, without prompting for credentials, and returns its output.
+func (f *Fetcher) git(ctx context.Context, dir string, args ...string) (string, error) {
+	// #nosec G204 - args are built from a validated repository URL and reference
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(security.SecureEnvironment(), "GIT_TERMINAL_PROMPT=0")
+
+	var stdout, stderr strings.Builder
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return "", &Error{Type: "TIMEOUT", Message: fmt.Sprintf("git %s timed out after %v", args[0], DefaultTimeout), Cause: err}
+		}
+		return "", &Error{
+			Type:    "GIT_ERROR",
+			Message: fmt.Sprintf("git %s failed: %s", args[0], security.SanitizeOutput(strings.TrimSpace(stderr.String()))),
+			Cause:   err,
+		}
+	}
+
+	return stdout.String(), nil
+}
+
+// parseGitReference splits a Git reference of the form https://host/repo.git[@ref]#path
+// into its repository URL, revision (HEAD by default), and file path.
+func parseGitReference(ref string) (repo, revision, filePath string, err error) {
+	repo, filePath, found := strings.Cut(ref, "#")
+	if !found || filePath == "" {
+		return "", "", "", &Error{
+			Type:    "INVALID_URL",
+			Message: "Git references must include the script path as a fragment, e.g. git+https://github.com/org/repo.git@main#tests/load.js",
+		}
+	}
+
+	filePath = path.Clean(strings.TrimPrefix(filePath, "/"))
+	if filePath == "." || filePath == ".." || strings.HasPrefix(filePath, "../") {
+		return "", "", "", &Error{Type: "INVALID_URL", Message: fmt.Sprintf("invalid script path %q", filePath)}
+	}
+
+	revision = "HEAD"
+	// The revision follows the last '@' of the path, so that user info in the host part is not mistaken for one.
+	if slash := strings.LastIndex(repo, "/"); slash >= 0 {
+		if at := strings.LastIndex(repo[slash:], "@"); at >= 0 {
+			revision = repo[slash+at+1:]
+			repo = repo[:slash+at]
+		}
+	}
+	if revision == "" || strings.HasPrefix(revision, "-") {
+		return "", "", "", &Error{Type: "INVALID_URL", Message: fmt.Sprintf("invalid Git revision %q", revision)}
+	}
+
+	return repo, revision, filePath, nil
+}
+
+// checkURL ensures the URL uses HTTPS, carries no credentials, and targets an allowed host.
+func (f *Fetcher) checkURL(u *url.URL) error {
+	if u.Scheme != "https" {
+		return &Error{Type: "URL_NOT_ALLOWED", Message: fmt.Sprintf("only https URLs are supported, got %q", u.Scheme)}
+	}
+	if u.User != nil {
+		return &Error{Type: "URL_NOT_ALLOWED", Message: "URLs must not embed credentials"}
+	}
+	if len(f.allowedHosts) == 0 {
+		return &Error{Type: "URL_NOT_ALLOWED", Message: "fetching scripts from URLs is disabled; set K6_MCP_SCRIPT_URL_ALLOWED_HOSTS to enable it"}
+	}
+
+	host := strings.ToLower(u.Hostname())
+	for _, allowed := range f.allowedHosts {
+		if host == allowed || (strings.HasPrefix(allowed, "*.") && strings.HasSuffix(host, allowed[1:])) {
+			return nil
+		}
+	}
+
+	return &Error{
+		Type:    "URL_NOT_ALLOWED",
+		Message: fmt.Sprintf("host %q is not allowed; allowed hosts: %s", host, strings.Join(f.allowedHosts, ", ")),
+	}
+}
+
+func (f *Fetcher) sizeError(size int64) error {
+	return &Error{
+		Type:    "SIZE_LIMIT_EXCEEDED",
+		Message: fmt.Sprintf("script size (%d bytes) exceeds maximum allowed size (%d bytes)", size, f.maxBytes),
+	}
+}
@@ -0,0 +1,56 @@
+package search
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// BenchmarkQueries are representative documentation queries used to measure search latency.
+var BenchmarkQueries = []string{
+	"http",
+	"thresholds",
+	"http.get",
+	"scenarios executor",
+	"\"ramping-vus\"",
+	"check*",
+	"browser page",
+	"grpc OR websockets",
+}
+
+// BenchmarkResult reports the latency of the benchmark queries.
+type BenchmarkResult struct {
+	Queries int
+	Median  time.Duration
+	Max     time.Duration
+	Total   time.Duration
+}
+
+// Benchmark runs each query against the given searcher and measures their latency. The
+// first error aborts the benchmark.
+func Benchmark(ctx context.Context, s Search, queries []string) (BenchmarkResult, error) {
+	durations := make([]time.Duration, 0, len(queries))
+	options := DefaultOptions()
+
+	for _, query := range queries {
+		start := time.Now()
+		if _, err := s.Search(ctx, query, options); err != nil {
+			return BenchmarkResult{}, err
+		}
+		durations = append(durations, time.Since(start))
+	}
+
+	result := BenchmarkResult{Queries: len(durations)}
+	if len(durations) == 0 {
+		return result, nil
+	}
+
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+	for _, d := range durations {
+		result.Total += d
+	}
+	result.Median = durations[len(durations)/2]
+	result.Max = durations[len(durations)-1]
+
+	return result, nil
+}
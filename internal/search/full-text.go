@@ -3,49 +3,146 @@ package search
 import (
 	"context"
 	"database/sql"
+	"fmt"
 	"strings"
+	"sync"
+	"time"
+	"unicode"
 )
 
+// fullTextQuery ranks the documentation chunks of a language table matching a query, by
+// their score: their BM25 score multiplied by the boost of their section, and by the boost
+// of recent pages. BM25 scores are negative, the most relevant chunks scoring the lowest.
+// Chunks of pages updated before a time are left out, when it is not 0.
+const fullTextQuery = `
+        SELECT title, content, path,
+            bm25(%[1]s, ?, ?, ?)
+            * CASE WHEN path LIKE ? ESCAPE '\' THEN ? ELSE ? END
+            * CASE WHEN path IN (SELECT path FROM pages WHERE updated > 0 AND updated >= (SELECT MAX(updated) FROM pages) - ?) THEN ? ELSE 1 END AS score
+        FROM %[1]s
+        WHERE %[1]s MATCH ?
+          AND (? = 0 OR path IN (SELECT path FROM pages WHERE updated >= ?))
+        ORDER BY score
+        LIMIT ?`
+
+// RecentWindow is the period before the last change of the indexed documentation in which
+// pages are considered recently updated.
+const RecentWindow = 90 * 24 * time.Hour
+
+// recentBoost multiplies the scores of the chunks of recently updated pages, when
+// Options.BoostRecent is set.
+const recentBoost = 1.5
+
 type FullTextSearch struct {
 	db *sql.DB
+
+	// Ranking boosts sections of the documentation depending on the query. Without
+	// ranking strategy, results are ranked by their BM25 score only.
+	Ranking RankingStrategy
+
+	// The search query of each language is compiled on first use, and reused by later searches.
+	mu    sync.Mutex
+	stmts map[string]*sql.Stmt
 }
 
 var _ Search = &FullTextSearch{}
 
 func NewFullTextSearcher(db *sql.DB) *FullTextSearch {
-	return &FullTextSearch{db: db}
+	return &FullTextSearch{db: db, Ranking: QueryKindRanking, stmts: make(map[string]*sql.Stmt)}
 }
 
 // Search returns up to limit results for the provided MATCH query.
 func (s *FullTextSearch) Search(ctx context.Context, query string, opts Options) ([]Result, error) {
-	// Preprocess the query to handle multi-word searches
-	processedQuery := preprocessQuery(query)
-
-	rows, err := s.db.QueryContext(ctx, `
-        SELECT title, content, path
-        FROM documentation
-        WHERE documentation MATCH ?
-        ORDER BY bm25(documentation, ?, ?, ?)
-        LIMIT ?`, processedQuery, BM25WeightTitle, BM25WeightContent, BM25WeightPath, opts.MaxResults)
+	language, err := LookupLanguage(opts.Language)
+	if err != nil {
+		return nil, err
+	}
+
+	boost := NoBoost
+	if s.Ranking != nil {
+		boost = s.Ranking(query)
+	}
+
+	// Preprocess the query to handle identifiers and multi-word searches
+	processedQuery := preprocessQuery(quoteIdentifiers(query), language)
+
+	stmt, err := s.statement(ctx, language)
+	if err != nil {
+		return nil, err
+	}
+
+	var since int64
+	if !opts.UpdatedSince.IsZero() {
+		since = opts.UpdatedSince.Unix()
+	}
+	recent := 1.0
+	if opts.BoostRecent {
+		recent = recentBoost
+	}
+
+	rows, err := stmt.QueryContext(ctx, BM25WeightTitle, BM25WeightContent, BM25WeightPath,
+		likePrefix(boost.Prefix), boost.Inside, boost.Outside, int64(RecentWindow.Seconds()), recent,
+		processedQuery, since, since, opts.MaxResults)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
 	var results []Result
+	var relevance []float64
 	for rows.Next() {
-		var c Result
-		if err := rows.Scan(&c.Title, &c.Content, &c.Path); err != nil {
+		c := Result{Source: SourceFullText}
+		var score float64
+		if err := rows.Scan(&c.Title, &c.Content, &c.Path, &score); err != nil {
 			return nil, err
 		}
 		results = append(results, c)
+		relevance = append(relevance, -score)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
 	}
+	NormalizeRanks(results, relevance)
+
 	return results, nil
 }
 
+// statement returns the compiled search query of the language. The query is compiled
+// without holding the lock, as compiling it waits for a connection, and so for the index
+// of deferred databases to be written: concurrent searches don't queue up behind it, and
+// each gives up when its context is done.
+func (s *FullTextSearch) statement(ctx context.Context, language Language) (*sql.Stmt, error) {
+	s.mu.Lock()
+	stmt, ok := s.stmts[language.Code]
+	s.mu.Unlock()
+	if ok {
+		return stmt, nil
+	}
+
+	stmt, err := s.db.PrepareContext(ctx, fmt.Sprintf(fullTextQuery, language.Table()))
+	if err != nil {
+		if strings.Contains(err.Error(), "no such table") {
+			return nil, fmt.Errorf("no %s documentation is indexed", language.Name)
+		}
+		return nil, err
+	}
+
+	// Searches compiling the query concurrently keep the first statement stored
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if stored, ok := s.stmts[language.Code]; ok {
+		_ = stmt.Close()
+		return stored, nil
+	}
+	s.stmts[language.Code] = stmt
+
+	return stmt, nil
+}
+
 // preprocessQuery converts space-separated words to FTS5 AND queries
 // while preserving explicit FTS5 syntax like AND, OR, quotes, etc.
-func preprocessQuery(query string) string {
+// Words of languages stemmed at query time are replaced with prefix queries of their stems.
+func preprocessQuery(query string, language Language) string {
 	query = strings.TrimSpace(query)
 	if query == "" {
 		return query
@@ -60,9 +157,27 @@ func preprocessQuery(query string) string {
 
 	// Split on spaces and join with AND
 	words := strings.Fields(query)
+	for i, word := range words {
+		if !isWord(word) {
+			continue
+		}
+		if stem, ok := language.stem(word); ok {
+			words[i] = stem + "*"
+		}
+	}
 	if len(words) <= 1 {
-		return query
+		return strings.Join(words, "")
 	}
 
 	return strings.Join(words, " AND ")
 }
+
+// isWord reports whether s only holds letters, and so is safe to turn into a prefix query.
+func isWord(s string) bool {
+	for _, r := range s {
+		if !unicode.IsLetter(r) {
+			return false
+		}
+	}
+	return s != ""
+}
@@ -0,0 +1,87 @@
+//go:build fts5
+
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSearchDeferredDatabase(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "index.db")
+	db, err := InitSQLiteDB(path, true)
+	if err != nil {
+		t.Fatalf("InitSQLiteDB: %v", err)
+	}
+	language, err := LookupLanguage(DefaultLanguage)
+	if err != nil {
+		t.Fatalf("LookupLanguage: %v", err)
+	}
+	insert := fmt.Sprintf(`INSERT INTO %s (title, content, path) VALUES (?, ?, ?)`, language.Table())
+	if _, err := db.Exec(insert, "Thresholds", "Thresholds are pass/fail criteria.", "using-k6/thresholds"); err != nil {
+		t.Fatalf("inserting: %v", err)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	// The index is ready once written is closed
+	written := make(chan struct{})
+	deferred := OpenDeferredReadOnlySQLiteDB(func(ctx context.Context) (string, error) {
+		select {
+		case <-written:
+			return path, nil
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	})
+	t.Cleanup(func() { _ = deferred.Close() })
+	searcher := NewFullTextSearcher(deferred)
+
+	// Searches waiting for the index give up with their context, concurrently
+	var wg sync.WaitGroup
+	errs := make(chan error, 4)
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+			_, err := searcher.Search(ctx, "thresholds", Options{MaxResults: 10})
+			errs <- err
+		}()
+	}
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("searches waiting for the index did not give up with their context")
+	}
+	close(errs)
+	for err := range errs {
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Search() error = %v, want %v", err, context.DeadlineExceeded)
+		}
+	}
+
+	// Once the index is written, searches succeed
+	close(written)
+	results, err := searcher.Search(context.Background(), "thresholds", Options{MaxResults: 10})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(results) != 1 || results[0].Path != "using-k6/thresholds" {
+		t.Errorf("Search() = %+v, want the thresholds chunk", results)
+	}
+}
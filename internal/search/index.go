@@ -2,9 +2,13 @@ package search
 
 import (
 	"database/sql"
+	"errors"
+	"fmt"
 	"io/fs"
+	"path"
 	"path/filepath"
 	"strings"
+	"time"
 )
 
 // Indexer is the interface that wraps the IndexDirectory method.
@@ -20,6 +24,15 @@ type Indexer interface {
 // It is used to index a directory of documents into a SQLite database.
 type SQLiteIndexer struct {
 	db *sql.DB
+
+	// Language is the code of the language of the indexed documents, English by default.
+	// Documents in other languages are only indexed as searchable chunks: the pages table
+	// keeps the English pages.
+	Language string
+
+	// Updated holds the times the documents were last changed, keyed by their document
+	// path (see DocumentPath). Pages of documents without time are indexed as of unknown age.
+	Updated map[string]time.Time
 }
 
 // NewSQLiteIndexer creates a new SQLiteIndexer with the given SQLite database.
@@ -29,20 +42,41 @@ func NewSQLiteIndexer(db *sql.DB) *SQLiteIndexer {
 
 // IndexDirectory walks the provided docsPath and indexes all .md files it finds.
 // It returns the number of files successfully indexed.
+//
+// Documents are stored under their path relative to docsPath (see DocumentPath), both
+// as searchable chunks and as full pages.
 func (i *SQLiteIndexer) IndexDirectory(docsPath string) (int, error) {
+	language, err := LookupLanguage(i.Language)
+	if err != nil {
+		return 0, err
+	}
+
 	count := 0
-	err := filepath.WalkDir(docsPath, func(path string, d fs.DirEntry, err error) error {
+	err = filepath.WalkDir(docsPath, func(filePath string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
 		if !d.IsDir() && strings.HasSuffix(d.Name(), ".md") {
-			chunks, perr := ParseMarkdown(path)
+			doc, perr := ParseDocument(filePath)
 			if perr != nil {
 				// Skip file on parse error
 				return nil
 			}
-			for _, c := range chunks {
-				if ierr := i.insertChunk(c); ierr != nil {
+
+			relPath, rerr := filepath.Rel(docsPath, filePath)
+			if rerr != nil {
+				return rerr
+			}
+			docPath := DocumentPath(relPath)
+
+			if language.Code == DefaultLanguage {
+				if ierr := i.insertPage(docPath, doc); ierr != nil {
+					return ierr
+				}
+			}
+			for _, c := range doc.Chunks {
+				c.Path = docPath
+				if ierr := i.insertChunk(language, c); ierr != nil {
 					return ierr
 				}
 			}
@@ -56,8 +90,115 @@ func (i *SQLiteIndexer) IndexDirectory(docsPath string) (int, error) {
 	return count, nil
 }
 
-func (i *SQLiteIndexer) insertChunk(c Result) error {
-	_, err := i.db.Exec(`INSERT INTO documentation (title, content, path) VALUES (?, ?, ?)`,
+// DocumentPath converts a markdown file path relative to the documentation root into
+// the slash-separated path documents are indexed under: the ".md" extension is dropped,
+// and section index files (_index.md, index.md) are addressed by their directory.
+//
+// For instance, "javascript-api/k6-http/_index.md" becomes "javascript-api/k6-http".
+func DocumentPath(relPath string) string {
+	p := strings.TrimSuffix(filepath.ToSlash(relPath), ".md")
+
+	if base := path.Base(p); base == "_index" || base == "index" {
+		p = path.Dir(p)
+		if p == "." {
+			p = ""
+		}
+	}
+
+	return p
+}
+
+func (i *SQLiteIndexer) insertChunk(language Language, c Result) error {
+	_, err := i.db.Exec(fmt.Sprintf(`INSERT INTO %s (title, content, path) VALUES (?, ?, ?)`, language.Table()),
 		c.Title, c.Content, c.Path)
 	return err
 }
+
+func (i *SQLiteIndexer) insertPage(docPath string, doc *Document) error {
+	var updated int64
+	if t, ok := i.Updated[docPath]; ok {
+		updated = t.Unix()
+	}
+
+	_, err := i.db.Exec(`INSERT OR REPLACE INTO pages (path, title, description, content, updated) VALUES (?, ?, ?, ?, ?)`,
+		docPath, doc.Title, doc.Description, doc.Content, updated)
+	return err
+}
+
+// IndexVersion indexes the documentation of docsPath as the pages of an earlier k6
+// version than the one of the pages table, such as v1.1.x. Only the pages differing from
+// those of the pages table are stored, along with markers for the pages the version
+// doesn't have. It returns the number of pages stored.
+func (i *SQLiteIndexer) IndexVersion(docsPath, version string) (int, error) {
+	latest := map[string]bool{}
+	rows, err := i.db.Query(`SELECT path FROM pages`)
+	if err != nil {
+		return 0, err
+	}
+	for rows.Next() {
+		var docPath string
+		if err := rows.Scan(&docPath); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		latest[docPath] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	count := 0
+	err = filepath.WalkDir(docsPath, func(filePath string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(d.Name(), ".md") {
+			return err
+		}
+		doc, perr := ParseDocument(filePath)
+		if perr != nil {
+			// Skip file on parse error
+			return nil
+		}
+		relPath, rerr := filepath.Rel(docsPath, filePath)
+		if rerr != nil {
+			return rerr
+		}
+		docPath := DocumentPath(relPath)
+		delete(latest, docPath)
+
+		var unchanged bool
+		if qerr := i.db.QueryRow(`SELECT title = ? AND description = ? AND content = ? FROM pages WHERE path = ?`,
+			doc.Title, doc.Description, doc.Content, docPath).Scan(&unchanged); qerr != nil && !errors.Is(qerr, sql.ErrNoRows) {
+			return qerr
+		}
+		if unchanged {
+			return nil
+		}
+
+		if _, ierr := i.db.Exec(`INSERT OR REPLACE INTO page_versions (version, path, title, description, content) VALUES (?, ?, ?, ?, ?)`,
+			version, docPath, doc.Title, doc.Description, doc.Content); ierr != nil {
+			return ierr
+		}
+		count++
+		return nil
+	})
+	if err != nil {
+		return count, err
+	}
+
+	// The pages of the latest version left were added after this version
+	for docPath := range latest {
+		if _, err := i.db.Exec(`INSERT OR REPLACE INTO page_versions (version, path) VALUES (?, ?)`, version, docPath); err != nil {
+			return count, err
+		}
+		count++
+	}
+
+	return count, i.RecordVersion(version, false)
+}
+
+// RecordVersion records an indexed k6 version of the documentation, the latest one being
+// that of the pages table.
+func (i *SQLiteIndexer) RecordVersion(version string, latest bool) error {
+	_, err := i.db.Exec(`INSERT OR REPLACE INTO doc_versions (version, latest) VALUES (?, ?)`, version, latest)
+	return err
+}
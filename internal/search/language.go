@@ -0,0 +1,132 @@
+package search
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// DefaultLanguage is the language of the k6 documentation, indexed in the documentation table.
+const DefaultLanguage = "en"
+
+// codeTokenChars are the characters kept within tokens, so that code identifiers such as
+// k6/http or ramping-vus are indexed whole.
+const codeTokenChars = `_/:#@-$`
+
+// Language configures how the documentation in a language is tokenized and stemmed.
+//
+// SQLite's FTS5 only ships an English stemmer (porter), so other languages are stemmed at
+// query time: the known inflectional suffixes of query terms are stripped, and the stems
+// are matched as prefixes. Languages without word separators are indexed as trigrams.
+type Language struct {
+	// Code is the ISO 639-1 code of the language.
+	Code string
+	Name string
+
+	// Tokenizer is the FTS5 tokenizer of the language's documentation table.
+	Tokenizer string
+
+	// Suffixes are the inflectional suffixes stripped from query terms, longest first.
+	Suffixes []string
+
+	// MinStem is the minimum length, in characters, of the stems left by stripping suffixes.
+	MinStem int
+}
+
+// unicodeTokenizer folds case and diacritics, so that queries match with or without
+// accents, e.g. "requete" matches "requête".
+var unicodeTokenizer = fmt.Sprintf("unicode61 remove_diacritics 2 tokenchars '%s'", codeTokenChars)
+
+// supportedLanguages are the languages documentation can be indexed in.
+var supportedLanguages = map[string]Language{
+	"en": {Code: "en", Name: "English", Tokenizer: unicodeTokenizer},
+	"fr": {
+		Code: "fr", Name: "French", Tokenizer: unicodeTokenizer, MinStem: 4,
+		Suffixes: []string{"issements", "issement", "ements", "ement", "ations", "ation", "euses", "euse", "ives", "ive", "ées", "ée", "és", "er", "es", "e", "s"},
+	},
+	"de": {
+		Code: "de", Name: "German", Tokenizer: unicodeTokenizer, MinStem: 4,
+		Suffixes: []string{"ungen", "ung", "heiten", "heit", "keiten", "keit", "ern", "em", "en", "er", "es", "e", "n", "s"},
+	},
+	"es": {
+		Code: "es", Name: "Spanish", Tokenizer: unicodeTokenizer, MinStem: 4,
+		Suffixes: []string{"aciones", "ación", "amientos", "amiento", "mente", "ares", "ar", "er", "ir", "es", "os", "as", "o", "a", "s"},
+	},
+	"pt": {
+		Code: "pt", Name: "Portuguese", Tokenizer: unicodeTokenizer, MinStem: 4,
+		Suffixes: []string{"ações", "ação", "amentos", "amento", "mente", "ar", "er", "ir", "es", "os", "as", "o", "a", "s"},
+	},
+	"it": {
+		Code: "it", Name: "Italian", Tokenizer: unicodeTokenizer, MinStem: 4,
+		Suffixes: []string{"azioni", "azione", "amenti", "amento", "mente", "are", "ere", "ire", "i", "e", "o", "a"},
+	},
+	"ja": {Code: "ja", Name: "Japanese", Tokenizer: "trigram"},
+	"zh": {Code: "zh", Name: "Chinese", Tokenizer: "trigram"},
+	"ko": {Code: "ko", Name: "Korean", Tokenizer: "trigram"},
+}
+
+// LookupLanguage returns the language of the code.
+func LookupLanguage(code string) (Language, error) {
+	if code == "" {
+		code = DefaultLanguage
+	}
+	language, ok := supportedLanguages[strings.ToLower(code)]
+	if !ok {
+		return Language{}, fmt.Errorf("unsupported language %q; supported languages are %s", code, strings.Join(LanguageCodes(), ", "))
+	}
+	return language, nil
+}
+
+// LanguageCodes returns the codes of the languages documentation can be indexed in.
+func LanguageCodes() []string {
+	codes := make([]string, 0, len(supportedLanguages))
+	for code := range supportedLanguages {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// Table returns the name of the FTS5 table holding the documentation chunks in the language.
+// English documentation keeps the documentation table, so that existing indexes still work.
+func (l Language) Table() string {
+	if l.Code == DefaultLanguage {
+		return "documentation"
+	}
+	return "documentation_" + l.Code
+}
+
+// stem strips the longest known suffix of word, keeping at least MinStem characters.
+func (l Language) stem(word string) (string, bool) {
+	lower := strings.ToLower(word)
+	for _, suffix := range l.Suffixes {
+		if !strings.HasSuffix(lower, suffix) {
+			continue
+		}
+		stem := lower[:len(lower)-len(suffix)]
+		if utf8.RuneCountInString(stem) >= l.MinStem {
+			return stem, true
+		}
+	}
+	return word, false
+}
+
+// IndexedLanguages returns the codes of the languages the database has documentation in.
+func IndexedLanguages(ctx context.Context, db *sql.DB) ([]string, error) {
+	var indexed []string
+	for _, code := range LanguageCodes() {
+		var name string
+		err := db.QueryRowContext(ctx, `SELECT name FROM sqlite_master WHERE type = 'table' AND name = ?`, supportedLanguages[code].Table()).Scan(&name)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		indexed = append(indexed, code)
+	}
+	return indexed, nil
+}
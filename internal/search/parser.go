@@ -1,6 +1,8 @@
 package search
 
 import (
+	"bufio"
+	"bytes"
 	"os"
 	"strings"
 
@@ -9,17 +11,50 @@ import (
 	"github.com/yuin/goldmark/text"
 )
 
+// frontMatterDelimiter is the line delimiting the YAML front matter block at the top
+// of the k6 documentation markdown files.
+const frontMatterDelimiter = "---"
+
+// Document is a parsed markdown documentation page, along with the chunks
+// it is split into for full-text indexing.
+type Document struct {
+	// Title is the front matter title, or the first H1 heading if none is declared.
+	Title string
+
+	// Description is the front matter description, if any.
+	Description string
+
+	// Content is the markdown body of the page, stripped of its front matter.
+	Content string
+
+	// Chunks are the searchable sections of the page.
+	Chunks []Result
+}
+
+// ParseMarkdown parses the markdown file at path and returns its searchable chunks.
 func ParseMarkdown(path string) ([]Result, error) {
-	src, err := os.ReadFile(path)
+	doc, err := ParseDocument(path)
 	if err != nil {
 		return nil, err
 	}
 
+	return doc.Chunks, nil
+}
+
+// ParseDocument parses the markdown file at path into a Document.
+func ParseDocument(path string) (*Document, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	frontMatter, src := splitFrontMatter(raw)
+
 	md := goldmark.New()
 	doc := md.Parser().Parse(text.NewReader(src))
 
 	var chunks []Result
-	var currentTitle string
+	currentTitle := frontMatter["title"]
 	var buffer strings.Builder
 
 	flush := func() {
@@ -80,5 +115,46 @@ func ParseMarkdown(path string) ([]Result, error) {
 	}
 	walk(doc)
 	flush()
-	return chunks, nil
+
+	return &Document{
+		Title:       currentTitle,
+		Description: frontMatter["description"],
+		Content:     strings.TrimSpace(string(src)),
+		Chunks:      chunks,
+	}, nil
+}
+
+// splitFrontMatter separates the YAML front matter block from the markdown body.
+//
+// Only top-level scalar `key: value` pairs are extracted, which is all the indexer
+// needs (title, description, ...). Nested structures are ignored.
+func splitFrontMatter(src []byte) (map[string]string, []byte) {
+	frontMatter := map[string]string{}
+
+	if !bytes.HasPrefix(src, []byte(frontMatterDelimiter+"\n")) {
+		return frontMatter, src
+	}
+
+	rest := src[len(frontMatterDelimiter)+1:]
+	end := bytes.Index(rest, []byte("\n"+frontMatterDelimiter))
+	if end < 0 {
+		return frontMatter, src
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(rest[:end]))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") {
+			continue
+		}
+
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		frontMatter[strings.TrimSpace(key)] = strings.Trim(strings.TrimSpace(value), `"'`)
+	}
+
+	body := rest[end+len(frontMatterDelimiter)+1:]
+	return frontMatter, bytes.TrimLeft(body, "\r\n")
 }
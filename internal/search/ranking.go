@@ -0,0 +1,123 @@
+package search
+
+import (
+	"regexp"
+	"strings"
+)
+
+// APIReferencePrefix is the path prefix of the JavaScript API reference pages.
+const APIReferencePrefix = "javascript-api/"
+
+// Boosts applied by QueryKindRanking. BM25 scores are negative, lower being better, so
+// boosting multiplies the scores of the chunks of a section.
+const (
+	codeQueryBoost  = 2.0
+	proseQueryBoost = 1.5
+
+	// minProseWords is the number of words from which queries without identifiers are
+	// considered prose, such as "how to ramp up virtual users".
+	minProseWords = 3
+)
+
+// QueryKind classifies search queries by what they look like.
+type QueryKind string
+
+const (
+	// QueryCode queries hold identifiers, such as http.batch, check(), setResponseCallback or k6/http.
+	QueryCode QueryKind = "code"
+	// QueryProse queries are sentences or lists of words, such as "ramp up virtual users".
+	QueryProse QueryKind = "prose"
+	// QueryKeywords queries are a word or two, matched without boosting.
+	QueryKeywords QueryKind = "keywords"
+)
+
+var (
+	// identifierPattern matches identifiers with a member access or a call, such as
+	// http.batch or check(), camelCase identifiers, such as setResponseCallback, and k6
+	// module paths, such as k6/http.
+	identifierPattern = regexp.MustCompile(`^[A-Za-z_$][\w$]*(\.[A-Za-z_$][\w$]*)+(\(\))?$|^[A-Za-z_$][\w$]*\(\)$|^[a-z][a-z0-9]*[A-Z][\w$]*$|^k6(/[\w-]+)+$`)
+
+	// fts5SyntaxPattern matches queries with explicit FTS5 syntax, which are kept as is.
+	fts5SyntaxPattern = regexp.MustCompile(`"|\*| AND | OR | NEAR|^NEAR`)
+)
+
+// Boost multiplies the scores of the chunks whose path starts with Prefix by Inside, and
+// the scores of the other chunks by Outside.
+type Boost struct {
+	Prefix  string
+	Inside  float64
+	Outside float64
+}
+
+// NoBoost ranks chunks by their BM25 score only.
+var NoBoost = Boost{Inside: 1, Outside: 1}
+
+// RankingStrategy returns the boost ranking the results of a query.
+type RankingStrategy func(query string) Boost
+
+// QueryKindRanking boosts the API reference for code-looking queries, and the guides for
+// prose-like queries.
+func QueryKindRanking(query string) Boost {
+	switch ClassifyQuery(query) {
+	case QueryCode:
+		return Boost{Prefix: APIReferencePrefix, Inside: codeQueryBoost, Outside: 1}
+	case QueryProse:
+		return Boost{Prefix: APIReferencePrefix, Inside: 1, Outside: proseQueryBoost}
+	default:
+		return NoBoost
+	}
+}
+
+// ClassifyQuery returns the kind of the query: code when one of its terms is an identifier,
+// prose when it has at least minProseWords words, and keywords otherwise. Queries with
+// explicit FTS5 syntax are keywords.
+func ClassifyQuery(query string) QueryKind {
+	if fts5SyntaxPattern.MatchString(query) {
+		return QueryKeywords
+	}
+
+	terms := strings.Fields(query)
+	words := 0
+	for _, term := range terms {
+		if identifierPattern.MatchString(term) {
+			return QueryCode
+		}
+		if isWord(term) {
+			words++
+		}
+	}
+
+	if words >= minProseWords && words == len(terms) {
+		return QueryProse
+	}
+	return QueryKeywords
+}
+
+// quoteIdentifiers rewrites the identifiers of queries without explicit FTS5 syntax as
+// phrases, without their call parentheses, since dots, slashes and parentheses are FTS5 syntax:
+// `http.batch` becomes `"http.batch"`, matching the http and batch tokens in sequence.
+func quoteIdentifiers(query string) string {
+	if fts5SyntaxPattern.MatchString(query) {
+		return query
+	}
+
+	terms := strings.Fields(query)
+	quoted := false
+	for i, term := range terms {
+		if identifierPattern.MatchString(term) && strings.ContainsAny(term, ".(/") {
+			terms[i] = `"` + strings.TrimSuffix(term, "()") + `"`
+			quoted = true
+		}
+	}
+	if !quoted {
+		return query
+	}
+
+	return strings.Join(terms, " ")
+}
+
+// likePrefix returns the LIKE pattern matching the paths starting with prefix.
+func likePrefix(prefix string) string {
+	escaper := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return escaper.Replace(prefix) + "%"
+}
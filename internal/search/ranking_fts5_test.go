@@ -0,0 +1,63 @@
+//go:build fts5
+
+package search
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+)
+
+func TestQueryKindRankingOrdersSections(t *testing.T) {
+	t.Parallel()
+
+	db, err := InitSQLiteDB(filepath.Join(t.TempDir(), "index.db"), true)
+	if err != nil {
+		t.Fatalf("InitSQLiteDB: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	// Both chunks hold the same text, so that their BM25 scores tie and the section boost
+	// alone orders them
+	const (
+		apiPath   = APIReferencePrefix + "k6-http/batch"
+		guidePath = "using-k6/http-requests"
+		content   = "Learn how to send requests in parallel with http.batch to ramp up virtual users faster."
+	)
+	language, err := LookupLanguage(DefaultLanguage)
+	if err != nil {
+		t.Fatalf("LookupLanguage: %v", err)
+	}
+	insert := fmt.Sprintf(`INSERT INTO %s (title, content, path) VALUES (?, ?, ?)`, language.Table())
+	for _, path := range []string{apiPath, guidePath} {
+		if _, err := db.Exec(insert, "Requests", content, path); err != nil {
+			t.Fatalf("inserting %s: %v", path, err)
+		}
+	}
+
+	searcher := NewFullTextSearcher(db)
+	tests := []struct {
+		name  string
+		query string
+		first string
+	}{
+		{name: "code", query: "http.batch", first: apiPath},
+		{name: "prose", query: "how to ramp up virtual users", first: guidePath},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := searcher.Search(context.Background(), tt.query, Options{MaxResults: 10})
+			if err != nil {
+				t.Fatalf("Search(%q): %v", tt.query, err)
+			}
+			if len(results) != 2 {
+				t.Fatalf("Search(%q) returned %d results, want 2", tt.query, len(results))
+			}
+			if results[0].Path != tt.first {
+				t.Errorf("Search(%q) ranked %s first, want %s", tt.query, results[0].Path, tt.first)
+			}
+		})
+	}
+}
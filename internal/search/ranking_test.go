@@ -0,0 +1,123 @@
+package search
+
+import "testing"
+
+func TestClassifyQuery(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		query string
+		want  QueryKind
+	}{
+		{name: "member access", query: "http.batch", want: QueryCode},
+		{name: "call", query: "check()", want: QueryCode},
+		{name: "camel case", query: "setResponseCallback", want: QueryCode},
+		{name: "module path", query: "k6/http", want: QueryCode},
+		{name: "identifier among words", query: "parallel requests with http.batch", want: QueryCode},
+		{name: "prose", query: "how to ramp up virtual users", want: QueryProse},
+		{name: "three words", query: "ramp virtual users", want: QueryProse},
+		{name: "single word", query: "thresholds", want: QueryKeywords},
+		{name: "two words", query: "ramp up", want: QueryKeywords},
+		{name: "words and non-words", query: "ramping-vus executor options", want: QueryKeywords},
+		{name: "phrase", query: `"x"`, want: QueryKeywords},
+		{name: "quoted identifier", query: `"http.batch"`, want: QueryKeywords},
+		{name: "or", query: "a OR b", want: QueryKeywords},
+		{name: "and", query: "virtual AND users AND ramp", want: QueryKeywords},
+		{name: "near", query: "NEAR(ramp users)", want: QueryKeywords},
+		{name: "prefix", query: "check*", want: QueryKeywords},
+		{name: "empty", query: "", want: QueryKeywords},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := ClassifyQuery(tt.query); got != tt.want {
+				t.Errorf("ClassifyQuery(%q) = %q, want %q", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQueryKindRanking(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		query string
+		want  Boost
+	}{
+		{name: "code", query: "http.batch", want: Boost{Prefix: APIReferencePrefix, Inside: codeQueryBoost, Outside: 1}},
+		{name: "prose", query: "how to ramp up virtual users", want: Boost{Prefix: APIReferencePrefix, Inside: 1, Outside: proseQueryBoost}},
+		{name: "keywords", query: "thresholds", want: NoBoost},
+		{name: "fts5 syntax", query: "check*", want: NoBoost},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := QueryKindRanking(tt.query); got != tt.want {
+				t.Errorf("QueryKindRanking(%q) = %+v, want %+v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuoteIdentifiers(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{name: "member access", query: "http.batch", want: `"http.batch"`},
+		{name: "call", query: "check()", want: `"check"`},
+		{name: "member call", query: "res.json()", want: `"res.json"`},
+		{name: "module path", query: "k6/http", want: `"k6/http"`},
+		{name: "camel case", query: "setResponseCallback", want: "setResponseCallback"},
+		{name: "among words", query: "parallel http.batch requests", want: `parallel "http.batch" requests`},
+		{name: "words", query: "ramp up virtual users", want: "ramp up virtual users"},
+		{name: "phrase", query: `"x"`, want: `"x"`},
+		{name: "or", query: "http.batch OR http.get", want: "http.batch OR http.get"},
+		{name: "prefix", query: "check*", want: "check*"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := quoteIdentifiers(tt.query); got != tt.want {
+				t.Errorf("quoteIdentifiers(%q) = %q, want %q", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLikePrefix(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		prefix string
+		want   string
+	}{
+		{name: "empty", prefix: "", want: "%"},
+		{name: "api reference", prefix: APIReferencePrefix, want: "javascript-api/%"},
+		{name: "underscore", prefix: "using_k6/", want: `using\_k6/%`},
+		{name: "percent", prefix: "100%/", want: `100\%/%`},
+		{name: "backslash", prefix: `a\b`, want: `a\\b%`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := likePrefix(tt.prefix); got != tt.want {
+				t.Errorf("likePrefix(%q) = %q, want %q", tt.prefix, got, tt.want)
+			}
+		})
+	}
+}
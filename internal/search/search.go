@@ -1,14 +1,23 @@
 package search
 
-import "context"
+import (
+	"context"
+	"math"
+	"time"
+)
 
 // Search is the interface that wraps the Search method.
 //
-// It is used to search for documents in the index.
+// It is used to search for documents in the index. Implementations return results by
+// decreasing relevance, with their Source set to the name of the backend, and their Rank
+// normalized with NormalizeRanks, so that results of different backends compare.
 type Search interface {
 	Search(ctx context.Context, query string, options Options) ([]Result, error)
 }
 
+// SourceFullText is the source of the results of the SQLite FTS5 backend.
+const SourceFullText = "full_text"
+
 // Result is the result of a search query.
 //
 // It contains the title, content, and path of the document.
@@ -25,21 +34,46 @@ type Result struct {
 	// The path of the document. Relative to the index.
 	Path string `json:"path"`
 
-	// Metadata (optional) is a map of key-value pairs containing additional information related to the document.
-	Metadata map[string]string `json:"metadata"`
+	// Source is the backend that returned the result, such as SourceFullText.
+	Source string `json:"source,omitempty"`
 
-	// Source (optional) is the original source of the result being returned
-	Source string `json:"source"`
+	// Rank is the relevance of the result relative to the most relevant result of the
+	// query, which ranks 1, down to 0 exclusive.
+	Rank float64 `json:"rank,omitempty"`
+}
 
-	// Rank represents the scoring or relevance of the document in the context of a search result.
-	Rank float64 `json:"rank"`
+// NormalizeRanks sets the ranks of the results from their relevance scores, higher
+// scores being more relevant, relative to the highest score, rounded to 3 decimals.
+func NormalizeRanks(results []Result, scores []float64) {
+	best := 0.0
+	for _, score := range scores {
+		best = math.Max(best, score)
+	}
+	for i := range results {
+		if best <= 0 || i >= len(scores) {
+			results[i].Rank = 0
+			continue
+		}
+		results[i].Rank = math.Round(scores[i]/best*1000) / 1000
+	}
 }
 
 // Options is the options for a search query.
 //
-// It contains the maximum number of results to return.
+// It contains the maximum number of results to return, the language of the
+// documentation to search, and how the age of pages filters and ranks the results.
 type Options struct {
 	MaxResults int `json:"max_results"`
+
+	// Language is the code of the language of the documentation to search, English by default.
+	Language string `json:"language,omitempty"`
+
+	// UpdatedSince, when not zero, restricts the search to the pages updated since then.
+	UpdatedSince time.Time `json:"updated_since,omitzero"`
+
+	// BoostRecent ranks the pages updated in the RecentWindow before the last change of the
+	// indexed documentation higher.
+	BoostRecent bool `json:"boost_recent,omitempty"`
 }
 
 // DefaultOptions returns default search configuration.
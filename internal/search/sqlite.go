@@ -1,34 +1,165 @@
 package search
 
 import (
+	"context"
 	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"strings"
 
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/mattn/go-sqlite3"
 )
 
+// IndexPageSize is the page size of index databases. Pages larger than SQLite's 4KB default
+// hold more of the FTS5 segments per read.
+const IndexPageSize = 8192
+
+// IndexMmapSize is the maximum number of bytes of an index database memory-mapped when it is
+// opened read-only, large enough to map the whole documentation index.
+const IndexMmapSize = 256 * 1024 * 1024
+
+// readOnlyDriverName is the database/sql driver opening index databases read-only, with
+// pragmas tuned for querying applied to every connection.
+const readOnlyDriverName = "sqlite3_index_readonly"
+
+// readOnlyDriver is the driver registered as readOnlyDriverName.
+var readOnlyDriver = &sqlite3.SQLiteDriver{
+	ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+		_, err := conn.Exec(fmt.Sprintf(`
+                PRAGMA query_only = ON;
+                PRAGMA mmap_size = %d;
+                PRAGMA temp_store = MEMORY;
+            `, IndexMmapSize), nil)
+		return err
+	},
+}
+
+func init() {
+	sql.Register(readOnlyDriverName, readOnlyDriver)
+}
+
+// OpenReadOnlySQLiteDB opens the index database at the given path for querying only,
+// memory-mapping it so that searches don't go through read system calls.
+func OpenReadOnlySQLiteDB(path string) (*sql.DB, error) {
+	return sql.Open(readOnlyDriverName, readOnlyDSN(path))
+}
+
+// OpenDeferredReadOnlySQLiteDB is OpenReadOnlySQLiteDB for an index database that is not
+// written yet: it returns at once, and connections wait for resolve to return the path of
+// the database, or fail with its error, so that the first query waits for the index.
+func OpenDeferredReadOnlySQLiteDB(resolve func(ctx context.Context) (string, error)) *sql.DB {
+	return sql.OpenDB(&deferredConnector{resolve: resolve})
+}
+
+// deferredConnector connects to the index database once its path is resolved.
+type deferredConnector struct {
+	resolve func(ctx context.Context) (string, error)
+}
+
+func (c *deferredConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	path, err := c.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return readOnlyDriver.Open(readOnlyDSN(path))
+}
+
+func (c *deferredConnector) Driver() driver.Driver {
+	return readOnlyDriver
+}
+
+func readOnlyDSN(path string) string {
+	return "file:" + path + "?mode=ro"
+}
+
 // InitSQLiteDB opens (or creates) the SQLite database at the given path and ensures
-// the FTS5 table exists with the intended tokenizer options.
-// If recreate is true, it drops any existing table named `documentation` first to rebuild.
-func InitSQLiteDB(path string, recreate bool) (*sql.DB, error) {
+// the FTS5 tables exist with the intended tokenizer options, alongside the pages table
+// holding the full content of each indexed documentation page.
+// A table is created for English documentation, and for each of the additional languages,
+// with the tokenizer of the language (see Language).
+// If recreate is true, it drops any existing documentation and `pages` tables first to rebuild.
+func InitSQLiteDB(path string, recreate bool, languages ...string) (*sql.DB, error) {
+	tables := []Language{}
+	for _, code := range append([]string{DefaultLanguage}, languages...) {
+		language, err := LookupLanguage(code)
+		if err != nil {
+			return nil, err
+		}
+		tables = append(tables, language)
+	}
+
 	db, err := sql.Open("sqlite3", path)
 	if err != nil {
 		return nil, err
 	}
 
-	// Optionally recreate the FTS5 table for documentation chunks.
-	// Use unicode61 tokenizer with extra token characters useful for code.
+	// The page size only applies to databases without tables yet, or when they are vacuumed
+	if _, err := db.Exec(fmt.Sprintf(`PRAGMA page_size = %d;`, IndexPageSize)); err != nil {
+		return nil, err
+	}
+
+	// Optionally recreate the FTS5 tables for documentation chunks.
 	if recreate {
-		if _, err := db.Exec(`DROP TABLE IF EXISTS documentation;`); err != nil {
-			return nil, err
+		for _, code := range LanguageCodes() {
+			if _, err := db.Exec(fmt.Sprintf(`DROP TABLE IF EXISTS %s;`, supportedLanguages[code].Table())); err != nil {
+				return nil, err
+			}
+		}
+		for _, table := range []string{"pages", "doc_versions", "page_versions"} {
+			if _, err := db.Exec(fmt.Sprintf(`DROP TABLE IF EXISTS %s;`, table)); err != nil {
+				return nil, err
+			}
 		}
 	}
-	_, err = db.Exec(`
-        CREATE VIRTUAL TABLE IF NOT EXISTS documentation
+	for _, language := range tables {
+		_, err = db.Exec(fmt.Sprintf(`
+        CREATE VIRTUAL TABLE IF NOT EXISTS %s
         USING fts5(
             title,
             content,
             path,
-            tokenize = 'unicode61 remove_diacritics 2 tokenchars ''_/:#@-$'''
+            tokenize = '%s'
+        );
+    `, language.Table(), strings.ReplaceAll(language.Tokenizer, "'", "''")))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create the %s documentation table: %w", language.Name, err)
+		}
+	}
+
+	// The pages table stores whole documentation pages, keyed by their path relative
+	// to the documentation root, so they can be served as-is without the original sources.
+	// Updated is the Unix time of the last change of the page, 0 when unknown.
+	_, err = db.Exec(`
+        CREATE TABLE IF NOT EXISTS pages (
+            path        TEXT PRIMARY KEY,
+            title       TEXT NOT NULL,
+            description TEXT NOT NULL DEFAULT '',
+            content     TEXT NOT NULL,
+            updated     INTEGER NOT NULL DEFAULT 0
+        );
+    `)
+	if err != nil {
+		return nil, err
+	}
+
+	// The doc_versions table lists the indexed k6 versions of the documentation, such as
+	// v1.2.x, and flags the latest one, whose pages are those of the pages table. The
+	// page_versions table stores the pages of the other versions which differ from the
+	// latest ones, so that versions can be compared without storing every page of each:
+	// pages it doesn't hold are those of the latest version, and a NULL content marks the
+	// pages of the latest version the version doesn't have.
+	_, err = db.Exec(`
+        CREATE TABLE IF NOT EXISTS doc_versions (
+            version TEXT PRIMARY KEY,
+            latest  INTEGER NOT NULL DEFAULT 0
+        );
+        CREATE TABLE IF NOT EXISTS page_versions (
+            version     TEXT NOT NULL,
+            path        TEXT NOT NULL,
+            title       TEXT NOT NULL DEFAULT '',
+            description TEXT NOT NULL DEFAULT '',
+            content     TEXT,
+            PRIMARY KEY (version, path)
         );
     `)
 	if err != nil {
@@ -36,3 +167,28 @@ func InitSQLiteDB(path string, recreate bool) (*sql.DB, error) {
 	}
 	return db, nil
 }
+
+// OptimizeSQLiteDB prepares a fully indexed database for querying: it merges the FTS5 index
+// segments into one, gathers the statistics the query planner uses, and vacuums the database
+// so that it is compact and uses IndexPageSize pages.
+func OptimizeSQLiteDB(db *sql.DB) error {
+	indexed, err := IndexedLanguages(context.Background(), db)
+	if err != nil {
+		return err
+	}
+
+	statements := []string{}
+	for _, code := range indexed {
+		table := supportedLanguages[code].Table()
+		statements = append(statements, fmt.Sprintf(`INSERT INTO %[1]s(%[1]s) VALUES('optimize');`, table))
+	}
+	statements = append(statements, `ANALYZE;`, `PRAGMA optimize;`, `VACUUM;`)
+
+	for _, statement := range statements {
+		if _, err := db.Exec(statement); err != nil {
+			return fmt.Errorf("failed to run %q: %w", statement, err)
+		}
+	}
+
+	return nil
+}
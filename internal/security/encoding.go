@@ -0,0 +1,188 @@
+package security
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+// sniffLen is the number of leading bytes content types are sniffed from.
+const sniffLen = 512
+
+// EncodingIssue describes script content that is not UTF-8 text, which k6 would otherwise
+// fail to parse with errors unrelated to the actual problem.
+type EncodingIssue struct {
+	// MIMEType is the media type the content was sniffed as, e.g. application/x-gzip.
+	MIMEType string `json:"mime_type"`
+	// Encoding is the encoding detected, e.g. UTF-16LE, or binary for binary data.
+	Encoding string `json:"encoding"`
+	// Offset is the byte offset of the first invalid sequence.
+	Offset int `json:"offset"`
+	// Line is the 1-based line of the offset, for content read as lines.
+	Line       int    `json:"line,omitempty"`
+	Message    string `json:"message"`
+	Suggestion string `json:"suggestion"`
+}
+
+// DetectEncodingIssue checks that script content is UTF-8 text, and returns the issue of
+// binary data, UTF-16 or UTF-32 text, HTML documents and text in legacy encodings such as
+// Windows-1252, or nil for UTF-8 text.
+func DetectEncodingIssue(content string) *EncodingIssue {
+	if content == "" {
+		return nil
+	}
+
+	head := content[:min(len(content), sniffLen)]
+	mimeType := http.DetectContentType([]byte(head))
+	mediaType, _, _ := strings.Cut(mimeType, ";")
+
+	if encoding := unicodeEncoding(head); encoding != "" {
+		return &EncodingIssue{
+			MIMEType:   mimeType,
+			Encoding:   encoding,
+			Message:    fmt.Sprintf("script content is encoded in %s, not UTF-8", encoding),
+			Suggestion: fmt.Sprintf("Save the script as UTF-8, e.g. with 'iconv -f %s -t UTF-8 script.js'", encoding),
+		}
+	}
+
+	switch {
+	case mediaType == "text/html" || mediaType == "text/xml":
+		offset := len(content) - len(strings.TrimLeft(content, " \t\r\n\f"))
+		return &EncodingIssue{
+			MIMEType:   mimeType,
+			Encoding:   "UTF-8",
+			Offset:     offset,
+			Line:       lineOf(content, offset),
+			Message:    fmt.Sprintf("script content is a markup document (%s), not a k6 script", mediaType),
+			Suggestion: "Pass the raw content of the script, e.g. the raw.githubusercontent.com URL of a script rather than its github.com page",
+		}
+	case !strings.HasPrefix(mediaType, "text/"):
+		offset := 0
+		if mediaType == "application/octet-stream" {
+			// Unrecognized binary data: point at the first binary byte
+			offset = strings.IndexFunc(head, isBinaryRune)
+		}
+		return &EncodingIssue{
+			MIMEType:   mimeType,
+			Encoding:   "binary",
+			Offset:     max(offset, 0),
+			Line:       lineOf(content, max(offset, 0)),
+			Message:    fmt.Sprintf("script content is binary data (%s), not UTF-8 text", mediaType),
+			Suggestion: "Pass the source code of the script rather than a compressed, compiled or binary file. Compressed scripts can be passed with the 'gzip+base64' encoding",
+		}
+	}
+
+	if offset := strings.IndexByte(content, 0); offset >= 0 {
+		return &EncodingIssue{
+			MIMEType:   mimeType,
+			Encoding:   "binary",
+			Offset:     offset,
+			Line:       lineOf(content, offset),
+			Message:    "script content contains binary data (a NUL byte), not UTF-8 text",
+			Suggestion: "Remove the binary data from the script, or load it from a companion file with open(path, 'b')",
+		}
+	}
+
+	if offset, multibyte := firstInvalidUTF8(content); offset >= 0 {
+		// Text without any valid multibyte sequence was most likely saved in a legacy encoding
+		encoding := "UTF-8 with invalid sequences"
+		suggestion := "Replace the invalid characters, which may come from text pasted from documents in another encoding"
+		if !multibyte {
+			encoding = "Windows-1252 or ISO-8859-1"
+			suggestion = "Save the script as UTF-8, e.g. with 'iconv -f WINDOWS-1252 -t UTF-8 script.js'"
+		}
+		return &EncodingIssue{
+			MIMEType:   mimeType,
+			Encoding:   encoding,
+			Offset:     offset,
+			Line:       lineOf(content, offset),
+			Message:    fmt.Sprintf("script content is not valid UTF-8 (detected: %s)", encoding),
+			Suggestion: suggestion,
+		}
+	}
+
+	return nil
+}
+
+// Description returns the message of the issue with the location of the first invalid
+// sequence.
+func (i *EncodingIssue) Description() string {
+	description := fmt.Sprintf("%s, from byte offset %d", i.Message, i.Offset)
+	if i.Line > 0 {
+		description += fmt.Sprintf(" (line %d)", i.Line)
+	}
+	return description
+}
+
+// Diagnostic returns the description of the issue followed by its suggestion.
+func (i *EncodingIssue) Diagnostic() string {
+	return i.Description() + ". " + i.Suggestion
+}
+
+// unicodeEncoding returns the UTF-16 or UTF-32 encoding of the content, from its byte
+// order mark or, without one, from the NUL bytes of its ASCII characters, or "" for other
+// content.
+func unicodeEncoding(head string) string {
+	switch {
+	case strings.HasPrefix(head, "\xff\xfe\x00\x00"):
+		return "UTF-32LE"
+	case strings.HasPrefix(head, "\x00\x00\xfe\xff"):
+		return "UTF-32BE"
+	case strings.HasPrefix(head, "\xff\xfe"):
+		return "UTF-16LE"
+	case strings.HasPrefix(head, "\xfe\xff"):
+		return "UTF-16BE"
+	}
+
+	// ASCII text encoded in UTF-16 has a NUL byte in every other byte
+	if len(head) < 4 {
+		return ""
+	}
+	var even, odd int
+	for i := 0; i < len(head); i++ {
+		if head[i] == 0 {
+			if i%2 == 0 {
+				even++
+			} else {
+				odd++
+			}
+		}
+	}
+	pairs := len(head) / 2
+	switch {
+	case odd*4 >= pairs*3 && even == 0:
+		return "UTF-16LE"
+	case even*4 >= pairs*3 && odd == 0:
+		return "UTF-16BE"
+	default:
+		return ""
+	}
+}
+
+// firstInvalidUTF8 returns the offset of the first invalid UTF-8 sequence of the content,
+// or -1, and whether valid multibyte sequences precede it.
+func firstInvalidUTF8(content string) (int, bool) {
+	multibyte := false
+	for offset := 0; offset < len(content); {
+		r, size := utf8.DecodeRuneInString(content[offset:])
+		if r == utf8.RuneError && size <= 1 {
+			return offset, multibyte
+		}
+		if size > 1 {
+			multibyte = true
+		}
+		offset += size
+	}
+	return -1, multibyte
+}
+
+// isBinaryRune reports the control characters that do not appear in text.
+func isBinaryRune(r rune) bool {
+	return r < 0x20 && r != '\t' && r != '\n' && r != '\r' && r != '\f' && r != 0x1b
+}
+
+// lineOf returns the 1-based line of the byte offset of the content.
+func lineOf(content string, offset int) int {
+	return strings.Count(content[:offset], "\n") + 1
+}
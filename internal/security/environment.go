@@ -0,0 +1,67 @@
+package security
+
+import (
+	"os"
+	"runtime"
+)
+
+const (
+	// SecureFileMode restricts files written for k6 (scripts, workspaces) to their owner.
+	SecureFileMode = 0o600
+	// SecureDirMode restricts directories created for k6 to their owner.
+	SecureDirMode = 0o700
+)
+
+// windowsEssentialVariables are the variables processes need to run on Windows: without
+// SYSTEMROOT, for instance, networking and crypto initialization fail in spawned processes.
+var windowsEssentialVariables = []string{
+	"PATH",
+	"PATHEXT",
+	"SYSTEMROOT",
+	"SYSTEMDRIVE",
+	"WINDIR",
+	"COMSPEC",
+	"TEMP",
+	"TMP",
+	"USERPROFILE",
+	"HOMEDRIVE",
+	"HOMEPATH",
+	"APPDATA",
+	"LOCALAPPDATA",
+	"PROGRAMDATA",
+}
+
+// EnvironmentLookup looks up an environment variable, like os.LookupEnv.
+type EnvironmentLookup func(key string) (string, bool)
+
+// Environment returns the minimal environment spawned processes get on the given operating
+// system (a runtime.GOOS value), reading variables through lookup. Only PATH and HOME are
+// passed on Unix systems; Windows processes also get the system variables they need to run.
+func Environment(goos string, lookup EnvironmentLookup) []string {
+	var names []string
+	if goos == "windows" {
+		names = windowsEssentialVariables
+	} else {
+		names = []string{"PATH", "HOME"}
+	}
+
+	env := make([]string, 0, len(names))
+	for _, name := range names {
+		// Empty values are dropped, as an empty HOME would be worse than none
+		if value, ok := lookup(name); ok && value != "" {
+			env = append(env, name+"="+value)
+		}
+	}
+
+	return env
+}
+
+// RestrictPermissions restricts the given file to its owner. Windows permissions are
+// ACLs, inherited from the per-user temporary directory, rather than mode bits, so this
+// is a no-op there.
+func RestrictPermissions(file *os.File) error {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+	return file.Chmod(SecureFileMode)
+}
@@ -0,0 +1,70 @@
+package security
+
+import (
+	"slices"
+	"testing"
+)
+
+// lookupMap returns an EnvironmentLookup reading the variables of env.
+func lookupMap(env map[string]string) EnvironmentLookup {
+	return func(key string) (string, bool) {
+		value, ok := env[key]
+		return value, ok
+	}
+}
+
+func TestEnvironment(t *testing.T) {
+	t.Parallel()
+
+	host := map[string]string{
+		"PATH":         "/usr/bin",
+		"HOME":         "/home/k6",
+		"PATHEXT":      ".COM;.EXE",
+		"SYSTEMROOT":   `C:\Windows`,
+		"USERPROFILE":  `C:\Users\k6`,
+		"AWS_SECRET":   "secret",
+		"K6_CLOUD_KEY": "key",
+	}
+
+	tests := []struct {
+		name string
+		goos string
+		env  map[string]string
+		want []string
+	}{
+		{
+			name: "linux",
+			goos: "linux",
+			env:  host,
+			want: []string{"PATH=/usr/bin", "HOME=/home/k6"},
+		},
+		{
+			name: "linux empty home",
+			goos: "linux",
+			env:  map[string]string{"PATH": "/usr/bin", "HOME": ""},
+			want: []string{"PATH=/usr/bin"},
+		},
+		{
+			name: "darwin unset path",
+			goos: "darwin",
+			env:  map[string]string{"HOME": "/Users/k6"},
+			want: []string{"HOME=/Users/k6"},
+		},
+		{
+			name: "windows",
+			goos: "windows",
+			env:  host,
+			want: []string{"PATH=/usr/bin", "PATHEXT=.COM;.EXE", `SYSTEMROOT=C:\Windows`, `USERPROFILE=C:\Users\k6`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := Environment(tt.goos, lookupMap(tt.env)); !slices.Equal(got, tt.want) {
+				t.Errorf("Environment(%q) = %v, want %v", tt.goos, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package security
+
+import (
+	"crypto/x509"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/oleiade/k6-mcp/internal/logging"
+)
+
+// Network holds the proxy and certificate authority settings spawned processes get, which
+// the minimal environment of Environment would otherwise strip.
+type Network struct {
+	// HTTPProxy and HTTPSProxy are the URLs of the proxies of HTTP and HTTPS requests,
+	// e.g. "http://proxy.corp.example.com:3128". The http, https and socks5 schemes are
+	// supported.
+	HTTPProxy  string
+	HTTPSProxy string
+	// NoProxy is the comma-separated list of hosts requests to which bypass the proxies.
+	NoProxy string
+	// CABundle is the path of a PEM file of the certificate authorities to trust, for
+	// targets with certificates issued by a private CA. It replaces the system bundle file,
+	// though system certificate directories are still read, so it should also hold the
+	// public authorities other targets need.
+	CABundle string
+}
+
+var (
+	networkMu sync.RWMutex
+	network   Network
+)
+
+// SetNetwork validates the network settings and sets them as the ones of spawned processes.
+func SetNetwork(settings Network) error {
+	if err := settings.Validate(); err != nil {
+		return err
+	}
+	if settings.CABundle != "" {
+		abs, err := filepath.Abs(settings.CABundle)
+		if err != nil {
+			return fmt.Errorf("invalid CA bundle path %q: %w", settings.CABundle, err)
+		}
+		settings.CABundle = abs
+
+		// Go programs, such as k6, only read SSL_CERT_FILE on Unix systems other than macOS
+		if runtime.GOOS == "darwin" || runtime.GOOS == "windows" {
+			logging.WithComponent("security").Warn("The CA bundle is ignored by k6 on this platform; add the certificate authorities to the system trust store instead",
+				slog.String("ca_bundle", settings.CABundle),
+				slog.String("os", runtime.GOOS),
+			)
+		}
+	}
+
+	networkMu.Lock()
+	defer networkMu.Unlock()
+	network = settings
+
+	return nil
+}
+
+// Validate checks that the proxies are valid URLs, and that the CA bundle holds certificates.
+func (n Network) Validate() error {
+	for name, proxy := range map[string]string{"HTTP proxy": n.HTTPProxy, "HTTPS proxy": n.HTTPSProxy} {
+		if proxy == "" {
+			continue
+		}
+		u, err := url.Parse(proxy)
+		if err != nil {
+			return fmt.Errorf("invalid %s URL: %w", name, err)
+		}
+		switch u.Scheme {
+		case "http", "https", "socks5":
+		default:
+			return fmt.Errorf("invalid %s URL %q: the scheme must be http, https or socks5", name, u.Redacted())
+		}
+		if u.Hostname() == "" {
+			return fmt.Errorf("invalid %s URL %q: missing host", name, u.Redacted())
+		}
+	}
+
+	if n.CABundle != "" {
+		pem, err := os.ReadFile(n.CABundle)
+		if err != nil {
+			return fmt.Errorf("failed to read CA bundle: %w", err)
+		}
+		if !x509.NewCertPool().AppendCertsFromPEM(pem) {
+			return fmt.Errorf("CA bundle %s holds no PEM certificate", n.CABundle)
+		}
+	}
+
+	return nil
+}
+
+// Environment returns the environment variables of the settings. Proxies are set in both
+// the upper and lower case variables, as tools such as git only read the latter.
+func (n Network) Environment() []string {
+	var env []string
+	for _, variable := range []struct{ name, value string }{
+		{"HTTP_PROXY", n.HTTPProxy},
+		{"HTTPS_PROXY", n.HTTPSProxy},
+		{"NO_PROXY", n.NoProxy},
+	} {
+		if variable.value != "" {
+			env = append(env, variable.name+"="+variable.value, strings.ToLower(variable.name)+"="+variable.value)
+		}
+	}
+	if n.CABundle != "" {
+		env = append(env, "SSL_CERT_FILE="+n.CABundle)
+	}
+
+	return env
+}
+
+// networkEnvironment returns the environment variables of the current network settings.
+func networkEnvironment() []string {
+	networkMu.RLock()
+	defer networkMu.RUnlock()
+	return network.Environment()
+}
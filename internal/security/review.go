@@ -0,0 +1,284 @@
+package security
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Rule IDs of the findings of security reviews, in addition to those of scans.
+const (
+	RuleExternalHost     = "host-external"
+	RuleEmbeddedSecret   = "secret-embedded"
+	RuleInsecureTLS      = "tls-insecure-skip-verify"
+	RuleWeakTLS          = "tls-weak-version"
+	RuleUnboundedPayload = "payload-unbounded"
+)
+
+// Categories of the findings of security reviews.
+const (
+	CategoryHosts     = "hosts"
+	CategorySecrets   = "secrets"
+	CategoryTLS       = "tls"
+	CategoryPayloads  = "payloads"
+	CategoryExecution = "execution"
+	CategoryScript    = "script"
+)
+
+const (
+	// largePayloadBytes and hugePayloadBytes are the sizes of the payloads built by
+	// scripts reviews report as medium and high risks.
+	largePayloadBytes = 1024 * 1024
+	hugePayloadBytes  = 10 * 1024 * 1024
+
+	// revealedSecretChars is the number of characters of embedded secrets reviews reveal.
+	revealedSecretChars = 4
+)
+
+// severityRanks orders severities, the most severe first.
+var severityRanks = map[string]int{"critical": 0, "high": 1, "medium": 2, "low": 3, "info": 4}
+
+// secretPattern is a pattern of secrets embedded in scripts. When the pattern has a group,
+// the group is the secret.
+type secretPattern struct {
+	pattern     *regexp.Regexp
+	description string
+	severity    string
+}
+
+var secretPatterns = []secretPattern{
+	{regexp.MustCompile(`-----BEGIN (?:RSA |EC |DSA |OPENSSH |ENCRYPTED )?PRIVATE KEY-----`), "a private key", "critical"},
+	{regexp.MustCompile(`\b((?:AKIA|ASIA)[0-9A-Z]{16})\b`), "an AWS access key ID", "critical"},
+	{regexp.MustCompile(`\b(gh[pousr]_[A-Za-z0-9]{36,})\b`), "a GitHub token", "high"},
+	{regexp.MustCompile(`\b(glc_[A-Za-z0-9+/=_-]{32,})`), "a Grafana Cloud token", "high"},
+	{regexp.MustCompile(`\b(xox[abprs]-[A-Za-z0-9-]{10,})`), "a Slack token", "high"},
+	{regexp.MustCompile(`\b(eyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,})`), "a JSON Web Token", "high"},
+	{regexp.MustCompile(`(?i)\b(?:bearer|basic)\s+([A-Za-z0-9._~+/-]{16,}=*)`), "an authorization header credential", "high"},
+	{regexp.MustCompile(`(?i)\b[a-z][a-z0-9+.-]*://[^\s/:@'"` + "`" + `]+:([^\s/@'"` + "`" + `$]{3,})@`), "a password in a URL", "high"},
+	{regexp.MustCompile(`(?i)["']?\b[\w-]*(?:password|passwd|secret|api[_-]?key|access[_-]?key|private[_-]?key|client[_-]?secret|token)["']?\s*[:=]\s*["']([^"'\s$]{6,})["']`), "a credential assigned in the script", "high"},
+}
+
+var (
+	// insecureTLSPattern matches the option disabling the verification of TLS certificates.
+	insecureTLSPattern = regexp.MustCompile(`\binsecureSkipTLSVerify\s*:\s*true\b`)
+	// weakTLSPattern matches TLS 1.0 and 1.1, deprecated by RFC 8996.
+	weakTLSPattern = regexp.MustCompile(`\bTLS_1_[01]\b|["']tls1\.[01]["']`)
+	// payloadPatterns match the calls building payloads of a given size: their group is
+	// the size expression.
+	payloadPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`\.repeat\(\s*([^()]+?)\s*\)`),
+		regexp.MustCompile(`\bnew\s+(?:ArrayBuffer|Uint8Array|Array)\(\s*([^()]+?)\s*\)`),
+		regexp.MustCompile(`\brandomBytes\(\s*([^()]+?)\s*\)`),
+	}
+	// sizeLiteralPattern matches numeric literals and simple products of them, such as
+	// 1024 * 1024.
+	sizeLiteralPattern = regexp.MustCompile(`^[0-9_]+(?:\s*\*\s*[0-9_]+)*$`)
+)
+
+// Review is a security review of a script: the findings of its scan, along with the
+// findings of the risks that do not block runs, rated by severity, and the hosts it
+// contacts.
+type Review struct {
+	// Risk is the highest severity of the findings, or none.
+	Risk string `json:"risk"`
+	// Passed reports whether the security checks of validations and runs accept the
+	// script; the other findings do not block it.
+	Passed bool `json:"passed"`
+	// Severities counts the findings by severity, and Categories by category.
+	Severities map[string]int `json:"severities"`
+	Categories map[string]int `json:"categories"`
+	// Hosts are the hosts the script sends requests to, of which ExternalHosts are not
+	// local.
+	Hosts         []Target `json:"hosts"`
+	ExternalHosts int      `json:"external_hosts"`
+	// Findings are sorted by severity, the most severe first, then by line.
+	Findings  []Finding `json:"findings"`
+	Truncated bool      `json:"truncated,omitempty"`
+}
+
+// ReviewScript reviews the security of a script, without executing it: on top of the
+// checks of Scan, it reports the external hosts the script contacts, the secrets embedded
+// in it, the TLS settings weakening its connections, and the payloads it builds without a
+// bound or beyond a few megabytes.
+func ReviewScript(content string) *Review {
+	scan := Scan(content)
+	review := &Review{
+		Passed:     scan.Passed,
+		Severities: map[string]int{},
+		Categories: map[string]int{},
+		Hosts:      scan.Targets,
+		Findings:   scan.Findings,
+		Truncated:  scan.Truncated,
+	}
+
+	for _, target := range scan.Targets {
+		if target.Local {
+			continue
+		}
+		review.ExternalHosts++
+		review.Findings = append(review.Findings, Finding{
+			RuleID:   RuleExternalHost,
+			Severity: "info",
+			Message:  "script sends requests to the external host " + target.Host,
+			Suggestion: "Check that the host belongs to the system under test, and that it may be " +
+				"load tested: third-party services often forbid it",
+			Line: target.Line,
+		})
+	}
+
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		review.Findings = append(review.Findings, lineFindings(line, i+1)...)
+	}
+
+	for i := range review.Findings {
+		finding := &review.Findings[i]
+		finding.Category = ruleCategory(finding.RuleID)
+		review.Severities[finding.Severity]++
+		review.Categories[finding.Category]++
+	}
+	sort.SliceStable(review.Findings, func(i, j int) bool {
+		a, b := review.Findings[i], review.Findings[j]
+		if severityRanks[a.Severity] != severityRanks[b.Severity] {
+			return severityRanks[a.Severity] < severityRanks[b.Severity]
+		}
+		return a.Line < b.Line
+	})
+	if len(review.Findings) > MaxScanFindings {
+		review.Findings = review.Findings[:MaxScanFindings]
+		review.Truncated = true
+	}
+
+	review.Risk = "none"
+	if len(review.Findings) > 0 {
+		review.Risk = review.Findings[0].Severity
+	}
+
+	return review
+}
+
+// lineFindings returns the findings of the secrets, TLS settings and payloads of a line.
+func lineFindings(line string, number int) []Finding {
+	var findings []Finding
+
+	reported := false
+	for _, secret := range secretPatterns {
+		match := secret.pattern.FindStringSubmatch(line)
+		if match == nil || reported {
+			continue
+		}
+		reported = true
+		value := match[0]
+		if len(match) > 1 {
+			value = match[1]
+		}
+		findings = append(findings, Finding{
+			RuleID:     RuleEmbeddedSecret,
+			Severity:   secret.severity,
+			Message:    "script embeds " + secret.description,
+			Suggestion: "Pass secrets in environment variables read through __ENV, and revoke this one if the script was shared",
+			Line:       number,
+			Match:      redactSecret(value),
+		})
+	}
+
+	if match := insecureTLSPattern.FindString(line); match != "" {
+		findings = append(findings, Finding{
+			RuleID:     RuleInsecureTLS,
+			Severity:   "high",
+			Message:    "script disables the verification of TLS certificates",
+			Suggestion: "Trust the certificate authority of the target instead, or keep the option to test environments with self-signed certificates",
+			Line:       number,
+			Match:      match,
+		})
+	}
+	if match := weakTLSPattern.FindString(line); match != "" {
+		findings = append(findings, Finding{
+			RuleID:     RuleWeakTLS,
+			Severity:   "medium",
+			Message:    "script allows TLS 1.0 or 1.1, which are deprecated",
+			Suggestion: "Require TLS 1.2 or later, unless the test targets legacy clients on purpose",
+			Line:       number,
+			Match:      match,
+		})
+	}
+
+	for _, pattern := range payloadPatterns {
+		for _, match := range pattern.FindAllStringSubmatch(line, -1) {
+			if finding, ok := payloadFinding(match[0], match[1], number); ok {
+				findings = append(findings, finding)
+			}
+		}
+	}
+
+	return findings
+}
+
+// payloadFinding returns the finding of a payload built with the size expression, if its
+// size is unbounded or large.
+func payloadFinding(match, size string, line int) (Finding, bool) {
+	finding := Finding{
+		RuleID:     RuleUnboundedPayload,
+		Line:       line,
+		Match:      match,
+		Suggestion: "Bound the size of generated payloads, e.g. with Math.min(size, 1024 * 1024): every VU holds them in memory, and sends them on every iteration",
+	}
+
+	if !sizeLiteralPattern.MatchString(size) {
+		finding.Severity = "medium"
+		finding.Message = "script builds a payload whose size the script does not bound: " + size
+		return finding, true
+	}
+
+	bytes := 1
+	for _, factor := range strings.Split(size, "*") {
+		n, err := strconv.Atoi(strings.ReplaceAll(strings.TrimSpace(factor), "_", ""))
+		if err != nil || n > hugePayloadBytes {
+			bytes = hugePayloadBytes + 1
+			break
+		}
+		bytes *= n
+		if bytes > hugePayloadBytes {
+			break
+		}
+	}
+
+	switch {
+	case bytes > hugePayloadBytes:
+		finding.Severity = "high"
+		finding.Message = "script builds a payload of more than 10MB"
+	case bytes > largePayloadBytes:
+		finding.Severity = "medium"
+		finding.Message = "script builds a payload of more than 1MB"
+	default:
+		return Finding{}, false
+	}
+	return finding, true
+}
+
+// ruleCategory returns the category of the findings of the rule.
+func ruleCategory(ruleID string) string {
+	switch {
+	case ruleID == RuleExternalHost || ruleID == RuleLocalTarget || ruleID == RulePlaintextHTTP:
+		return CategoryHosts
+	case ruleID == RuleEmbeddedSecret:
+		return CategorySecrets
+	case ruleID == RuleInsecureTLS || ruleID == RuleWeakTLS:
+		return CategoryTLS
+	case ruleID == RuleUnboundedPayload:
+		return CategoryPayloads
+	case strings.HasPrefix(ruleID, ruleDangerousGroup):
+		return CategoryExecution
+	default:
+		return CategoryScript
+	}
+}
+
+// redactSecret reveals the first characters of a secret, enough to recognize it.
+func redactSecret(secret string) string {
+	if len(secret) <= 2*revealedSecretChars {
+		return strings.Repeat("*", len(secret))
+	}
+	return secret[:revealedSecretChars] + strings.Repeat("*", 8)
+}
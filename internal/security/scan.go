@@ -0,0 +1,249 @@
+package security
+
+import (
+	"net"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// MaxScanFindings is the maximum number of findings a scan reports.
+const MaxScanFindings = 200
+
+// Rule IDs of the scan findings.
+const (
+	RuleEmptyScript    = "script-empty"
+	RuleScriptSize     = "script-size"
+	RuleScriptEncoding = "script-encoding"
+	RulePlaintextHTTP  = "target-plaintext"
+	RuleLocalTarget    = "target-local"
+	ruleDangerousGroup = "dangerous-pattern/"
+)
+
+// dangerousPatterns are the patterns scripts are rejected for, indicating attempts to
+// execute system commands or access forbidden APIs. They are matched case-insensitively.
+var dangerousPatterns = map[string]PatternInfo{
+	"require('child_process')":   {RuleID: ruleDangerousGroup + "child-process", Description: "child process execution", Suggestion: "Use k6's built-in HTTP module for external requests"},
+	"require(\"child_process\")": {RuleID: ruleDangerousGroup + "child-process", Description: "child process execution", Suggestion: "Use k6's built-in HTTP module for external requests"},
+	"require('fs')":              {RuleID: ruleDangerousGroup + "fs", Description: "file system access", Suggestion: "Use k6's data loading features or environment variables"},
+	"require(\"fs\")":            {RuleID: ruleDangerousGroup + "fs", Description: "file system access", Suggestion: "Use k6's data loading features or environment variables"},
+	"require('os')":              {RuleID: ruleDangerousGroup + "os", Description: "operating system access", Suggestion: "Use k6's environment variable access instead"},
+	"require(\"os\")":            {RuleID: ruleDangerousGroup + "os", Description: "operating system access", Suggestion: "Use k6's environment variable access instead"},
+	"require('process')":         {RuleID: ruleDangerousGroup + "process", Description: "process manipulation", Suggestion: "Use k6's VU context and built-in functions"},
+	"require(\"process\")":       {RuleID: ruleDangerousGroup + "process", Description: "process manipulation", Suggestion: "Use k6's VU context and built-in functions"},
+	"exec(":                      {RuleID: ruleDangerousGroup + "exec", Description: "command execution", Suggestion: "Replace with k6 HTTP requests or built-in functions"},
+	"execSync(":                  {RuleID: ruleDangerousGroup + "exec", Description: "synchronous command execution", Suggestion: "Replace with k6 HTTP requests or built-in functions"},
+	"spawn(":                     {RuleID: ruleDangerousGroup + "spawn", Description: "process spawning", Suggestion: "Use k6's HTTP module for external communication"},
+	"fork(":                      {RuleID: ruleDangerousGroup + "fork", Description: "process forking", Suggestion: "Use k6's scenarios for concurrent testing"},
+	"execFile(":                  {RuleID: ruleDangerousGroup + "exec", Description: "file execution", Suggestion: "Replace with k6 built-in functionality"},
+	"eval(":                      {RuleID: ruleDangerousGroup + "eval", Description: "code evaluation", Suggestion: "Avoid dynamic code execution in k6 scripts"},
+	"Function(":                  {RuleID: ruleDangerousGroup + "function-constructor", Description: "dynamic function creation", Suggestion: "Use static function definitions in k6"},
+	"new Function(":              {RuleID: ruleDangerousGroup + "function-constructor", Description: "dynamic function creation", Suggestion: "Use static function definitions in k6"},
+	"import(":                    {RuleID: ruleDangerousGroup + "dynamic-import", Description: "dynamic import", Suggestion: "Use static import statements at the top of your script"},
+}
+
+// targetURLPattern matches the URL literals of scripts.
+var targetURLPattern = regexp.MustCompile("(?i)\\b(?:https?|wss?)://[^\\s'\"`<>(){}\\[\\],;]+")
+
+// Finding is an issue found by a scan.
+type Finding struct {
+	RuleID   string `json:"rule_id"`
+	Severity string `json:"severity"`
+	// Category groups the findings of security reviews, such as secrets or tls.
+	Category string `json:"category,omitempty"`
+	// Blocking findings make validations and runs reject the script.
+	Blocking   bool   `json:"blocking"`
+	Message    string `json:"message"`
+	Suggestion string `json:"suggestion,omitempty"`
+	// Line is the 1-based line of the finding, or 0 for findings on the whole script.
+	Line  int    `json:"line,omitempty"`
+	Match string `json:"match,omitempty"`
+}
+
+// Target is a host a script sends requests to, as found in its URL literals.
+type Target struct {
+	Scheme string `json:"scheme"`
+	Host   string `json:"host"`
+	// Port is the port of the URLs, when they set one.
+	Port string `json:"port,omitempty"`
+	// Local targets are loopback, private network or local domain hosts.
+	Local bool `json:"local"`
+	// Line is the line of the first URL of the target.
+	Line int `json:"line"`
+	URLs int `json:"urls"`
+}
+
+// ScanResult is the result of a scan.
+type ScanResult struct {
+	// Passed reports whether the scan found no blocking findings.
+	Passed    bool      `json:"passed"`
+	SizeBytes int       `json:"size_bytes"`
+	MaxBytes  int       `json:"max_bytes"`
+	Findings  []Finding `json:"findings"`
+	// Truncated reports whether findings beyond MaxScanFindings were left out.
+	Truncated bool     `json:"truncated,omitempty"`
+	Targets   []Target `json:"targets"`
+}
+
+// Scan runs the security checks of script contents, without executing the script: the
+// size limit and dangerous patterns ValidateScriptContent rejects scripts for, and an
+// analysis of the hosts the script targets. Unlike ValidateScriptContent, it reports
+// every finding rather than the first, with its line.
+func Scan(content string) *ScanResult {
+	result := &ScanResult{
+		SizeBytes: len(content),
+		MaxBytes:  MaxScriptSizeBytes,
+		Findings:  []Finding{},
+		Targets:   []Target{},
+	}
+
+	if strings.TrimSpace(content) == "" {
+		result.add(Finding{
+			RuleID:     RuleEmptyScript,
+			Severity:   "high",
+			Blocking:   len(content) == 0,
+			Message:    "script content is empty",
+			Suggestion: "Provide a k6 script with an import and a default function",
+		})
+	}
+	if len(content) > MaxScriptSizeBytes {
+		finding := Finding{
+			RuleID:   RuleScriptSize,
+			Severity: "high",
+			Blocking: true,
+			Message:  "script size exceeds the maximum allowed size",
+		}
+		if suggestions := generateContentOptimizationSuggestions(content); len(suggestions) > 0 {
+			finding.Suggestion = strings.Join(suggestions, "; ")
+		}
+		result.add(finding)
+	}
+
+	if issue := DetectEncodingIssue(content); issue != nil {
+		result.add(Finding{
+			RuleID:     RuleScriptEncoding,
+			Severity:   "high",
+			Blocking:   true,
+			Message:    issue.Description(),
+			Suggestion: issue.Suggestion,
+			Line:       issue.Line,
+		})
+	}
+
+	lines := strings.Split(content, "\n")
+	result.scanPatterns(lines)
+	result.scanTargets(lines)
+
+	sort.SliceStable(result.Findings, func(i, j int) bool { return result.Findings[i].Line < result.Findings[j].Line })
+	if len(result.Findings) > MaxScanFindings {
+		result.Findings = result.Findings[:MaxScanFindings]
+		result.Truncated = true
+	}
+
+	result.Passed = true
+	for _, finding := range result.Findings {
+		if finding.Blocking {
+			result.Passed = false
+		}
+	}
+
+	return result
+}
+
+func (r *ScanResult) add(finding Finding) {
+	r.Findings = append(r.Findings, finding)
+}
+
+// scanPatterns reports the dangerous patterns of the lines, once per rule and line.
+func (r *ScanResult) scanPatterns(lines []string) {
+	patterns := make([]string, 0, len(dangerousPatterns))
+	for pattern := range dangerousPatterns {
+		patterns = append(patterns, pattern)
+	}
+	// Longer patterns first, so that lines report "new Function(" rather than "Function("
+	sort.Slice(patterns, func(i, j int) bool {
+		if len(patterns[i]) != len(patterns[j]) {
+			return len(patterns[i]) > len(patterns[j])
+		}
+		return patterns[i] < patterns[j]
+	})
+
+	for i, line := range lines {
+		lineLower := strings.ToLower(line)
+		reported := make(map[string]bool)
+		for _, pattern := range patterns {
+			info := dangerousPatterns[pattern]
+			if reported[info.RuleID] || !strings.Contains(lineLower, strings.ToLower(pattern)) {
+				continue
+			}
+			reported[info.RuleID] = true
+			r.add(Finding{
+				RuleID:     info.RuleID,
+				Severity:   "critical",
+				Blocking:   true,
+				Message:    "script contains potentially dangerous pattern related to " + info.Description,
+				Suggestion: info.Suggestion,
+				Line:       i + 1,
+				Match:      pattern,
+			})
+		}
+	}
+}
+
+// scanTargets collects the hosts of the URL literals of the lines, and reports plaintext
+// and local targets.
+func (r *ScanResult) scanTargets(lines []string) {
+	targets := make(map[string]int)
+	for i, line := range lines {
+		for _, match := range targetURLPattern.FindAllString(line, -1) {
+			u, err := url.Parse(match)
+			// Hosts interpolated in template literals are left out
+			if err != nil || u.Hostname() == "" || strings.Contains(u.Host, "$") {
+				continue
+			}
+
+			scheme := strings.ToLower(u.Scheme)
+			host := strings.ToLower(u.Hostname())
+			key := scheme + "://" + strings.ToLower(u.Host)
+			if index, seen := targets[key]; seen {
+				r.Targets[index].URLs++
+				continue
+			}
+
+			target := Target{Scheme: scheme, Host: host, Port: u.Port(), Local: isLocalHost(host), Line: i + 1, URLs: 1}
+			targets[key] = len(r.Targets)
+			r.Targets = append(r.Targets, target)
+
+			switch {
+			case target.Local:
+				r.add(Finding{
+					RuleID:     RuleLocalTarget,
+					Severity:   "low",
+					Message:    "script targets the local host " + host,
+					Suggestion: "Consider using environment variables for URLs, e.g. __ENV.BASE_URL, so that the script can target other environments",
+					Line:       i + 1,
+					Match:      u.Redacted(),
+				})
+			case scheme == "http" || scheme == "ws":
+				r.add(Finding{
+					RuleID:     RulePlaintextHTTP,
+					Severity:   "medium",
+					Message:    "script sends unencrypted requests to " + host,
+					Suggestion: "Use https:// or wss:// URLs, so that credentials and test data are not sent in plaintext",
+					Line:       i + 1,
+					Match:      u.Redacted(),
+				})
+			}
+		}
+	}
+}
+
+// isLocalHost reports whether the host is a loopback, private network or local domain host.
+func isLocalHost(host string) bool {
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") || strings.HasSuffix(host, ".local") || strings.HasSuffix(host, ".internal") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && (ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsLinkLocalUnicast())
+}
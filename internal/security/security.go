@@ -6,10 +6,11 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
-	"os/exec"
+	"runtime"
 	"strings"
 	"time"
 
+	"github.com/oleiade/k6-mcp/internal/k6bin"
 	"github.com/oleiade/k6-mcp/internal/logging"
 )
 
@@ -61,6 +62,20 @@ func ValidateScriptContent(content string) error {
 		return err
 	}
 
+	// Reject binary or wrongly-encoded content, which k6 would fail to parse obscurely
+	if issue := DetectEncodingIssue(content); issue != nil {
+		logger.Debug("Script content validation failed: invalid encoding",
+			slog.String("encoding", issue.Encoding),
+			slog.String("mime_type", issue.MIMEType),
+			slog.Int("offset", issue.Offset),
+		)
+
+		return &Error{
+			Type:    "INVALID_ENCODING",
+			Message: issue.Diagnostic(),
+		}
+	}
+
 	if len(content) > MaxScriptSizeBytes {
 		// Auto-suggest content optimization
 		suggestions := generateContentOptimizationSuggestions(content)
@@ -201,11 +216,11 @@ func ValidateEnvironment() error {
 	
 	logger.Debug("Validating environment dependencies")
 	
-	// Check if k6 is available in PATH
-	if _, err := exec.LookPath("k6"); err != nil {
+	// Check if k6 is available in PATH, or installed by the server
+	if _, err := k6bin.Find(); err != nil {
 		securityErr := &Error{
 			Type:    "MISSING_DEPENDENCY",
-			Message: "k6 executable not found in PATH",
+			Message: k6bin.NotFoundMessage,
 			Cause:   err,
 		}
 		
@@ -227,19 +242,12 @@ func ValidateEnvironment() error {
 func SecureEnvironment() []string {
 	logger := logging.WithComponent("security")
 	
-	// Provide only essential environment variables
-	essential := []string{
-		"PATH=" + os.Getenv("PATH"),
-	}
-
-	// Add HOME only if it exists and is not empty
-	if home := os.Getenv("HOME"); home != "" {
-		essential = append(essential, "HOME="+home)
-	}
+	// Provide only essential environment variables, see Environment, and the network settings
+	essential := append(Environment(runtime.GOOS, os.LookupEnv), networkEnvironment()...)
 
 	logger.Debug("Created secure environment",
 		slog.Int("env_var_count", len(essential)),
-		slog.Bool("has_home", os.Getenv("HOME") != ""),
+		slog.String("os", runtime.GOOS),
 	)
 
 	return essential
@@ -285,27 +293,6 @@ func generateContentOptimizationSuggestions(content string) []string {
 
 // checkDangerousPatternsWithSuggestions scans for dangerous patterns and provides corrections
 func checkDangerousPatternsWithSuggestions(content string) error {
-	// Enhanced patterns with specific suggestions
-	dangerousPatterns := map[string]PatternInfo{
-		"require('child_process')":     {Description: "child process execution", Suggestion: "Use k6's built-in HTTP module for external requests"},
-		"require(\"child_process\")":   {Description: "child process execution", Suggestion: "Use k6's built-in HTTP module for external requests"},
-		"require('fs')":                {Description: "file system access", Suggestion: "Use k6's data loading features or environment variables"},
-		"require(\"fs\")":              {Description: "file system access", Suggestion: "Use k6's data loading features or environment variables"},
-		"require('os')":                {Description: "operating system access", Suggestion: "Use k6's environment variable access instead"},
-		"require(\"os\")":              {Description: "operating system access", Suggestion: "Use k6's environment variable access instead"},
-		"require('process')":           {Description: "process manipulation", Suggestion: "Use k6's VU context and built-in functions"},
-		"require(\"process\")":         {Description: "process manipulation", Suggestion: "Use k6's VU context and built-in functions"},
-		"exec(":                        {Description: "command execution", Suggestion: "Replace with k6 HTTP requests or built-in functions"},
-		"execSync(":                    {Description: "synchronous command execution", Suggestion: "Replace with k6 HTTP requests or built-in functions"},
-		"spawn(":                       {Description: "process spawning", Suggestion: "Use k6's HTTP module for external communication"},
-		"fork(":                        {Description: "process forking", Suggestion: "Use k6's scenarios for concurrent testing"},
-		"execFile(":                    {Description: "file execution", Suggestion: "Replace with k6 built-in functionality"},
-		"eval(":                        {Description: "code evaluation", Suggestion: "Avoid dynamic code execution in k6 scripts"},
-		"Function(":                    {Description: "dynamic function creation", Suggestion: "Use static function definitions in k6"},
-		"new Function(":                {Description: "dynamic function creation", Suggestion: "Use static function definitions in k6"},
-		"import(":                      {Description: "dynamic import", Suggestion: "Use static import statements at the top of your script"},
-	}
-
 	contentLower := strings.ToLower(content)
 
 	for pattern, info := range dangerousPatterns {
@@ -382,6 +369,7 @@ func detectScriptIssuesWithSuggestions(content string) []string {
 
 // PatternInfo contains information about dangerous patterns
 type PatternInfo struct {
+	RuleID      string
 	Description string
 	Suggestion  string
 }
\ No newline at end of file
@@ -0,0 +1,197 @@
+package style
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Rules of the style lint.
+const (
+	RuleModules     = "modules"
+	RuleLanguage    = "language"
+	RuleQuotes      = "quotes"
+	RuleCheckNaming = "check_naming"
+)
+
+// Violation is a part of a script not following the style.
+type Violation struct {
+	Rule       string `json:"rule"`
+	Message    string `json:"message"`
+	Suggestion string `json:"suggestion"`
+	Line       int    `json:"line,omitempty"`
+}
+
+var (
+	// esmImportPattern matches ES import statements, with their binding, and the quote of
+	// their module.
+	esmImportPattern = regexp.MustCompile(`^\s*import\s+(?:([^'"]*?)\s+from\s+)?(['"])([^'"]+)['"]`)
+	// requirePattern matches require calls, with the binding they are assigned to, and the
+	// quote of their module.
+	requirePattern = regexp.MustCompile(`(?:\b(?:const|let|var)\s+([^=]+?)\s*=\s*)?\brequire\(\s*(['"])([^'"]+)['"]\s*\)`)
+	// esmExportPattern matches ES export statements.
+	esmExportPattern = regexp.MustCompile(`^\s*export\s+(default|const|let|var|function|async|class|\{)`)
+	// commonJSExportPattern matches assignments to module.exports and exports.
+	commonJSExportPattern = regexp.MustCompile(`^\s*(module\.)?exports(\.\w+)?\s*=`)
+	// typeScriptPattern matches the TypeScript-only declarations and annotations of lines.
+	typeScriptPattern = regexp.MustCompile(`^\s*(export\s+)?(interface\s+\w+|type\s+\w+\s*=|enum\s+\w+|import\s+type\b|declare\s)|\)\s*:\s*\w+(\[\])?\s*(=>|\{)|\b(?:const|let|var)\s+\w+\s*:\s*\w+`)
+	// checkCallPattern matches the beginning of check calls.
+	checkCallPattern = regexp.MustCompile(`\bcheck\s*\(`)
+	// checkNamePattern matches the quoted keys of the objects of check calls.
+	checkNamePattern = regexp.MustCompile(`(['"])((?:\\.|[^\\'"])*)(['"])\s*:`)
+)
+
+// Lint returns the violations of the style in the script.
+func (s Style) Lint(script string) []Violation {
+	var violations []Violation
+	lines := strings.Split(script, "\n")
+
+	for i, line := range lines {
+		lineNum := i + 1
+		if strings.HasPrefix(strings.TrimSpace(line), "//") {
+			continue
+		}
+
+		if match := esmImportPattern.FindStringSubmatch(line); match != nil {
+			if s.Modules == ModulesCommonJS {
+				violations = append(violations, Violation{
+					Rule:       RuleModules,
+					Message:    "ES import statement in a CommonJS script",
+					Suggestion: "Import the module with require: " + s.Import(match[3], match[1]),
+					Line:       lineNum,
+				})
+			}
+			violations = append(violations, s.lintQuote(match[2], match[3], lineNum)...)
+		}
+		for _, match := range requirePattern.FindAllStringSubmatch(line, -1) {
+			if s.Modules == ModulesESM {
+				violations = append(violations, Violation{
+					Rule:       RuleModules,
+					Message:    "require call in an ES module script",
+					Suggestion: "Import the module with an import statement: " + s.Import(match[3], match[1]),
+					Line:       lineNum,
+				})
+			}
+			violations = append(violations, s.lintQuote(match[2], match[3], lineNum)...)
+		}
+
+		if s.Modules == ModulesESM && commonJSExportPattern.MatchString(line) {
+			violations = append(violations, Violation{
+				Rule:       RuleModules,
+				Message:    "module.exports assignment in an ES module script",
+				Suggestion: "Export with export statements: export const options = {...}, export default function () {...}",
+				Line:       lineNum,
+			})
+		}
+		if s.Modules == ModulesCommonJS && esmExportPattern.MatchString(line) {
+			violations = append(violations, Violation{
+				Rule:       RuleModules,
+				Message:    "ES export statement in a CommonJS script",
+				Suggestion: "Export with module.exports: module.exports.options = {...}, module.exports.default = function () {...}",
+				Line:       lineNum,
+			})
+		}
+
+		if s.Language == LanguageJavaScript && typeScriptPattern.MatchString(line) {
+			violations = append(violations, Violation{
+				Rule:       RuleLanguage,
+				Message:    "TypeScript syntax in a JavaScript script",
+				Suggestion: "Remove the type declarations and annotations, or configure the typescript language",
+				Line:       lineNum,
+			})
+		}
+	}
+
+	return append(violations, s.lintCheckNames(script)...)
+}
+
+// lintQuote returns the violation of a module specifier quoted with the wrong quote.
+func (s Style) lintQuote(quote, module string, line int) []Violation {
+	if quote != oppositeQuote(s.Quotes) {
+		return nil
+	}
+	return []Violation{{
+		Rule:       RuleQuotes,
+		Message:    fmt.Sprintf("module %s quoted with %s quotes", quote+module+quote, oppositeQuotes(s.Quotes)),
+		Suggestion: "Use " + s.Quotes + " quotes: " + s.Quote(module),
+		Line:       line,
+	}}
+}
+
+// lintCheckNames returns the violations of the names of the checks of the script.
+func (s Style) lintCheckNames(script string) []Violation {
+	var violations []Violation
+	for _, loc := range checkCallPattern.FindAllStringIndex(script, -1) {
+		call := callArguments(script[loc[1]:])
+		for _, match := range checkNamePattern.FindAllStringSubmatchIndex(call, -1) {
+			quote, name := call[match[2]:match[3]], call[match[4]:match[5]]
+			if call[match[6]:match[7]] != quote {
+				continue
+			}
+			line := strings.Count(script[:loc[1]+match[0]], "\n") + 1
+
+			if expected := s.CheckName(name); name != expected {
+				violations = append(violations, Violation{
+					Rule:       RuleCheckNaming,
+					Message:    fmt.Sprintf("check %s is not named in %s", quote+name+quote, s.CheckNaming),
+					Suggestion: "Rename the check " + s.Quote(expected),
+					Line:       line,
+				})
+			}
+			if quote == oppositeQuote(s.Quotes) {
+				violations = append(violations, Violation{
+					Rule:       RuleQuotes,
+					Message:    fmt.Sprintf("check %s quoted with %s quotes", quote+name+quote, oppositeQuotes(s.Quotes)),
+					Suggestion: "Use " + s.Quotes + " quotes: " + s.Quote(name),
+					Line:       line,
+				})
+			}
+		}
+	}
+
+	return violations
+}
+
+// callArguments returns the arguments of the call whose opening parenthesis precedes
+// text, up to its closing parenthesis, skipping the parentheses of string literals.
+func callArguments(text string) string {
+	depth := 1
+	var quote byte
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+			if depth == 0 {
+				return text[:i]
+			}
+		}
+	}
+	return text
+}
+
+// oppositeQuote returns the quote character of the other quote style.
+func oppositeQuote(quotes string) string {
+	if quotes == QuotesDouble {
+		return "'"
+	}
+	return `"`
+}
+
+// oppositeQuotes returns the name of the other quote style.
+func oppositeQuotes(quotes string) string {
+	if quotes == QuotesDouble {
+		return QuotesSingle
+	}
+	return QuotesDouble
+}
@@ -0,0 +1,280 @@
+// Package style holds the conventions of the k6 scripts the server writes: their module
+// system, language, quotes and check names. Generators apply the configured style, and the
+// validation lints scripts against it, so that generated code matches team conventions.
+package style
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Module systems of scripts.
+const (
+	// ModulesESM scripts import modules with import statements, and export their options
+	// and default function.
+	ModulesESM = "esm"
+	// ModulesCommonJS scripts import modules with require, and assign module.exports, as
+	// run by k6 in its extended compatibility mode.
+	ModulesCommonJS = "commonjs"
+)
+
+// Languages of scripts.
+const (
+	LanguageJavaScript = "javascript"
+	LanguageTypeScript = "typescript"
+)
+
+// Quotes of string literals.
+const (
+	QuotesSingle = "single"
+	QuotesDouble = "double"
+)
+
+// Naming conventions of check names.
+const (
+	// CheckNamingSentence names checks with sentences: "status is 200".
+	CheckNamingSentence = "sentence"
+	// CheckNamingSnakeCase names checks in snake_case: "status_is_200".
+	CheckNamingSnakeCase = "snake_case"
+	// CheckNamingKebabCase names checks in kebab-case: "status-is-200".
+	CheckNamingKebabCase = "kebab-case"
+)
+
+// Style is the conventions of scripts.
+type Style struct {
+	Modules     string `json:"modules"`
+	Language    string `json:"language"`
+	Quotes      string `json:"quotes"`
+	CheckNaming string `json:"check_naming"`
+}
+
+// Error represents errors of invalid style configurations.
+type Error struct {
+	Type    string
+	Message string
+	Cause   error
+}
+
+func (e *Error) Error() string {
+	if e.Cause != nil {
+		return fmt.Sprintf("%s: %s (caused by: %v)", e.Type, e.Message, e.Cause)
+	}
+	return fmt.Sprintf("%s: %s", e.Type, e.Message)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Cause
+}
+
+// Default returns the style of the k6 documentation: JavaScript ES modules, single quotes
+// and sentence check names.
+func Default() Style {
+	return Style{
+		Modules:     ModulesESM,
+		Language:    LanguageJavaScript,
+		Quotes:      QuotesSingle,
+		CheckNaming: CheckNamingSentence,
+	}
+}
+
+var (
+	mu         sync.RWMutex
+	current    = Default()
+	configured bool
+)
+
+// Set sets the style generators apply and scripts are linted against.
+func Set(style Style) {
+	mu.Lock()
+	defer mu.Unlock()
+	current, configured = style, true
+}
+
+// Configured reports whether a style was set. Scripts are only linted against configured
+// styles, since the default one is a convention of the documentation, not of the team.
+func Configured() bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	return configured
+}
+
+// Current returns the configured style, or the default one.
+func Current() Style {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current
+}
+
+// Load reads a style from a JSON file. The conventions the file leaves out keep their
+// default.
+func Load(path string) (Style, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Style{}, &Error{Type: "CONFIG_ERROR", Message: "failed to read the style file", Cause: err}
+	}
+
+	style := Default()
+	if err := json.Unmarshal(data, &style); err != nil {
+		return Style{}, &Error{Type: "CONFIG_ERROR", Message: "invalid style file", Cause: err}
+	}
+	if err := style.Validate(); err != nil {
+		return Style{}, err
+	}
+
+	return style, nil
+}
+
+// Validate checks that the conventions of the style are known.
+func (s Style) Validate() error {
+	for _, convention := range []struct {
+		name, value string
+		allowed     []string
+	}{
+		{"modules", s.Modules, []string{ModulesESM, ModulesCommonJS}},
+		{"language", s.Language, []string{LanguageJavaScript, LanguageTypeScript}},
+		{"quotes", s.Quotes, []string{QuotesSingle, QuotesDouble}},
+		{"check_naming", s.CheckNaming, []string{CheckNamingSentence, CheckNamingSnakeCase, CheckNamingKebabCase}},
+	} {
+		if !contains(convention.allowed, convention.value) {
+			return &Error{Type: "CONFIG_ERROR", Message: fmt.Sprintf("style %q must be one of %s, got %q", convention.name, strings.Join(convention.allowed, ", "), convention.value)}
+		}
+	}
+
+	return nil
+}
+
+// Extension returns the file extension of scripts, .js or .ts.
+func (s Style) Extension() string {
+	if s.Language == LanguageTypeScript {
+		return ".ts"
+	}
+	return ".js"
+}
+
+// Quote returns the text as a string literal.
+func (s Style) Quote(text string) string {
+	quote := "'"
+	if s.Quotes == QuotesDouble {
+		quote = `"`
+	}
+
+	escaper := strings.NewReplacer(`\`, `\\`, quote, `\`+quote, "\n", `\n`, "\r", `\r`, "\t", `\t`)
+	return quote + escaper.Replace(text) + quote
+}
+
+// Import returns the statement importing the module, bound to a default name, or
+// destructured into names: import http from 'k6/http', or const { check } = require('k6').
+// Without name, the module is imported for its side effects only.
+func (s Style) Import(module, defaultName string, names ...string) string {
+	binding := defaultName
+	if len(names) > 0 {
+		binding = "{ " + strings.Join(names, ", ") + " }"
+	}
+
+	switch {
+	case binding == "" && s.Modules == ModulesCommonJS:
+		return fmt.Sprintf("require(%s);", s.Quote(module))
+	case binding == "":
+		return fmt.Sprintf("import %s;", s.Quote(module))
+	case s.Modules == ModulesCommonJS:
+		return fmt.Sprintf("const %s = require(%s);", binding, s.Quote(module))
+	default:
+		return fmt.Sprintf("import %s from %s;", binding, s.Quote(module))
+	}
+}
+
+// Export returns the beginning of the statement exporting a value under the name, to
+// follow with its value: export const options = , or module.exports.options = . The
+// default function is exported under the name "default".
+func (s Style) Export(name string) string {
+	if s.Modules == ModulesCommonJS {
+		return "module.exports." + name + " = "
+	}
+	if name == "default" {
+		return "export default "
+	}
+	return "export const " + name + " = "
+}
+
+// CheckName returns the description as a check name following the naming convention.
+// Sentences keep the case of descriptions with spaces, such as "status is 200 OK".
+func (s Style) CheckName(description string) string {
+	words := checkWords(description)
+	switch s.CheckNaming {
+	case CheckNamingSnakeCase:
+		return strings.ToLower(strings.Join(words, "_"))
+	case CheckNamingKebabCase:
+		return strings.ToLower(strings.Join(words, "-"))
+	default:
+		if strings.Contains(description, " ") && !strings.ContainsAny(description, "_-") {
+			return strings.Join(words, " ")
+		}
+		return strings.ToLower(strings.Join(words, " "))
+	}
+}
+
+// Describe returns the conventions of the style as instructions for script authors.
+func (s Style) Describe() string {
+	var conventions []string
+	if s.Language == LanguageTypeScript {
+		conventions = append(conventions, "Write the script in TypeScript, in a .ts file, typing the values k6 doesn't infer")
+	} else {
+		conventions = append(conventions, "Write the script in JavaScript, in a .js file, without TypeScript syntax")
+	}
+	if s.Modules == ModulesCommonJS {
+		conventions = append(conventions, fmt.Sprintf("Import modules with require, e.g. `%s`, and export with module.exports, e.g. `module.exports.options = {...}` and `module.exports.default = function () {...}`", s.Import("k6/http", "http")))
+	} else {
+		conventions = append(conventions, fmt.Sprintf("Import modules with ES import statements, e.g. `%s`, and export with `export const options` and `export default function`", s.Import("k6/http", "http")))
+	}
+	if s.Quotes == QuotesDouble {
+		conventions = append(conventions, "Use double quotes for string literals")
+	} else {
+		conventions = append(conventions, "Use single quotes for string literals")
+	}
+	conventions = append(conventions, fmt.Sprintf("Name checks in %s, e.g. `%s`", s.CheckNaming, s.Quote(s.CheckName("status is 200"))))
+
+	return "- " + strings.Join(conventions, "\n- ")
+}
+
+// checkWords returns the words of a check description, splitting camelCase, snake_case and
+// kebab-case words.
+func checkWords(description string) []string {
+	var words []string
+	var word []rune
+	flush := func() {
+		if len(word) > 0 {
+			words = append(words, string(word))
+			word = word[:0]
+		}
+	}
+
+	runes := []rune(description)
+	for i, r := range runes {
+		switch {
+		case r == ' ' || r == '_' || r == '-' || r == '\t':
+			flush()
+		case unicode.IsUpper(r) && i > 0 && unicode.IsLower(runes[i-1]):
+			flush()
+			word = append(word, r)
+		default:
+			word = append(word, r)
+		}
+	}
+	flush()
+
+	return words
+}
+
+// contains reports whether values holds the value.
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
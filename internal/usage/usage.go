@@ -0,0 +1,143 @@
+// Package usage tracks how the tools of the server are used since it started, for
+// operators to see how a deployment is used without external metrics infrastructure.
+package usage
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MaxLatencySamples is the number of latest calls of each tool their latency percentiles
+// are computed from, bounding the memory of long-running servers.
+const MaxLatencySamples = 1000
+
+// Stats are the usage statistics of the tools of the server.
+type Stats struct {
+	StartedAt time.Time `json:"started_at"`
+	Uptime    string    `json:"uptime"`
+	Calls     int64     `json:"calls"`
+	Failures  int64     `json:"failures"`
+	// Tools are the statistics of the tools called since the server started, the most
+	// called first.
+	Tools []ToolStats `json:"tools"`
+}
+
+// ToolStats are the usage statistics of a tool. Calls fail when the tool returns an error
+// result, such as invalid arguments or a failed validation, or panics.
+type ToolStats struct {
+	Tool        string  `json:"tool"`
+	Calls       int64   `json:"calls"`
+	Successes   int64   `json:"successes"`
+	Failures    int64   `json:"failures"`
+	SuccessRate float64 `json:"success_rate_percent"`
+	// MedianLatencyMs and P95LatencyMs are computed over the MaxLatencySamples latest calls,
+	// MaxLatencyMs over every call.
+	MedianLatencyMs float64   `json:"median_latency_ms"`
+	P95LatencyMs    float64   `json:"p95_latency_ms"`
+	MaxLatencyMs    float64   `json:"max_latency_ms"`
+	LastCalledAt    time.Time `json:"last_called_at"`
+}
+
+// toolUsage accumulates the calls of a tool.
+type toolUsage struct {
+	calls     int64
+	successes int64
+	max       time.Duration
+	last      time.Time
+	// latencies is a ring of the latencies of the latest calls, next the index of the
+	// oldest one once it is full.
+	latencies []time.Duration
+	next      int
+}
+
+var (
+	mu        sync.Mutex
+	startedAt = time.Now()
+	tools     = make(map[string]*toolUsage)
+)
+
+// Record records a call of the tool, and how long it took.
+func Record(tool string, success bool, duration time.Duration) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	usage, ok := tools[tool]
+	if !ok {
+		usage = &toolUsage{}
+		tools[tool] = usage
+	}
+
+	usage.calls++
+	if success {
+		usage.successes++
+	}
+	usage.max = max(usage.max, duration)
+	usage.last = time.Now()
+	if len(usage.latencies) < MaxLatencySamples {
+		usage.latencies = append(usage.latencies, duration)
+	} else {
+		usage.latencies[usage.next] = duration
+		usage.next = (usage.next + 1) % MaxLatencySamples
+	}
+}
+
+// Snapshot returns the usage statistics of the tools.
+func Snapshot() Stats {
+	mu.Lock()
+	defer mu.Unlock()
+
+	stats := Stats{
+		StartedAt: startedAt.UTC(),
+		Uptime:    time.Since(startedAt).Round(time.Second).String(),
+		Tools:     make([]ToolStats, 0, len(tools)),
+	}
+	for name, usage := range tools {
+		latencies := make([]time.Duration, len(usage.latencies))
+		copy(latencies, usage.latencies)
+		sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+
+		tool := ToolStats{
+			Tool:            name,
+			Calls:           usage.calls,
+			Successes:       usage.successes,
+			Failures:        usage.calls - usage.successes,
+			SuccessRate:     round(float64(usage.successes) / float64(usage.calls) * 100),
+			MedianLatencyMs: milliseconds(percentile(latencies, 0.5)),
+			P95LatencyMs:    milliseconds(percentile(latencies, 0.95)),
+			MaxLatencyMs:    milliseconds(usage.max),
+			LastCalledAt:    usage.last.UTC(),
+		}
+		stats.Calls += tool.Calls
+		stats.Failures += tool.Failures
+		stats.Tools = append(stats.Tools, tool)
+	}
+	sort.Slice(stats.Tools, func(i, j int) bool {
+		if stats.Tools[i].Calls != stats.Tools[j].Calls {
+			return stats.Tools[i].Calls > stats.Tools[j].Calls
+		}
+		return stats.Tools[i].Tool < stats.Tools[j].Tool
+	})
+
+	return stats
+}
+
+// percentile returns the nearest-rank percentile of the sorted latencies.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	return sorted[max(rank, 0)]
+}
+
+// milliseconds returns the duration in milliseconds, rounded to the hundredth.
+func milliseconds(d time.Duration) float64 {
+	return round(float64(d) / float64(time.Millisecond))
+}
+
+// round rounds the value to the hundredth.
+func round(value float64) float64 {
+	return math.Round(value*100) / 100
+}
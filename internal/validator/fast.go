@@ -0,0 +1,122 @@
+package validator
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Validation modes.
+const (
+	// ModeFull validates scripts by running a single iteration of them, which catches the
+	// errors of their default function but takes as long as its requests.
+	ModeFull = "full"
+	// ModeFast validates scripts by only running their init phase, with k6 inspect: it
+	// transpiles and parses the script, resolves its imports and checks its options, which
+	// takes a fraction of a second, without executing the default function.
+	ModeFast = "fast"
+)
+
+const (
+	// FastCacheSize is the number of fast validation results cached.
+	FastCacheSize = 256
+	// FastCacheTTL is how long fast validation results are reused, bounding the staleness of
+	// the remote modules scripts import.
+	FastCacheTTL = 10 * time.Minute
+)
+
+// fastResults caches the results of fast validations, so that agents validating the same
+// script again, e.g. after reverting an edit, get its result without starting k6.
+var fastResults = newResultCache(FastCacheSize, FastCacheTTL)
+
+// resultCache is a least recently used cache of validation results, keyed by fastCacheKey.
+type resultCache struct {
+	mu      sync.Mutex
+	size    int
+	ttl     time.Duration
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+// resultCacheEntry is a result cached by a resultCache.
+type resultCacheEntry struct {
+	key    string
+	result ValidationResult
+	stored time.Time
+}
+
+func newResultCache(size int, ttl time.Duration) *resultCache {
+	return &resultCache{
+		size:    size,
+		ttl:     ttl,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// get returns a copy of the result cached under key, or nil when there is none or it expired.
+func (c *resultCache) get(key string) *ValidationResult {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return nil
+	}
+	entry := element.Value.(*resultCacheEntry)
+	if time.Since(entry.stored) > c.ttl {
+		c.order.Remove(element)
+		delete(c.entries, key)
+		return nil
+	}
+	c.order.MoveToFront(element)
+
+	return cloneResult(&entry.result)
+}
+
+// put caches a copy of the result under key, evicting the least recently used results
+// beyond the size of the cache.
+func (c *resultCache) put(key string, result *ValidationResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := &resultCacheEntry{key: key, result: *cloneResult(result), stored: time.Now()}
+	if element, ok := c.entries[key]; ok {
+		element.Value = entry
+		c.order.MoveToFront(element)
+		return
+	}
+	c.entries[key] = c.order.PushFront(entry)
+
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*resultCacheEntry).key)
+	}
+}
+
+// cloneResult returns a copy of the result that shares none of its slices.
+func cloneResult(result *ValidationResult) *ValidationResult {
+	clone := *result
+	clone.Issues = slices.Clone(result.Issues)
+	clone.Recommendations = slices.Clone(result.Recommendations)
+	clone.NextSteps = slices.Clone(result.NextSteps)
+	return &clone
+}
+
+// fastCacheKey returns the cache key of the fast validation of the script by the k6
+// binary at k6Path, which changes with the binary, e.g. when k6 is upgraded.
+func fastCacheKey(k6Path, script string) string {
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s\x00", k6Path)
+	if info, err := os.Stat(k6Path); err == nil {
+		fmt.Fprintf(hash, "%d\x00%d\x00", info.Size(), info.ModTime().UnixNano())
+	}
+	hash.Write([]byte(script))
+	return hex.EncodeToString(hash.Sum(nil))
+}
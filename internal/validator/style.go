@@ -0,0 +1,25 @@
+package validator
+
+import "github.com/oleiade/k6-mcp/internal/style"
+
+// styleIssues returns the violations of the configured code style in the script, as
+// low severity issues, which don't fail validation.
+func styleIssues(script string) []ValidationIssue {
+	if !style.Configured() {
+		return nil
+	}
+	violations := style.Current().Lint(script)
+
+	issues := make([]ValidationIssue, 0, len(violations))
+	for _, violation := range violations {
+		issues = append(issues, ValidationIssue{
+			Type:       "style",
+			Severity:   "low",
+			Message:    "Style (" + violation.Rule + "): " + violation.Message,
+			Suggestion: violation.Suggestion,
+			LineNumber: violation.Line,
+		})
+	}
+
+	return issues
+}
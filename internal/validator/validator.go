@@ -6,12 +6,18 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
-	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 	"time"
 
+	"github.com/oleiade/k6-mcp/internal/execx"
+	"github.com/oleiade/k6-mcp/internal/k6bin"
+	"github.com/oleiade/k6-mcp/internal/locale"
 	"github.com/oleiade/k6-mcp/internal/logging"
+	"github.com/oleiade/k6-mcp/internal/sandbox"
+	"github.com/oleiade/k6-mcp/internal/security"
+	"github.com/oleiade/k6-mcp/internal/workspace"
 )
 
 const (
@@ -29,6 +35,8 @@ type ValidationResult struct {
 	Stderr          string            `json:"stderr"`
 	Error           string            `json:"error,omitempty"`
 	Duration        string            `json:"duration"`
+	Mode            string            `json:"mode"`
+	Cached          bool              `json:"cached,omitempty"`
 	ScriptURL       string            `json:"script_url,omitempty"`
 	Summary         ValidationSummary `json:"summary"`
 	Issues          []ValidationIssue `json:"issues,omitempty"`
@@ -74,11 +82,23 @@ func (e *ValidationError) Unwrap() error {
 
 // ValidateK6Script validates a k6 script by executing it with minimal configuration.
 func ValidateK6Script(ctx context.Context, script string) (*ValidationResult, error) {
+	return validate(ctx, script, ModeFull)
+}
+
+// ValidateK6ScriptFast validates a k6 script by only running its init phase, reusing the
+// result of previous validations of the same script by the same k6 binary.
+func ValidateK6ScriptFast(ctx context.Context, script string) (*ValidationResult, error) {
+	return validate(ctx, script, ModeFast)
+}
+
+// validate validates a k6 script in the mode.
+func validate(ctx context.Context, script, mode string) (*ValidationResult, error) {
 	startTime := time.Now()
 	logger := logging.WithComponent("validator")
 
 	logger.DebugContext(ctx, "Starting script validation",
 		slog.Int("script_size", len(script)),
+		slog.String("mode", mode),
 	)
 
 	// Input validation
@@ -89,10 +109,11 @@ func ValidateK6Script(ctx context.Context, script string) (*ValidationResult, er
 		})
 
 		issue := createValidationIssueFromError(err)
-		return &ValidationResult{
+		result := &ValidationResult{
 			Valid:    false,
 			Error:    err.Error(),
 			Duration: time.Since(startTime).String(),
+			Mode:     mode,
 			Summary: ValidationSummary{
 				Status:      "failed",
 				Description: "Script validation failed during input validation",
@@ -103,21 +124,45 @@ func ValidateK6Script(ctx context.Context, script string) (*ValidationResult, er
 			Issues:          []ValidationIssue{issue},
 			Recommendations: getRecommendationsForIssue(issue),
 			NextSteps:       []string{"Fix the validation issue and try again", "Use the 'search' tool for k6 documentation"},
-		}, err
+		}
+		localizeResult(result)
+		return result, err
 	}
 
 	logging.ValidationEvent(ctx, "input_validation", true, map[string]interface{}{
 		"script_size": len(script),
 	})
 
-	// Create secure temporary file
-	tempFile, cleanup, err := createSecureTempFile(script)
+	// Reuse the result of the init phase of scripts validated before
+	cacheKey := ""
+	if mode == ModeFast {
+		if k6Path, err := k6bin.Find(); err == nil {
+			cacheKey = fastCacheKey(k6Path, script)
+			if cached := fastResults.get(cacheKey); cached != nil {
+				cached.Duration = time.Since(startTime).String()
+				cached.Cached = true
+				logger.DebugContext(ctx, "Reused cached validation result",
+					slog.Bool("valid", cached.Valid),
+				)
+				return cached, nil
+			}
+		}
+	}
+
+	// Materialize the script in a private temporary workspace
+	ws, err := workspace.Create("k6-validate-", script, nil)
 	if err != nil {
-		logging.FileOperation(ctx, "validator", "create_temp_file", tempFile, err)
-		return &ValidationResult{
+		logging.FileOperation(ctx, "validator", "create_workspace", "", err)
+		err = &ValidationError{
+			Type:    "FILE_CREATION",
+			Message: "failed to create temporary workspace",
+			Cause:   err,
+		}
+		result := &ValidationResult{
 			Valid:    false,
 			Error:    fmt.Sprintf("failed to create temporary file: %v", err),
 			Duration: time.Since(startTime).String(),
+			Mode:     mode,
 			Summary: ValidationSummary{
 				Status:      "failed",
 				Description: "Internal error: failed to create temporary file for validation",
@@ -132,14 +177,16 @@ func ValidateK6Script(ctx context.Context, script string) (*ValidationResult, er
 				Suggestion: "This is an internal error. Please try again or contact support if the issue persists.",
 			}},
 			NextSteps: []string{"Try running the validation again", "Check system permissions and disk space"},
-		}, err
+		}
+		localizeResult(result)
+		return result, err
 	}
-	defer cleanup()
+	defer ws.Cleanup()
 
-	logging.FileOperation(ctx, "validator", "create_temp_file", tempFile, nil)
+	logging.FileOperation(ctx, "validator", "create_workspace", ws.ScriptPath, nil)
 
 	// Execute k6 validation
-	result, err := executeK6Validation(ctx, tempFile)
+	result, err := executeK6Validation(ctx, ws.ScriptPath, mode)
 	result.Duration = time.Since(startTime).String()
 
 	// Enhance result with analysis if validation completed
@@ -147,6 +194,12 @@ func ValidateK6Script(ctx context.Context, script string) (*ValidationResult, er
 		enhanceValidationResult(result, script)
 	}
 
+	// Cache the results of completed validations, valid or not, but not those of timeouts
+	// or cancellations
+	if cacheKey != "" && err == nil {
+		fastResults.put(cacheKey, result)
+	}
+
 	logger.DebugContext(ctx, "Validation completed",
 		slog.Bool("valid", result.Valid),
 		slog.Int("exit_code", result.ExitCode),
@@ -165,6 +218,14 @@ func validateInput(script string) error {
 		}
 	}
 
+	// Binary or wrongly-encoded content would surface as obscure parse errors of k6
+	if issue := security.DetectEncodingIssue(script); issue != nil {
+		return &ValidationError{
+			Type:    "ENCODING_VALIDATION",
+			Message: issue.Diagnostic(),
+		}
+	}
+
 	if len(script) > MaxScriptSize {
 		return &ValidationError{
 			Type:    "INPUT_VALIDATION",
@@ -196,147 +257,73 @@ func validateInput(script string) error {
 	return nil
 }
 
-// createSecureTempFile creates a secure temporary file with the script content.
-func createSecureTempFile(script string) (string, func(), error) {
-	tmpFile, err := os.CreateTemp("", "k6-script-*.js")
-	if err != nil {
-		return "", nil, &ValidationError{
-			Type:    "FILE_CREATION",
-			Message: "failed to create temporary file",
-			Cause:   err,
-		}
-	}
-
-	filename := tmpFile.Name()
-	cleanup := func() {
-		if removeErr := os.Remove(filename); removeErr != nil {
-			logging.WithComponent("validator").Warn("Failed to remove temporary file",
-				slog.String("operation", "cleanup"),
-				slog.String("error", removeErr.Error()),
-			)
-		}
-	}
-
-	if err := setupTempFile(tmpFile, script); err != nil {
-		cleanupTempFile(tmpFile)
-		return "", nil, err
-	}
-
-	return filename, cleanup, nil
-}
-
-// setupTempFile configures and writes to the temporary file.
-func setupTempFile(tmpFile *os.File, script string) error {
-	// Set secure permissions (owner read/write only)
-	const secureFileMode = 0o600
-	if err := tmpFile.Chmod(secureFileMode); err != nil {
-		return &ValidationError{
-			Type:    "FILE_PERMISSION",
-			Message: "failed to set secure file permissions",
-			Cause:   err,
-		}
-	}
-
-	// Write script content
-	if _, err := tmpFile.WriteString(script); err != nil {
-		return &ValidationError{
-			Type:    "FILE_WRITE",
-			Message: "failed to write script to temporary file",
-			Cause:   err,
-		}
-	}
-
-	if err := tmpFile.Close(); err != nil {
-		return &ValidationError{
-			Type:    "FILE_CLOSE",
-			Message: "failed to close temporary file",
-			Cause:   err,
-		}
-	}
-
-	return nil
-}
-
-// cleanupTempFile safely cleans up a temporary file.
-func cleanupTempFile(tmpFile *os.File) {
-	logger := logging.WithComponent("validator")
-
-	if closeErr := tmpFile.Close(); closeErr != nil {
-		logger.Warn("Failed to close temp file",
-			slog.String("operation", "cleanup"),
-			slog.String("error", closeErr.Error()),
-		)
-	}
-	if removeErr := os.Remove(tmpFile.Name()); removeErr != nil {
-		logger.Warn("Failed to remove temp file",
-			slog.String("operation", "cleanup"),
-			slog.String("error", removeErr.Error()),
-		)
-	}
-}
-
-// executeK6Validation executes k6 with the given script file.
-func executeK6Validation(ctx context.Context, scriptPath string) (*ValidationResult, error) {
+// executeK6Validation executes k6 with the given script file: a single iteration of it, or
+// its init phase only in fast mode.
+func executeK6Validation(ctx context.Context, scriptPath, mode string) (*ValidationResult, error) {
 	logger := logging.WithComponent("validator")
 	startTime := time.Now()
 
-	// Create context with timeout
-	cmdCtx, cancel := context.WithTimeout(ctx, DefaultTimeout)
-	defer cancel()
-
 	// Check if k6 is available
-	if _, err := exec.LookPath("k6"); err != nil {
+	k6Path, err := k6bin.Find()
+	if err != nil {
 		logger.ErrorContext(ctx, "k6 executable not found",
 			slog.String("error", err.Error()),
 		)
 		return &ValidationResult{
 				Valid: false,
-				Error: "k6 executable not found in PATH",
+				Error: k6bin.NotFoundMessage,
+				Mode:  mode,
 			}, &ValidationError{
 				Type:    "K6_NOT_FOUND",
-				Message: "k6 executable not found in PATH",
+				Message: k6bin.NotFoundMessage,
 				Cause:   err,
 			}
 	}
 
 	// Prepare k6 command with minimal configuration and additional validation flags
-	cmd := exec.CommandContext(cmdCtx, "k6", "run",
+	args := []string{
+		"run",
 		"--vus", "1",
 		"--iterations", "1",
 		"--quiet",
 		"--insecure-skip-tls-verify",
 		"--log-format=json",
 		"--no-usage-report",
-		scriptPath)
-
-	// Set minimal environment
-	cmd.Env = []string{
-		"PATH=" + os.Getenv("PATH"),
-		"HOME=" + os.Getenv("HOME"),
+		scriptPath,
+	}
+	command := "k6 run"
+	if mode == ModeFast {
+		// inspect runs the init phase, and checks the options with the execution requirements
+		args = []string{"inspect", "--execution-requirements", "--log-format=json", scriptPath}
+		command = "k6 inspect"
 	}
 
 	logger.DebugContext(ctx, "Executing k6 validation command",
-		slog.String("command", "k6 run"),
-		slog.String("script_path", getPathType(scriptPath)),
+		slog.String("command", command),
+		slog.String("script_path", logging.PathType(scriptPath)),
 	)
 
-	// Execute command and capture output
-	stdout, stderr, exitCode, err := executeCommand(cmd)
+	// Execute command with a minimal environment, and capture output
+	execution, err := sandbox.Default().Run(ctx, sandbox.Command{Path: k6Path, Args: args, Env: security.SecureEnvironment(), Mounts: []string{filepath.Dir(scriptPath)}},
+		execx.WithTimeout(DefaultTimeout),
+	)
+	stdout, stderr, exitCode := execution.Stdout, execution.Stderr, execution.ExitCode
 
 	// Log execution results
-	logging.ExecutionEvent(ctx, "validator", "k6 run", time.Since(startTime), exitCode, err)
+	logging.ExecutionEvent(ctx, "validator", command, time.Since(startTime), exitCode, err)
 
 	result := &ValidationResult{
 		Valid:    exitCode == 0,
 		ExitCode: exitCode,
 		Stdout:   stdout,
 		Stderr:   stderr,
+		Mode:     mode,
 	}
 
 	// Handle different types of errors
 	if err != nil {
 		switch {
-		case errors.Is(err, context.DeadlineExceeded):
+		case errors.Is(err, execx.ErrTimeout):
 			// Command timed out
 			result.Error = fmt.Sprintf("k6 validation timed out after %v", DefaultTimeout)
 			return result, &ValidationError{
@@ -344,6 +331,14 @@ func executeK6Validation(ctx context.Context, scriptPath string) (*ValidationRes
 				Message: fmt.Sprintf("k6 validation timed out after %v", DefaultTimeout),
 				Cause:   err,
 			}
+		case errors.Is(err, execx.ErrCanceled):
+			// The request was cancelled, or the client disconnected
+			result.Error = "k6 validation was cancelled"
+			return result, &ValidationError{
+				Type:    "CANCELED",
+				Message: "k6 validation was cancelled",
+				Cause:   err,
+			}
 		default:
 			var exitError *exec.ExitError
 			if errors.As(err, &exitError) {
@@ -372,43 +367,6 @@ func executeK6Validation(ctx context.Context, scriptPath string) (*ValidationRes
 	return result, nil
 }
 
-// executeCommand executes a command and returns stdout, stderr, exit code, and error.
-func executeCommand(cmd *exec.Cmd) (stdout, stderr string, exitCode int, err error) {
-	var stdoutBuf, stderrBuf strings.Builder
-	cmd.Stdout = &stdoutBuf
-	cmd.Stderr = &stderrBuf
-
-	err = cmd.Run()
-	stdout = stdoutBuf.String()
-	stderr = stderrBuf.String()
-
-	if err != nil {
-		var exitError *exec.ExitError
-		if errors.As(err, &exitError) {
-			exitCode = exitError.ExitCode()
-		} else {
-			exitCode = -1
-		}
-	}
-
-	if err != nil {
-		return stdout, stderr, exitCode, fmt.Errorf("command execution failed: %w", err)
-	}
-	return stdout, stderr, exitCode, nil
-}
-
-// getPathType returns a safe representation of file paths for logging
-func getPathType(path string) string {
-	if strings.Contains(path, "temp") || strings.Contains(path, "tmp") {
-		return "temporary"
-	} else if strings.HasSuffix(path, ".js") {
-		return "javascript"
-	} else if strings.HasSuffix(path, ".ts") {
-		return "typescript"
-	}
-	return "other"
-}
-
 // isThresholdFailure checks if a k6 run failure was due to threshold violations
 // rather than syntax or runtime errors. For validation purposes, we only care
 // about syntax correctness, not whether performance thresholds are met.
@@ -487,6 +445,8 @@ func mapErrorTypeToIssueType(errorType string) string {
 		return "syntax"
 	case "SECURITY_VALIDATION":
 		return "security"
+	case "ENCODING_VALIDATION":
+		return "encoding"
 	case "FILE_CREATION", "FILE_PERMISSION", "FILE_WRITE", "FILE_CLOSE":
 		return "system"
 	case "K6_NOT_FOUND", "EXECUTION_ERROR":
@@ -505,7 +465,7 @@ func mapErrorTypeToSeverity(errorType string) string {
 		return "critical"
 	case "K6_NOT_FOUND", "EXECUTION_ERROR":
 		return "critical"
-	case "INPUT_VALIDATION":
+	case "INPUT_VALIDATION", "ENCODING_VALIDATION":
 		return "high"
 	case "TIMEOUT":
 		return "medium"
@@ -529,6 +489,8 @@ func getSuggestionForErrorType(errorType, message string) string {
 		return "Check your script syntax and ensure it follows k6 script structure"
 	case "SECURITY_VALIDATION":
 		return "Remove dangerous patterns from your script. k6 scripts should only use k6 APIs, not Node.js system functions."
+	case "ENCODING_VALIDATION":
+		return "Pass the source code of the script as UTF-8 text."
 	case "K6_NOT_FOUND":
 		return "Install k6 on your system. Visit https://k6.io/docs/getting-started/installation/ for installation instructions."
 	case "TIMEOUT":
@@ -553,6 +515,10 @@ func getRecommendationsForIssue(issue ValidationIssue) []string {
 			"Remove any Node.js system calls or file system access",
 			"Use the 'search' tool with query 'k6 modules' to see available APIs",
 		}
+	case "style":
+		return []string{
+			"Follow the code style of the team, configured on the server with K6_MCP_SCRIPT_STYLE",
+		}
 	case "environment":
 		return []string{
 			"Ensure k6 is installed and available in your PATH",
@@ -585,6 +551,7 @@ func enhanceValidationResult(result *ValidationResult, script string) {
 
 	// Analyze the script and k6 output for additional insights
 	issues := analyzeScriptContent(script)
+	issues = append(issues, styleIssues(script)...)
 	if result.Stderr != "" || result.ExitCode != 0 {
 		issues = append(issues, analyzeK6Output(result.Stderr, result.Stdout)...)
 	}
@@ -607,6 +574,19 @@ func enhanceValidationResult(result *ValidationResult, script string) {
 
 	// Add workflow integration suggestions
 	addWorkflowIntegrationSuggestions(result)
+
+	localizeResult(result)
+}
+
+// localizeResult translates the summary, suggestions, recommendations and next steps of
+// the result into the configured locale.
+func localizeResult(result *ValidationResult) {
+	result.Summary.Description = locale.T(result.Summary.Description)
+	for i := range result.Issues {
+		result.Issues[i].Suggestion = locale.T(result.Issues[i].Suggestion)
+	}
+	result.Recommendations = locale.List(result.Recommendations)
+	result.NextSteps = locale.List(result.NextSteps)
 }
 
 // analyzeScriptContent performs static analysis of the script content
@@ -624,13 +604,13 @@ func analyzeScriptContent(script string) []ValidationIssue {
 		line = strings.TrimSpace(line)
 		lineNum := i + 1
 
-		// Check for imports
-		if strings.HasPrefix(line, "import") && strings.Contains(line, "k6/") {
+		// Check for imports, as ES import statements or CommonJS require calls
+		if (strings.HasPrefix(line, "import") || strings.Contains(line, "require(")) && strings.Contains(line, "k6/") {
 			hasImport = true
 		}
 
-		// Check for default function
-		if strings.Contains(line, "export default function") {
+		// Check for default function, exported as an ES module or with module.exports
+		if strings.Contains(line, "export default function") || strings.Contains(line, "exports.default = function") {
 			hasDefaultFunction = true
 		}
 
@@ -756,7 +736,7 @@ func generateValidationSummary(result *ValidationResult) ValidationSummary {
 			summary.Severity = "none"
 		} else {
 			summary.Status = "warning"
-			summary.Description = fmt.Sprintf("Script validation passed but found %d minor issues", summary.IssueCount)
+			summary.Description = locale.Sprintf("Script validation passed but found %d minor issues", summary.IssueCount)
 			summary.Severity = "low"
 		}
 	} else {
@@ -795,6 +775,10 @@ func generateNextSteps(result *ValidationResult) []string {
 	if result.Valid && result.ExitCode == 0 {
 		steps := []string{"Your script is ready to run!"}
 
+		if result.Mode == ModeFast {
+			steps = append(steps, "Fast validation only ran the init phase of the script: validate it in 'full' mode to also run an iteration of its default function")
+		}
+
 		if len(result.Issues) > 0 {
 			steps = append(steps, "Consider addressing the minor issues found for better script quality")
 		}
@@ -0,0 +1,355 @@
+// Package workspace materializes k6 scripts and their companion files (local modules,
+// data files, gRPC proto definitions) in private temporary directories.
+package workspace
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/oleiade/k6-mcp/internal/logging"
+	"github.com/oleiade/k6-mcp/internal/privacy"
+	"github.com/oleiade/k6-mcp/internal/security"
+)
+
+const (
+	// ScriptName is the name the main script is written under.
+	ScriptName = "script.js"
+	// MaxFiles is the maximum number of companion files a workspace can hold.
+	MaxFiles = 100
+	// MaxFilesSizeBytes is the maximum total size of the companion files.
+	MaxFilesSizeBytes = 10 * 1024 * 1024 // 10MB
+)
+
+var (
+	// grpcLoadPattern matches the import paths argument of k6/net/grpc Client.load() calls.
+	grpcLoadPattern = regexp.MustCompile(`\.load\(\s*\[([^\]]*)\]`)
+
+	// stringLiteralPattern matches single- and double-quoted JavaScript string literals.
+	stringLiteralPattern = regexp.MustCompile(`'([^'\\]*)'|"([^"\\]*)"`)
+)
+
+// Workspace is a private temporary directory holding a script and its companion files.
+type Workspace struct {
+	// Dir is the workspace directory.
+	Dir string
+
+	// ScriptPath is the path of the main script, at the root of Dir.
+	ScriptPath string
+}
+
+// Create writes the script and its files, keyed by their path relative to the script,
+// to a new temporary directory whose name starts with prefix. Files should have been
+// checked with ValidateFiles first.
+//
+// Proto files given by their bare name are also placed in each relative import path
+// the script passes to k6/net/grpc's Client.load(), where k6 resolves them. When the
+// privacy policy scrubs files, the personal data of the data files is masked before they
+// are written, and that of the script with the same scrubber, so that the values the
+// script looks up keep matching the masked data. Modules and proto files are left as
+// they are, since masking digits could corrupt their numeric literals.
+func Create(prefix, script string, files map[string]string) (*Workspace, error) {
+	dir, err := os.MkdirTemp("", prefix+"*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+
+	w := &Workspace{
+		Dir:        dir,
+		ScriptPath: filepath.Join(dir, ScriptName),
+	}
+
+	var scrubber *privacy.Scrubber
+	if policy := privacy.Current(); policy.Files && hasDataFiles(files) {
+		scrubber = privacy.NewScrubber(policy.Rules)
+	}
+
+	content := script
+	if scrubber != nil {
+		content = scrubber.Scrub(script)
+	}
+	if err := w.writeFile(ScriptName, content); err != nil {
+		w.Cleanup()
+		return nil, err
+	}
+
+	importPaths := protoImportPaths(script)
+	for name, content := range files {
+		targets, err := fileTargets(name, importPaths)
+		if err != nil {
+			w.Cleanup()
+			return nil, err
+		}
+		if scrubber != nil && isDataFile(name) {
+			content = scrubber.Scrub(content)
+		}
+
+		for _, target := range targets {
+			if err := w.writeFile(target, content); err != nil {
+				w.Cleanup()
+				return nil, err
+			}
+		}
+	}
+
+	if scrubber != nil {
+		if findings := scrubber.Findings(); len(findings) > 0 {
+			logging.WithComponent("workspace").Info("Scrubbed personal data from the script and its data files",
+				slog.String("dir", dir),
+				slog.Any("findings", findings),
+			)
+		}
+	}
+
+	return w, nil
+}
+
+// File is a file of a workspace layout.
+type File struct {
+	// Path is the slash-separated path of the file, relative to the workspace directory.
+	Path  string `json:"path"`
+	Bytes int    `json:"bytes"`
+	// Source is the companion file the file is a copy of, for proto files copied to the
+	// import paths of the script.
+	Source string `json:"source,omitempty"`
+}
+
+// Layout returns the files Create would write for the script and its files, sorted by
+// path, without writing them.
+func Layout(script string, files map[string]string) ([]File, error) {
+	layout := []File{{Path: ScriptName, Bytes: len(script)}}
+
+	importPaths := protoImportPaths(script)
+	for name, content := range files {
+		targets, err := fileTargets(name, importPaths)
+		if err != nil {
+			return nil, err
+		}
+
+		for i, target := range targets {
+			file := File{Path: target, Bytes: len(content)}
+			if i > 0 {
+				file.Source = targets[0]
+			}
+			layout = append(layout, file)
+		}
+	}
+	sort.Slice(layout, func(i, j int) bool { return layout[i].Path < layout[j].Path })
+
+	return layout, nil
+}
+
+// fileTargets returns the slash-separated relative paths a companion file is written to:
+// its own, and for bare proto files, their copies in the import paths.
+func fileTargets(name string, importPaths []string) ([]string, error) {
+	cleaned, err := CleanRelativePath(name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid file path %q: %w", name, err)
+	}
+
+	targets := []string{cleaned}
+	if isBareProto(cleaned) {
+		for _, importPath := range importPaths {
+			targets = append(targets, path.Join(importPath, cleaned))
+		}
+	}
+
+	return targets, nil
+}
+
+// Cleanup removes the workspace directory.
+func (w *Workspace) Cleanup() {
+	if err := os.RemoveAll(w.Dir); err != nil {
+		logging.WithComponent("workspace").Warn("Failed to remove temporary directory",
+			slog.String("operation", "cleanup"),
+			slog.String("error", err.Error()),
+		)
+	}
+}
+
+// TempPrefixes are the name prefixes of the workspaces, checkouts and run outputs the server
+// creates in the temporary directory, which processes killed mid-run leave behind.
+var TempPrefixes = []string{"k6-run-", "k6-validate-", "k6-archive-", "k6-git-", "k6-mcp-run-output-"}
+
+// PruneStale removes the entries of the temporary directory named after TempPrefixes and
+// last modified before the cutoff, and returns their number and total size. With dryRun,
+// nothing is removed.
+func PruneStale(before time.Time, dryRun bool) (int, int64, error) {
+	entries, err := os.ReadDir(os.TempDir())
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to list the temporary directory: %w", err)
+	}
+
+	var removed int
+	var freed int64
+	for _, entry := range entries {
+		if !hasTempPrefix(entry.Name()) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || !info.ModTime().Before(before) {
+			continue
+		}
+
+		stale := filepath.Join(os.TempDir(), entry.Name())
+		size := diskUsage(stale)
+		if !dryRun {
+			if err := os.RemoveAll(stale); err != nil {
+				continue
+			}
+		}
+		removed++
+		freed += size
+	}
+
+	return removed, freed, nil
+}
+
+func hasTempPrefix(name string) bool {
+	for _, prefix := range TempPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// diskUsage returns the total size of the regular files at root.
+func diskUsage(root string) int64 {
+	var size int64
+	_ = filepath.WalkDir(root, func(_ string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info, err := entry.Info(); err == nil && info.Mode().IsRegular() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
+
+// writeFile writes content to the slash-separated relative path name, with owner-only permissions.
+func (w *Workspace) writeFile(name, content string) error {
+	target := filepath.Join(w.Dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(target), security.SecureDirMode); err != nil {
+		return fmt.Errorf("failed to create directory for %q: %w", name, err)
+	}
+
+	if err := os.WriteFile(target, []byte(content), security.SecureFileMode); err != nil {
+		return fmt.Errorf("failed to write %q: %w", name, err)
+	}
+
+	return nil
+}
+
+// ValidateFiles checks the number, size, and paths of companion files, and runs the
+// script security validation on JavaScript and TypeScript modules.
+func ValidateFiles(files map[string]string) error {
+	if len(files) > MaxFiles {
+		return fmt.Errorf("too many files (%d); at most %d files are supported", len(files), MaxFiles)
+	}
+
+	totalSize := 0
+	for name, content := range files {
+		cleaned, err := CleanRelativePath(name)
+		if err != nil {
+			return fmt.Errorf("invalid file path %q: %w", name, err)
+		}
+		if cleaned == ScriptName {
+			return fmt.Errorf("file path %q is reserved for the main script", ScriptName)
+		}
+
+		if isModule(cleaned) {
+			if err := security.ValidateScriptContent(content); err != nil {
+				return fmt.Errorf("module %q failed security validation: %w", name, err)
+			}
+		}
+
+		totalSize += len(content)
+	}
+
+	if totalSize > MaxFilesSizeBytes {
+		return fmt.Errorf("files total size (%d bytes) exceeds maximum allowed size (%d bytes)", totalSize, MaxFilesSizeBytes)
+	}
+
+	return nil
+}
+
+// CleanRelativePath cleans a slash-separated path, and ensures it is relative and does
+// not escape its base directory.
+func CleanRelativePath(name string) (string, error) {
+	if name == "" {
+		return "", errors.New("path cannot be empty")
+	}
+
+	cleaned := path.Clean(filepath.ToSlash(name))
+	if path.IsAbs(cleaned) || filepath.IsAbs(name) || filepath.VolumeName(name) != "" {
+		return "", errors.New("path must be relative")
+	}
+	if cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", errors.New("path must stay within its base directory")
+	}
+
+	return cleaned, nil
+}
+
+// isModule reports whether the named file is a JavaScript or TypeScript module.
+func isModule(name string) bool {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".js", ".mjs", ".cjs", ".ts":
+		return true
+	default:
+		return false
+	}
+}
+
+// isDataFile reports whether the named companion file is a data file, rather than a
+// module or a proto definition.
+func isDataFile(name string) bool {
+	return !isModule(name) && !strings.EqualFold(path.Ext(name), ".proto")
+}
+
+// hasDataFiles reports whether the companion files include a data file.
+func hasDataFiles(files map[string]string) bool {
+	for name := range files {
+		if isDataFile(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// isBareProto reports whether name is a proto file without a directory.
+func isBareProto(name string) bool {
+	return strings.EqualFold(path.Ext(name), ".proto") && !strings.Contains(name, "/")
+}
+
+// protoImportPaths returns the relative import paths the script passes to Client.load(),
+// which k6 resolves relative to the script.
+func protoImportPaths(script string) []string {
+	var paths []string
+	seen := make(map[string]bool)
+
+	for _, call := range grpcLoadPattern.FindAllStringSubmatch(script, -1) {
+		for _, literal := range stringLiteralPattern.FindAllStringSubmatch(call[1], -1) {
+			importPath := literal[1] + literal[2]
+			cleaned, err := CleanRelativePath(importPath)
+			if err != nil || seen[cleaned] {
+				continue
+			}
+			seen[cleaned] = true
+			paths = append(paths, cleaned)
+		}
+	}
+
+	return paths
+}
@@ -0,0 +1,85 @@
+package workspace
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/oleiade/k6-mcp/internal/privacy"
+)
+
+// The test sets the scrubbing policy of the process, so it does not run in parallel.
+func TestCreateScrubsScriptAndDataFiles(t *testing.T) {
+	previous := privacy.Current()
+	privacy.Set(privacy.Policy{Rules: privacy.Builtin(), Files: true})
+	t.Cleanup(func() { privacy.Set(previous) })
+
+	script := "const users = new SharedArray('users', () => open('data/users.csv'));\n" +
+		"if (user.email === 'alice@example.org') { call('+1 415 555 2671'); }\n"
+	module := "export const timeout = 4155552671;\nexport const owner = 'alice@example.org';\n"
+	proto := "syntax = \"proto3\";\n// Contact alice@example.org\n"
+	files := map[string]string{
+		"data/users.csv": "email,phone\nalice@example.org,+1 415 555 2671\n",
+		"lib/config.js":  module,
+		"hello.proto":    proto,
+	}
+
+	ws, err := Create("k6-test-", script, files)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	t.Cleanup(ws.Cleanup)
+
+	read := func(name string) string {
+		t.Helper()
+		data, err := os.ReadFile(filepath.Join(ws.Dir, filepath.FromSlash(name)))
+		if err != nil {
+			t.Fatalf("ReadFile(%s): %v", name, err)
+		}
+		return string(data)
+	}
+
+	gotScript, gotData := read(ScriptName), read("data/users.csv")
+	for _, value := range []string{"alice@example.org", "415 555 2671"} {
+		if strings.Contains(gotScript, value) || strings.Contains(gotData, value) {
+			t.Errorf("%q was not scrubbed:\n%s\n%s", value, gotScript, gotData)
+		}
+	}
+
+	// The script and the data files mask each value the same way
+	mask := strings.SplitN(strings.Split(gotData, "\n")[1], ",", 2)[0]
+	if !strings.Contains(gotScript, "'"+mask+"'") {
+		t.Errorf("script does not look up the masked email %q of the data file:\n%s", mask, gotScript)
+	}
+
+	// Modules and proto files are left as they are
+	if got := read("lib/config.js"); got != module {
+		t.Errorf("module was scrubbed: %q", got)
+	}
+	if got := read("hello.proto"); got != proto {
+		t.Errorf("proto file was scrubbed: %q", got)
+	}
+}
+
+// The test sets the scrubbing policy of the process, so it does not run in parallel.
+func TestCreateLeavesScriptWithoutDataFiles(t *testing.T) {
+	previous := privacy.Current()
+	privacy.Set(privacy.Policy{Rules: privacy.Builtin(), Files: true})
+	t.Cleanup(func() { privacy.Set(previous) })
+
+	script := "import { owner } from './lib/config.js';\nconst email = 'alice@example.org';\n"
+	ws, err := Create("k6-test-", script, map[string]string{"lib/config.js": "export const owner = 'bob@example.org';\n"})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	t.Cleanup(ws.Cleanup)
+
+	got, err := os.ReadFile(ws.ScriptPath)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if string(got) != script {
+		t.Errorf("script without data files was scrubbed: %q", got)
+	}
+}
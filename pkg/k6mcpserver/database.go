@@ -0,0 +1,261 @@
+//go:build fts5
+
+package k6mcpserver
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/oleiade/k6-mcp/internal/buildinfo"
+	"github.com/oleiade/k6-mcp/internal/docs"
+	"github.com/oleiade/k6-mcp/internal/handlers"
+	"github.com/oleiade/k6-mcp/internal/search"
+)
+
+const (
+	// dbCachePrefix and dbCacheSuffix delimit the names of cached index database files.
+	dbCachePrefix = "index-"
+	dbCacheSuffix = ".db"
+
+	// searchBenchmarkTimeout bounds the startup search self-benchmark.
+	searchBenchmarkTimeout = 10 * time.Second
+	// indexAgeTimeout bounds the query of the age of the index.
+	indexAgeTimeout = 5 * time.Second
+)
+
+// indexLoader writes the embedded search index database to disk in the background, so
+// that the server answers the initialize handshake of clients without waiting for it.
+type indexLoader struct {
+	done chan struct{}
+
+	// path is the path of the written database, tempPath its path when it is a temporary
+	// file to remove once the database is closed, and err the error writing it failed
+	// with. They are set once done is closed.
+	path     string
+	tempPath string
+	err      error
+	elapsed  time.Duration
+}
+
+// loadIndex starts writing the index database, returning at once.
+//
+// The index is written once to a cache file named after the server version and the index
+// checksum, and reused across restarts. When the cache directory is not writable, the index
+// is written to a temporary file instead.
+func loadIndex(logger *slog.Logger, dbData []byte, cacheDir string) *indexLoader {
+	l := &indexLoader{done: make(chan struct{})}
+
+	go func() {
+		defer close(l.done)
+		start := time.Now()
+
+		dbPath, err := cachedDBPath(logger, dbData, cacheDir)
+		if err != nil {
+			logger.Warn("Falling back to a temporary index database file", "error", err)
+
+			dbPath, err = writeTempDB(dbData)
+			if err != nil {
+				l.err = fmt.Errorf("error writing the index database: %w", err)
+				logger.Error("Search index unavailable", "error", l.err)
+				return
+			}
+			l.tempPath = dbPath
+		}
+
+		l.path, l.elapsed = dbPath, time.Since(start)
+		logger.Debug("Search index ready", slog.Duration("duration", l.elapsed))
+	}()
+
+	return l
+}
+
+// wait returns the path of the index database once written.
+func (l *indexLoader) wait(ctx context.Context) (string, error) {
+	select {
+	case <-l.done:
+		return l.path, l.err
+	case <-ctx.Done():
+		return "", fmt.Errorf("the search index is still loading: %w", ctx.Err())
+	}
+}
+
+// status returns the state of the index.
+func (l *indexLoader) status() handlers.IndexStatus {
+	select {
+	case <-l.done:
+	default:
+		return handlers.IndexStatus{State: handlers.IndexLoading}
+	}
+
+	if l.err != nil {
+		return handlers.IndexStatus{State: handlers.IndexFailed, Error: l.err.Error()}
+	}
+	return handlers.IndexStatus{State: handlers.IndexReady, Ready: true, LoadMs: l.elapsed.Milliseconds()}
+}
+
+// indexBypass returns a function reporting why the documentation tools can't be answered
+// from the index: it failed to load, or its documentation is older than maxAge, when set.
+// It reports nothing while the index loads, since the tools wait for it.
+func indexBypass(logger *slog.Logger, db *sql.DB, status func() handlers.IndexStatus, maxAge time.Duration) func(ctx context.Context) string {
+	// The index doesn't change once loaded: query its age once
+	latestUpdate := sync.OnceValue(func() time.Time {
+		ctx, cancel := context.WithTimeout(context.Background(), indexAgeTimeout)
+		defer cancel()
+
+		latest, err := docs.NewStore(db).LatestUpdate(ctx)
+		if err != nil {
+			logger.Warn("Error querying the age of the search index", "error", err)
+		}
+		return latest
+	})
+
+	return func(_ context.Context) string {
+		current := status()
+		switch current.State {
+		case handlers.IndexFailed:
+			return "the local index is unavailable: " + current.Error
+		case handlers.IndexReady:
+		default:
+			return ""
+		}
+
+		if maxAge <= 0 {
+			return ""
+		}
+		latest := latestUpdate()
+		if !latest.IsZero() && time.Since(latest) > maxAge {
+			return "the local index is stale: its documentation was last updated on " + latest.Format(time.DateOnly)
+		}
+		return ""
+	}
+}
+
+// cachedDBPath returns the path of the cached index database, writing it first if it is
+// missing or doesn't match the embedded data. Index files of other versions are removed.
+func cachedDBPath(logger *slog.Logger, dbData []byte, cacheDir string) (string, error) {
+	checksum := sha256.Sum256(dbData)
+	name := fmt.Sprintf("%s%s-%s%s", dbCachePrefix, sanitizeVersion(buildinfo.Version), hex.EncodeToString(checksum[:6]), dbCacheSuffix)
+	dbPath := filepath.Join(cacheDir, name)
+
+	if info, err := os.Stat(dbPath); err == nil && info.Size() == int64(len(dbData)) {
+		logger.Debug("Reusing cached index database", "path", dbPath)
+		return dbPath, nil
+	}
+
+	if err := os.MkdirAll(cacheDir, 0o750); err != nil {
+		return "", fmt.Errorf("error creating cache directory: %w", err)
+	}
+
+	// Write to a temporary file in the cache directory first, so that concurrently starting
+	// servers never open a partially written index.
+	tmpFile, err := os.CreateTemp(cacheDir, name+".tmp-*")
+	if err != nil {
+		return "", fmt.Errorf("error creating cached database file: %w", err)
+	}
+	defer func() { _ = os.Remove(tmpFile.Name()) }() // no-op once renamed
+
+	if _, err := tmpFile.Write(dbData); err != nil {
+		_ = tmpFile.Close()
+		return "", fmt.Errorf("error writing cached database file: %w", err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		return "", fmt.Errorf("error closing cached database file: %w", err)
+	}
+	if err := os.Rename(tmpFile.Name(), dbPath); err != nil {
+		return "", fmt.Errorf("error moving cached database file into place: %w", err)
+	}
+
+	logger.Info("Cached index database", "path", dbPath)
+	pruneCachedDBs(logger, cacheDir, name)
+
+	return dbPath, nil
+}
+
+// pruneCachedDBs removes the index databases cached by other server versions.
+func pruneCachedDBs(logger *slog.Logger, cacheDir, keep string) {
+	matches, err := filepath.Glob(filepath.Join(cacheDir, dbCachePrefix+"*"+dbCacheSuffix))
+	if err != nil {
+		return
+	}
+
+	for _, match := range matches {
+		if filepath.Base(match) == keep {
+			continue
+		}
+		if err := os.Remove(match); err != nil {
+			logger.Debug("Error removing stale cached index database", "path", match, "error", err)
+		}
+	}
+}
+
+// writeTempDB writes the index database to a temporary file and returns its path.
+func writeTempDB(dbData []byte) (string, error) {
+	dbFile, err := os.CreateTemp("", "k6-mcp-index-*.db")
+	if err != nil {
+		return "", fmt.Errorf("error creating temporary database file: %w", err)
+	}
+
+	if _, err := dbFile.Write(dbData); err != nil {
+		_ = dbFile.Close()
+		_ = os.Remove(dbFile.Name())
+		return "", fmt.Errorf("error writing index database to temporary file: %w", err)
+	}
+	if err := dbFile.Close(); err != nil {
+		_ = os.Remove(dbFile.Name())
+		return "", fmt.Errorf("error closing temporary database file: %w", err)
+	}
+
+	return dbFile.Name(), nil
+}
+
+// sanitizeVersion makes a version string safe for use in file names.
+func sanitizeVersion(version string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, version)
+}
+
+// benchmarkSearch runs representative queries against the search index and logs their latency.
+func benchmarkSearch(logger *slog.Logger, db *sql.DB) {
+	ctx, cancel := context.WithTimeout(context.Background(), searchBenchmarkTimeout)
+	defer cancel()
+
+	result, err := search.Benchmark(ctx, search.NewFullTextSearcher(db), search.BenchmarkQueries)
+	if err != nil {
+		logger.Warn("Search self-benchmark failed", "error", err)
+		return
+	}
+
+	logger.Info("Search self-benchmark",
+		slog.Int("queries", result.Queries),
+		slog.Duration("median", result.Median),
+		slog.Duration("max", result.Max),
+		slog.Duration("total", result.Total),
+	)
+}
+
+// removeDBFile removes a temporary index database file, if any.
+func removeDBFile(logger *slog.Logger, path string) {
+	if path == "" {
+		return
+	}
+
+	err := os.Remove(path)
+	if err != nil {
+		logger.Error("Error removing temporary database file", "error", err)
+	}
+}
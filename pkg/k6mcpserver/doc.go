@@ -0,0 +1,24 @@
+// Package k6mcpserver builds the k6 MCP server, so that other Go programs can embed it,
+// serve it over their own transports, or extend it with their own tools.
+//
+// The server is configured from the K6_MCP_* environment variables, like the k6-mcp
+// command, and options then enable or disable groups of tools, override limits and
+// substitute the search index:
+//
+//	srv, err := k6mcpserver.New(
+//		k6mcpserver.EnableRun(false),
+//		k6mcpserver.WithLimits(k6mcpserver.Limits{InlineOutputBytes: 8 << 10}),
+//		k6mcpserver.WithTools(myTool),
+//	)
+//	if err != nil {
+//		return err
+//	}
+//	defer srv.Close()
+//
+//	return srv.ServeStdio()
+//
+// A process runs one server at a time: the configuration of the runs is process-wide, so
+// New fails with ErrServerOpen until the open server is closed.
+//
+// The package requires the fts5 build tag, like the k6-mcp command.
+package k6mcpserver
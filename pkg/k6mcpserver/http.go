@@ -0,0 +1,77 @@
+//go:build fts5
+
+package k6mcpserver
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const (
+	// HTTPEndpoint is the path of the streamable HTTP endpoint of the server, which
+	// K6_MCP_UPSTREAM_URL points at on the servers deferring to it.
+	HTTPEndpoint = "/mcp"
+
+	// httpShutdownTimeout bounds the wait for the requests in progress once signaled.
+	httpShutdownTimeout = 10 * time.Second
+)
+
+// ServeStreamableHTTP serves the server over streamable HTTP on addr, at HTTPEndpoint,
+// until the process is signaled. With a token, clients must present it as a bearer token.
+func (s *Server) ServeStreamableHTTP(addr, token string) error {
+	mux := http.NewServeMux()
+	mux.Handle(HTTPEndpoint, requireToken(token, server.NewStreamableHTTPServer(s.mcp)))
+	httpServer := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), httpShutdownTimeout)
+		defer cancel()
+		_ = httpServer.Shutdown(shutdownCtx)
+	}()
+
+	if token == "" {
+		s.logger.Warn("Serving over HTTP without a token: set K6_MCP_HTTP_TOKEN unless the network is trusted")
+	}
+	s.logger.Info("Starting MCP server on streamable HTTP",
+		slog.String("addr", addr),
+		slog.String("endpoint", HTTPEndpoint),
+	)
+
+	if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// requireToken rejects the requests without the bearer token, when there is one.
+func requireToken(token string, next http.Handler) http.Handler {
+	if token == "" {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		presented, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(presented), []byte(token)) != 1 {
+			http.Error(w, "missing or invalid token", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
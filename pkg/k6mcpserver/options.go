@@ -0,0 +1,120 @@
+//go:build fts5
+
+package k6mcpserver
+
+import (
+	"database/sql"
+	"log/slog"
+
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/oleiade/k6-mcp/internal/runner"
+)
+
+// Option configures the server built by New.
+type Option func(*options)
+
+// Limits bounds the sizes the server handles. Zero fields keep the limits of the
+// configuration.
+type Limits struct {
+	// ScriptURLMaxBytes is the maximum size of a script fetched through script_url. It
+	// cannot exceed the script size limit the security checks enforce.
+	ScriptURLMaxBytes int64
+
+	// ArtifactsMaxBytes is the total size of the stored artifacts, beyond which the oldest
+	// ones are evicted.
+	ArtifactsMaxBytes int64
+
+	// InlineOutputBytes is the size of run outputs and search results inlined in tool
+	// responses, beyond which the remainder is retrieved with get_more_output.
+	InlineOutputBytes int
+}
+
+// ResultHook post-processes the results of runs before they are returned, to enrich them
+// with the analyses of a deployment, such as its SLO policy or cost model. Its report is
+// added to the hooks of run results, keyed by its name.
+type ResultHook = runner.ResultHook
+
+// RunResult and RunOptions are the result and options of the runs result hooks process.
+type (
+	RunResult  = runner.RunResult
+	RunOptions = runner.RunOptions
+)
+
+type options struct {
+	run    bool
+	search bool
+	limits Limits
+	db     *sql.DB
+	logger *slog.Logger
+	tools  []server.ServerTool
+	hooks  []ResultHook
+}
+
+func defaultOptions() options {
+	return options{
+		run:    true,
+		search: true,
+		logger: slog.Default(),
+	}
+}
+
+// EnableRun enables or disables the tools executing load tests: run_k6_script,
+// estimate_run, run_matrix, find_breaking_point, run_suite, run_workflow, run_distributed,
+// get_run_progress, pause_test, resume_test, scale_test and setup_k6. They are enabled by
+// default. Validations, which only run a single iteration, stay enabled.
+func EnableRun(enabled bool) Option {
+	return func(o *options) {
+		o.run = enabled
+	}
+}
+
+// EnableSearch enables or disables the tools and resources of the documentation search
+// index: search_k6_documentation, ask_documentation, browse_documentation, diff_docs,
+// lookup_api, search_types, explain_script, explain_options, annotate_script and the docs://k6/pages/
+// and docs://k6/whats_new resources. They are enabled by default; when disabled, the search
+// index is not opened.
+func EnableSearch(enabled bool) Option {
+	return func(o *options) {
+		o.search = enabled
+	}
+}
+
+// WithLimits overrides the limits of the configuration with the non-zero fields of limits.
+func WithLimits(limits Limits) Option {
+	return func(o *options) {
+		o.limits = limits
+	}
+}
+
+// WithSearchBackend serves the documentation tools from db, an index database built by
+// the prepare command, instead of the index embedded in the binary. The caller keeps
+// ownership of db: the server doesn't close it.
+func WithSearchBackend(db *sql.DB) Option {
+	return func(o *options) {
+		o.db = db
+	}
+}
+
+// WithLogger sets the logger of the server's startup and shutdown. It defaults to
+// slog.Default().
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// WithTools registers additional tools, after the built-in ones. Tools of the same name
+// as a built-in tool replace it.
+func WithTools(tools ...server.ServerTool) Option {
+	return func(o *options) {
+		o.tools = append(o.tools, tools...)
+	}
+}
+
+// WithResultHooks post-processes the results of runs with additional hooks, after the
+// built-in hooks of K6_MCP_RESULT_HOOKS.
+func WithResultHooks(hooks ...ResultHook) Option {
+	return func(o *options) {
+		o.hooks = append(o.hooks, hooks...)
+	}
+}
@@ -0,0 +1,120 @@
+//go:build fts5
+
+package k6mcpserver
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	k6mcp "github.com/oleiade/k6-mcp"
+	"github.com/oleiade/k6-mcp/internal"
+	"github.com/oleiade/k6-mcp/internal/handlers"
+	"github.com/oleiade/k6-mcp/internal/resources"
+)
+
+func registerBestPracticesResource(s *server.MCPServer) {
+	bestPracticesResource := mcp.NewResource(
+		"docs://k6/best_practices",
+		"k6 best practices",
+		mcp.WithResourceDescription("Provides a list of best practices for writing k6 scripts."),
+		mcp.WithMIMEType("text/markdown"),
+	)
+
+	s.AddResource(bestPracticesResource, func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		content, err := resources.ReadFile("resources/practices/PRACTICES.md")
+		if err != nil {
+			return nil, fmt.Errorf("failed to read best practices resource: %w", err)
+		}
+
+		return []mcp.ResourceContents{
+			mcp.TextResourceContents{
+				URI:      "docs://k6/best_practices",
+				MIMEType: "text/markdown",
+				Text:     string(content),
+			},
+		}, nil
+	})
+}
+
+func registerDocumentationResources(s *server.MCPServer, h handlers.ResourceHandler) {
+	documentationTemplate := mcp.NewResourceTemplate(
+		handlers.DocumentationURIPrefix+"{+path}",
+		"k6 documentation page",
+		mcp.WithTemplateDescription("Provides the full markdown content of a k6 documentation page, addressed by its path relative to the documentation root (as returned by the search tool). Example: docs://k6/pages/javascript-api/k6-http/batch"),
+		mcp.WithTemplateMIMEType("text/markdown"),
+	)
+
+	s.AddResourceTemplate(documentationTemplate, h.Handle)
+}
+
+func registerWhatsNewResource(s *server.MCPServer, h handlers.ResourceHandler) {
+	whatsNewResource := mcp.NewResource(
+		handlers.WhatsNewURI,
+		"What's new in k6",
+		mcp.WithResourceDescription("Lists the latest k6 release notes, the experimental modules, and the documentation pages updated recently, to learn about new k6 features."),
+		mcp.WithMIMEType("text/markdown"),
+	)
+
+	s.AddResource(whatsNewResource, h.Handle)
+}
+
+func registerUsageStatsResource(s *server.MCPServer, h handlers.ResourceHandler) {
+	usageResource := mcp.NewResource(
+		handlers.UsageStatsURI,
+		"Tool usage statistics",
+		mcp.WithResourceDescription("Reports how the tools of the server were used since it started: the calls, success rate and latencies of each tool, for operators."),
+		mcp.WithMIMEType("application/json"),
+	)
+
+	s.AddResource(usageResource, h.Handle)
+}
+
+func registerTypeDefinitionsResource(s *server.MCPServer) {
+	_ = fs.WalkDir(k6mcp.TypeDefinitions, ".", func(path string, d fs.DirEntry, err error) error {
+		if !d.IsDir() && strings.HasSuffix(path, internal.DistDTSFileSuffix) {
+			bytes, err := k6mcp.TypeDefinitions.ReadFile(path)
+			if err != nil {
+				return err
+			}
+
+			relPath := strings.TrimPrefix(path, internal.DefinitionsPath)
+			uri := "types://k6/" + relPath
+			displayName := relPath
+
+			fileBytes := bytes
+			fileURI := uri
+			resource := mcp.NewResource(
+				fileURI,
+				displayName,
+				mcp.WithResourceDescription("Provides type definitions for k6."),
+				mcp.WithMIMEType("application/json"),
+			)
+
+			s.AddResource(resource, func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+				return []mcp.ResourceContents{
+					mcp.TextResourceContents{
+						URI:      fileURI,
+						MIMEType: "application/json",
+						Text:     string(fileBytes),
+					},
+				}, nil
+			})
+		}
+		return nil
+	})
+}
+
+func registerGenerateScriptPrompt(s *server.MCPServer, h handlers.PromptHandler) {
+	generateScriptPrompt := mcp.NewPrompt(
+		"generate_script",
+		mcp.WithPromptDescription("Generate a k6 script based on the user's request."),
+		mcp.WithArgument("description", mcp.ArgumentDescription("The description of the script to generate.")),
+	)
+
+	s.AddPrompt(generateScriptPrompt, h.Handle)
+}
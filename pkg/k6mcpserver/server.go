@@ -0,0 +1,508 @@
+//go:build fts5
+
+package k6mcpserver
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path/filepath"
+	"sync/atomic"
+
+	"github.com/mark3labs/mcp-go/server"
+
+	k6mcp "github.com/oleiade/k6-mcp"
+	"github.com/oleiade/k6-mcp/internal"
+	"github.com/oleiade/k6-mcp/internal/annotations"
+	"github.com/oleiade/k6-mcp/internal/apiref"
+	"github.com/oleiade/k6-mcp/internal/artifacts"
+	"github.com/oleiade/k6-mcp/internal/auth"
+	"github.com/oleiade/k6-mcp/internal/baseline"
+	"github.com/oleiade/k6-mcp/internal/budget"
+	"github.com/oleiade/k6-mcp/internal/buildinfo"
+	"github.com/oleiade/k6-mcp/internal/bundle"
+	"github.com/oleiade/k6-mcp/internal/catalog"
+	"github.com/oleiade/k6-mcp/internal/cloud"
+	"github.com/oleiade/k6-mcp/internal/config"
+	"github.com/oleiade/k6-mcp/internal/continuation"
+	"github.com/oleiade/k6-mcp/internal/defaults"
+	"github.com/oleiade/k6-mcp/internal/distributed"
+	"github.com/oleiade/k6-mcp/internal/federation"
+	"github.com/oleiade/k6-mcp/internal/handlers"
+	"github.com/oleiade/k6-mcp/internal/history"
+	"github.com/oleiade/k6-mcp/internal/k6bin"
+	"github.com/oleiade/k6-mcp/internal/locale"
+	"github.com/oleiade/k6-mcp/internal/notify"
+	"github.com/oleiade/k6-mcp/internal/privacy"
+	"github.com/oleiade/k6-mcp/internal/recorder"
+	"github.com/oleiade/k6-mcp/internal/resources"
+	"github.com/oleiade/k6-mcp/internal/retention"
+	"github.com/oleiade/k6-mcp/internal/runner"
+	"github.com/oleiade/k6-mcp/internal/sandbox"
+	"github.com/oleiade/k6-mcp/internal/scriptsource"
+	"github.com/oleiade/k6-mcp/internal/search"
+	"github.com/oleiade/k6-mcp/internal/security"
+	"github.com/oleiade/k6-mcp/internal/style"
+)
+
+// Server is a k6 MCP server, with its tools, resources and prompts registered.
+type Server struct {
+	mcp    *server.MCPServer
+	logger *slog.Logger
+
+	// db is the search index database the server opened, if any, and index the loader
+	// writing its file; both are released by Close.
+	db    *sql.DB
+	index *indexLoader
+
+	// recorder runs the recordings of start_recording, whose proxies Close stops.
+	recorder *recorder.Recorder
+
+	// stopJanitor stops the retention janitor.
+	stopJanitor context.CancelFunc
+
+	// upstream is the client of the server documentation tools are deferred to, if any.
+	upstream *federation.Client
+
+	// open is set until Close releases the process-wide configuration of the server.
+	open bool
+}
+
+// ErrServerOpen is returned by New while another server of the process is open.
+var ErrServerOpen = errors.New("a k6 MCP server is already open in this process")
+
+// serverOpen is set while a server of the process is open.
+var serverOpen atomic.Bool
+
+// New builds a k6 MCP server configured from the K6_MCP_* environment variables and the
+// options. The returned server must be closed once served.
+//
+// Only one server per process is supported: the configuration of the runs, such as the
+// sandbox, network settings, script style, warning thresholds and result hooks, is
+// process-wide, so New returns ErrServerOpen until the open server is closed.
+func New(opts ...Option) (_ *Server, err error) {
+	if !serverOpen.CompareAndSwap(false, true) {
+		return nil, ErrServerOpen
+	}
+	defer func() {
+		if err != nil {
+			serverOpen.Store(false)
+		}
+	}()
+
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+	logger := o.logger
+
+	cfg := config.Load()
+	applyLimits(&cfg, o.limits)
+
+	// Pass the proxy and CA bundle settings on to k6, whose environment is otherwise minimal
+	if err := security.SetNetwork(cfg.Network); err != nil {
+		return nil, fmt.Errorf("invalid network configuration: %w", err)
+	}
+
+	// Run k6 at the configured isolation level
+	if err := sandbox.Configure(cfg.Sandbox, logger); err != nil {
+		return nil, fmt.Errorf("invalid sandbox configuration: %w", err)
+	}
+
+	// Load the OAuth2 profiles runs acquire tokens with, keeping their secrets server-side
+	var authProvider *auth.Provider
+	if cfg.AuthProfiles != "" {
+		provider, err := auth.Load(cfg.AuthProfiles)
+		if err != nil {
+			return nil, fmt.Errorf("error loading auth profiles: %w", err)
+		}
+		authProvider = provider
+	}
+
+	// Load the code style generators apply and validation checks
+	if cfg.ScriptStyle != "" {
+		scriptStyle, err := style.Load(cfg.ScriptStyle)
+		if err != nil {
+			return nil, fmt.Errorf("error loading script style: %w", err)
+		}
+		style.Set(scriptStyle)
+		logger.Info("Using script style", slog.String("file", cfg.ScriptStyle), slog.Any("style", scriptStyle))
+	}
+
+	// Apply the same guardrails to the summary of every run
+	if cfg.WarningThresholds != "" {
+		thresholds, err := runner.ParseWarningThresholds(cfg.WarningThresholds)
+		if err != nil {
+			return nil, fmt.Errorf("invalid warning thresholds: %w", err)
+		}
+		runner.SetWarningThresholds(thresholds)
+		logger.Info("Using warning thresholds", slog.Any("thresholds", thresholds))
+	}
+
+	// Enrich the results of runs with the analyses of the deployment, e.g. its SLO policy
+	resultHooks := make([]runner.ResultHook, 0, len(cfg.ResultHooks))
+	for _, name := range cfg.ResultHooks {
+		hook, err := runner.NewResultHook(name, cfg.ResultHookSettings[name])
+		if err != nil {
+			return nil, err
+		}
+		resultHooks = append(resultHooks, hook)
+	}
+	resultHooks = append(resultHooks, o.hooks...)
+	runner.SetResultHooks(resultHooks)
+	if len(resultHooks) > 0 {
+		names := make([]string, 0, len(resultHooks))
+		for _, hook := range resultHooks {
+			names = append(names, hook.Name())
+		}
+		logger.Info("Using result hooks", slog.Any("hooks", names))
+	}
+
+	// Translate the guidance of the tools for teams working in another language
+	if cfg.Locale != "" {
+		code, err := locale.Parse(cfg.Locale)
+		if err != nil {
+			return nil, fmt.Errorf("invalid locale: %w", err)
+		}
+		locale.Set(code)
+		logger.Info("Using locale", slog.String("locale", code))
+	}
+
+	// Mask the personal data of fixtures and recordings, with the detection rules of the team
+	scrubPolicy := privacy.Policy{Rules: privacy.Builtin()}
+	if cfg.ScrubRules != "" {
+		rules, err := privacy.Load(cfg.ScrubRules)
+		if err != nil {
+			return nil, fmt.Errorf("error loading scrubbing rules: %w", err)
+		}
+		scrubPolicy.Rules = rules
+	}
+	scrubPolicy.Files, scrubPolicy.Recordings, err = privacy.ParseTargets(cfg.ScrubTargets)
+	if err != nil {
+		return nil, fmt.Errorf("invalid scrubbing targets: %w", err)
+	}
+	privacy.Set(scrubPolicy)
+	if scrubPolicy.Files || scrubPolicy.Recordings || cfg.ScrubRules != "" {
+		names := make([]string, 0, len(scrubPolicy.Rules))
+		for _, rule := range scrubPolicy.Rules {
+			names = append(names, rule.Name)
+		}
+		logger.Info("Scrubbing personal data",
+			slog.Any("rules", names),
+			slog.Bool("files", scrubPolicy.Files),
+			slog.Bool("recordings", scrubPolicy.Recordings),
+		)
+	}
+
+	// Spread runs across the configured agents, e.g. one per region
+	agents, err := distributed.ParseAgents(cfg.Agents)
+	if err != nil {
+		return nil, fmt.Errorf("invalid agents: %w", err)
+	}
+	if len(agents) > 0 && cfg.AgentToken == "" {
+		return nil, fmt.Errorf("invalid agents: %w", distributed.ErrNoToken)
+	}
+
+	// Defer the documentation tools to an upstream server while the local index can't answer
+	if err := cfg.Upstream.Validate(); err != nil {
+		return nil, err
+	}
+	maxIndexAge, err := retention.ParseAge(cfg.UpstreamMaxIndexAge)
+	if err != nil {
+		return nil, fmt.Errorf("invalid upstream max index age: %w", err)
+	}
+
+	// Bound the data long-running servers accumulate on disk
+	policy, err := retentionPolicy(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	srv := &Server{logger: logger, upstream: federation.NewClient(cfg.Upstream), open: true}
+
+	// Open the embedded database SQLite file, unless a search backend is provided. The file
+	// is written in the background, and the first queries wait for it, so that clients
+	// don't wait for it to complete their initialize handshake.
+	db := o.db
+	var indexStatus func() handlers.IndexStatus
+	if o.search && db == nil {
+		srv.index = loadIndex(logger, k6mcp.EmbeddedDB, cfg.CacheDir)
+		srv.db = search.OpenDeferredReadOnlySQLiteDB(srv.index.wait)
+		db = srv.db
+		indexStatus = srv.index.status
+	} else if o.search {
+		indexStatus = func() handlers.IndexStatus { return handlers.IndexStatus{State: handlers.IndexReady, Ready: true} }
+	}
+
+	// Answer the documentation tools from the upstream server, if any, when the index fails
+	// to load or is stale
+	var bypass func(ctx context.Context) string
+	if srv.upstream.Configured() && indexStatus != nil {
+		bypass = indexBypass(logger, db, indexStatus, maxIndexAge)
+		logger.Info("Deferring documentation tools to an upstream server",
+			slog.String("url", srv.upstream.URL()),
+			slog.Duration("max_index_age", maxIndexAge),
+		)
+	}
+	docTool := func(name string, h handlers.ToolHandler) handlers.ToolHandler {
+		return handlers.WithToolMiddleware(name, handlers.WithUpstream(name, h, srv.upstream, bypass))
+	}
+
+	// Index the declarations of the embedded type definitions, for search_types
+	var typeIndex *apiref.TypeIndex
+	if o.search {
+		definitions, err := fs.Sub(k6mcp.TypeDefinitions, filepath.ToSlash(internal.DefinitionsPath))
+		if err != nil {
+			_ = srv.Close()
+			return nil, fmt.Errorf("error opening type definitions: %w", err)
+		}
+		typeIndex, err = apiref.NewTypeIndex(definitions)
+		if err != nil {
+			_ = srv.Close()
+			return nil, fmt.Errorf("error indexing type definitions: %w", err)
+		}
+
+		// Measure search latency in the background, once the index is loaded, so that slow
+		// indexes show up in the logs
+		go func() {
+			if srv.index != nil {
+				<-srv.index.done
+			}
+			benchmarkSearch(logger, db)
+		}()
+	}
+
+	// Serve the templates of the templates directory in place of the embedded ones
+	resources.SetOverrideDir(cfg.TemplatesDir)
+	if overrides := resources.Overrides(); len(overrides) > 0 {
+		logger.Info("Overriding embedded templates",
+			slog.String("dir", cfg.TemplatesDir),
+			slog.Any("files", overrides),
+		)
+	}
+
+	fetcher := scriptsource.NewFetcher(cfg)
+	baselines := baseline.NewStore(cfg.DataDir)
+	scripts := history.NewStore(cfg.DataDir)
+	tests := catalog.NewStore(cfg.DataDir)
+	runDefaults := defaults.NewStore(cfg.DataDir)
+	dryRuns := handlers.NewDryRuns()
+	workspaceStores := bundle.Stores{Scripts: scripts, Baselines: baselines, Defaults: runDefaults}
+	artifactStore := artifacts.NewStore(cfg.DataDir, cfg.ArtifactsMaxBytes)
+
+	// Keep responses within the context of small clients, storing the complete ones
+	budget.Set(budget.Budget{MaxTokens: cfg.ResponseMaxTokens, Artifacts: artifactStore})
+	if cfg.ResponseMaxTokens > 0 {
+		logger.Info("Using response budget", slog.Int("max_tokens", cfg.ResponseMaxTokens))
+	}
+
+	moreOutput := continuation.NewStore(cfg.InlineOutputBytes, continuation.DefaultTTL)
+	srv.recorder = recorder.New(cfg.DataDir)
+	runAnnotations := annotations.NewClient(cfg.Grafana)
+	if runAnnotations.Configured() {
+		logger.Info("Annotating runs in Grafana", slog.String("url", cfg.Grafana.URL), slog.String("dashboard_uid", cfg.Grafana.DashboardUID))
+	}
+	janitor := retention.NewJanitor(policy, scripts, artifactStore, logger)
+	janitorCtx, stopJanitor := context.WithCancel(context.Background())
+	srv.stopJanitor = stopJanitor
+	go janitor.Run(janitorCtx)
+
+	// Forward significant events to clients as logging notifications
+	hooks := &server.Hooks{}
+	notify.RegisterHooks(hooks)
+	runDefaults.RegisterHooks(hooks)
+
+	s := server.NewMCPServer(
+		"k6",
+		buildinfo.Version,
+		server.WithResourceCapabilities(true, true),
+		server.WithLogging(),
+		server.WithRecovery(),
+		server.WithHooks(hooks),
+	)
+	srv.mcp = s
+
+	// Register tools
+	if o.run {
+		registerRunTool(s, handlers.WithToolMiddleware("run_k6_script", handlers.NewRunHandler(fetcher, scripts, runDefaults, authProvider, artifactStore, moreOutput, runAnnotations, dryRuns, tests, baselines)))
+	}
+	if o.search {
+		registerDocumentationTools(s, docTool("search_k6_documentation", handlers.NewFullTextSearchHandler(db, moreOutput)))
+		registerAskDocumentationTool(s, docTool("ask_documentation", handlers.NewAskDocumentationHandler(db)))
+		registerBrowseDocumentationTool(s, docTool("browse_documentation", handlers.NewBrowseDocumentationHandler(db)))
+		registerDiffDocsTool(s, docTool("diff_docs", handlers.NewDiffDocsHandler(db)))
+		registerLookupAPITool(s, docTool("lookup_api", handlers.NewLookupAPIHandler(db)))
+		registerSearchTypesTool(s, handlers.WithToolMiddleware("search_types", handlers.NewSearchTypesHandler(typeIndex)))
+		registerFindExtensionTool(s, docTool("find_extension", handlers.NewFindExtensionHandler(db)))
+	}
+	registerValidationTool(s, handlers.WithToolMiddleware("validate_k6_script", handlers.NewValidationHandler(fetcher, scripts)))
+	registerScanScriptTool(s, handlers.WithToolMiddleware("scan_script", handlers.NewScanScriptHandler(fetcher)))
+	registerSecurityReviewTool(s, handlers.WithToolMiddleware("security_review", handlers.NewSecurityReviewHandler(fetcher)))
+	registerValidateThresholdsTool(s, handlers.WithToolMiddleware("validate_thresholds", handlers.NewValidateThresholdsHandler(fetcher)))
+	registerReadinessCheckTool(s, handlers.WithToolMiddleware("readiness_check", handlers.NewReadinessCheckHandler(fetcher, runDefaults)))
+	if o.search {
+		registerExplainScriptTool(s, handlers.WithToolMiddleware("explain_script", handlers.NewExplainScriptHandler(fetcher, db)))
+		registerExplainOptionsTool(s, handlers.WithToolMiddleware("explain_options", handlers.NewExplainOptionsHandler(fetcher, db)))
+		registerAnnotateScriptTool(s, handlers.WithToolMiddleware("annotate_script", handlers.NewAnnotateScriptHandler(fetcher, db)))
+	}
+	registerSetDefaultsTool(s, handlers.WithToolMiddleware("set_defaults", handlers.NewSetDefaultsHandler(runDefaults)))
+	registerGetDefaultsTool(s, handlers.WithToolMiddleware("get_defaults", handlers.NewGetDefaultsHandler(runDefaults)))
+	registerSetBaselineTool(s, handlers.WithToolMiddleware("set_baseline", handlers.NewSetBaselineHandler(baselines)))
+	registerCheckAgainstBaselineTool(s, handlers.WithToolMiddleware("check_against_baseline", handlers.NewCheckAgainstBaselineHandler(baselines)))
+	registerRegisterTestTool(s, handlers.WithToolMiddleware("register_test", handlers.NewRegisterTestHandler(tests, scripts, fetcher)))
+	registerUpdateTestTool(s, handlers.WithToolMiddleware("update_test", handlers.NewUpdateTestHandler(tests, scripts, fetcher)))
+	registerGetTestTool(s, handlers.WithToolMiddleware("get_test", handlers.NewGetTestHandler(tests, scripts, baselines)))
+	registerListTestsTool(s, handlers.WithToolMiddleware("list_tests", handlers.NewListTestsHandler(tests)))
+	registerDeleteTestTool(s, handlers.WithToolMiddleware("delete_test", handlers.NewDeleteTestHandler(tests)))
+	registerGetScriptHistoryTool(s, handlers.WithToolMiddleware("get_script_history", handlers.NewGetScriptHistoryHandler(scripts)))
+	registerDiffScriptVersionsTool(s, handlers.WithToolMiddleware("diff_script_versions", handlers.NewDiffScriptVersionsHandler(scripts)))
+	registerQueryRunHistoryTool(s, handlers.WithToolMiddleware("query_run_history", handlers.NewQueryRunHistoryHandler(scripts)))
+	registerHistoryStatsTool(s, handlers.WithToolMiddleware("history_stats", handlers.NewHistoryStatsHandler(scripts)))
+	registerImportResultsTool(s, handlers.WithToolMiddleware("import_results", handlers.NewImportResultsHandler(scripts)))
+	registerListAuthProfilesTool(s, handlers.WithToolMiddleware("list_auth_profiles", handlers.NewListAuthProfilesHandler(authProvider)))
+	if cloudClient := cloud.NewClient(cfg.Cloud); cloudClient.Configured() {
+		registerGetCloudTestTool(s, handlers.WithToolMiddleware("get_cloud_test", handlers.NewGetCloudTestHandler(cloudClient)))
+		registerUpdateCloudTestScriptTool(s, handlers.WithToolMiddleware("update_cloud_test_script", handlers.NewUpdateCloudTestScriptHandler(cloudClient, fetcher)))
+	}
+	if o.run {
+		registerEstimateRunTool(s, handlers.WithToolMiddleware("estimate_run", handlers.NewEstimateRunHandler(fetcher, runDefaults, dryRuns)))
+		registerRunMatrixTool(s, handlers.WithToolMiddleware("run_matrix", handlers.NewRunMatrixHandler(fetcher, scripts)))
+		registerFindBreakingPointTool(s, handlers.WithToolMiddleware("find_breaking_point", handlers.NewFindBreakingPointHandler(fetcher, scripts)))
+		registerRunSuiteTool(s, handlers.WithToolMiddleware("run_suite", handlers.NewRunSuiteHandler(scripts)))
+		registerRunWorkflowTool(s, handlers.WithToolMiddleware("run_workflow", handlers.NewRunWorkflowHandler(fetcher, scripts)))
+		registerGetRunProgressTool(s, handlers.WithToolMiddleware("get_run_progress", handlers.NewGetRunProgressHandler()))
+		registerPauseTestTool(s, handlers.WithToolMiddleware("pause_test", handlers.NewPauseTestHandler()))
+		registerResumeTestTool(s, handlers.WithToolMiddleware("resume_test", handlers.NewResumeTestHandler()))
+		registerScaleTestTool(s, handlers.WithToolMiddleware("scale_test", handlers.NewScaleTestHandler()))
+	}
+	if coordinator := distributed.NewCoordinator(agents, cfg.AgentToken); o.run && coordinator.Configured() {
+		registerRunDistributedTool(s, handlers.WithToolMiddleware("run_distributed", handlers.NewRunDistributedHandler(fetcher, coordinator)))
+	}
+	registerGenerateReportTool(s, handlers.WithToolMiddleware("generate_report", handlers.NewGenerateReportHandler(artifactStore)))
+	registerFormatSummaryTool(s, handlers.WithToolMiddleware("format_summary", handlers.NewFormatSummaryHandler()))
+	registerListArtifactsTool(s, handlers.WithToolMiddleware("list_artifacts", handlers.NewListArtifactsHandler(artifactStore)))
+	registerGetArtifactTool(s, handlers.WithToolMiddleware("get_artifact", handlers.NewGetArtifactHandler(artifactStore)))
+	registerPurgeDataTool(s, handlers.WithToolMiddleware("purge_data", handlers.NewPurgeDataHandler(janitor)))
+	registerGetMoreOutputTool(s, handlers.WithToolMiddleware("get_more_output", handlers.NewGetMoreOutputHandler(moreOutput, srv.upstream)))
+	registerExportArchiveTool(s, handlers.WithToolMiddleware("export_archive", handlers.NewExportArchiveHandler()))
+	registerExportWorkspaceTool(s, handlers.WithToolMiddleware("export_workspace", handlers.NewExportWorkspaceHandler(workspaceStores)))
+	registerImportWorkspaceTool(s, handlers.WithToolMiddleware("import_workspace", handlers.NewImportWorkspaceHandler(workspaceStores)))
+	registerInfrastructureTool(s, handlers.WithToolMiddleware("generate_k6_cloud_terraform_load_test_resource", handlers.NewInfrastructureHandler()))
+	registerComposeTool(s, handlers.WithToolMiddleware("generate_k6_docker_compose", handlers.NewComposeHandler()))
+	registerWeightScenariosTool(s, handlers.WithToolMiddleware("generate_weighted_scenarios", handlers.NewWeightScenariosHandler()))
+	registerGenerateChecksTool(s, handlers.WithToolMiddleware("generate_checks", handlers.NewGenerateChecksHandler()))
+	registerStartRecordingTool(s, handlers.WithToolMiddleware("start_recording", handlers.NewStartRecordingHandler(srv.recorder)))
+	registerStopRecordingTool(s, handlers.WithToolMiddleware("stop_recording", handlers.NewStopRecordingHandler(srv.recorder)))
+	registerScrubDataTool(s, handlers.WithToolMiddleware("scrub_data", handlers.NewScrubDataHandler()))
+
+	registerServerStatusTool(s, handlers.WithToolMiddleware("server_status", handlers.NewServerStatusHandler(indexStatus, o.run)))
+	registerSetLogLevelTool(s, handlers.WithToolMiddleware("set_log_level", handlers.NewSetLogLevelHandler()))
+	registerUsageStatsTool(s, handlers.WithToolMiddleware("usage_stats", handlers.NewUsageStatsHandler()))
+
+	// Offer to download k6 when it is missing, rather than only failing each validation and run
+	k6bin.SetManagedDir(cfg.K6Dir)
+	if _, err := k6bin.Find(); err != nil && o.run {
+		logger.Warn("k6 executable not found; registering the setup_k6 tool",
+			slog.Bool("download_enabled", cfg.K6Download),
+		)
+		registerSetupK6Tool(s, handlers.WithToolMiddleware("setup_k6", handlers.NewSetupK6Handler(cfg.K6Download)))
+	}
+
+	if len(o.tools) > 0 {
+		s.AddTools(o.tools...)
+	}
+
+	// Register resources
+	registerBestPracticesResource(s)
+	registerTypeDefinitionsResource(s)
+	registerUsageStatsResource(s, handlers.NewUsageStatsResourceHandler())
+	if o.search {
+		registerDocumentationResources(s, handlers.NewDocumentationResourceHandler(db))
+		registerWhatsNewResource(s, handlers.NewWhatsNewResourceHandler(db))
+	}
+
+	// Register prompts
+	registerGenerateScriptPrompt(s, handlers.WithPromptMiddleware("generate_k6_script", handlers.NewScriptGenerator()))
+
+	return srv, nil
+}
+
+// MCPServer returns the underlying MCP server, to serve it over other transports, such as
+// server.NewStreamableHTTPServer, or register more tools, resources and prompts.
+func (s *Server) MCPServer() *server.MCPServer {
+	return s.mcp
+}
+
+// ServeStdio serves the server over standard input and output, until standard input is
+// closed or the process is signaled.
+func (s *Server) ServeStdio() error {
+	s.logger.Info("Starting MCP server on stdio")
+	return server.ServeStdio(s.mcp)
+}
+
+// Close stops the recordings in progress and the retention janitor, closes the session
+// with the upstream server, and releases the search index database the server opened, if any.
+func (s *Server) Close() error {
+	if s.open {
+		s.open = false
+		defer serverOpen.Store(false)
+	}
+	if s.recorder != nil {
+		s.recorder.Close()
+	}
+	if s.stopJanitor != nil {
+		s.stopJanitor()
+	}
+	if s.upstream != nil {
+		_ = s.upstream.Close()
+	}
+	if s.db == nil {
+		return nil
+	}
+
+	err := s.db.Close()
+	if err != nil {
+		s.logger.Error("Error closing database connection", "error", err)
+	}
+	if s.index != nil {
+		<-s.index.done
+		removeDBFile(s.logger, s.index.tempPath)
+	}
+	s.db, s.index = nil, nil
+
+	return err
+}
+
+// retentionPolicy returns the retention policy of the configuration.
+func retentionPolicy(cfg config.Config) (retention.Policy, error) {
+	policy := retention.Policy{HistoryMaxBytes: cfg.HistoryMaxBytes}
+
+	var err error
+	if policy.MaxAge, err = retention.ParseAge(cfg.RetentionMaxAge); err != nil {
+		return policy, fmt.Errorf("invalid retention max age: %w", err)
+	}
+	if policy.WorkspaceMaxAge, err = retention.ParseAge(cfg.WorkspaceMaxAge); err != nil {
+		return policy, fmt.Errorf("invalid workspace max age: %w", err)
+	}
+	if policy.Interval, err = retention.ParseAge(cfg.RetentionInterval); err != nil {
+		return policy, fmt.Errorf("invalid retention interval: %w", err)
+	}
+
+	return policy, nil
+}
+
+// applyLimits overrides the limits of the configuration with the non-zero limits.
+func applyLimits(cfg *config.Config, limits Limits) {
+	if limits.ScriptURLMaxBytes > 0 && limits.ScriptURLMaxBytes <= security.MaxScriptSizeBytes {
+		cfg.ScriptURLMaxBytes = limits.ScriptURLMaxBytes
+	}
+	if limits.ArtifactsMaxBytes > 0 {
+		cfg.ArtifactsMaxBytes = limits.ArtifactsMaxBytes
+	}
+	if limits.InlineOutputBytes > 0 {
+		cfg.InlineOutputBytes = limits.InlineOutputBytes
+	}
+}
@@ -0,0 +1,1982 @@
+//go:build fts5
+
+package k6mcpserver
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/oleiade/k6-mcp/internal/artifacts"
+	"github.com/oleiade/k6-mcp/internal/checkgen"
+	"github.com/oleiade/k6-mcp/internal/defaults"
+	"github.com/oleiade/k6-mcp/internal/handlers"
+	"github.com/oleiade/k6-mcp/internal/report"
+	"github.com/oleiade/k6-mcp/internal/retention"
+	"github.com/oleiade/k6-mcp/internal/runner"
+)
+
+const envDescription = "Optional environment variables exposed to the script through __ENV, as an object of string values. Example: {\"BASE_URL\": \"https://staging.example.com\"}"
+
+const scriptNameDescription = "Optional name of the script, e.g. 'checkout-flow'. When set, the script is recorded as a new revision of the named script if it changed, so that get_script_history and diff_script_versions can show how it evolved and restore earlier versions."
+
+// scriptURLDescription documents the script_url parameter of the tools accepting scripts.
+const scriptURLDescription = "Location of the script to fetch server-side instead of passing its content, so that scripts stored in repositories don't have to go through the conversation. Either an https URL, a Git reference of the form git+https://host/repo.git[@ref]#path/to/script.js, or a Grafana Cloud k6 test of the form k6cloud://<test id>. Only hosts allowed by the server configuration can be fetched from. Examples: 'https://raw.githubusercontent.com/org/repo/main/tests/load.js', 'git+https://github.com/org/repo.git@main#tests/load.js', 'k6cloud://1234'"
+
+// encodingDescription documents the encoding parameter of the tools accepting content.
+const encodingDescription = "Optional encoding of the content parameters ('script', the values of 'files', and a 'summary' given as a string), so that large scripts and files fit within the message size limits of clients: 'gzip+base64' for content compressed with gzip then base64-encoded, e.g. with 'gzip -c script.js | base64', 'base64', or 'none' (default). Size limits apply to the decoded content."
+
+func registerValidationTool(s *server.MCPServer, h handlers.ToolHandler) {
+	validateTool := mcp.NewTool(
+		"validate_k6_script",
+		mcp.WithDescription("Validate a k6 script by running it with minimal configuration (1 VU, 1 iteration), or only its init phase in fast mode. Returns detailed validation results with syntax errors, runtime issues, and actionable recommendations for fixing problems. The script is validated alone: validate scripts importing local modules or opening data files with run_k6_script and its files parameter."),
+		mcp.WithString(
+			"script",
+			mcp.Description("The k6 script content to validate (JavaScript/TypeScript). Required unless script_url is provided. Example: 'import http from \"k6/http\"; export default function() { http.get(\"https://httpbin.org/get\"); }'"),
+		),
+		mcp.WithString(
+			"encoding",
+			mcp.Description(encodingDescription),
+			mcp.Enum("none", "base64", "gzip+base64"),
+		),
+		mcp.WithString(
+			"script_url",
+			mcp.Description(scriptURLDescription),
+		),
+		mcp.WithString(
+			"script_name",
+			mcp.Description(scriptNameDescription),
+		),
+		mcp.WithString(
+			"mode",
+			mcp.Description("How to validate the script: 'full' (default) runs a single iteration of it, or 'fast' only runs its init phase, parsing it, resolving its imports and checking its options, in a fraction of a second without executing the default function. Fast results are cached, so validating an unchanged script again is instant. Use 'fast' in edit-validate loops, then 'full' before running the script."),
+			mcp.Enum("full", "fast"),
+		),
+		mcp.WithString(
+			"output_format",
+			mcp.Description("The format of the result: 'json' (default) for the detailed validation result, or 'sarif' for a SARIF 2.1.0 report of the issues, for code scanning UIs such as GitHub code scanning."),
+			mcp.Enum("json", "sarif"),
+		),
+		mcp.WithString(
+			"script_path",
+			mcp.Description("The path of the script in its repository, which the results of SARIF reports point to (default: script.js). Example: 'tests/load.js'"),
+		),
+	)
+
+	s.AddTool(validateTool, h.Handle)
+}
+
+func registerScanScriptTool(s *server.MCPServer, h handlers.ToolHandler) {
+	scanTool := mcp.NewTool(
+		"scan_script",
+		mcp.WithDescription("Run the security checks of a k6 script without executing it: the size limit, the dangerous patterns (child processes, file system access, eval, dynamic imports...) validations and runs reject scripts for, and an analysis of the hosts the script targets. Returns every finding with its rule ID, severity, line and suggestion, and 'passed', false when a blocking finding would make validations and runs reject the script. Fast and k6-free, suited to gating scripts in CI."),
+		mcp.WithString(
+			"script",
+			mcp.Description("The k6 script content to scan. Required unless script_url is provided."),
+		),
+		mcp.WithString(
+			"encoding",
+			mcp.Description(encodingDescription),
+			mcp.Enum("none", "base64", "gzip+base64"),
+		),
+		mcp.WithString(
+			"script_url",
+			mcp.Description(scriptURLDescription),
+		),
+		mcp.WithString(
+			"output_format",
+			mcp.Description("The format of the result: 'json' (default) for the scan result, or 'sarif' for a SARIF 2.1.0 report of the findings, for code scanning UIs."),
+			mcp.Enum("json", "sarif"),
+		),
+		mcp.WithString(
+			"script_path",
+			mcp.Description("The path of the script in its repository, which the results of SARIF reports point to (default: script.js). Example: 'tests/load.js'"),
+		),
+	)
+
+	s.AddTool(scanTool, h.Handle)
+}
+
+func registerSecurityReviewTool(s *server.MCPServer, h handlers.ToolHandler) {
+	reviewTool := mcp.NewTool(
+		"security_review",
+		mcp.WithDescription("Produce a structured security review of a k6 script without executing it, for reviewers rather than as a gate: on top of the findings of scan_script, the external hosts it contacts, the secrets embedded in it (tokens, keys, passwords, credentials in URLs, shown redacted), the TLS settings weakening its connections (insecureSkipTLSVerify, TLS 1.0 and 1.1), and the payloads it builds without a bound or beyond 1MB. Each finding has a category, a severity from critical to info, its line and a suggestion. Returns the overall risk, the counts of findings by severity and category, the hosts, and the findings, the most severe first."),
+		mcp.WithString(
+			"script",
+			mcp.Description("The k6 script content to review. Required unless script_url is provided."),
+		),
+		mcp.WithString(
+			"encoding",
+			mcp.Description(encodingDescription),
+			mcp.Enum("none", "base64", "gzip+base64"),
+		),
+		mcp.WithString(
+			"script_url",
+			mcp.Description(scriptURLDescription),
+		),
+		mcp.WithString(
+			"output_format",
+			mcp.Description("The format of the result: 'json' (default) for the review, or 'sarif' for a SARIF 2.1.0 report of the findings, for code scanning UIs."),
+			mcp.Enum("json", "sarif"),
+		),
+		mcp.WithString(
+			"script_path",
+			mcp.Description("The path of the script in its repository, which the results of SARIF reports point to (default: script.js). Example: 'tests/load.js'"),
+		),
+	)
+
+	s.AddTool(reviewTool, h.Handle)
+}
+
+func registerValidateThresholdsTool(s *server.MCPServer, h handlers.ToolHandler) {
+	validateThresholdsTool := mcp.NewTool(
+		"validate_thresholds",
+		mcp.WithDescription("Check k6 threshold expressions without running k6: their syntax (p(95)<500, rate<0.01, count>100), and whether their aggregation methods apply to the types of their metrics, built-in or custom. Catches mistakes such as p95<500, units in values (p(95)<1s), percentiles of rate metrics or misspelled metric names, with a suggested fix for each. Takes the thresholds, or a script whose options define them. Returns 'valid', false when k6 would reject a threshold, and the issues."),
+		mcp.WithObject(
+			"thresholds",
+			mcp.Description("Threshold expressions keyed by metric, optionally with a tag filter, as in the options of scripts. Required unless 'script' or 'script_url' is set. Example: {\"http_req_duration\": [\"p(95)<500\"], \"http_req_failed\": [{\"threshold\": \"rate<0.01\", \"abortOnFail\": true}], \"http_req_duration{scenario:checkout}\": [\"p(99)<1500\"]}"),
+		),
+		mcp.WithString(
+			"script",
+			mcp.Description("A k6 script whose options define the thresholds to check. The custom metrics it declares (new Trend('name')...) are recognized. Without 'thresholds', the thresholds of the script are checked."),
+		),
+		mcp.WithString(
+			"encoding",
+			mcp.Description(encodingDescription),
+			mcp.Enum("none", "base64", "gzip+base64"),
+		),
+		mcp.WithString(
+			"script_url",
+			mcp.Description(scriptURLDescription),
+		),
+		mcp.WithObject(
+			"custom_metrics",
+			mcp.Description("The types of the custom metrics of the thresholds, keyed by name: counter, gauge, rate or trend. Example: {\"login_time\": \"trend\"}"),
+		),
+	)
+
+	s.AddTool(validateThresholdsTool, h.Handle)
+}
+
+func registerReadinessCheckTool(s *server.MCPServer, h handlers.ToolHandler) {
+	readinessTool := mcp.NewTool(
+		"readiness_check",
+		mcp.WithDescription("Check that a k6 script is ready for a scale-up run, before increasing the load: the script validates with a single iteration, thresholds are defined and valid, responses are verified with checks, test data varies across VUs and iterations, every target host is allow-listed, iterations are paced, and an environment profile (the defaults of a project or session) selects the target. Returns a checklist scored out of 100, with a fix for each failed item; the run is ready when no blocking item (validation, thresholds, targets) failed."),
+		mcp.WithString(
+			"script",
+			mcp.Description("The k6 script to check. Required unless script_url is provided."),
+		),
+		mcp.WithString(
+			"encoding",
+			mcp.Description(encodingDescription),
+			mcp.Enum("none", "base64", "gzip+base64"),
+		),
+		mcp.WithString(
+			"script_url",
+			mcp.Description(scriptURLDescription),
+		),
+		mcp.WithArray(
+			"allowed_targets",
+			mcp.Description("Optional hosts approved to take the load, e.g. [\"staging.example.com\", \"*.test.example.com\"]. Defaults to the target_host of the environment profile."),
+		),
+		mcp.WithString(
+			"project",
+			mcp.Description("Optional project whose defaults, set with set_defaults, are the environment profile of the run, along with the session defaults, which take precedence."),
+		),
+		mcp.WithObject(
+			"thresholds",
+			mcp.Description("Optional thresholds the run adds to the script's, keyed by metric, as in run_test."),
+		),
+	)
+
+	s.AddTool(readinessTool, h.Handle)
+}
+
+func registerExplainScriptTool(s *server.MCPServer, h handlers.ToolHandler) {
+	explainTool := mcp.NewTool(
+		"explain_script",
+		mcp.WithDescription("List what a k6 script uses and where it is documented, without executing it: its imports (k6 modules, extensions, remote libraries and local modules), and each k6 API function, class, member and global it uses (e.g. http.get, check, Counter, open, __ENV), with the lines using it, its TypeScript signature, the path of its documentation page and an excerpt of the page. Usages no k6 API symbol resolves, such as the members of extensions, are listed as undocumented. Suited to reviewing scripts written by others."),
+		mcp.WithString(
+			"script",
+			mcp.Description("The k6 script content to explain. Required unless script_url is provided."),
+		),
+		mcp.WithString(
+			"encoding",
+			mcp.Description(encodingDescription),
+			mcp.Enum("none", "base64", "gzip+base64"),
+		),
+		mcp.WithString(
+			"script_url",
+			mcp.Description(scriptURLDescription),
+		),
+	)
+
+	s.AddTool(explainTool, h.Handle)
+}
+
+func registerAnnotateScriptTool(s *server.MCPServer, h handlers.ToolHandler) {
+	annotateTool := mcp.NewTool(
+		"annotate_script",
+		mcp.WithDescription("Return a k6 script with comments inserted above its k6 API calls, linking each to its documentation page on grafana.com with a one-line summary, e.g. above http.get or check, without executing it. Useful to onboard reviewers to generated scripts. Only the first use of each symbol is annotated by default; annotating an annotated script changes nothing. Returns the annotated script, the annotations inserted with their lines, and the usages without documentation page."),
+		mcp.WithString(
+			"script",
+			mcp.Description("The k6 script content to annotate. Required unless script_url is provided."),
+		),
+		mcp.WithString(
+			"encoding",
+			mcp.Description(encodingDescription),
+			mcp.Enum("none", "base64", "gzip+base64"),
+		),
+		mcp.WithString(
+			"script_url",
+			mcp.Description(scriptURLDescription),
+		),
+		mcp.WithBoolean(
+			"all_occurrences",
+			mcp.Description("Annotate every line using a symbol, rather than its first use only (default: false)."),
+		),
+	)
+
+	s.AddTool(annotateTool, h.Handle)
+}
+
+func registerExplainOptionsTool(s *server.MCPServer, h handlers.ToolHandler) {
+	explainOptionsTool := mcp.NewTool(
+		"explain_options",
+		mcp.WithDescription("Explain each option a k6 script sets in its options export, without executing it: what the option does, its default, whether the script deviates from it and the risk of the deviation (e.g. insecureSkipTLSVerify, noConnectionReuse, rps), its TypeScript signature, and the path and an excerpt of the section of the options reference documenting it. Options k6 does not define are flagged, with the closest known option. Suited to reviewing unfamiliar configurations."),
+		mcp.WithString(
+			"script",
+			mcp.Description("The k6 script whose options to explain. Required unless script_url is provided."),
+		),
+		mcp.WithString(
+			"encoding",
+			mcp.Description(encodingDescription),
+			mcp.Enum("none", "base64", "gzip+base64"),
+		),
+		mcp.WithString(
+			"script_url",
+			mcp.Description(scriptURLDescription),
+		),
+	)
+
+	s.AddTool(explainOptionsTool, h.Handle)
+}
+
+func registerServerStatusTool(s *server.MCPServer, h handlers.ToolHandler) {
+	statusTool := mcp.NewTool(
+		"server_status",
+		mcp.WithDescription("Report the version of the k6 MCP server, whether the tools executing load tests are enabled, and the state of the documentation search index: loading while it is written to disk at startup, during which documentation tools wait for it, ready, failed with its error, or disabled."),
+	)
+
+	s.AddTool(statusTool, h.Handle)
+}
+
+func registerUsageStatsTool(s *server.MCPServer, h handlers.ToolHandler) {
+	usageTool := mcp.NewTool(
+		"usage_stats",
+		mcp.WithDescription("Report how the tools of the server were used since it started: for each tool, its number of calls, successes and failures, success rate, median, p95 and maximum latencies, and when it was last called, the most called tools first. Calls fail when the tool returns an error, e.g. invalid arguments or a failed validation."),
+		mcp.WithString(
+			"tool",
+			mcp.Description("The name of a tool to report only, e.g. 'run_k6_script'. Omit to report every tool called."),
+		),
+	)
+
+	s.AddTool(usageTool, h.Handle)
+}
+
+func registerSetLogLevelTool(s *server.MCPServer, h handlers.ToolHandler) {
+	logLevelTool := mcp.NewTool(
+		"set_log_level",
+		mcp.WithDescription("Change the verbosity of the server logs while it runs, without restarting it, e.g. to debug a misbehaving tool and restore the level afterwards. Returns the previous and current log levels; without a level, reports the current one."),
+		mcp.WithString(
+			"level",
+			mcp.Description("The minimum level of the records to log."),
+			mcp.Enum("debug", "info", "warn", "error"),
+		),
+	)
+
+	s.AddTool(logLevelTool, h.Handle)
+}
+
+func registerDocumentationTools(s *server.MCPServer, h handlers.ToolHandler) {
+	// Register the search tool
+	searchTool := mcp.NewTool(
+		"search_k6_documentation",
+		mcp.WithDescription("Search up-to-date k6 documentation using SQLite FTS5 full-text search. Use proactively while authoring or validating scripts to find best practices, troubleshoot errors, discover examples/templates, and learn idiomatic k6 usage. Query semantics: space-separated terms are ANDed by default; use quotes for exact phrases; FTS5 operators (AND, OR, NEAR, parentheses) and prefix wildcards (e.g., http*) are supported. Returns structured results with title, content, and path; the full page for a result can be read from the docs://k6/pages/{path} resource. The docs://k6/whats_new resource lists the recently updated pages."),
+		mcp.WithString(
+			"keywords",
+			mcp.Description("FTS5 query string; required unless 'queries' is set. Use space-separated terms (implicit AND), quotes for exact phrases, and optional FTS5 operators. Examples: 'load' → matches load; 'load testing' → matches load AND testing; '\"load testing\"' → exact phrase; 'thresholds OR checks'; 'stages NEAR/5 ramping'; 'http*' for prefix."),
+		),
+		mcp.WithArray(
+			"queries",
+			mcp.Description("Up to 5 related FTS5 query strings to search in one call, instead of 'keywords'. Results are grouped by query, and max_results and language apply to each. Example: [\"thresholds\", \"http.batch\", \"ramping-arrival-rate\"]"),
+		),
+		mcp.WithNumber(
+			"max_results",
+			mcp.Description("Maximum number of results to return (default: 10, max: 20). Use 5–10 for focused results, 15–20 for broader coverage."),
+		),
+		mcp.WithString(
+			"language",
+			mcp.Description("ISO 639-1 code of the language of the documentation to search, when translated documentation is indexed (default: 'en'). Queries match with or without diacritics; French, German, Spanish, Portuguese and Italian terms also match their inflected forms, and Japanese, Chinese and Korean are matched as substrings of at least 3 characters."),
+		),
+		mcp.WithString(
+			"updated_since",
+			mcp.Description("Optional date, formatted as YYYY-MM-DD, restricting the search to the documentation pages updated since then. Example: '2025-01-31'"),
+		),
+		mcp.WithBoolean(
+			"boost_recent",
+			mcp.Description("Rank the pages updated in the 90 days before the last update of the indexed documentation higher, to surface new features such as experimental modules (default: false)."),
+		),
+	)
+
+	s.AddTool(searchTool, h.Handle)
+}
+
+func registerAskDocumentationTool(s *server.MCPServer, h handlers.ToolHandler) {
+	askTool := mcp.NewTool(
+		"ask_documentation",
+		mcp.WithDescription("Answer a question about k6 with quotes of the documentation, citing the path of each quoted page. The server retrieves the documentation chunks matching the terms of the question, and quotes their paragraphs matching the most terms, so that clients without the context to read full search results still get documentation-grounded answers. The answer is made of verbatim quotes, each referring to its citation as [1], [2], ...; read a cited page in full from its docs://k6/pages/{path} resource."),
+		mcp.WithString(
+			"question",
+			mcp.Required(),
+			mcp.Description("The question, in English. Examples: 'How do I abort a test when a threshold is crossed?', 'What does http.batch return?'"),
+		),
+		mcp.WithNumber(
+			"max_citations",
+			mcp.Description(fmt.Sprintf("Maximum number of quotes the answer is composed of (default: %d, max: %d).", handlers.DefaultCitations, handlers.MaxCitations)),
+		),
+	)
+
+	s.AddTool(askTool, h.Handle)
+}
+
+func registerLookupAPITool(s *server.MCPServer, h handlers.ToolHandler) {
+	lookupTool := mcp.NewTool(
+		"lookup_api",
+		mcp.WithDescription("Look up a symbol of the k6 JavaScript API by its exact name, without fuzzy matching: returns its module, kind, TypeScript signature, the description from the type definitions, and the path of its documentation page when there is one. Prefer it to search_k6_documentation when the name of a function, class, method or option is known. A module path, such as 'k6/http', lists the module's top-level symbols. Names match case-insensitively only when no symbol has the exact name; lookups finding nothing suggest the symbols of the same unqualified name."),
+		mcp.WithString(
+			"name",
+			mcp.Required(),
+			mcp.Description("The name of the symbol: 'k6/http.batch', 'http.batch', 'check', 'k6/metrics.Counter.add', 'Response.json', 'Options.thresholds', or a module such as 'k6/crypto'. A trailing '()' is ignored."),
+		),
+	)
+
+	s.AddTool(lookupTool, h.Handle)
+}
+
+func registerFindExtensionTool(s *server.MCPServer, h handlers.ToolHandler) {
+	findTool := mcp.NewTool(
+		"find_extension",
+		mcp.WithDescription("Find the k6 extensions of the xk6 registry adding a protocol, a data store or an output k6 lacks, such as Kafka, MQTT, SQL or Redis. Use it when asked whether k6 can test a technology. Returns each matching extension with its Go module, JavaScript imports or outputs, tier (official, partner or community), maintenance status (maintained, stale or archived), latest version, and the instructions to build k6 with it."),
+		mcp.WithString(
+			"query",
+			mcp.Required(),
+			mcp.Description("The protocol, technology or feature, or a question about it. Examples: 'kafka', 'mqtt', 'sql postgres', 'can k6 test redis?'"),
+		),
+		mcp.WithString(
+			"tier",
+			mcp.Description("Only return the extensions of a tier (default: every tier). Official and partner extensions are supported by Grafana and its partners."),
+			mcp.Enum("official", "partner", "community"),
+		),
+		mcp.WithBoolean(
+			"include_archived",
+			mcp.Description("Also return the extensions whose repository is archived (default: false)."),
+		),
+		mcp.WithNumber(
+			"max_results",
+			mcp.Description(fmt.Sprintf("Maximum number of extensions to return (default: %d, max: %d).", handlers.DefaultExtensionResults, handlers.MaxExtensionResults)),
+		),
+	)
+
+	s.AddTool(findTool, h.Handle)
+}
+
+func registerSearchTypesTool(s *server.MCPServer, h handlers.ToolHandler) {
+	searchTypesTool := mcp.NewTool(
+		"search_types",
+		mcp.WithDescription("Search the declarations of the embedded k6 TypeScript type definitions by name, signature and doc comment, and return the matching declaration snippets with their doc comments, instead of whole definition files. Every query term must match; name matches rank first. Use it to check the exact parameters and return types of the k6 API while writing scripts."),
+		mcp.WithString(
+			"query",
+			mcp.Description("Terms to search, matched case-insensitively in the qualified names (e.g. 'Counter.add'), signatures and doc comments of the declarations. Examples: 'batch', 'cookie jar', 'Response json', 'thresholds'."),
+		),
+		mcp.WithString(
+			"module",
+			mcp.Description("Only search the declarations of this module, e.g. 'k6/http', 'k6/metrics', 'k6' or 'global'."),
+		),
+		mcp.WithString(
+			"kind",
+			mcp.Description("Only search this kind of declarations."),
+			mcp.Enum("function", "class", "interface", "namespace", "enum", "type", "constant", "method", "property", "constructor"),
+		),
+		mcp.WithNumber(
+			"max_results",
+			mcp.Description("Maximum number of declarations to return (default: 10, max 50)."),
+		),
+	)
+
+	s.AddTool(searchTypesTool, h.Handle)
+}
+
+func registerBrowseDocumentationTool(s *server.MCPServer, h handlers.ToolHandler) {
+	browseTool := mcp.NewTool(
+		"browse_documentation",
+		mcp.WithDescription("Browse the k6 documentation tree hierarchically. Without a prefix, lists the top-level categories; with a prefix, lists the pages and sections directly under it. Each entry has a path, title, description, and the number of pages nested under it. Use entry paths as the next prefix to drill down, and read a page in full from the docs://k6/pages/{path} resource."),
+		mcp.WithString(
+			"prefix",
+			mcp.Description("Path of the section to list, relative to the documentation root. Examples: 'javascript-api', 'javascript-api/k6-http', 'using-k6'. Omit to list top-level categories."),
+		),
+	)
+
+	s.AddTool(browseTool, h.Handle)
+}
+
+func registerDiffDocsTool(s *server.MCPServer, h handlers.ToolHandler) {
+	diffTool := mcp.NewTool(
+		"diff_docs",
+		mcp.WithDescription("Compare the k6 documentation of an API or topic between two indexed k6 versions. Returns the pages added, removed and changed, with the headings and options or functions each gained or lost, and a one-line summary. Use it to upgrade scripts to a newer k6, or to explain why an older script misbehaves on a newer binary."),
+		mcp.WithString(
+			"from",
+			mcp.Required(),
+			mcp.Description("The k6 version to compare from. Examples: '0.52', 'v1.0.x', 'v1.1.0'."),
+		),
+		mcp.WithString(
+			"to",
+			mcp.Description("The k6 version to compare to. Defaults to 'latest', the version the other documentation tools serve."),
+		),
+		mcp.WithString(
+			"path",
+			mcp.Description("Path of the page or section to compare, relative to the documentation root. Examples: 'javascript-api/k6-http', 'using-k6/k6-options/reference'. Omit to compare the whole documentation."),
+		),
+	)
+
+	s.AddTool(diffTool, h.Handle)
+}
+
+func registerRunTool(s *server.MCPServer, h handlers.ToolHandler) {
+	// Register the run tool
+	runTool := mcp.NewTool(
+		"run_k6_script",
+		mcp.WithDescription("Run a k6 test script with configurable parameters. Returns detailed execution results including performance metrics, failure analysis, and optimization recommendations."),
+		mcp.WithString(
+			"script",
+			mcp.Description("The k6 script content to run (JavaScript/TypeScript). Should be a valid k6 script with proper imports and default function. Required unless script_url is provided."),
+		),
+		mcp.WithString(
+			"encoding",
+			mcp.Description(encodingDescription),
+			mcp.Enum("none", "base64", "gzip+base64"),
+		),
+		mcp.WithString(
+			"script_url",
+			mcp.Description(scriptURLDescription),
+		),
+		mcp.WithString(
+			"script_name",
+			mcp.Description(scriptNameDescription),
+		),
+		mcp.WithString(
+			"test_name",
+			mcp.Description(testNameDescription+" The run executes the latest revision of the script of the test, unless 'script' or 'script_url' is given, which is then recorded as a new revision of it. Runs are recorded under the test name, and compared to the baseline of the test when it has one."),
+		),
+		mcp.WithObject(
+			"files",
+			mcp.Description("Optional companion files the script needs, keyed by their path relative to the script: local modules, data files opened with open(), or gRPC .proto definitions loaded with k6/net/grpc's client.load(). Data files go through this parameter too: there is no separate data_files parameter. Proto files given by bare name are also placed in the import paths passed to client.load(). Example: {\"protos/hello.proto\": \"syntax = \\\"proto3\\\"; ...\", \"data/users.csv\": \"username\\nalice\"}"),
+		),
+		mcp.WithNumber(
+			"vus",
+			mcp.Description("Number of virtual users (default: 1, max: 50). Examples: 1 for basic test, 10 for moderate load, 50 for stress test. Browser scripts reject vus, duration, iterations and stages: their scenarios set the VUs and duration of the run."),
+		),
+		mcp.WithString(
+			"duration",
+			mcp.Description("Test duration (default: '30s', max: '5m'). Examples: '30s', '2m', '5m'. Overridden by iterations if specified."),
+		),
+		mcp.WithNumber(
+			"iterations",
+			mcp.Description("Number of iterations per VU (overrides duration). Examples: 1 for single run, 100 for throughput test."),
+		),
+		mcp.WithObject(
+			"stages",
+			mcp.Description("Load profile stages for ramping (array of {duration, target}). Example: [{\"duration\": \"30s\", \"target\": 10}, {\"duration\": \"1m\", \"target\": 20}]"),
+		),
+		mcp.WithObject(
+			"options",
+			mcp.Description("Additional k6 options as JSON object. Example: {\"thresholds\": {\"http_req_duration\": [\"p(95)<500\"]}}"),
+		),
+		mcp.WithBoolean(
+			"debug_responses",
+			mcp.Description(fmt.Sprintf("When true, the run uses k6's HTTP debugging to capture up to %d failing (4xx/5xx) request/response pairs, with credentials and sensitive fields redacted and bodies capped at %d bytes, returned as debug_responses. Use it to diagnose why checks fail; it slows the run down, so keep the load low (default: false).", runner.MaxResponseSamples, runner.MaxSampleBodyBytes)),
+		),
+		mcp.WithNumber(
+			"pacing",
+			mcp.Description(fmt.Sprintf("Optional pacing, in iterations each VU starts per minute, e.g. 6 for one iteration every 10 seconds. The run then uses a constant-arrival-rate scenario with 'vus' VUs for 'duration', instead of looping iterations back to back; it can't be combined with iterations or stages. The result's pacing section shows the scenario, think time guidance, and warnings when the pacing is infeasible (max %d).", runner.MaxPacing)),
+		),
+		mcp.WithNumber(
+			"seed",
+			mcp.Description(fmt.Sprintf("Optional seed (0 to %d) making the script's random data reproducible: Math.random is seeded with it and the VU number in each VU, and it is exposed as __ENV.K6_MCP_SEED. The seed is recorded in the run history; rerun with the same seed to reproduce an anomaly.", int64(runner.MaxSeed))),
+		),
+		mcp.WithObject(
+			"tags",
+			mcp.Description(fmt.Sprintf("Optional tags of all the metrics of the run, as an object of string values (up to %d), so that exported metrics are attributable in downstream systems, e.g. {\"team\": \"checkout\"}. Runs are tagged automatically with test_name (the script_name), script_sha256, environment and k6_mcp_version; tags of the same name override them. k6's own tags, such as url or status, cannot be set.", runner.MaxTags)),
+		),
+		mcp.WithString(
+			"environment",
+			mcp.Description("Optional label of the environment the run targets, such as staging or production, tagging its metrics as environment and recorded in the run history."),
+		),
+		mcp.WithObject(
+			"env",
+			mcp.Description(envDescription),
+		),
+		mcp.WithArray(
+			"client_certificates",
+			mcp.Description(fmt.Sprintf("Optional client certificates k6 presents to the hosts of their domains, for mutual TLS (max %d). Each has the 'domains' (e.g. 'api.example.com' or '*.example.com'), and the paths of its PEM 'cert' and 'key' among 'files'; 'password' decrypts encrypted keys. Certificates are checked against their key and validity dates before the run. Example: [{\"domains\": [\"api.example.com\"], \"cert\": \"certs/client.crt\", \"key\": \"certs/client.key\"}]", runner.MaxClientCertificates)),
+		),
+		mcp.WithArray(
+			"outputs",
+			mcp.Description("Optional output presets streaming the metrics of the run to a time series database, in addition to the results returned, without raw k6 --out syntax. Each has a 'type': 'influxdb' (InfluxDB v2, requires 'url', 'organization', 'bucket' and 'token'; optional 'insecure_skip_tls_verify') or 'datadog' (a Datadog agent over DogStatsD; optional 'address', default 'localhost:8125', and 'namespace', default 'k6.'), and an optional 'push_interval' (e.g. '1s'). Connection parameters are checked before the run, and passed to k6 in its environment; the token is redacted from the output. The k6 binary must be built with the xk6-output-influxdb or xk6-output-statsd extension (see find_extension). Example: [{\"type\": \"influxdb\", \"url\": \"http://localhost:8086\", \"organization\": \"acme\", \"bucket\": \"k6\", \"token\": \"...\"}]"),
+		),
+		mcp.WithString(
+			"auth_profile",
+			mcp.Description("Optional auth profile, as listed by list_auth_profiles, to acquire an OAuth2 access token with before the run. The server requests the token with the credentials of its configuration, and exposes it to the script in the environment variable of the profile (__ENV.ACCESS_TOKEN by default), so that no secret is written in the script or parameters. The token is redacted from the output."),
+		),
+		mcp.WithBoolean(
+			"precheck",
+			mcp.Description("When true, check that the hosts targeted by the script and its environment variable URLs are reachable before starting the load, with a TCP connection (and TLS handshake for https and wss), and abort with a 'target unreachable' error instead of running a test full of connection errors (default: false)."),
+		),
+		mcp.WithBoolean(
+			"save_output",
+			mcp.Description("When true, the complete k6 JSON metrics output is also stored as an artifact, listed in the result's artifacts and read with get_artifact, whose path on the server is returned as output_file. The result itself only includes the last 64KB of text output and the first 1000 raw metrics (default: false)."),
+		),
+		mcp.WithObject(
+			"thresholds",
+			mcp.Description("Optional thresholds, keyed by metric name, each with an array of threshold expressions, evaluated by k6 at the end of the run; the script's own thresholds take precedence. Example: {\"http_req_duration\": [\"p(95)<500\"], \"http_req_failed\": [\"rate<0.01\"]}"),
+		),
+		mcp.WithString(
+			"project",
+			mcp.Description("Optional project whose defaults, set with set_defaults, complete the parameters not given explicitly, along with the session defaults, which take precedence."),
+		),
+		mcp.WithBoolean(
+			"ignore_rate_limit",
+			mcp.Description("When true, run even when the projected peak request rate exceeds the rate_limit of the defaults, e.g. when the load generator is allow-listed (default: false)."),
+		),
+		mcp.WithBoolean(
+			"preview",
+			mcp.Description("When true, nothing is executed: returns the fully resolved k6 command line (with env values redacted), the k6 configuration file, the resolved options, and the files of the temporary workspace the run would use, so the run can be audited first. The script and parameters are validated as for a run (default: false)."),
+		),
+		mcp.WithString(
+			"output_format",
+			mcp.Description("The format of the result: 'json' (default) for the detailed run result, or 'junit' for a JUnit XML report with a test case per threshold and check of the script, for CI systems. Runs failing for other reasons than their thresholds report an errored 'run' test case."),
+			mcp.Enum("json", "junit"),
+		),
+		mcp.WithString(
+			"output_level",
+			mcp.Description("How much of the run's output the JSON result includes: 'summary_only' for the summary, analysis, thresholds and checks only, 'standard' (default) for the output of k6 too, or 'full' for the raw metrics too. Lower levels save context; the run history and artifacts are unaffected."),
+			mcp.Enum("summary_only", "standard", "full"),
+		),
+	)
+
+	s.AddTool(runTool, h.Handle)
+}
+
+// defaultsDescription describes how defaults apply to runs.
+const defaultsDescription = "Defaults complete the parameters of run_k6_script calls that don't set them: session defaults apply to every run of the session, and project defaults to runs naming the project, with the session defaults taking precedence. Explicit parameters always win; env and thresholds are merged by variable and metric."
+
+func registerSetDefaultsTool(s *server.MCPServer, h handlers.ToolHandler) {
+	setDefaultsTool := mcp.NewTool(
+		"set_defaults",
+		mcp.WithDescription("Store default run options (VUs, duration, thresholds, env, target host) for this session, or for a named project persisted across sessions, so that runs don't have to repeat them. "+defaultsDescription+" Returns the resulting defaults."),
+		mcp.WithString(
+			"project",
+			mcp.Description("The project to store the defaults under. Omit it to set the defaults of the current session."),
+		),
+		mcp.WithNumber(
+			"vus",
+			mcp.Description(fmt.Sprintf("Default number of virtual users (max: %d). Not applied to runs using stages.", runner.MaxVUs)),
+		),
+		mcp.WithString(
+			"duration",
+			mcp.Description("Default test duration, e.g. '1m'. Not applied to runs using stages or iterations."),
+		),
+		mcp.WithObject(
+			"thresholds",
+			mcp.Description("Default thresholds, keyed by metric name. Example: {\"http_req_duration\": [\"p(95)<500\"], \"http_req_failed\": [\"rate<0.01\"]}"),
+		),
+		mcp.WithObject(
+			"env",
+			mcp.Description("Default environment variables exposed to scripts through __ENV."),
+		),
+		mcp.WithString(
+			"target_host",
+			mcp.Description(fmt.Sprintf("Base URL of the system under test, exposed to scripts as __ENV.%s unless env sets it. Example: 'https://staging.example.com'", defaults.TargetHostEnvVar)),
+		),
+		mcp.WithNumber(
+			"rate_limit",
+			mcp.Description("Request rate, per second, the target tolerates from the load generator before its WAF or rate limiting throttles or blocks it. Runs are measured with a single iteration first, and blocked when their projected peak request rate exceeds it; estimate_run reports the check."),
+		),
+		mcp.WithBoolean(
+			"replace",
+			mcp.Description("When true, replace the existing defaults instead of merging into them; with no other option, this clears them (default: false)."),
+		),
+	)
+
+	s.AddTool(setDefaultsTool, h.Handle)
+}
+
+func registerGetDefaultsTool(s *server.MCPServer, h handlers.ToolHandler) {
+	getDefaultsTool := mcp.NewTool(
+		"get_defaults",
+		mcp.WithDescription("Return the default run options of this session and, when named, of a project, with the effective defaults runs receive and the projects having defaults. "+defaultsDescription),
+		mcp.WithString(
+			"project",
+			mcp.Description("The project to return the defaults of."),
+		),
+	)
+
+	s.AddTool(getDefaultsTool, h.Handle)
+}
+
+func registerSetBaselineTool(s *server.MCPServer, h handlers.ToolHandler) {
+	setBaselineTool := mcp.NewTool(
+		"set_baseline",
+		mcp.WithDescription("Mark a run as the performance baseline of a named test, replacing any previous baseline. Later runs can be compared to it with check_against_baseline."),
+		mcp.WithString(
+			"test_name",
+			mcp.Required(),
+			mcp.Description("The name of the test the baseline is for. Example: 'checkout-flow'"),
+		),
+		mcp.WithObject(
+			"summary",
+			mcp.Required(),
+			mcp.Description("The 'summary' object returned by run_k6_script for the baseline run."),
+		),
+		mcp.WithString(
+			"notes",
+			mcp.Description("Optional notes about the baseline, e.g. the version or commit it was measured on."),
+		),
+	)
+
+	s.AddTool(setBaselineTool, h.Handle)
+}
+
+func registerCheckAgainstBaselineTool(s *server.MCPServer, h handlers.ToolHandler) {
+	checkTool := mcp.NewTool(
+		"check_against_baseline",
+		mcp.WithDescription("Compare a run to the baseline of a named test, and return a pass/fail gate suitable for CI: the gate fails when response times grow, or the request rate drops, by more than the tolerance, or when the error rate grows by more than the allowed increase."),
+		mcp.WithString(
+			"test_name",
+			mcp.Required(),
+			mcp.Description("The name of the test to compare to its baseline. Example: 'checkout-flow'"),
+		),
+		mcp.WithObject(
+			"summary",
+			mcp.Required(),
+			mcp.Description("The 'summary' object returned by run_k6_script for the run to check."),
+		),
+		mcp.WithNumber(
+			"tolerance_percent",
+			mcp.Description("The relative degradation allowed for the average and p95 response times and the request rate, in percent (default: 10)."),
+		),
+		mcp.WithNumber(
+			"max_error_rate_increase",
+			mcp.Description("The error rate increase allowed, in percentage points (default: 1)."),
+		),
+	)
+
+	s.AddTool(checkTool, h.Handle)
+}
+
+func registerGetScriptHistoryTool(s *server.MCPServer, h handlers.ToolHandler) {
+	historyTool := mcp.NewTool(
+		"get_script_history",
+		mcp.WithDescription("List the recorded revisions of a named script (revision number, SHA-256, timestamp, recording tool, size and lines changed since the previous revision), or return the full content of one revision, e.g. to revert a bad edit. Revisions are recorded when a script_name is passed to the validation and run tools. Without a script_name, lists the named scripts."),
+		mcp.WithString(
+			"script_name",
+			mcp.Description("The name of the script. Omit it to list the named scripts."),
+		),
+		mcp.WithNumber(
+			"revision",
+			mcp.Description("A revision to return with its content. 0 is the latest revision, and negative numbers count back from it: -1 is the one before the latest."),
+		),
+		mcp.WithString(
+			"suite_name",
+			mcp.Description("Return the recorded runs of this suite instead, most recent first. An empty string returns the runs of all suites."),
+		),
+	)
+
+	s.AddTool(historyTool, h.Handle)
+}
+
+func registerDiffScriptVersionsTool(s *server.MCPServer, h handlers.ToolHandler) {
+	diffTool := mcp.NewTool(
+		"diff_script_versions",
+		mcp.WithDescription("Compare two revisions of a named script as a unified diff, with the number of lines added and removed. Use it to understand what changed between runs with different results. By default, compares the latest revision to the previous one."),
+		mcp.WithString(
+			"script_name",
+			mcp.Required(),
+			mcp.Description("The name of the script."),
+		),
+		mcp.WithNumber(
+			"from",
+			mcp.Description("The revision to compare from (default: the revision before 'to'). 0 and negative numbers count back from the latest revision."),
+		),
+		mcp.WithNumber(
+			"to",
+			mcp.Description("The revision to compare to (default: the latest). 0 and negative numbers count back from the latest revision."),
+		),
+	)
+
+	s.AddTool(diffTool, h.Handle)
+}
+
+// testNameDescription documents the test_name parameter of the tools running registered tests.
+const testNameDescription = "The name of a test registered with register_test. Example: 'checkout-flow'"
+
+func registerRegisterTestTool(s *server.MCPServer, h handlers.ToolHandler) {
+	registerTool := mcp.NewTool(
+		"register_test",
+		mcp.WithDescription("Register a named test: a durable identity, with an owner, a description and tags, linked to the named script it runs. Run it with run_k6_script's 'test_name': its runs and baseline are keyed by the test name, so they stay attached to the test as its script is edited, or replaced with update_test."),
+		mcp.WithString(
+			"test_name",
+			mcp.Required(),
+			mcp.Description("The name of the test, up to 128 letters, digits, spaces and '_.:/-'. Example: 'checkout-flow'"),
+		),
+		mcp.WithString(
+			"script_name",
+			mcp.Required(),
+			mcp.Description("The name of the script the test runs, as recorded by the validation and run tools' 'script_name'. It must have a recorded revision, unless 'script' or 'script_url' is given."),
+		),
+		mcp.WithString(
+			"script",
+			mcp.Description("The content of the script, recorded as a new revision of 'script_name'."),
+		),
+		mcp.WithString(
+			"encoding",
+			mcp.Description(encodingDescription),
+			mcp.Enum("none", "base64", "gzip+base64"),
+		),
+		mcp.WithString(
+			"script_url",
+			mcp.Description(scriptURLDescription),
+		),
+		mcp.WithString(
+			"owner",
+			mcp.Description("The team or person owning the test. Example: 'team-checkout'"),
+		),
+		mcp.WithString(
+			"description",
+			mcp.Description("What the test covers, e.g. the user journey and the load it models."),
+		),
+		mcp.WithArray(
+			"tags",
+			mcp.Description("Tags to find the test by with list_tests, lowercased. Example: [\"checkout\", \"smoke\"]"),
+		),
+	)
+
+	s.AddTool(registerTool, h.Handle)
+}
+
+func registerUpdateTestTool(s *server.MCPServer, h handlers.ToolHandler) {
+	updateTool := mcp.NewTool(
+		"update_test",
+		mcp.WithDescription("Update a registered test: its owner, description or tags, the script it runs, or the content of its script. Only the parameters given change; its runs and baseline stay attached to it."),
+		mcp.WithString(
+			"test_name",
+			mcp.Required(),
+			mcp.Description(testNameDescription),
+		),
+		mcp.WithString(
+			"script_name",
+			mcp.Description("Link the test to another named script, which must have a recorded revision unless 'script' or 'script_url' is given."),
+		),
+		mcp.WithString(
+			"script",
+			mcp.Description("New content of the script of the test, recorded as a new revision of it."),
+		),
+		mcp.WithString(
+			"encoding",
+			mcp.Description(encodingDescription),
+			mcp.Enum("none", "base64", "gzip+base64"),
+		),
+		mcp.WithString(
+			"script_url",
+			mcp.Description(scriptURLDescription),
+		),
+		mcp.WithString(
+			"owner",
+			mcp.Description("The new owner of the test."),
+		),
+		mcp.WithString(
+			"description",
+			mcp.Description("The new description of the test."),
+		),
+		mcp.WithArray(
+			"tags",
+			mcp.Description("The new tags of the test, replacing the current ones. Pass [] to remove them."),
+		),
+	)
+
+	s.AddTool(updateTool, h.Handle)
+}
+
+func registerGetTestTool(s *server.MCPServer, h handlers.ToolHandler) {
+	getTool := mcp.NewTool(
+		"get_test",
+		mcp.WithDescription("Get a registered test, with the latest revision of its script, its baseline, and the statistics of its recorded runs across the revisions and scripts it ran, with the trend of the 10 most recent ones."),
+		mcp.WithString(
+			"test_name",
+			mcp.Required(),
+			mcp.Description(testNameDescription),
+		),
+	)
+
+	s.AddTool(getTool, h.Handle)
+}
+
+func registerListTestsTool(s *server.MCPServer, h handlers.ToolHandler) {
+	listTool := mcp.NewTool(
+		"list_tests",
+		mcp.WithDescription("List the registered tests, sorted by name. Filters combine."),
+		mcp.WithString(
+			"owner",
+			mcp.Description("Only include the tests of this owner, ignoring case."),
+		),
+		mcp.WithString(
+			"tag",
+			mcp.Description("Only include the tests having this tag."),
+		),
+		mcp.WithString(
+			"script_name",
+			mcp.Description("Only include the tests running this named script."),
+		),
+	)
+
+	s.AddTool(listTool, h.Handle)
+}
+
+func registerDeleteTestTool(s *server.MCPServer, h handlers.ToolHandler) {
+	deleteTool := mcp.NewTool(
+		"delete_test",
+		mcp.WithDescription("Unregister a test. Its recorded runs, baseline and script history are kept, and attached again if a test is registered under the same name."),
+		mcp.WithString(
+			"test_name",
+			mcp.Required(),
+			mcp.Description(testNameDescription),
+		),
+	)
+
+	s.AddTool(deleteTool, h.Handle)
+}
+
+// sinceDescription and untilDescription document the date range filters of the run history tools.
+const (
+	sinceDescription = "Only include runs started at or after this time: an RFC 3339 time ('2025-06-01T12:00:00Z'), a date ('2025-06-01'), or a duration back from now ('24h', '7d')."
+	untilDescription = "Only include runs started at or before this time, in the formats of 'since'. Dates include their whole day."
+)
+
+func registerQueryRunHistoryTool(s *server.MCPServer, h handlers.ToolHandler) {
+	queryTool := mcp.NewTool(
+		"query_run_history",
+		mcp.WithDescription("Query the recorded runs, most recent first: each record holds the script name, revision and SHA-256, the targeted hosts, the load configuration, the outcome, and the key results (requests, error rate, average and p95 response times, request rate, crossed thresholds). Runs of the run tool are recorded, unless they are rejected before k6 starts. Filters combine."),
+		mcp.WithString(
+			"test_name",
+			mcp.Description("Only include runs of this registered test, whatever the script they ran."),
+		),
+		mcp.WithString(
+			"script_name",
+			mcp.Description("Only include runs of this named script."),
+		),
+		mcp.WithString(
+			"script_sha256",
+			mcp.Description("Only include runs of scripts whose SHA-256 starts with this prefix, e.g. a revision's 'sha256' from get_script_history."),
+		),
+		mcp.WithString(
+			"target_host",
+			mcp.Description("Only include runs targeting this host or its subdomains, from the script's URLs or its environment variable URLs such as BASE_URL. Example: 'staging.example.com'"),
+		),
+		mcp.WithString(
+			"since",
+			mcp.Description(sinceDescription),
+		),
+		mcp.WithString(
+			"until",
+			mcp.Description(untilDescription),
+		),
+		mcp.WithString(
+			"status",
+			mcp.Description("Only include passed or failed runs."),
+			mcp.Enum("passed", "failed"),
+		),
+		mcp.WithNumber(
+			"limit",
+			mcp.Description("The maximum number of runs to return (default: 20, maximum: 200). 'total' counts all matching runs."),
+		),
+	)
+
+	s.AddTool(queryTool, h.Handle)
+}
+
+func registerHistoryStatsTool(s *server.MCPServer, h handlers.ToolHandler) {
+	statsTool := mcp.NewTool(
+		"history_stats",
+		mcp.WithDescription("Aggregate the recorded runs of named scripts into trends: per script, the number of runs, pass rate, latest, min, max and average of the p95 response time and error rate, their least squares slope per run and trend ('improving', 'degrading', 'stable' or 'insufficient_data'), and the trend line of the runs. Use it to spot regressions across runs and revisions."),
+		mcp.WithString(
+			"test_name",
+			mcp.Description("The registered test to aggregate: its runs are aggregated together, across the revisions and scripts the test ran."),
+		),
+		mcp.WithString(
+			"script_name",
+			mcp.Description("The named script to aggregate. Omit it to aggregate every named script."),
+		),
+		mcp.WithString(
+			"target_host",
+			mcp.Description("Only include runs targeting this host or its subdomains."),
+		),
+		mcp.WithString(
+			"since",
+			mcp.Description(sinceDescription),
+		),
+		mcp.WithString(
+			"until",
+			mcp.Description(untilDescription),
+		),
+		mcp.WithString(
+			"status",
+			mcp.Description("Only include passed or failed runs."),
+			mcp.Enum("passed", "failed"),
+		),
+		mcp.WithNumber(
+			"limit",
+			mcp.Description("The maximum number of runs in the trend line of each script, the most recent ones (default and maximum: 200). Aggregates cover all matching runs."),
+		),
+	)
+
+	s.AddTool(statsTool, h.Handle)
+}
+
+func registerImportResultsTool(s *server.MCPServer, h handlers.ToolHandler) {
+	importTool := mcp.NewTool(
+		"import_results",
+		mcp.WithDescription("Import the results of a k6 run executed outside the server, such as a CI or manual run, into the run history, so that query_run_history, history_stats and the baseline tools span every execution environment. Accepts a k6 --summary-export file or the JSON of the data passed to handleSummary(), and returns the recorded run along with its summary in the format of run_k6_script summaries, its thresholds and its checks."),
+		mcp.WithObject(
+			"summary",
+			mcp.Required(),
+			mcp.Description("The k6 summary: the content of a --summary-export file, or the JSON of the data passed to handleSummary(), as an object or a JSON string (at most 5MB)."),
+		),
+		mcp.WithString(
+			"script_name",
+			mcp.Description("The named script the run ran. Without 'script', the run is attributed to the latest recorded revision of the script, unless 'script_sha256' differs from it."),
+		),
+		mcp.WithString(
+			"script",
+			mcp.Description("The content of the script the run ran, from which its SHA-256 and targets are recorded. With 'script_name', it is also recorded as a revision of the script."),
+		),
+		mcp.WithString(
+			"encoding",
+			mcp.Description(encodingDescription),
+			mcp.Enum("none", "base64", "gzip+base64"),
+		),
+		mcp.WithString(
+			"script_sha256",
+			mcp.Description("The hex-encoded SHA-256 of the script the run ran, when 'script' is not given."),
+		),
+		mcp.WithString(
+			"environment",
+			mcp.Description("Where the run executed. Examples: 'github-actions', 'ci', 'laptop'"),
+		),
+		mcp.WithString(
+			"started_at",
+			mcp.Description("When the run started, as an RFC 3339 time (default: now). Example: '2025-06-01T12:00:00Z'"),
+		),
+		mcp.WithString(
+			"target_host",
+			mcp.Description("The host the run targeted, e.g. 'staging.example.com', added to the hosts found in 'script'."),
+		),
+		mcp.WithNumber(
+			"exit_code",
+			mcp.Description("The exit code of k6. Without it, the run passed when none of its thresholds was crossed."),
+		),
+	)
+
+	s.AddTool(importTool, h.Handle)
+}
+
+func registerListAuthProfilesTool(s *server.MCPServer, h handlers.ToolHandler) {
+	listAuthProfilesTool := mcp.NewTool(
+		"list_auth_profiles",
+		mcp.WithDescription("List the OAuth2 auth profiles configured on the server, which runs can acquire access tokens with through the 'auth_profile' parameter of the run tool: their name, grant type, token endpoint host, scope, audience, and the environment variable the script reads the token from. Credentials are never returned."),
+	)
+
+	s.AddTool(listAuthProfilesTool, h.Handle)
+}
+
+func registerEstimateRunTool(s *server.MCPServer, h handlers.ToolHandler) {
+	estimateTool := mcp.NewTool(
+		"estimate_run",
+		mcp.WithDescription("Estimate what a run would cost before running it: runs a single iteration of the script with 1 VU to measure its requests, duration and bytes transferred, then projects the total iterations, requests, average and peak request rates, data transfer, and data transfer cost of the full configuration, and checks the peak request rate against the rate limit of the target. Takes the same run parameters as the run tool, completed with the session and project defaults."),
+		mcp.WithString(
+			"script",
+			mcp.Description("The k6 script content to estimate. Required unless script_url is provided."),
+		),
+		mcp.WithString(
+			"encoding",
+			mcp.Description(encodingDescription),
+			mcp.Enum("none", "base64", "gzip+base64"),
+		),
+		mcp.WithString(
+			"script_url",
+			mcp.Description(scriptURLDescription),
+		),
+		mcp.WithNumber(
+			"vus",
+			mcp.Description("Number of virtual users of the full run."),
+		),
+		mcp.WithString(
+			"duration",
+			mcp.Description("Duration of the full run, e.g. '5m'."),
+		),
+		mcp.WithNumber(
+			"iterations",
+			mcp.Description("Total iterations of the full run."),
+		),
+		mcp.WithArray(
+			"stages",
+			mcp.Description("Stages of the full run. Example: [{\"duration\": \"1m\", \"target\": 20}, {\"duration\": \"2m\", \"target\": 20}]"),
+		),
+		mcp.WithNumber(
+			"pacing",
+			mcp.Description("Pacing of the full run, in iterations per VU per minute."),
+		),
+		mcp.WithNumber(
+			"cost_per_gb",
+			mcp.Description(fmt.Sprintf("Data transfer price per GB used for the cost estimate (default: %v, a typical cloud egress price in USD).", handlers.DefaultCostPerGB)),
+		),
+		mcp.WithNumber(
+			"rate_limit",
+			mcp.Description("Request rate, per second, the target tolerates before rate limiting, to check the projected peak request rate against (default: the rate_limit of the defaults)."),
+		),
+		mcp.WithString(
+			"project",
+			mcp.Description("Optional project whose defaults, set with set_defaults, complete the parameters not given explicitly, along with the session defaults, which take precedence, as for the run tool."),
+		),
+		mcp.WithObject(
+			"env",
+			mcp.Description(envDescription),
+		),
+		mcp.WithObject(
+			"files",
+			mcp.Description("Optional companion files keyed by their path relative to the script, as for the run tool."),
+		),
+	)
+
+	s.AddTool(estimateTool, h.Handle)
+}
+
+func registerRunMatrixTool(s *server.MCPServer, h handlers.ToolHandler) {
+	matrixTool := mcp.NewTool(
+		"run_matrix",
+		mcp.WithDescription(fmt.Sprintf("Run the same k6 script across a matrix of parameter sets (VU counts x target environments x payload sizes), one after the other, and return a comparison table of their results. Use it for capacity-planning experiments. At most %d parameter sets; each runs for 'duration' (default: 30s) or 'iterations'.", handlers.MaxMatrixRuns)),
+		mcp.WithString(
+			"script",
+			mcp.Description("The k6 script content to run. Required unless script_url is provided."),
+		),
+		mcp.WithString(
+			"encoding",
+			mcp.Description(encodingDescription),
+			mcp.Enum("none", "base64", "gzip+base64"),
+		),
+		mcp.WithString(
+			"script_url",
+			mcp.Description(scriptURLDescription),
+		),
+		mcp.WithString(
+			"script_name",
+			mcp.Description(scriptNameDescription),
+		),
+		mcp.WithArray(
+			"vus",
+			mcp.Description(fmt.Sprintf("VU counts to run, e.g. [5, 10, 20] (default: [1], max %d).", runner.MaxVUs)),
+		),
+		mcp.WithArray(
+			"environments",
+			mcp.Description("Target environments to run against, each an object with a 'name' and the 'env' variables exposed to the script through __ENV. Example: [{\"name\": \"staging\", \"env\": {\"BASE_URL\": \"https://staging.example.com\"}}]"),
+		),
+		mcp.WithArray(
+			"payload_sizes",
+			mcp.Description(fmt.Sprintf("Payload sizes in bytes, exposed to the script as __ENV.%s, e.g. [1024, 102400]. The script is responsible for building payloads of this size.", handlers.PayloadSizeEnvVar)),
+		),
+		mcp.WithString(
+			"duration",
+			mcp.Description("Duration of each run, e.g. '30s', '1m'."),
+		),
+		mcp.WithNumber(
+			"iterations",
+			mcp.Description("Total iterations of each run, instead of a duration."),
+		),
+		mcp.WithObject(
+			"env",
+			mcp.Description("Environment variables exposed to every run, overridden by those of the environments."),
+		),
+		mcp.WithObject(
+			"files",
+			mcp.Description("Optional companion files keyed by their path relative to the script, as for the run tool."),
+		),
+	)
+
+	s.AddTool(matrixTool, h.Handle)
+}
+
+func registerRunDistributedTool(s *server.MCPServer, h handlers.ToolHandler) {
+	distributedTool := mcp.NewTool(
+		"run_distributed",
+		mcp.WithDescription("Run a k6 script on several remote k6-mcp agents at once, for example one per region: the script is packaged once into a k6 archive, dispatched to every agent, and the summaries of their results are merged. Each agent generates the full load it is given, so the total load is the load of one run times the number of agents. The metrics of each agent are tagged with its name as 'agent'. Returns the merged summary and the result of each agent."),
+		mcp.WithString(
+			"script",
+			mcp.Description("The k6 script content to run. Required unless script_url is provided."),
+		),
+		mcp.WithString(
+			"encoding",
+			mcp.Description(encodingDescription),
+			mcp.Enum("none", "base64", "gzip+base64"),
+		),
+		mcp.WithString(
+			"script_url",
+			mcp.Description(scriptURLDescription),
+		),
+		mcp.WithString(
+			"script_name",
+			mcp.Description(scriptNameDescription),
+		),
+		mcp.WithArray(
+			"agents",
+			mcp.Description("Names of the agents to run on, among those configured with K6_MCP_AGENTS (default: all of them). Example: [\"eu-west\", \"us-east\"]"),
+		),
+		mcp.WithNumber(
+			"vus",
+			mcp.Description(fmt.Sprintf("Number of virtual users of each agent (default: 1, max %d).", runner.MaxVUs)),
+		),
+		mcp.WithString(
+			"duration",
+			mcp.Description("Duration of the run, e.g. '30s', '5m' (default: 30s)."),
+		),
+		mcp.WithNumber(
+			"iterations",
+			mcp.Description("Total iterations of each agent, instead of a duration."),
+		),
+		mcp.WithArray(
+			"stages",
+			mcp.Description("Load profile stages of each agent (array of {duration, target}). Example: [{\"duration\": \"30s\", \"target\": 10}, {\"duration\": \"1m\", \"target\": 20}]"),
+		),
+		mcp.WithObject(
+			"env",
+			mcp.Description("Environment variables exposed to the script on every agent."),
+		),
+		mcp.WithObject(
+			"thresholds",
+			mcp.Description("Optional thresholds, keyed by metric, evaluated by each agent on its own metrics. Example: {\"http_req_duration\": [\"p(95)<500\"]}"),
+		),
+		mcp.WithObject(
+			"tags",
+			mcp.Description("Optional tags of all the metrics of the run, as for run_test."),
+		),
+		mcp.WithString(
+			"environment",
+			mcp.Description("Optional label of the environment the run targets, such as staging or production."),
+		),
+		mcp.WithObject(
+			"files",
+			mcp.Description("Optional companion files keyed by their path relative to the script, packaged into the archive with it."),
+		),
+	)
+
+	s.AddTool(distributedTool, h.Handle)
+}
+
+func registerGetRunProgressTool(s *server.MCPServer, h handlers.ToolHandler) {
+	progressTool := mcp.NewTool(
+		"get_run_progress",
+		mcp.WithDescription("Report the cumulative metrics of the k6 runs in progress, parsed from their output as it is produced: requests, failed requests and error rate, request rate, average response time, iterations, checks, and active VUs, sampled every second. Call it while a run_k6_script, run_matrix, run_suite or find_breaking_point call executes to decide early whether to cancel that call, which stops k6, or to shape its load with pause_test, resume_test and scale_test. Runs are only observable while they execute over a transport handling requests concurrently, such as streamable HTTP (k6-mcp serve -http): stdio handles one request at a time."),
+		mcp.WithString(
+			"run_id",
+			mcp.Description("ID of the run in progress to report, as listed by a call without it (default: all the runs in progress)."),
+		),
+		mcp.WithString(
+			"format",
+			mcp.Description("Format of the snapshot: json, or openmetrics for the OpenMetrics text format read by Prometheus tooling (default: json)."),
+			mcp.Enum("json", "openmetrics"),
+		),
+	)
+
+	s.AddTool(progressTool, h.Handle)
+}
+
+// runIDDescription describes the run_id parameter of the tools controlling runs in progress.
+const runIDDescription = "ID of the run in progress to control, as listed by get_run_progress. Optional when a single run is in progress."
+
+func registerPauseTestTool(s *server.MCPServer, h handlers.ToolHandler) {
+	pauseTool := mcp.NewTool(
+		"pause_test",
+		mcp.WithDescription("Pause a k6 run in progress through the REST API of k6: VUs finish their iteration in progress, then start no other until resume_test. The paused time counts towards the duration of the run. Returns the status of the run: paused, running, stopped, tainted (a threshold already failed), vus and vus-max."),
+		mcp.WithString(
+			"run_id",
+			mcp.Description(runIDDescription),
+		),
+	)
+
+	s.AddTool(pauseTool, h.Handle)
+}
+
+func registerResumeTestTool(s *server.MCPServer, h handlers.ToolHandler) {
+	resumeTool := mcp.NewTool(
+		"resume_test",
+		mcp.WithDescription("Resume a k6 run paused with pause_test. Returns the status of the run."),
+		mcp.WithString(
+			"run_id",
+			mcp.Description(runIDDescription),
+		),
+	)
+
+	s.AddTool(resumeTool, h.Handle)
+}
+
+func registerScaleTestTool(s *server.MCPServer, h handlers.ToolHandler) {
+	scaleTool := mcp.NewTool(
+		"scale_test",
+		mcp.WithDescription("Change the number of active VUs of a k6 run in progress through the REST API of k6, to shape the load interactively, e.g. step it up while watching get_run_progress. k6 only scales runs whose script has a scenario of the externally-controlled executor, such as { executor: 'externally-controlled', vus: 1, maxVUs: 10, duration: '10m' }. Returns the status of the run."),
+		mcp.WithString(
+			"run_id",
+			mcp.Description(runIDDescription),
+		),
+		mcp.WithNumber(
+			"vus",
+			mcp.Required(),
+			mcp.Description(fmt.Sprintf("Number of active VUs (max %d). It cannot exceed the VUs allocated, vus-max.", runner.MaxVUs)),
+		),
+		mcp.WithNumber(
+			"max_vus",
+			mcp.Description(fmt.Sprintf("Number of VUs allocated, to raise it above the maxVUs of the scenario (max %d; default: unchanged).", runner.MaxVUs)),
+		),
+	)
+
+	s.AddTool(scaleTool, h.Handle)
+}
+
+func registerRunWorkflowTool(s *server.MCPServer, h handlers.ToolHandler) {
+	workflowTool := mcp.NewTool(
+		"run_workflow",
+		mcp.WithDescription("Run the recommended testing workflow of a script in one call: lint (security checks), validate (a single iteration), smoke (a 1 VU run), load (a small load run) and report, stopping at the first stage failing its gate. Returns a single consolidated result with the outcome of every stage, the digest of the runs, a Markdown or HTML report of them and next steps. Use it once a script is written, before scaling up the load."),
+		mcp.WithString(
+			"script",
+			mcp.Description("The k6 script content to run. It should not set its own VUs, duration or scenarios. Required unless script_url is provided."),
+		),
+		mcp.WithString(
+			"encoding",
+			mcp.Description(encodingDescription),
+			mcp.Enum("none", "base64", "gzip+base64"),
+		),
+		mcp.WithString(
+			"script_url",
+			mcp.Description(scriptURLDescription),
+		),
+		mcp.WithString(
+			"script_name",
+			mcp.Description(scriptNameDescription),
+		),
+		mcp.WithArray(
+			"stages",
+			mcp.Description("The stages to run, among lint, validate, smoke, load and report (default: all of them). Stages always run in that order. Example: [\"lint\", \"validate\", \"smoke\"]"),
+		),
+		mcp.WithString(
+			"lint_gate",
+			mcp.Description("The security findings failing the lint stage: 'blocking' (default) for those that would make runs reject the script, 'any' for every finding, or 'off' to only report them."),
+			mcp.Enum("blocking", "any", "off"),
+		),
+		mcp.WithNumber(
+			"max_error_rate_percent",
+			mcp.Description(fmt.Sprintf("Error rate above which the smoke and load runs fail their gate (default: %v).", handlers.DefaultMaxErrorRatePercent)),
+		),
+		mcp.WithNumber(
+			"max_p95_ms",
+			mcp.Description("p95 response time, in milliseconds, above which the load run fails its gate (default: no latency limit)."),
+		),
+		mcp.WithString(
+			"smoke_duration",
+			mcp.Description(fmt.Sprintf("Duration of the 1 VU smoke run (default: %s, max %v).", handlers.DefaultSmokeDuration, handlers.MaxWorkflowRunDuration)),
+		),
+		mcp.WithNumber(
+			"load_vus",
+			mcp.Description(fmt.Sprintf("VUs of the load run (default: %d, max %d).", handlers.DefaultWorkflowLoadVUs, handlers.MaxWorkflowLoadVUs)),
+		),
+		mcp.WithString(
+			"load_duration",
+			mcp.Description(fmt.Sprintf("Duration of the load run (default: %s, max %v).", handlers.DefaultWorkflowLoadDuration, handlers.MaxWorkflowRunDuration)),
+		),
+		mcp.WithString(
+			"report_format",
+			mcp.Description("The format of the report: 'markdown' (default) or 'html'."),
+			mcp.Enum("markdown", "html"),
+		),
+		mcp.WithString(
+			"title",
+			mcp.Description("The title of the report (default: 'k6 workflow report')."),
+		),
+		mcp.WithObject(
+			"env",
+			mcp.Description(envDescription),
+		),
+		mcp.WithObject(
+			"files",
+			mcp.Description("Optional companion files keyed by their path relative to the script, as for the run tool."),
+		),
+	)
+
+	s.AddTool(workflowTool, h.Handle)
+}
+
+func registerFindBreakingPointTool(s *server.MCPServer, h handlers.ToolHandler) {
+	breakingPointTool := mcp.NewTool(
+		"find_breaking_point",
+		mcp.WithDescription("Find the load at which a system starts failing: runs the script in short runs with increasing VUs until the error rate or p95 latency exceeds its limit, or the script's thresholds are crossed, then bisects between the last passing and the first failing load. Returns the estimated capacity with the data of every run. Validate the script first: runs failing for other reasons stop the search."),
+		mcp.WithString(
+			"script",
+			mcp.Description("The k6 script content to run. It should not set its own VUs, duration or scenarios. Required unless script_url is provided."),
+		),
+		mcp.WithString(
+			"encoding",
+			mcp.Description(encodingDescription),
+			mcp.Enum("none", "base64", "gzip+base64"),
+		),
+		mcp.WithString(
+			"script_url",
+			mcp.Description(scriptURLDescription),
+		),
+		mcp.WithString(
+			"script_name",
+			mcp.Description(scriptNameDescription),
+		),
+		mcp.WithNumber(
+			"start_vus",
+			mcp.Description("VUs of the first run (default: 1)."),
+		),
+		mcp.WithNumber(
+			"max_vus",
+			mcp.Description(fmt.Sprintf("Highest load to try (default and maximum: %d).", runner.MaxVUs)),
+		),
+		mcp.WithNumber(
+			"step_vus",
+			mcp.Description("VUs added between ramp runs. Omit it to double the VUs between runs instead."),
+		),
+		mcp.WithNumber(
+			"max_error_rate_percent",
+			mcp.Description(fmt.Sprintf("Error rate above which a load is past the breaking point (default: %v).", handlers.DefaultMaxErrorRatePercent)),
+		),
+		mcp.WithNumber(
+			"max_p95_ms",
+			mcp.Description("p95 response time, in milliseconds, above which a load is past the breaking point (default: no latency limit)."),
+		),
+		mcp.WithNumber(
+			"max_runs",
+			mcp.Description(fmt.Sprintf("Maximum number of runs of the search (default: %d, max %d).", handlers.DefaultBreakingPointRuns, handlers.MaxBreakingPointRuns)),
+		),
+		mcp.WithString(
+			"duration",
+			mcp.Description(fmt.Sprintf("Duration of each run (default: %s, max %v).", handlers.DefaultProbeDuration, handlers.MaxProbeDuration)),
+		),
+		mcp.WithObject(
+			"env",
+			mcp.Description(envDescription),
+		),
+		mcp.WithObject(
+			"files",
+			mcp.Description("Optional companion files keyed by their path relative to the script, as for the run tool."),
+		),
+	)
+
+	s.AddTool(breakingPointTool, h.Handle)
+}
+
+func registerRunSuiteTool(s *server.MCPServer, h handlers.ToolHandler) {
+	suiteTool := mcp.NewTool(
+		"run_suite",
+		mcp.WithDescription(fmt.Sprintf("Run an ordered list of named scripts as one suite (e.g. a smoke test, then a load test), and return the outcome of each. Scripts are the revisions recorded by passing 'script_name' to the validation and run tools. By default, the suite stops at the first failing script. The suite run is recorded, see get_script_history. At most %d scripts per suite.", handlers.MaxSuiteScripts)),
+		mcp.WithArray(
+			"scripts",
+			mcp.Required(),
+			mcp.Description("The scripts to run, in order. Each is an object with 'script_name' (required), an optional 'revision' (default: the latest; negative numbers count back from it), and the optional run parameters 'vus', 'duration', 'iterations', 'stages', 'options', 'env' and 'files'. Example: [{\"script_name\": \"smoke\", \"iterations\": 1}, {\"script_name\": \"checkout-flow\", \"vus\": 10, \"duration\": \"1m\"}]"),
+		),
+		mcp.WithString(
+			"suite_name",
+			mcp.Description("Optional name the suite run is recorded under, e.g. 'release-journeys'."),
+		),
+		mcp.WithBoolean(
+			"stop_on_failure",
+			mcp.Description("Skip the remaining scripts once one fails (default: true)."),
+		),
+	)
+
+	s.AddTool(suiteTool, h.Handle)
+}
+
+func registerGenerateReportTool(s *server.MCPServer, h handlers.ToolHandler) {
+	reportTool := mcp.NewTool(
+		"generate_report",
+		mcp.WithDescription("Render the result of a run, or a comparison of several runs, into a polished Markdown or HTML load test report, suitable for pasting into tickets or wikis. Reports summarize status, grade, thresholds and response times, chart response times (ASCII in Markdown, inline SVG in HTML), compare runs to the first one, and list per-scenario metrics, issues and recommendations. Pass run tool results as-is."),
+		mcp.WithObject(
+			"run",
+			mcp.Description("The JSON result of a run tool call to report on."),
+		),
+		mcp.WithString(
+			"label",
+			mcp.Description("The label of 'run' in the report (default: 'Run 1')."),
+		),
+		mcp.WithArray(
+			"runs",
+			mcp.Description(fmt.Sprintf("Runs to compare, at most %d, each with a label and the JSON result of a run tool call. The first run is the baseline of the comparison. Example: [{\"label\": \"before\", \"result\": {...}}, {\"label\": \"after\", \"result\": {...}}]", report.MaxRuns)),
+		),
+		mcp.WithString(
+			"format",
+			mcp.Description("The report format: 'markdown' (default) or 'html'."),
+			mcp.Enum(string(report.FormatMarkdown), string(report.FormatHTML)),
+		),
+		mcp.WithString(
+			"title",
+			mcp.Description("The report title (default: 'k6 load test report')."),
+		),
+		mcp.WithString(
+			"notes",
+			mcp.Description("Optional notes introducing the report, e.g. the purpose of the test or the system under test."),
+		),
+		mcp.WithString(
+			"path",
+			mcp.Description("Optional workspace-relative path to write the report to, e.g. 'reports/checkout.md', instead of returning it."),
+		),
+		mcp.WithBoolean(
+			"save_artifact",
+			mcp.Description("When true and no 'path' is given, the report is stored as an artifact, returned as 'artifact' and read with get_artifact, instead of being returned inline (default: false)."),
+		),
+	)
+
+	s.AddTool(reportTool, h.Handle)
+}
+
+func registerFormatSummaryTool(s *server.MCPServer, h handlers.ToolHandler) {
+	formatSummaryTool := mcp.NewTool(
+		"format_summary",
+		mcp.WithDescription("Render the result of a run into a chat message, ready to post to a Slack incoming webhook or API (Block Kit) or to a Microsoft Teams incoming webhook or workflow (Adaptive Card): a compact summary of the status, grade, duration, requests, error rate, p95 response time and request rate, a table of the thresholds, failed ones first, and links to the artifacts of the run. Returns the JSON payload of the message. Pass run tool results as-is."),
+		mcp.WithObject(
+			"run",
+			mcp.Required(),
+			mcp.Description("The JSON result of a run tool call to summarize."),
+		),
+		mcp.WithString(
+			"format",
+			mcp.Required(),
+			mcp.Description("The chat the message is for: 'slack' (Block Kit) or 'teams' (Adaptive Card)."),
+			mcp.Enum(string(report.ChatSlack), string(report.ChatTeams)),
+		),
+		mcp.WithString(
+			"title",
+			mcp.Description("The title of the message, e.g. the name of the test (default: 'k6 load test')."),
+		),
+		mcp.WithString(
+			"link",
+			mcp.Description("Optional URL the message opens, e.g. a Grafana dashboard or a report."),
+		),
+		mcp.WithString(
+			"artifacts_url",
+			mcp.Description("Optional base URL the artifacts of the server are served under, by ID, to link them, e.g. 'https://files.example.com/k6-artifacts'. Without it, artifacts are listed by name."),
+		),
+	)
+
+	s.AddTool(formatSummaryTool, h.Handle)
+}
+
+func registerListArtifactsTool(s *server.MCPServer, h handlers.ToolHandler) {
+	listArtifactsTool := mcp.NewTool(
+		"list_artifacts",
+		mcp.WithDescription("List the stored artifacts, most recent first: the summary exports and saved k6 JSON outputs of runs, the reports stored by generate_report, and the complete responses of tools truncated to fit the response budget. Each artifact has an ID to read it with get_artifact, a name, kind, content type, size, SHA-256 and creation time, and the ID of its run in the run history. Also returns the used size and quota of the artifacts, beyond which the oldest ones are evicted."),
+		mcp.WithNumber(
+			"run_id",
+			mcp.Description("Optional ID of the run in the run history to list the artifacts of."),
+		),
+		mcp.WithString(
+			"kind",
+			mcp.Description("Optional kind of the artifacts to list."),
+			mcp.Enum(artifacts.KindOutput, artifacts.KindSummaryExport, artifacts.KindReport, artifacts.KindToolResponse),
+		),
+		mcp.WithNumber(
+			"limit",
+			mcp.Description(fmt.Sprintf("Maximum number of artifacts to return (default: %d, max %d).", artifacts.DefaultListLimit, artifacts.MaxListLimit)),
+		),
+	)
+
+	s.AddTool(listArtifactsTool, h.Handle)
+}
+
+func registerPurgeDataTool(s *server.MCPServer, h handlers.ToolHandler) {
+	purgeDataTool := mcp.NewTool(
+		"purge_data",
+		mcp.WithDescription("Remove the stored data older than an age: run records, suite runs, script revisions but the latest of each script, artifacts, and the workspaces runs killed midway left in the temporary directory. Returns the number and size of the removed items of each target, and the retention policy the server applies in the background. Use dry_run to preview what would be removed."),
+		mcp.WithString(
+			"older_than",
+			mcp.Required(),
+			mcp.Description("The age of the data to remove: a number of days such as '30d', or a duration such as '36h'. '0' removes all of it. Workspaces younger than 1h are always kept, as their runs may be in progress."),
+		),
+		mcp.WithArray(
+			"targets",
+			mcp.Description("Optional data to purge, all of it by default: "+strings.Join(retention.Targets, ", ")+"."),
+		),
+		mcp.WithBoolean(
+			"dry_run",
+			mcp.Description("When true, nothing is removed: returns what would be (default: false)."),
+		),
+	)
+
+	s.AddTool(purgeDataTool, h.Handle)
+}
+
+func registerGetArtifactTool(s *server.MCPServer, h handlers.ToolHandler) {
+	getArtifactTool := mcp.NewTool(
+		"get_artifact",
+		mcp.WithDescription("Read the content of a stored artifact, in chunks: returns up to 'max_bytes' bytes from 'offset', as text, or base64 for binary content, and the 'next_offset' to read the next chunk from, until the end of the artifact."),
+		mcp.WithString(
+			"id",
+			mcp.Required(),
+			mcp.Description("The ID of the artifact, as returned by the run tool or list_artifacts."),
+		),
+		mcp.WithNumber(
+			"offset",
+			mcp.Description("The offset to read from, in bytes (default: 0)."),
+		),
+		mcp.WithNumber(
+			"max_bytes",
+			mcp.Description("Maximum number of bytes to read (default: 65536, max 1048576)."),
+		),
+	)
+
+	s.AddTool(getArtifactTool, h.Handle)
+}
+
+func registerGetCloudTestTool(s *server.MCPServer, h handlers.ToolHandler) {
+	getCloudTestTool := mcp.NewTool(
+		"get_cloud_test",
+		mcp.WithDescription("Get a Grafana Cloud k6 load test: its name, project, dates and script. To validate or run the test locally, pass its 'script_url' (k6cloud://<test id>) to the validation and run tools, and push modifications back with update_cloud_test_script."),
+		mcp.WithNumber(
+			"test_id",
+			mcp.Required(),
+			mcp.Description("The ID of the load test, as shown in the URL of the test in Grafana Cloud k6. Example: 1234"),
+		),
+		mcp.WithBoolean(
+			"include_script",
+			mcp.Description("Whether to include the script of the test (default: true)."),
+		),
+	)
+
+	s.AddTool(getCloudTestTool, h.Handle)
+}
+
+func registerUpdateCloudTestScriptTool(s *server.MCPServer, h handlers.ToolHandler) {
+	updateCloudTestScriptTool := mcp.NewTool(
+		"update_cloud_test_script",
+		mcp.WithDescription("Replace the script of a Grafana Cloud k6 load test with a modified script, after validating it locally: scripts failing validation are not pushed. The next cloud runs of the test use the new script."),
+		mcp.WithNumber(
+			"test_id",
+			mcp.Required(),
+			mcp.Description("The ID of the load test to update. Example: 1234"),
+		),
+		mcp.WithString(
+			"script",
+			mcp.Description("The new script of the test. Required unless 'script_url' is set."),
+		),
+		mcp.WithString(
+			"encoding",
+			mcp.Description(encodingDescription),
+			mcp.Enum("none", "base64", "gzip+base64"),
+		),
+		mcp.WithString(
+			"script_url",
+			mcp.Description(scriptURLDescription),
+		),
+	)
+
+	s.AddTool(updateCloudTestScriptTool, h.Handle)
+}
+
+func registerGetMoreOutputTool(s *server.MCPServer, h handlers.ToolHandler) {
+	getMoreOutputTool := mcp.NewTool(
+		"get_more_output",
+		mcp.WithDescription("Retrieve the remainder of a truncated tool response field, in chunks. Large run outputs (stdout, stderr) and documentation search results are truncated to fit in the context, with a '*_continuation' field holding a token, the total and the remaining bytes. Returns the next chunk of the content and, until the end of the content, the token of the following chunk. Tokens expire 30 minutes after their content was last read."),
+		mcp.WithString(
+			"token",
+			mcp.Required(),
+			mcp.Description("The continuation token, from a '*_continuation' field or the 'next' field of a previous get_more_output result."),
+		),
+		mcp.WithNumber(
+			"max_bytes",
+			mcp.Description("Maximum number of bytes to return (default: the inline output size, 16384 unless configured; max 262144)."),
+		),
+	)
+
+	s.AddTool(getMoreOutputTool, h.Handle)
+}
+
+func registerExportArchiveTool(s *server.MCPServer, h handlers.ToolHandler) {
+	exportArchiveTool := mcp.NewTool(
+		"export_archive",
+		mcp.WithDescription("Package a k6 script, its local modules and data files into a k6 archive (the tarball produced by `k6 archive`), to hand off the exact runnable test to CI or Grafana Cloud. The archive is returned base64 encoded, or written to the workspace. Returns the archive's size, SHA-256 checksum and entries."),
+		mcp.WithString(
+			"script",
+			mcp.Required(),
+			mcp.Description("The main k6 script content (JavaScript/TypeScript). It is archived as script.js."),
+		),
+		mcp.WithString(
+			"encoding",
+			mcp.Description(encodingDescription),
+			mcp.Enum("none", "base64", "gzip+base64"),
+		),
+		mcp.WithObject(
+			"files",
+			mcp.Description("Optional local modules and data files the script depends on, keyed by their path relative to the script. Example: {\"lib/auth.js\": \"export function login() {}\", \"data/users.csv\": \"username,password\\nalice,secret\"}"),
+		),
+		mcp.WithString(
+			"output",
+			mcp.Description("How to return the archive: 'base64' (default) to return it inline, or 'file' to write it to the workspace."),
+			mcp.Enum("base64", "file"),
+		),
+		mcp.WithString(
+			"path",
+			mcp.Description("The workspace-relative path to write the archive to when output is 'file' (default: archive.tar)."),
+		),
+	)
+
+	s.AddTool(exportArchiveTool, h.Handle)
+}
+
+func registerExportWorkspaceTool(s *server.MCPServer, h handlers.ToolHandler) {
+	exportWorkspaceTool := mcp.NewTool(
+		"export_workspace",
+		mcp.WithDescription("Export the assets of a project into a portable bundle (a gzipped tarball), to move a load testing setup between machines or share it with a teammate: the scripts and their revisions, the project defaults, the baselines, the run history and the given fixture files. Import it elsewhere with import_workspace. The bundle is returned base64 encoded, or written to the workspace. Returns the manifest of the bundle."),
+		mcp.WithString(
+			"project",
+			mcp.Description("The project to export: its defaults, and the scripts, baselines and runs named after it (the project name itself, or names starting with '<project>/'). Without a project and scripts, every asset is exported."),
+		),
+		mcp.WithArray(
+			"scripts",
+			mcp.Description("Optional names of the scripts to export, instead of the ones named after the project. Example: [\"checkout/smoke\", \"checkout/load\"]"),
+		),
+		mcp.WithObject(
+			"files",
+			mcp.Description("Optional fixtures to bundle, such as data files and local modules, keyed by their path relative to the scripts. Example: {\"data/users.csv\": \"username,password\\nalice,secret\"}"),
+		),
+		mcp.WithString(
+			"encoding",
+			mcp.Description(encodingDescription),
+			mcp.Enum("none", "base64", "gzip+base64"),
+		),
+		mcp.WithString(
+			"output",
+			mcp.Description("How to return the bundle: 'base64' (default) to return it inline, or 'file' to write it to the workspace."),
+			mcp.Enum("base64", "file"),
+		),
+		mcp.WithString(
+			"path",
+			mcp.Description("The workspace-relative path to write the bundle to when output is 'file' (default: k6-workspace.tar.gz)."),
+		),
+	)
+
+	s.AddTool(exportWorkspaceTool, h.Handle)
+}
+
+func registerImportWorkspaceTool(s *server.MCPServer, h handlers.ToolHandler) {
+	importWorkspaceTool := mcp.NewTool(
+		"import_workspace",
+		mcp.WithDescription("Import a bundle made by export_workspace: its scripts, project defaults, baselines and run history are added to the stores of this server. Existing scripts, projects and baselines are kept unless replace is set, and runs already recorded are skipped. Fixtures are written to the workspace when fixtures_dir is set, and returned otherwise. Returns what was imported and skipped."),
+		mcp.WithString(
+			"bundle_base64",
+			mcp.Description("The bundle, base64 encoded, as returned by export_workspace. Required unless 'path' is set."),
+		),
+		mcp.WithString(
+			"path",
+			mcp.Description("The workspace-relative path of a bundle file. Required unless 'bundle_base64' is set."),
+		),
+		mcp.WithBoolean(
+			"replace",
+			mcp.Description("Replace the scripts, project defaults, baselines and fixture files that already exist (default: false, they are kept, and fixtures are not written over existing files)."),
+		),
+		mcp.WithString(
+			"fixtures_dir",
+			mcp.Description("Optional workspace-relative directory to write the fixtures of the bundle to. Without it, the fixtures are returned in the result."),
+		),
+	)
+
+	s.AddTool(importWorkspaceTool, h.Handle)
+}
+
+func registerSetupK6Tool(s *server.MCPServer, h handlers.ToolHandler) {
+	setupK6Tool := mcp.NewTool(
+		"setup_k6",
+		mcp.WithDescription("Download an official k6 release from GitHub for this platform, verify it against the release checksums, and install it into a directory managed by the server. Only available when k6 is not installed; use it before validating or running scripts. Requires the server to allow downloads (K6_MCP_K6_DOWNLOAD=true). Subsequent validations and runs use the installed k6."),
+		mcp.WithString(
+			"version",
+			mcp.Description("The k6 version to install, e.g. '1.2.0' (default: latest)."),
+		),
+	)
+
+	s.AddTool(setupK6Tool, h.Handle)
+}
+
+func registerInfrastructureTool(s *server.MCPServer, h handlers.ToolHandler) {
+	infrastructureTool := mcp.NewTool(
+		"generate_k6_cloud_terraform_load_test_resource",
+		mcp.WithDescription("Generate infrastructure-as-code for a k6 load test in Grafana Cloud: Terraform (default), Pulumi (TypeScript or Python), or an AWS CDK stack scheduling runs of existing cloud tests, selected via the format parameter. Optionally renders schedules, notes, and one load test per environment (with its own project and environment variables). Terraform output is validated before being returned as a string."),
+		mcp.WithString(
+			"format",
+			mcp.Description("The output format (default: terraform). The cdk-typescript format only schedules runs of load tests that already exist in Grafana Cloud k6, and requires a schedule."),
+			mcp.Enum("terraform", "pulumi-typescript", "pulumi-python", "cdk-typescript"),
+		),
+		mcp.WithString(
+			"load_test_name",
+			mcp.Required(),
+			mcp.Description("The human-readable name of the load test. Example: 'My Load Test'"),
+		),
+		mcp.WithString(
+			"load_test_resource_name",
+			mcp.Required(),
+			mcp.Description("The name of the resource to generate, also used to derive variable names. Letters, digits, '_' and '-'. Example: 'my_load_test'"),
+		),
+		mcp.WithString(
+			"script",
+			mcp.Required(),
+			mcp.Description("The k6 script content to run (JavaScript/TypeScript). Should be a valid k6 script with proper imports and default function."),
+		),
+		mcp.WithString(
+			"encoding",
+			mcp.Description(encodingDescription),
+			mcp.Enum("none", "base64", "gzip+base64"),
+		),
+		mcp.WithString(
+			"project_id",
+			mcp.Description("The Grafana Cloud k6 project ID to create the load test in. Required unless every environment defines its own project. Example: '3688954'"),
+		),
+		mcp.WithString(
+			"notes",
+			mcp.Description("Optional notes rendered as a comment block at the top of the generated configuration. Example: 'Owned by the checkout team. Runbook: https://wiki.example.com/checkout-load'"),
+		),
+		mcp.WithObject(
+			"schedule",
+			mcp.Description("Optional schedule for each load test. Fields: starts (RFC3339, required), frequency (HOURLY, DAILY, WEEKLY, MONTHLY, YEARLY), interval (1 for YEARLY), count, until (RFC3339), or cron and time_zone instead of frequency. Example: {\"starts\": \"2025-01-01T08:00:00Z\", \"frequency\": \"DAILY\"}"),
+		),
+		mcp.WithArray(
+			"environments",
+			mcp.Description("Optional environments to provision the load test for, one load test per environment. Fields: name (required), project_id or project_name (creates a new project), env (environment variables exposed through __ENV), notes. Example: [{\"name\": \"staging\", \"project_id\": \"123\", \"env\": {\"BASE_URL\": \"https://staging.example.com\"}}]"),
+		),
+		mcp.WithBoolean(
+			"per_workspace",
+			mcp.Description("When true, each environment's resources are only created in the Terraform workspace (or Pulumi stack) of the same name (default: false)."),
+		),
+	)
+
+	s.AddTool(infrastructureTool, h.Handle)
+}
+
+func registerComposeTool(s *server.MCPServer, h handlers.ToolHandler) {
+	composeTool := mcp.NewTool(
+		"generate_k6_docker_compose",
+		mcp.WithDescription("Generate a self-contained docker-compose.yaml running a k6 script locally with full observability: k6 sends its metrics to Prometheus through remote write, and Grafana is provisioned with a k6 dashboard. Use it to reproduce a test run by the MCP server on your machine with `docker compose up`."),
+		mcp.WithString(
+			"script",
+			mcp.Required(),
+			mcp.Description("The k6 script content to run (JavaScript/TypeScript). It is inlined in the compose file and mounted in the k6 container."),
+		),
+		mcp.WithString(
+			"encoding",
+			mcp.Description(encodingDescription),
+			mcp.Enum("none", "base64", "gzip+base64"),
+		),
+		mcp.WithObject(
+			"env",
+			mcp.Description("Optional environment variables exposed to the script through __ENV. Example: {\"BASE_URL\": \"https://test.k6.io\"}"),
+		),
+		mcp.WithString(
+			"k6_image",
+			mcp.Description("The k6 image to run the script with (default: grafana/k6:latest). Example: 'grafana/k6:1.0.0'"),
+		),
+		mcp.WithNumber(
+			"grafana_port",
+			mcp.Description("The host port Grafana is exposed on (default: 3000)."),
+		),
+		mcp.WithNumber(
+			"prometheus_port",
+			mcp.Description("The host port Prometheus is exposed on (default: 9090)."),
+		),
+	)
+
+	s.AddTool(composeTool, h.Handle)
+}
+
+func registerWeightScenariosTool(s *server.MCPServer, h handlers.ToolHandler) {
+	weightTool := mcp.NewTool(
+		"generate_weighted_scenarios",
+		mcp.WithDescription("Generate the options of a load matching a production traffic mix, such as one exported from analytics: one scenario per endpoint or user journey, each running its own exported function with an arrival-rate executor at its share of the total rate. Returns the options statement, the rate and VUs of each scenario, and, when every entry is an endpoint and base_url is given, a whole script requesting them."),
+		mcp.WithArray(
+			"traffic",
+			mcp.Required(),
+			mcp.Description("The traffic distribution: entries of endpoints, such as 'GET /products', or journeys, such as 'checkout', with their share of the traffic. Shares are normalized, so percentages, fractions and hit counts all work. An object of shares by name is also accepted. Example: [{\"name\": \"GET /products\", \"share\": 70}, {\"name\": \"POST /cart\", \"share\": 25}, {\"name\": \"POST /checkout\", \"share\": 5}]"),
+		),
+		mcp.WithNumber(
+			"total_rate",
+			mcp.Required(),
+			mcp.Description("The total iterations per time unit of all scenarios, such as the production request rate. Example: 120"),
+		),
+		mcp.WithString(
+			"time_unit",
+			mcp.Description("The time unit of total_rate (default: 1s). Rates that would round poorly are expressed in a longer unit."),
+			mcp.Enum("1s", "1m", "1h"),
+		),
+		mcp.WithString(
+			"duration",
+			mcp.Description(fmt.Sprintf("How long the scenarios run at their rate (default: %s).", handlers.DefaultWeightedDuration)),
+		),
+		mcp.WithString(
+			"ramp_up",
+			mcp.Description("Ramp the rates up from 0 over this duration with ramping-arrival-rate executors, instead of starting at full rate. Example: '1m'"),
+		),
+		mcp.WithNumber(
+			"iteration_duration_ms",
+			mcp.Description(fmt.Sprintf("The expected duration of an iteration, in milliseconds, the VUs of the scenarios are allocated for (default: %v).", handlers.DefaultIterationDurationMs)),
+		),
+		mcp.WithString(
+			"base_url",
+			mcp.Description("The base URL of the endpoints, to get a whole script requesting them. Example: 'https://test.k6.io'"),
+		),
+	)
+
+	s.AddTool(weightTool, h.Handle)
+}
+
+func registerGenerateChecksTool(s *server.MCPServer, h handlers.ToolHandler) {
+	checksTool := mcp.NewTool(
+		"generate_checks",
+		mcp.WithDescription("Generate the k6 check() code asserting the status, content type and key JSON fields of the responses of an endpoint, from a single probe request or a sample response. The types of the fields are inferred from the response, e.g. data.id is a number. Returns the inferred fields, the check names, the import of check, and the check() call on the response res, ready to paste after the request in a script."),
+		mcp.WithString(
+			"url",
+			mcp.Description("URL of the endpoint to probe with a single request, sent from the server. Required unless response is provided. Example: 'https://quickpizza.grafana.com/api/ratings'"),
+		),
+		mcp.WithString(
+			"method",
+			mcp.Description("Method of the probe request (default: GET)."),
+			mcp.Enum(checkgen.ProbeMethods...),
+		),
+		mcp.WithObject(
+			"headers",
+			mcp.Description("Headers of the probe request, e.g. {\"Authorization\": \"Bearer <token>\"}."),
+		),
+		mcp.WithString(
+			"body",
+			mcp.Description("Body of the probe request, e.g. the JSON payload of a POST."),
+		),
+		mcp.WithObject(
+			"response",
+			mcp.Description("A sample response to infer the checks from, instead of probing: its status, headers and body. Example: {\"status\": 200, \"headers\": {\"Content-Type\": \"application/json\"}, \"body\": \"{\\\"id\\\": 1, \\\"name\\\": \\\"pizza\\\"}\"}"),
+		),
+		mcp.WithNumber(
+			"max_fields",
+			mcp.Description(fmt.Sprintf("Maximum number of JSON fields to check, the shallowest first (default: %d, max %d).", checkgen.DefaultMaxFields, checkgen.MaxFields)),
+		),
+	)
+
+	s.AddTool(checksTool, h.Handle)
+}
+
+func registerStartRecordingTool(s *server.MCPServer, h handlers.ToolHandler) {
+	startTool := mcp.NewTool(
+		"start_recording",
+		mcp.WithDescription("Start recording HTTP traffic through a local capture proxy, to turn a user journey driven in a browser or an API client into a k6 script. Returns the proxy address to configure in the client, and the certificate authority the client must trust for HTTPS traffic to be recorded. Call stop_recording with the returned session_id once the journey is done."),
+		mcp.WithString(
+			"name",
+			mcp.Description("A name for the recording, from which the script file name is derived. Example: 'checkout'"),
+		),
+		mcp.WithNumber(
+			"port",
+			mcp.Description("The local port the proxy listens on (default: a free port). The proxy only listens on 127.0.0.1."),
+		),
+		mcp.WithArray(
+			"hosts",
+			mcp.Description("The hosts whose requests are recorded (default: every host). Entries starting with '*.' also match any subdomain. Example: [\"test.k6.io\", \"*.example.com\"]"),
+		),
+		mcp.WithString(
+			"max_duration",
+			mcp.Description("How long the proxy runs before stopping by itself, keeping its requests until stop_recording (default: 30m, at most 4h). Example: '15m'"),
+		),
+	)
+
+	s.AddTool(startTool, h.Handle)
+}
+
+func registerStopRecordingTool(s *server.MCPServer, h handlers.ToolHandler) {
+	stopTool := mcp.NewTool(
+		"stop_recording",
+		mcp.WithDescription("Stop a recording started with start_recording, and convert the requests it recorded into a k6 script in the configured script style. Requests are grouped by the pauses between them, with the pauses as think times and the statuses received as checks. Dynamic values responses set, such as session IDs, CSRF tokens and bearer tokens, are extracted with res.json(), HTML selectors, headers or regular expressions and sent by the later requests, and values that look dynamic but that no response sets are reported. Static assets and failed requests are left out, and so are cookies, replayed by k6, and uncorrelated credential headers, which the result lists."),
+		mcp.WithString(
+			"session_id",
+			mcp.Required(),
+			mcp.Description("The session_id returned by start_recording."),
+		),
+		mcp.WithBoolean(
+			"include_static",
+			mcp.Description("Keep the requests of static assets, such as images, stylesheets, scripts and fonts (default: false)."),
+		),
+		mcp.WithNumber(
+			"group_gap_seconds",
+			mcp.Description("The pause between requests, in seconds, beyond which a new group starts, such as a page navigation (default: 2). 0 writes the requests outside groups."),
+		),
+		mcp.WithBoolean(
+			"think_time",
+			mcp.Description("Convert the pauses between groups into sleep() calls, of at most 10 seconds (default: true)."),
+		),
+		mcp.WithBoolean(
+			"correlate",
+			mcp.Description("Extract the dynamic values responses set for the later requests sending them, instead of replaying their recorded values (default: true)."),
+		),
+		mcp.WithObject(
+			"payload_hints",
+			mcp.Description("Fields of the JSON payloads to generate on each iteration instead of replaying their recorded values, which servers may reject as duplicates. Keys are field names or dotted paths, and values kinds: uuid, email, username, name, phone, timestamp, string[:length] or int[:min-max]. Example: {\"email\": \"email\", \"order.quantity\": \"int:1-5\"}"),
+		),
+		mcp.WithBoolean(
+			"fake_payloads",
+			mcp.Description("Also generate the string fields of JSON payloads named as emails, UUIDs, usernames, names and phone numbers on each iteration (default: false)."),
+		),
+		mcp.WithBoolean(
+			"scrub",
+			mcp.Description("Mask the personal data of the recorded requests and responses, such as emails, phone numbers and card numbers, before converting them, so that the script neither replays nor exposes it (default: true when the server scrubs recordings, false otherwise)."),
+		),
+	)
+
+	s.AddTool(stopTool, h.Handle)
+}
+
+func registerScrubDataTool(s *server.MCPServer, h handlers.ToolHandler) {
+	scrubTool := mcp.NewTool(
+		"scrub_data",
+		mcp.WithDescription("Detect and mask personal data, such as emails, phone numbers and card numbers, in a script and its fixtures before storing or sharing them. Each distinct value is masked the same way everywhere, keeping lookups between the script and its data files consistent: emails become userN@example.com, and phone and card numbers keep their format with their digits replaced. Returns the scrubbed content, and the number of matches of each rule, never the values matched. Rules are configured on the server, with custom patterns for other kinds of data."),
+		mcp.WithString(
+			"script",
+			mcp.Description("The script to scrub, e.g. with hard-coded test accounts."),
+		),
+		mcp.WithObject(
+			"files",
+			mcp.Description("The files to scrub, keyed by their path, such as data files opened with open(). Example: {\"data/users.csv\": \"email,phone\\nalice@example.org,+1 415 555 2671\"}"),
+		),
+		mcp.WithArray(
+			"rules",
+			mcp.Description("Names of the rules to apply, e.g. [\"email\", \"card\"]. Defaults to every rule of the server: email, phone and card unless disabled, and its custom rules."),
+		),
+		mcp.WithString(
+			"encoding",
+			mcp.Description(encodingDescription),
+			mcp.Enum("none", "base64", "gzip+base64"),
+		),
+	)
+
+	s.AddTool(scrubTool, h.Handle)
+}